@@ -412,6 +412,13 @@ func (m callmsg) GasPrice() *big.Int   { return m.CallMsg.GasPrice }
 func (m callmsg) Gas() uint64          { return m.CallMsg.Gas }
 func (m callmsg) Value() *big.Int      { return m.CallMsg.Value }
 func (m callmsg) Data() []byte         { return m.CallMsg.Data }
+func (m callmsg) Type() uint           { return types.TxTypeTransfer }
+
+// FeePayer implements core.Message, calls are never sponsored.
+func (m callmsg) FeePayer() *common.Address { return nil }
+
+// MultisigSigners implements core.Message, calls are never signed.
+func (m callmsg) MultisigSigners() [][]byte { return nil }
 
 // filterBackend implements filters.Backend to support filtering for logs without
 // taking bloom-bits acceleration structures into account.
@@ -111,6 +111,10 @@ type Wallet interface {
 	// about which fields or actions are needed. The user may retry by providing
 	// the needed details via SignTxWithPassphrase, or by other means (e.g. unlock
 	// the account in a keystore).
+	//
+	// If the transaction is sent from a multi-signature account, the signature of
+	// the account is added to the ones already collected instead of replacing it.
+	// Wallets unable to produce such signatures return ErrNotSupported.
 	SignTx(account Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
 
 	// SignHashWithPassphrase requests the wallet to sign the given hash with the
@@ -0,0 +1,237 @@
+// Package external implements an account backend delegating all signing to an
+// external signer daemon, such as clef.
+package external
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	yooba "github.com/yooba-team/yooba"
+	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/event"
+	"github.com/yooba-team/yooba/internal/ethapi"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/rpc"
+)
+
+// ExternalBackend is an accounts.Backend exposing the accounts of an external
+// signer as a single wallet.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend connects to the external signer at the given endpoint, an
+// IPC path or an HTTP URL.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{signers: []accounts.Wallet{signer}}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer is connected for
+// the lifetime of the backend, so no wallet events are ever sent.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is a wallet whose keys are held by an external signer, which
+// asks its user or its rules for the approval of every signing request.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+	status   string
+
+	cacheMu sync.RWMutex
+	cache   []accounts.Account
+}
+
+// NewExternalSigner connects to the external signer at the given endpoint.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	signer := &ExternalSigner{
+		client:   client,
+		endpoint: endpoint,
+		status:   "ok",
+	}
+	if _, err := signer.listAccounts(); err != nil {
+		log.Warn("Failed to list accounts of external signer", "endpoint", endpoint, "err", err)
+		signer.status = fmt.Sprintf("failed to list accounts: %v", err)
+	}
+	return signer, nil
+}
+
+// URL implements accounts.Wallet, returning the endpoint of the signer.
+func (api *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{Scheme: "extapi", Path: api.endpoint}
+}
+
+// Status implements accounts.Wallet.
+func (api *ExternalSigner) Status() (string, error) {
+	return api.status, nil
+}
+
+// Open implements accounts.Wallet, but is a noop as the connection is opened
+// when the signer is created.
+func (api *ExternalSigner) Open(passphrase string) error {
+	return nil
+}
+
+// Close implements accounts.Wallet, closing the connection to the signer.
+func (api *ExternalSigner) Close() error {
+	api.client.Close()
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the accounts the signer lets
+// this node see. The list is requested from the signer only once, as every
+// request may need to be approved by its user.
+func (api *ExternalSigner) Accounts() []accounts.Account {
+	api.cacheMu.RLock()
+	cache := api.cache
+	api.cacheMu.RUnlock()
+
+	if cache != nil {
+		return cache
+	}
+	accs, err := api.listAccounts()
+	if err != nil {
+		log.Error("Failed to list accounts of external signer", "endpoint", api.endpoint, "err", err)
+		return nil
+	}
+	return accs
+}
+
+// Contains implements accounts.Wallet.
+func (api *ExternalSigner) Contains(account accounts.Account) bool {
+	for _, acc := range api.Accounts() {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == api.URL()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, but is not supported by external signers.
+func (api *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for external signers.
+func (api *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain yooba.ChainStateReader) {
+	log.Error("Operation not supported on external signers")
+}
+
+// SignHash implements accounts.Wallet, but signing arbitrary hashes is not
+// supported by external signers, which only sign data they can show to their
+// user.
+func (api *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignHeader requests the signer to seal a block header produced by account,
+// returning the producer seal.
+func (api *ExternalSigner) SignHeader(account accounts.Account, header *types.Header) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := api.client.Call(&signature, "account_signHeader", common.NewMixedcaseAddress(account.Address), header); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// SignTx implements accounts.Wallet, requesting the signer to sign tx. Multi-
+// signature transactions get the signature of account added to the ones already
+// collected.
+func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if tx.FeePayer() != nil {
+		return nil, accounts.ErrNotSupported
+	}
+	var res ethapi.SignTransactionResult
+	if tx.MultisigSender() != nil {
+		encoded, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			return nil, err
+		}
+		if err := api.client.Call(&res, "account_signMultisigTransaction", common.NewMixedcaseAddress(account.Address), hexutil.Bytes(encoded)); err != nil {
+			return nil, err
+		}
+		return res.Tx, nil
+	}
+	args := map[string]interface{}{
+		"from":     common.NewMixedcaseAddress(account.Address),
+		"gas":      hexutil.Uint64(tx.Gas()),
+		"gasPrice": (*hexutil.Big)(tx.GasPrice()),
+		"value":    (*hexutil.Big)(tx.Value()),
+		"nonce":    hexutil.Uint64(tx.Nonce()),
+		"type":     hexutil.Uint64(tx.Type()),
+		"data":     hexutil.Bytes(tx.Data()),
+	}
+	if to := tx.To(); to != nil {
+		args["to"] = common.NewMixedcaseAddress(*to)
+	}
+	if err := api.client.Call(&res, "account_signTransaction", args, nil); err != nil {
+		return nil, err
+	}
+	// Make sure the signer signed what was asked for with the requested key
+	if res.Tx == nil {
+		return nil, fmt.Errorf("external signer returned no transaction")
+	}
+	sender, err := types.Sender(types.NewEIP155Signer(chainID), res.Tx)
+	if err != nil {
+		return nil, err
+	}
+	if sender != account.Address {
+		return nil, fmt.Errorf("signer mismatch: expected %s, got %s", account.Address.Hex(), sender.Hex())
+	}
+	return res.Tx, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, but is not supported, the
+// passphrases of external signers are never sent over the wire.
+func (api *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, but is not supported, the
+// passphrases of external signers are never sent over the wire.
+func (api *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// listAccounts requests the accounts of the signer and caches them.
+func (api *ExternalSigner) listAccounts() ([]accounts.Account, error) {
+	var res []struct {
+		URL     accounts.URL   `json:"url"`
+		Address common.Address `json:"address"`
+	}
+	if err := api.client.CallContext(context.Background(), &res, "account_list"); err != nil {
+		return nil, err
+	}
+	accs := make([]accounts.Account, 0, len(res))
+	for _, acc := range res {
+		accs = append(accs, accounts.Account{Address: acc.Address, URL: api.URL()})
+	}
+	api.cacheMu.Lock()
+	api.cache = accs
+	api.cacheMu.Unlock()
+
+	return accs, nil
+}
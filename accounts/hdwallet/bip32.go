@@ -0,0 +1,107 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/common/math"
+	"github.com/yooba-team/yooba/crypto"
+)
+
+// hardenedOffset is the first child index of hardened BIP-32 derivations.
+const hardenedOffset = 0x80000000
+
+// masterSecret is the HMAC key used to derive the master node from a seed.
+var masterSecret = []byte("Bitcoin seed")
+
+// errInvalidKey is returned if a derivation step results in an invalid private
+// key. The chance of this is lower than 1 in 2^127, but BIP-32 requires it to
+// be handled nonetheless.
+var errInvalidKey = errors.New("invalid derived key")
+
+// extendedKey is a BIP-32 extended private key, the pair of a secp256k1 private
+// key and the chain code needed to derive its children.
+type extendedKey struct {
+	key       []byte // 32 byte big endian private key
+	chainCode []byte // 32 byte chain code
+}
+
+// newMasterKey derives the root of the BIP-32 key tree from a seed.
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, masterSecret)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errInvalidKey
+	}
+	return &extendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// child derives the child key at the given index, hardened if the index is at
+// least hardenedOffset.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	var data []byte
+	if index >= hardenedOffset {
+		data = append([]byte{0x00}, k.key...)
+	} else {
+		priv, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return nil, err
+		}
+		data = crypto.CompressPubkey(&priv.PublicKey)
+	}
+	var seq [4]byte
+	binary.BigEndian.PutUint32(seq[:], index)
+	data = append(data, seq[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	// The child key is the parent key tweaked by the left half of the hash
+	n := crypto.S256().Params().N
+
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, errInvalidKey
+	}
+	key := tweak.Add(tweak, new(big.Int).SetBytes(k.key))
+	key.Mod(key, n)
+	if key.Sign() == 0 {
+		return nil, errInvalidKey
+	}
+	return &extendedKey{key: math.PaddedBigBytes(key, 32), chainCode: sum[32:]}, nil
+}
+
+// derive walks the key tree along the given path, starting from this key.
+func (k *extendedKey) derive(path accounts.DerivationPath) (*extendedKey, error) {
+	var err error
+	for _, index := range path {
+		if k, err = k.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// privateKey converts the extended key into an ECDSA private key.
+func (k *extendedKey) privateKey() (*ecdsa.PrivateKey, error) {
+	return crypto.ToECDSA(k.key)
+}
+
+// zero wipes the key material from memory.
+func (k *extendedKey) zero() {
+	for i := range k.key {
+		k.key[i] = 0
+	}
+	for i := range k.chainCode {
+		k.chainCode[i] = 0
+	}
+}
@@ -0,0 +1,234 @@
+package hdwallet
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/accounts/keystore"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
+)
+
+// Mnemonic and first account of the well known BIP-39 test vector.
+const (
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testAddress  = "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+)
+
+// Tests the BIP-32 private key derivation against test vector 1 of the spec.
+func TestBIP32Derivation(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := newMasterKey(seed)
+	if err != nil {
+		t.Fatalf("failed to derive master key: %v", err)
+	}
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, tt := range tests {
+		path, err := accounts.ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatalf("%s: invalid path: %v", tt.path, err)
+		}
+		key, err := master.derive(path)
+		if err != nil {
+			t.Fatalf("%s: derivation failed: %v", tt.path, err)
+		}
+		if have := hex.EncodeToString(key.key); have != tt.key {
+			t.Errorf("%s: key mismatch: have %s, want %s", tt.path, have, tt.key)
+		}
+	}
+}
+
+func tmpHub(t *testing.T) (string, *Hub) {
+	dir, err := ioutil.TempDir("", "yooba-hdwallet-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, NewHub(dir, keystore.LightScryptN, keystore.LightScryptP)
+}
+
+// Tests that imported mnemonics derive the standard accounts and are persisted
+// encrypted, pinned accounts included.
+func TestImportMnemonic(t *testing.T) {
+	dir, hub := tmpHub(t)
+	defer os.RemoveAll(dir)
+
+	if _, err := hub.ImportMnemonic("abandon abandon about", "", "foo"); err != ErrInvalidMnemonic {
+		t.Fatalf("invalid mnemonic error mismatch: have %v, want %v", err, ErrInvalidMnemonic)
+	}
+	wallet, err := hub.ImportMnemonic(testMnemonic, "", "foo")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	accs := wallet.Accounts()
+	if len(accs) != 1 || accs[0].Address != common.HexToAddress(testAddress) {
+		t.Fatalf("account mismatch: have %v, want %s", accs, testAddress)
+	}
+	if _, err := hub.ImportMnemonic(testMnemonic, "", "bar"); err != ErrWalletExists {
+		t.Fatalf("duplicate import error mismatch: have %v, want %v", err, ErrWalletExists)
+	}
+	// Derivation needs the wallet to be opened with the right passphrase
+	path, _ := accounts.ParseDerivationPath("m/44'/60'/0'/0/1")
+	if _, err := wallet.Derive(path, true); err != accounts.ErrWalletClosed {
+		t.Fatalf("closed derivation error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if err := wallet.Open("bar"); err != accounts.ErrInvalidPassphrase {
+		t.Fatalf("open error mismatch: have %v, want %v", err, accounts.ErrInvalidPassphrase)
+	}
+	if err := wallet.Open("foo"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	pinned, err := wallet.Derive(path, true)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	// Reload the wallet from disk and check that pinned accounts are retained
+	reloaded := NewHub(dir, keystore.LightScryptN, keystore.LightScryptP).Wallets()
+	if len(reloaded) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(reloaded))
+	}
+	if !reloaded[0].Contains(pinned) || len(reloaded[0].Accounts()) != 2 {
+		t.Fatalf("pinned account not persisted: %v", reloaded[0].Accounts())
+	}
+	// The mnemonic passphrase results in a different wallet
+	other, err := hub.ImportMnemonic(testMnemonic, "TREZOR", "foo")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic with passphrase: %v", err)
+	}
+	if other.Contains(accs[0]) {
+		t.Fatalf("mnemonic passphrase ignored")
+	}
+}
+
+// Tests that new wallets hand out a backup mnemonic restoring the same accounts.
+func TestNewWallet(t *testing.T) {
+	dir, hub := tmpHub(t)
+	defer os.RemoveAll(dir)
+
+	wallet, mnemonic, err := hub.NewWallet("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if words := len(strings.Fields(mnemonic)); words != 24 {
+		t.Fatalf("mnemonic length mismatch: have %d, want 24", words)
+	}
+	if _, err := hub.ImportMnemonic(mnemonic, "bar", "baz"); err != ErrWalletExists {
+		t.Fatalf("restored wallet mismatch: have %v, want %v", err, ErrWalletExists)
+	}
+	if accs := hub.Wallets(); len(accs) != 1 || accs[0].URL() != wallet.URL() {
+		t.Fatalf("wallet list mismatch: have %v", accs)
+	}
+}
+
+// Tests that transactions are signed either by open wallets or by passphrase.
+func TestSignTx(t *testing.T) {
+	dir, hub := tmpHub(t)
+	defer os.RemoveAll(dir)
+
+	wallet, err := hub.ImportMnemonic(testMnemonic, "", "foo")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	account := wallet.Accounts()[0]
+	chainID := big.NewInt(1)
+	tx := types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), types.TxTypeTransfer, nil)
+
+	if _, err := wallet.SignTx(account, tx, chainID); err != accounts.ErrWalletClosed {
+		t.Fatalf("closed signing error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if _, err := wallet.SignTxWithPassphrase(account, "bar", tx, chainID); err != accounts.ErrInvalidPassphrase {
+		t.Fatalf("passphrase error mismatch: have %v, want %v", err, accounts.ErrInvalidPassphrase)
+	}
+	signed, err := wallet.SignTxWithPassphrase(account, "foo", tx, chainID)
+	if err != nil {
+		t.Fatalf("failed to sign with passphrase: %v", err)
+	}
+	if sender, err := types.Sender(types.NewEIP155Signer(chainID), signed); err != nil || sender != account.Address {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", sender, err, account.Address)
+	}
+	if err := wallet.Open("foo"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	signed, err = wallet.SignTx(account, tx, chainID)
+	if err != nil {
+		t.Fatalf("failed to sign with open wallet: %v", err)
+	}
+	if sender, err := types.Sender(types.NewEIP155Signer(chainID), signed); err != nil || sender != account.Address {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", sender, err, account.Address)
+	}
+	if _, err := wallet.SignTx(accounts.Account{Address: common.Address{0x02}}, tx, chainID); err != accounts.ErrUnknownAccount {
+		t.Fatalf("unknown account error mismatch: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+}
+
+// testChain is a chain state reader where only a given set of accounts is used.
+type testChain map[common.Address]bool
+
+func (c testChain) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if c[account] {
+		return big.NewInt(1), nil
+	}
+	return new(big.Int), nil
+}
+
+func (c testChain) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c testChain) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c testChain) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return 0, nil
+}
+
+// Tests that open wallets discover used accounts up to the first empty one.
+func TestSelfDerive(t *testing.T) {
+	dir, hub := tmpHub(t)
+	defer os.RemoveAll(dir)
+
+	wallet, err := hub.ImportMnemonic(testMnemonic, "", "foo")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if err := wallet.Open("foo"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	used := make(testChain)
+	for i := 0; i < 3; i++ {
+		path := append(accounts.DerivationPath{}, accounts.DefaultBaseDerivationPath...)
+		path[len(path)-1] = uint32(i)
+
+		account, err := wallet.Derive(path, false)
+		if err != nil {
+			t.Fatalf("failed to derive account %d: %v", i, err)
+		}
+		used[account.Address] = true
+	}
+	wallet.SelfDerive(accounts.DefaultBaseDerivationPath, used)
+
+	// The three used accounts and the first empty one are discovered
+	if accs := wallet.Accounts(); len(accs) != 4 {
+		t.Fatalf("account count mismatch: have %d, want 4", len(accs))
+	}
+	for address := range used {
+		if !wallet.Contains(accounts.Account{Address: address}) {
+			t.Errorf("used account %x not discovered", address)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Package hdwallet implements a software hierarchical deterministic wallet,
+// deriving its accounts from a BIP-39 mnemonic along BIP-32 derivation paths.
+package hdwallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/tyler-smith/go-bip39"
+	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/accounts/keystore"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/event"
+	"github.com/yooba-team/yooba/log"
+)
+
+// Scheme is the protocol scheme prefixing HD wallet and account URLs.
+const Scheme = "hd"
+
+// Version of the wallet file format.
+const version = 1
+
+// Entropy of newly generated mnemonics, resulting in 24 words.
+const entropyBits = 256
+
+// Maximum time between wallet refreshes, picking up wallet files created or
+// removed by other processes.
+const walletRefreshCycle = 3 * time.Second
+
+var (
+	// ErrInvalidMnemonic is returned if an imported mnemonic has unknown words or
+	// a wrong checksum.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// ErrWalletExists is returned if a mnemonic is imported whose wallet is
+	// already present in the hub.
+	ErrWalletExists = errors.New("wallet already exists")
+)
+
+// HubType is the reflect type of an HD wallet backend.
+var HubType = reflect.TypeOf(&Hub{})
+
+// Hub is an accounts.Backend managing the HD wallet files stored in a directory.
+type Hub struct {
+	dir     string // Directory containing the wallet files
+	scryptN int    // Scrypt parameters to encrypt new seeds with
+	scryptP int
+
+	wallets     []*wallet               // List of wallets loaded from the directory, sorted by URL
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+	updating    bool                    // Whether the event notification loop is running
+
+	lock sync.RWMutex
+}
+
+// NewHub creates an HD wallet backend over the given directory, encrypting the
+// seeds of new wallets with the given scrypt parameters.
+func NewHub(dir string, scryptN, scryptP int) *Hub {
+	dir, _ = filepath.Abs(dir)
+	hub := &Hub{
+		dir:     dir,
+		scryptN: scryptN,
+		scryptP: scryptP,
+	}
+	hub.refreshWallets()
+	return hub
+}
+
+// Wallets implements accounts.Backend, returning all the HD wallets stored in
+// the hub's directory.
+func (hub *Hub) Wallets() []accounts.Wallet {
+	// Make sure the list of wallets is in sync with the directory
+	hub.refreshWallets()
+
+	hub.lock.RLock()
+	defer hub.lock.RUnlock()
+
+	cpy := make([]accounts.Wallet, len(hub.wallets))
+	for i, wallet := range hub.wallets {
+		cpy[i] = wallet
+	}
+	return cpy
+}
+
+// refreshWallets scans the wallet directory and updates the list of wallets,
+// firing events for any arrived or dropped ones.
+func (hub *Hub) refreshWallets() {
+	files, err := ioutil.ReadDir(hub.dir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Debug("Failed to scan HD wallet directory", "dir", hub.dir, "err", err)
+		return
+	}
+	hub.lock.Lock()
+
+	known := make(map[string]*wallet, len(hub.wallets))
+	for _, wallet := range hub.wallets {
+		known[wallet.url.Path] = wallet
+	}
+	var (
+		wallets []*wallet
+		events  []accounts.WalletEvent
+	)
+	for _, fi := range files {
+		// Skip editor backups, hidden files and folders
+		name := fi.Name()
+		if fi.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		path := filepath.Join(hub.dir, name)
+		if wallet, ok := known[path]; ok {
+			wallets = append(wallets, wallet)
+			delete(known, path)
+			continue
+		}
+		wallet, err := hub.loadWallet(path)
+		if err != nil {
+			log.Debug("Failed to load HD wallet", "path", path, "err", err)
+			continue
+		}
+		wallets = append(wallets, wallet)
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	}
+	for _, wallet := range known {
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+	}
+	sort.Slice(wallets, func(i, j int) bool { return wallets[i].url.Cmp(wallets[j].url) < 0 })
+	hub.wallets = wallets
+	hub.lock.Unlock()
+
+	// Fire all wallet events and return
+	for _, event := range events {
+		hub.updateFeed.Send(event)
+	}
+}
+
+// loadWallet parses the wallet file at the given path.
+func (hub *Hub) loadWallet(path string) (*wallet, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var blob walletJSON
+	if err := json.Unmarshal(content, &blob); err != nil {
+		return nil, err
+	}
+	if blob.Version != version {
+		return nil, fmt.Errorf("version not supported: %v", blob.Version)
+	}
+	wallet := hub.newWallet(path, blob.Id, blob.Crypto)
+	for _, account := range blob.Accounts {
+		if !common.IsHexAddress(account.Address) {
+			return nil, fmt.Errorf("invalid account address %q", account.Address)
+		}
+		derivationPath, err := accounts.ParseDerivationPath(account.Path)
+		if err != nil {
+			return nil, err
+		}
+		address := common.HexToAddress(account.Address)
+
+		wallet.accounts = append(wallet.accounts, wallet.account(address, derivationPath))
+		wallet.paths[address] = derivationPath
+		wallet.pinned[address] = true
+	}
+	return wallet, nil
+}
+
+// newWallet creates a closed wallet backed by the given file.
+func (hub *Hub) newWallet(path string, id string, seed keystore.CryptoJSON) *wallet {
+	wallet := &wallet{
+		hub:        hub,
+		url:        accounts.URL{Scheme: Scheme, Path: path},
+		id:         id,
+		seed:       seed,
+		paths:      make(map[common.Address]accounts.DerivationPath),
+		pinned:     make(map[common.Address]bool),
+		deriveLock: make(chan struct{}, 1),
+	}
+	wallet.deriveLock <- struct{}{} // Enable lock
+	return wallet
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of HD wallets.
+func (hub *Hub) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	// We need the mutex to reliably start/stop the update loop
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	// Subscribe the caller and track the subscriber count
+	sub := hub.updateScope.Track(hub.updateFeed.Subscribe(sink))
+
+	// Subscribers require an active notification loop, start it
+	if !hub.updating {
+		hub.updating = true
+		go hub.updater()
+	}
+	return sub
+}
+
+// updater is responsible for maintaining an up-to-date list of wallets stored
+// in the directory, and for firing wallet addition/removal events.
+func (hub *Hub) updater() {
+	for {
+		// Wait for the refresh timeout and run the wallet refresher
+		time.Sleep(walletRefreshCycle)
+		hub.refreshWallets()
+
+		// If all our subscribers left, stop the updater
+		hub.lock.Lock()
+		if hub.updateScope.Count() == 0 {
+			hub.updating = false
+			hub.lock.Unlock()
+			return
+		}
+		hub.lock.Unlock()
+	}
+}
+
+// NewWallet generates a new 24 word mnemonic and stores the wallet derived from
+// it, protected by the optional mnemonic passphrase, encrypted with passphrase.
+// The mnemonic is returned so that the user can back it up, it is not stored.
+func (hub *Hub) NewWallet(passphrase, mnemonicPassphrase string) (accounts.Wallet, string, error) {
+	entropy, err := bip39.NewEntropy(entropyBits)
+	if err != nil {
+		return nil, "", err
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, "", err
+	}
+	wallet, err := hub.ImportMnemonic(mnemonic, mnemonicPassphrase, passphrase)
+	if err != nil {
+		return nil, "", err
+	}
+	return wallet, mnemonic, nil
+}
+
+// ImportMnemonic stores the wallet derived from an existing mnemonic and the
+// optional mnemonic passphrase, encrypting its seed with passphrase. The first
+// account of the default derivation path is pinned to the new wallet.
+func (hub *Hub) ImportMnemonic(mnemonic, mnemonicPassphrase, passphrase string) (accounts.Wallet, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+	seed := bip39.NewSeed(mnemonic, mnemonicPassphrase)
+	defer zeroBytes(seed)
+
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	defer master.zero()
+
+	seedJSON, err := keystore.EncryptDataV3(seed, []byte(passphrase), hub.scryptN, hub.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	// Assemble the wallet and pin its first account
+	wallet := hub.newWallet("", uuid.NewRandom().String(), seedJSON)
+	wallet.master = master
+
+	path := make(accounts.DerivationPath, len(accounts.DefaultBaseDerivationPath))
+	copy(path, accounts.DefaultBaseDerivationPath)
+
+	address, err := wallet.derive(path)
+	if err != nil {
+		return nil, err
+	}
+	wallet.master = nil
+
+	// Refuse duplicates, they would only differ in their passphrase
+	hub.refreshWallets()
+
+	hub.lock.Lock()
+	for _, known := range hub.wallets {
+		if known.Contains(accounts.Account{Address: address}) {
+			hub.lock.Unlock()
+			return nil, ErrWalletExists
+		}
+	}
+	wallet.url.Path = filepath.Join(hub.dir, walletFileName(address))
+	wallet.accounts = append(wallet.accounts, wallet.account(address, path))
+	wallet.paths[address] = path
+	wallet.pinned[address] = true
+
+	if err := wallet.store(); err != nil {
+		hub.lock.Unlock()
+		return nil, err
+	}
+	hub.lock.Unlock()
+
+	// Let the refresher pick up the new file, firing the arrival event
+	hub.refreshWallets()
+
+	hub.lock.RLock()
+	defer hub.lock.RUnlock()
+
+	for _, known := range hub.wallets {
+		if known.url == wallet.url {
+			return known, nil
+		}
+	}
+	return nil, accounts.ErrUnknownWallet
+}
+
+// walletFileName implements the naming convention for HD wallet files:
+// UTC--<created_at UTC ISO8601>--<first account address hex>
+func walletFileName(address common.Address) string {
+	ts := time.Now().UTC()
+	return fmt.Sprintf("UTC--%s--%s", ts.Format("2006-01-02T15-04-05.000000000Z"), hex.EncodeToString(address[:]))
+}
+
+// writeWalletFile atomically replaces the given wallet file with content.
+func writeWalletFile(file string, content []byte) error {
+	// Create the wallet directory with appropriate permissions
+	// in case it is not present yet.
+	const dirPerm = 0700
+	if err := os.MkdirAll(filepath.Dir(file), dirPerm); err != nil {
+		return err
+	}
+	// Atomic write: create a temporary hidden file first
+	// then move it into place. TempFile assigns mode 0600.
+	f, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), file)
+}
@@ -0,0 +1,431 @@
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	yooba "github.com/yooba-team/yooba"
+	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/accounts/keystore"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/log"
+)
+
+// Minimum time to wait between self derivation attempts, even it the user is
+// requesting accounts like crazy.
+const selfDeriveThrottling = time.Second
+
+// walletJSON is the on-disk format of an HD wallet: the scrypt encrypted seed
+// and the accounts pinned to it, which are kept in plain text to allow listing
+// them without the passphrase.
+type walletJSON struct {
+	Accounts []accountJSON       `json:"accounts"`
+	Crypto   keystore.CryptoJSON `json:"crypto"`
+	Id       string              `json:"id"`
+	Version  int                 `json:"version"`
+}
+
+type accountJSON struct {
+	Address string `json:"address"`
+	Path    string `json:"path"`
+}
+
+// wallet is a software HD wallet deriving all its accounts from a single BIP-39
+// seed. The seed is only held in memory while the wallet is open.
+type wallet struct {
+	hub  *Hub         // Hub the wallet file belongs to
+	url  accounts.URL // Textual URL uniquely identifying this wallet
+	id   string       // Unique identifier of the wallet file
+	seed keystore.CryptoJSON
+
+	master   *extendedKey                               // Root of the key tree, nil if the wallet is closed
+	accounts []accounts.Account                         // List of accounts derived from the wallet
+	paths    map[common.Address]accounts.DerivationPath // Known derivation paths for signing operations
+	pinned   map[common.Address]bool                    // Accounts persisted in the wallet file
+
+	deriveNextPath accounts.DerivationPath // Next derivation path for account auto-discovery
+	deriveChain    yooba.ChainStateReader  // Blockchain state reader to discover used account with
+	deriveLast     time.Time               // Time of the last self-derivation, used for throttling
+	deriveLock     chan struct{}           // Mutex (buf=1) serializing self-derivations
+
+	stateLock sync.RWMutex // Protects read and write access to the wallet struct fields
+}
+
+// URL implements accounts.Wallet, returning the URL of the wallet file.
+func (w *wallet) URL() accounts.URL {
+	return w.url // Immutable, no need for a lock
+}
+
+// Status implements accounts.Wallet, returning whether the seed of the wallet
+// is currently decrypted.
+func (w *wallet) Status() (string, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.master == nil {
+		return "Closed", nil
+	}
+	return "Open", nil
+}
+
+// Open implements accounts.Wallet, decrypting the seed of the wallet with the
+// passphrase and keeping it in memory until the wallet is closed.
+func (w *wallet) Open(passphrase string) error {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master != nil {
+		return accounts.ErrWalletAlreadyOpen
+	}
+	master, err := w.decrypt(passphrase)
+	if err != nil {
+		return err
+	}
+	w.master = master
+
+	// Notify anyone listening for wallet events that the wallet is accessible
+	go w.hub.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+
+	return nil
+}
+
+// decrypt recovers the root of the key tree from the encrypted seed.
+func (w *wallet) decrypt(passphrase string) (*extendedKey, error) {
+	seed, err := keystore.DecryptDataV3(w.seed, passphrase)
+	if err == keystore.ErrDecrypt {
+		return nil, accounts.ErrInvalidPassphrase
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	return newMasterKey(seed)
+}
+
+// Close implements accounts.Wallet, wiping the decrypted seed from memory.
+func (w *wallet) Close() error {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master != nil {
+		w.master.zero()
+		w.master = nil
+	}
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts pinned to
+// the wallet. If self-derivation was enabled, the account list is periodically
+// expanded based on current chain state.
+func (w *wallet) Accounts() []accounts.Account {
+	w.selfDerive()
+
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// selfDerive attempts to find new non-zero accounts, starting at the next
+// derivation path and stopping at the first empty account.
+func (w *wallet) selfDerive() {
+	// Skip if another derivation is running or one finished recently
+	select {
+	case <-w.deriveLock:
+	default:
+		return
+	}
+	defer func() { w.deriveLock <- struct{}{} }()
+
+	w.stateLock.RLock()
+	chain := w.deriveChain
+	nextPath := make(accounts.DerivationPath, len(w.deriveNextPath))
+	copy(nextPath[:], w.deriveNextPath[:])
+	w.stateLock.RUnlock()
+
+	if chain == nil || time.Since(w.deriveLast) < selfDeriveThrottling {
+		return
+	}
+	defer func() { w.deriveLast = time.Now() }()
+
+	var (
+		accs  []accounts.Account
+		paths []accounts.DerivationPath
+
+		context = context.Background()
+	)
+	for empty := false; !empty; {
+		// Retrieve the next derived Yooba account, bailing out if the wallet closed
+		w.stateLock.RLock()
+		if w.master == nil {
+			w.stateLock.RUnlock()
+			return
+		}
+		nextAddr, err := w.derive(nextPath)
+		w.stateLock.RUnlock()
+
+		if err != nil {
+			log.Warn("HD wallet account derivation failed", "url", w.url, "err", err)
+			break
+		}
+		// Check the account's status against the current chain state
+		balance, err := chain.BalanceAt(context, nextAddr, nil)
+		if err != nil {
+			log.Warn("HD wallet balance retrieval failed", "err", err)
+			break
+		}
+		nonce, err := chain.NonceAt(context, nextAddr, nil)
+		if err != nil {
+			log.Warn("HD wallet nonce retrieval failed", "err", err)
+			break
+		}
+		// If the next account is empty, stop self-derivation, but add it nonetheless
+		if balance.Sign() == 0 && nonce == 0 {
+			empty = true
+		}
+		path := make(accounts.DerivationPath, len(nextPath))
+		copy(path[:], nextPath[:])
+		paths = append(paths, path)
+
+		accs = append(accs, w.account(nextAddr, path))
+
+		// Fetch the next potential account
+		if !empty {
+			nextPath[len(nextPath)-1]++
+		}
+	}
+	// Insert any accounts successfully derived and shift the self-derivation forward
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	for i := 0; i < len(accs); i++ {
+		if _, ok := w.paths[accs[i].Address]; !ok {
+			log.Info("HD wallet discovered new account", "address", accs[i].Address, "path", paths[i])
+			w.accounts = append(w.accounts, accs[i])
+			w.paths[accs[i].Address] = paths[i]
+		}
+	}
+	w.deriveNextPath = nextPath
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not pinned into this wallet instance.
+func (w *wallet) Contains(account accounts.Account) bool {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	_, exists := w.paths[account.Address]
+	return exists
+}
+
+// Derive implements accounts.Wallet, deriving a new account at the specific
+// derivation path. If pin is set to true, the account will be added to the list
+// of tracked accounts and persisted in the wallet file.
+func (w *wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master == nil {
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	address, err := w.derive(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	account := w.account(address, path)
+	if !pin || w.pinned[address] {
+		return account, nil
+	}
+	if _, ok := w.paths[address]; !ok {
+		w.accounts = append(w.accounts, account)
+		w.paths[address] = path
+	}
+	w.pinned[address] = true
+	return account, w.store()
+}
+
+// derive returns the address of the account at the given derivation path.
+//
+// Note, derive assumes the state lock is held and the wallet is open!
+func (w *wallet) derive(path accounts.DerivationPath) (common.Address, error) {
+	key, err := w.master.derive(path)
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer key.zero()
+
+	priv, err := key.privateKey()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(priv.PublicKey), nil
+}
+
+// account assembles the account located at the given derivation path.
+func (w *wallet) account(address common.Address, path accounts.DerivationPath) accounts.Account {
+	return accounts.Account{
+		Address: address,
+		URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)},
+	}
+}
+
+// SelfDerive implements accounts.Wallet, trying to discover accounts that the
+// user used previously (based on the chain state), but ones that he/she did not
+// explicitly pin to the wallet manually. To avoid chain head monitoring, self
+// derivation only runs during account listing (and even then throttled).
+func (w *wallet) SelfDerive(base accounts.DerivationPath, chain yooba.ChainStateReader) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveNextPath = make(accounts.DerivationPath, len(base))
+	copy(w.deriveNextPath[:], base[:])
+
+	w.deriveChain = chain
+}
+
+// SignHash implements accounts.Wallet, signing the hash with the key of the
+// requested account. The wallet needs to be open.
+func (w *wallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	key, err := w.signingKey(w.master, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	return crypto.Sign(hash, key)
+}
+
+// SignTx implements accounts.Wallet, signing the transaction with the key of the
+// requested account. The wallet needs to be open.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	key, err := w.signingKey(w.master, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	return signTx(tx, chainID, key)
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, decrypting the seed with the
+// passphrase for the duration of the signature only.
+func (w *wallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	master, err := w.decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer master.zero()
+
+	key, err := w.signingKey(master, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	return crypto.Sign(hash, key)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, decrypting the seed with the
+// passphrase for the duration of the signature only.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	master, err := w.decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer master.zero()
+
+	key, err := w.signingKey(master, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	return signTx(tx, chainID, key)
+}
+
+// signingKey derives the private key of a known account from the given root.
+//
+// Note, signingKey assumes the state lock is held!
+func (w *wallet) signingKey(master *extendedKey, account accounts.Account) (*ecdsa.PrivateKey, error) {
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	if master == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	key, err := master.derive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer key.zero()
+
+	return key.privateKey()
+}
+
+// store persists the encrypted seed and the pinned accounts into the wallet file.
+//
+// Note, store assumes the state lock is held!
+func (w *wallet) store() error {
+	blob := walletJSON{
+		Crypto:  w.seed,
+		Id:      w.id,
+		Version: version,
+	}
+	for _, account := range w.accounts {
+		if w.pinned[account.Address] {
+			blob.Accounts = append(blob.Accounts, accountJSON{
+				Address: account.Address.Hex(),
+				Path:    w.paths[account.Address].String(),
+			})
+		}
+	}
+	content, err := json.Marshal(blob)
+	if err != nil {
+		return err
+	}
+	return writeWalletFile(w.url.Path, content)
+}
+
+func signTx(tx *types.Transaction, chainID *big.Int, key *ecdsa.PrivateKey) (*types.Transaction, error) {
+	signer := types.NewEIP155Signer(chainID)
+	if tx.MultisigSender() != nil {
+		return types.SignMultisig(tx, signer, key)
+	}
+	return types.SignTx(tx, signer, key)
+}
+
+func zeroKey(k *ecdsa.PrivateKey) {
+	b := k.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
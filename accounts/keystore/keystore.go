@@ -277,7 +277,7 @@ func (ks *KeyStore) SignTx(a accounts.Account, tx *types.Transaction, chainID *b
 	if !found {
 		return nil, ErrLocked
 	}
-		return types.SignTx(tx, types.NewEIP155Signer(chainID), unlockedKey.PrivateKey)
+	return signTx(tx, chainID, unlockedKey.PrivateKey)
 
 }
 
@@ -302,10 +302,20 @@ func (ks *KeyStore) SignTxWithPassphrase(a accounts.Account, passphrase string,
 	}
 	defer zeroKey(key.PrivateKey)
 
-	return types.SignTx(tx, types.NewEIP155Signer(chainID), key.PrivateKey)
+	return signTx(tx, chainID, key.PrivateKey)
 
 }
 
+// signTx signs tx with the given key. Transactions sent from multi-signature
+// accounts get the signature added to the ones collected from other key holders.
+func signTx(tx *types.Transaction, chainID *big.Int, key *ecdsa.PrivateKey) (*types.Transaction, error) {
+	signer := types.NewEIP155Signer(chainID)
+	if tx.MultisigSender() != nil {
+		return types.SignMultisig(tx, signer, key)
+	}
+	return types.SignTx(tx, signer, key)
+}
+
 // Unlock unlocks the given account indefinitely.
 func (ks *KeyStore) Unlock(a accounts.Account, passphrase string) error {
 	return ks.TimedUnlock(a, passphrase, 0)
@@ -18,6 +18,7 @@ package keystore
 
 import (
 	"io/ioutil"
+	"math/big"
 	"math/rand"
 	"os"
 	"runtime"
@@ -28,6 +29,8 @@ import (
 
 	"github.com/yooba-team/yooba/accounts"
 	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
 	"github.com/yooba-team/yooba/event"
 )
 
@@ -113,6 +116,49 @@ func TestSignWithPassphrase(t *testing.T) {
 	}
 }
 
+// Tests that signing a transaction of a multi-signature account collects the
+// signature of every key holder instead of replacing the previous one.
+func TestSignMultisigTx(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	pass := "passwd"
+	signers := make([]accounts.Account, 2)
+	for i := range signers {
+		acc, err := ks.NewAccount(pass)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers[i] = acc
+	}
+	chainID := big.NewInt(1)
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), types.TxTypeTransfer, nil)
+	tx = tx.WithMultisigSender(common.HexToAddress("0x0123"))
+
+	for _, acc := range signers {
+		var err error
+		if tx, err = ks.SignTxWithPassphrase(acc, pass, tx, chainID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, err := types.MultisigSigners(types.NewEIP155Signer(chainID), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != len(signers) {
+		t.Fatalf("signature count mismatch: have %d, want %d", len(keys), len(signers))
+	}
+	for i, key := range keys {
+		pub, err := crypto.DecompressPubkey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr := crypto.PubkeyToAddress(*pub); addr != signers[i].Address {
+			t.Errorf("signer %d mismatch: have %x, want %x", i, addr, signers[i].Address)
+		}
+	}
+}
+
 func TestTimedUnlock(t *testing.T) {
 	dir, ks := tmpKeyStore(t, true)
 	defer os.RemoveAll(dir)
@@ -17,79 +17,79 @@ var _ = math.Inf
 type MessageType int32
 
 const (
-	MessageType_MessageType_Initialize               MessageType = 0
-	MessageType_MessageType_Ping                     MessageType = 1
-	MessageType_MessageType_Success                  MessageType = 2
-	MessageType_MessageType_Failure                  MessageType = 3
-	MessageType_MessageType_ChangePin                MessageType = 4
-	MessageType_MessageType_WipeDevice               MessageType = 5
-	MessageType_MessageType_FirmwareErase            MessageType = 6
-	MessageType_MessageType_FirmwareUpload           MessageType = 7
-	MessageType_MessageType_FirmwareRequest          MessageType = 8
-	MessageType_MessageType_GetEntropy               MessageType = 9
-	MessageType_MessageType_Entropy                  MessageType = 10
-	MessageType_MessageType_GetPublicKey             MessageType = 11
-	MessageType_MessageType_PublicKey                MessageType = 12
-	MessageType_MessageType_LoadDevice               MessageType = 13
-	MessageType_MessageType_ResetDevice              MessageType = 14
-	MessageType_MessageType_SignTx                   MessageType = 15
-	MessageType_MessageType_SimpleSignTx             MessageType = 16
-	MessageType_MessageType_Features                 MessageType = 17
-	MessageType_MessageType_PinMatrixRequest         MessageType = 18
-	MessageType_MessageType_PinMatrixAck             MessageType = 19
-	MessageType_MessageType_Cancel                   MessageType = 20
-	MessageType_MessageType_TxRequest                MessageType = 21
-	MessageType_MessageType_TxAck                    MessageType = 22
-	MessageType_MessageType_CipherKeyValue           MessageType = 23
-	MessageType_MessageType_ClearSession             MessageType = 24
-	MessageType_MessageType_ApplySettings            MessageType = 25
-	MessageType_MessageType_ButtonRequest            MessageType = 26
-	MessageType_MessageType_ButtonAck                MessageType = 27
-	MessageType_MessageType_ApplyFlags               MessageType = 28
-	MessageType_MessageType_GetAddress               MessageType = 29
-	MessageType_MessageType_Address                  MessageType = 30
-	MessageType_MessageType_SelfTest                 MessageType = 32
-	MessageType_MessageType_BackupDevice             MessageType = 34
-	MessageType_MessageType_EntropyRequest           MessageType = 35
-	MessageType_MessageType_EntropyAck               MessageType = 36
-	MessageType_MessageType_SignMessage              MessageType = 38
-	MessageType_MessageType_VerifyMessage            MessageType = 39
-	MessageType_MessageType_MessageSignature         MessageType = 40
-	MessageType_MessageType_PassphraseRequest        MessageType = 41
-	MessageType_MessageType_PassphraseAck            MessageType = 42
-	MessageType_MessageType_EstimateTxSize           MessageType = 43
-	MessageType_MessageType_TxSize                   MessageType = 44
-	MessageType_MessageType_RecoveryDevice           MessageType = 45
-	MessageType_MessageType_WordRequest              MessageType = 46
-	MessageType_MessageType_WordAck                  MessageType = 47
-	MessageType_MessageType_CipheredKeyValue         MessageType = 48
-	MessageType_MessageType_EncryptMessage           MessageType = 49
-	MessageType_MessageType_EncryptedMessage         MessageType = 50
-	MessageType_MessageType_DecryptMessage           MessageType = 51
-	MessageType_MessageType_DecryptedMessage         MessageType = 52
-	MessageType_MessageType_SignIdentity             MessageType = 53
-	MessageType_MessageType_SignedIdentity           MessageType = 54
-	MessageType_MessageType_GetFeatures              MessageType = 55
-	MessageType_MessageType_EthereumGetAddress       MessageType = 56
-	MessageType_MessageType_EthereumAddress          MessageType = 57
-	MessageType_MessageType_EthereumSignTx           MessageType = 58
-	MessageType_MessageType_EthereumTxRequest        MessageType = 59
-	MessageType_MessageType_EthereumTxAck            MessageType = 60
-	MessageType_MessageType_GetECDHSessionKey        MessageType = 61
-	MessageType_MessageType_ECDHSessionKey           MessageType = 62
-	MessageType_MessageType_SetU2FCounter            MessageType = 63
-	MessageType_MessageType_EthereumSignMessage      MessageType = 64
-	MessageType_MessageType_EthereumVerifyMessage    MessageType = 65
-	MessageType_MessageType_EthereumMessageSignature MessageType = 66
-	MessageType_MessageType_DebugLinkDecision        MessageType = 100
-	MessageType_MessageType_DebugLinkGetState        MessageType = 101
-	MessageType_MessageType_DebugLinkState           MessageType = 102
-	MessageType_MessageType_DebugLinkStop            MessageType = 103
-	MessageType_MessageType_DebugLinkLog             MessageType = 104
-	MessageType_MessageType_DebugLinkMemoryRead      MessageType = 110
-	MessageType_MessageType_DebugLinkMemory          MessageType = 111
-	MessageType_MessageType_DebugLinkMemoryWrite     MessageType = 112
-	MessageType_MessageType_DebugLinkFlashErase      MessageType = 113
+	MessageType_MessageType_Initialize            MessageType = 0
+	MessageType_MessageType_Ping                  MessageType = 1
+	MessageType_MessageType_Success               MessageType = 2
+	MessageType_MessageType_Failure               MessageType = 3
+	MessageType_MessageType_ChangePin             MessageType = 4
+	MessageType_MessageType_WipeDevice            MessageType = 5
+	MessageType_MessageType_FirmwareErase         MessageType = 6
+	MessageType_MessageType_FirmwareUpload        MessageType = 7
+	MessageType_MessageType_FirmwareRequest       MessageType = 8
+	MessageType_MessageType_GetEntropy            MessageType = 9
+	MessageType_MessageType_Entropy               MessageType = 10
+	MessageType_MessageType_GetPublicKey          MessageType = 11
+	MessageType_MessageType_PublicKey             MessageType = 12
+	MessageType_MessageType_LoadDevice            MessageType = 13
+	MessageType_MessageType_ResetDevice           MessageType = 14
+	MessageType_MessageType_SignTx                MessageType = 15
+	MessageType_MessageType_SimpleSignTx          MessageType = 16
+	MessageType_MessageType_Features              MessageType = 17
+	MessageType_MessageType_PinMatrixRequest      MessageType = 18
+	MessageType_MessageType_PinMatrixAck          MessageType = 19
+	MessageType_MessageType_Cancel                MessageType = 20
+	MessageType_MessageType_TxRequest             MessageType = 21
+	MessageType_MessageType_TxAck                 MessageType = 22
+	MessageType_MessageType_CipherKeyValue        MessageType = 23
+	MessageType_MessageType_ClearSession          MessageType = 24
+	MessageType_MessageType_ApplySettings         MessageType = 25
+	MessageType_MessageType_ButtonRequest         MessageType = 26
+	MessageType_MessageType_ButtonAck             MessageType = 27
+	MessageType_MessageType_ApplyFlags            MessageType = 28
+	MessageType_MessageType_GetAddress            MessageType = 29
+	MessageType_MessageType_Address               MessageType = 30
+	MessageType_MessageType_SelfTest              MessageType = 32
+	MessageType_MessageType_BackupDevice          MessageType = 34
+	MessageType_MessageType_EntropyRequest        MessageType = 35
+	MessageType_MessageType_EntropyAck            MessageType = 36
+	MessageType_MessageType_SignMessage           MessageType = 38
+	MessageType_MessageType_VerifyMessage         MessageType = 39
+	MessageType_MessageType_MessageSignature      MessageType = 40
+	MessageType_MessageType_PassphraseRequest     MessageType = 41
+	MessageType_MessageType_PassphraseAck         MessageType = 42
+	MessageType_MessageType_EstimateTxSize        MessageType = 43
+	MessageType_MessageType_TxSize                MessageType = 44
+	MessageType_MessageType_RecoveryDevice        MessageType = 45
+	MessageType_MessageType_WordRequest           MessageType = 46
+	MessageType_MessageType_WordAck               MessageType = 47
+	MessageType_MessageType_CipheredKeyValue      MessageType = 48
+	MessageType_MessageType_EncryptMessage        MessageType = 49
+	MessageType_MessageType_EncryptedMessage      MessageType = 50
+	MessageType_MessageType_DecryptMessage        MessageType = 51
+	MessageType_MessageType_DecryptedMessage      MessageType = 52
+	MessageType_MessageType_SignIdentity          MessageType = 53
+	MessageType_MessageType_SignedIdentity        MessageType = 54
+	MessageType_MessageType_GetFeatures           MessageType = 55
+	MessageType_MessageType_YoobaGetAddress       MessageType = 56
+	MessageType_MessageType_YoobaAddress          MessageType = 57
+	MessageType_MessageType_YoobaSignTx           MessageType = 58
+	MessageType_MessageType_YoobaTxRequest        MessageType = 59
+	MessageType_MessageType_YoobaTxAck            MessageType = 60
+	MessageType_MessageType_GetECDHSessionKey     MessageType = 61
+	MessageType_MessageType_ECDHSessionKey        MessageType = 62
+	MessageType_MessageType_SetU2FCounter         MessageType = 63
+	MessageType_MessageType_YoobaSignMessage      MessageType = 64
+	MessageType_MessageType_YoobaVerifyMessage    MessageType = 65
+	MessageType_MessageType_YoobaMessageSignature MessageType = 66
+	MessageType_MessageType_DebugLinkDecision     MessageType = 100
+	MessageType_MessageType_DebugLinkGetState     MessageType = 101
+	MessageType_MessageType_DebugLinkState        MessageType = 102
+	MessageType_MessageType_DebugLinkStop         MessageType = 103
+	MessageType_MessageType_DebugLinkLog          MessageType = 104
+	MessageType_MessageType_DebugLinkMemoryRead   MessageType = 110
+	MessageType_MessageType_DebugLinkMemory       MessageType = 111
+	MessageType_MessageType_DebugLinkMemoryWrite  MessageType = 112
+	MessageType_MessageType_DebugLinkFlashErase   MessageType = 113
 )
 
 var MessageType_name = map[int32]string{
@@ -146,17 +146,17 @@ var MessageType_name = map[int32]string{
 	53:  "MessageType_SignIdentity",
 	54:  "MessageType_SignedIdentity",
 	55:  "MessageType_GetFeatures",
-	56:  "MessageType_EthereumGetAddress",
-	57:  "MessageType_EthereumAddress",
-	58:  "MessageType_EthereumSignTx",
-	59:  "MessageType_EthereumTxRequest",
-	60:  "MessageType_EthereumTxAck",
+	56:  "MessageType_YoobaGetAddress",
+	57:  "MessageType_YoobaAddress",
+	58:  "MessageType_YoobaSignTx",
+	59:  "MessageType_YoobaTxRequest",
+	60:  "MessageType_YoobaTxAck",
 	61:  "MessageType_GetECDHSessionKey",
 	62:  "MessageType_ECDHSessionKey",
 	63:  "MessageType_SetU2FCounter",
-	64:  "MessageType_EthereumSignMessage",
-	65:  "MessageType_EthereumVerifyMessage",
-	66:  "MessageType_EthereumMessageSignature",
+	64:  "MessageType_YoobaSignMessage",
+	65:  "MessageType_YoobaVerifyMessage",
+	66:  "MessageType_YoobaMessageSignature",
 	100: "MessageType_DebugLinkDecision",
 	101: "MessageType_DebugLinkGetState",
 	102: "MessageType_DebugLinkState",
@@ -168,79 +168,79 @@ var MessageType_name = map[int32]string{
 	113: "MessageType_DebugLinkFlashErase",
 }
 var MessageType_value = map[string]int32{
-	"MessageType_Initialize":               0,
-	"MessageType_Ping":                     1,
-	"MessageType_Success":                  2,
-	"MessageType_Failure":                  3,
-	"MessageType_ChangePin":                4,
-	"MessageType_WipeDevice":               5,
-	"MessageType_FirmwareErase":            6,
-	"MessageType_FirmwareUpload":           7,
-	"MessageType_FirmwareRequest":          8,
-	"MessageType_GetEntropy":               9,
-	"MessageType_Entropy":                  10,
-	"MessageType_GetPublicKey":             11,
-	"MessageType_PublicKey":                12,
-	"MessageType_LoadDevice":               13,
-	"MessageType_ResetDevice":              14,
-	"MessageType_SignTx":                   15,
-	"MessageType_SimpleSignTx":             16,
-	"MessageType_Features":                 17,
-	"MessageType_PinMatrixRequest":         18,
-	"MessageType_PinMatrixAck":             19,
-	"MessageType_Cancel":                   20,
-	"MessageType_TxRequest":                21,
-	"MessageType_TxAck":                    22,
-	"MessageType_CipherKeyValue":           23,
-	"MessageType_ClearSession":             24,
-	"MessageType_ApplySettings":            25,
-	"MessageType_ButtonRequest":            26,
-	"MessageType_ButtonAck":                27,
-	"MessageType_ApplyFlags":               28,
-	"MessageType_GetAddress":               29,
-	"MessageType_Address":                  30,
-	"MessageType_SelfTest":                 32,
-	"MessageType_BackupDevice":             34,
-	"MessageType_EntropyRequest":           35,
-	"MessageType_EntropyAck":               36,
-	"MessageType_SignMessage":              38,
-	"MessageType_VerifyMessage":            39,
-	"MessageType_MessageSignature":         40,
-	"MessageType_PassphraseRequest":        41,
-	"MessageType_PassphraseAck":            42,
-	"MessageType_EstimateTxSize":           43,
-	"MessageType_TxSize":                   44,
-	"MessageType_RecoveryDevice":           45,
-	"MessageType_WordRequest":              46,
-	"MessageType_WordAck":                  47,
-	"MessageType_CipheredKeyValue":         48,
-	"MessageType_EncryptMessage":           49,
-	"MessageType_EncryptedMessage":         50,
-	"MessageType_DecryptMessage":           51,
-	"MessageType_DecryptedMessage":         52,
-	"MessageType_SignIdentity":             53,
-	"MessageType_SignedIdentity":           54,
-	"MessageType_GetFeatures":              55,
-	"MessageType_EthereumGetAddress":       56,
-	"MessageType_EthereumAddress":          57,
-	"MessageType_EthereumSignTx":           58,
-	"MessageType_EthereumTxRequest":        59,
-	"MessageType_EthereumTxAck":            60,
-	"MessageType_GetECDHSessionKey":        61,
-	"MessageType_ECDHSessionKey":           62,
-	"MessageType_SetU2FCounter":            63,
-	"MessageType_EthereumSignMessage":      64,
-	"MessageType_EthereumVerifyMessage":    65,
-	"MessageType_EthereumMessageSignature": 66,
-	"MessageType_DebugLinkDecision":        100,
-	"MessageType_DebugLinkGetState":        101,
-	"MessageType_DebugLinkState":           102,
-	"MessageType_DebugLinkStop":            103,
-	"MessageType_DebugLinkLog":             104,
-	"MessageType_DebugLinkMemoryRead":      110,
-	"MessageType_DebugLinkMemory":          111,
-	"MessageType_DebugLinkMemoryWrite":     112,
-	"MessageType_DebugLinkFlashErase":      113,
+	"MessageType_Initialize":            0,
+	"MessageType_Ping":                  1,
+	"MessageType_Success":               2,
+	"MessageType_Failure":               3,
+	"MessageType_ChangePin":             4,
+	"MessageType_WipeDevice":            5,
+	"MessageType_FirmwareErase":         6,
+	"MessageType_FirmwareUpload":        7,
+	"MessageType_FirmwareRequest":       8,
+	"MessageType_GetEntropy":            9,
+	"MessageType_Entropy":               10,
+	"MessageType_GetPublicKey":          11,
+	"MessageType_PublicKey":             12,
+	"MessageType_LoadDevice":            13,
+	"MessageType_ResetDevice":           14,
+	"MessageType_SignTx":                15,
+	"MessageType_SimpleSignTx":          16,
+	"MessageType_Features":              17,
+	"MessageType_PinMatrixRequest":      18,
+	"MessageType_PinMatrixAck":          19,
+	"MessageType_Cancel":                20,
+	"MessageType_TxRequest":             21,
+	"MessageType_TxAck":                 22,
+	"MessageType_CipherKeyValue":        23,
+	"MessageType_ClearSession":          24,
+	"MessageType_ApplySettings":         25,
+	"MessageType_ButtonRequest":         26,
+	"MessageType_ButtonAck":             27,
+	"MessageType_ApplyFlags":            28,
+	"MessageType_GetAddress":            29,
+	"MessageType_Address":               30,
+	"MessageType_SelfTest":              32,
+	"MessageType_BackupDevice":          34,
+	"MessageType_EntropyRequest":        35,
+	"MessageType_EntropyAck":            36,
+	"MessageType_SignMessage":           38,
+	"MessageType_VerifyMessage":         39,
+	"MessageType_MessageSignature":      40,
+	"MessageType_PassphraseRequest":     41,
+	"MessageType_PassphraseAck":         42,
+	"MessageType_EstimateTxSize":        43,
+	"MessageType_TxSize":                44,
+	"MessageType_RecoveryDevice":        45,
+	"MessageType_WordRequest":           46,
+	"MessageType_WordAck":               47,
+	"MessageType_CipheredKeyValue":      48,
+	"MessageType_EncryptMessage":        49,
+	"MessageType_EncryptedMessage":      50,
+	"MessageType_DecryptMessage":        51,
+	"MessageType_DecryptedMessage":      52,
+	"MessageType_SignIdentity":          53,
+	"MessageType_SignedIdentity":        54,
+	"MessageType_GetFeatures":           55,
+	"MessageType_YoobaGetAddress":       56,
+	"MessageType_YoobaAddress":          57,
+	"MessageType_YoobaSignTx":           58,
+	"MessageType_YoobaTxRequest":        59,
+	"MessageType_YoobaTxAck":            60,
+	"MessageType_GetECDHSessionKey":     61,
+	"MessageType_ECDHSessionKey":        62,
+	"MessageType_SetU2FCounter":         63,
+	"MessageType_YoobaSignMessage":      64,
+	"MessageType_YoobaVerifyMessage":    65,
+	"MessageType_YoobaMessageSignature": 66,
+	"MessageType_DebugLinkDecision":     100,
+	"MessageType_DebugLinkGetState":     101,
+	"MessageType_DebugLinkState":        102,
+	"MessageType_DebugLinkStop":         103,
+	"MessageType_DebugLinkLog":          104,
+	"MessageType_DebugLinkMemoryRead":   110,
+	"MessageType_DebugLinkMemory":       111,
+	"MessageType_DebugLinkMemoryWrite":  112,
+	"MessageType_DebugLinkFlashErase":   113,
 }
 
 func (x MessageType) Enum() *MessageType {
@@ -2018,6 +2018,9 @@ type YoobaSignTx struct {
 	DataInitialChunk []byte   `protobuf:"bytes,7,opt,name=data_initial_chunk,json=dataInitialChunk" json:"data_initial_chunk,omitempty"`
 	DataLength       *uint32  `protobuf:"varint,8,opt,name=data_length,json=dataLength" json:"data_length,omitempty"`
 	ChainId          *uint32  `protobuf:"varint,9,opt,name=chain_id,json=chainId" json:"chain_id,omitempty"`
+	TxType           *uint32  `protobuf:"varint,10,opt,name=tx_type,json=txType" json:"tx_type,omitempty"`
+	FeePayer         []byte   `protobuf:"bytes,11,opt,name=fee_payer,json=feePayer" json:"fee_payer,omitempty"`
+	MultisigSender   []byte   `protobuf:"bytes,12,opt,name=multisig_sender,json=multisigSender" json:"multisig_sender,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
@@ -2089,6 +2092,27 @@ func (m *YoobaSignTx) GetChainId() uint32 {
 	return 0
 }
 
+func (m *YoobaSignTx) GetTxType() uint32 {
+	if m != nil && m.TxType != nil {
+		return *m.TxType
+	}
+	return 0
+}
+
+func (m *YoobaSignTx) GetFeePayer() []byte {
+	if m != nil {
+		return m.FeePayer
+	}
+	return nil
+}
+
+func (m *YoobaSignTx) GetMultisigSender() []byte {
+	if m != nil {
+		return m.MultisigSender
+	}
+	return nil
+}
+
 // *
 // Response: Device asks for more data from transaction payload, or returns the signature.
 // If data_length is set, device awaits that many more bytes of payload.
@@ -33,7 +33,6 @@ import (
 	"github.com/yooba-team/yooba/common/hexutil"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/log"
-	"github.com/yooba-team/yooba/rlp"
 )
 
 // ledgerOpcode is an enumeration encoding the supported Ledger opcodes.
@@ -297,6 +296,9 @@ func (w *ledgerDriver) ledgerDerive(derivationPath []uint32) (common.Address, er
 //   signature V | 1 byte
 //   signature R | 32 bytes
 //   signature S | 32 bytes
+//
+// The RLP transaction is the EIP-155 signing payload of types.EIP155Signer, so
+// the transaction type, fee payer and multisig sender are appended when set.
 func (w *ledgerDriver) ledgerSign(derivationPath []uint32, tx *types.Transaction, chainID *big.Int) (common.Address, *types.Transaction, error) {
 	// Flatten the derivation path into the Ledger request
 	path := make([]byte, 1+4*len(derivationPath))
@@ -304,19 +306,10 @@ func (w *ledgerDriver) ledgerSign(derivationPath []uint32, tx *types.Transaction
 	for i, component := range derivationPath {
 		binary.BigEndian.PutUint32(path[1+4*i:], component)
 	}
-	// Create the transaction RLP based on whether legacy or EIP155 signing was requeste
-	var (
-		txrlp []byte
-		err   error
-	)
-	if chainID == nil {
-		if txrlp, err = rlp.EncodeToBytes([]interface{}{tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data()}); err != nil {
-			return common.Address{}, nil, err
-		}
-	} else {
-		if txrlp, err = rlp.EncodeToBytes([]interface{}{tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), chainID, big.NewInt(0), big.NewInt(0)}); err != nil {
-			return common.Address{}, nil, err
-		}
+	// Create the transaction RLP, covering the Yooba specific fields too
+	txrlp, err := types.NewEIP155Signer(chainID).SigningPayload(tx)
+	if err != nil {
+		return common.Address{}, nil, err
 	}
 	payload := append(path, txrlp...)
 
@@ -345,20 +338,7 @@ func (w *ledgerDriver) ledgerSign(derivationPath []uint32, tx *types.Transaction
 		return common.Address{}, nil, errors.New("reply lacks signature")
 	}
 	signature := append(reply[1:], reply[0])
-
-	// Create the correct signer and signature transform based on the chain ID
-	var signer types.Signer
-	signer = types.NewEIP155Signer(chainID)
-	signature[64] = signature[64] - byte(chainID.Uint64()*2+35)
-	signed, err := tx.WithSignature(signer, signature)
-	if err != nil {
-		return common.Address{}, nil, err
-	}
-	sender, err := types.Sender(signer, signed)
-	if err != nil {
-		return common.Address{}, nil, err
-	}
-	return sender, signed, nil
+	return withDeviceSignature(tx, chainID, signature)
 }
 
 // ledgerExchange performs a data exchange with the Ledger wallet, sending it a
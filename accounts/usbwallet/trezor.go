@@ -196,6 +196,15 @@ func (w *trezorDriver) trezorSign(derivationPath []uint32, tx *types.Transaction
 		id := uint32(chainID.Int64())
 		request.ChainId = &id
 	}
+	if txType := uint32(tx.Type()); txType != types.TxTypeTransfer { // Yooba specific fields, only signed if set
+		request.TxType = &txType
+	}
+	if payer := tx.FeePayer(); payer != nil {
+		request.FeePayer = (*payer)[:]
+	}
+	if sender := tx.MultisigSender(); sender != nil {
+		request.MultisigSender = (*sender)[:]
+	}
 	// Send the initiation message and stream content until a signature is returned
 	response := new(trezor.YoobaTxRequest)
 	if _, err := w.trezorExchange(request, response); err != nil {
@@ -214,22 +223,7 @@ func (w *trezorDriver) trezorSign(derivationPath []uint32, tx *types.Transaction
 		return common.Address{}, nil, errors.New("reply lacks signature")
 	}
 	signature := append(append(response.GetSignatureR(), response.GetSignatureS()...), byte(response.GetSignatureV()))
-
-	// Create the correct signer and signature transform based on the chain ID
-	var signer types.Signer
-		signer = types.NewEIP155Signer(chainID)
-		signature[64] = signature[64] - byte(chainID.Uint64()*2+35)
-
-	// Inject the final signature into the transaction and sanity check the sender
-	signed, err := tx.WithSignature(signer, signature)
-	if err != nil {
-		return common.Address{}, nil, err
-	}
-	sender, err := types.Sender(signer, signed)
-	if err != nil {
-		return common.Address{}, nil, err
-	}
-	return sender, signed, nil
+	return withDeviceSignature(tx, chainID, signature)
 }
 
 // trezorExchange performs a data exchange with the Trezor wallet, sending it a
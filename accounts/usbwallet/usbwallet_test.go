@@ -0,0 +1,336 @@
+package usbwallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/karalabe/hid"
+	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/accounts/usbwallet/internal/trezor"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/rlp"
+)
+
+// emulatorKey returns the key an emulated device derives on a path.
+func emulatorKey(path []uint32) *ecdsa.PrivateKey {
+	blob := make([]byte, 4*len(path))
+	for i, component := range path {
+		binary.BigEndian.PutUint32(blob[4*i:], component)
+	}
+	key, _ := crypto.ToECDSA(crypto.Keccak256(blob))
+	return key
+}
+
+// emulatorSign signs a hash with an EIP-155 recovery id, the way hardware
+// wallets return transaction signatures.
+func emulatorSign(hash []byte, key *ecdsa.PrivateKey, chainID uint64) []byte {
+	sig, _ := crypto.Sign(hash, key)
+	sig[64] += byte(35 + 2*chainID)
+	return sig
+}
+
+// ledgerEmulator is a Ledger device speaking the APDU transport, signing with
+// deterministic keys instead of asking for user confirmation.
+type ledgerEmulator struct {
+	request []byte        // APDU currently being received
+	reply   *bytes.Buffer // Reply chunks to be read by the driver
+	sign    []byte        // Transaction signing payload received so far
+}
+
+func newLedgerEmulator() *ledgerEmulator {
+	return &ledgerEmulator{reply: new(bytes.Buffer)}
+}
+
+func (e *ledgerEmulator) Read(p []byte) (int, error) {
+	return e.reply.Read(p)
+}
+
+func (e *ledgerEmulator) Write(chunk []byte) (int, error) {
+	if binary.BigEndian.Uint16(chunk[3:5]) == 0 {
+		e.request = append([]byte{}, chunk[5:]...)
+	} else {
+		e.request = append(e.request, chunk[5:]...)
+	}
+	if size := int(binary.BigEndian.Uint16(e.request)); len(e.request) >= 2+size {
+		e.handle(e.request[2 : 2+size])
+	}
+	return len(chunk), nil
+}
+
+// handle processes a complete APDU and queues its reply.
+func (e *ledgerEmulator) handle(apdu []byte) {
+	op, p1, data := ledgerOpcode(apdu[1]), ledgerParam1(apdu[2]), apdu[5:5+int(apdu[4])]
+
+	var reply []byte
+	switch op {
+	case ledgerOpGetConfiguration:
+		reply = []byte{0x00, 1, 0, 3}
+
+	case ledgerOpRetrieveAddress:
+		key := emulatorKey(ledgerPath(data))
+		pubkey := crypto.FromECDSAPub(&key.PublicKey)
+		address := hex.EncodeToString(crypto.PubkeyToAddress(key.PublicKey).Bytes())
+
+		reply = append(append([]byte{byte(len(pubkey))}, pubkey...), byte(len(address)))
+		reply = append(reply, address...)
+
+	case ledgerOpSignTransaction:
+		if p1 == ledgerP1InitTransactionData {
+			e.sign = nil
+		}
+		e.sign = append(e.sign, data...)
+
+		// Sign once the whole transaction arrived
+		path := ledgerPath(e.sign)
+		payload := e.sign[1+4*len(path):]
+		if _, _, rest, err := rlp.Split(payload); err == nil && len(rest) == 0 {
+			var fields []rlp.RawValue
+			rlp.DecodeBytes(payload, &fields)
+
+			var chainID uint64
+			rlp.DecodeBytes(fields[6], &chainID)
+
+			sig := emulatorSign(crypto.Keccak256(payload), emulatorKey(path), chainID)
+			reply = append([]byte{sig[64]}, sig[:64]...)
+		}
+	}
+	reply = append(reply, 0x90, 0x00)
+
+	// Chunk up the reply into the transport framing
+	msg := make([]byte, 2, 2+len(reply))
+	binary.BigEndian.PutUint16(msg, uint16(len(reply)))
+	msg = append(msg, reply...)
+
+	for seq := 0; len(msg) > 0; seq++ {
+		chunk := make([]byte, 64)
+		copy(chunk, []byte{0x01, 0x01, 0x05})
+		binary.BigEndian.PutUint16(chunk[3:], uint16(seq))
+		msg = msg[copy(chunk[5:], msg):]
+		e.reply.Write(chunk)
+	}
+}
+
+// ledgerPath parses the derivation path in front of Ledger request data.
+func ledgerPath(data []byte) []uint32 {
+	path := make([]uint32, data[0])
+	for i := range path {
+		path[i] = binary.BigEndian.Uint32(data[1+4*i:])
+	}
+	return path
+}
+
+// trezorEmulator is a Trezor device speaking the protobuf transport, signing
+// with deterministic keys instead of asking for user confirmation.
+type trezorEmulator struct {
+	request []byte        // Message currently being received
+	reply   *bytes.Buffer // Reply chunks to be read by the driver
+	sign    *trezor.YoobaSignTx
+	data    []byte // Transaction payload received so far
+}
+
+func newTrezorEmulator() *trezorEmulator {
+	return &trezorEmulator{reply: new(bytes.Buffer)}
+}
+
+func (e *trezorEmulator) Read(p []byte) (int, error) {
+	return e.reply.Read(p)
+}
+
+func (e *trezorEmulator) Write(chunk []byte) (int, error) {
+	if e.request == nil {
+		e.request = append([]byte{}, chunk[1:]...)
+	} else {
+		e.request = append(e.request, chunk[1:]...)
+	}
+	if size := int(binary.BigEndian.Uint32(e.request[4:8])); len(e.request) >= 8+size {
+		e.handle(binary.BigEndian.Uint16(e.request[2:4]), e.request[8:8+size])
+		e.request = nil
+	}
+	return len(chunk), nil
+}
+
+// handle processes a complete message and queues its reply.
+func (e *trezorEmulator) handle(kind uint16, data []byte) {
+	var reply proto.Message
+	switch trezor.MessageType(kind) {
+	case trezor.MessageType_MessageType_Initialize:
+		major, minor, patch, label := uint32(1), uint32(5), uint32(2), "emulator"
+		reply = &trezor.Features{MajorVersion: &major, MinorVersion: &minor, PatchVersion: &patch, Label: &label}
+
+	case trezor.MessageType_MessageType_Ping:
+		reply = new(trezor.Success)
+
+	case trezor.MessageType_MessageType_YoobaGetAddress:
+		req := new(trezor.YoobaGetAddress)
+		proto.Unmarshal(data, req)
+		key := emulatorKey(req.AddressN)
+		reply = &trezor.YoobaAddress{Address: crypto.PubkeyToAddress(key.PublicKey).Bytes()}
+
+	case trezor.MessageType_MessageType_YoobaSignTx:
+		e.sign = new(trezor.YoobaSignTx)
+		proto.Unmarshal(data, e.sign)
+		e.data = e.sign.DataInitialChunk
+		reply = e.signTx()
+
+	case trezor.MessageType_MessageType_YoobaTxAck:
+		ack := new(trezor.YoobaTxAck)
+		proto.Unmarshal(data, ack)
+		e.data = append(e.data, ack.DataChunk...)
+		reply = e.signTx()
+	}
+	blob, _ := proto.Marshal(reply)
+
+	// Chunk up the reply into the transport framing
+	msg := make([]byte, 8, 8+len(blob))
+	copy(msg, []byte{0x23, 0x23})
+	binary.BigEndian.PutUint16(msg[2:], trezor.Type(reply))
+	binary.BigEndian.PutUint32(msg[4:], uint32(len(blob)))
+	msg = append(msg, blob...)
+
+	for len(msg) > 0 {
+		chunk := make([]byte, 64)
+		chunk[0] = 0x3f
+		msg = msg[copy(chunk[1:], msg):]
+		e.reply.Write(chunk)
+	}
+}
+
+// signTx requests the rest of the transaction payload, or signs the transaction
+// reassembled from the request fields if all of it arrived.
+func (e *trezorEmulator) signTx() *trezor.YoobaTxRequest {
+	if left := int(e.sign.GetDataLength()) - len(e.data); left > 0 {
+		if left > 1024 {
+			left = 1024
+		}
+		length := uint32(left)
+		return &trezor.YoobaTxRequest{DataLength: &length}
+	}
+	var (
+		nonce = new(big.Int).SetBytes(e.sign.Nonce).Uint64()
+		price = new(big.Int).SetBytes(e.sign.GasPrice)
+		gas   = new(big.Int).SetBytes(e.sign.GasLimit).Uint64()
+		value = new(big.Int).SetBytes(e.sign.Value)
+	)
+	var tx *types.Transaction
+	if e.sign.To == nil {
+		tx = types.NewContractCreation(nonce, value, gas, price, e.data)
+	} else {
+		tx = types.NewTransaction(nonce, common.BytesToAddress(e.sign.To), value, gas, price, uint(e.sign.GetTxType()), e.data)
+	}
+	if e.sign.FeePayer != nil {
+		tx = tx.WithFeePayer(common.BytesToAddress(e.sign.FeePayer))
+	}
+	if e.sign.MultisigSender != nil {
+		tx = tx.WithMultisigSender(common.BytesToAddress(e.sign.MultisigSender))
+	}
+	chainID := uint64(e.sign.GetChainId())
+	hash := types.NewEIP155Signer(new(big.Int).SetUint64(chainID)).Hash(tx)
+
+	sig := emulatorSign(hash[:], emulatorKey(e.sign.AddressN), chainID)
+	v := uint32(sig[64])
+	return &trezor.YoobaTxRequest{SignatureV: &v, SignatureR: sig[:32], SignatureS: sig[32:64]}
+}
+
+// signingTests are transactions exercising the Yooba specific signing fields.
+func signingTests() map[string]*types.Transaction {
+	var (
+		to      = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+		payer   = common.HexToAddress("0xfeefeefeefeefeefeefeefeefeefeefeefeefee")
+		account = common.HexToAddress("0x5151515151515151515151515151515151515151")
+	)
+	return map[string]*types.Transaction{
+		"transfer":  types.NewTransaction(1, to, big.NewInt(1000), 21000, big.NewInt(1), types.TxTypeTransfer, nil),
+		"typed":     types.NewTransaction(2, to, big.NewInt(0), 50000, big.NewInt(1), types.TxTypeVote, []byte{0x01, 0x02}),
+		"creation":  types.NewContractCreation(3, big.NewInt(0), 1000000, big.NewInt(1), bytes.Repeat([]byte{0xfe}, 3000)),
+		"sponsored": types.NewTransaction(4, to, big.NewInt(1), 21000, big.NewInt(1), types.TxTypeGoods, nil).WithFeePayer(payer),
+		"multisig":  types.NewTransaction(5, to, big.NewInt(1), 21000, big.NewInt(1), types.TxTypeTransfer, nil).WithMultisigSender(account),
+	}
+}
+
+// testDeviceSigning checks that a driver signs the signing tests such that the
+// signatures verify against the transaction hashing of the types package.
+func testDeviceSigning(t *testing.T, driver driver) {
+	chainID := big.NewInt(1919)
+	signer := types.NewEIP155Signer(chainID)
+
+	path := accounts.DefaultBaseDerivationPath
+	address, err := driver.Derive(path)
+	if err != nil {
+		t.Fatalf("failed to derive address: %v", err)
+	}
+	if want := crypto.PubkeyToAddress(emulatorKey(path).PublicKey); address != want {
+		t.Fatalf("address mismatch: have %x, want %x", address, want)
+	}
+	for name, tx := range signingTests() {
+		sender, signed, err := driver.SignTx(path, tx, chainID)
+		if err != nil {
+			t.Errorf("%s: failed to sign: %v", name, err)
+			continue
+		}
+		if sender != address {
+			t.Errorf("%s: signer mismatch: have %x, want %x", name, sender, address)
+		}
+		if signed.Type() != tx.Type() {
+			t.Errorf("%s: type mismatch: have %d, want %d", name, signed.Type(), tx.Type())
+		}
+		if tx.MultisigSender() != nil {
+			signers, err := types.MultisigSigners(signer, signed)
+			if err != nil {
+				t.Errorf("%s: invalid multisig signature: %v", name, err)
+				continue
+			}
+			if len(signers) != 1 || !bytes.Equal(signers[0], crypto.CompressPubkey(&emulatorKey(path).PublicKey)) {
+				t.Errorf("%s: multisig signer mismatch: have %x", name, signers)
+			}
+			continue
+		}
+		if from, err := types.Sender(signer, signed); err != nil || from != address {
+			t.Errorf("%s: recovered sender mismatch: have %x (%v), want %x", name, from, err, address)
+		}
+	}
+}
+
+// Tests that Ledger wallets sign Yooba transactions verifiable by the node.
+func TestLedgerSignTx(t *testing.T) {
+	driver := newLedgerDriver(log.New())
+	if err := driver.Open(newLedgerEmulator(), ""); err != nil {
+		t.Fatalf("failed to open ledger: %v", err)
+	}
+	if status, err := driver.Status(); err != nil || status != "Ethereum app v1.0.3 online" {
+		t.Fatalf("status mismatch: have %q (%v)", status, err)
+	}
+	testDeviceSigning(t, driver)
+}
+
+// Tests that Trezor wallets sign Yooba transactions verifiable by the node.
+func TestTrezorSignTx(t *testing.T) {
+	driver := newTrezorDriver(log.New())
+	if err := driver.Open(newTrezorEmulator(), ""); err != nil {
+		t.Fatalf("failed to open trezor: %v", err)
+	}
+	testDeviceSigning(t, driver)
+}
+
+// Tests that transactions without a chain ID are refused before reaching the
+// device, as it could only sign them in a format the node doesn't verify.
+func TestSignTxWithoutChainID(t *testing.T) {
+	path := accounts.DefaultBaseDerivationPath
+	account := accounts.Account{Address: crypto.PubkeyToAddress(emulatorKey(path).PublicKey)}
+
+	w := &wallet{
+		device: new(hid.Device),
+		paths:  map[common.Address]accounts.DerivationPath{account.Address: path},
+	}
+	tx := types.NewTransaction(1, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), types.TxTypeTransfer, nil)
+	if _, err := w.SignTx(account, tx, nil); err != errNoChainID {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNoChainID)
+	}
+}
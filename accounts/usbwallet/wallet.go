@@ -19,6 +19,7 @@ package usbwallet
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -29,6 +30,7 @@ import (
 	"github.com/yooba-team/yooba/accounts"
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
 	"github.com/yooba-team/yooba/log"
 	"github.com/karalabe/hid"
 )
@@ -36,6 +38,10 @@ import (
 // Maximum time between wallet health checks to detect USB unplugs.
 const heartbeatCycle = time.Second
 
+// errNoChainID is returned when a transaction is to be signed without a chain
+// ID. Hardware wallets only sign EIP-155 transactions, like the node expects.
+var errNoChainID = errors.New("chain ID required for hardware wallet signing")
+
 // Minimum time to wait between self derivation attempts, even it the user is
 // requesting accounts like crazy.
 const selfDeriveThrottling = time.Second
@@ -521,6 +527,10 @@ func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID
 	if !ok {
 		return nil, accounts.ErrUnknownAccount
 	}
+	// Devices only sign the EIP-155 payload, which needs a chain ID
+	if chainID == nil {
+		return nil, errNoChainID
+	}
 	// All infos gathered and metadata checks out, request signing
 	<-w.commsLock
 	defer func() { w.commsLock <- struct{}{} }()
@@ -560,3 +570,36 @@ func (w *wallet) SignHashWithPassphrase(account accounts.Account, passphrase str
 func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
 	return w.SignTx(account, tx, chainID)
 }
+
+// withDeviceSignature injects a signature made by a hardware wallet over the
+// EIP-155 signing payload of tx, returning the address of the signing key along
+// with the signed transaction. The signature is in the [R || S || V] format with
+// V as returned by the device, offset by the chain ID, which must not be nil.
+// Transactions sent from a multi-signature account collect it among the
+// signatures of the key holders.
+func withDeviceSignature(tx *types.Transaction, chainID *big.Int, sig []byte) (common.Address, *types.Transaction, error) {
+	sig[64] -= byte(chainID.Uint64()*2 + 35)
+
+	signer := types.NewEIP155Signer(chainID)
+	if tx.MultisigSender() != nil {
+		hash := signer.Hash(tx)
+		pub, err := crypto.SigToPub(hash[:], sig)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		signed, err := tx.WithMultisigSignature(sig)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		return crypto.PubkeyToAddress(*pub), signed, nil
+	}
+	signed, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return sender, signed, nil
+}
@@ -0,0 +1,401 @@
+// clef is a standalone signer daemon, holding the keys of a Yooba node apart
+// from it and approving its signing requests manually or through a rule set.
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/yooba-team/yooba/cmd/utils"
+	"github.com/yooba-team/yooba/console"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/node"
+	"github.com/yooba-team/yooba/rpc"
+	"github.com/yooba-team/yooba/signer/core"
+	"github.com/yooba-team/yooba/signer/rules"
+	"github.com/yooba-team/yooba/signer/storage"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+// Parameters of the derivation of the credential storage keys from the master
+// password, salted with the name of the storage.
+const (
+	scryptN      = 1 << 18
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+var (
+	logLevelFlag = cli.IntFlag{
+		Name:  "loglevel",
+		Value: int(log.LvlInfo),
+		Usage: "log level to emit to the screen",
+	}
+	keystoreFlag = cli.StringFlag{
+		Name:  "keystore",
+		Value: filepath.Join(node.DefaultDataDir(), "keystore"),
+		Usage: "Directory for the keystore",
+	}
+	configdirFlag = cli.StringFlag{
+		Name:  "configdir",
+		Value: defaultConfigDir(),
+		Usage: "Directory for clef configuration, credentials and the IPC endpoint",
+	}
+	chainIdFlag = cli.Int64Flag{
+		Name:  "chainid",
+		Value: 1,
+		Usage: "Chain id to use for signing",
+	}
+	rpcPortFlag = cli.IntFlag{
+		Name:  "rpcport",
+		Value: 8550,
+		Usage: "HTTP-RPC server listening port",
+	}
+	signerSecretFlag = cli.StringFlag{
+		Name:  "signersecret",
+		Usage: "A file containing the master password, prompted for if not given",
+	}
+	customDBFlag = cli.StringFlag{
+		Name:  "4bytedb-custom",
+		Value: "./4byte-custom.json",
+		Usage: "File used for writing new 4byte-identifiers submitted via API",
+	}
+	auditLogFlag = cli.StringFlag{
+		Name:  "auditlog",
+		Value: "audit.log",
+		Usage: "File used to emit audit logs. Set to \"\" to disable",
+	}
+	ruleFlag = cli.StringFlag{
+		Name:  "rules",
+		Usage: "Enable rule-engine, approving requests automatically through the given javascript file",
+	}
+	stdiouiFlag = cli.BoolFlag{
+		Name: "stdio-ui",
+		Usage: "Use STDIN/STDOUT as a channel for an external UI. " +
+			"This means that an STDIN/STDOUT is used for RPC-communication with a e.g. a graphical user " +
+			"interface, and can be used when clef is started by an external process.",
+	}
+	dBFlag = cli.StringFlag{
+		Name:  "4bytedb",
+		Usage: "File containing 4byte-identifiers",
+		Value: "./4byte.json",
+	}
+
+	app = utils.NewApp(gitCommit, "Manage Yooba account operations")
+
+	setCredentialCommand = cli.Command{
+		Action:    utils.MigrateFlags(setCredential),
+		Name:      "setpw",
+		Usage:     "Store a credential for a keystore file",
+		ArgsUsage: "<address>",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			configdirFlag,
+			signerSecretFlag,
+		},
+		Description: `
+The setpw command stores a password for a given address (keyfile), which is
+used by the rule engine to sign approved requests without prompting.`,
+	}
+	attestCommand = cli.Command{
+		Action:    utils.MigrateFlags(attestFile),
+		Name:      "attest",
+		Usage:     "Attest that a js-file is to be used",
+		ArgsUsage: "<sha256sum>",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			configdirFlag,
+			signerSecretFlag,
+		},
+		Description: `
+The attest command stores the sha256 of the rule.js-file that you want to use
+for automatic processing of requests. Rule files whose hash was not attested
+are refused at startup.`,
+	}
+)
+
+func init() {
+	app.Name = "Clef"
+	app.Flags = []cli.Flag{
+		logLevelFlag,
+		keystoreFlag,
+		configdirFlag,
+		chainIdFlag,
+		utils.LightKDFFlag,
+		utils.NoUSBFlag,
+		utils.RPCListenAddrFlag,
+		utils.RPCVirtualHostsFlag,
+		utils.IPCDisabledFlag,
+		utils.IPCPathFlag,
+		utils.RPCEnabledFlag,
+		rpcPortFlag,
+		signerSecretFlag,
+		dBFlag,
+		customDBFlag,
+		auditLogFlag,
+		ruleFlag,
+		stdiouiFlag,
+	}
+	app.Action = signer
+	app.Commands = []cli.Command{setCredentialCommand, attestCommand}
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// setCredential stores the password of an account in the encrypted credential
+// storage.
+func setCredential(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an address to be passed as an argument")
+	}
+	if err := initialize(ctx); err != nil {
+		return err
+	}
+	address := ctx.Args().First()
+	password, err := console.Stdin.PromptPassword("Enter a password to store with this address: ")
+	if err != nil {
+		utils.Fatalf("Failed to read password: %v", err)
+	}
+	secret, err := readMasterSecret(ctx)
+	if err != nil {
+		utils.Fatalf(err.Error())
+	}
+	credentials, err := openStorage(ctx, secret, "credentials")
+	if err != nil {
+		utils.Fatalf(err.Error())
+	}
+	credentials.Put(strings.ToLower(address), password)
+	log.Info("Credential store updated", "key", address)
+	return nil
+}
+
+// attestFile records the hash of a rule file as trusted.
+func attestFile(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	if err := initialize(ctx); err != nil {
+		return err
+	}
+	secret, err := readMasterSecret(ctx)
+	if err != nil {
+		utils.Fatalf(err.Error())
+	}
+	config, err := openStorage(ctx, secret, "config")
+	if err != nil {
+		utils.Fatalf(err.Error())
+	}
+	val := ctx.Args().First()
+	config.Put("ruleset_sha256", val)
+	log.Info("Ruleset attestation updated", "sha256", val)
+	return nil
+}
+
+// initialize sets up the logging and the configuration directory of clef.
+func initialize(c *cli.Context) error {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(true)))
+	glogger.Verbosity(log.Lvl(c.Int(logLevelFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	return os.MkdirAll(c.String(configdirFlag.Name), 0700)
+}
+
+// signer runs the signer daemon until interrupted.
+func signer(c *cli.Context) error {
+	if err := initialize(c); err != nil {
+		return err
+	}
+	var (
+		ui core.SignerUI
+	)
+	if c.Bool(stdiouiFlag.Name) {
+		log.Info("Using stdin/stdout as UI-channel")
+		ui = core.NewStdIOUI()
+	} else {
+		log.Info("Using CLI as UI-channel")
+		ui = core.NewCommandlineUI()
+	}
+	db, err := core.NewAbiDBFromFiles(c.String(dBFlag.Name), c.String(customDBFlag.Name))
+	if err != nil {
+		utils.Fatalf(err.Error())
+	}
+	log.Info("Loaded 4byte db", "signatures", db.Size(), "file", c.String(dBFlag.Name))
+
+	// Load the rule set and wrap the UI into the rule engine, which only falls
+	// back to asking the user for requests the rules don't decide
+	if ruleFile := c.String(ruleFlag.Name); ruleFile != "" {
+		ruleJS, err := ioutil.ReadFile(ruleFile)
+		if err != nil {
+			utils.Fatalf("Could not read rules file: %v", err)
+		}
+		secret, err := readMasterSecret(c)
+		if err != nil {
+			utils.Fatalf(err.Error())
+		}
+		config, err := openStorage(c, secret, "config")
+		if err != nil {
+			utils.Fatalf(err.Error())
+		}
+		shasum := fmt.Sprintf("%x", sha256.Sum256(ruleJS))
+		if attested := config.Get("ruleset_sha256"); attested != shasum {
+			utils.Fatalf("Rule file %s (sha256 %s) is not attested, use 'clef attest %s' first", ruleFile, shasum, shasum)
+		}
+		jsStorage, err := openStorage(c, secret, "jsstorage")
+		if err != nil {
+			utils.Fatalf(err.Error())
+		}
+		credentials, err := openStorage(c, secret, "credentials")
+		if err != nil {
+			utils.Fatalf(err.Error())
+		}
+		ruleEngine, err := rules.NewRuleEvaluator(ui, jsStorage, credentials)
+		if err != nil {
+			utils.Fatalf(err.Error())
+		}
+		if err := ruleEngine.Init(string(ruleJS)); err != nil {
+			utils.Fatalf(err.Error())
+		}
+		ui = ruleEngine
+		log.Info("Rule engine configured", "file", ruleFile)
+	}
+	apiImpl := core.NewSignerAPI(
+		c.Int64(chainIdFlag.Name),
+		c.String(keystoreFlag.Name),
+		c.Bool(utils.NoUSBFlag.Name),
+		ui, db,
+		c.Bool(utils.LightKDFFlag.Name))
+
+	api := core.ExternalAPI(apiImpl)
+	if logfile := c.String(auditLogFlag.Name); logfile != "" {
+		// Log every request and its outcome to the audit log
+		if api, err = core.NewAuditLogger(logfile, api); err != nil {
+			utils.Fatalf(err.Error())
+		}
+		log.Info("Audit logs configured", "file", logfile)
+	}
+	// Register the signer API and start serving it
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", api); err != nil {
+		utils.Fatalf("Could not register API: %v", err)
+	}
+	var (
+		extapiURL = "n/a"
+		ipcapiURL = "n/a"
+	)
+	if c.Bool(utils.RPCEnabledFlag.Name) {
+		vhosts := splitAndTrim(c.GlobalString(utils.RPCVirtualHostsFlag.Name))
+		endpoint := fmt.Sprintf("%s:%d", c.String(utils.RPCListenAddrFlag.Name), c.Int(rpcPortFlag.Name))
+
+		listener, err := net.Listen("tcp", endpoint)
+		if err != nil {
+			utils.Fatalf("Could not start HTTP listener: %v", err)
+		}
+		go rpc.NewHTTPServer([]string{"*"}, vhosts, server).Serve(listener)
+
+		extapiURL = fmt.Sprintf("http://%s", endpoint)
+		log.Info("HTTP endpoint opened", "url", extapiURL)
+
+		defer func() {
+			listener.Close()
+			log.Info("HTTP endpoint closed", "url", extapiURL)
+		}()
+	}
+	if !c.Bool(utils.IPCDisabledFlag.Name) {
+		ipcapiURL = filepath.Join(c.String(configdirFlag.Name), "clef.ipc")
+		if c.IsSet(utils.IPCPathFlag.Name) {
+			ipcapiURL = c.String(utils.IPCPathFlag.Name)
+		}
+		listener, err := rpc.CreateIPCListener(ipcapiURL)
+		if err != nil {
+			utils.Fatalf("Could not start IPC listener: %v", err)
+		}
+		go server.ServeListener(listener)
+
+		log.Info("IPC endpoint opened", "url", ipcapiURL)
+
+		defer func() {
+			listener.Close()
+			log.Info("IPC endpoint closed", "url", ipcapiURL)
+		}()
+	}
+	ui.OnSignerStartup(core.StartupInfo{
+		Info: map[string]interface{}{
+			"chainid":     c.Int64(chainIdFlag.Name),
+			"keystore":    c.String(keystoreFlag.Name),
+			"extapi_http": extapiURL,
+			"extapi_ipc":  ipcapiURL,
+		},
+	})
+	abortChan := make(chan os.Signal, 1)
+	signal.Notify(abortChan, os.Interrupt)
+
+	sig := <-abortChan
+	log.Info("Exiting...", "signal", sig)
+
+	return nil
+}
+
+// readMasterSecret reads the master password, either from the file given on the
+// command line or by prompting the user.
+func readMasterSecret(c *cli.Context) ([]byte, error) {
+	if file := c.String(signerSecretFlag.Name); file != "" {
+		secret, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master secret: %v", err)
+		}
+		return []byte(strings.TrimRight(string(secret), "\r\n")), nil
+	}
+	secret, err := console.Stdin.PromptPassword("Master password: ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master secret: %v", err)
+	}
+	if len(secret) < 10 {
+		return nil, fmt.Errorf("master password must be at least 10 characters")
+	}
+	return []byte(secret), nil
+}
+
+// openStorage opens the named encrypted storage in the configuration directory,
+// keyed by the master secret.
+func openStorage(c *cli.Context, secret []byte, name string) (storage.Storage, error) {
+	key, err := scrypt.Key(secret, []byte(name), scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(c.String(configdirFlag.Name), name+".json")
+	return storage.NewAESEncryptedStorage(path, key), nil
+}
+
+// splitAndTrim splits input separated by a comma and trims excessive white
+// space from the substrings.
+func splitAndTrim(input string) []string {
+	result := strings.Split(input, ",")
+	for i, r := range result {
+		result[i] = strings.TrimSpace(r)
+	}
+	return result
+}
+
+// defaultConfigDir returns the default directory of the clef configuration.
+func defaultConfigDir() string {
+	if datadir := node.DefaultDataDir(); datadir != "" {
+		return filepath.Join(filepath.Dir(datadir), "Signer")
+	}
+	return ""
+}
@@ -27,15 +27,18 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/yooba-team/yooba/accounts"
 	"github.com/yooba-team/yooba/accounts/keystore"
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/fdlimit"
 	"github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/rawdb"
 	"github.com/yooba-team/yooba/core/state"
 	"github.com/yooba-team/yooba/core/vm"
 	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/graphql"
 	"github.com/yooba-team/yooba/yoo"
 	"github.com/yooba-team/yooba/yoo/downloader"
 	"github.com/yooba-team/yooba/yoo/gasprice"
@@ -114,6 +117,19 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{node.DefaultDataDir()},
 	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Data directory for the ancient blocks (default = inside the chaindata)",
+	}
+	AncientThresholdFlag = cli.Uint64Flag{
+		Name:  "ancient.threshold",
+		Usage: "Number of recent blocks kept in the chain database, older ones are moved to the ancient store",
+		Value: yoo.DefaultConfig.FreezerThreshold,
+	}
+	DatabaseEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: `Backing database implementation ("leveldb" or "bolt"), defaults to the one of an existing database`,
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -122,6 +138,10 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer (url or path to ipc file) to delegate block and transaction signing to",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby)",
@@ -163,7 +183,7 @@ var (
 	defaultSyncMode = yoo.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "snap")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -171,6 +191,10 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	SnapshotFlag = cli.BoolTFlag{
+		Name:  "snapshot",
+		Usage: `Enables the flat state snapshot for faster state reads (use "--snapshot=false" to disable)`,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -307,6 +331,11 @@ var (
 		Usage: "Password file to use for non-interactive password input",
 		Value: "",
 	}
+	MnemonicPassphraseFlag = cli.StringFlag{
+		Name:  "mnemonic.passphrase",
+		Usage: "File containing the optional BIP-39 passphrase protecting the mnemonic",
+		Value: "",
+	}
 
 	VMEnableDebugFlag = cli.BoolFlag{
 		Name:  "vmdebug",
@@ -321,6 +350,21 @@ var (
 		Name:  metrics.MetricsEnabledFlag,
 		Usage: "Enable metrics collection and reporting",
 	}
+	MetricsAddrFlag = cli.StringFlag{
+		Name:  "metrics.addr",
+		Usage: "Prometheus metrics HTTP server listening interface (metrics endpoint disabled if empty)",
+		Value: "",
+	}
+	MetricsPortFlag = cli.IntFlag{
+		Name:  "metrics.port",
+		Usage: "Prometheus metrics HTTP server listening port",
+		Value: node.DefaultMetricsPort,
+	}
+	MetricsLabelsFlag = cli.StringFlag{
+		Name:  "metrics.labels",
+		Usage: "Comma separated key=value labels added to all served metrics",
+		Value: "",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -359,6 +403,59 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL server",
+	}
+	GraphQLListenAddrFlag = cli.StringFlag{
+		Name:  "graphql.addr",
+		Usage: "GraphQL server listening interface",
+		Value: node.DefaultGraphQLHost,
+	}
+	GraphQLPortFlag = cli.IntFlag{
+		Name:  "graphql.port",
+		Usage: "GraphQL server listening port",
+		Value: node.DefaultGraphQLPort,
+	}
+	GraphQLCORSDomainFlag = cli.StringFlag{
+		Name:  "graphql.corsdomain",
+		Usage: "Comma separated list of domains from which to accept cross origin requests (browser enforced)",
+		Value: "",
+	}
+	GraphQLVirtualHostsFlag = cli.StringFlag{
+		Name:  "graphql.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
+		Value: "localhost",
+	}
+	JWTSecretFlag = cli.StringFlag{
+		Name:  "jwtsecret",
+		Usage: "Path to a hex encoded HS256 secret authenticating HTTP-RPC, WS-RPC and GraphQL requests with JSON web tokens",
+		Value: "",
+	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpc.batchlimit",
+		Usage: "Maximum number of requests in an RPC batch (0 = unlimited)",
+	}
+	RPCResponseLimitFlag = cli.IntFlag{
+		Name:  "rpc.responselimit",
+		Usage: "Maximum size in bytes of an RPC response, in total for batches (0 = unlimited)",
+	}
+	RPCConcurrencyLimitFlag = cli.IntFlag{
+		Name:  "rpc.concurrencylimit",
+		Usage: "Maximum number of RPC requests executed concurrently per IPC or WS connection (0 = unlimited)",
+	}
+	RPCSubscriptionLimitFlag = cli.IntFlag{
+		Name:  "rpc.subscriptionlimit",
+		Usage: "Maximum number of subscriptions per IPC or WS connection (0 = unlimited)",
+	}
+	RPCTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.timeout",
+		Usage: "Maximum execution time of RPC method calls (0 = unlimited)",
+	}
+	RPCMethodTimeoutsFlag = cli.StringFlag{
+		Name:  "rpc.methodtimeouts",
+		Usage: "Comma separated execution time limits of specific RPC methods (e.g. yoo_getLogs=10s)",
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -651,6 +748,24 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	cfg.HTTPVirtualHosts = splitAndTrim(ctx.GlobalString(RPCVirtualHostsFlag.Name))
 }
 
+// setGraphQL creates the GraphQL listener interface string from the set
+// command line flags, returning empty if the GraphQL endpoint is disabled.
+func setGraphQL(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(GraphQLEnabledFlag.Name) && cfg.GraphQLHost == "" {
+		cfg.GraphQLHost = "127.0.0.1"
+		if ctx.GlobalIsSet(GraphQLListenAddrFlag.Name) {
+			cfg.GraphQLHost = ctx.GlobalString(GraphQLListenAddrFlag.Name)
+		}
+	}
+	if ctx.GlobalIsSet(GraphQLPortFlag.Name) {
+		cfg.GraphQLPort = ctx.GlobalInt(GraphQLPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLCORSDomainFlag.Name) {
+		cfg.GraphQLCors = splitAndTrim(ctx.GlobalString(GraphQLCORSDomainFlag.Name))
+	}
+	cfg.GraphQLVirtualHosts = splitAndTrim(ctx.GlobalString(GraphQLVirtualHostsFlag.Name))
+}
+
 // setWS creates the WebSocket RPC listener interface string from the set
 // command line flags, returning empty if the HTTP endpoint is disabled.
 func setWS(ctx *cli.Context, cfg *node.Config) {
@@ -672,6 +787,69 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setMetrics creates the Prometheus metrics endpoint configuration from the set
+// command line flags.
+func setMetrics(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(MetricsAddrFlag.Name) {
+		cfg.MetricsHost = ctx.GlobalString(MetricsAddrFlag.Name)
+		if cfg.MetricsHost != "" && !metrics.Enabled {
+			log.Warn("Metrics endpoint enabled without metrics collection", "flag", MetricsEnabledFlag.Name)
+		}
+	}
+	if ctx.GlobalIsSet(MetricsPortFlag.Name) {
+		cfg.MetricsPort = ctx.GlobalInt(MetricsPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsLabelsFlag.Name) {
+		cfg.MetricsLabels = make(map[string]string)
+		for _, label := range splitAndTrim(ctx.GlobalString(MetricsLabelsFlag.Name)) {
+			if label == "" {
+				continue
+			}
+			parts := strings.SplitN(label, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				Fatalf("Invalid metrics label %q, expected key=value", label)
+			}
+			cfg.MetricsLabels[parts[0]] = parts[1]
+		}
+	}
+}
+
+// setRPCLimits creates the RPC resource limits from the set command line flags.
+func setRPCLimits(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCBatchLimitFlag.Name) {
+		cfg.RPCLimits.BatchItems = ctx.GlobalInt(RPCBatchLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCResponseLimitFlag.Name) {
+		cfg.RPCLimits.ResponseSize = ctx.GlobalInt(RPCResponseLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCConcurrencyLimitFlag.Name) {
+		cfg.RPCLimits.ConcurrentRequests = ctx.GlobalInt(RPCConcurrencyLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCSubscriptionLimitFlag.Name) {
+		cfg.RPCLimits.Subscriptions = ctx.GlobalInt(RPCSubscriptionLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCTimeoutFlag.Name) {
+		cfg.RPCLimits.ExecutionTimeout = ctx.GlobalDuration(RPCTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCMethodTimeoutsFlag.Name) {
+		cfg.RPCLimits.MethodTimeouts = make(map[string]time.Duration)
+		for _, limit := range splitAndTrim(ctx.GlobalString(RPCMethodTimeoutsFlag.Name)) {
+			if limit == "" {
+				continue
+			}
+			parts := strings.SplitN(limit, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				Fatalf("Invalid RPC method timeout %q, expected method=duration", limit)
+			}
+			timeout, err := time.ParseDuration(parts[1])
+			if err != nil {
+				Fatalf("Invalid RPC method timeout %q: %v", limit, err)
+			}
+			cfg.RPCLimits.MethodTimeouts[parts[0]] = timeout
+		}
+	}
+}
+
 // setIPC creates an IPC path configuration from the set command line flags,
 // returning an empty string if IPC was explicitly disabled, or the set path.
 func setIPC(ctx *cli.Context, cfg *node.Config) {
@@ -827,6 +1005,12 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
 	setWS(ctx, cfg)
+	setGraphQL(ctx, cfg)
+	setRPCLimits(ctx, cfg)
+	setMetrics(ctx, cfg)
+	if ctx.GlobalIsSet(JWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(JWTSecretFlag.Name)
+	}
 	setNodeUserIdent(ctx, cfg)
 
 	switch {
@@ -840,6 +1024,14 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.DataDir = filepath.Join(node.DefaultDataDir(), "rinkeby")
 	}
 
+	if ctx.GlobalIsSet(DatabaseEngineFlag.Name) {
+		switch engine := ctx.GlobalString(DatabaseEngineFlag.Name); engine {
+		case yoobadb.EngineLevelDB, yoobadb.EngineBolt:
+			cfg.DatabaseEngine = engine
+		default:
+			Fatalf("--%s must be either %q or %q", DatabaseEngineFlag.Name, yoobadb.EngineLevelDB, yoobadb.EngineBolt)
+		}
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -849,6 +1041,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -982,6 +1177,14 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *yoo.Config) {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	cfg.Snapshot = ctx.GlobalBoolT(SnapshotFlag.Name)
+
+	if ctx.GlobalIsSet(AncientFlag.Name) {
+		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientThresholdFlag.Name) {
+		cfg.FreezerThreshold = ctx.GlobalUint64(AncientThresholdFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -1095,13 +1298,20 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// RegisterGraphQLService is a utility function to construct a new service and register it against a node.
+func RegisterGraphQLService(stack *node.Node, endpoint string, cors, vhosts []string, jwtSecret string) {
+	if err := graphql.RegisterGraphQLService(stack, endpoint, cors, vhosts, jwtSecret); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	// TODO(fjl): move target gas limit into config
 	params.TargetGasLimit = ctx.GlobalUint64(TargetGasLimitFlag.Name)
 }
 
-// MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
+// MakeChainDatabase open the chain database using the flags passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context, stack *node.Node) yoobadb.Database {
 	var (
 		cache   = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
@@ -1115,6 +1325,18 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) yoobadb.Database {
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
+	// Serve the ancient chain segment of full nodes from the freezer
+	if dbdir := stack.ResolvePath(name); dbdir != "" && !ctx.GlobalBool(LightModeFlag.Name) {
+		freezer := filepath.Join(dbdir, "ancient")
+		if ctx.GlobalIsSet(AncientFlag.Name) {
+			freezer = stack.ResolvePath(ctx.GlobalString(AncientFlag.Name))
+		}
+		frdb, err := rawdb.NewDatabaseWithFreezer(chainDb, freezer, ctx.GlobalUint64(AncientThresholdFlag.Name))
+		if err != nil {
+			Fatalf("Could not open ancient database: %v", err)
+		}
+		chainDb = frdb
+	}
 	return chainDb
 }
 
@@ -1147,6 +1369,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieNodeLimit: yoo.DefaultConfig.TrieCache,
 		TrieTimeLimit: yoo.DefaultConfig.TrieTimeout,
+		Snapshot:      ctx.GlobalBoolT(SnapshotFlag.Name),
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
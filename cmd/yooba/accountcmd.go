@@ -19,8 +19,10 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/accounts/hdwallet"
 	"github.com/yooba-team/yooba/accounts/keystore"
 	"github.com/yooba-team/yooba/cmd/utils"
 	"github.com/yooba-team/yooba/console"
@@ -126,6 +128,64 @@ Note, this is meant to be used for testing only, it is a bad idea to save your
 password to file or expose in any other way.
 `,
 			},
+			{
+				Name:  "hd",
+				Usage: "Manage HD wallets deriving accounts from a mnemonic",
+				Description: `
+HD wallets derive all their accounts from a single BIP-39 mnemonic, which can
+be written down as a backup to restore every account of the wallet. The seed
+of the wallet is stored encrypted under <DATADIR>/keystore/hd.`,
+				Subcommands: []cli.Command{
+					{
+						Name:   "new",
+						Usage:  "Create a new HD wallet",
+						Action: utils.MigrateFlags(hdWalletCreate),
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.KeyStoreDirFlag,
+							utils.PasswordFileFlag,
+							utils.MnemonicPassphraseFlag,
+							utils.LightKDFFlag,
+						},
+						Description: `
+    yooba account hd new
+
+Generates a new 24 word mnemonic, creates the HD wallet derived from it and
+prints the mnemonic along with the wallet's first account.
+
+Write down the mnemonic and keep it safe, it is the only way to restore the
+wallet if the wallet file or its password is lost. It is not stored anywhere.
+
+The mnemonic may be protected by an additional passphrase, which is prompted
+for or read from the file given by --mnemonic.passphrase. It is needed along
+with the mnemonic to restore the wallet.
+
+The seed is saved in encrypted format, you are prompted for a password.
+`,
+					},
+					{
+						Name:      "import",
+						Usage:     "Import a mnemonic into a new HD wallet",
+						Action:    utils.MigrateFlags(hdWalletImport),
+						ArgsUsage: "[<mnemonicFile>]",
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.KeyStoreDirFlag,
+							utils.PasswordFileFlag,
+							utils.MnemonicPassphraseFlag,
+							utils.LightKDFFlag,
+						},
+						Description: `
+    yooba account hd import [<mnemonicFile>]
+
+Restores the HD wallet derived from an existing BIP-39 mnemonic, read from
+<mnemonicFile> or prompted for, and prints its first account.
+
+The seed is saved in encrypted format, you are prompted for a password.
+`,
+					},
+				},
+			},
 			{
 				Name:      "update",
 				Usage:     "Update an existing account",
@@ -316,6 +376,91 @@ func accountCreate(ctx *cli.Context) error {
 	return nil
 }
 
+// hdWalletCreate creates a new HD wallet into the keystore defined by the CLI
+// flags, printing the mnemonic to back it up with.
+func hdWalletCreate(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	hub := stack.AccountManager().Backends(hdwallet.HubType)[0].(*hdwallet.Hub)
+
+	password := getPassPhrase("Your new HD wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+	mnemonicPassphrase := getMnemonicPassphrase(ctx, true)
+
+	wallet, mnemonic, err := hub.NewWallet(password, mnemonicPassphrase)
+	if err != nil {
+		utils.Fatalf("Failed to create HD wallet: %v", err)
+	}
+	fmt.Printf("Mnemonic: %s\n\n", mnemonic)
+	fmt.Println("Write down the mnemonic and keep it safe, it restores all accounts of the wallet.")
+	fmt.Println("It is not stored, there is no way to recover it once this output is gone.")
+	fmt.Println()
+	fmt.Printf("Wallet: %s\n", wallet.URL())
+	fmt.Printf("Address: {%x}\n", wallet.Accounts()[0].Address)
+	return nil
+}
+
+// hdWalletImport restores an HD wallet from an existing mnemonic into the
+// keystore defined by the CLI flags.
+func hdWalletImport(ctx *cli.Context) error {
+	var mnemonic string
+	if file := ctx.Args().First(); file != "" {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read mnemonic file: %v", err)
+		}
+		mnemonic = string(content)
+	} else {
+		input, err := console.Stdin.PromptPassword("Mnemonic: ")
+		if err != nil {
+			utils.Fatalf("Failed to read mnemonic: %v", err)
+		}
+		mnemonic = input
+	}
+	stack, _ := makeConfigNode(ctx)
+	hub := stack.AccountManager().Backends(hdwallet.HubType)[0].(*hdwallet.Hub)
+
+	mnemonicPassphrase := getMnemonicPassphrase(ctx, false)
+	password := getPassPhrase("Your new HD wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	wallet, err := hub.ImportMnemonic(mnemonic, mnemonicPassphrase, password)
+	if err != nil {
+		utils.Fatalf("Could not import the mnemonic: %v", err)
+	}
+	fmt.Printf("Wallet: %s\n", wallet.URL())
+	fmt.Printf("Address: {%x}\n", wallet.Accounts()[0].Address)
+	return nil
+}
+
+// getMnemonicPassphrase retrieves the optional BIP-39 passphrase, either read
+// from the file given on the command line or requested interactively if no
+// password file was given either.
+func getMnemonicPassphrase(ctx *cli.Context, confirmation bool) string {
+	if file := ctx.String(utils.MnemonicPassphraseFlag.Name); file != "" {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read mnemonic passphrase file: %v", err)
+		}
+		return strings.TrimRight(string(text), "\r\n")
+	}
+	if ctx.GlobalString(utils.PasswordFileFlag.Name) != "" {
+		return ""
+	}
+	fmt.Println("The mnemonic may be protected by an additional passphrase. Leave it empty for none.")
+	passphrase, err := console.Stdin.PromptPassword("Mnemonic passphrase: ")
+	if err != nil {
+		utils.Fatalf("Failed to read mnemonic passphrase: %v", err)
+	}
+	if confirmation && passphrase != "" {
+		confirm, err := console.Stdin.PromptPassword("Repeat mnemonic passphrase: ")
+		if err != nil {
+			utils.Fatalf("Failed to read mnemonic passphrase confirmation: %v", err)
+		}
+		if passphrase != confirm {
+			utils.Fatalf("Mnemonic passphrases do not match")
+		}
+	}
+	return passphrase
+}
+
 // accountUpdate transitions an account from a previous format to the current
 // one, also providing the possibility to change the pass-phrase.
 func accountUpdate(ctx *cli.Context) error {
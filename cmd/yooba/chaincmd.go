@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"sync/atomic"
@@ -36,7 +37,6 @@ import (
 	"github.com/yooba-team/yooba/event"
 	"github.com/yooba-team/yooba/log"
 	"github.com/yooba-team/yooba/trie"
-	"github.com/syndtr/goleveldb/leveldb/util"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -65,9 +65,11 @@ It expects the genesis file as argument.`,
 		ArgsUsage: "<filename> (<filename 2> ... <filename N>) ",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.LightModeFlag,
 			utils.GCModeFlag,
+			utils.SnapshotFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
 		},
@@ -86,6 +88,7 @@ processing will proceed even if an individual RLP-file import failure occurs.`,
 		ArgsUsage: "<filename> [<blockNumFirst> <blockNumLast>]",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.LightModeFlag,
 		},
@@ -103,6 +106,7 @@ if already existing.`,
 		ArgsUsage: "<sourceChaindataDir>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 			utils.FakePoWFlag,
@@ -120,11 +124,29 @@ The first argument must be the directory containing the blockchain to download f
 		ArgsUsage: " ",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.LightModeFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 Remove blockchain and state databases`,
+	}
+	migratedbCommand = cli.Command{
+		Action:    utils.MigrateFlags(migrateDB),
+		Name:      "migratedb",
+		Usage:     "Convert blockchain and state databases to another engine",
+		ArgsUsage: "<engine>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.TestnetFlag,
+			utils.RinkebyFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The migratedb command copies the full and light chain databases into a new
+database of the given engine ("leveldb" or "bolt") and swaps it in place of the
+old one, which is kept next to it as a backup. The node must not be running.`,
 	}
 	dumpCommand = cli.Command{
 		Action:    utils.MigrateFlags(dump),
@@ -133,6 +155,7 @@ Remove blockchain and state databases`,
 		ArgsUsage: "[<blockHash> | <blockNum>]...",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.LightModeFlag,
 		},
@@ -218,9 +241,7 @@ func importChain(ctx *cli.Context) error {
 	fmt.Printf("Import done in %v.\n\n", time.Since(start))
 
 	// Output pre-compaction stats mostly to see the import trashing
-	db := chainDb.(*yoobadb.LDBDatabase)
-
-	stats, err := db.LDB().GetProperty("leveldb.stats")
+	stats, err := chainDb.Stat("")
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
@@ -244,12 +265,12 @@ func importChain(ctx *cli.Context) error {
 	// Compact the entire database to more accurately measure disk io and print the stats
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = db.LDB().CompactRange(util.Range{}); err != nil {
+	if err = chainDb.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 
-	stats, err = db.LDB().GetProperty("leveldb.stats")
+	stats, err = chainDb.Stat("")
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
@@ -303,7 +324,7 @@ func copyDb(ctx *cli.Context) error {
 	dl := downloader.New(syncmode, chainDb, new(event.TypeMux), chain, nil, nil)
 
 	// Create a source peer to satisfy downloader requests from
-	db, err := yoobadb.NewLDBDatabase(ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name), 256)
+	db, err := yoobadb.Open("", ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name), 256)
 	if err != nil {
 		return err
 	}
@@ -330,7 +351,7 @@ func copyDb(ctx *cli.Context) error {
 	// Compact the entire database to remove any sync overhead
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = chainDb.(*yoobadb.LDBDatabase).LDB().CompactRange(util.Range{}); err != nil {
+	if err = chainDb.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
@@ -341,11 +362,22 @@ func copyDb(ctx *cli.Context) error {
 func removeDB(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 
-	for _, name := range []string{"chaindata", "lightchaindata"} {
+	dbdirs := map[string]string{
+		"chaindata":      stack.ResolvePath("chaindata"),
+		"lightchaindata": stack.ResolvePath("lightchaindata"),
+	}
+	// The ancient store is removed along with the chain database, unless kept elsewhere
+	if ctx.GlobalIsSet(utils.AncientFlag.Name) {
+		dbdirs["ancient"] = stack.ResolvePath(ctx.GlobalString(utils.AncientFlag.Name))
+	}
+	for _, name := range []string{"chaindata", "lightchaindata", "ancient"} {
+		dbdir, ok := dbdirs[name]
+		if !ok {
+			continue
+		}
 		// Ensure the database exists in the first place
 		logger := log.New("database", name)
 
-		dbdir := stack.ResolvePath(name)
 		if !common.FileExist(dbdir) {
 			logger.Info("Database doesn't exist, skipping", "path", dbdir)
 			continue
@@ -367,6 +399,70 @@ func removeDB(ctx *cli.Context) error {
 	return nil
 }
 
+func migrateDB(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires the target engine as argument")
+	}
+	engine := ctx.Args().First()
+	if engine != yoobadb.EngineLevelDB && engine != yoobadb.EngineBolt {
+		utils.Fatalf("Unknown database engine %q, must be %q or %q", engine, yoobadb.EngineLevelDB, yoobadb.EngineBolt)
+	}
+	stack, _ := makeConfigNode(ctx)
+	cache := ctx.GlobalInt(utils.CacheFlag.Name)
+
+	for _, name := range []string{"chaindata", "lightchaindata"} {
+		logger := log.New("database", name)
+
+		dbdir := stack.ResolvePath(name)
+		current := yoobadb.DetectEngine(dbdir)
+		switch current {
+		case "":
+			logger.Info("Database doesn't exist, skipping", "path", dbdir)
+			continue
+		case engine:
+			logger.Info("Database already uses the engine, skipping", "path", dbdir, "engine", engine)
+			continue
+		}
+		// Copy the database into a fresh one next to it
+		tmpdir := dbdir + ".migrating"
+		if err := os.RemoveAll(tmpdir); err != nil {
+			utils.Fatalf("Failed to remove stale migration database: %v", err)
+		}
+		src, err := yoobadb.Open(current, dbdir, cache, 256)
+		if err != nil {
+			utils.Fatalf("Failed to open source database: %v", err)
+		}
+		dst, err := yoobadb.Open(engine, tmpdir, cache, 256)
+		if err != nil {
+			utils.Fatalf("Failed to create target database: %v", err)
+		}
+		logger.Info("Migrating database", "from", current, "to", engine)
+		_, err = yoobadb.Migrate(dst, src)
+		src.Close()
+		dst.Close()
+		if err != nil {
+			os.RemoveAll(tmpdir)
+			utils.Fatalf("Database migration failed: %v", err)
+		}
+		// Carry over the ancient store kept within the database directory
+		if ancient := filepath.Join(dbdir, "ancient"); common.FileExist(ancient) {
+			if err := os.Rename(ancient, filepath.Join(tmpdir, "ancient")); err != nil {
+				utils.Fatalf("Failed to move ancient database: %v", err)
+			}
+		}
+		// Swap the databases, keeping the old one around
+		backup := fmt.Sprintf("%s.%s.bak", dbdir, current)
+		if err := os.Rename(dbdir, backup); err != nil {
+			utils.Fatalf("Failed to back up old database: %v", err)
+		}
+		if err := os.Rename(tmpdir, dbdir); err != nil {
+			utils.Fatalf("Failed to move migrated database in place: %v", err)
+		}
+		logger.Info("Database migrated, old one kept as backup", "backup", backup)
+	}
+	return nil
+}
+
 func dump(ctx *cli.Context) error {
 	stack := makeFullNode(ctx)
 	chain, chainDb := utils.MakeChain(ctx, stack)
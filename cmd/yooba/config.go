@@ -165,6 +165,11 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 
+	// Add the GraphQL service if requested, alongside the JSON-RPC servers.
+	if endpoint := cfg.Node.GraphQLEndpoint(); endpoint != "" {
+		utils.RegisterGraphQLService(stack, endpoint, cfg.Node.GraphQLCors, cfg.Node.GraphQLVirtualHosts, cfg.Node.JWTSecret)
+	}
+
 	// Add the Yooba Stats daemon if requested.
 	if cfg.YooStats.URL != "" {
 		utils.RegisterEthStatsService(stack, cfg.YooStats.URL)
@@ -59,8 +59,11 @@ var (
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
+		utils.AncientFlag,
+		utils.DatabaseEngineFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ExternalSignerFlag,
 		utils.DashboardEnabledFlag,
 		utils.EthashCacheDirFlag,
 		utils.TxPoolNoLocalsFlag,
@@ -77,6 +80,8 @@ var (
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.SnapshotFlag,
+		utils.AncientThresholdFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
@@ -108,6 +113,9 @@ var (
 		utils.RPCVirtualHostsFlag,
 		utils.EthStatsURLFlag,
 		utils.MetricsEnabledFlag,
+		utils.MetricsAddrFlag,
+		utils.MetricsPortFlag,
+		utils.MetricsLabelsFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
@@ -125,7 +133,19 @@ var (
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
 		utils.WSApiFlag,
+		utils.JWTSecretFlag,
+		utils.RPCBatchLimitFlag,
+		utils.RPCResponseLimitFlag,
+		utils.RPCConcurrencyLimitFlag,
+		utils.RPCSubscriptionLimitFlag,
+		utils.RPCTimeoutFlag,
+		utils.RPCMethodTimeoutsFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.GraphQLEnabledFlag,
+		utils.GraphQLListenAddrFlag,
+		utils.GraphQLPortFlag,
+		utils.GraphQLCORSDomainFlag,
+		utils.GraphQLVirtualHostsFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 	}
@@ -149,7 +169,10 @@ func init() {
 		exportCommand,
 		copydbCommand,
 		removedbCommand,
+		migratedbCommand,
 		dumpCommand,
+		// See snapshot.go:
+		snapshotCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
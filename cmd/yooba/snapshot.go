@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/yooba-team/yooba/cmd/utils"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/state/pruner"
+	"github.com/yooba-team/yooba/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	bloomFilterSizeFlag = cli.Uint64Flag{
+		Name:  "bloomfilter.size",
+		Usage: "Megabytes of memory allocated to the bloom filter for pruning",
+		Value: pruner.DefaultBloomSize,
+	}
+
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Snapshot based state maintenance",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(pruneState),
+				Name:      "prune-state",
+				Usage:     "Prune stale state data from the database",
+				ArgsUsage: "[<root>]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.CacheFlag,
+					utils.CacheDatabaseFlag,
+					bloomFilterSizeFlag,
+				},
+				Description: `
+yooba snapshot prune-state <state-root>
+deletes every trie node and contract code from the database that isn't part of
+the given state. If no root is given, the state of the current head block is
+retained, or the one of its most recent ancestor if the head state is missing.
+
+Pruning collects the retained state in a bloom filter, which needs to fit into
+memory and is written to the data directory before anything is deleted. If the
+pruning is interrupted, it is resumed on the next run of this command or on the
+next startup of the node.
+
+The node must not be running while pruning.`,
+			},
+		},
+	}
+)
+
+// pruneState deletes all state data not belonging to the target state.
+func pruneState(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command accepts at most one argument.")
+	}
+	var root common.Hash
+	if len(ctx.Args()) == 1 {
+		if !hashish(ctx.Args()[0]) || len(common.FromHex(ctx.Args()[0])) != common.HashLength {
+			utils.Fatalf("Invalid state root: %s", ctx.Args()[0])
+		}
+		root = common.HexToHash(ctx.Args()[0])
+	}
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	if err := pruner.NewPruner(chainDb, stack.InstanceDir(), ctx.GlobalUint64(bloomFilterSizeFlag.Name)).Prune(root); err != nil {
+		log.Error("Failed to prune state", "err", err)
+		return err
+	}
+	return nil
+}
@@ -20,11 +20,16 @@ import (
 	"errors"
 	"math/big"
 	"time"
+	"github.com/yooba-team/yooba/accounts"
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/consensus"
+	"github.com/yooba-team/yooba/core/governance"
 	"github.com/yooba-team/yooba/core/state"
 	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/crypto/sha3"
 	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/rlp"
 	"fmt"
 	"runtime"
 )
@@ -36,8 +41,25 @@ var (
 var (
 	errZeroBlockTime     = errors.New("timestamp equals parent's")
 	errInvalidHeaderNumber        = errors.New("invalid header number")
+
+	// errInvalidSeal is returned if the producer seal of a block is malformed
+	// or not signed by the coinbase of the block.
+	errInvalidSeal = errors.New("invalid producer seal")
+
+	// errMissingSeal is returned if a block past the seal fork doesn't carry the
+	// seal of its producer.
+	errMissingSeal = errors.New("missing producer seal")
+
+	// errUnauthorized is returned if a block past the seal fork is to be sealed
+	// without the key of its producer.
+	errUnauthorized = errors.New("unauthorized producer")
 )
 
+// extraSeal is the number of extra-data suffix bytes holding the producer seal.
+// Blocks past the seal fork carry it after at most MaximumExtraDataSize bytes
+// of vanity, earlier blocks are unsealed.
+const extraSeal = 65
+
 func (dpos *dpos) Author(header *types.Header) (common.Address, error) {
 	return header.Coinbase, nil
 }
@@ -147,8 +169,14 @@ func (dpos *dpos) verifyHeaderWorker(chain consensus.ChainReader, headers []*typ
 
 func (dpos *dpos) verifyHeader(chain consensus.ChainReader, header, parent *types.Header, seal bool) error {
 	// Ensure that the header's extra-data section is of a reasonable size
-	if uint64(len(header.Extra)) > params.MaximumExtraDataSize {
-		return fmt.Errorf("extra-data too long: %d > %d", len(header.Extra), params.MaximumExtraDataSize)
+	vanity := len(header.Extra)
+	if chain.Config().IsSeal(header.Number) {
+		if vanity -= extraSeal; vanity < 0 {
+			return errMissingSeal
+		}
+	}
+	if vanity > int(params.MaximumExtraDataSize) {
+		return fmt.Errorf("extra-data too long: %d > %d", vanity, params.MaximumExtraDataSize)
 	}
 	// Verify the header's timestamp
 
@@ -171,15 +199,22 @@ func (dpos *dpos) verifyHeader(chain consensus.ChainReader, header, parent *type
 		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
 	}
 
-	// Verify that the gas limit remains within allowed bounds
-	diff := int64(parent.GasLimit) - int64(header.GasLimit)
-	if diff < 0 {
-		diff *= -1
-	}
-	limit := parent.GasLimit / params.GasLimitBoundDivisor
+	// Verify that the gas limit remains within the bounds set by governance. A
+	// gas limit below the governed minimum has to rise towards it, so a raised
+	// minimum is reached instead of invalidating every next block.
+	if gov := governedParams(chain, header, parent); gov != nil {
+		diff := int64(parent.GasLimit) - int64(header.GasLimit)
+		if diff < 0 {
+			diff *= -1
+		}
+		limit := parent.GasLimit / gov.GasLimitBoundDivisor
 
-	if uint64(diff) >= limit || header.GasLimit < params.MinGasLimit {
-		return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit)
+		if uint64(diff) >= limit {
+			return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit)
+		}
+		if header.GasLimit < gov.MinGasLimit && header.GasLimit <= parent.GasLimit {
+			return fmt.Errorf("invalid gas limit: have %d, want rising towards minimum %d", header.GasLimit, gov.MinGasLimit)
+		}
 	}
 	if diff := new(big.Int).Sub(header.Number, parent.Number); diff.Cmp(big.NewInt(1)) != 0 {
 		return consensus.ErrInvalidNumber
@@ -194,6 +229,29 @@ func (dpos *dpos) verifyHeader(chain consensus.ChainReader, header, parent *type
 
 
 
+// stateReader is implemented by chains able to serve the state of past blocks,
+// which is needed to verify headers against the governed chain parameters.
+type stateReader interface {
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// governedParams retrieves the governance parameters effective for header from
+// the state of its parent. Nil is returned if the chain can't serve that state
+// (light clients, headers below the fast sync pivot), in which case the header
+// is only verified against the rules that don't depend on governance. Checking
+// against the defaults instead would reject headers after any governed change.
+func governedParams(chain consensus.ChainReader, header, parent *types.Header) *governance.Params {
+	reader, ok := chain.(stateReader)
+	if !ok {
+		return nil
+	}
+	statedb, err := reader.StateAt(parent.Root)
+	if err != nil {
+		return nil
+	}
+	return governance.ReadParams(statedb, header.Number)
+}
+
 // Some weird constants to avoid constant memory allocs for them.
 var (
 	expDiffPeriod = big.NewInt(100000)
@@ -208,15 +266,68 @@ var (
 
 
 func (dpos *dpos) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
-		time.Sleep(dpos.fakeDelay)
-		if dpos.fakeFail == header.Number.Uint64() {
-			return errInvalidHeaderNumber
-		}
+	time.Sleep(dpos.fakeDelay)
+	if dpos.fakeFail == header.Number.Uint64() {
+		return errInvalidHeaderNumber
+	}
+	// Blocks past the seal fork must be signed by their producer
+	if !chain.Config().IsSeal(header.Number) {
 		return nil
-   //TODO: more verify the seal block
+	}
+	if !sealed(header) {
+		return errMissingSeal
+	}
+	signer, err := ecrecover(header)
+	if err != nil || signer != header.Coinbase {
+		return errInvalidSeal
+	}
 	return nil
 }
 
+// sealed returns whether the extra-data of a header ends in a producer seal. The
+// vanity is capped at MaximumExtraDataSize, so only sealed headers exceed it.
+func sealed(header *types.Header) bool {
+	return len(header.Extra) > int(params.MaximumExtraDataSize) && len(header.Extra) >= extraSeal
+}
+
+// SealHash returns the hash of a header prior to it being sealed, which is the
+// hash signed by the producer.
+func SealHash(header *types.Header) (hash common.Hash) {
+	extra := header.Extra
+	if sealed(header) {
+		extra = extra[:len(extra)-extraSeal]
+	}
+	hasher := sha3.NewKeccak256()
+	rlp.Encode(hasher, []interface{}{
+		header.ParentHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		extra,
+		header.Nonce,
+	})
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+// ecrecover extracts the producer address from a sealed header.
+func ecrecover(header *types.Header) (common.Address, error) {
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+	pubkey, err := crypto.Ecrecover(SealHash(header).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
+
 // Prepare implements consensus.Engine, initializing the difficulty field of a
 // header to conform to the dpos protocol. The changes are done inline.
 func (dpos *dpos) Prepare(chain consensus.ChainReader, header *types.Header) error {
@@ -231,6 +342,13 @@ func (dpos *dpos) Prepare(chain consensus.ChainReader, header *types.Header) err
 // setting the final state and assembling the block.
 func (dpos *dpos) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) (*types.Block, error) {
 	accumulateRewards(chain.Config(), state, header)
+
+	// Account the block to its producer, elect the next schedule at the end of
+	// the interval and close the governance proposals that ran out of time
+	recordProduced(state, header.Coinbase, header.Time)
+	UpdateSchedule(state, header.Number)
+	governance.CloseExpired(state, header.Number)
+
 	header.Root = state.IntermediateRoot(true)
 
 	return types.NewBlock(header, txs, receipts), nil
@@ -247,13 +365,31 @@ func (dpos *dpos) Seal(chain consensus.ChainReader, block *types.Block, stop <-c
 	)
 	var result *types.Block
 	header = types.CopyHeader(header)
+
+	// Seal the block with the producer's signature past the seal fork
+	dpos.lock.Lock()
+	producer, signFn := dpos.producer, dpos.signFn
+	dpos.lock.Unlock()
+
+	if chain.Config().IsSeal(header.Number) && !sealed(header) {
+		if signFn == nil || producer != header.Coinbase {
+			return nil, errUnauthorized
+		}
+		signature, err := signFn(accounts.Account{Address: producer}, header)
+		if err != nil {
+			return nil, err
+		}
+		if len(signature) != extraSeal {
+			return nil, errInvalidSeal
+		}
+		header.Extra = append(header.Extra, signature...)
+	}
 	result = block.WithSeal(header);
 	return result, nil
 }
 
 func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header) {
-
-	//TODO: For now ,reward set to 0.Future it will change
-	reward := new(big.Int).Set(big.NewInt(0))
+	// The block reward is set by governance and defaults to 0
+	reward := governance.Value(state, governance.BlockReward, header.Number)
 	state.AddBalance(header.Coinbase, reward)
 }
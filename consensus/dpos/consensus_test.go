@@ -19,9 +19,17 @@ package dpos
 import (
 	"encoding/json"
 	"math/big"
+	"testing"
 
+	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/math"
-
+	"github.com/yooba-team/yooba/consensus"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/yoobadb"
 )
 
 type diffTest struct {
@@ -47,4 +55,104 @@ func (d *diffTest) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+// sealChain is a chain reader only serving the chain configuration, which is all
+// the seal and stateless header checks need.
+type sealChain struct {
+	consensus.ChainReader
+	config *params.ChainConfig
+}
+
+func (c sealChain) Config() *params.ChainConfig { return c.config }
+
+// Tests that blocks past the seal fork carry a verifiable seal of their producer,
+// and that blocks before it stay unsealed.
+func TestProducerSeal(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	producer := crypto.PubkeyToAddress(key.PublicKey)
+
+	engine := New(Config{})
+	chain := sealChain{config: &params.ChainConfig{SealBlock: big.NewInt(2)}}
+
+	// Blocks before the fork are neither sealed nor required to be
+	header := &types.Header{Number: big.NewInt(1), Time: big.NewInt(1), Coinbase: producer, Extra: []byte("vanity")}
+	unsealed, err := engine.Seal(chain, types.NewBlockWithHeader(header), nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(unsealed.Extra()) != len("vanity") {
+		t.Fatalf("block sealed before the fork: extra-data length %d", len(unsealed.Extra()))
+	}
+	if err := engine.VerifySeal(chain, unsealed.Header()); err != nil {
+		t.Fatalf("unsealed block rejected before the fork: %v", err)
+	}
+	// Blocks past the fork can only be produced with the producer's key
+	header.Number = big.NewInt(2)
+	if _, err := engine.Seal(chain, types.NewBlockWithHeader(header), nil); err != errUnauthorized {
+		t.Fatalf("unauthorized seal error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+	if err := engine.VerifySeal(chain, header); err != errMissingSeal {
+		t.Fatalf("unsealed block error mismatch: have %v, want %v", err, errMissingSeal)
+	}
+	engine.Authorize(producer, func(account accounts.Account, header *types.Header) ([]byte, error) {
+		return crypto.Sign(SealHash(header).Bytes(), key)
+	})
+	block, err := engine.Seal(chain, types.NewBlockWithHeader(header), nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(block.Extra()) != len("vanity")+extraSeal {
+		t.Fatalf("seal missing: extra-data length %d", len(block.Extra()))
+	}
+	if SealHash(block.Header()) != SealHash(header) {
+		t.Fatalf("seal hash changed by sealing")
+	}
+	if err := engine.VerifySeal(chain, block.Header()); err != nil {
+		t.Fatalf("sealed block rejected: %v", err)
+	}
+	// Seals of another producer must be rejected
+	forged := block.Header()
+	forged.Coinbase = common.HexToAddress("0xdeadbeef")
+	if err := engine.VerifySeal(chain, forged); err != errInvalidSeal {
+		t.Fatalf("forged seal error mismatch: have %v, want %v", err, errInvalidSeal)
+	}
+}
+
+// stateChain is a chain reader additionally serving a single state for the
+// parents of all headers.
+type stateChain struct {
+	sealChain
+	statedb *state.StateDB
+}
+
+func (c stateChain) StateAt(root common.Hash) (*state.StateDB, error) { return c.statedb, nil }
+
+// Tests that the gas limit of headers is bounded by the governed parameters if
+// the chain can serve the state of their parent, and left unchecked otherwise.
+func TestGasLimitBounds(t *testing.T) {
+	engine := New(Config{})
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
 
+	var (
+		stateless = sealChain{config: params.TestChainConfig}
+		stateful  = stateChain{sealChain: stateless, statedb: statedb}
+		parent    = &types.Header{Number: big.NewInt(1), Time: big.NewInt(1), GasLimit: params.GenesisGasLimit}
+	)
+	tests := []struct {
+		gasLimit uint64
+		valid    bool
+	}{
+		{params.GenesisGasLimit, true},
+		{params.GenesisGasLimit + params.GenesisGasLimit/params.GasLimitBoundDivisor - 1, true},
+		{params.GenesisGasLimit + params.GenesisGasLimit/params.GasLimitBoundDivisor, false},
+		{params.GenesisGasLimit - params.GenesisGasLimit/params.GasLimitBoundDivisor, false},
+	}
+	for i, tt := range tests {
+		header := &types.Header{Number: big.NewInt(2), Time: big.NewInt(2), GasLimit: tt.gasLimit}
+		if err := engine.verifyHeader(stateful, header, parent, false); (err == nil) != tt.valid {
+			t.Errorf("test %d: gas limit %d: validity mismatch: have %v, want valid %v", i, tt.gasLimit, err, tt.valid)
+		}
+		if err := engine.verifyHeader(stateless, header, parent, false); err != nil {
+			t.Errorf("test %d: gas limit %d: rejected without state: %v", i, tt.gasLimit, err)
+		}
+	}
+}
@@ -23,7 +23,10 @@ import (
 	"math/rand"
 	"sync"
 	"time"
+	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/consensus"
+	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/rpc"
 
 )
@@ -54,6 +57,10 @@ type Config struct {
 
 }
 
+// SignerFn is a signer callback function to request a header to be sealed by a
+// backing account. The returned signature has to be over SealHash(header).
+type SignerFn func(accounts.Account, *types.Header) ([]byte, error)
+
 // dpos is a consensus engine based on proot-of-work implementing the dpos
 // algorithm.
 type dpos struct {
@@ -64,6 +71,9 @@ type dpos struct {
 	threads  int           // Number of threads to mine on if mining
 	update   chan struct{} // Notification channel to update mining parameters
 
+	producer common.Address // Yooba address of the sealing key
+	signFn   SignerFn       // Signer function to seal blocks with
+
 	// The fields below are hooks for testing
 	fakeFail  uint64        // Block number which fails PoW check even in fake mode
 	fakeDelay time.Duration // Time delay to sleep for before returning from verify
@@ -87,10 +97,29 @@ func Default() *dpos {
 	//TODO set default config here
 }
 
+// NewFakeFailer creates a dpos engine for testing, which fails the seal
+// verification of the block with the given number.
+func NewFakeFailer(fail uint64) *dpos {
+	return &dpos{
+		update:   make(chan struct{}),
+		fakeFail: fail,
+	}
+}
+
 func (dpos *dpos)  SetConfig(config Config){
    dpos.config = config
 }
 
+// Authorize injects the key of a producer into the consensus engine, sealing the
+// blocks it mines with the producer's signature from now on.
+func (dpos *dpos) Authorize(producer common.Address, signFn SignerFn) {
+	dpos.lock.Lock()
+	defer dpos.lock.Unlock()
+
+	dpos.producer = producer
+	dpos.signFn = signFn
+}
+
 
 
 func (dpos *dpos) APIs(chain consensus.ChainReader) []rpc.API {
@@ -0,0 +1,387 @@
+package dpos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/params"
+)
+
+// The producer registry keeps the DPoS election in the storage of
+// params.ProducerRegistryAddress, so it follows the state through reorgs and can
+// be read at any block.
+//
+// Candidates register with a TxTypeWitness transaction. Stakers vote for up to
+// params.MaxVotedProducers of them with a TxTypeVote transaction, locking the
+// transferred value in the registry and adding their whole stake to the votes of
+// every producer voted for. Every params.ProducerScheduleInterval blocks the best
+// voted producers become the active schedule. Until the first election the
+// producers of the chain config are active.
+
+var (
+	ErrWrongRegistry     = errors.New("producer transaction not sent to the producer registry")
+	ErrUnexpectedValue   = errors.New("producer registrations can't transfer value")
+	ErrInfoTooLong       = errors.New("producer url or location too long")
+	ErrUnknownProducer   = errors.New("vote for unregistered producer")
+	ErrDuplicateProducer = errors.New("duplicate producer in vote")
+	ErrTooManyProducers  = errors.New("too many producers in vote")
+	ErrNoStake           = errors.New("vote without stake")
+)
+
+// RegisterData is the RLP encoded payload of a TxTypeWitness transaction.
+type RegisterData struct {
+	Url      string
+	Location string
+}
+
+// VoteData is the RLP encoded payload of a TxTypeVote transaction. An empty
+// producer list withdraws the vote and returns the stake.
+type VoteData struct {
+	Producers []common.Address
+}
+
+// Register records producer as a candidate, or updates the details of an already
+// registered one.
+func Register(db vm.StateDB, producer common.Address, data *RegisterData) error {
+	if len(data.Url) > params.MaxProducerInfoSize || len(data.Location) > params.MaxProducerInfoSize {
+		return ErrInfoTooLong
+	}
+	touch(db)
+
+	if get(db, producerKey(producer, fieldIndex)).Sign() == 0 {
+		index := get(db, producerCountKey).Uint64() + 1
+		set(db, producerCountKey, new(big.Int).SetUint64(index))
+		set(db, producerIndexKey(index), new(big.Int).SetBytes(producer.Bytes()))
+		set(db, producerKey(producer, fieldIndex), new(big.Int).SetUint64(index))
+	}
+	setString(db, producerKey(producer, fieldUrl), data.Url)
+	setString(db, producerKey(producer, fieldLocation), data.Location)
+	return nil
+}
+
+// CastVote replaces the vote of owner, adding stake to the balance it already
+// locked in the registry. The vote is cast in a block with the given time.
+func CastVote(db vm.StateDB, owner common.Address, time *big.Int, stake *big.Int, data *VoteData) error {
+	if len(data.Producers) > params.MaxVotedProducers {
+		return ErrTooManyProducers
+	}
+	seen := make(map[common.Address]bool)
+	for _, producer := range data.Producers {
+		if get(db, producerKey(producer, fieldIndex)).Sign() == 0 {
+			return ErrUnknownProducer
+		}
+		if seen[producer] {
+			return ErrDuplicateProducer
+		}
+		seen[producer] = true
+	}
+	if db.GetBalance(owner).Cmp(stake) < 0 {
+		return vm.ErrInsufficientBalance
+	}
+	previous := GetVote(db, owner)
+	if previous == nil && (len(data.Producers) == 0 || stake.Sign() == 0) {
+		return ErrNoStake
+	}
+	touch(db)
+
+	// Withdraw the weight of the previous vote from its producers
+	total := new(big.Int).Set(stake)
+	if previous != nil {
+		staked := get(db, voteKey(owner, fieldStake))
+		for _, producer := range previous.Producers {
+			key := producerKey(producer.Address, fieldVotes)
+			set(db, key, new(big.Int).Sub(get(db, key), staked))
+		}
+		total.Add(total, staked)
+	}
+	db.SubBalance(owner, stake)
+	db.AddBalance(params.ProducerRegistryAddress, stake)
+
+	// An empty vote returns the whole stake, anything else records the new vote
+	if len(data.Producers) == 0 {
+		db.SubBalance(params.ProducerRegistryAddress, total)
+		db.AddBalance(owner, total)
+
+		set(db, voteKey(owner, fieldStake), new(big.Int))
+		set(db, voteKey(owner, fieldStart), new(big.Int))
+		setAddresses(db, voteKey(owner, fieldProducers), nil)
+		return nil
+	}
+	for _, producer := range data.Producers {
+		key := producerKey(producer, fieldVotes)
+		set(db, key, new(big.Int).Add(get(db, key), total))
+	}
+	key := voteKey(owner, fieldCount)
+	set(db, key, new(big.Int).Add(get(db, key), common.Big1))
+
+	set(db, voteKey(owner, fieldStake), total)
+	set(db, voteKey(owner, fieldStart), time)
+	setAddresses(db, voteKey(owner, fieldProducers), data.Producers)
+	return nil
+}
+
+// GetProducer retrieves a registered producer, or nil if producer never
+// registered.
+func GetProducer(db vm.StateDB, producer common.Address) *Producer {
+	if get(db, producerKey(producer, fieldIndex)).Sign() == 0 {
+		return nil
+	}
+	active := false
+	for _, addr := range getAddresses(db, scheduleKey) {
+		if addr == producer {
+			active = true
+			break
+		}
+	}
+	return &Producer{
+		TotalVotesCount: new(big.Int).Div(get(db, producerKey(producer, fieldVotes)), big.NewInt(params.Ether)).Uint64(),
+		TotalProduced:   get(db, producerKey(producer, fieldProduced)).Uint64(),
+		Address:         producer,
+		IsActive:        active,
+		Url:             getString(db, producerKey(producer, fieldUrl)),
+		Location:        getString(db, producerKey(producer, fieldLocation)),
+		LastProduceTime: get(db, producerKey(producer, fieldLastProduced)),
+	}
+}
+
+// GetProducers retrieves all registered producers in registration order.
+func GetProducers(db vm.StateDB) []*Producer {
+	count := get(db, producerCountKey).Uint64()
+
+	producers := make([]*Producer, 0, count)
+	for index := uint64(1); index <= count; index++ {
+		producers = append(producers, GetProducer(db, common.BigToAddress(get(db, producerIndexKey(index)))))
+	}
+	return producers
+}
+
+// GetVote retrieves the current vote of owner, or nil if it has none.
+func GetVote(db vm.StateDB, owner common.Address) *Vote {
+	addrs := getAddresses(db, voteKey(owner, fieldProducers))
+	if len(addrs) == 0 {
+		return nil
+	}
+	producers := make([]Producer, len(addrs))
+	for i, addr := range addrs {
+		producers[i] = *GetProducer(db, addr)
+	}
+	stake := get(db, voteKey(owner, fieldStake))
+	weight, _ := new(big.Float).Quo(new(big.Float).SetInt(stake), big.NewFloat(params.Ether)).Float64()
+
+	return &Vote{
+		Owner:         owner,
+		VoteId:        strconv.FormatUint(get(db, voteKey(owner, fieldCount)).Uint64(), 10),
+		Producers:     producers,
+		Staked:        new(big.Int).Div(stake, big.NewInt(params.Ether)).Int64(),
+		LastWeight:    weight,
+		VoteStartTime: get(db, voteKey(owner, fieldStart)).Int64(),
+	}
+}
+
+// ActiveProducers returns the producers of the schedule effective in the given
+// state, falling back to the producers of the chain config until the first
+// election.
+func ActiveProducers(db vm.StateDB, config *params.ChainConfig) []common.Address {
+	if producers := getAddresses(db, scheduleKey); len(producers) > 0 {
+		return producers
+	}
+	if config.Ethash != nil {
+		return config.Ethash.Producers
+	}
+	return nil
+}
+
+// ScheduleNumber returns the number of the block that elected the schedule
+// effective in the given state, zero if there was no election yet.
+func ScheduleNumber(db vm.StateDB) uint64 {
+	return get(db, scheduleNumberKey).Uint64()
+}
+
+// UpdateSchedule elects the best voted producers as the new schedule if the
+// block with the given number ends a schedule interval. It returns whether the
+// schedule changed.
+func UpdateSchedule(db vm.StateDB, number *big.Int) bool {
+	if number.Sign() == 0 || number.Uint64()%params.ProducerScheduleInterval != 0 {
+		return false
+	}
+	type candidate struct {
+		addr  common.Address
+		votes *big.Int
+	}
+	var (
+		count      = get(db, producerCountKey).Uint64()
+		candidates []candidate
+	)
+	for index := uint64(1); index <= count; index++ {
+		addr := common.BigToAddress(get(db, producerIndexKey(index)))
+		if votes := get(db, producerKey(addr, fieldVotes)); votes.Sign() > 0 {
+			candidates = append(candidates, candidate{addr, votes})
+		}
+	}
+	// Keep the current schedule if nobody was voted for
+	if len(candidates) == 0 {
+		return false
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if cmp := candidates[i].votes.Cmp(candidates[j].votes); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(candidates[i].addr[:], candidates[j].addr[:]) < 0
+	})
+	if len(candidates) > params.MaxActiveProducers {
+		candidates = candidates[:params.MaxActiveProducers]
+	}
+	producers := make([]common.Address, len(candidates))
+	for i, c := range candidates {
+		producers[i] = c.addr
+	}
+	current := getAddresses(db, scheduleKey)
+	if len(current) == len(producers) {
+		changed := false
+		for i := range current {
+			if current[i] != producers[i] {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return false
+		}
+	}
+	setAddresses(db, scheduleKey, producers)
+	set(db, scheduleNumberKey, number)
+	return true
+}
+
+// recordProduced updates the production statistics of a registered producer
+// after it produced a block at the given time.
+func recordProduced(db vm.StateDB, producer common.Address, time *big.Int) {
+	if get(db, producerKey(producer, fieldIndex)).Sign() == 0 {
+		return
+	}
+	key := producerKey(producer, fieldProduced)
+	set(db, key, new(big.Int).Add(get(db, key), common.Big1))
+	set(db, producerKey(producer, fieldLastProduced), time)
+}
+
+// Storage layout of the producer registry.
+const (
+	fieldIndex byte = iota
+	fieldUrl
+	fieldLocation
+	fieldVotes
+	fieldProduced
+	fieldLastProduced
+)
+
+const (
+	fieldStake byte = iota
+	fieldStart
+	fieldCount
+	fieldProducers
+)
+
+var (
+	producerCountKey  = crypto.Keccak256Hash([]byte("producer-count"))
+	scheduleKey       = crypto.Keccak256Hash([]byte("schedule"))
+	scheduleNumberKey = crypto.Keccak256Hash([]byte("schedule-number"))
+)
+
+func producerKey(producer common.Address, field byte) common.Hash {
+	return crypto.Keccak256Hash([]byte("producer"), producer.Bytes(), []byte{field})
+}
+
+func producerIndexKey(index uint64) common.Hash {
+	return crypto.Keccak256Hash([]byte("producer-index"), encodeIndex(index))
+}
+
+func voteKey(owner common.Address, field byte) common.Hash {
+	return crypto.Keccak256Hash([]byte("vote"), owner.Bytes(), []byte{field})
+}
+
+// elemKey returns the slot of the index-th element of a list stored at key.
+func elemKey(key common.Hash, index uint64) common.Hash {
+	return crypto.Keccak256Hash(key.Bytes(), encodeIndex(index))
+}
+
+func encodeIndex(index uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, index)
+	return enc
+}
+
+func get(db vm.StateDB, key common.Hash) *big.Int {
+	return db.GetState(params.ProducerRegistryAddress, key).Big()
+}
+
+func set(db vm.StateDB, key common.Hash, value *big.Int) {
+	db.SetState(params.ProducerRegistryAddress, key, common.BigToHash(value))
+}
+
+// getAddresses retrieves a list of addresses stored as its length at key and
+// the elements in the slots following it.
+func getAddresses(db vm.StateDB, key common.Hash) []common.Address {
+	count := get(db, key).Uint64()
+	if count == 0 {
+		return nil
+	}
+	addrs := make([]common.Address, count)
+	for i := range addrs {
+		addrs[i] = common.BigToAddress(get(db, elemKey(key, uint64(i))))
+	}
+	return addrs
+}
+
+// setAddresses replaces a list of addresses, clearing the slots of elements no
+// longer in the list.
+func setAddresses(db vm.StateDB, key common.Hash, addrs []common.Address) {
+	for i := uint64(len(addrs)); i < get(db, key).Uint64(); i++ {
+		db.SetState(params.ProducerRegistryAddress, elemKey(key, i), common.Hash{})
+	}
+	for i, addr := range addrs {
+		set(db, elemKey(key, uint64(i)), new(big.Int).SetBytes(addr.Bytes()))
+	}
+	set(db, key, big.NewInt(int64(len(addrs))))
+}
+
+// getString retrieves a string stored as its length at key and its content in
+// 32 byte chunks in the slots following it.
+func getString(db vm.StateDB, key common.Hash) string {
+	size := get(db, key).Uint64()
+
+	buf := make([]byte, 0, size+common.HashLength)
+	for i := uint64(0); uint64(len(buf)) < size; i++ {
+		buf = append(buf, db.GetState(params.ProducerRegistryAddress, elemKey(key, i)).Bytes()...)
+	}
+	return string(buf[:size])
+}
+
+// setString replaces a string, clearing the slots of chunks no longer used.
+func setString(db vm.StateDB, key common.Hash, s string) {
+	chunks := func(size uint64) uint64 { return (size + common.HashLength - 1) / common.HashLength }
+
+	for i := chunks(uint64(len(s))); i < chunks(get(db, key).Uint64()); i++ {
+		db.SetState(params.ProducerRegistryAddress, elemKey(key, i), common.Hash{})
+	}
+	for i := uint64(0); i < chunks(uint64(len(s))); i++ {
+		var chunk common.Hash
+		copy(chunk[:], s[i*common.HashLength:])
+		db.SetState(params.ProducerRegistryAddress, elemKey(key, i), chunk)
+	}
+	set(db, key, big.NewInt(int64(len(s))))
+}
+
+// touch makes sure the registry account isn't considered empty, which would get
+// it and its storage deleted at the end of the transaction.
+func touch(db vm.StateDB) {
+	if db.GetNonce(params.ProducerRegistryAddress) == 0 {
+		db.SetNonce(params.ProducerRegistryAddress, 1)
+	}
+}
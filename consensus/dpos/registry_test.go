@@ -0,0 +1,162 @@
+package dpos
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+func newTestState(t *testing.T) *state.StateDB {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	return statedb
+}
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.Ether))
+}
+
+// Tests that registrations and votes are recorded and that votes can be replaced
+// and withdrawn.
+func TestProducerVotes(t *testing.T) {
+	var (
+		db    = newTestState(t)
+		alice = common.HexToAddress("0xa1")
+		bob   = common.HexToAddress("0xa2")
+		voter = common.HexToAddress("0x01")
+	)
+	db.AddBalance(voter, ether(100))
+
+	url := strings.Repeat("u", 70) // spans multiple storage slots
+	if err := Register(db, alice, &RegisterData{Url: url, Location: "DE"}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	if err := Register(db, bob, &RegisterData{Url: "https://bob.example", Location: "SG"}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	if err := Register(db, alice, &RegisterData{Url: "https://alice.example", Location: "DE"}); err != nil {
+		t.Fatalf("failed to update registration: %v", err)
+	}
+	if producers := GetProducers(db); len(producers) != 2 || producers[0].Address != alice || producers[0].Url != "https://alice.example" {
+		t.Fatalf("producers mismatch: have %+v", producers)
+	}
+	// Invalid votes must be refused without any effect
+	invalid := []struct {
+		stake *big.Int
+		data  *VoteData
+		err   error
+	}{
+		{ether(1), &VoteData{Producers: []common.Address{common.HexToAddress("0xff")}}, ErrUnknownProducer},
+		{ether(1), &VoteData{Producers: []common.Address{alice, alice}}, ErrDuplicateProducer},
+		{ether(1000), &VoteData{Producers: []common.Address{alice}}, vm.ErrInsufficientBalance},
+		{new(big.Int), &VoteData{Producers: []common.Address{alice}}, ErrNoStake},
+	}
+	for i, tt := range invalid {
+		if err := CastVote(db, voter, big.NewInt(10), tt.stake, tt.data); err != tt.err {
+			t.Errorf("invalid vote %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	if GetVote(db, voter) != nil || db.GetBalance(voter).Cmp(ether(100)) != 0 {
+		t.Fatalf("invalid votes took effect")
+	}
+	// Vote for both, then move the vote to bob adding more stake
+	if err := CastVote(db, voter, big.NewInt(10), ether(30), &VoteData{Producers: []common.Address{alice, bob}}); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	if a, b := GetProducer(db, alice), GetProducer(db, bob); a.TotalVotesCount != 30 || b.TotalVotesCount != 30 {
+		t.Fatalf("votes mismatch: have %d and %d, want 30 each", a.TotalVotesCount, b.TotalVotesCount)
+	}
+	if err := CastVote(db, voter, big.NewInt(20), ether(20), &VoteData{Producers: []common.Address{bob}}); err != nil {
+		t.Fatalf("failed to replace vote: %v", err)
+	}
+	if a, b := GetProducer(db, alice), GetProducer(db, bob); a.TotalVotesCount != 0 || b.TotalVotesCount != 50 {
+		t.Fatalf("votes mismatch after replacement: have %d and %d, want 0 and 50", a.TotalVotesCount, b.TotalVotesCount)
+	}
+	vote := GetVote(db, voter)
+	if vote == nil || vote.VoteId != "2" || vote.Staked != 50 || vote.VoteStartTime != 20 || len(vote.Producers) != 1 || vote.Producers[0].Address != bob {
+		t.Fatalf("vote mismatch: have %+v", vote)
+	}
+	if balance := db.GetBalance(params.ProducerRegistryAddress); balance.Cmp(ether(50)) != 0 {
+		t.Fatalf("locked stake mismatch: have %v, want %v", balance, ether(50))
+	}
+	// Withdrawing returns the whole stake
+	if err := CastVote(db, voter, big.NewInt(30), new(big.Int), &VoteData{}); err != nil {
+		t.Fatalf("failed to withdraw vote: %v", err)
+	}
+	if GetVote(db, voter) != nil || GetProducer(db, bob).TotalVotesCount != 0 {
+		t.Fatalf("vote not withdrawn")
+	}
+	if db.GetBalance(voter).Cmp(ether(100)) != 0 || db.GetBalance(params.ProducerRegistryAddress).Sign() != 0 {
+		t.Fatalf("stake not returned: voter %v, registry %v", db.GetBalance(voter), db.GetBalance(params.ProducerRegistryAddress))
+	}
+}
+
+// Tests that the schedule is elected from the best voted producers at interval
+// boundaries, and that the configured producers are active until then.
+func TestProducerSchedule(t *testing.T) {
+	var (
+		db      = newTestState(t)
+		genesis = common.HexToAddress("0xaa")
+		config  = &params.ChainConfig{Ethash: &params.EthashConfig{Producers: []common.Address{genesis}}}
+	)
+	if active := ActiveProducers(db, config); len(active) != 1 || active[0] != genesis {
+		t.Fatalf("initial producers mismatch: have %v", active)
+	}
+	// Register more candidates than fit in a schedule, voted for in reverse order
+	candidates := make([]common.Address, params.MaxActiveProducers+2)
+	for i := range candidates {
+		candidates[i] = common.BigToAddress(big.NewInt(int64(0x100 + i)))
+		if err := Register(db, candidates[i], &RegisterData{}); err != nil {
+			t.Fatalf("failed to register candidate %d: %v", i, err)
+		}
+		voter := common.BigToAddress(big.NewInt(int64(0x1000 + i)))
+		db.AddBalance(voter, ether(int64(i+1)))
+		if err := CastVote(db, voter, big.NewInt(1), ether(int64(i+1)), &VoteData{Producers: []common.Address{candidates[i]}}); err != nil {
+			t.Fatalf("failed to vote for candidate %d: %v", i, err)
+		}
+	}
+	// Only interval boundaries elect a new schedule
+	if UpdateSchedule(db, new(big.Int).SetUint64(params.ProducerScheduleInterval-1)) {
+		t.Fatalf("schedule updated within interval")
+	}
+	number := new(big.Int).SetUint64(params.ProducerScheduleInterval)
+	if !UpdateSchedule(db, number) {
+		t.Fatalf("schedule not updated at interval boundary")
+	}
+	active := ActiveProducers(db, config)
+	if len(active) != params.MaxActiveProducers {
+		t.Fatalf("schedule size mismatch: have %d, want %d", len(active), params.MaxActiveProducers)
+	}
+	for i, producer := range active {
+		if want := candidates[len(candidates)-1-i]; producer != want {
+			t.Errorf("schedule slot %d: have %x, want %x", i, producer, want)
+		}
+	}
+	if ScheduleNumber(db) != number.Uint64() {
+		t.Errorf("schedule number mismatch: have %d, want %d", ScheduleNumber(db), number)
+	}
+	if GetProducer(db, candidates[0]).IsActive || !GetProducer(db, candidates[len(candidates)-1]).IsActive {
+		t.Errorf("activity flags mismatch")
+	}
+	// An unchanged election doesn't count as a schedule change
+	if UpdateSchedule(db, new(big.Int).Mul(number, big.NewInt(2))) {
+		t.Errorf("unchanged schedule reported as updated")
+	}
+	// Producing blocks is accounted to registered producers only
+	recordProduced(db, candidates[0], big.NewInt(1234))
+	recordProduced(db, genesis, big.NewInt(1234))
+	if p := GetProducer(db, candidates[0]); p.TotalProduced != 1 || p.LastProduceTime.Int64() != 1234 {
+		t.Errorf("production stats mismatch: have %+v", p)
+	}
+	if GetProducer(db, genesis) != nil {
+		t.Errorf("unregistered producer recorded")
+	}
+}
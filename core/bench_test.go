@@ -25,11 +25,11 @@ import (
 
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/math"
+	"github.com/yooba-team/yooba/core/rawdb"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/core/vm"
 	"github.com/yooba-team/yooba/crypto"
 	"github.com/yooba-team/yooba/yoobadb"
-	"github.com/yooba-team/yooba/consensus/dpos"
 	"github.com/yooba-team/yooba/params"
 )
 
@@ -81,7 +81,7 @@ func genValueTx(nbytes int) func(int, *BlockGen) {
 		toaddr := common.Address{}
 		data := make([]byte, nbytes)
 		gas, _ := IntrinsicGas(data, false)
-		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(benchRootAddr), toaddr, big.NewInt(1), gas, nil, types.TxTypeTransfer,data), types.EIP155Signer{}, benchRootKey)
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(benchRootAddr), toaddr, big.NewInt(1), gas, nil, types.TxTypeTransfer,data), types.NewEIP155Signer(params.TestChainConfig.ChainId), benchRootKey)
 		gen.AddTx(tx)
 	}
 }
@@ -122,7 +122,7 @@ func genTxRing(naccounts int) func(int, *BlockGen) {
 				types.TxTypeTransfer,
 				nil,
 			)
-			tx, _ = types.SignTx(tx, types.EIP155Signer{}, ringKeys[from])
+			tx, _ = types.SignTx(tx, types.NewEIP155Signer(params.TestChainConfig.ChainId), ringKeys[from])
 			gen.AddTx(tx)
 			from = to
 		}
@@ -135,7 +135,7 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 	// Create the database in memory or in a temporary directory.
 	var db yoobadb.Database
 	if !disk {
-		db, _ = yoobadb.NewMemDatabase()
+		db = yoobadb.NewMemDatabase()
 	} else {
 		dir, err := ioutil.TempDir("", "yoo-core-bench")
 		if err != nil {
@@ -156,11 +156,11 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 		Alloc:  GenesisAlloc{benchRootAddr: {Balance: benchRootFunds}},
 	}
 	genesis := gspec.MustCommit(db)
-	chain, _ := GenerateChain(gspec.Config, genesis, dpos.NewFaker(), db, b.N, gen)
+	chain, _ := GenerateChain(gspec.Config, genesis, db, b.N, gen)
 
 	// Time the insertion of the new chain.
 	// State and blocks are stored in the same DB.
-	chainman, _ := NewBlockChain(db, nil, gspec.Config, dpos.NewFaker(), vm.Config{})
+	chainman, _ := NewBlockChain(db, nil, gspec.Config, vm.Config{})
 	defer chainman.Stop()
 	b.ReportAllocs()
 	b.ResetTimer()
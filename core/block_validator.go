@@ -101,11 +101,17 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 // CalcGasLimit computes the gas limit of the next block after parent.
 // This is miner strategy, not consensus protocol.
 func CalcGasLimit(parent *types.Block) uint64 {
+	return CalcGovernedGasLimit(parent, params.GasLimitBoundDivisor, params.MinGasLimit)
+}
+
+// CalcGovernedGasLimit computes the gas limit of the next block after parent,
+// using the gas limit bounds currently set by governance.
+func CalcGovernedGasLimit(parent *types.Block, boundDivisor, minGasLimit uint64) uint64 {
 	// contrib = (parentGasUsed * 3 / 2) / 1024
-	contrib := (parent.GasUsed() + parent.GasUsed()/2) / params.GasLimitBoundDivisor
+	contrib := (parent.GasUsed() + parent.GasUsed()/2) / boundDivisor
 
 	// decay = parentGasLimit / 1024 -1
-	decay := parent.GasLimit()/params.GasLimitBoundDivisor - 1
+	decay := parent.GasLimit()/boundDivisor - 1
 
 	/*
 		strategy: gasLimit of block-to-mine is set based on parent's
@@ -115,8 +121,12 @@ func CalcGasLimit(parent *types.Block) uint64 {
 		from parentGasLimit * (2/3) parentGasUsed is.
 	*/
 	limit := parent.GasLimit() - decay + contrib
-	if limit < params.MinGasLimit {
-		limit = params.MinGasLimit
+	if limit < minGasLimit {
+		// Rise towards a governed minimum above the parent as fast as allowed
+		limit = minGasLimit
+		if max := parent.GasLimit() + decay; limit > max {
+			limit = max
+		}
 	}
 	// however, if we're now below the target (TargetGasLimit) we increase the
 	// limit as much as we can (parentGasLimit / 1024 -1)
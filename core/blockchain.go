@@ -31,6 +31,7 @@ import (
 	"github.com/yooba-team/yooba/consensus"
 	"github.com/yooba-team/yooba/core/rawdb"
 	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/state/snapshot"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/core/vm"
 	"github.com/yooba-team/yooba/crypto"
@@ -70,6 +71,7 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	Snapshot      bool          // Whether to maintain a flat snapshot of the state for faster reads
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -112,6 +114,7 @@ type BlockChain struct {
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
 
 	stateCache   state.Database // State database to reuse between imports (contains state cache)
+	snaps        *snapshot.Tree // Flat state snapshot, nil if disabled
 	bodyCache    *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
 	blockCache   *lru.Cache     // Cache for the most recent entire blocks
@@ -190,6 +193,10 @@ func NewBlockChain(db yoobadb.Database, cacheConfig *CacheConfig, chainConfig *p
 			}
 		}
 	}
+	// Load the flat state snapshot, generating it in the background if missing
+	if cacheConfig.Snapshot {
+		bc.snaps = snapshot.New(db, bc.stateCache.TrieDB(), bc.CurrentBlock().Root())
+	}
 	// Take ownership of this particular state
 	go bc.update()
 	return bc, nil
@@ -271,6 +278,13 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.hc.SetHead(head, delFn)
 	currentHeader := bc.hc.CurrentHeader()
 
+	// Drop the frozen blocks above the new head, the freezer doesn't rewind itself
+	if adb, ok := bc.db.(rawdb.AncientWriter); ok {
+		if err := adb.TruncateAncients(currentHeader.Number.Uint64() + 1); err != nil {
+			log.Error("Failed to truncate ancient blocks", "err", err)
+		}
+	}
+
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -304,7 +318,14 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	rawdb.WriteHeadBlockHash(bc.db, currentBlock.Hash())
 	rawdb.WriteHeadFastBlockHash(bc.db, currentFastBlock.Hash())
 
-	return bc.loadLastState()
+	if err := bc.loadLastState(); err != nil {
+		return err
+	}
+	// The snapshot may already be past the new head, regenerate it in that case
+	if root := bc.CurrentBlock().Root(); bc.snaps != nil && bc.snaps.Snapshot(root) == nil {
+		bc.snaps.Rebuild(root)
+	}
+	return nil
 }
 
 // FastSyncCommitHead sets the current head block to the one defined by the hash
@@ -323,6 +344,11 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	bc.currentBlock.Store(block)
 	bc.mu.Unlock()
 
+	// The snapshot doesn't know about the synced state yet
+	if bc.snaps != nil {
+		bc.snaps.Rebuild(block.Root())
+	}
+
 	log.Info("Committed new head block", "number", block.Number(), "hash", hash)
 	return nil
 }
@@ -379,7 +405,7 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, bc.stateCache)
+	return state.NewWithSnapshot(root, bc.stateCache, bc.snaps)
 }
 
 // Reset purges the entire blockchain, restoring it to its genesis state.
@@ -408,6 +434,9 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 	bc.hc.SetCurrentHeader(bc.genesisBlock.Header())
 	bc.currentFastBlock.Store(bc.genesisBlock)
 
+	if bc.snaps != nil && bc.snaps.Snapshot(genesis.Root()) == nil {
+		bc.snaps.Rebuild(genesis.Root())
+	}
 	return nil
 }
 
@@ -625,6 +654,11 @@ func (bc *BlockChain) TrieNode(hash common.Hash) ([]byte, error) {
 	return bc.stateCache.TrieDB().Node(hash)
 }
 
+// StateCache returns the caching database underpinning the blockchain instance.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
+
 // Stop stops the blockchain service. If any imports are currently in progress
 // it will abort them using the procInterrupt.
 func (bc *BlockChain) Stop() {
@@ -638,6 +672,14 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	// Flatten the snapshot into the head state, so it can be reused on restart.
+	// The generator has to stop before the cached tries are released below.
+	if bc.snaps != nil {
+		if err := bc.snaps.Cap(bc.CurrentBlock().Root(), 0); err != nil {
+			log.Error("Failed to persist state snapshot", "err", err)
+		}
+		bc.snaps.Release()
+	}
 	// Ensure the state of a recent block is also stored to disk before exiting.
 	// We're writing three different states to catch different restart scenarios:
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
@@ -1082,7 +1124,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		} else {
 			parent = chain[i-1]
 		}
-		state, err := state.New(parent.Root(), bc.stateCache)
+		state, err := state.NewWithSnapshot(parent.Root(), bc.stateCache, bc.snaps)
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
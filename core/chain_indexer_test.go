@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/yoobadb"
 )
@@ -30,7 +30,7 @@ import (
 
 // Tests block header storage and retrieval operations.
 func TestHeaderStorage(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
+	db := yoobadb.NewMemDatabase()
 
 	// Create a test header to move around the database and make sure it's really new
 	header := &types.Header{Number: big.NewInt(42), Extra: []byte("test header")}
@@ -65,26 +65,36 @@ func TestHeaderStorage(t *testing.T) {
 
 // Tests block body storage and retrieval operations.
 func TestBodyStorage(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
-
+	db := yoobadb.NewMemDatabase()
 
+	// Create a test body to move around the database and make sure it's really new
+	body := &types.Body{Transactions: types.Transactions{types.NewTransaction(1, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), types.TxTypeTransfer, nil)}}
 
 	hasher := sha3.NewKeccak256()
+	rlp.Encode(hasher, body)
 	hash := common.BytesToHash(hasher.Sum(nil))
 
 	if entry := GetBody(db, hash, 0); entry != nil {
 		t.Fatalf("Non existent body returned: %v", entry)
 	}
-
+	// Write and verify the body in the database
+	if err := WriteBody(db, hash, 0, body); err != nil {
+		t.Fatalf("Failed to write body into database: %v", err)
+	}
+	if entry := GetBody(db, hash, 0); entry == nil {
 		t.Fatalf("Stored body not found")
-
+	} else if types.DeriveSha(types.Transactions(entry.Transactions)) != types.DeriveSha(types.Transactions(body.Transactions)) {
+		t.Fatalf("Retrieved body mismatch: have %v, want %v", entry, body)
+	}
 	if entry := GetBodyRLP(db, hash, 0); entry == nil {
 		t.Fatalf("Stored body RLP not found")
 	} else {
 		hasher := sha3.NewKeccak256()
 		hasher.Write(entry)
 
-	
+		if calc := common.BytesToHash(hasher.Sum(nil)); calc != hash {
+			t.Fatalf("Retrieved RLP body mismatch: have %v, want %v", entry, body)
+		}
 	}
 	// Delete the body and verify the execution
 	DeleteBody(db, hash, 0)
@@ -95,7 +105,7 @@ func TestBodyStorage(t *testing.T) {
 
 // Tests block storage and retrieval operations.
 func TestBlockStorage(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
+	db := yoobadb.NewMemDatabase()
 
 	// Create a test block to move around the database and make sure it's really new
 	block := types.NewBlockWithHeader(&types.Header{
@@ -146,7 +156,7 @@ func TestBlockStorage(t *testing.T) {
 
 // Tests that partial block contents don't get reassembled into full blocks.
 func TestPartialBlockStorage(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
+	db := yoobadb.NewMemDatabase()
 	block := types.NewBlockWithHeader(&types.Header{
 		Extra:       []byte("test block"),
 		TxHash:      types.EmptyRootHash,
@@ -187,7 +197,7 @@ func TestPartialBlockStorage(t *testing.T) {
 
 // Tests that canonical numbers can be mapped to hashes and retrieved.
 func TestCanonicalMappingStorage(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
+	db := yoobadb.NewMemDatabase()
 
 	// Create a test canonical number and assinged hash to move around
 	hash, number := common.Hash{0: 0xff}, uint64(314)
@@ -212,7 +222,7 @@ func TestCanonicalMappingStorage(t *testing.T) {
 
 // Tests that head headers and head blocks can be assigned, individually.
 func TestHeadStorage(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
+	db := yoobadb.NewMemDatabase()
 
 	blockHead := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block header")})
 	blockFull := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block full")})
@@ -252,7 +262,7 @@ func TestHeadStorage(t *testing.T) {
 
 // Tests that positional lookup metadata can be stored and retrieved.
 func TestLookupStorage(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
+	db := yoobadb.NewMemDatabase()
 
 	tx1 := types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), big.NewInt(111), 1111, big.NewInt(11111), types.TxTypeTransfer,[]byte{0x11, 0x11, 0x11})
 	tx2 := types.NewTransaction(2, common.BytesToAddress([]byte{0x22}), big.NewInt(222), 2222, big.NewInt(22222), types.TxTypeTransfer, []byte{0x22, 0x22, 0x22})
@@ -297,7 +307,7 @@ func TestLookupStorage(t *testing.T) {
 
 // Tests that receipts associated with a single block can be stored and retrieved.
 func TestBlockReceiptStorage(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
+	db := yoobadb.NewMemDatabase()
 
 	receipt1 := &types.Receipt{
 		Status:            types.ReceiptStatusFailed,
@@ -32,4 +32,20 @@ var (
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local chain.
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	// ErrMultisigRequired is returned if a transaction from a multi-signature
+	// account is signed by a single key.
+	ErrMultisigRequired = errors.New("sender requires multiple signatures")
+
+	// ErrNotMultisigAccount is returned if a multi-signature transaction is sent
+	// from an account that isn't controlled by multiple keys.
+	ErrNotMultisigAccount = errors.New("sender is not a multi-signature account")
+
+	// ErrMultisigThreshold is returned if the signatures of a multi-signature
+	// transaction don't reach the threshold of the sending account.
+	ErrMultisigThreshold = errors.New("multi-signature threshold not reached")
+
+	// ErrLockedBalance is returned if the gas or value of a transaction can only
+	// be paid from balance still locked by a vesting schedule.
+	ErrLockedBalance = errors.New("insufficient unlocked balance")
 )
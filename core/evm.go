@@ -45,7 +45,7 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 		beneficiary = *author
 	}
 	return vm.Context{
-		CanTransfer: CanTransfer,
+		CanTransfer: CanTransferAt(header.Time.Uint64()),
 		Transfer:    Transfer,
 		GetHash:     GetHashFn(header, chain),
 		Origin:      msg.From(),
@@ -89,6 +89,15 @@ func CanTransfer(db vm.StateDB, addr common.Address, amount *big.Int) bool {
 	return db.GetBalance(addr).Cmp(amount) >= 0
 }
 
+// CanTransferAt returns a transfer check that also keeps the balance locked by
+// vesting schedules at the given time from being transferred, be it by the
+// account itself or by its contract code.
+func CanTransferAt(time uint64) vm.CanTransferFunc {
+	return func(db vm.StateDB, addr common.Address, amount *big.Int) bool {
+		return SpendableBalance(db, addr, time).Cmp(amount) >= 0
+	}
+}
+
 // Transfer subtracts amount from sender and adds amount to recipient using the given Db
 func Transfer(db vm.StateDB, sender, recipient common.Address, amount *big.Int) {
 	db.SubBalance(sender, amount)
@@ -10,6 +10,7 @@ import (
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/hexutil"
 	"github.com/yooba-team/yooba/common/math"
+	"github.com/yooba-team/yooba/core/types"
 )
 
 var _ = (*genesisAccountMarshaling)(nil)
@@ -20,6 +21,7 @@ func (g GenesisAccount) MarshalJSON() ([]byte, error) {
 		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
 		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
 		Nonce      math.HexOrDecimal64         `json:"nonce,omitempty"`
+		Vesting    []types.VestingSchedule     `json:"vesting,omitempty"`
 		PrivateKey hexutil.Bytes               `json:"secretKey,omitempty"`
 	}
 	var enc GenesisAccount
@@ -32,6 +34,7 @@ func (g GenesisAccount) MarshalJSON() ([]byte, error) {
 	}
 	enc.Balance = (*math.HexOrDecimal256)(g.Balance)
 	enc.Nonce = math.HexOrDecimal64(g.Nonce)
+	enc.Vesting = g.Vesting
 	enc.PrivateKey = g.PrivateKey
 	return json.Marshal(&enc)
 }
@@ -42,6 +45,7 @@ func (g *GenesisAccount) UnmarshalJSON(input []byte) error {
 		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
 		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
 		Nonce      *math.HexOrDecimal64        `json:"nonce,omitempty"`
+		Vesting    []types.VestingSchedule     `json:"vesting,omitempty"`
 		PrivateKey *hexutil.Bytes              `json:"secretKey,omitempty"`
 	}
 	var dec GenesisAccount
@@ -64,6 +68,9 @@ func (g *GenesisAccount) UnmarshalJSON(input []byte) error {
 	if dec.Nonce != nil {
 		g.Nonce = uint64(*dec.Nonce)
 	}
+	if dec.Vesting != nil {
+		g.Vesting = dec.Vesting
+	}
 	if dec.PrivateKey != nil {
 		g.PrivateKey = *dec.PrivateKey
 	}
@@ -82,6 +82,7 @@ type GenesisAccount struct {
 	Storage    map[common.Hash]common.Hash `json:"storage,omitempty"`
 	Balance    *big.Int                    `json:"balance" gencodec:"required"`
 	Nonce      uint64                      `json:"nonce,omitempty"`
+	Vesting    []types.VestingSchedule     `json:"vesting,omitempty"` // Locked parts of the balance
 	PrivateKey []byte                      `json:"secretKey,omitempty"` // for tests
 }
 
@@ -230,6 +231,9 @@ func (g *Genesis) ToBlock(db yoobadb.Database) *types.Block {
 		for key, value := range account.Storage {
 			statedb.SetState(addr, key, value)
 		}
+		if len(account.Vesting) > 0 {
+			statedb.SetVesting(addr, account.Vesting)
+		}
 	}
 	root := statedb.IntermediateRoot(false)
 	head := &types.Header{
@@ -0,0 +1,409 @@
+// Package governance implements on-chain parameter-change proposals voted on
+// by the active DPoS producers.
+//
+// A staked account submits a proposal to change one of the governed chain
+// parameters, locking params.GovernanceProposalDeposit in the governance
+// account. The active producers then vote on it during the voting period. Once
+// more than two thirds of them approved, the new value is scheduled and becomes
+// effective from the proposal's activation block onwards. Proposals rejected by
+// more than a third of the producers, or still open at the end of the voting
+// period, are closed and forfeit their deposit.
+//
+// All governance data lives in the storage of params.GovernanceAddress, so it
+// follows the state through reorgs and can be read at any block.
+package governance
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/params"
+)
+
+var (
+	ErrWrongRecipient      = errors.New("governance transaction not sent to the governance account")
+	ErrUnknownParam        = errors.New("unknown governance parameter")
+	ErrInvalidValue        = errors.New("invalid governance parameter value")
+	ErrInsufficientStake   = errors.New("insufficient stake for proposal")
+	ErrActivationTooEarly  = errors.New("activation block before end of voting period")
+	ErrUnknownProposal     = errors.New("unknown proposal")
+	ErrProposalClosed      = errors.New("proposal is closed for voting")
+	ErrNotProducer         = errors.New("voter is not an active producer")
+	ErrAlreadyVoted        = errors.New("producer already voted on proposal")
+	ErrUnexpectedVoteValue = errors.New("vote transactions can't transfer value")
+)
+
+// Param identifies a chain parameter that can be changed through governance.
+type Param uint8
+
+const (
+	GasLimitBoundDivisor Param = iota // Bound divisor of the gas limit between blocks
+	MinGasLimit                       // Minimum gas limit of a block
+	MinGasPrice                       // Price floor enforced by the transaction pool
+	BlockReward                       // Reward credited to the producer of a block
+
+	numParams
+)
+
+var paramNames = [numParams]string{
+	GasLimitBoundDivisor: "gasLimitBoundDivisor",
+	MinGasLimit:          "minGasLimit",
+	MinGasPrice:          "minGasPrice",
+	BlockReward:          "blockReward",
+}
+
+// String implements the fmt.Stringer interface.
+func (p Param) String() string {
+	if p >= numParams {
+		return "unknown"
+	}
+	return paramNames[p]
+}
+
+// ParseParam returns the governed parameter with the given name.
+func ParseParam(name string) (Param, error) {
+	for p, n := range paramNames {
+		if n == name {
+			return Param(p), nil
+		}
+	}
+	return 0, ErrUnknownParam
+}
+
+// validate checks whether value is acceptable for the parameter. The gas limit
+// parameters are kept within bounds the chain can always follow, so no vote can
+// halt block production.
+func (p Param) validate(value *big.Int) error {
+	switch p {
+	case GasLimitBoundDivisor:
+		if !value.IsUint64() || value.Uint64() < params.MinGovernedGasLimitBoundDivisor || value.Uint64() > params.MaxGovernedGasLimitBoundDivisor {
+			return ErrInvalidValue
+		}
+	case MinGasLimit:
+		if !value.IsUint64() || value.Uint64() < params.MinGasLimit || value.Uint64() > params.TargetGasLimit {
+			return ErrInvalidValue
+		}
+	case MinGasPrice, BlockReward:
+		if value.Sign() < 0 || value.BitLen() > 256 {
+			return ErrInvalidValue
+		}
+	default:
+		return ErrUnknownParam
+	}
+	return nil
+}
+
+// Params is the set of governed chain parameters effective at a given block.
+type Params struct {
+	GasLimitBoundDivisor uint64
+	MinGasLimit          uint64
+	MinGasPrice          *big.Int
+	BlockReward          *big.Int
+}
+
+// DefaultParams returns the compile-time parameters used until governance
+// changes them.
+func DefaultParams() *Params {
+	return &Params{
+		GasLimitBoundDivisor: params.GasLimitBoundDivisor,
+		MinGasLimit:          params.MinGasLimit,
+		MinGasPrice:          new(big.Int),
+		BlockReward:          new(big.Int),
+	}
+}
+
+// ReadParams retrieves the governed parameters effective for the block with
+// the given number, based on the state the block is executed on.
+func ReadParams(db vm.StateDB, number *big.Int) *Params {
+	p := DefaultParams()
+	if v := Value(db, GasLimitBoundDivisor, number); v.Sign() > 0 {
+		p.GasLimitBoundDivisor = v.Uint64()
+	}
+	if v := Value(db, MinGasLimit, number); v.Sign() > 0 {
+		p.MinGasLimit = v.Uint64()
+	}
+	p.MinGasPrice = Value(db, MinGasPrice, number)
+	p.BlockReward = Value(db, BlockReward, number)
+	return p
+}
+
+// Value retrieves the value of a single governed parameter effective for the
+// block with the given number. Zero is returned if governance never changed the
+// parameter, callers are expected to fall back to the defaults in that case.
+func Value(db vm.StateDB, p Param, number *big.Int) *big.Int {
+	if activation := get(db, paramKey(p, fieldActivation)); activation.Sign() > 0 && activation.Cmp(number) <= 0 {
+		return get(db, paramKey(p, fieldPending))
+	}
+	return get(db, paramKey(p, fieldCurrent))
+}
+
+// Status is the lifecycle state of a proposal.
+type Status uint8
+
+const (
+	StatusOpen Status = iota
+	StatusApproved
+	StatusRejected
+	StatusExpired
+)
+
+// String implements the fmt.Stringer interface.
+func (s Status) String() string {
+	switch s {
+	case StatusOpen:
+		return "open"
+	case StatusApproved:
+		return "approved"
+	case StatusRejected:
+		return "rejected"
+	case StatusExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// ProposalData is the RLP encoded payload of a TxTypeProposal transaction.
+type ProposalData struct {
+	Param      string
+	Value      *big.Int
+	Activation uint64 // Block number from which the new value is effective
+}
+
+// VoteData is the RLP encoded payload of a TxTypeProposalVote transaction.
+type VoteData struct {
+	Proposal uint64
+	Approve  bool
+}
+
+// Proposal is a parameter-change proposal as stored in the governance state.
+type Proposal struct {
+	Id         uint64
+	Proposer   common.Address
+	Param      Param
+	Value      *big.Int
+	Deposit    *big.Int
+	Activation uint64
+	Deadline   uint64 // Last block in which producers may vote
+	Yeas       uint64
+	Nays       uint64
+	Status     Status
+}
+
+// Propose records a new parameter-change proposal submitted by proposer in
+// block number, moving the deposit into the governance account. It returns the
+// id of the new proposal.
+func Propose(db vm.StateDB, proposer common.Address, number *big.Int, deposit *big.Int, data *ProposalData) (uint64, error) {
+	param, err := ParseParam(data.Param)
+	if err != nil {
+		return 0, err
+	}
+	if data.Value == nil {
+		return 0, ErrInvalidValue
+	}
+	if err := param.validate(data.Value); err != nil {
+		return 0, err
+	}
+	if deposit.Cmp(params.GovernanceProposalDeposit) < 0 {
+		return 0, ErrInsufficientStake
+	}
+	if db.GetBalance(proposer).Cmp(deposit) < 0 {
+		return 0, vm.ErrInsufficientBalance
+	}
+	deadline := number.Uint64() + params.GovernanceVotingPeriod
+	if data.Activation <= deadline {
+		return 0, ErrActivationTooEarly
+	}
+	touch(db)
+
+	id := get(db, countKey).Uint64() + 1
+	set(db, countKey, new(big.Int).SetUint64(id))
+
+	set(db, proposalKey(id, fieldProposer), new(big.Int).SetBytes(proposer.Bytes()))
+	set(db, proposalKey(id, fieldParam), big.NewInt(int64(param)))
+	set(db, proposalKey(id, fieldValue), data.Value)
+	set(db, proposalKey(id, fieldDeposit), deposit)
+	set(db, proposalKey(id, fieldActivation), new(big.Int).SetUint64(data.Activation))
+	set(db, proposalKey(id, fieldDeadline), new(big.Int).SetUint64(deadline))
+
+	db.SubBalance(proposer, deposit)
+	db.AddBalance(params.GovernanceAddress, deposit)
+
+	return id, nil
+}
+
+// Vote records the vote of a producer on an open proposal. The proposal is
+// approved as soon as more than two thirds of the producers voted for it, and
+// rejected once that majority can't be reached anymore.
+func Vote(db vm.StateDB, voter common.Address, number *big.Int, producers []common.Address, data *VoteData) error {
+	if !isProducer(producers, voter) {
+		return ErrNotProducer
+	}
+	proposal := GetProposal(db, data.Proposal)
+	if proposal == nil {
+		return ErrUnknownProposal
+	}
+	if proposal.Status != StatusOpen || number.Uint64() > proposal.Deadline {
+		return ErrProposalClosed
+	}
+	if get(db, voteKey(data.Proposal, voter)).Sign() != 0 {
+		return ErrAlreadyVoted
+	}
+	set(db, voteKey(data.Proposal, voter), common.Big1)
+
+	total := uint64(len(producers))
+	if data.Approve {
+		proposal.Yeas++
+		set(db, proposalKey(proposal.Id, fieldYeas), new(big.Int).SetUint64(proposal.Yeas))
+	} else {
+		proposal.Nays++
+		set(db, proposalKey(proposal.Id, fieldNays), new(big.Int).SetUint64(proposal.Nays))
+	}
+	switch {
+	case proposal.Yeas*3 > total*2:
+		approve(db, proposal, number)
+	case proposal.Nays*3 >= total:
+		forfeit(db, proposal, StatusRejected)
+	}
+	return nil
+}
+
+// CloseExpired closes the proposals whose voting period ended before the block
+// with the given number without reaching a decision. It is called by the
+// consensus engine when finalizing every block.
+func CloseExpired(db vm.StateDB, number *big.Int) {
+	var (
+		count   = get(db, countKey).Uint64()
+		settled = get(db, settledKey).Uint64()
+	)
+	// Deadlines grow with the proposal ids, so the expired proposals are always
+	// the ones following the last settled one
+	id := settled + 1
+	for ; id <= count; id++ {
+		proposal := GetProposal(db, id)
+		if proposal.Deadline >= number.Uint64() {
+			break
+		}
+		if proposal.Status == StatusOpen {
+			forfeit(db, proposal, StatusExpired)
+		}
+	}
+	if id-1 != settled {
+		set(db, settledKey, new(big.Int).SetUint64(id-1))
+	}
+}
+
+// approve marks the proposal approved, schedules the new parameter value and
+// returns the deposit to the proposer.
+func approve(db vm.StateDB, proposal *Proposal, number *big.Int) {
+	set(db, proposalKey(proposal.Id, fieldStatus), big.NewInt(int64(StatusApproved)))
+
+	// Settle a previously scheduled value that already took effect, so the
+	// pending slot can be reused for this proposal. A scheduled value that is
+	// not yet effective is superseded by the later approval.
+	current := Value(db, proposal.Param, number)
+	set(db, paramKey(proposal.Param, fieldCurrent), current)
+	set(db, paramKey(proposal.Param, fieldPending), proposal.Value)
+	set(db, paramKey(proposal.Param, fieldActivation), new(big.Int).SetUint64(proposal.Activation))
+
+	db.SubBalance(params.GovernanceAddress, proposal.Deposit)
+	db.AddBalance(proposal.Proposer, proposal.Deposit)
+}
+
+// forfeit closes the proposal with the given status and burns its deposit.
+func forfeit(db vm.StateDB, proposal *Proposal, status Status) {
+	set(db, proposalKey(proposal.Id, fieldStatus), big.NewInt(int64(status)))
+	db.SubBalance(params.GovernanceAddress, proposal.Deposit)
+}
+
+// GetProposal retrieves the proposal with the given id, or nil if it doesn't
+// exist.
+func GetProposal(db vm.StateDB, id uint64) *Proposal {
+	if id == 0 || id > get(db, countKey).Uint64() {
+		return nil
+	}
+	return &Proposal{
+		Id:         id,
+		Proposer:   common.BigToAddress(get(db, proposalKey(id, fieldProposer))),
+		Param:      Param(get(db, proposalKey(id, fieldParam)).Uint64()),
+		Value:      get(db, proposalKey(id, fieldValue)),
+		Deposit:    get(db, proposalKey(id, fieldDeposit)),
+		Activation: get(db, proposalKey(id, fieldActivation)).Uint64(),
+		Deadline:   get(db, proposalKey(id, fieldDeadline)).Uint64(),
+		Yeas:       get(db, proposalKey(id, fieldYeas)).Uint64(),
+		Nays:       get(db, proposalKey(id, fieldNays)).Uint64(),
+		Status:     Status(get(db, proposalKey(id, fieldStatus)).Uint64()),
+	}
+}
+
+// ProposalCount returns the number of proposals ever submitted.
+func ProposalCount(db vm.StateDB) uint64 {
+	return get(db, countKey).Uint64()
+}
+
+func isProducer(producers []common.Address, addr common.Address) bool {
+	for _, producer := range producers {
+		if producer == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Storage layout of the governance account.
+const (
+	fieldCurrent byte = iota
+	fieldPending
+	fieldActivation
+	fieldProposer
+	fieldParam
+	fieldValue
+	fieldDeposit
+	fieldDeadline
+	fieldYeas
+	fieldNays
+	fieldStatus
+)
+
+var (
+	countKey   = crypto.Keccak256Hash([]byte("proposal-count"))
+	settledKey = crypto.Keccak256Hash([]byte("proposal-settled")) // Last proposal checked for expiry
+)
+
+func paramKey(p Param, field byte) common.Hash {
+	return crypto.Keccak256Hash([]byte("param"), []byte{byte(p), field})
+}
+
+func proposalKey(id uint64, field byte) common.Hash {
+	return crypto.Keccak256Hash([]byte("proposal"), encodeId(id), []byte{field})
+}
+
+func voteKey(id uint64, voter common.Address) common.Hash {
+	return crypto.Keccak256Hash([]byte("vote"), encodeId(id), voter.Bytes())
+}
+
+func encodeId(id uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, id)
+	return enc
+}
+
+func get(db vm.StateDB, key common.Hash) *big.Int {
+	return db.GetState(params.GovernanceAddress, key).Big()
+}
+
+func set(db vm.StateDB, key common.Hash, value *big.Int) {
+	db.SetState(params.GovernanceAddress, key, common.BigToHash(value))
+}
+
+// touch makes sure the governance account isn't considered empty, which would
+// get it and its storage deleted at the end of the transaction.
+func touch(db vm.StateDB) {
+	if db.GetNonce(params.GovernanceAddress) == 0 {
+		db.SetNonce(params.GovernanceAddress, 1)
+	}
+}
@@ -0,0 +1,199 @@
+package governance
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+func newTestState(t *testing.T) *state.StateDB {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	return statedb
+}
+
+func TestProposalLifecycle(t *testing.T) {
+	var (
+		db        = newTestState(t)
+		proposer  = common.HexToAddress("0x01")
+		producers = []common.Address{common.HexToAddress("0xa1"), common.HexToAddress("0xa2"), common.HexToAddress("0xa3")}
+		deposit   = params.GovernanceProposalDeposit
+		number    = big.NewInt(10)
+	)
+	db.AddBalance(proposer, deposit)
+
+	activation := number.Uint64() + params.GovernanceVotingPeriod + 100
+	id, err := Propose(db, proposer, number, deposit, &ProposalData{Param: "minGasPrice", Value: big.NewInt(5), Activation: activation})
+	if err != nil {
+		t.Fatalf("failed to propose: %v", err)
+	}
+	if db.GetBalance(proposer).Sign() != 0 {
+		t.Fatalf("deposit not locked: balance %v", db.GetBalance(proposer))
+	}
+	// Non-producers and double votes must be refused
+	if err := Vote(db, proposer, number, producers, &VoteData{Proposal: id, Approve: true}); err != ErrNotProducer {
+		t.Fatalf("non-producer vote error mismatch: have %v, want %v", err, ErrNotProducer)
+	}
+	if err := Vote(db, producers[0], number, producers, &VoteData{Proposal: id, Approve: true}); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	if err := Vote(db, producers[0], number, producers, &VoteData{Proposal: id, Approve: true}); err != ErrAlreadyVoted {
+		t.Fatalf("double vote error mismatch: have %v, want %v", err, ErrAlreadyVoted)
+	}
+	if status := GetProposal(db, id).Status; status != StatusOpen {
+		t.Fatalf("status mismatch after single vote: have %v, want %v", status, StatusOpen)
+	}
+	// Two out of three is not a two thirds supermajority, three is
+	if err := Vote(db, producers[1], number, producers, &VoteData{Proposal: id, Approve: true}); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	if status := GetProposal(db, id).Status; status != StatusOpen {
+		t.Fatalf("status mismatch after two votes: have %v, want %v", status, StatusOpen)
+	}
+	if err := Vote(db, producers[2], number, producers, &VoteData{Proposal: id, Approve: true}); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	if status := GetProposal(db, id).Status; status != StatusApproved {
+		t.Fatalf("status mismatch after all votes: have %v, want %v", status, StatusApproved)
+	}
+	if db.GetBalance(proposer).Cmp(deposit) != 0 {
+		t.Fatalf("deposit not refunded: have %v, want %v", db.GetBalance(proposer), deposit)
+	}
+	// The new value only takes effect from the activation block on
+	before := new(big.Int).SetUint64(activation - 1)
+	if p := ReadParams(db, before); p.MinGasPrice.Sign() != 0 {
+		t.Errorf("value effective before activation: %v", p.MinGasPrice)
+	}
+	after := new(big.Int).SetUint64(activation)
+	if p := ReadParams(db, after); p.MinGasPrice.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("value mismatch after activation: have %v, want %v", p.MinGasPrice, 5)
+	}
+}
+
+func TestProposalRejection(t *testing.T) {
+	var (
+		db        = newTestState(t)
+		proposer  = common.HexToAddress("0x01")
+		producers = []common.Address{common.HexToAddress("0xa1"), common.HexToAddress("0xa2"), common.HexToAddress("0xa3")}
+		deposit   = params.GovernanceProposalDeposit
+		number    = big.NewInt(1)
+	)
+	db.AddBalance(proposer, deposit)
+
+	activation := number.Uint64() + params.GovernanceVotingPeriod + 1
+	id, err := Propose(db, proposer, number, deposit, &ProposalData{Param: "gasLimitBoundDivisor", Value: big.NewInt(2048), Activation: activation})
+	if err != nil {
+		t.Fatalf("failed to propose: %v", err)
+	}
+	if err := Vote(db, producers[0], number, producers, &VoteData{Proposal: id, Approve: false}); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	if status := GetProposal(db, id).Status; status != StatusRejected {
+		t.Fatalf("status mismatch: have %v, want %v", status, StatusRejected)
+	}
+	if err := Vote(db, producers[1], number, producers, &VoteData{Proposal: id, Approve: true}); err != ErrProposalClosed {
+		t.Fatalf("vote on closed proposal error mismatch: have %v, want %v", err, ErrProposalClosed)
+	}
+	if db.GetBalance(proposer).Sign() != 0 {
+		t.Errorf("deposit of rejected proposal refunded")
+	}
+	if db.GetBalance(params.GovernanceAddress).Sign() != 0 {
+		t.Errorf("deposit of rejected proposal not burnt")
+	}
+	if p := ReadParams(db, new(big.Int).SetUint64(activation)); p.GasLimitBoundDivisor != params.GasLimitBoundDivisor {
+		t.Errorf("rejected value took effect: %d", p.GasLimitBoundDivisor)
+	}
+}
+
+func TestProposeValidation(t *testing.T) {
+	var (
+		db       = newTestState(t)
+		proposer = common.HexToAddress("0x01")
+		number   = big.NewInt(1)
+		late     = number.Uint64() + params.GovernanceVotingPeriod + 1
+	)
+	db.AddBalance(proposer, params.GovernanceProposalDeposit)
+
+	tests := []struct {
+		deposit *big.Int
+		data    *ProposalData
+		err     error
+	}{
+		{params.GovernanceProposalDeposit, &ProposalData{Param: "unknown", Value: big.NewInt(1), Activation: late}, ErrUnknownParam},
+		{params.GovernanceProposalDeposit, &ProposalData{Param: "minGasLimit", Value: big.NewInt(0), Activation: late}, ErrInvalidValue},
+		{params.GovernanceProposalDeposit, &ProposalData{Param: "minGasLimit", Value: new(big.Int).SetUint64(params.MinGasLimit - 1), Activation: late}, ErrInvalidValue},
+		{params.GovernanceProposalDeposit, &ProposalData{Param: "minGasLimit", Value: new(big.Int).SetUint64(params.TargetGasLimit + 1), Activation: late}, ErrInvalidValue},
+		{params.GovernanceProposalDeposit, &ProposalData{Param: "gasLimitBoundDivisor", Value: big.NewInt(1), Activation: late}, ErrInvalidValue},
+		{params.GovernanceProposalDeposit, &ProposalData{Param: "gasLimitBoundDivisor", Value: new(big.Int).SetUint64(params.MaxGovernedGasLimitBoundDivisor + 1), Activation: late}, ErrInvalidValue},
+		{big.NewInt(1), &ProposalData{Param: "minGasPrice", Value: big.NewInt(1), Activation: late}, ErrInsufficientStake},
+		{params.GovernanceProposalDeposit, &ProposalData{Param: "minGasPrice", Value: big.NewInt(1), Activation: late - 1}, ErrActivationTooEarly},
+	}
+	for i, tt := range tests {
+		if _, err := Propose(db, proposer, number, tt.deposit, tt.data); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	if count := ProposalCount(db); count != 0 {
+		t.Errorf("invalid proposals recorded: %d", count)
+	}
+}
+
+func TestProposalExpiry(t *testing.T) {
+	var (
+		db        = newTestState(t)
+		proposer  = common.HexToAddress("0x01")
+		producers = []common.Address{common.HexToAddress("0xa1"), common.HexToAddress("0xa2"), common.HexToAddress("0xa3")}
+		deposit   = params.GovernanceProposalDeposit
+	)
+	db.AddBalance(proposer, new(big.Int).Mul(deposit, big.NewInt(2)))
+
+	// Submit two proposals in consecutive blocks, rejecting none of them
+	var ids []uint64
+	for number := uint64(1); number <= 2; number++ {
+		activation := number + params.GovernanceVotingPeriod + 1
+		id, err := Propose(db, proposer, new(big.Int).SetUint64(number), deposit, &ProposalData{Param: "minGasPrice", Value: big.NewInt(1), Activation: activation})
+		if err != nil {
+			t.Fatalf("failed to propose: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := Vote(db, producers[0], big.NewInt(2), producers, &VoteData{Proposal: ids[1], Approve: true}); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	// Proposals stay open up to and including their deadline
+	deadline := GetProposal(db, ids[0]).Deadline
+	CloseExpired(db, new(big.Int).SetUint64(deadline))
+	if status := GetProposal(db, ids[0]).Status; status != StatusOpen {
+		t.Fatalf("status mismatch at deadline: have %v, want %v", status, StatusOpen)
+	}
+	// The block after the deadline closes the first proposal only
+	CloseExpired(db, new(big.Int).SetUint64(deadline+1))
+	if status := GetProposal(db, ids[0]).Status; status != StatusExpired {
+		t.Fatalf("status mismatch after deadline: have %v, want %v", status, StatusExpired)
+	}
+	if status := GetProposal(db, ids[1]).Status; status != StatusOpen {
+		t.Fatalf("later proposal closed early: %v", status)
+	}
+	if balance := db.GetBalance(params.GovernanceAddress); balance.Cmp(deposit) != 0 {
+		t.Fatalf("expired deposit not burnt: governance balance %v, want %v", balance, deposit)
+	}
+	if err := Vote(db, producers[1], new(big.Int).SetUint64(deadline+1), producers, &VoteData{Proposal: ids[0], Approve: true}); err != ErrProposalClosed {
+		t.Fatalf("vote on expired proposal error mismatch: have %v, want %v", err, ErrProposalClosed)
+	}
+	CloseExpired(db, new(big.Int).SetUint64(deadline+2))
+	if status := GetProposal(db, ids[1]).Status; status != StatusExpired {
+		t.Fatalf("status mismatch after second deadline: have %v, want %v", status, StatusExpired)
+	}
+	if balance := db.GetBalance(params.GovernanceAddress); balance.Sign() != 0 {
+		t.Errorf("deposits left in governance account: %v", balance)
+	}
+	if balance := db.GetBalance(proposer); balance.Sign() != 0 {
+		t.Errorf("expired deposits refunded: %v", balance)
+	}
+}
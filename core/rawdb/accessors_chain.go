@@ -25,11 +25,17 @@ import (
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/log"
 	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/yoobadb"
 )
 
 // ReadCanonicalHash retrieves the hash assigned to a canonical block number.
 func ReadCanonicalHash(db DatabaseReader, number uint64) common.Hash {
 	data, _ := db.Get(headerHashKey(number))
+	if len(data) == 0 {
+		if adb, ok := db.(AncientReader); ok {
+			data, _ = adb.Ancient(freezerHashTable, number)
+		}
+	}
 	if len(data) == 0 {
 		return common.Hash{}
 	}
@@ -50,6 +56,23 @@ func DeleteCanonicalHash(db DatabaseDeleter, number uint64) {
 	}
 }
 
+// ReadAllHashes retrieves the hashes of all blocks with the given number in the
+// key-value store, canonical or not.
+func ReadAllHashes(db yoobadb.Iteratee, number uint64) []common.Hash {
+	prefix := headerKey(number, common.Hash{})[:len(headerPrefix)+8]
+
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	var hashes []common.Hash
+	for it.Next() {
+		if key := it.Key(); len(key) == len(prefix)+common.HashLength {
+			hashes = append(hashes, common.BytesToHash(key[len(prefix):]))
+		}
+	}
+	return hashes
+}
+
 // ReadHeaderNumber returns the header number assigned to a hash.
 func ReadHeaderNumber(db DatabaseReader, hash common.Hash) *uint64 {
 	data, _ := db.Get(headerNumberKey(hash))
@@ -126,16 +149,42 @@ func WriteFastTrieProgress(db DatabaseWriter, count uint64) {
 	}
 }
 
+// ReadSnapSyncStatus retrieves the serialized progress of the range based state
+// sync to allow resuming it across restarts.
+func ReadSnapSyncStatus(db DatabaseReader) []byte {
+	data, _ := db.Get(snapSyncStatusKey)
+	return data
+}
+
+// WriteSnapSyncStatus stores the serialized progress of the range based state
+// sync.
+func WriteSnapSyncStatus(db DatabaseWriter, status []byte) {
+	if err := db.Put(snapSyncStatusKey, status); err != nil {
+		log.Crit("Failed to store snap sync status", "err", err)
+	}
+}
+
+// DeleteSnapSyncStatus removes the progress of the range based state sync once
+// the synced state was committed.
+func DeleteSnapSyncStatus(db DatabaseDeleter) {
+	if err := db.Delete(snapSyncStatusKey); err != nil {
+		log.Crit("Failed to remove snap sync status", "err", err)
+	}
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerHeaderTable, hash, number)
+	}
 	return data
 }
 
 // HasHeader verifies the existence of a block header corresponding to the hash.
 func HasHeader(db DatabaseReader, hash common.Hash, number uint64) bool {
 	if has, err := db.Has(headerKey(number, hash)); !has || err != nil {
-		return false
+		return isAncient(db, hash, number)
 	}
 	return true
 }
@@ -191,6 +240,9 @@ func DeleteHeader(db DatabaseDeleter, hash common.Hash, number uint64) {
 // ReadBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func ReadBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(blockBodyKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerBodiesTable, hash, number)
+	}
 	return data
 }
 
@@ -204,7 +256,7 @@ func WriteBodyRLP(db DatabaseWriter, hash common.Hash, number uint64, rlp rlp.Ra
 // HasBody verifies the existence of a block body corresponding to the hash.
 func HasBody(db DatabaseReader, hash common.Hash, number uint64) bool {
 	if has, err := db.Has(blockBodyKey(number, hash)); !has || err != nil {
-		return false
+		return isAncient(db, hash, number)
 	}
 	return true
 }
@@ -239,9 +291,19 @@ func DeleteBody(db DatabaseDeleter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadTdRLP retrieves a block's total difficulty corresponding to the hash in
+// its raw RLP database encoding.
+func ReadTdRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
+	data, _ := db.Get(headerTDKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerDifficultyTable, hash, number)
+	}
+	return data
+}
+
 // ReadTd retrieves a block's total difficulty corresponding to the hash.
 func ReadTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
-	data, _ := db.Get(headerTDKey(number, hash))
+	data := ReadTdRLP(db, hash, number)
 	if len(data) == 0 {
 		return nil
 	}
@@ -271,10 +333,20 @@ func DeleteTd(db DatabaseDeleter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadReceiptsRLP retrieves all the transaction receipts belonging to a block in
+// their raw RLP database encoding.
+func ReadReceiptsRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
+	data, _ := db.Get(blockReceiptsKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerReceiptTable, hash, number)
+	}
+	return data
+}
+
 // ReadReceipts retrieves all the transaction receipts belonging to a block.
 func ReadReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
 	// Retrieve the flattened receipt slice
-	data, _ := db.Get(blockReceiptsKey(number, hash))
+	data := ReadReceiptsRLP(db, hash, number)
 	if len(data) == 0 {
 		return nil
 	}
@@ -347,6 +419,37 @@ func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteTd(db, hash, number)
 }
 
+// DeleteBlockWithoutNumber removes all block data associated with a hash, except
+// the hash to number mapping.
+func DeleteBlockWithoutNumber(db DatabaseDeleter, hash common.Hash, number uint64) {
+	DeleteReceipts(db, hash, number)
+	if err := db.Delete(headerKey(number, hash)); err != nil {
+		log.Crit("Failed to delete header", "err", err)
+	}
+	DeleteBody(db, hash, number)
+	DeleteTd(db, hash, number)
+}
+
+// readAncient retrieves an item of the given kind belonging to a block from the
+// freezer, if the database has one and the block is a frozen canonical one.
+func readAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	if !isAncient(db, hash, number) {
+		return nil
+	}
+	data, _ := db.(AncientReader).Ancient(kind, number)
+	return data
+}
+
+// isAncient reports whether the block is a canonical one moved into the freezer.
+func isAncient(db DatabaseReader, hash common.Hash, number uint64) bool {
+	adb, ok := db.(AncientReader)
+	if !ok {
+		return false
+	}
+	data, _ := adb.Ancient(freezerHashTable, number)
+	return len(data) == common.HashLength && common.BytesToHash(data) == hash
+}
+
 // FindCommonAncestor returns the last common ancestor of two block headers
 func FindCommonAncestor(db DatabaseReader, a, b *types.Header) *types.Header {
 	for bn := b.Number.Uint64(); a.Number.Uint64() > bn; {
@@ -0,0 +1,97 @@
+package rawdb
+
+import (
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/log"
+)
+
+// ReadSnapshotRoot retrieves the root of the state the flat snapshot on disk
+// belongs to.
+func ReadSnapshotRoot(db DatabaseReader) common.Hash {
+	data, _ := db.Get(snapshotRootKey)
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteSnapshotRoot stores the root of the state the flat snapshot on disk
+// belongs to.
+func WriteSnapshotRoot(db DatabaseWriter, root common.Hash) {
+	if err := db.Put(snapshotRootKey, root[:]); err != nil {
+		log.Crit("Failed to store snapshot root", "err", err)
+	}
+}
+
+// DeleteSnapshotRoot removes the snapshot root, marking the flat snapshot on
+// disk unusable.
+func DeleteSnapshotRoot(db DatabaseDeleter) {
+	if err := db.Delete(snapshotRootKey); err != nil {
+		log.Crit("Failed to remove snapshot root", "err", err)
+	}
+}
+
+// ReadSnapshotGenerator retrieves the position of an unfinished snapshot
+// generation, or nil if the snapshot is complete.
+func ReadSnapshotGenerator(db DatabaseReader) []byte {
+	data, _ := db.Get(snapshotGeneratorKey)
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// WriteSnapshotGenerator stores the position of an unfinished snapshot generation.
+func WriteSnapshotGenerator(db DatabaseWriter, marker []byte) {
+	if err := db.Put(snapshotGeneratorKey, marker); err != nil {
+		log.Crit("Failed to store snapshot generator", "err", err)
+	}
+}
+
+// DeleteSnapshotGenerator removes the snapshot generation position, marking the
+// snapshot complete.
+func DeleteSnapshotGenerator(db DatabaseDeleter) {
+	if err := db.Delete(snapshotGeneratorKey); err != nil {
+		log.Crit("Failed to remove snapshot generator", "err", err)
+	}
+}
+
+// ReadAccountSnapshot retrieves the snapshot entry of an account trie leaf.
+func ReadAccountSnapshot(db DatabaseReader, hash common.Hash) []byte {
+	data, _ := db.Get(accountSnapshotKey(hash))
+	return data
+}
+
+// WriteAccountSnapshot stores the snapshot entry of an account trie leaf.
+func WriteAccountSnapshot(db DatabaseWriter, hash common.Hash, entry []byte) {
+	if err := db.Put(accountSnapshotKey(hash), entry); err != nil {
+		log.Crit("Failed to store account snapshot", "err", err)
+	}
+}
+
+// DeleteAccountSnapshot removes the snapshot entry of an account trie leaf.
+func DeleteAccountSnapshot(db DatabaseDeleter, hash common.Hash) {
+	if err := db.Delete(accountSnapshotKey(hash)); err != nil {
+		log.Crit("Failed to delete account snapshot", "err", err)
+	}
+}
+
+// ReadStorageSnapshot retrieves the snapshot entry of a storage trie leaf.
+func ReadStorageSnapshot(db DatabaseReader, accountHash, storageHash common.Hash) []byte {
+	data, _ := db.Get(storageSnapshotKey(accountHash, storageHash))
+	return data
+}
+
+// WriteStorageSnapshot stores the snapshot entry of a storage trie leaf.
+func WriteStorageSnapshot(db DatabaseWriter, accountHash, storageHash common.Hash, entry []byte) {
+	if err := db.Put(storageSnapshotKey(accountHash, storageHash), entry); err != nil {
+		log.Crit("Failed to store storage snapshot", "err", err)
+	}
+}
+
+// DeleteStorageSnapshot removes the snapshot entry of a storage trie leaf.
+func DeleteStorageSnapshot(db DatabaseDeleter, accountHash, storageHash common.Hash) {
+	if err := db.Delete(storageSnapshotKey(accountHash, storageHash)); err != nil {
+		log.Crit("Failed to delete storage snapshot", "err", err)
+	}
+}
@@ -0,0 +1,93 @@
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// freezerdb is a key-value store backed database serving the ancient chain
+// segment from a freezer. The accessors read ancient blocks transparently from
+// it via the AncientReader interface.
+type freezerdb struct {
+	yoobadb.Database
+	*freezer
+}
+
+// Close closes both the freezer and the key-value store.
+func (frdb *freezerdb) Close() {
+	if err := frdb.freezer.Close(); err != nil {
+		log.Error("Failed to close ancient database", "err", err)
+	}
+	frdb.Database.Close()
+}
+
+// Stat returns a particular internal stat of the key-value store, adding the
+// freezer statistics to the summary requested by an empty property.
+func (frdb *freezerdb) Stat(property string) (string, error) {
+	stats, err := frdb.Database.Stat(property)
+	if err != nil || property != "" {
+		return stats, err
+	}
+	frozen, _ := frdb.Ancients()
+	size, err := frdb.AncientSize()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\nAncient blocks: %d\nAncient size:   %v\n", stats, frozen, common.StorageSize(size)), nil
+}
+
+// NewDatabaseWithFreezer wraps a key-value store with the freezer in the given
+// directory, and starts moving the canonical blocks older than the threshold
+// below the head into it. The key-value store is closed along with the returned
+// database, but not if an error is returned.
+func NewDatabaseWithFreezer(db yoobadb.Database, freezer string, threshold uint64) (yoobadb.Database, error) {
+	frdb, err := newFreezer(freezer, threshold)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFreezer(db, frdb); err != nil {
+		frdb.Close()
+		return nil, err
+	}
+	frdb.wg.Add(1)
+	go frdb.freeze(db)
+
+	return &freezerdb{
+		Database: db,
+		freezer:  frdb,
+	}, nil
+}
+
+// checkFreezer ensures that the freezer and the key-value store belong to the
+// same chain and that no blocks are missing in between, since they may be stored
+// on different disks and get mixed up.
+func checkFreezer(db yoobadb.Database, frdb *freezer) error {
+	frozen, _ := frdb.Ancients()
+	if frozen == 0 {
+		return nil
+	}
+	kvgenesis, _ := db.Get(headerHashKey(0))
+	if len(kvgenesis) == 0 {
+		return fmt.Errorf("ancient database holds %d blocks, but the chain database is empty", frozen)
+	}
+	if frgenesis, _ := frdb.Ancient(freezerHashTable, 0); !bytes.Equal(kvgenesis, frgenesis) {
+		return fmt.Errorf("genesis mismatch: %#x (chain database) != %#x (ancient database)", kvgenesis, frgenesis)
+	}
+	number := ReadHeaderNumber(db, ReadHeadHeaderHash(db))
+	if number == nil {
+		return nil
+	}
+	// Drop the frozen blocks above a head rewound while the freezer was away
+	if *number+1 < frozen {
+		log.Warn("Truncating ancient blocks above the chain head", "head", *number, "frozen", frozen)
+		return frdb.TruncateAncients(*number + 1)
+	}
+	if kvhash, _ := db.Get(headerHashKey(frozen)); len(kvhash) == 0 && *number >= frozen {
+		return fmt.Errorf("gap (#%d) in the chain between ancient and chain database", frozen)
+	}
+	return nil
+}
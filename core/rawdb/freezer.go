@@ -0,0 +1,371 @@
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/prometheus/util/flock"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/metrics"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+var (
+	// errUnknownTable is returned if the user attempts to read from a table that is
+	// not tracked by the freezer.
+	errUnknownTable = errors.New("unknown table")
+
+	// errOutOrderInsertion is returned if the user attempts to inject out-of-order
+	// binary blobs into the freezer.
+	errOutOrderInsertion = errors.New("the append operation is out-order")
+)
+
+const (
+	// DefaultFreezerThreshold is the default number of recent blocks kept in the
+	// key-value store, older ones are moved into the freezer.
+	DefaultFreezerThreshold = 90000
+
+	// freezerRecheckInterval is the frequency to check the key-value database for
+	// chain progression that might permit new blocks to be frozen.
+	freezerRecheckInterval = time.Minute
+
+	// freezerBatchLimit is the maximum number of blocks to freeze in one batch
+	// before syncing the freezer and deleting the blocks from the key-value store.
+	freezerBatchLimit = 30000
+)
+
+// freezer is an append-only store of the canonical chain segment older than a
+// threshold below the head. The data is kept in flat files per kind, indexed by
+// block number, so it needs no compaction unlike the key-value store it was
+// moved out of.
+type freezer struct {
+	frozen    uint64 // Number of blocks already frozen, atomic
+	threshold uint64 // Number of recent blocks kept in the key-value store
+
+	tables       map[string]*freezerTable // Data tables for storing everything
+	instanceLock flock.Releaser           // File-system lock to prevent double opens
+
+	quit chan struct{}  // Channel to signal the freezing loop to terminate
+	wg   sync.WaitGroup // Wait group for the freezing loop to terminate
+}
+
+// newFreezer opens the freezer in the given directory, creating it if it doesn't
+// exist yet, and truncates all tables to the same length.
+func newFreezer(datadir string, threshold uint64) (*freezer, error) {
+	if err := os.MkdirAll(datadir, 0755); err != nil {
+		return nil, err
+	}
+	lock, _, err := flock.New(filepath.Join(datadir, "FLOCK"))
+	if err != nil {
+		return nil, err
+	}
+	freezer := &freezer{
+		threshold:    threshold,
+		tables:       make(map[string]*freezerTable),
+		instanceLock: lock,
+		quit:         make(chan struct{}),
+	}
+	for name, disableSnappy := range freezerNoSnappy {
+		var (
+			readMeter  = metrics.NewRegisteredMeter("yoo/db/chaindata/ancient/"+name+"/read", nil)
+			writeMeter = metrics.NewRegisteredMeter("yoo/db/chaindata/ancient/"+name+"/write", nil)
+		)
+		table, err := newTable(datadir, name, readMeter, writeMeter, disableSnappy)
+		if err != nil {
+			freezer.closeTables()
+			lock.Release()
+			return nil, err
+		}
+		freezer.tables[name] = table
+	}
+	if err := freezer.repair(); err != nil {
+		freezer.closeTables()
+		lock.Release()
+		return nil, err
+	}
+	log.Info("Opened ancient database", "database", datadir, "frozen", freezer.frozen)
+	return freezer, nil
+}
+
+// repair truncates all tables to the length of the shortest one, dropping blocks
+// only partially frozen before a crash.
+func (f *freezer) repair() error {
+	min := uint64(math.MaxUint64)
+	for _, table := range f.tables {
+		if items := atomic.LoadUint64(&table.items); items < min {
+			min = items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(min); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, min)
+	return nil
+}
+
+// Close terminates the freezing loop and closes all tables.
+func (f *freezer) Close() error {
+	close(f.quit)
+	f.wg.Wait()
+
+	errs := f.closeTables()
+	if err := f.instanceLock.Release(); err != nil {
+		errs = append(errs, err)
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// closeTables closes all opened tables, returning the errors encountered.
+func (f *freezer) closeTables() []error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// HasAncient returns an indicator whether the specified ancient data exists in
+// the freezer.
+func (f *freezer) HasAncient(kind string, number uint64) (bool, error) {
+	if table := f.tables[kind]; table != nil {
+		return table.has(number), nil
+	}
+	return false, nil
+}
+
+// Ancient retrieves an ancient binary blob from the append-only immutable files.
+func (f *freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	if table := f.tables[kind]; table != nil {
+		return table.Retrieve(number)
+	}
+	return nil, errUnknownTable
+}
+
+// Ancients returns the number of blocks held in the freezer.
+func (f *freezer) Ancients() (uint64, error) {
+	return atomic.LoadUint64(&f.frozen), nil
+}
+
+// AncientSize returns the total size of all tables of the freezer.
+func (f *freezer) AncientSize() (uint64, error) {
+	var total uint64
+	for _, table := range f.tables {
+		size, err := table.size()
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// AppendAncient injects all binary blobs belonging to a block at the end of the
+// append-only immutable table files. If any of them fails, the tables are rolled
+// back to the previous block.
+func (f *freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) (err error) {
+	if frozen := atomic.LoadUint64(&f.frozen); frozen != number {
+		return errOutOrderInsertion
+	}
+	defer func() {
+		if err != nil {
+			for _, table := range f.tables {
+				if rerr := table.truncate(number); rerr != nil {
+					log.Error("Failed to roll back ancient table", "table", table.name, "err", rerr)
+				}
+			}
+		}
+	}()
+	if err := f.tables[freezerHashTable].Append(number, hash); err != nil {
+		log.Error("Failed to append ancient hash", "number", number, "hash", common.BytesToHash(hash), "err", err)
+		return err
+	}
+	if err := f.tables[freezerHeaderTable].Append(number, header); err != nil {
+		log.Error("Failed to append ancient header", "number", number, "hash", common.BytesToHash(hash), "err", err)
+		return err
+	}
+	if err := f.tables[freezerBodiesTable].Append(number, body); err != nil {
+		log.Error("Failed to append ancient body", "number", number, "hash", common.BytesToHash(hash), "err", err)
+		return err
+	}
+	if err := f.tables[freezerReceiptTable].Append(number, receipts); err != nil {
+		log.Error("Failed to append ancient receipts", "number", number, "hash", common.BytesToHash(hash), "err", err)
+		return err
+	}
+	if err := f.tables[freezerDifficultyTable].Append(number, td); err != nil {
+		log.Error("Failed to append ancient difficulty", "number", number, "hash", common.BytesToHash(hash), "err", err)
+		return err
+	}
+	atomic.AddUint64(&f.frozen, 1)
+	return nil
+}
+
+// TruncateAncients discards all blocks from the given number on. If the freezer
+// holds no more blocks than that, this is a noop.
+func (f *freezer) TruncateAncients(items uint64) error {
+	if atomic.LoadUint64(&f.frozen) <= items {
+		return nil
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(items); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, items)
+	return nil
+}
+
+// Sync flushes all tables to disk.
+func (f *freezer) Sync() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// freeze is the background loop moving ancient blocks into the freezer, backing
+// off between batches unless there's more to catch up on.
+func (f *freezer) freeze(db yoobadb.Database) {
+	defer f.wg.Done()
+
+	backoff := false
+	for {
+		select {
+		case <-f.quit:
+			log.Info("Freezer shutting down")
+			return
+		default:
+		}
+		if backoff {
+			select {
+			case <-time.NewTimer(freezerRecheckInterval).C:
+			case <-f.quit:
+				log.Info("Freezer shutting down")
+				return
+			}
+		}
+		backoff = !f.freezeBatch(db)
+	}
+}
+
+// freezeBatch moves the next batch of canonical blocks older than the threshold
+// below the head from the key-value store into the freezer, reporting whether
+// more blocks are ready to be frozen. Side chain blocks at the frozen heights are
+// dropped, as they can't become canonical any more. The genesis block is always
+// kept in the key-value store too.
+func (f *freezer) freezeBatch(db yoobadb.Database) bool {
+	// Find the range of blocks old enough to be frozen
+	hash := ReadHeadBlockHash(db)
+	if hash == (common.Hash{}) {
+		log.Debug("Current full block hash unavailable")
+		return false
+	}
+	number := ReadHeaderNumber(db, hash)
+	frozen := atomic.LoadUint64(&f.frozen)
+	switch {
+	case number == nil:
+		log.Error("Current full block number unavailable", "hash", hash)
+		return false
+	case *number < f.threshold:
+		log.Debug("Current full block not old enough", "number", *number, "hash", hash, "delay", f.threshold)
+		return false
+	case *number-f.threshold <= frozen:
+		log.Debug("Ancient blocks frozen already", "number", *number, "hash", hash, "frozen", frozen)
+		return false
+	}
+	limit, more := *number-f.threshold, false
+	if limit-frozen > freezerBatchLimit {
+		limit, more = frozen+freezerBatchLimit, true
+	}
+	// Move the canonical blocks into the freezer
+	var (
+		start    = time.Now()
+		first    = frozen
+		ancients = make([]common.Hash, 0, limit-frozen)
+	)
+	for number := first; number < limit; number++ {
+		hash := ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			log.Error("Canonical hash missing, can't freeze", "number", number)
+			break
+		}
+		header := ReadHeaderRLP(db, hash, number)
+		if len(header) == 0 {
+			log.Error("Block header missing, can't freeze", "number", number, "hash", hash)
+			break
+		}
+		body := ReadBodyRLP(db, hash, number)
+		if len(body) == 0 {
+			log.Error("Block body missing, can't freeze", "number", number, "hash", hash)
+			break
+		}
+		receipts := ReadReceiptsRLP(db, hash, number)
+		if len(receipts) == 0 {
+			log.Error("Block receipts missing, can't freeze", "number", number, "hash", hash)
+			break
+		}
+		td := ReadTdRLP(db, hash, number)
+		if len(td) == 0 {
+			log.Error("Total difficulty missing, can't freeze", "number", number, "hash", hash)
+			break
+		}
+		if err := f.AppendAncient(number, hash[:], header, body, receipts, td); err != nil {
+			break
+		}
+		ancients = append(ancients, hash)
+	}
+	if len(ancients) == 0 {
+		return false
+	}
+	if err := f.Sync(); err != nil {
+		log.Crit("Failed to flush frozen tables", "err", err)
+	}
+	// Wipe the frozen canonical blocks and the side chains next to them
+	batch := db.NewBatch()
+	for i, hash := range ancients {
+		number := first + uint64(i)
+		if number == 0 {
+			continue
+		}
+		DeleteBlockWithoutNumber(batch, hash, number)
+		DeleteCanonicalHash(batch, number)
+
+		for _, side := range ReadAllHashes(db, number) {
+			if side != hash {
+				DeleteBlock(batch, side, number)
+			}
+		}
+		if batch.ValueSize() >= yoobadb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to delete frozen blocks", "err", err)
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete frozen blocks", "err", err)
+	}
+	log.Info("Moved blocks into ancient store", "blocks", len(ancients), "elapsed", common.PrettyDuration(time.Since(start)),
+		"number", first+uint64(len(ancients))-1, "hash", ancients[len(ancients)-1])
+
+	return more && uint64(len(ancients)) == limit-first
+}
@@ -0,0 +1,432 @@
+package rawdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/metrics"
+)
+
+var (
+	// errClosed is returned if an operation attempts to read from or write to the
+	// freezer table after it has already been closed.
+	errClosed = errors.New("closed")
+
+	// errOutOfBounds is returned if the item requested is not contained within the
+	// freezer table.
+	errOutOfBounds = errors.New("out of bounds")
+)
+
+// freezerTableSize is the maximum size of a single data file of a freezer table.
+const freezerTableSize = 2 * 1000 * 1000 * 1000
+
+// indexEntry points to the end of an item within the data files of a table.
+// Serialized, the file number takes 2 bytes and the offset 4, both big endian.
+type indexEntry struct {
+	filenum uint32 // Number of the data file holding the item
+	offset  uint32 // Offset within the file just after the item
+}
+
+const indexEntrySize = 6
+
+// unmarshalBinary deserializes an index entry.
+func (i *indexEntry) unmarshalBinary(b []byte) {
+	i.filenum = uint32(binary.BigEndian.Uint16(b[:2]))
+	i.offset = binary.BigEndian.Uint32(b[2:6])
+}
+
+// marshalBinary serializes an index entry.
+func (i *indexEntry) marshalBinary() []byte {
+	b := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint16(b[:2], uint16(i.filenum))
+	binary.BigEndian.PutUint32(b[2:6], i.offset)
+	return b
+}
+
+// freezerTable is an append-only store of a single kind of item, numbered from
+// zero. Items are written into data files of a bounded size, and located via an
+// index file holding the end position of every item. The first index entry is a
+// placeholder marking the start of the first item.
+type freezerTable struct {
+	items uint64 // Number of items stored in the table, atomic
+
+	noCompression bool   // Whether items are stored without snappy compression
+	maxFileSize   uint32 // Maximum size of a data file before a new one is started
+	name          string
+	path          string
+
+	head      *os.File            // Data file currently appended to
+	files     map[uint32]*os.File // All opened data files, by number
+	headId    uint32              // Number of the head data file
+	headBytes uint32              // Number of bytes written into the head file
+	index     *os.File            // Index file of the table, nil once closed
+
+	readMeter  metrics.Meter // Meter for the data read from the table
+	writeMeter metrics.Meter // Meter for the data written into the table
+
+	logger log.Logger
+	lock   sync.RWMutex // Mutex protecting the files from concurrent closing
+}
+
+// newTable opens a freezer table with the default data file size, creating it if
+// it doesn't exist yet.
+func newTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, noCompression bool) (*freezerTable, error) {
+	return newCustomTable(path, name, readMeter, writeMeter, freezerTableSize, noCompression)
+}
+
+// newCustomTable opens a freezer table with the given data file size, repairing
+// any inconsistency between the index and data files left by a crash.
+func newCustomTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, maxFilesize uint32, noCompression bool) (*freezerTable, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	idxName := fmt.Sprintf("%s.cidx", name)
+	if noCompression {
+		idxName = fmt.Sprintf("%s.ridx", name)
+	}
+	index, err := os.OpenFile(filepath.Join(path, idxName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	tab := &freezerTable{
+		noCompression: noCompression,
+		maxFileSize:   maxFilesize,
+		name:          name,
+		path:          path,
+		files:         make(map[uint32]*os.File),
+		index:         index,
+		readMeter:     readMeter,
+		writeMeter:    writeMeter,
+		logger:        log.New("table", name),
+	}
+	if err := tab.repair(); err != nil {
+		tab.Close()
+		return nil, err
+	}
+	return tab, nil
+}
+
+// repair cross checks the index against the data files, truncating whichever
+// runs ahead of the other, and opens all data files.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	// Write the placeholder entry into a new index, drop any partial entry
+	if stat.Size() == 0 {
+		if _, err := t.index.WriteAt(new(indexEntry).marshalBinary(), 0); err != nil {
+			return err
+		}
+		stat, err = t.index.Stat()
+		if err != nil {
+			return err
+		}
+	}
+	offsetsSize := stat.Size()
+	if overflow := offsetsSize % indexEntrySize; overflow != 0 {
+		offsetsSize -= overflow
+		if err := t.index.Truncate(offsetsSize); err != nil {
+			return err
+		}
+	}
+	// Open the head file pointed to by the last index entry
+	var lastIndex indexEntry
+	if err := t.readEntry(offsetsSize/indexEntrySize-1, &lastIndex); err != nil {
+		return err
+	}
+	if t.head, err = t.openFile(lastIndex.filenum, os.O_RDWR|os.O_CREATE); err != nil {
+		return err
+	}
+	if stat, err = t.head.Stat(); err != nil {
+		return err
+	}
+	contentSize := stat.Size()
+
+	// Drop index entries of items not fully written and data not yet indexed
+	for contentSize != int64(lastIndex.offset) {
+		if contentSize > int64(lastIndex.offset) {
+			t.logger.Warn("Truncating dangling head", "indexed", common.StorageSize(lastIndex.offset), "stored", common.StorageSize(contentSize))
+			if err := t.head.Truncate(int64(lastIndex.offset)); err != nil {
+				return err
+			}
+			contentSize = int64(lastIndex.offset)
+			break
+		}
+		t.logger.Warn("Truncating dangling index", "indexed", common.StorageSize(lastIndex.offset), "stored", common.StorageSize(contentSize))
+		offsetsSize -= indexEntrySize
+		if err := t.index.Truncate(offsetsSize); err != nil {
+			return err
+		}
+		var newLastIndex indexEntry
+		if err := t.readEntry(offsetsSize/indexEntrySize-1, &newLastIndex); err != nil {
+			return err
+		}
+		// The previous item may end in an earlier file
+		if newLastIndex.filenum != lastIndex.filenum {
+			t.closeFile(lastIndex.filenum)
+			os.Remove(t.fileName(lastIndex.filenum))
+
+			if t.head, err = t.openFile(newLastIndex.filenum, os.O_RDWR); err != nil {
+				return err
+			}
+			if stat, err = t.head.Stat(); err != nil {
+				return err
+			}
+			contentSize = stat.Size()
+		}
+		lastIndex = newLastIndex
+	}
+	if err := t.index.Sync(); err != nil {
+		return err
+	}
+	if err := t.head.Sync(); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&t.items, uint64(offsetsSize/indexEntrySize-1))
+	t.headId = lastIndex.filenum
+	t.headBytes = uint32(contentSize)
+
+	// Open all data files preceding the head for reading
+	for i := uint32(0); i < t.headId; i++ {
+		if _, err := t.openFile(i, os.O_RDONLY); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readEntry reads the index entry at the given position.
+func (t *freezerTable) readEntry(pos int64, entry *indexEntry) error {
+	buf := make([]byte, indexEntrySize)
+	if _, err := t.index.ReadAt(buf, pos*indexEntrySize); err != nil {
+		return err
+	}
+	entry.unmarshalBinary(buf)
+	return nil
+}
+
+// fileName returns the path of the data file with the given number.
+func (t *freezerTable) fileName(num uint32) string {
+	if t.noCompression {
+		return filepath.Join(t.path, fmt.Sprintf("%s.%04d.rdat", t.name, num))
+	}
+	return filepath.Join(t.path, fmt.Sprintf("%s.%04d.cdat", t.name, num))
+}
+
+// openFile opens the data file with the given number, reusing an already opened
+// handle.
+func (t *freezerTable) openFile(num uint32, flag int) (*os.File, error) {
+	if f, ok := t.files[num]; ok {
+		return f, nil
+	}
+	f, err := os.OpenFile(t.fileName(num), flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	t.files[num] = f
+	return f, nil
+}
+
+// closeFile closes the data file with the given number if it's open.
+func (t *freezerTable) closeFile(num uint32) {
+	if f, ok := t.files[num]; ok {
+		f.Close()
+		delete(t.files, num)
+	}
+}
+
+// truncate discards all items from the given number on. If the table holds no
+// more than the requested items, this is a noop.
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	existing := atomic.LoadUint64(&t.items)
+	if existing <= items {
+		return nil
+	}
+	t.logger.Warn("Truncating freezer table", "items", existing, "limit", items)
+	if err := t.index.Truncate(int64(items+1) * indexEntrySize); err != nil {
+		return err
+	}
+	var expected indexEntry
+	if err := t.readEntry(int64(items), &expected); err != nil {
+		return err
+	}
+	// Drop the data files past the new head
+	if expected.filenum != t.headId {
+		// Earlier data files are opened read only, reopen the new head for writing
+		t.closeFile(expected.filenum)
+		newHead, err := t.openFile(expected.filenum, os.O_RDWR)
+		if err != nil {
+			return err
+		}
+		for num := expected.filenum + 1; num <= t.headId; num++ {
+			t.closeFile(num)
+			os.Remove(t.fileName(num))
+		}
+		t.head, t.headId = newHead, expected.filenum
+	}
+	if err := t.head.Truncate(int64(expected.offset)); err != nil {
+		return err
+	}
+	t.headBytes = expected.offset
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// Append injects the next item into the table. Items must be appended in order,
+// numbered from zero.
+func (t *freezerTable) Append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if items := atomic.LoadUint64(&t.items); items != item {
+		return fmt.Errorf("appending unexpected item: want %d, have %d", items, item)
+	}
+	if !t.noCompression {
+		blob = snappy.Encode(nil, blob)
+	}
+	// Start a new data file if the item doesn't fit into the head one
+	if t.headBytes+uint32(len(blob)) > t.maxFileSize {
+		nextId := t.headId + 1
+		newHead, err := t.openFile(nextId, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+		t.head, t.headId, t.headBytes = newHead, nextId, 0
+	}
+	if _, err := t.head.WriteAt(blob, int64(t.headBytes)); err != nil {
+		return err
+	}
+	t.headBytes += uint32(len(blob))
+
+	entry := indexEntry{filenum: t.headId, offset: t.headBytes}
+	if _, err := t.index.WriteAt(entry.marshalBinary(), int64(item+1)*indexEntrySize); err != nil {
+		return err
+	}
+	t.writeMeter.Mark(int64(len(blob) + indexEntrySize))
+	atomic.AddUint64(&t.items, 1)
+	return nil
+}
+
+// Retrieve looks up the data stored for the given item number.
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return nil, errClosed
+	}
+	if atomic.LoadUint64(&t.items) <= item {
+		return nil, errOutOfBounds
+	}
+	var start, end indexEntry
+	if err := t.readEntry(int64(item), &start); err != nil {
+		return nil, err
+	}
+	if err := t.readEntry(int64(item+1), &end); err != nil {
+		return nil, err
+	}
+	// Items never span files, an item starting a new file starts at its beginning
+	startOffset := start.offset
+	if start.filenum != end.filenum {
+		startOffset = 0
+	}
+	file, ok := t.files[end.filenum]
+	if !ok {
+		return nil, fmt.Errorf("missing data file %d", end.filenum)
+	}
+	blob := make([]byte, end.offset-startOffset)
+	if _, err := file.ReadAt(blob, int64(startOffset)); err != nil {
+		return nil, err
+	}
+	t.readMeter.Mark(int64(len(blob) + 2*indexEntrySize))
+
+	if t.noCompression {
+		return blob, nil
+	}
+	return snappy.Decode(nil, blob)
+}
+
+// has reports whether the item is stored in the table.
+func (t *freezerTable) has(item uint64) bool {
+	return atomic.LoadUint64(&t.items) > item
+}
+
+// size returns the total size of the index and data files of the table.
+func (t *freezerTable) size() (uint64, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return 0, errClosed
+	}
+	stat, err := t.index.Stat()
+	if err != nil {
+		return 0, err
+	}
+	total := uint64(stat.Size())
+	for _, f := range t.files {
+		if stat, err = f.Stat(); err != nil {
+			return 0, err
+		}
+		total += uint64(stat.Size())
+	}
+	return total, nil
+}
+
+// Sync flushes the index and head data file to disk.
+func (t *freezerTable) Sync() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if err := t.index.Sync(); err != nil {
+		return err
+	}
+	return t.head.Sync()
+}
+
+// Close closes all files of the table.
+func (t *freezerTable) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	if t.index != nil {
+		if err := t.index.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		t.index = nil
+	}
+	for num, f := range t.files {
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(t.files, num)
+	}
+	t.head = nil
+
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yooba-team/yooba/metrics"
+)
+
+// getChunk returns a chunk of the given size filled with the given byte.
+func getChunk(size int, b int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(b)
+	}
+	return data
+}
+
+func newTestTable(t *testing.T, dir string, maxFileSize uint32, noCompression bool) *freezerTable {
+	table, err := newCustomTable(dir, "test", metrics.NewMeter(), metrics.NewMeter(), maxFileSize, noCompression)
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	return table
+}
+
+// Tests that items can be written across data files and read back, also after
+// reopening the table.
+func TestFreezerBasics(t *testing.T) {
+	for _, noCompression := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "freezer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		// Write more items than fit into a single file
+		table := newTestTable(t, dir, 50, noCompression)
+		for i := 0; i < 255; i++ {
+			if err := table.Append(uint64(i), getChunk(15, i)); err != nil {
+				t.Fatalf("failed to append item %d: %v", i, err)
+			}
+		}
+		if err := table.Append(300, getChunk(15, 0)); err == nil {
+			t.Fatalf("out of order append succeeded")
+		}
+		check := func(table *freezerTable) {
+			for i := 0; i < 255; i++ {
+				blob, err := table.Retrieve(uint64(i))
+				if err != nil {
+					t.Fatalf("failed to retrieve item %d: %v", i, err)
+				}
+				if exp := getChunk(15, i); !bytes.Equal(blob, exp) {
+					t.Fatalf("item %d mismatch: have %x, want %x", i, blob, exp)
+				}
+			}
+			if _, err := table.Retrieve(255); err != errOutOfBounds {
+				t.Fatalf("retrieving missing item: have %v, want %v", err, errOutOfBounds)
+			}
+		}
+		check(table)
+		table.Close()
+
+		table = newTestTable(t, dir, 50, noCompression)
+		check(table)
+		table.Close()
+	}
+}
+
+// Tests that a partially written item is dropped when the table is reopened.
+func TestFreezerRepairDanglingIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	table := newTestTable(t, dir, 50, true)
+	for i := 0; i < 9; i++ {
+		table.Append(uint64(i), getChunk(15, i))
+	}
+	table.Close()
+
+	// Cut off the last data file in the middle of the ninth item
+	path := filepath.Join(dir, fmt.Sprintf("test.%04d.rdat", 2))
+	if err := os.Truncate(path, 35); err != nil {
+		t.Fatal(err)
+	}
+	table = newTestTable(t, dir, 50, true)
+	defer table.Close()
+
+	if table.items != 8 {
+		t.Fatalf("item count mismatch: have %d, want %d", table.items, 8)
+	}
+	if _, err := table.Retrieve(8); err != errOutOfBounds {
+		t.Fatalf("retrieving dropped item: have %v, want %v", err, errOutOfBounds)
+	}
+	if err := table.Append(8, getChunk(15, 0xff)); err != nil {
+		t.Fatalf("failed to append after repair: %v", err)
+	}
+	if blob, _ := table.Retrieve(8); !bytes.Equal(blob, getChunk(15, 0xff)) {
+		t.Fatalf("item mismatch after repair: have %x", blob)
+	}
+	if blob, _ := table.Retrieve(7); !bytes.Equal(blob, getChunk(15, 7)) {
+		t.Fatalf("item mismatch after repair: have %x", blob)
+	}
+}
+
+// Tests that truncating a table drops the items and data files past the limit.
+func TestFreezerTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	table := newTestTable(t, dir, 50, false)
+	for i := 0; i < 30; i++ {
+		table.Append(uint64(i), getChunk(15, i))
+	}
+	if err := table.truncate(10); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+	table.Close()
+
+	table = newTestTable(t, dir, 50, false)
+	defer table.Close()
+
+	if table.items != 10 {
+		t.Fatalf("item count mismatch: have %d, want %d", table.items, 10)
+	}
+	if _, err := table.Retrieve(10); err != errOutOfBounds {
+		t.Fatalf("retrieving truncated item: have %v, want %v", err, errOutOfBounds)
+	}
+	for i := 10; i < 20; i++ {
+		if err := table.Append(uint64(i), getChunk(15, i+100)); err != nil {
+			t.Fatalf("failed to append item %d: %v", i, err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		exp := getChunk(15, i)
+		if i >= 10 {
+			exp = getChunk(15, i+100)
+		}
+		if blob, err := table.Retrieve(uint64(i)); err != nil || !bytes.Equal(blob, exp) {
+			t.Fatalf("item %d mismatch: have %x (%v), want %x", i, blob, err, exp)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package rawdb
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// makeTestChain writes a canonical chain of the given length into the database,
+// along with a side chain block at height one.
+func makeTestChain(db yoobadb.Database, n int) ([]*types.Block, *types.Block) {
+	var (
+		blocks []*types.Block
+		parent common.Hash
+	)
+	for i := 0; i < n; i++ {
+		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), ParentHash: parent})
+		WriteBlock(db, block)
+		WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
+		WriteTd(db, block.Hash(), block.NumberU64(), big.NewInt(int64(i+1)))
+		WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+
+		blocks, parent = append(blocks, block), block.Hash()
+	}
+	WriteHeadBlockHash(db, parent)
+	WriteHeadHeaderHash(db, parent)
+
+	side := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), ParentHash: blocks[0].Hash(), Extra: []byte("side")})
+	WriteBlock(db, side)
+	WriteTd(db, side.Hash(), 1, big.NewInt(2))
+
+	return blocks, side
+}
+
+// Tests that blocks older than the threshold are moved into the freezer, and are
+// read back transparently through the accessors.
+func TestFreezerMovesAncientBlocks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kvdb := yoobadb.NewMemDatabase()
+	blocks, side := makeTestChain(kvdb, 10)
+
+	f, err := newFreezer(dir, 4)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	db := &freezerdb{Database: kvdb, freezer: f}
+	defer db.Close()
+
+	if more := f.freezeBatch(kvdb); more {
+		t.Fatalf("freezer reported pending blocks")
+	}
+	if frozen, _ := f.Ancients(); frozen != 5 {
+		t.Fatalf("frozen block count mismatch: have %d, want %d", frozen, 5)
+	}
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+
+		// Frozen blocks except the genesis must be gone from the key-value store
+		if stored := ReadHeader(kvdb, hash, number) != nil; stored != (i == 0 || i >= 5) {
+			t.Errorf("block %d: key-value store presence %v", i, stored)
+		}
+		if have := ReadCanonicalHash(db, number); have != hash {
+			t.Errorf("block %d: canonical hash mismatch: have %x, want %x", i, have, hash)
+		}
+		if !HasHeader(db, hash, number) || !HasBody(db, hash, number) {
+			t.Errorf("block %d: header or body missing", i)
+		}
+		if have := ReadBlock(db, hash, number); have == nil || have.Hash() != hash {
+			t.Errorf("block %d: block mismatch: have %v", i, have)
+		}
+		if have := ReadTd(db, hash, number); have == nil || have.Int64() != int64(i+1) {
+			t.Errorf("block %d: total difficulty mismatch: have %v, want %d", i, have, i+1)
+		}
+		if have := ReadReceiptsRLP(db, hash, number); len(have) == 0 {
+			t.Errorf("block %d: receipts missing", i)
+		}
+		if have := ReadHeaderNumber(db, hash); have == nil || *have != number {
+			t.Errorf("block %d: number mismatch: have %v, want %d", i, have, number)
+		}
+	}
+	// The side chain block at a frozen height must be dropped
+	if HasHeader(db, side.Hash(), 1) || ReadTd(db, side.Hash(), 1) != nil {
+		t.Errorf("side chain block retained")
+	}
+	// Truncating must drop the frozen blocks above the limit
+	if err := db.TruncateAncients(3); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+	if hash := ReadCanonicalHash(db, 3); hash != (common.Hash{}) {
+		t.Errorf("truncated block still canonical: %x", hash)
+	}
+	if hash := ReadCanonicalHash(db, 2); hash != blocks[2].Hash() {
+		t.Errorf("retained block canonical hash mismatch: have %x, want %x", hash, blocks[2].Hash())
+	}
+}
+
+// Tests that a freezer of another chain is rejected.
+func TestFreezerGenesisMismatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Fill the freezer from one chain
+	kvdb := yoobadb.NewMemDatabase()
+	makeTestChain(kvdb, 10)
+
+	f, err := newFreezer(dir, 4)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	f.freezeBatch(kvdb)
+	f.Close()
+
+	// Open it along with a different chain
+	other := yoobadb.NewMemDatabase()
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Extra: []byte("other")})
+	WriteCanonicalHash(other, genesis.Hash(), 0)
+
+	if _, err := NewDatabaseWithFreezer(other, dir, 4); err == nil {
+		t.Fatalf("freezer of another chain accepted")
+	}
+}
@@ -31,3 +31,22 @@ type DatabaseWriter interface {
 type DatabaseDeleter interface {
 	Delete(key []byte) error
 }
+
+// AncientReader wraps the retrieval of the ancient chain segment moved out of the
+// key-value store into the freezer.
+type AncientReader interface {
+	// HasAncient returns whether an item of the given kind is frozen for the block.
+	HasAncient(kind string, number uint64) (bool, error)
+
+	// Ancient retrieves an item of the given kind of a frozen block.
+	Ancient(kind string, number uint64) ([]byte, error)
+
+	// Ancients returns the number of frozen blocks.
+	Ancients() (uint64, error)
+}
+
+// AncientWriter wraps the removal of frozen blocks.
+type AncientWriter interface {
+	// TruncateAncients discards all frozen blocks from the given number on.
+	TruncateAncients(items uint64) error
+}
@@ -41,6 +41,15 @@ var (
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
+	// snapSyncStatusKey tracks the progress of the range based state sync.
+	snapSyncStatusKey = []byte("SnapSyncStatus")
+
+	// snapshotRootKey tracks the state root of the flat snapshot on disk.
+	snapshotRootKey = []byte("SnapshotRoot")
+
+	// snapshotGeneratorKey tracks the progress of the snapshot generation.
+	snapshotGeneratorKey = []byte("SnapshotGenerator")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -53,6 +62,9 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
+	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("yooba-config-") // config prefix for the db
 
@@ -63,6 +75,25 @@ var (
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
 )
 
+// The tables of the ancient chain segment freezer, indexed by block number.
+const (
+	freezerHeaderTable     = "headers"  // Canonical block headers
+	freezerHashTable       = "hashes"   // Canonical block hashes
+	freezerBodiesTable     = "bodies"   // Canonical block bodies
+	freezerReceiptTable    = "receipts" // Canonical block receipts
+	freezerDifficultyTable = "diffs"    // Canonical block total difficulties
+)
+
+// freezerNoSnappy configures which freezer tables are stored uncompressed, as
+// their items don't compress.
+var freezerNoSnappy = map[string]bool{
+	freezerHeaderTable:     false,
+	freezerHashTable:       true,
+	freezerBodiesTable:     false,
+	freezerReceiptTable:    false,
+	freezerDifficultyTable: true,
+}
+
 // TxLookupEntry is a positional metadata to help looking up the data content of
 // a transaction or receipt given only its hash.
 type TxLookupEntry struct {
@@ -78,6 +109,22 @@ func encodeBlockNumber(number uint64) []byte {
 	return enc
 }
 
+// accountSnapshotKey = SnapshotAccountPrefix + hash
+func accountSnapshotKey(hash common.Hash) []byte {
+	return append(SnapshotAccountPrefix, hash.Bytes()...)
+}
+
+// storageSnapshotKey = SnapshotStoragePrefix + account hash + storage hash
+func storageSnapshotKey(accountHash, storageHash common.Hash) []byte {
+	return append(append(SnapshotStoragePrefix, accountHash.Bytes()...), storageHash.Bytes()...)
+}
+
+// StorageSnapshotsKey = SnapshotStoragePrefix + account hash, the prefix of all
+// storage snapshot entries of an account
+func StorageSnapshotsKey(accountHash common.Hash) []byte {
+	return append(SnapshotStoragePrefix, accountHash.Bytes()...)
+}
+
 // headerKey = headerPrefix + num (uint64 big endian) + hash
 func headerKey(number uint64, hash common.Hash) []byte {
 	return append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/governance"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/rlp"
+)
+
+var (
+	errResourcesDisabled = errors.New("resource fee mode not enabled")
+	errResourceRecipient = errors.New("resource stake not sent to the resource account")
+	errInvalidStake      = errors.New("stake amount must be positive")
+	errUnstakeValue      = errors.New("unstaking can't transfer value")
+	errInsufficientStake = errors.New("insufficient stake")
+)
+
+// Resources is the resource quota of an account and its usage at a given block.
+type Resources struct {
+	Stake          *big.Int
+	GasQuota       uint64              // Gas usable within the usage window
+	BandwidthQuota uint64              // Transaction bytes usable within the usage window
+	Usage          types.ResourceUsage // Usage decayed to the block
+}
+
+// AccountResources returns the resource quota of addr and its usage at the given
+// block number. The quota is the share of the block resources over the usage
+// window proportional to the stake of the account.
+func AccountResources(config *params.ResourceConfig, db vm.StateDB, addr common.Address, number uint64) *Resources {
+	res := &Resources{
+		Stake: new(big.Int).Set(db.GetStake(addr)),
+		Usage: db.GetResources(addr).Decayed(number, config.Window),
+	}
+	if total := db.GetStake(params.ResourceAddress); res.Stake.Sign() > 0 && total.Sign() > 0 {
+		res.GasQuota = resourceShare(config.BlockGas, config.Window, res.Stake, total)
+		res.BandwidthQuota = resourceShare(config.BlockBandwidth, config.Window, res.Stake, total)
+	}
+	return res
+}
+
+// resourceShare returns the stake/total share of the given per block amount over
+// the window, capped to the maximum uint64.
+func resourceShare(amount, window uint64, stake, total *big.Int) uint64 {
+	share := new(big.Int).SetUint64(amount)
+	share.Mul(share, new(big.Int).SetUint64(window))
+	share.Mul(share, stake)
+	share.Div(share, total)
+	if !share.IsUint64() {
+		return ^uint64(0)
+	}
+	return share.Uint64()
+}
+
+// GasLeft returns the gas left in the quota.
+func (r *Resources) GasLeft() uint64 {
+	if r.Usage.Gas >= r.GasQuota {
+		return 0
+	}
+	return r.GasQuota - r.Usage.Gas
+}
+
+// BandwidthLeft returns the bandwidth left in the quota.
+func (r *Resources) BandwidthLeft() uint64 {
+	if r.Usage.Bandwidth >= r.BandwidthQuota {
+		return 0
+	}
+	return r.BandwidthQuota - r.Usage.Bandwidth
+}
+
+// Covers reports whether the quota left covers the given gas and bandwidth.
+func (r *Resources) Covers(gas, bandwidth uint64) bool {
+	return gas <= r.GasLeft() && bandwidth <= r.BandwidthLeft()
+}
+
+// TxBandwidth returns the bandwidth charged for a transaction with the given
+// payload.
+func TxBandwidth(data []byte) uint64 {
+	return params.TxBandwidth + uint64(len(data))
+}
+
+// checkResources makes messages within the resource quota of their payer free
+// of charge. Transactions exceeding the quota are paid by gas price as usual,
+// which can't be below the minimum set by governance.
+func (st *StateTransition) checkResources() error {
+	config := st.evm.ChainConfig().Resource
+	if config == nil {
+		return nil
+	}
+	res := AccountResources(config, st.state, st.payer(), st.evm.BlockNumber.Uint64())
+	if st.free = res.Covers(st.msg.Gas(), TxBandwidth(st.data)); st.free {
+		st.gasPrice = new(big.Int)
+		return nil
+	}
+	if st.msg.CheckNonce() && st.gasPrice.Cmp(governance.Value(st.state, governance.MinGasPrice, st.evm.BlockNumber)) < 0 {
+		return ErrUnderpriced
+	}
+	return nil
+}
+
+// useResources charges the gas used and the bandwidth of a free message to the
+// resource usage of its payer.
+func (st *StateTransition) useResources() {
+	var (
+		config = st.evm.ChainConfig().Resource
+		payer  = st.payer()
+	)
+	usage := st.state.GetResources(payer).Add(st.evm.BlockNumber.Uint64(), config.Window, st.gasUsed(), TxBandwidth(st.data))
+	st.state.SetResources(payer, usage)
+}
+
+// applyResourceStake stakes the value of the message for block resources. Like
+// governance messages, invalid stakes are included without any effect besides
+// the gas spent.
+func (st *StateTransition) applyResourceStake() error {
+	if st.evm.ChainConfig().Resource == nil {
+		return errResourcesDisabled
+	}
+	if st.to() != params.ResourceAddress {
+		return errResourceRecipient
+	}
+	if st.value.Sign() <= 0 {
+		return errInvalidStake
+	}
+	from := st.msg.From()
+	if !st.evm.CanTransfer(st.state, from, st.value) {
+		return vm.ErrInsufficientBalance
+	}
+	st.evm.Transfer(st.state, from, params.ResourceAddress, st.value)
+	addStake(st.state, from, st.value)
+	return nil
+}
+
+// applyResourceUnstake returns the amount in the message payload from the stake
+// of the sender. The returned funds stay locked for the unstake delay, so a stake
+// can't be moved between accounts to multiply its quota.
+func (st *StateTransition) applyResourceUnstake() error {
+	config := st.evm.ChainConfig().Resource
+	if config == nil {
+		return errResourcesDisabled
+	}
+	if st.to() != params.ResourceAddress {
+		return errResourceRecipient
+	}
+	if st.value.Sign() != 0 {
+		return errUnstakeValue
+	}
+	amount := new(big.Int)
+	if err := rlp.DecodeBytes(st.data, amount); err != nil {
+		return err
+	}
+	from := st.msg.From()
+	if amount.Sign() <= 0 || amount.Cmp(st.state.GetStake(from)) > 0 {
+		return errInsufficientStake
+	}
+	addStake(st.state, from, new(big.Int).Neg(amount))
+	st.evm.Transfer(st.state, params.ResourceAddress, from, amount)
+
+	if config.UnstakeDelay > 0 {
+		time := st.evm.Time.Uint64()
+		addVesting(st.state, from, time, types.VestingSchedule{
+			Amount:   amount,
+			Start:    time,
+			Cliff:    config.UnstakeDelay,
+			Duration: config.UnstakeDelay,
+		})
+	}
+	return nil
+}
+
+// addStake adds the given, possibly negative, amount to the stake of addr and
+// to the total stake.
+func addStake(db vm.StateDB, addr common.Address, amount *big.Int) {
+	db.SetStake(addr, new(big.Int).Add(db.GetStake(addr), amount))
+	db.SetStake(params.ResourceAddress, new(big.Int).Add(db.GetStake(params.ResourceAddress), amount))
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/governance"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// Tests that transactions within the resource quota of their sender are free,
+// that the quota decays over the usage window and that over-quota transactions
+// fall back to the gas price.
+func TestResourceQuota(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Resource = &params.ResourceConfig{Window: 100, BlockGas: 400, BlockBandwidth: 10, UnstakeDelay: 50}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
+	var (
+		alice = common.HexToAddress("0xa11ce")
+		bob   = common.HexToAddress("0xb0b")
+		price = big.NewInt(1)
+		funds = big.NewInt(params.Ether)
+		nonce = make(map[common.Address]uint64)
+	)
+	statedb.AddBalance(alice, funds)
+	statedb.AddBalance(bob, funds)
+
+	apply := func(number uint64, from common.Address, to common.Address, value *big.Int, txType uint, data []byte) {
+		context := vm.Context{
+			CanTransfer: CanTransfer,
+			Transfer:    Transfer,
+			BlockNumber: new(big.Int).SetUint64(number),
+			Time:        new(big.Int).SetUint64(number),
+			GasLimit:    10000000,
+		}
+		gas, _ := IntrinsicGas(data, false)
+		msg := types.NewMessage(from, &to, nonce[from], value, gas, price, txType, data, true)
+		if _, _, failed, err := ApplyMessage(vm.NewEVM(context, statedb, &config, vm.Config{}), msg, new(GasPool).AddGas(10000000)); err != nil || failed {
+			t.Fatalf("block %d: failed to apply message: err %v, failed %v", number, err, failed)
+		}
+		nonce[from]++
+	}
+	// Without any stake, transactions pay the gas price
+	balance := new(big.Int).Set(statedb.GetBalance(alice))
+	apply(1, alice, params.ResourceAddress, big.NewInt(3000), types.TxTypeResourceStake, nil)
+	if spent := new(big.Int).Sub(balance, statedb.GetBalance(alice)); spent.Cmp(big.NewInt(3000+21000)) != 0 {
+		t.Fatalf("stake cost mismatch: have %v, want %v", spent, 3000+21000)
+	}
+	apply(1, bob, params.ResourceAddress, big.NewInt(1000), types.TxTypeResourceStake, nil)
+	if stake := statedb.GetStake(alice); stake.Cmp(big.NewInt(3000)) != 0 {
+		t.Fatalf("stake mismatch: have %v, want %v", stake, 3000)
+	}
+	if total := statedb.GetStake(params.ResourceAddress); total.Cmp(big.NewInt(4000)) != 0 {
+		t.Fatalf("total stake mismatch: have %v, want %v", total, 4000)
+	}
+	// Alice owns 3/4 of the stake, her quota suffices for a single transfer
+	res := AccountResources(config.Resource, statedb, alice, 2)
+	if res.GasQuota != 30000 || res.BandwidthQuota != 750 {
+		t.Fatalf("quota mismatch: have %d/%d, want %d/%d", res.GasQuota, res.BandwidthQuota, 30000, 750)
+	}
+	balance.Set(statedb.GetBalance(alice))
+	apply(2, alice, bob, big.NewInt(1), types.TxTypeTransfer, nil)
+	if spent := new(big.Int).Sub(balance, statedb.GetBalance(alice)); spent.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("in-quota transfer cost mismatch: have %v, want %v", spent, 1)
+	}
+	res = AccountResources(config.Resource, statedb, alice, 2)
+	if res.GasLeft() != 9000 || res.BandwidthLeft() != 750-params.TxBandwidth {
+		t.Fatalf("quota left mismatch: have %d/%d, want %d/%d", res.GasLeft(), res.BandwidthLeft(), 9000, 750-params.TxBandwidth)
+	}
+	// The next transfer exceeds the quota and pays the gas price
+	balance.Set(statedb.GetBalance(alice))
+	apply(2, alice, bob, big.NewInt(1), types.TxTypeTransfer, nil)
+	if spent := new(big.Int).Sub(balance, statedb.GetBalance(alice)); spent.Cmp(big.NewInt(1+21000)) != 0 {
+		t.Fatalf("over-quota transfer cost mismatch: have %v, want %v", spent, 1+21000)
+	}
+	// Once the usage decayed, transfers are free again
+	balance.Set(statedb.GetBalance(alice))
+	apply(102, alice, bob, big.NewInt(1), types.TxTypeTransfer, nil)
+	if spent := new(big.Int).Sub(balance, statedb.GetBalance(alice)); spent.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("decayed transfer cost mismatch: have %v, want %v", spent, 1)
+	}
+	// Unstaking returns the funds locked for the unstake delay
+	data, _ := rlp.EncodeToBytes(big.NewInt(2000))
+	apply(300, alice, params.ResourceAddress, new(big.Int), types.TxTypeResourceUnstake, data)
+	if stake := statedb.GetStake(alice); stake.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("stake mismatch after unstaking: have %v, want %v", stake, 1000)
+	}
+	if total := statedb.GetStake(params.ResourceAddress); total.Cmp(big.NewInt(2000)) != 0 {
+		t.Fatalf("total stake mismatch after unstaking: have %v, want %v", total, 2000)
+	}
+	if locked := LockedBalance(statedb, alice, 300); locked.Cmp(big.NewInt(2000)) != 0 {
+		t.Fatalf("locked balance mismatch: have %v, want %v", locked, 2000)
+	}
+	if locked := LockedBalance(statedb, alice, 350); locked.Sign() != 0 {
+		t.Fatalf("balance still locked after the unstake delay: %v", locked)
+	}
+}
+
+// Tests that transactions exceeding the resource quota of their payer are
+// rejected below the minimum gas price set by governance, while free ones and
+// calls are still accepted.
+func TestResourceMinGasPrice(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Resource = &params.ResourceConfig{Window: 1, BlockGas: 21000, BlockBandwidth: 1000}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
+	var (
+		alice     = common.HexToAddress("0xa11ce")
+		bob       = common.HexToAddress("0xb0b")
+		producers = []common.Address{common.HexToAddress("0xa1")}
+		deposit   = params.GovernanceProposalDeposit
+	)
+	statedb.AddBalance(alice, big.NewInt(params.Ether))
+	statedb.AddBalance(bob, big.NewInt(params.Ether))
+	statedb.AddBalance(producers[0], deposit)
+	addStake(statedb, alice, big.NewInt(1))
+
+	// Raise the minimum gas price through governance
+	id, err := governance.Propose(statedb, producers[0], big.NewInt(1), deposit, &governance.ProposalData{Param: "minGasPrice", Value: big.NewInt(5), Activation: params.GovernanceVotingPeriod + 2})
+	if err != nil {
+		t.Fatalf("failed to propose: %v", err)
+	}
+	if err := governance.Vote(statedb, producers[0], big.NewInt(1), producers, &governance.VoteData{Proposal: id, Approve: true}); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	number := new(big.Int).SetUint64(params.GovernanceVotingPeriod + 2)
+
+	apply := func(from common.Address, nonce uint64, price int64, check bool) error {
+		context := vm.Context{
+			CanTransfer: CanTransfer,
+			Transfer:    Transfer,
+			BlockNumber: number,
+			Time:        number,
+			GasLimit:    10000000,
+		}
+		msg := types.NewMessage(from, &bob, nonce, big.NewInt(1), 21000, big.NewInt(price), types.TxTypeTransfer, nil, check)
+		_, _, _, err := ApplyMessage(vm.NewEVM(context, statedb, &config, vm.Config{}), msg, new(GasPool).AddGas(10000000))
+		return err
+	}
+	// Alice's quota covers a single cheap transfer, the next one has to pay
+	if err := apply(alice, 0, 1, true); err != nil {
+		t.Fatalf("free transfer failed: %v", err)
+	}
+	if err := apply(alice, 1, 1, true); err != ErrUnderpriced {
+		t.Fatalf("cheap over-quota transfer error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := apply(alice, 1, 5, true); err != nil {
+		t.Fatalf("over-quota transfer at the minimum price failed: %v", err)
+	}
+	// Bob has no quota at all, only calls may go below the minimum price
+	if err := apply(bob, 0, 1, true); err != ErrUnderpriced {
+		t.Fatalf("cheap transfer error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := apply(bob, 0, 1, false); err != nil {
+		t.Fatalf("cheap call failed: %v", err)
+	}
+}
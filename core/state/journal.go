@@ -20,6 +20,7 @@ import (
 	"math/big"
 
 	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
 )
 
 type journalEntry interface {
@@ -88,7 +89,8 @@ type (
 		account *common.Address
 	}
 	resetObjectChange struct {
-		prev *stateObject
+		prev         *stateObject
+		prevdestruct bool
 	}
 	suicideChange struct {
 		account     *common.Address
@@ -132,6 +134,27 @@ type (
 		prev    map[common.Address]*Account
 	}
 
+	multisigChange struct {
+		account       *common.Address
+		prevKeys      []types.MultisigKey
+		prevThreshold uint64
+	}
+
+	vestingChange struct {
+		account *common.Address
+		prev    []types.VestingSchedule
+	}
+
+	stakeChange struct {
+		account *common.Address
+		prev    *big.Int
+	}
+
+	resourcesChange struct {
+		account *common.Address
+		prev    types.ResourceUsage
+	}
+
 	historyurlChange struct {
 		account *common.Address
 		prev    common.Hash
@@ -180,6 +203,9 @@ func (ch createObjectChange) dirtied() *common.Address {
 
 func (ch resetObjectChange) revert(s *StateDB) {
 	s.setStateObject(ch.prev)
+	if !ch.prevdestruct && s.snap != nil {
+		delete(s.snapDestructs, ch.prev.addrHash)
+	}
 }
 
 func (ch resetObjectChange) dirtied() *common.Address {
@@ -240,6 +266,38 @@ func (ch storeMapChange) dirtied() *common.Address {
 	return ch.account
 }
 
+func (ch multisigChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setMultisig(ch.prevKeys, ch.prevThreshold)
+}
+
+func (ch multisigChange) dirtied() *common.Address {
+	return ch.account
+}
+
+func (ch vestingChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setVesting(ch.prev)
+}
+
+func (ch vestingChange) dirtied() *common.Address {
+	return ch.account
+}
+
+func (ch stakeChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setStake(ch.prev)
+}
+
+func (ch stakeChange) dirtied() *common.Address {
+	return ch.account
+}
+
+func (ch resourcesChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setResources(ch.prev)
+}
+
+func (ch resourcesChange) dirtied() *common.Address {
+	return ch.account
+}
+
 func (ch homepageChange) revert(s *StateDB) {
 	s.getStateObject(*ch.account).setHomepage(ch.prev)
 }
@@ -0,0 +1,100 @@
+package state
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// errNoStorageTrie is returned when a storage proof is requested for an account
+// that doesn't exist.
+var errNoStorageTrie = errors.New("storage trie for requested address does not exist")
+
+// proofList collects the trie nodes of a Merkle proof in root to leaf order.
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// GetProof returns the Merkle proof of the account of addr in the state trie.
+// The proof is a proof of absence if the account doesn't exist.
+func (self *StateDB) GetProof(addr common.Address) ([][]byte, error) {
+	var proof proofList
+	err := self.trie.Prove(crypto.Keccak256(addr.Bytes()), 0, &proof)
+	return [][]byte(proof), err
+}
+
+// GetStorageProof returns the Merkle proof of the given storage slot of addr in
+// the storage trie of the account.
+func (self *StateDB) GetStorageProof(addr common.Address, key common.Hash) ([][]byte, error) {
+	trie := self.StorageTrie(addr)
+	if trie == nil {
+		return nil, errNoStorageTrie
+	}
+	var proof proofList
+	err := trie.Prove(crypto.Keccak256(key.Bytes()), 0, &proof)
+	return [][]byte(proof), err
+}
+
+// GetAccount returns a copy of the consensus representation of the account of
+// addr, or nil if the account doesn't exist.
+func (self *StateDB) GetAccount(addr common.Address) *Account {
+	stateObject := self.getStateObject(addr)
+	if stateObject == nil {
+		return nil
+	}
+	account := stateObject.data
+	account.Balance = new(big.Int).Set(account.Balance)
+	account.CodeHash = common.CopyBytes(account.CodeHash)
+	return &account
+}
+
+// proofDatabase converts a list of proof nodes into the node database expected
+// by trie.VerifyProof.
+func proofDatabase(proof [][]byte) *yoobadb.MemDatabase {
+	db := yoobadb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// VerifyAccountProof checks a Merkle proof of the account of addr against the
+// given state root, as returned by GetProof. It returns the proven account, or
+// nil if the proof is a valid proof of absence.
+func VerifyAccountProof(root common.Hash, addr common.Address, proof [][]byte) (*Account, error) {
+	enc, _, err := trie.VerifyProof(root, crypto.Keccak256(addr.Bytes()), proofDatabase(proof))
+	if err != nil || enc == nil {
+		return nil, err
+	}
+	account := new(Account)
+	if err := rlp.DecodeBytes(enc, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// VerifyStorageProof checks a Merkle proof of a storage slot against the storage
+// root of an account, as returned by GetStorageProof. It returns the proven
+// value of the slot, which is zero for a valid proof of absence.
+func VerifyStorageProof(root common.Hash, key common.Hash, proof [][]byte) (common.Hash, error) {
+	enc, _, err := trie.VerifyProof(root, crypto.Keccak256(key.Bytes()), proofDatabase(proof))
+	if err != nil || enc == nil {
+		return common.Hash{}, err
+	}
+	_, content, _, err := rlp.Split(enc)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(content) > common.HashLength {
+		return common.Hash{}, errors.New("invalid storage value encoding")
+	}
+	return common.BytesToHash(content), nil
+}
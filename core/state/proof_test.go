@@ -0,0 +1,81 @@
+package state
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// Tests that storage proofs verify against the storage root of the account and
+// that tampered proofs are rejected.
+func TestStorageProof(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(yoobadb.NewMemDatabase()))
+	addr := common.HexToAddress("0x01")
+	for i := byte(1); i <= 32; i++ {
+		state.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i, i}))
+	}
+	root := state.StorageTrie(addr).Hash()
+
+	for i := byte(1); i <= 33; i++ {
+		key := common.BytesToHash([]byte{i})
+		proof, err := state.GetStorageProof(addr, key)
+		if err != nil {
+			t.Fatalf("slot %d: failed to prove: %v", i, err)
+		}
+		value, err := VerifyStorageProof(root, key, proof)
+		if err != nil {
+			t.Fatalf("slot %d: failed to verify: %v", i, err)
+		}
+		if want := state.GetState(addr, key); value != want {
+			t.Errorf("slot %d: value mismatch: have %x, want %x", i, value, want)
+		}
+		// Corrupting any node must invalidate the proof
+		proof[len(proof)-1] = append([]byte{}, proof[len(proof)-1]...)
+		proof[len(proof)-1][len(proof[len(proof)-1])-1] ^= 0xff
+		if _, err := VerifyStorageProof(root, key, proof); err == nil {
+			t.Errorf("slot %d: tampered proof accepted", i)
+		}
+	}
+	if _, err := state.GetStorageProof(common.HexToAddress("0x02"), common.Hash{}); err != errNoStorageTrie {
+		t.Errorf("missing account error mismatch: have %v, want %v", err, errNoStorageTrie)
+	}
+}
+
+// Tests that account proofs verify against the state root, for both existing
+// and missing accounts, and that tampered proofs are rejected.
+func TestAccountProof(t *testing.T) {
+	db := NewDatabase(yoobadb.NewMemDatabase())
+	state, _ := New(common.Hash{}, db)
+	for i := byte(1); i <= 32; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)))
+		state.SetNonce(addr, uint64(i))
+	}
+	state.SetState(common.BytesToAddress([]byte{1}), common.Hash{1}, common.Hash{2})
+	root, _ := state.Commit(false)
+	state, _ = New(root, db)
+
+	for i := byte(1); i <= 33; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		proof, err := state.GetProof(addr)
+		if err != nil {
+			t.Fatalf("account %d: failed to prove: %v", i, err)
+		}
+		account, err := VerifyAccountProof(root, addr, proof)
+		if err != nil {
+			t.Fatalf("account %d: failed to verify: %v", i, err)
+		}
+		if want := state.GetAccount(addr); !reflect.DeepEqual(account, want) {
+			t.Errorf("account %d: proven account mismatch: have %+v, want %+v", i, account, want)
+		}
+		// Corrupting any node must invalidate the proof
+		proof[len(proof)-1] = append([]byte{}, proof[len(proof)-1]...)
+		proof[len(proof)-1][len(proof[len(proof)-1])-1] ^= 0xff
+		if _, err := VerifyAccountProof(root, addr, proof); err == nil {
+			t.Errorf("account %d: tampered proof accepted", i)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package pruner
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/yooba-team/yooba/common"
+)
+
+// stateBloom is a bloom filter over the hashes of all trie nodes and contract
+// codes of a state. As the keys are already cryptographic hashes, the filter
+// uses four distinct 8 byte slices of them as its hash functions.
+//
+// False positives keep some dead entries in the database, but false negatives
+// are impossible, so live state is never deleted.
+type stateBloom struct {
+	bits []uint64
+}
+
+// newStateBloomWithSize creates a bloom filter of the given size in megabytes.
+func newStateBloomWithSize(megabytes uint64) *stateBloom {
+	if megabytes == 0 {
+		megabytes = 1
+	}
+	return &stateBloom{bits: make([]uint64, megabytes*1024*1024/8)}
+}
+
+// positions returns the bit positions the given key sets in the filter.
+func (b *stateBloom) positions(key []byte) [4]uint64 {
+	var (
+		pos  [4]uint64
+		size = uint64(len(b.bits)) * 64
+	)
+	for i := range pos {
+		pos[i] = binary.BigEndian.Uint64(key[i*8:]) % size
+	}
+	return pos
+}
+
+// Put adds a node or code hash to the filter. Keys of other lengths are ignored
+// as they are never subject to pruning.
+func (b *stateBloom) Put(key []byte) {
+	if len(key) != common.HashLength {
+		return
+	}
+	for _, pos := range b.positions(key) {
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+// Contain reports whether the key may be part of the filtered state.
+func (b *stateBloom) Contain(key []byte) bool {
+	if len(key) != common.HashLength {
+		return false
+	}
+	for _, pos := range b.positions(key) {
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Commit writes the filter to the given file. The data is written to a temporary
+// file first and moved in place afterwards, so the file either holds a complete
+// filter or doesn't exist at all.
+func (b *stateBloom) Commit(filename, tempname string) error {
+	f, err := os.Create(tempname)
+	if err != nil {
+		return err
+	}
+	w := gzip.NewWriter(f)
+	if err := binary.Write(w, binary.BigEndian, uint64(len(b.bits))); err != nil {
+		f.Close()
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, b.bits); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempname, filename)
+}
+
+// newStateBloomFromDisk loads a filter written by Commit.
+func newStateBloomFromDisk(filename string) (*stateBloom, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	var words uint64
+	if err := binary.Read(r, binary.BigEndian, &words); err != nil {
+		return nil, err
+	}
+	if words == 0 || words > 1<<40 {
+		return nil, errors.New("invalid state bloom size")
+	}
+	b := &stateBloom{bits: make([]uint64, words)}
+	if err := binary.Read(r, binary.BigEndian, b.bits); err != nil {
+		return nil, err
+	}
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		return nil, errors.New("trailing data in state bloom")
+	}
+	return b, nil
+}
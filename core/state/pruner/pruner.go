@@ -0,0 +1,342 @@
+// Package pruner implements offline pruning of the state stored in the chain
+// database, deleting every trie node and contract code not referenced by a
+// single recent state.
+package pruner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+const (
+	// bloomFilePrefix and bloomFileSuffix frame the name of the bloom filter
+	// file of a pruning in progress. The target state root is put in between.
+	bloomFilePrefix = "statebloom"
+	bloomFileSuffix = "bf.gz"
+
+	// DefaultBloomSize is the default size of the bloom filter in megabytes.
+	DefaultBloomSize = 2048
+)
+
+// emptyCode is the code hash of accounts without code.
+var emptyCode = crypto.Keccak256(nil)
+
+// Pruner deletes all trie nodes and contract codes which aren't part of a given
+// target state from the database.
+//
+// Pruning happens in two phases. First the target state is walked and all its
+// node and code hashes are collected in a bloom filter, which is persisted to
+// the data directory. Then the database is iterated and every state entry not
+// contained in the filter is deleted. An interrupted pruning is resumed from the
+// persisted filter by RecoverPruning, as the second phase may already have
+// deleted parts of every other state.
+type Pruner struct {
+	db        yoobadb.Database
+	datadir   string
+	bloomSize uint64 // Size of the bloom filter in megabytes
+}
+
+// NewPruner creates a pruner for the given database. The bloom filter of a
+// pruning in progress is kept in datadir.
+func NewPruner(db yoobadb.Database, datadir string, bloomSize uint64) *Pruner {
+	if bloomSize == 0 {
+		bloomSize = DefaultBloomSize
+	}
+	return &Pruner{
+		db:        db,
+		datadir:   datadir,
+		bloomSize: bloomSize,
+	}
+}
+
+// Prune deletes every state entry from the database that isn't referenced by
+// the state with the given root. If root is empty, the state of the current head
+// block is retained, or the one of its most recent ancestor that is available.
+func (p *Pruner) Prune(root common.Hash) error {
+	// An interrupted pruning has to be finished first, its target may be the
+	// only complete state left.
+	if path, bloomRoot, err := findBloomFilter(p.datadir); err != nil {
+		return err
+	} else if path != "" {
+		if root != (common.Hash{}) && root != bloomRoot {
+			return fmt.Errorf("unfinished pruning of state %x found, resume it first", bloomRoot)
+		}
+		return RecoverPruning(p.datadir, p.db)
+	}
+	if root == (common.Hash{}) {
+		target, err := findTarget(p.db)
+		if err != nil {
+			return err
+		}
+		root = target
+	}
+	if !hasState(p.db, root) {
+		return fmt.Errorf("state %x is not available", root)
+	}
+	bloom, err := generateBloom(p.db, root, p.bloomSize)
+	if err != nil {
+		return err
+	}
+	path := bloomFilePath(p.datadir, root)
+	if err := bloom.Commit(path, path+".tmp"); err != nil {
+		return err
+	}
+	log.Info("Committed state bloom filter", "path", path)
+
+	return prune(p.db, bloom, path)
+}
+
+// RecoverPruning finishes a pruning that was interrupted after its bloom filter
+// was committed. It does nothing if no pruning is in progress. Nodes must call
+// it before opening the database for regular use.
+func RecoverPruning(datadir string, db yoobadb.Database) error {
+	path, root, err := findBloomFilter(datadir)
+	if err != nil || path == "" {
+		return err
+	}
+	bloom, err := newStateBloomFromDisk(path)
+	if err != nil {
+		return fmt.Errorf("failed to load state bloom filter %s: %v", path, err)
+	}
+	log.Info("Resuming interrupted state pruning", "root", root)
+	return prune(db, bloom, path)
+}
+
+// generateBloom walks the state with the given root and collects all node and
+// code hashes in a bloom filter. The genesis state is always retained too, as
+// the chain can't be set up again without it.
+func generateBloom(db yoobadb.Database, root common.Hash, megabytes uint64) (*stateBloom, error) {
+	var (
+		bloom = newStateBloomWithSize(megabytes)
+		start = time.Now()
+	)
+	count, err := extractState(db, root, bloom)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Generated state bloom filter", "root", root, "entries", count, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	if err := extractGenesis(db, bloom); err != nil {
+		return nil, err
+	}
+	return bloom, nil
+}
+
+// extractGenesis adds all node and code hashes of the genesis state to the
+// bloom filter.
+func extractGenesis(db yoobadb.Database, bloom *stateBloom) error {
+	hash := rawdb.ReadCanonicalHash(db, 0)
+	if hash == (common.Hash{}) {
+		return errors.New("missing genesis hash")
+	}
+	genesis := rawdb.ReadHeader(db, hash, 0)
+	if genesis == nil {
+		return errors.New("missing genesis header")
+	}
+	_, err := extractState(db, genesis.Root, bloom)
+	return err
+}
+
+// extractState adds the node hashes of the account trie with the given root to
+// the bloom filter, together with the code and the nodes of all tries every
+// account refers to: the storage trie and any goods, history or orders trie.
+// It returns the number of entries added.
+func extractState(db yoobadb.Database, root common.Hash, bloom *stateBloom) (int, error) {
+	var (
+		triedb = trie.NewDatabase(db)
+		count  int
+		start  = time.Now()
+		logged = time.Now()
+	)
+	// extractTrie adds all nodes of the trie with the given root
+	extractTrie := func(root common.Hash) error {
+		t, err := trie.New(root, triedb)
+		if err != nil {
+			return err
+		}
+		it := t.NodeIterator(nil)
+		for it.Next(true) {
+			if hash := it.Hash(); hash != (common.Hash{}) {
+				bloom.Put(hash.Bytes())
+				count++
+			}
+		}
+		return it.Error()
+	}
+	accounts, err := trie.New(root, triedb)
+	if err != nil {
+		return 0, err
+	}
+	it := accounts.NodeIterator(nil)
+	for it.Next(true) {
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			bloom.Put(hash.Bytes())
+			count++
+		}
+		if !it.Leaf() {
+			continue
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return count, err
+		}
+		if account.Root != types.EmptyRootHash {
+			if err := extractTrie(account.Root); err != nil {
+				return count, err
+			}
+		}
+		// The goods, history and orders hashes are only tries if their root
+		// node is stored, they're left alone otherwise.
+		for _, sub := range []common.Hash{account.Goodsurl, account.Historyurl, account.Ordersurl} {
+			if sub == (common.Hash{}) || sub == types.EmptyRootHash {
+				continue
+			}
+			if ok, _ := db.Has(sub.Bytes()); !ok {
+				continue
+			}
+			if err := extractTrie(sub); err != nil {
+				return count, err
+			}
+		}
+		if !bytes.Equal(account.CodeHash, emptyCode) {
+			bloom.Put(account.CodeHash)
+			count++
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Generating state bloom filter", "root", root, "entries", count, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	return count, it.Error()
+}
+
+// prune deletes all state entries not contained in the bloom filter, compacts
+// the database and removes the filter file, marking the pruning finished.
+// Deleting is idempotent, so an interrupted run can simply be repeated.
+func prune(db yoobadb.Database, bloom *stateBloom, path string) error {
+	var (
+		count  int
+		size   common.StorageSize
+		start  = time.Now()
+		logged = time.Now()
+		batch  = db.NewBatch()
+		it     = db.NewIterator()
+	)
+	for it.Next() {
+		// Trie nodes and contract codes are the only entries keyed by their
+		// plain hash, all other data is prefixed.
+		key := it.Key()
+		if len(key) != common.HashLength || bloom.Contain(key) {
+			continue
+		}
+		count++
+		size += common.StorageSize(len(key) + len(it.Value()))
+		batch.Delete(key)
+
+		if batch.ValueSize() >= yoobadb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				it.Release()
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning state data", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Pruned state data", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Compact the whole database to actually release the disk space
+	cstart := time.Now()
+	log.Info("Compacting database")
+	if err := db.Compact(nil, nil); err != nil {
+		return err
+	}
+	log.Info("Compacted database", "elapsed", common.PrettyDuration(time.Since(cstart)))
+
+	return os.Remove(path)
+}
+
+// findTarget returns the state root of the current head block or, if its state
+// isn't available, of its most recent ancestor with state.
+func findTarget(db yoobadb.Database) (common.Hash, error) {
+	head := rawdb.ReadHeadBlockHash(db)
+	number := rawdb.ReadHeaderNumber(db, head)
+	if number == nil {
+		return common.Hash{}, errors.New("head block not found")
+	}
+	for n := *number; ; n-- {
+		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, n), n)
+		if header == nil {
+			return common.Hash{}, fmt.Errorf("canonical header #%d not found", n)
+		}
+		if hasState(db, header.Root) {
+			if n != *number {
+				log.Warn("Head state missing, pruning to older state", "head", *number, "number", n)
+			}
+			return header.Root, nil
+		}
+		if n == 0 {
+			return common.Hash{}, errors.New("no state available")
+		}
+	}
+}
+
+// hasState reports whether the root node of the given state is available.
+func hasState(db yoobadb.Database, root common.Hash) bool {
+	_, err := trie.New(root, trie.NewDatabase(db))
+	return err == nil
+}
+
+// bloomFilePath returns the path of the bloom filter file for the given root.
+func bloomFilePath(datadir string, root common.Hash) string {
+	return filepath.Join(datadir, fmt.Sprintf("%s.%s.%s", bloomFilePrefix, root.Hex(), bloomFileSuffix))
+}
+
+// findBloomFilter looks for the bloom filter of a pruning in progress, returning
+// its path and target root. The path is empty if there is none.
+func findBloomFilter(datadir string) (string, common.Hash, error) {
+	files, err := ioutil.ReadDir(datadir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", common.Hash{}, nil
+		}
+		return "", common.Hash{}, err
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, bloomFilePrefix+".") || !strings.HasSuffix(name, "."+bloomFileSuffix) {
+			continue
+		}
+		hex := strings.TrimSuffix(strings.TrimPrefix(name, bloomFilePrefix+"."), "."+bloomFileSuffix)
+		if len(hex) != 2+2*common.HashLength {
+			continue
+		}
+		return filepath.Join(datadir, name), common.HexToHash(hex), nil
+	}
+	return "", common.Hash{}, nil
+}
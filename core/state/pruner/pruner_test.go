@@ -0,0 +1,211 @@
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// makeTrie creates and persists a trie with the given number of entries, where
+// the values are derived from the seed.
+func makeTrie(t *testing.T, db yoobadb.Database, seed byte, n int) common.Hash {
+	triedb := trie.NewDatabase(db)
+	tr, _ := trie.New(common.Hash{}, triedb)
+	for i := 0; i < n; i++ {
+		key := crypto.Keccak256([]byte{byte(i), byte(i >> 8)})
+		tr.Update(key, crypto.Keccak256([]byte{seed, byte(i), byte(i >> 8)}))
+	}
+	root, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to flush trie: %v", err)
+	}
+	return root
+}
+
+// trieBloom collects the node hashes of a trie in a bloom filter.
+func trieBloom(t *testing.T, db yoobadb.Database, root common.Hash) *stateBloom {
+	tr, err := trie.New(root, trie.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open trie: %v", err)
+	}
+	bloom := newStateBloomWithSize(1)
+	for it := tr.NodeIterator(nil); it.Next(true); {
+		if it.Hash() != (common.Hash{}) {
+			bloom.Put(it.Hash().Bytes())
+		}
+	}
+	return bloom
+}
+
+// checkTrie reports whether all nodes of the given trie are present.
+func checkTrie(db yoobadb.Database, root common.Hash) bool {
+	tr, err := trie.New(root, trie.NewDatabase(db))
+	if err != nil {
+		return false
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+	}
+	return it.Error() == nil
+}
+
+func newTestDatabase(t *testing.T) (*yoobadb.LDBDatabase, string) {
+	dir, err := ioutil.TempDir("", "pruner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := yoobadb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 0, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, dir
+}
+
+// Tests that pruning retains the target trie, deletes the stale one and leaves
+// all non-state data alone.
+func TestPrune(t *testing.T) {
+	db, dir := newTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	live := makeTrie(t, db, 1, 500)
+	stale := makeTrie(t, db, 2, 500)
+	db.Put([]byte("LastBlock"), live.Bytes())
+
+	bloom := trieBloom(t, db, live)
+	path := bloomFilePath(dir, live)
+	if err := bloom.Commit(path, path+".tmp"); err != nil {
+		t.Fatalf("failed to commit bloom: %v", err)
+	}
+	if err := RecoverPruning(dir, db); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if !checkTrie(db, live) {
+		t.Errorf("live trie damaged")
+	}
+	if checkTrie(db, stale) {
+		t.Errorf("stale trie retained")
+	}
+	if enc, _ := db.Get([]byte("LastBlock")); common.BytesToHash(enc) != live {
+		t.Errorf("non-state data deleted")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("bloom filter not removed after pruning: %v", err)
+	}
+	// Without a pending bloom filter recovery is a noop
+	if err := RecoverPruning(dir, db); err != nil {
+		t.Errorf("noop recovery failed: %v", err)
+	}
+}
+
+func TestStateBloomPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pruner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bloom := newStateBloomWithSize(1)
+	keys := make([][]byte, 100)
+	for i := range keys {
+		keys[i] = crypto.Keccak256([]byte{byte(i)})
+		bloom.Put(keys[i])
+	}
+	root := common.HexToHash("0x01")
+	path := bloomFilePath(dir, root)
+	if err := bloom.Commit(path, path+".tmp"); err != nil {
+		t.Fatalf("failed to commit bloom: %v", err)
+	}
+	found, foundRoot, err := findBloomFilter(dir)
+	if err != nil || found != path || foundRoot != root {
+		t.Fatalf("bloom filter lookup mismatch: have %s %x %v, want %s %x", found, foundRoot, err, path, root)
+	}
+	loaded, err := newStateBloomFromDisk(path)
+	if err != nil {
+		t.Fatalf("failed to load bloom: %v", err)
+	}
+	for i, key := range keys {
+		if !loaded.Contain(key) {
+			t.Errorf("key %d missing from loaded bloom", i)
+		}
+	}
+	if loaded.Contain([]byte("short key")) {
+		t.Errorf("non-hash key reported as contained")
+	}
+}
+
+// makeState creates and persists an account trie with a single account, whose
+// storage and goods hashes are the roots of the given tries.
+func makeState(t *testing.T, db yoobadb.Database, storage, goods common.Hash) common.Hash {
+	triedb := trie.NewDatabase(db)
+	tr, _ := trie.New(common.Hash{}, triedb)
+
+	enc, err := rlp.EncodeToBytes(state.Account{Balance: big.NewInt(1), Root: storage, CodeHash: emptyCode, Goodsurl: goods})
+	if err != nil {
+		t.Fatalf("failed to encode account: %v", err)
+	}
+	tr.Update(crypto.Keccak256(common.HexToAddress("0x01").Bytes()), enc)
+	root, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to flush trie: %v", err)
+	}
+	return root
+}
+
+// Tests that the generated bloom filter retains the tries referenced by the
+// accounts of the target state and the whole genesis state.
+func TestGenerateBloom(t *testing.T) {
+	db, dir := newTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	var (
+		genesisStorage = makeTrie(t, db, 1, 100)
+		storage        = makeTrie(t, db, 2, 100)
+		goods          = makeTrie(t, db, 3, 100)
+		stale          = makeTrie(t, db, 4, 100)
+		genesis        = makeState(t, db, genesisStorage, common.Hash{})
+		target         = makeState(t, db, storage, goods)
+	)
+	header := &types.Header{Number: big.NewInt(0), Root: genesis}
+	rawdb.WriteHeader(db, header)
+	rawdb.WriteCanonicalHash(db, header.Hash(), 0)
+
+	bloom, err := generateBloom(db, target, 1)
+	if err != nil {
+		t.Fatalf("failed to generate bloom: %v", err)
+	}
+	path := bloomFilePath(dir, target)
+	if err := bloom.Commit(path, path+".tmp"); err != nil {
+		t.Fatalf("failed to commit bloom: %v", err)
+	}
+	if err := prune(db, bloom, path); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	for name, root := range map[string]common.Hash{"target": target, "storage": storage, "goods": goods, "genesis": genesis, "genesis storage": genesisStorage} {
+		if !checkTrie(db, root) {
+			t.Errorf("%s trie damaged", name)
+		}
+	}
+	if checkTrie(db, stale) {
+		t.Errorf("stale trie retained")
+	}
+}
@@ -0,0 +1,100 @@
+package snapshot
+
+import (
+	"sync"
+
+	"github.com/yooba-team/yooba/common"
+)
+
+// diffLayer is an in-memory layer holding the state changes of a single block
+// on top of its parent layer.
+type diffLayer struct {
+	parent snapshot    // Parent snapshot modified by this one, never nil
+	root   common.Hash // Root hash to which this snapshot diff belongs to
+
+	destructs map[common.Hash]struct{}               // Accounts deleted or recreated in this layer
+	accounts  map[common.Hash][]byte                 // Live accounts written in this layer
+	storage   map[common.Hash]map[common.Hash][]byte // Storage slots written in this layer, nil for deletions
+	stale     bool                                   // Signals that the layer was flattened or dropped
+
+	lock sync.RWMutex
+}
+
+// newDiffLayer creates a new diff on top of an existing snapshot.
+func newDiffLayer(parent snapshot, root common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer {
+	if destructs == nil {
+		destructs = make(map[common.Hash]struct{})
+	}
+	if accounts == nil {
+		accounts = make(map[common.Hash][]byte)
+	}
+	if storage == nil {
+		storage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+	return &diffLayer{
+		parent:    parent,
+		root:      root,
+		destructs: destructs,
+		accounts:  accounts,
+		storage:   storage,
+	}
+}
+
+// Root returns the root hash for which this snapshot was made.
+func (dl *diffLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Account directly retrieves the account associated with a particular hash,
+// falling back to the parent layers if it wasn't changed in this one.
+func (dl *diffLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.stale {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	if data, ok := dl.accounts[hash]; ok {
+		dl.lock.RUnlock()
+		return data, nil
+	}
+	if _, ok := dl.destructs[hash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.Account(hash)
+}
+
+// Storage directly retrieves the storage slot associated with a particular hash
+// within a particular account, falling back to the parent layers if it wasn't
+// changed in this one.
+func (dl *diffLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.stale {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	if slots, ok := dl.storage[accountHash]; ok {
+		if data, ok := slots[storageHash]; ok {
+			dl.lock.RUnlock()
+			return data, nil
+		}
+	}
+	if _, ok := dl.destructs[accountHash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.Storage(accountHash, storageHash)
+}
+
+func (dl *diffLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
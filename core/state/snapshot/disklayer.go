@@ -0,0 +1,168 @@
+package snapshot
+
+import (
+	"sync"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// diskLayer is the bottom layer of the snapshot tree, backed by the flat account
+// and storage entries in the database.
+type diskLayer struct {
+	diskdb yoobadb.Database // Key-value store containing the flat snapshot
+	triedb *trie.Database   // Trie node cache used by the generator
+	root   common.Hash      // Root hash of the state the layer belongs to
+
+	genMarker []byte             // First key not yet generated, nil if the generation is done
+	genAbort  chan chan struct{} // Notification channel to abort the generation, nil if not running
+	stale     bool               // Signals that the layer was flattened into a newer one
+
+	lock sync.RWMutex
+}
+
+// Root returns the root hash for which this snapshot was made.
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Account directly retrieves the account associated with a particular hash.
+func (dl *diskLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !covered(dl.genMarker, hash[:]) {
+		return nil, ErrNotCoveredYet
+	}
+	return rawdb.ReadAccountSnapshot(dl.diskdb, hash), nil
+}
+
+// Storage directly retrieves the storage slot associated with a particular hash
+// within a particular account.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !covered(dl.genMarker, append(accountHash[:], storageHash[:]...)) {
+		return nil, ErrNotCoveredYet
+	}
+	return rawdb.ReadStorageSnapshot(dl.diskdb, accountHash, storageHash), nil
+}
+
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// startGeneration starts generating the snapshot entries from genMarker onwards
+// in the background.
+func (dl *diskLayer) startGeneration() {
+	dl.genAbort = make(chan chan struct{})
+	go dl.generate()
+}
+
+// stopGeneration aborts a running generation and waits until its progress is
+// persisted.
+func (dl *diskLayer) stopGeneration() {
+	if dl.genAbort == nil {
+		return
+	}
+	abort := make(chan struct{})
+	dl.genAbort <- abort
+	<-abort
+	dl.genAbort = nil
+}
+
+// persist writes the changes of the given diff layers, oldest first, into the
+// database and returns a new disk layer for the root of the last one. Entries
+// the generator hasn't reached yet are skipped, the new layer continues the
+// generation from the same position on its own state.
+func (dl *diskLayer) persist(diffs []*diffLayer) *diskLayer {
+	dl.stopGeneration()
+	dl.markStale()
+
+	// Invalidate the snapshot on disk until all changes are written
+	rawdb.DeleteSnapshotRoot(dl.diskdb)
+
+	var (
+		marker = dl.genMarker
+		batch  = dl.diskdb.NewBatch()
+	)
+	flush := func() {
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write state snapshot", "err", err)
+		}
+		batch.Reset()
+	}
+	for _, diff := range diffs {
+		// Wiping the storage of destructed accounts needs to see all previous
+		// writes, so every layer starts on a flushed database
+		for hash := range diff.destructs {
+			rawdb.DeleteAccountSnapshot(batch, hash)
+
+			it := dl.diskdb.NewIteratorWithPrefix(rawdb.StorageSnapshotsKey(hash))
+			for it.Next() {
+				if key := it.Key(); len(key) == len(rawdb.SnapshotStoragePrefix)+2*common.HashLength {
+					batch.Delete(common.CopyBytes(key))
+				}
+			}
+			it.Release()
+		}
+		for hash, data := range diff.accounts {
+			if covered(marker, hash[:]) {
+				rawdb.WriteAccountSnapshot(batch, hash, data)
+			}
+		}
+		for accountHash, slots := range diff.storage {
+			for storageHash, data := range slots {
+				if !covered(marker, append(accountHash[:], storageHash[:]...)) {
+					continue
+				}
+				if len(data) == 0 {
+					rawdb.DeleteStorageSnapshot(batch, accountHash, storageHash)
+				} else {
+					rawdb.WriteStorageSnapshot(batch, accountHash, storageHash, data)
+				}
+			}
+			if batch.ValueSize() >= yoobadb.IdealBatchSize {
+				flush()
+			}
+		}
+		flush()
+	}
+	base := &diskLayer{
+		diskdb:    dl.diskdb,
+		triedb:    dl.triedb,
+		root:      diffs[len(diffs)-1].root,
+		genMarker: marker,
+	}
+	writeGenerator(batch, marker)
+	rawdb.WriteSnapshotRoot(batch, base.root)
+	flush()
+
+	if marker != nil {
+		base.startGeneration()
+	}
+	return base
+}
+
+// writeGenerator stores the generation progress marker, nil meaning done.
+func writeGenerator(db yoobadb.Putter, marker []byte) {
+	blob, err := rlp.EncodeToBytes(generatorStatus{Done: marker == nil, Marker: marker})
+	if err != nil {
+		log.Crit("Failed to encode snapshot generator", "err", err)
+	}
+	rawdb.WriteSnapshotGenerator(db, blob)
+}
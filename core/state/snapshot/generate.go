@@ -0,0 +1,243 @@
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"time"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// emptyRoot is the known root hash of an empty trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// generatorStats is a collection of statistics gathered by the snapshot generator
+// for logging purposes.
+type generatorStats struct {
+	accounts uint64
+	slots    uint64
+	start    time.Time
+	logged   time.Time
+}
+
+// generateSnapshot wipes the snapshot on disk and regenerates it for the given
+// root in the background, returning the disk layer serving the progress.
+func generateSnapshot(diskdb yoobadb.Database, triedb *trie.Database, root common.Hash) *diskLayer {
+	batch := diskdb.NewBatch()
+	writeGenerator(batch, []byte{})
+	rawdb.WriteSnapshotRoot(batch, root)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write initialized state snapshot", "err", err)
+	}
+	base := &diskLayer{
+		diskdb:    diskdb,
+		triedb:    triedb,
+		root:      root,
+		genMarker: []byte{},
+	}
+	base.startGeneration()
+	return base
+}
+
+// slimAccount is the part of the consensus representation of an account the
+// generator needs, the remaining fields are skipped.
+type slimAccount struct {
+	Nonce   uint64
+	Balance *big.Int
+	Root    common.Hash
+	Rest    []rlp.RawValue `rlp:"tail"`
+}
+
+// generate iterates the state trie from the generation marker onwards and writes
+// the flat account and storage entries. An empty marker means nothing has been
+// generated yet, which requires wiping any leftovers first. Progress is persisted
+// regularly and when aborted. Once done or failed, it waits for the abort signal.
+func (dl *diskLayer) generate() {
+	var (
+		marker = dl.genMarker
+		stats  = &generatorStats{start: time.Now(), logged: time.Now()}
+		batch  = dl.diskdb.NewBatch()
+	)
+	// checkpoint persists the progress if the batch grew large or if the
+	// generation is to be aborted, reporting the latter.
+	checkpoint := func(marker []byte) bool {
+		var abort chan struct{}
+		select {
+		case abort = <-dl.genAbort:
+		default:
+		}
+		if batch.ValueSize() >= yoobadb.IdealBatchSize || abort != nil {
+			writeGenerator(batch, marker)
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to write state snapshot", "err", err)
+			}
+			batch.Reset()
+
+			dl.lock.Lock()
+			dl.genMarker = marker
+			dl.lock.Unlock()
+		}
+		if abort != nil {
+			log.Debug("Aborted state snapshot generation", "root", dl.root, "accounts", stats.accounts, "slots", stats.slots)
+			close(abort)
+			return true
+		}
+		if time.Since(stats.logged) > 8*time.Second {
+			log.Info("Generating state snapshot", "root", dl.root, "accounts", stats.accounts, "slots", stats.slots, "elapsed", common.PrettyDuration(time.Since(stats.start)))
+			stats.logged = time.Now()
+		}
+		return false
+	}
+	// fail keeps the progress and waits for the generation to be aborted, it will
+	// be retried on the next disk layer
+	fail := func(marker []byte, err error) {
+		log.Warn("State snapshot generation failed", "root", dl.root, "err", err)
+		writeGenerator(batch, marker)
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write state snapshot", "err", err)
+		}
+		dl.lock.Lock()
+		dl.genMarker = marker
+		dl.lock.Unlock()
+
+		abort := <-dl.genAbort
+		close(abort)
+	}
+	if len(marker) == 0 {
+		if dl.wipe() {
+			return
+		}
+		log.Info("Wiped stale state snapshot", "elapsed", common.PrettyDuration(time.Since(stats.start)))
+	}
+	accTrie, err := trie.New(dl.root, dl.triedb)
+	if err != nil {
+		fail(marker, err)
+		return
+	}
+	var accStart []byte
+	if len(marker) > 0 {
+		accStart = marker[:common.HashLength]
+	}
+	accIt := trie.NewIterator(accTrie.NodeIterator(accStart))
+	for accIt.Next() {
+		accountHash := common.BytesToHash(accIt.Key)
+		rawdb.WriteAccountSnapshot(batch, accountHash, accIt.Value)
+		stats.accounts++
+
+		// Resume the storage of a partially generated account
+		var storeStart []byte
+		if len(marker) > common.HashLength && bytes.Equal(accIt.Key, marker[:common.HashLength]) {
+			storeStart = marker[common.HashLength:]
+			if len(storeStart) > common.HashLength {
+				storeStart = storeStart[:common.HashLength]
+			}
+		}
+		if checkpoint(append(accountHash.Bytes(), 0)) {
+			return
+		}
+		var account slimAccount
+		if err := rlp.DecodeBytes(accIt.Value, &account); err != nil {
+			fail(append(accountHash.Bytes(), 0), err)
+			return
+		}
+		if account.Root != emptyRoot {
+			storeTrie, err := trie.New(account.Root, dl.triedb)
+			if err != nil {
+				fail(append(accountHash.Bytes(), 0), err)
+				return
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(storeStart))
+			for storeIt.Next() {
+				rawdb.WriteStorageSnapshot(batch, accountHash, common.BytesToHash(storeIt.Key), storeIt.Value)
+				stats.slots++
+
+				if checkpoint(append(append(accountHash.Bytes(), storeIt.Key...), 0)) {
+					return
+				}
+			}
+			if storeIt.Err != nil {
+				fail(append(accountHash.Bytes(), 0), storeIt.Err)
+				return
+			}
+		}
+		// Move the marker past all keys of the account
+		if next, ok := incHash(accountHash); ok {
+			if checkpoint(next.Bytes()) {
+				return
+			}
+		}
+	}
+	if accIt.Err != nil {
+		fail(marker, accIt.Err)
+		return
+	}
+	// Snapshot fully generated, persist and wait until stopped
+	writeGenerator(batch, nil)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write state snapshot", "err", err)
+	}
+	dl.lock.Lock()
+	dl.genMarker = nil
+	dl.lock.Unlock()
+
+	log.Info("Generated state snapshot", "root", dl.root, "accounts", stats.accounts, "slots", stats.slots, "elapsed", common.PrettyDuration(time.Since(stats.start)))
+
+	abort := <-dl.genAbort
+	close(abort)
+}
+
+// wipe deletes all flat account and storage entries from the database. It
+// reports whether it was aborted, in which case the next generation starts over.
+func (dl *diskLayer) wipe() bool {
+	batch := dl.diskdb.NewBatch()
+	for _, prefix := range [][]byte{rawdb.SnapshotAccountPrefix, rawdb.SnapshotStoragePrefix} {
+		// Trie nodes keyed by hash may share the prefix, only the key lengths of
+		// snapshot entries are unique
+		keylen := len(prefix) + common.HashLength
+		if bytes.Equal(prefix, rawdb.SnapshotStoragePrefix) {
+			keylen += common.HashLength
+		}
+		it := dl.diskdb.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			if key := it.Key(); len(key) == keylen {
+				batch.Delete(common.CopyBytes(key))
+			}
+			if batch.ValueSize() < yoobadb.IdealBatchSize {
+				continue
+			}
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to wipe state snapshot", "err", err)
+			}
+			batch.Reset()
+
+			select {
+			case abort := <-dl.genAbort:
+				it.Release()
+				close(abort)
+				return true
+			default:
+			}
+		}
+		it.Release()
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to wipe state snapshot", "err", err)
+	}
+	return false
+}
+
+// incHash returns the hash following h, or false if h is the largest one.
+func incHash(h common.Hash) (common.Hash, bool) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			return h, true
+		}
+	}
+	return h, false
+}
@@ -0,0 +1,287 @@
+// Package snapshot implements a flat, trie independent view of the state which
+// allows reading accounts and storage slots without walking the tries.
+//
+// The snapshot consists of a persistent disk layer holding the state of some
+// older block, and a tree of in-memory diff layers on top of it, one for each
+// more recent block. Diff layers of blocks on side chains coexist with the ones
+// of the canonical chain until they are capped, so reorgs only need to pick a
+// different layer to read from.
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+var (
+	// ErrSnapshotStale is returned from data accessors if the underlying snapshot
+	// layer had been invalidated due to the chain progressing forward far enough
+	// to not maintain the layer's original state.
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	// ErrNotCoveredYet is returned from data accessors if the underlying snapshot
+	// is being generated currently and the requested data item is not yet in the
+	// range of accounts covered.
+	ErrNotCoveredYet = errors.New("not covered yet")
+
+	// errSnapshotCycle is returned if a snapshot is attempted to be inserted
+	// that forms a cycle in the snapshot tree.
+	errSnapshotCycle = errors.New("snapshot cycle")
+)
+
+// Snapshot represents the functionality supported by a snapshot storage layer.
+// Accounts and storage slots are addressed by the hash of their trie key and
+// returned in the same RLP encoding as in the tries. A nil value without error
+// means the item doesn't exist.
+type Snapshot interface {
+	// Root returns the root hash for which this snapshot was made.
+	Root() common.Hash
+
+	// Account directly retrieves the RLP encoded account associated with a
+	// particular hash in the snapshot slim data format.
+	Account(hash common.Hash) ([]byte, error)
+
+	// Storage directly retrieves the RLP encoded storage slot associated with
+	// a particular hash within a particular account.
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is the internal version of the snapshot data layer that supports some
+// additional methods compared to the public API.
+type snapshot interface {
+	Snapshot
+
+	// markStale invalidates the layer, failing all subsequent reads.
+	markStale()
+}
+
+// generatorStatus is the persisted progress of the snapshot generation.
+type generatorStatus struct {
+	Done   bool
+	Marker []byte
+}
+
+// Tree is a collection of snapshot layers, a single persistent disk layer and
+// any number of in-memory diff layers on top of it, indexed by state root.
+type Tree struct {
+	diskdb yoobadb.Database
+	triedb *trie.Database
+	layers map[common.Hash]snapshot
+	lock   sync.RWMutex
+}
+
+// New attempts to load an already existing snapshot from the database for the
+// state with the given root. If the snapshot is missing or doesn't belong to
+// the root, it is regenerated in the background.
+func New(diskdb yoobadb.Database, triedb *trie.Database, root common.Hash) *Tree {
+	snap := &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		layers: make(map[common.Hash]snapshot),
+	}
+	if base := loadDiskLayer(diskdb, triedb, root); base != nil {
+		snap.layers[root] = base
+		return snap
+	}
+	log.Warn("State snapshot missing or stale, rebuilding", "root", root)
+	snap.layers[root] = generateSnapshot(diskdb, triedb, root)
+	return snap
+}
+
+// loadDiskLayer loads the disk layer of the snapshot on disk if it belongs to
+// the given root, resuming its generation if unfinished.
+func loadDiskLayer(diskdb yoobadb.Database, triedb *trie.Database, root common.Hash) *diskLayer {
+	if rawdb.ReadSnapshotRoot(diskdb) != root {
+		return nil
+	}
+	blob := rawdb.ReadSnapshotGenerator(diskdb)
+	if blob == nil {
+		return nil
+	}
+	var status generatorStatus
+	if err := rlp.DecodeBytes(blob, &status); err != nil {
+		log.Warn("Failed to decode snapshot generator", "err", err)
+		return nil
+	}
+	base := &diskLayer{
+		diskdb: diskdb,
+		triedb: triedb,
+		root:   root,
+	}
+	if !status.Done {
+		base.genMarker = status.Marker
+		if base.genMarker == nil {
+			base.genMarker = []byte{}
+		}
+		base.startGeneration()
+	}
+	return base
+}
+
+// Snapshot retrieves a snapshot belonging to the given state root, or nil if no
+// snapshot is maintained for that root.
+func (t *Tree) Snapshot(root common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if snap, ok := t.layers[root]; ok {
+		return snap
+	}
+	return nil
+}
+
+// Update adds a new diff layer with the given root on top of the layer of the
+// parent root. Destructed accounts have their storage wiped before the account
+// and storage changes of the layer apply. Storage slots with nil values were
+// deleted.
+func (t *Tree) Update(root common.Hash, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	if root == parentRoot {
+		return errSnapshotCycle
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	parent, ok := t.layers[parentRoot]
+	if !ok {
+		return fmt.Errorf("parent [%#x] snapshot missing", parentRoot)
+	}
+	if _, ok := t.layers[root]; ok {
+		return nil
+	}
+	t.layers[root] = newDiffLayer(parent, root, destructs, accounts, storage)
+	return nil
+}
+
+// Cap traverses downwards the diff tree from the layer of the given root and
+// flattens all diff layers below the given number of retained layers into the
+// disk layer. Layers not descending from the new disk layer belong to chains
+// which can't become canonical anymore and are dropped.
+//
+// A cap of zero flattens every layer below and including the given root.
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	snap, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	diff, ok := snap.(*diffLayer)
+	if !ok {
+		return nil // Already the disk layer
+	}
+	var base *diskLayer
+	if layers == 0 {
+		base = flatten(diff)
+	} else {
+		keep := diff
+		for i := 0; i < layers-1; i++ {
+			parent, ok := keep.parent.(*diffLayer)
+			if !ok {
+				return nil
+			}
+			keep = parent
+		}
+		bottom, ok := keep.parent.(*diffLayer)
+		if !ok {
+			return nil
+		}
+		base = flatten(bottom)
+
+		keep.lock.Lock()
+		keep.parent = base
+		keep.lock.Unlock()
+	}
+	// Drop all layers not built on top of the new disk layer
+	remaining := map[common.Hash]snapshot{base.root: base}
+	for root, layer := range t.layers {
+		if diskLayerOf(layer) == base {
+			remaining[root] = layer
+		} else {
+			layer.markStale()
+		}
+	}
+	t.layers = remaining
+	return nil
+}
+
+// Rebuild wipes all layers and the snapshot on disk, and regenerates it in the
+// background for the given root. It's needed when the chain is rewound below
+// the disk layer.
+func (t *Tree) Rebuild(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, layer := range t.layers {
+		if base, ok := layer.(*diskLayer); ok {
+			base.stopGeneration()
+		}
+		layer.markStale()
+	}
+	log.Info("Rebuilding state snapshot", "root", root)
+	t.layers = map[common.Hash]snapshot{root: generateSnapshot(t.diskdb, t.triedb, root)}
+}
+
+// Release stops the background snapshot generation, if any.
+func (t *Tree) Release() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, layer := range t.layers {
+		if base, ok := layer.(*diskLayer); ok {
+			base.stopGeneration()
+		}
+	}
+}
+
+// diskLayerOf returns the disk layer at the bottom of the given layer.
+func diskLayerOf(layer snapshot) *diskLayer {
+	for {
+		switch l := layer.(type) {
+		case *diskLayer:
+			return l
+		case *diffLayer:
+			layer = l.parent
+		}
+	}
+}
+
+// flatten merges the given diff layer and all diff layers below it into the
+// disk layer, returning the new disk layer. The old disk layer and the merged
+// diff layers are marked stale.
+func flatten(diff *diffLayer) *diskLayer {
+	var diffs []*diffLayer
+	for layer := snapshot(diff); ; {
+		d, ok := layer.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append(diffs, d)
+		layer = d.parent
+	}
+	// Apply the layers in the order they were created
+	for i, j := 0, len(diffs)-1; i < j; i, j = i+1, j-1 {
+		diffs[i], diffs[j] = diffs[j], diffs[i]
+	}
+	base := diskLayerOf(diff).persist(diffs)
+	for _, d := range diffs {
+		d.markStale()
+	}
+	return base
+}
+
+// covered reports whether a snapshot key has already been processed by the
+// generator with the given progress marker. A nil marker means the generation
+// is complete.
+func covered(marker []byte, key []byte) bool {
+	return marker == nil || bytes.Compare(key, marker) < 0
+}
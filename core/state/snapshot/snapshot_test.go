@@ -0,0 +1,224 @@
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// makeState creates a state trie of a few accounts, some of them with storage,
+// returning its root and the expected flat entries.
+func makeState(t *testing.T, triedb *trie.Database) (common.Hash, map[common.Hash][]byte, map[common.Hash]map[common.Hash][]byte) {
+	accounts := make(map[common.Hash][]byte)
+	storage := make(map[common.Hash]map[common.Hash][]byte)
+
+	accTrie, _ := trie.New(common.Hash{}, triedb)
+	for i := byte(1); i <= 16; i++ {
+		root := emptyRoot
+		if i%2 == 0 {
+			storeTrie, _ := trie.New(common.Hash{}, triedb)
+			slots := make(map[common.Hash][]byte)
+			for j := byte(1); j <= 8; j++ {
+				key := common.BytesToHash([]byte{i, j})
+				val, _ := rlp.EncodeToBytes([]byte{j})
+				storeTrie.Update(key[:], val)
+				slots[key] = val
+			}
+			var err error
+			if root, err = storeTrie.Commit(nil); err != nil {
+				t.Fatalf("failed to commit storage trie: %v", err)
+			}
+			storage[common.BytesToHash([]byte{i})] = slots
+		}
+		blob, _ := rlp.EncodeToBytes(&slimAccount{Nonce: uint64(i), Balance: big.NewInt(int64(i)), Root: root})
+		hash := common.BytesToHash([]byte{i})
+		accTrie.Update(hash[:], blob)
+		accounts[hash] = blob
+	}
+	root, err := accTrie.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit account trie: %v", err)
+	}
+	return root, accounts, storage
+}
+
+// waitGeneration waits until the generation of the disk layer finishes.
+func waitGeneration(t *testing.T, snaps *Tree, root common.Hash) {
+	base := snaps.Snapshot(root).(*diskLayer)
+	for i := 0; i < 100; i++ {
+		base.lock.RLock()
+		done := base.genMarker == nil
+		base.lock.RUnlock()
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("snapshot generation didn't finish")
+}
+
+// checkSnapshot verifies that the snapshot serves exactly the given entries.
+func checkSnapshot(t *testing.T, snap Snapshot, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) {
+	for hash, want := range accounts {
+		if have, err := snap.Account(hash); err != nil || !bytes.Equal(have, want) {
+			t.Errorf("account %x mismatch: have %x, %v, want %x", hash, have, err, want)
+		}
+	}
+	for accountHash, slots := range storage {
+		for storageHash, want := range slots {
+			if have, err := snap.Storage(accountHash, storageHash); err != nil || !bytes.Equal(have, want) {
+				t.Errorf("slot %x/%x mismatch: have %x, %v, want %x", accountHash, storageHash, have, err, want)
+			}
+		}
+	}
+}
+
+// Tests that a snapshot is generated from the state trie on a fresh database.
+func TestGeneration(t *testing.T) {
+	diskdb := yoobadb.NewMemDatabase()
+	triedb := trie.NewDatabase(diskdb)
+	root, accounts, storage := makeState(t, triedb)
+
+	snaps := New(diskdb, triedb, root)
+	waitGeneration(t, snaps, root)
+	checkSnapshot(t, snaps.Snapshot(root), accounts, storage)
+
+	if have := rawdb.ReadSnapshotRoot(diskdb); have != root {
+		t.Errorf("persisted root mismatch: have %x, want %x", have, root)
+	}
+	if _, err := snaps.Snapshot(root).Account(common.HexToHash("0xff")); err != nil {
+		t.Errorf("missing account failed: %v", err)
+	}
+	snaps.Release()
+}
+
+// Tests that an interrupted generation is resumed from its persisted marker and
+// that entries beyond the marker are not served until generated.
+func TestGenerationResume(t *testing.T) {
+	diskdb := yoobadb.NewMemDatabase()
+	triedb := trie.NewDatabase(diskdb)
+	root, accounts, storage := makeState(t, triedb)
+
+	// Pretend the first half of the accounts was generated
+	marker := common.BytesToHash([]byte{9}).Bytes()
+	for hash, blob := range accounts {
+		if bytes.Compare(hash[:], marker) < 0 {
+			rawdb.WriteAccountSnapshot(diskdb, hash, blob)
+			for storageHash, val := range storage[hash] {
+				rawdb.WriteStorageSnapshot(diskdb, hash, storageHash, val)
+			}
+		}
+	}
+	writeGenerator(diskdb, marker)
+	rawdb.WriteSnapshotRoot(diskdb, root)
+
+	base := &diskLayer{diskdb: diskdb, triedb: triedb, root: root, genMarker: marker}
+	if _, err := base.Account(common.BytesToHash([]byte{12})); err != ErrNotCoveredYet {
+		t.Errorf("uncovered account error mismatch: have %v, want %v", err, ErrNotCoveredYet)
+	}
+	snaps := New(diskdb, triedb, root)
+	waitGeneration(t, snaps, root)
+	checkSnapshot(t, snaps.Snapshot(root), accounts, storage)
+	snaps.Release()
+}
+
+// Tests that diff layers shadow their parents, that side chains coexist until
+// capped and that capping flattens the layers into the disk.
+func TestDiffLayers(t *testing.T) {
+	diskdb := yoobadb.NewMemDatabase()
+	triedb := trie.NewDatabase(diskdb)
+	root, accounts, storage := makeState(t, triedb)
+
+	snaps := New(diskdb, triedb, root)
+	waitGeneration(t, snaps, root)
+
+	var (
+		acc2, acc4 = common.BytesToHash([]byte{2}), common.BytesToHash([]byte{4})
+		slot1      = common.BytesToHash([]byte{2, 1})
+		slot2      = common.BytesToHash([]byte{2, 2})
+		root1      = common.HexToHash("0x01")
+		side1      = common.HexToHash("0x02")
+		root2      = common.HexToHash("0x03")
+	)
+	// Block 1 modifies an account and a slot, deletes another slot and
+	// destructs a whole account
+	err := snaps.Update(root1, root, map[common.Hash]struct{}{acc4: {}},
+		map[common.Hash][]byte{acc2: []byte("acc2")},
+		map[common.Hash]map[common.Hash][]byte{acc2: {slot1: []byte("slot1"), slot2: nil}})
+	if err != nil {
+		t.Fatalf("failed to add block 1: %v", err)
+	}
+	// A side chain block on the same parent and a child of block 1
+	if err := snaps.Update(side1, root, nil, map[common.Hash][]byte{acc2: []byte("side")}, nil); err != nil {
+		t.Fatalf("failed to add side block: %v", err)
+	}
+	if err := snaps.Update(root2, root1, nil, map[common.Hash][]byte{acc4: []byte("acc4")}, nil); err != nil {
+		t.Fatalf("failed to add block 2: %v", err)
+	}
+	if err := snaps.Update(root2, root2, nil, nil, nil); err != errSnapshotCycle {
+		t.Errorf("cycle error mismatch: have %v, want %v", err, errSnapshotCycle)
+	}
+	check := func(snap Snapshot, acc common.Hash, slot common.Hash, wantAcc, wantSlot []byte) {
+		if have, err := snap.Account(acc); err != nil || !bytes.Equal(have, wantAcc) {
+			t.Errorf("root %x: account %x mismatch: have %x, %v, want %x", snap.Root(), acc, have, err, wantAcc)
+		}
+		if have, err := snap.Storage(acc, slot); err != nil || !bytes.Equal(have, wantSlot) {
+			t.Errorf("root %x: slot %x mismatch: have %x, %v, want %x", snap.Root(), slot, have, err, wantSlot)
+		}
+	}
+	check(snaps.Snapshot(side1), acc2, slot1, []byte("side"), storage[acc2][slot1])
+	check(snaps.Snapshot(root1), acc2, slot1, []byte("acc2"), []byte("slot1"))
+	check(snaps.Snapshot(root1), acc2, slot2, []byte("acc2"), nil)
+	check(snaps.Snapshot(root1), acc4, common.BytesToHash([]byte{4, 1}), nil, nil)
+	check(snaps.Snapshot(root2), acc4, common.BytesToHash([]byte{4, 1}), []byte("acc4"), nil)
+
+	// Flatten block 1 into the disk, dropping the side chain
+	stale := snaps.Snapshot(root1)
+	if err := snaps.Cap(root2, 1); err != nil {
+		t.Fatalf("failed to cap: %v", err)
+	}
+	if snaps.Snapshot(side1) != nil {
+		t.Errorf("side chain layer retained")
+	}
+	if _, err := stale.Account(acc2); err != ErrSnapshotStale {
+		t.Errorf("flattened layer error mismatch: have %v, want %v", err, ErrSnapshotStale)
+	}
+	if _, ok := snaps.Snapshot(root1).(*diskLayer); !ok {
+		t.Fatalf("block 1 not flattened into disk layer")
+	}
+	if have := rawdb.ReadSnapshotRoot(diskdb); have != root1 {
+		t.Errorf("persisted root mismatch: have %x, want %x", have, root1)
+	}
+	if have := rawdb.ReadStorageSnapshot(diskdb, acc4, common.BytesToHash([]byte{4, 1})); have != nil {
+		t.Errorf("destructed storage not wiped: %x", have)
+	}
+	check(snaps.Snapshot(root2), acc2, slot1, []byte("acc2"), []byte("slot1"))
+	check(snaps.Snapshot(root2), acc2, slot2, []byte("acc2"), nil)
+	check(snaps.Snapshot(root2), acc4, common.BytesToHash([]byte{4, 1}), []byte("acc4"), nil)
+
+	// Untouched entries are still served from the disk
+	delete(accounts, acc2)
+	delete(accounts, acc4)
+	delete(storage, acc2)
+	delete(storage, acc4)
+	checkSnapshot(t, snaps.Snapshot(root2), accounts, storage)
+
+	// Flatten everything
+	if err := snaps.Cap(root2, 0); err != nil {
+		t.Fatalf("failed to cap: %v", err)
+	}
+	if have := rawdb.ReadAccountSnapshot(diskdb, acc4); !bytes.Equal(have, []byte("acc4")) {
+		t.Errorf("persisted account mismatch: have %x, want %x", have, []byte("acc4"))
+	}
+	if have := rawdb.ReadSnapshotRoot(diskdb); have != root2 {
+		t.Errorf("persisted root mismatch: have %x, want %x", have, root2)
+	}
+	snaps.Release()
+}
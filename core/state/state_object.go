@@ -23,6 +23,7 @@ import (
 	"math/big"
 
 	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/crypto"
 	"github.com/yooba-team/yooba/rlp"
 )
@@ -77,7 +78,7 @@ type stateObject struct {
 	trie Trie // storage trie, which becomes non-nil on first access
 	code Code // contract bytecode, which gets set when code is loaded
 
-	cachedStorage Storage // Storage entry cache to avoid duplicate reads
+	originStorage Storage // Storage cache of original entries to dedup rewrites
 	dirtyStorage  Storage // Storage entries that need to be flushed to disk
 
 	// Cache flags.
@@ -86,6 +87,10 @@ type stateObject struct {
 	dirtyCode bool // true if the code was updated
 	suicided  bool
 	deleted   bool
+
+	// storageReplaced is set once SetStorage swapped the storage trie, from
+	// then on the snapshot holds outdated slots and must not be read.
+	storageReplaced bool
 }
 
 // empty returns whether the account is considered empty.
@@ -108,6 +113,89 @@ type Account struct {
 	Goodsurl   common.Hash
 	Historyurl common.Hash
 	Ordersurl  common.Hash
+
+	// Signing keys of multi-signature accounts, a zero threshold means the
+	// account is controlled by the single key its address is derived from.
+	MultisigKeys      []types.MultisigKey
+	MultisigThreshold uint64
+
+	// Vesting schedules locking part of the balance
+	Vesting []types.VestingSchedule
+
+	// Balance staked for a share of the block resources and the resources used
+	// from it. The stake of the resource system account is the total stake.
+	Stake     *big.Int
+	Resources types.ResourceUsage
+}
+
+// accountRLP is the encoding of an Account in the account trie. RLP has no
+// signed integers, so the score is stored as its two's complement byte.
+type accountRLP struct {
+	Nonce             uint64
+	Balance           *big.Int
+	Root              common.Hash
+	CodeHash          []byte
+	Homepage          string
+	AccountName       string
+	IsStore           bool
+	Score             uint8
+	Goodsurl          common.Hash
+	Historyurl        common.Hash
+	Ordersurl         common.Hash
+	MultisigKeys      []types.MultisigKey
+	MultisigThreshold uint64
+	Vesting           []types.VestingSchedule
+	Stake             *big.Int
+	Resources         types.ResourceUsage
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (a Account) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, &accountRLP{
+		Nonce:             a.Nonce,
+		Balance:           a.Balance,
+		Root:              a.Root,
+		CodeHash:          a.CodeHash,
+		Homepage:          a.Homepage,
+		AccountName:       a.AccountName,
+		IsStore:           a.IsStore,
+		Score:             uint8(a.Score),
+		Goodsurl:          a.Goodsurl,
+		Historyurl:        a.Historyurl,
+		Ordersurl:         a.Ordersurl,
+		MultisigKeys:      a.MultisigKeys,
+		MultisigThreshold: a.MultisigThreshold,
+		Vesting:           a.Vesting,
+		Stake:             a.Stake,
+		Resources:         a.Resources,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (a *Account) DecodeRLP(s *rlp.Stream) error {
+	var dec accountRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	*a = Account{
+		Nonce:             dec.Nonce,
+		Balance:           dec.Balance,
+		Root:              dec.Root,
+		CodeHash:          dec.CodeHash,
+		Homepage:          dec.Homepage,
+		AccountName:       dec.AccountName,
+		IsStore:           dec.IsStore,
+		Score:             int8(dec.Score),
+		Goodsurl:          dec.Goodsurl,
+		Historyurl:        dec.Historyurl,
+		Ordersurl:         dec.Ordersurl,
+		MultisigKeys:      dec.MultisigKeys,
+		MultisigThreshold: dec.MultisigThreshold,
+		Vesting:           dec.Vesting,
+		Stake:             dec.Stake,
+		Resources:         dec.Resources,
+	}
+	return nil
 }
 
 
@@ -120,12 +208,15 @@ func newObject(db *StateDB, address common.Address, data Account) *stateObject {
 	if data.CodeHash == nil {
 		data.CodeHash = emptyCodeHash
 	}
+	if data.Stake == nil {
+		data.Stake = new(big.Int)
+	}
 	return &stateObject{
 		db:            db,
 		address:       address,
 		addrHash:      crypto.Keccak256Hash(address[:]),
 		data:          data,
-		cachedStorage: make(Storage),
+		originStorage: make(Storage),
 		dirtyStorage:  make(Storage),
 	}
 }
@@ -169,17 +260,47 @@ func (c *stateObject) getTrie(db Database) Trie {
 	return c.trie
 }
 
-// GetState returns a value in account storage.
+// GetState retrieves a value from the account storage trie.
 func (self *stateObject) GetState(db Database, key common.Hash) common.Hash {
-	value, exists := self.cachedStorage[key]
-	if exists {
+	// If we have a dirty value for this state entry, return it
+	value, dirty := self.dirtyStorage[key]
+	if dirty {
 		return value
 	}
-	// Load from DB in case it is missing.
-	enc, err := self.getTrie(db).TryGet(key[:])
-	if err != nil {
-		self.setError(err)
-		return common.Hash{}
+	// Otherwise return the entry's original value
+	return self.GetCommittedState(db, key)
+}
+
+// GetCommittedState retrieves a value from the committed account storage trie.
+func (self *stateObject) GetCommittedState(db Database, key common.Hash) common.Hash {
+	// If we have the original value cached, return that
+	value, cached := self.originStorage[key]
+	if cached {
+		return value
+	}
+	// Storage of accounts destructed in this block is gone, the snapshot doesn't
+	// know about that yet
+	if self.db.snap != nil {
+		if _, destructed := self.db.snapDestructs[self.addrHash]; destructed {
+			self.originStorage[key] = common.Hash{}
+			return common.Hash{}
+		}
+	}
+	// Otherwise load the value from the snapshot if it covers the slot and the
+	// storage wasn't replaced, or from the database
+	var (
+		enc     []byte
+		err     error
+		useSnap = self.db.snap != nil && !self.storageReplaced
+	)
+	if useSnap {
+		enc, err = self.db.snap.Storage(self.addrHash, crypto.Keccak256Hash(key[:]))
+	}
+	if !useSnap || err != nil {
+		if enc, err = self.getTrie(db).TryGet(key[:]); err != nil {
+			self.setError(err)
+			return common.Hash{}
+		}
 	}
 	if len(enc) > 0 {
 		_, content, _, err := rlp.Split(enc)
@@ -188,7 +309,7 @@ func (self *stateObject) GetState(db Database, key common.Hash) common.Hash {
 		}
 		value.SetBytes(content)
 	}
-	self.cachedStorage[key] = value
+	self.originStorage[key] = value
 	return value
 }
 
@@ -203,22 +324,58 @@ func (self *stateObject) SetState(db Database, key, value common.Hash) {
 }
 
 func (self *stateObject) setState(key, value common.Hash) {
-	self.cachedStorage[key] = value
 	self.dirtyStorage[key] = value
 }
 
+// SetStorage replaces the entire account storage. The change isn't journaled, it
+// is only meant for simulating calls against modified state.
+func (self *stateObject) SetStorage(db Database, storage map[common.Hash]common.Hash) {
+	self.trie, _ = db.OpenStorageTrie(self.addrHash, common.Hash{})
+	self.originStorage = make(Storage)
+	self.dirtyStorage = make(Storage)
+	self.storageReplaced = true
+
+	// Committing the state has to drop the old slots from the snapshot too
+	if self.db.snap != nil {
+		self.db.snapDestructs[self.addrHash] = struct{}{}
+		delete(self.db.snapStorage, self.addrHash)
+	}
+	for key, value := range storage {
+		self.setState(key, value)
+	}
+}
+
 // updateTrie writes cached storage modifications into the object's storage trie.
 func (self *stateObject) updateTrie(db Database) Trie {
 	tr := self.getTrie(db)
+
+	var storage map[common.Hash][]byte
+	if self.db.snap != nil {
+		if storage = self.db.snapStorage[self.addrHash]; storage == nil {
+			storage = make(map[common.Hash][]byte)
+			self.db.snapStorage[self.addrHash] = storage
+		}
+	}
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
+
+		// Skip noop changes, persist actual changes
+		if value == self.originStorage[key] {
+			continue
+		}
+		self.originStorage[key] = value
+
+		var v []byte
 		if (value == common.Hash{}) {
 			self.setError(tr.TryDelete(key[:]))
-			continue
+		} else {
+			// Encoding []byte cannot fail, ok to ignore the error.
+			v, _ = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+			self.setError(tr.TryUpdate(key[:], v))
+		}
+		if storage != nil {
+			storage[crypto.Keccak256Hash(key[:])] = v // nil marks a deletion
 		}
-		// Encoding []byte cannot fail, ok to ignore the error.
-		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
-		self.setError(tr.TryUpdate(key[:], v))
 	}
 	return tr
 }
@@ -289,10 +446,11 @@ func (self *stateObject) deepCopy(db *StateDB) *stateObject {
 	}
 	stateObject.code = self.code
 	stateObject.dirtyStorage = self.dirtyStorage.Copy()
-	stateObject.cachedStorage = self.dirtyStorage.Copy()
+	stateObject.originStorage = self.originStorage.Copy()
 	stateObject.suicided = self.suicided
 	stateObject.dirtyCode = self.dirtyCode
 	stateObject.deleted = self.deleted
+	stateObject.storageReplaced = self.storageReplaced
 	return stateObject
 }
 
@@ -389,6 +547,56 @@ func (self *stateObject) setStoreMap(storeMap map[common.Address] *Account) {
 
 }
 
+func (self *stateObject) SetMultisig(keys []types.MultisigKey, threshold uint64) {
+	self.db.journal.append(multisigChange{
+		account:       &self.address,
+		prevKeys:      self.data.MultisigKeys,
+		prevThreshold: self.data.MultisigThreshold,
+	})
+	self.setMultisig(keys, threshold)
+}
+
+func (self *stateObject) setMultisig(keys []types.MultisigKey, threshold uint64) {
+	self.data.MultisigKeys = keys
+	self.data.MultisigThreshold = threshold
+}
+
+func (self *stateObject) SetVesting(schedules []types.VestingSchedule) {
+	self.db.journal.append(vestingChange{
+		account: &self.address,
+		prev:    self.data.Vesting,
+	})
+	self.setVesting(schedules)
+}
+
+func (self *stateObject) setVesting(schedules []types.VestingSchedule) {
+	self.data.Vesting = schedules
+}
+
+func (self *stateObject) SetStake(amount *big.Int) {
+	self.db.journal.append(stakeChange{
+		account: &self.address,
+		prev:    new(big.Int).Set(self.data.Stake),
+	})
+	self.setStake(amount)
+}
+
+func (self *stateObject) setStake(amount *big.Int) {
+	self.data.Stake = amount
+}
+
+func (self *stateObject) SetResources(usage types.ResourceUsage) {
+	self.db.journal.append(resourcesChange{
+		account: &self.address,
+		prev:    self.data.Resources,
+	})
+	self.setResources(usage)
+}
+
+func (self *stateObject) setResources(usage types.ResourceUsage) {
+	self.data.Resources = usage
+}
+
 func (self *stateObject) SetHistoryurl(historyurl common.Hash) {
 	self.db.journal.append(historyurlChange{
 		account: &self.address,
@@ -462,6 +670,22 @@ func (self *stateObject) Score() int8 {
 	return self.data.Score
 }
 
+func (self *stateObject) Multisig() ([]types.MultisigKey, uint64) {
+	return self.data.MultisigKeys, self.data.MultisigThreshold
+}
+
+func (self *stateObject) Vesting() []types.VestingSchedule {
+	return self.data.Vesting
+}
+
+func (self *stateObject) Stake() *big.Int {
+	return self.data.Stake
+}
+
+func (self *stateObject) Resources() types.ResourceUsage {
+	return self.data.Resources
+}
+
 func (self *stateObject) Historyurl() common.Hash {
 	return self.data.Historyurl
 }
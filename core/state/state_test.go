@@ -53,7 +53,7 @@ func (s *StateSuite) TestDump(c *checker.C) {
 	// check that dump contains the state objects that are in trie
 	got := string(s.state.Dump())
 	want := `{
-    "root": "71edff0130dd2385947095001c73d9e28d862fc286fca2b922ca6f6f3cddfdd2",
+    "root": "962785cbbdc00df72200043fc772ea9bf88bd139a3bf7d5bcb4055adeaabdbbd",
     "accounts": {
         "0000000000000000000000000000000000000001": {
             "balance": "22",
@@ -133,7 +133,7 @@ func (s *StateSuite) TestSnapshotEmpty(c *checker.C) {
 // use testing instead of checker because checker does not support
 // printing/logging in tests (-check.vv does not work)
 func TestSnapshot2(t *testing.T) {
-	db, _ := yoobadb.NewMemDatabase()
+	db := yoobadb.NewMemDatabase()
 	state, _ := New(common.Hash{}, NewDatabase(db))
 
 	stateobjaddr0 := toAddr([]byte("so0"))
@@ -209,16 +209,16 @@ func compareStateObjects(so0, so1 *stateObject, t *testing.T) {
 		t.Fatalf("Code mismatch: have %v, want %v", so0.code, so1.code)
 	}
 
-	if len(so1.cachedStorage) != len(so0.cachedStorage) {
-		t.Errorf("Storage size mismatch: have %d, want %d", len(so1.cachedStorage), len(so0.cachedStorage))
+	if len(so1.originStorage) != len(so0.originStorage) {
+		t.Errorf("Storage size mismatch: have %d, want %d", len(so1.originStorage), len(so0.originStorage))
 	}
-	for k, v := range so1.cachedStorage {
-		if so0.cachedStorage[k] != v {
-			t.Errorf("Storage key %x mismatch: have %v, want %v", k, so0.cachedStorage[k], v)
+	for k, v := range so1.originStorage {
+		if so0.originStorage[k] != v {
+			t.Errorf("Storage key %x mismatch: have %v, want %v", k, so0.originStorage[k], v)
 		}
 	}
-	for k, v := range so0.cachedStorage {
-		if so1.cachedStorage[k] != v {
+	for k, v := range so0.originStorage {
+		if so1.originStorage[k] != v {
 			t.Errorf("Storage key %x mismatch: have %v, want none.", k, v)
 		}
 	}
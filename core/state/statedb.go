@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/state/snapshot"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/crypto"
 	"github.com/yooba-team/yooba/log"
@@ -44,6 +45,10 @@ var (
 	emptyCode = crypto.Keccak256Hash(nil)
 )
 
+// snapshotLayers is the number of in-memory diff layers kept on top of the flat
+// state snapshot on disk, matching the number of recent tries kept in memory.
+const snapshotLayers = 128
+
 // StateDBs within the Yooba protocol are used to store anything
 // within the merkle trie. StateDBs take care of caching and storing
 // nested states. It's the general query interface to retrieve:
@@ -53,6 +58,14 @@ type StateDB struct {
 	db   Database
 	trie Trie
 
+	// Flat state snapshot consulted before the trie, and the changes of the
+	// block to be added to it on commit. All nil if no snapshot is available.
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects      map[common.Address]*stateObject
 	stateObjectsDirty map[common.Address]struct{}
@@ -100,6 +113,32 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 	}, nil
 }
 
+// NewWithSnapshot creates a new state from a given trie, reading accounts and
+// storage from the flat snapshot of the root if the snapshot tree has one. The
+// changes committed to the state are added to the tree.
+func NewWithSnapshot(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error) {
+	sdb, err := New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	if snaps != nil {
+		sdb.snaps = snaps
+		sdb.openSnapshot(root)
+	}
+	return sdb, nil
+}
+
+// openSnapshot selects the snapshot layer of the given root and resets the
+// tracked changes.
+func (self *StateDB) openSnapshot(root common.Hash) {
+	self.snap, self.snapDestructs, self.snapAccounts, self.snapStorage = nil, nil, nil, nil
+	if self.snap = self.snaps.Snapshot(root); self.snap != nil {
+		self.snapDestructs = make(map[common.Hash]struct{})
+		self.snapAccounts = make(map[common.Hash][]byte)
+		self.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+}
+
 // setError remembers the first non-nil error it is called with.
 func (self *StateDB) setError(err error) {
 	if self.dbErr == nil {
@@ -128,6 +167,9 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.logSize = 0
 	self.preimages = make(map[common.Hash][]byte)
 	self.clearJournalAndRefund()
+	if self.snaps != nil {
+		self.openSnapshot(root)
+	}
 	return nil
 }
 
@@ -174,6 +216,16 @@ func (self *StateDB) AddRefund(gas uint64) {
 	self.refund += gas
 }
 
+// SubRefund removes gas from the refund counter.
+// This method will panic if the refund counter goes below zero
+func (self *StateDB) SubRefund(gas uint64) {
+	self.journal.append(refundChange{prev: self.refund})
+	if gas > self.refund {
+		panic("Refund counter below zero")
+	}
+	self.refund -= gas
+}
+
 // Exist reports whether the given account address exists in the state.
 // Notably this also returns true for suicided accounts.
 func (self *StateDB) Exist(addr common.Address) bool {
@@ -205,6 +257,43 @@ func (self *StateDB) GetNonce(addr common.Address) uint64 {
 	return 0
 }
 
+// GetMultisig returns the signing keys and threshold of a multi-signature
+// account. A zero threshold is returned for regular accounts.
+func (self *StateDB) GetMultisig(addr common.Address) ([]types.MultisigKey, uint64) {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.Multisig()
+	}
+	return nil, 0
+}
+
+// GetVesting returns the vesting schedules locking part of the balance of addr.
+func (self *StateDB) GetVesting(addr common.Address) []types.VestingSchedule {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.Vesting()
+	}
+	return nil
+}
+
+// GetStake returns the balance addr staked for block resources.
+func (self *StateDB) GetStake(addr common.Address) *big.Int {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.Stake()
+	}
+	return common.Big0
+}
+
+// GetResources returns the block resources used by addr, before decay.
+func (self *StateDB) GetResources(addr common.Address) types.ResourceUsage {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.Resources()
+	}
+	return types.ResourceUsage{}
+}
+
 func (self *StateDB) GetCode(addr common.Address) []byte {
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
@@ -246,6 +335,15 @@ func (self *StateDB) GetState(addr common.Address, bhash common.Hash) common.Has
 	return common.Hash{}
 }
 
+// GetCommittedState retrieves a value from the given account's committed storage trie.
+func (self *StateDB) GetCommittedState(addr common.Address, hash common.Hash) common.Hash {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.GetCommittedState(self.db, hash)
+	}
+	return common.Hash{}
+}
+
 // Database retrieves the low level database supporting the lower level trie ops.
 func (self *StateDB) Database() Database {
 	return self.db
@@ -304,6 +402,40 @@ func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
 	}
 }
 
+// SetMultisig turns addr into a multi-signature account controlled by the
+// given keys, or updates the keys and threshold of an existing one.
+func (self *StateDB) SetMultisig(addr common.Address, keys []types.MultisigKey, threshold uint64) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetMultisig(keys, threshold)
+	}
+}
+
+// SetVesting replaces the vesting schedules of addr. The slice must not be
+// modified afterwards, as it is retained for reverting the change.
+func (self *StateDB) SetVesting(addr common.Address, schedules []types.VestingSchedule) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetVesting(schedules)
+	}
+}
+
+// SetStake sets the balance addr staked for block resources.
+func (self *StateDB) SetStake(addr common.Address, amount *big.Int) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetStake(amount)
+	}
+}
+
+// SetResources sets the block resources used by addr.
+func (self *StateDB) SetResources(addr common.Address, usage types.ResourceUsage) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetResources(usage)
+	}
+}
+
 func (self *StateDB) SetCode(addr common.Address, code []byte) {
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
@@ -318,6 +450,15 @@ func (self *StateDB) SetState(addr common.Address, key, value common.Hash) {
 	}
 }
 
+// SetStorage replaces the entire storage of addr with the given one. It can't be
+// reverted and is only meant for simulating calls against modified state.
+func (self *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common.Hash) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetStorage(self.db, storage)
+	}
+}
+
 // Suicide marks the given account as suicided.
 // This clears the account balance.
 //
@@ -351,6 +492,10 @@ func (self *StateDB) updateStateObject(stateObject *stateObject) {
 		panic(fmt.Errorf("can't encode object at %x: %v", addr[:], err))
 	}
 	self.setError(self.trie.TryUpdate(addr[:], data))
+
+	if self.snap != nil {
+		self.snapAccounts[stateObject.addrHash] = data
+	}
 }
 
 // deleteStateObject removes the given object from the state trie.
@@ -358,6 +503,12 @@ func (self *StateDB) deleteStateObject(stateObject *stateObject) {
 	stateObject.deleted = true
 	addr := stateObject.Address()
 	self.setError(self.trie.TryDelete(addr[:]))
+
+	if self.snap != nil {
+		self.snapDestructs[stateObject.addrHash] = struct{}{}
+		delete(self.snapAccounts, stateObject.addrHash)
+		delete(self.snapStorage, stateObject.addrHash)
+	}
 }
 
 // Retrieve a state object given by the address. Returns nil if not found.
@@ -370,8 +521,18 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 		return obj
 	}
 
-	// Load the object from the database.
-	enc, err := self.trie.TryGet(addr[:])
+	// Load the object from the snapshot if it covers the account, otherwise
+	// from the database.
+	var (
+		enc []byte
+		err error
+	)
+	if self.snap != nil {
+		enc, err = self.snap.Account(crypto.Keccak256Hash(addr[:]))
+	}
+	if self.snap == nil || err != nil {
+		enc, err = self.trie.TryGet(addr[:])
+	}
 	if len(enc) == 0 {
 		self.setError(err)
 		return nil
@@ -409,7 +570,13 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObjec
 	if prev == nil {
 		self.journal.append(createObjectChange{account: &addr})
 	} else {
-		self.journal.append(resetObjectChange{prev: prev})
+		// The storage of the overwritten account is gone
+		var prevdestruct bool
+		if self.snap != nil {
+			_, prevdestruct = self.snapDestructs[prev.addrHash]
+			self.snapDestructs[prev.addrHash] = struct{}{}
+		}
+		self.journal.append(resetObjectChange{prev: prev, prevdestruct: prevdestruct})
 	}
 	self.setStateObject(newobj)
 	return newobj, prev
@@ -438,18 +605,14 @@ func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common
 		return
 	}
 
-	// When iterating over the storage check the cache first
-	for h, value := range so.cachedStorage {
-		cb(h, value)
-	}
-
 	it := trie.NewIterator(so.getTrie(db.db).NodeIterator(nil))
 	for it.Next() {
-		// ignore cached values
 		key := common.BytesToHash(db.trie.GetKey(it.Key))
-		if _, ok := so.cachedStorage[key]; !ok {
-			cb(key, common.BytesToHash(it.Value))
+		if value, dirty := so.dirtyStorage[key]; dirty {
+			cb(key, value)
+			continue
 		}
+		cb(key, common.BytesToHash(it.Value))
 	}
 }
 
@@ -499,6 +662,25 @@ func (self *StateDB) Copy() *StateDB {
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
+	if self.snap != nil {
+		state.snaps = self.snaps
+		state.snap = self.snap
+		state.snapDestructs = make(map[common.Hash]struct{}, len(self.snapDestructs))
+		for hash := range self.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte, len(self.snapAccounts))
+		for hash, data := range self.snapAccounts {
+			state.snapAccounts[hash] = data
+		}
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte, len(self.snapStorage))
+		for hash, slots := range self.snapStorage {
+			state.snapStorage[hash] = make(map[common.Hash][]byte, len(slots))
+			for key, data := range slots {
+				state.snapStorage[hash][key] = data
+			}
+		}
+	}
 	return state
 }
 
@@ -626,5 +808,18 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 		return nil
 	})
 	log.Debug("Trie cache stats after commit", "misses", trie.CacheMisses(), "unloads", trie.CacheUnloads())
+
+	// Add the changes as a new layer on top of the snapshot of the parent state
+	if err == nil && s.snap != nil {
+		if parent := s.snap.Root(); parent != root {
+			if err := s.snaps.Update(root, parent, s.snapDestructs, s.snapAccounts, s.snapStorage); err != nil {
+				log.Warn("Failed to update state snapshot", "from", parent, "to", root, "err", err)
+			}
+			if err := s.snaps.Cap(root, snapshotLayers); err != nil {
+				log.Warn("Failed to cap state snapshot", "root", root, "layers", snapshotLayers, "err", err)
+			}
+		}
+		s.snap, s.snapDestructs, s.snapAccounts, s.snapStorage = nil, nil, nil, nil
+	}
 	return root, err
 }
@@ -31,6 +31,7 @@ import (
 	check "gopkg.in/check.v1"
 
 	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/state/snapshot"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/yoobadb"
 )
@@ -435,3 +436,52 @@ func TestCopyOfCopy(t *testing.T) {
 		t.Fatalf("2nd copy fail, expected 42, got %v", got)
 	}
 }
+
+// Tests that replacing the storage of an account hides all previous slots.
+func TestSetStorage(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(yoobadb.NewMemDatabase()))
+	addr := common.HexToAddress("0x01")
+	a, b := common.HexToHash("0x0a"), common.HexToHash("0x0b")
+
+	state.SetState(addr, a, common.HexToHash("0x01"))
+	state.SetStorage(addr, map[common.Hash]common.Hash{b: common.HexToHash("0x02")})
+
+	if value := state.GetState(addr, a); value != (common.Hash{}) {
+		t.Errorf("replaced slot still visible: %x", value)
+	}
+	if value := state.GetState(addr, b); value != common.HexToHash("0x02") {
+		t.Errorf("new slot mismatch: have %x, want %x", value, common.HexToHash("0x02"))
+	}
+}
+
+// Tests that replacing the storage of an account also hides the slots the state
+// snapshot holds for it.
+func TestSetStorageWithSnapshot(t *testing.T) {
+	var (
+		diskdb = yoobadb.NewMemDatabase()
+		db     = NewDatabase(diskdb)
+		addr   = common.HexToAddress("0x01")
+		a, b   = common.HexToHash("0x0a"), common.HexToHash("0x0b")
+	)
+	// Commit the slot into a diff layer of the snapshot, so reads are served by it
+	snaps := snapshot.New(diskdb, db.TrieDB(), types.EmptyRootHash)
+	state, _ := NewWithSnapshot(types.EmptyRootHash, db, snaps)
+	state.SetState(addr, a, common.HexToHash("0x01"))
+	root, _ := state.Commit(false)
+
+	state, _ = NewWithSnapshot(root, db, snaps)
+	if state.snap == nil {
+		t.Fatalf("state not backed by snapshot")
+	}
+	state.SetStorage(addr, map[common.Hash]common.Hash{b: common.HexToHash("0x02")})
+
+	if value := state.GetCommittedState(addr, a); value != (common.Hash{}) {
+		t.Errorf("replaced committed slot still visible: %x", value)
+	}
+	if value := state.GetState(addr, a); value != (common.Hash{}) {
+		t.Errorf("replaced slot still visible: %x", value)
+	}
+	if value := state.GetState(addr, b); value != common.HexToHash("0x02") {
+		t.Errorf("new slot mismatch: have %x, want %x", value, common.HexToHash("0x02"))
+	}
+}
@@ -22,13 +22,19 @@ import (
 	"math/big"
 
 	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/consensus/dpos"
+	"github.com/yooba-team/yooba/core/governance"
+	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/core/vm"
 	"github.com/yooba-team/yooba/log"
 	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/rlp"
 )
 
 var (
 	errInsufficientBalanceForGas = errors.New("insufficient balance to pay for gas")
+	errMultisigSetupRecipient    = errors.New("multi-signature setup not sent to the sender itself")
+	errMultisigSetupValue        = errors.New("multi-signature setup can't transfer value")
 )
 
 /*
@@ -58,6 +64,7 @@ type StateTransition struct {
 	data       []byte
 	state      vm.StateDB
 	evm        *vm.EVM
+	free       bool // Whether the message is paid from the resource quota of its payer
 }
 
 // Message represents a message sent to a contract.
@@ -73,6 +80,15 @@ type Message interface {
 	Nonce() uint64
 	CheckNonce() bool
 	Data() []byte
+	Type() uint
+
+	// FeePayer returns the account paying for the gas of a sponsored message,
+	// or nil if it is paid by the sender.
+	FeePayer() *common.Address
+
+	// MultisigSigners returns the public keys that signed a message sent from
+	// a multi-signature account, or nil if it was signed by a single key.
+	MultisigSigners() [][]byte
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
@@ -149,9 +165,17 @@ func (st *StateTransition) useGas(amount uint64) error {
 	return nil
 }
 
+// payer returns the account charged for the gas of the message.
+func (st *StateTransition) payer() common.Address {
+	if payer := st.msg.FeePayer(); payer != nil {
+		return *payer
+	}
+	return st.msg.From()
+}
+
 func (st *StateTransition) buyGas() error {
 	mgval := new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.gasPrice)
-	if st.state.GetBalance(st.msg.From()).Cmp(mgval) < 0 {
+	if st.state.GetBalance(st.payer()).Cmp(mgval) < 0 {
 		return errInsufficientBalanceForGas
 	}
 	if err := st.gp.SubGas(st.msg.Gas()); err != nil {
@@ -160,7 +184,7 @@ func (st *StateTransition) buyGas() error {
 	st.gas += st.msg.Gas()
 
 	st.initialGas = st.msg.Gas()
-	st.state.SubBalance(st.msg.From(), mgval)
+	st.state.SubBalance(st.payer(), mgval)
 	return nil
 }
 
@@ -173,10 +197,37 @@ func (st *StateTransition) preCheck() error {
 		} else if nonce > st.msg.Nonce() {
 			return ErrNonceTooLow
 		}
+		// Make sure the transaction is authorized by the keys controlling the
+		// sender. Calls are never signed, hence only checked for transactions.
+		if err := CheckMultisig(st.state, st.msg.From(), st.msg.MultisigSigners()); err != nil {
+			return err
+		}
+	}
+	if err := st.checkResources(); err != nil {
+		return err
+	}
+	if err := st.checkSpendable(); err != nil {
+		return err
 	}
 	return st.buyGas()
 }
 
+// CheckMultisig verifies that a transaction from the given sender, signed by the
+// given multi-signature signers (nil for single key transactions), is authorized
+// by the keys controlling the sender.
+func CheckMultisig(db vm.StateDB, from common.Address, signers [][]byte) error {
+	keys, threshold := db.GetMultisig(from)
+	switch {
+	case threshold == 0 && signers != nil:
+		return ErrNotMultisigAccount
+	case threshold != 0 && signers == nil:
+		return ErrMultisigRequired
+	case threshold != 0 && types.MultisigWeight(keys, signers) < threshold:
+		return ErrMultisigThreshold
+	}
+	return nil
+}
+
 // TransitionDb will transition the state by applying the current message and
 // returning the result including the the used gas. It returns an error if it
 // failed. An error indicates a consensus issue.
@@ -205,9 +256,38 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		// error.
 		vmerr error
 	)
-	if contractCreation {
+	switch {
+	case msg.Type() == types.TxTypeProposal || msg.Type() == types.TxTypeProposalVote:
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyGovernance()
+	case msg.Type() == types.TxTypeWitness || msg.Type() == types.TxTypeVote:
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyProducerRegistry()
+	case msg.Type() == types.TxTypeMultisigSetup:
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyMultisigSetup()
+	case msg.Type() == types.TxTypeVestingGrant:
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyVestingGrant()
+	case msg.Type() == types.TxTypeVestingRevoke:
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyVestingRevoke()
+	case msg.Type() == types.TxTypeResourceStake:
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyResourceStake()
+	case msg.Type() == types.TxTypeResourceUnstake:
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyResourceUnstake()
+	case contractCreation:
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
-	} else {
+	default:
 		// Increment the nonce for the next transaction
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
 		ret, st.gas, vmerr = evm.Call(sender, st.to(), st.data, st.gas, st.value)
@@ -222,11 +302,90 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		}
 	}
 	st.refundGas()
+	if st.free {
+		st.useResources()
+	}
 	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 
 	return ret, st.gasUsed(), vmerr != nil, err
 }
 
+// applyGovernance executes a governance proposal or vote message against the
+// governance state. Failures are treated like EVM errors: the transaction is
+// included, but has no effect besides the gas spent.
+func (st *StateTransition) applyGovernance() error {
+	if st.to() != params.GovernanceAddress {
+		return governance.ErrWrongRecipient
+	}
+	number := st.evm.BlockNumber
+	switch st.msg.Type() {
+	case types.TxTypeProposal:
+		data := new(governance.ProposalData)
+		if err := rlp.DecodeBytes(st.data, data); err != nil {
+			return err
+		}
+		_, err := governance.Propose(st.state, st.msg.From(), number, st.value, data)
+		return err
+	default:
+		if st.value.Sign() != 0 {
+			return governance.ErrUnexpectedVoteValue
+		}
+		data := new(governance.VoteData)
+		if err := rlp.DecodeBytes(st.data, data); err != nil {
+			return err
+		}
+		producers := dpos.ActiveProducers(st.state, st.evm.ChainConfig())
+		return governance.Vote(st.state, st.msg.From(), number, producers, data)
+	}
+}
+
+// applyProducerRegistry executes a producer registration or vote message against
+// the producer registry. Like governance messages, invalid ones are included
+// without any effect besides the gas spent.
+func (st *StateTransition) applyProducerRegistry() error {
+	if st.to() != params.ProducerRegistryAddress {
+		return dpos.ErrWrongRegistry
+	}
+	switch st.msg.Type() {
+	case types.TxTypeWitness:
+		if st.value.Sign() != 0 {
+			return dpos.ErrUnexpectedValue
+		}
+		data := new(dpos.RegisterData)
+		if err := rlp.DecodeBytes(st.data, data); err != nil {
+			return err
+		}
+		return dpos.Register(st.state, st.msg.From(), data)
+	default:
+		data := new(dpos.VoteData)
+		if err := rlp.DecodeBytes(st.data, data); err != nil {
+			return err
+		}
+		return dpos.CastVote(st.state, st.msg.From(), st.evm.Time, st.value, data)
+	}
+}
+
+// applyMultisigSetup replaces the signing keys and threshold of the sender. Like
+// governance messages, invalid setups are included without any effect besides
+// the gas spent.
+func (st *StateTransition) applyMultisigSetup() error {
+	if st.to() != st.msg.From() {
+		return errMultisigSetupRecipient
+	}
+	if st.value.Sign() != 0 {
+		return errMultisigSetupValue
+	}
+	setup := new(types.MultisigSetup)
+	if err := rlp.DecodeBytes(st.data, setup); err != nil {
+		return err
+	}
+	if err := setup.Validate(); err != nil {
+		return err
+	}
+	st.state.SetMultisig(st.msg.From(), setup.Keys, setup.Threshold)
+	return nil
+}
+
 func (st *StateTransition) refundGas() {
 	// Apply refund counter, capped to half of the used gas.
 	refund := st.gasUsed() / 2
@@ -237,7 +396,7 @@ func (st *StateTransition) refundGas() {
 
 	// Return YOO for remaining gas, exchanged at the original rate.
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
-	st.state.AddBalance(st.msg.From(), remaining)
+	st.state.AddBalance(st.payer(), remaining)
 
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
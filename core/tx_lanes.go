@@ -0,0 +1,211 @@
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/log"
+)
+
+// DefaultTxLane is the name of the lane shared by all transaction types without
+// a dedicated lane configured.
+const DefaultTxLane = "default"
+
+// txTypeNames are the human readable names of the native transaction types, also
+// used to name their lanes.
+var txTypeNames = map[uint]string{
+	types.TxTypeTransfer:      "transfer",
+	types.TxTypeGoods:         "goods",
+	types.TxTypeVote:          "vote",
+	types.TxTypeContract:      "contract",
+	types.TxTypeWitness:       "witness",
+	types.TxTypeProposal:      "proposal",
+	types.TxTypeProposalVote:  "proposalvote",
+	types.TxTypeMultisigSetup: "multisig",
+	types.TxTypeVestingGrant:  "vestinggrant",
+	types.TxTypeVestingRevoke: "vestingrevoke",
+
+	types.TxTypeResourceStake:   "resourcestake",
+	types.TxTypeResourceUnstake: "resourceunstake",
+}
+
+// TxTypeName returns the human readable name of a native transaction type, and
+// whether the type is known at all.
+func TxTypeName(typ uint) (string, bool) {
+	name, ok := txTypeNames[typ]
+	return name, ok
+}
+
+// TxLaneConfig are the configuration parameters of a transaction pool lane, a
+// sub-pool dedicated to a single transaction type. Transactions in a lane only
+// compete for slots with each other, so a flood of other transactions can't
+// evict them from the pool.
+type TxLaneConfig struct {
+	Type uint // Transaction type the lane is dedicated to
+
+	Slots      uint64        // Maximum number of transaction slots (executable and not) in the lane
+	PriceLimit uint64        // Minimum gas price to enforce for acceptance into the lane (0 = pool minimum)
+	Lifetime   time.Duration // Maximum amount of time non-executable transactions are queued (0 = pool lifetime)
+
+	Reserve uint64 // Percentage of the block gas limit reserved by the miner for the lane
+}
+
+// DefaultTxLanes are the lanes of the consensus critical and settlement
+// transactions, keeping them from being starved by spam transfers.
+var DefaultTxLanes = []TxLaneConfig{
+	{Type: types.TxTypeVote, Slots: 1024, PriceLimit: 1, Reserve: 10},
+	{Type: types.TxTypeProposalVote, Slots: 512, PriceLimit: 1, Reserve: 5},
+	{Type: types.TxTypeWitness, Slots: 256, PriceLimit: 1, Reserve: 5},
+	{Type: types.TxTypeGoods, Slots: 2048},
+}
+
+// txLane is a dedicated sub-pool of a single transaction type.
+type txLane struct {
+	config TxLaneConfig
+	name   string
+	priced *txPricedList // All transactions of the lane sorted by price
+}
+
+// sanitizeLanes checks the configured lanes, dropping the unworkable ones.
+func sanitizeLanes(lanes []TxLaneConfig) []TxLaneConfig {
+	var (
+		sane    []TxLaneConfig
+		seen    = make(map[uint]bool)
+		reserve uint64
+	)
+	for _, lane := range lanes {
+		if _, ok := txTypeNames[lane.Type]; !ok {
+			log.Warn("Dropping txpool lane of unknown transaction type", "type", lane.Type)
+			continue
+		}
+		if seen[lane.Type] {
+			log.Warn("Dropping duplicate txpool lane", "lane", txTypeNames[lane.Type])
+			continue
+		}
+		if lane.Slots == 0 {
+			log.Warn("Dropping txpool lane without slots", "lane", txTypeNames[lane.Type])
+			continue
+		}
+		if reserve+lane.Reserve > 100 {
+			log.Warn("Sanitizing invalid txpool lane reserve", "lane", txTypeNames[lane.Type], "provided", lane.Reserve, "updated", 100-reserve)
+			lane.Reserve = 100 - reserve
+		}
+		reserve += lane.Reserve
+		seen[lane.Type] = true
+		sane = append(sane, lane)
+	}
+	return sane
+}
+
+// lane returns the dedicated lane of a transaction, or nil if it belongs to the
+// default lane.
+func (pool *TxPool) lane(tx *types.Transaction) *txLane {
+	return pool.lanes[tx.Type()]
+}
+
+// pricedList returns the price-sorted heap of the lane of a transaction.
+func (pool *TxPool) pricedList(tx *types.Transaction) *txPricedList {
+	if lane := pool.lane(tx); lane != nil {
+		return lane.priced
+	}
+	return pool.priced
+}
+
+// priceLimit returns the minimum gas price accepted in the lane of a transaction.
+func (pool *TxPool) priceLimit(tx *types.Transaction) *big.Int {
+	if lane := pool.lane(tx); lane != nil && lane.config.PriceLimit > 0 {
+		return new(big.Int).SetUint64(lane.config.PriceLimit)
+	}
+	return pool.gasPrice
+}
+
+// lifetime returns the maximum amount of time a non-executable transaction of
+// the lane of the given transaction is queued.
+func (pool *TxPool) lifetime(tx *types.Transaction) time.Duration {
+	if lane := pool.lane(tx); lane != nil && lane.config.Lifetime > 0 {
+		return lane.config.Lifetime
+	}
+	return pool.config.Lifetime
+}
+
+// laneUsage returns the number of transactions in the lane of a transaction and
+// the number of slots available to the lane.
+func (pool *TxPool) laneUsage(tx *types.Transaction) (int, int) {
+	if lane := pool.lane(tx); lane != nil {
+		return pool.all.CountType(tx.Type()), int(lane.config.Slots)
+	}
+	count := pool.all.Count()
+	for typ := range pool.lanes {
+		count -= pool.all.CountType(typ)
+	}
+	return count, int(pool.config.GlobalSlots + pool.config.GlobalQueue)
+}
+
+// Lane returns the name of the lane a transaction belongs to.
+func (pool *TxPool) Lane(tx *types.Transaction) string {
+	if lane := pool.lane(tx); lane != nil {
+		return lane.name
+	}
+	return DefaultTxLane
+}
+
+// Reservations returns the percentage of the block gas limit the miner should
+// reserve for each transaction type with a dedicated lane.
+func (pool *TxPool) Reservations() map[uint]uint64 {
+	reserves := make(map[uint]uint64)
+	for typ, lane := range pool.lanes {
+		if lane.config.Reserve > 0 {
+			reserves[typ] = lane.config.Reserve
+		}
+	}
+	return reserves
+}
+
+// laneLen returns the number of transactions of a list belonging to dedicated
+// lanes, which don't count against the global slots.
+func (pool *TxPool) laneLen(list *txList) int {
+	if len(pool.lanes) == 0 {
+		return 0
+	}
+	count := 0
+	for _, tx := range list.txs.items {
+		if pool.lane(tx) != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// defaultLen returns the number of pending transactions of an account belonging
+// to the default lane.
+func (pool *TxPool) defaultLen(addr common.Address) int {
+	list := pool.pending[addr]
+	return list.Len() - pool.laneLen(list)
+}
+
+// capPending drops the highest nonce pending transaction of an account to
+// enforce the fairness cap of the default lane, reporting whether it did. Lane
+// transactions are exempt from the cap, so an account whose last pending
+// transaction belongs to a lane isn't reduced any further. The lane slots and
+// the default lane's share of the pool still bound such accounts.
+func (pool *TxPool) capPending(addr common.Address) bool {
+	list := pool.pending[addr]
+	if txs := list.Flatten(); len(txs) == 0 || pool.lane(txs[len(txs)-1]) != nil {
+		return false
+	}
+	for _, tx := range list.Cap(list.Len() - 1) {
+		// Drop the transaction from the global pools too
+		hash := tx.Hash()
+		pool.all.Remove(hash)
+		pool.priced.Removed()
+
+		// Update the account nonce to the dropped transaction
+		if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
+			pool.pendingState.SetNonce(addr, nonce)
+		}
+		log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+	}
+	return true
+}
@@ -397,16 +397,19 @@ func (h *priceHeap) Pop() interface{} {
 // txPricedList is a price-sorted heap to allow operating on transactions pool
 // contents in a price-incrementing way.
 type txPricedList struct {
-	all    *txLookup  // Pointer to the map of all transactions
-	items  *priceHeap // Heap of prices of all the stored transactions
-	stales int        // Number of stale price points to (re-heap trigger)
+	all    *txLookup                     // Pointer to the map of all transactions
+	filter func(*types.Transaction) bool // Filter of the transactions tracked by the list
+	items  *priceHeap                    // Heap of prices of all the stored transactions
+	stales int                           // Number of stale price points to (re-heap trigger)
 }
 
-// newTxPricedList creates a new price-sorted transaction heap.
-func newTxPricedList(all *txLookup) *txPricedList {
+// newTxPricedList creates a new price-sorted transaction heap, tracking the
+// transactions matching the filter (or all if nil).
+func newTxPricedList(all *txLookup, filter func(*types.Transaction) bool) *txPricedList {
 	return &txPricedList{
-		all:   all,
-		items: new(priceHeap),
+		all:    all,
+		filter: filter,
+		items:  new(priceHeap),
 	}
 }
 
@@ -429,7 +432,9 @@ func (l *txPricedList) Removed() {
 
 	l.stales, l.items = 0, &reheap
 	l.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
-		*l.items = append(*l.items, tx)
+		if l.filter == nil || l.filter(tx) {
+			*l.items = append(*l.items, tx)
+		}
 		return true
 	})
 	heap.Init(l.items)
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/governance"
 	"github.com/yooba-team/yooba/core/state"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/event"
@@ -60,6 +61,18 @@ var (
 	// is higher than the balance of the user's account.
 	ErrInsufficientFunds = errors.New("insufficient funds for gas * price + value")
 
+	// ErrInvalidFeePayer is returned if the fee payer signature of a sponsored
+	// transaction is invalid or was not made by the declared fee payer.
+	ErrInvalidFeePayer = errors.New("invalid fee payer")
+
+	// ErrFeePayerIsSender is returned if a sponsored transaction names its own
+	// sender as the fee payer.
+	ErrFeePayerIsSender = errors.New("fee payer is the sender")
+
+	// ErrInsufficientFeePayerFunds is returned if the fee payer of a sponsored
+	// transaction can't cover its gas * price.
+	ErrInsufficientFeePayerFunds = errors.New("insufficient fee payer funds for gas * price")
+
 	// ErrIntrinsicGas is returned if the transaction is specified to use less gas
 	// than required to start the invocation.
 	ErrIntrinsicGas = errors.New("intrinsic gas too low")
@@ -136,6 +149,8 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Lanes []TxLaneConfig // Dedicated sub-pools of transaction types, outside of the global slots
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -153,6 +168,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	Lanes: DefaultTxLanes,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -171,6 +188,7 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	conf.Lanes = sanitizeLanes(conf.Lanes)
 	return conf
 }
 
@@ -186,6 +204,7 @@ type TxPool struct {
 	chainconfig  *params.ChainConfig
 	chain        blockChain
 	gasPrice     *big.Int
+	govGasPrice  *big.Int // Price floor set by on-chain governance
 	txFeed       event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
@@ -196,6 +215,8 @@ type TxPool struct {
 	currentState  *state.StateDB      // Current state in the blockchain head
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas uint64              // Current gas limit for transaction caps
+	currentTime   uint64              // Current head timestamp for vesting locks
+	currentNumber uint64              // Current head number for resource usage decay
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -204,7 +225,8 @@ type TxPool struct {
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
-	priced  *txPricedList                // All transactions sorted by price
+	priced  *txPricedList                // All transactions of the default lane sorted by price
+	lanes   map[uint]*txLane             // Dedicated lanes of transaction types
 
 	wg sync.WaitGroup // for shutdown sync
 }
@@ -227,9 +249,19 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		all:         newTxLookup(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
+		govGasPrice: new(big.Int),
 	}
 	pool.locals = newAccountSet(pool.signer)
-	pool.priced = newTxPricedList(pool.all)
+	pool.lanes = make(map[uint]*txLane)
+	for _, config := range config.Lanes {
+		typ := config.Type
+		pool.lanes[typ] = &txLane{
+			config: config,
+			name:   txTypeNames[typ],
+			priced: newTxPricedList(pool.all, func(tx *types.Transaction) bool { return tx.Type() == typ }),
+		}
+	}
+	pool.priced = newTxPricedList(pool.all, func(tx *types.Transaction) bool { return pool.lanes[tx.Type()] == nil })
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// If local transactions and journaling is enabled, load from disk
@@ -310,9 +342,9 @@ func (pool *TxPool) loop() {
 				if pool.locals.contains(addr) {
 					continue
 				}
-				// Any non-locals old enough should be removed
-				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
-					for _, tx := range pool.queue[addr].Flatten() {
+				// Any non-locals old enough for their lane should be removed
+				for _, tx := range pool.queue[addr].Flatten() {
+					if time.Since(pool.beats[addr]) > pool.lifetime(tx) {
 						pool.removeTx(tx.Hash(), true)
 					}
 				}
@@ -403,6 +435,9 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.currentState = statedb
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
+	pool.currentTime = newHead.Time.Uint64()
+	pool.currentNumber = newHead.Number.Uint64()
+	pool.govGasPrice = governance.Value(statedb, governance.MinGasPrice, new(big.Int).Add(newHead.Number, common.Big1))
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
@@ -464,6 +499,14 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	for _, tx := range pool.priced.Cap(price, pool.locals) {
 		pool.removeTx(tx.Hash(), false)
 	}
+	// Lanes with their own price limit are not affected by the pool's one
+	for _, lane := range pool.lanes {
+		if lane.config.PriceLimit == 0 {
+			for _, tx := range lane.priced.Cap(price, pool.locals) {
+				pool.removeTx(tx.Hash(), false)
+			}
+		}
+	}
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
@@ -475,6 +518,58 @@ func (pool *TxPool) State() *state.ManagedState {
 	return pool.pendingState
 }
 
+// ResourceQuota returns the resource quota of addr and its usage as of the next
+// block, or nil if the resource fee mode is not enabled.
+func (pool *TxPool) ResourceQuota(addr common.Address) *Resources {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.resourceQuota(addr)
+}
+
+// resourceQuota returns the resource quota of addr and its usage as of the next
+// block, or nil if the resource fee mode is not enabled.
+func (pool *TxPool) resourceQuota(addr common.Address) *Resources {
+	config := pool.chainconfig.Resource
+	if config == nil {
+		return nil
+	}
+	return AccountResources(config, pool.currentState, addr, pool.currentNumber+1)
+}
+
+// pendingUsage returns the gas and bandwidth the pending transactions of an
+// account will charge to its own resource quota, apart from the one a new
+// transaction of the given sender and nonce replaces.
+func (pool *TxPool) pendingUsage(addr common.Address, sender common.Address, nonce uint64) (uint64, uint64) {
+	list := pool.pending[addr]
+	if list == nil {
+		return 0, 0
+	}
+	var gas, bandwidth uint64
+	for _, tx := range list.txs.items {
+		if addr == sender && tx.Nonce() == nonce || tx.FeePayer() != nil {
+			continue
+		}
+		gas += tx.Gas()
+		bandwidth += TxBandwidth(tx.Data())
+	}
+	return gas, bandwidth
+}
+
+// replaced returns the pending or queued transaction of a sender a new one with
+// the given nonce replaces, or nil if there is none.
+func (pool *TxPool) replaced(sender common.Address, nonce uint64) *types.Transaction {
+	if list := pool.pending[sender]; list != nil {
+		if tx := list.txs.Get(nonce); tx != nil {
+			return tx
+		}
+	}
+	if list := pool.queue[sender]; list != nil {
+		return list.txs.Get(nonce)
+	}
+	return nil
+}
+
 // Stats retrieves the current pool stats, namely the number of pending and the
 // number of queued (non-executable) transactions.
 func (pool *TxPool) Stats() (int, int) {
@@ -566,20 +661,67 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return ErrInvalidSender
 	}
-	// Drop non-local transactions under our own minimal accepted gas price
+	// Transactions within the resource quota of their payer are free, exempt
+	// them from the price floors
+	payer := from
+	if tx.FeePayer() != nil {
+		payer = *tx.FeePayer()
+	}
+	free := false
+	if res := pool.resourceQuota(payer); res != nil {
+		gas, bandwidth := pool.pendingUsage(payer, from, tx.Nonce())
+		free = res.Covers(gas+tx.Gas(), bandwidth+TxBandwidth(tx.Data()))
+	}
+	// Drop non-local transactions under our own minimal accepted gas price of the lane
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
-	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
+	if !local && !free && pool.priceLimit(tx).Cmp(tx.GasPrice()) > 0 {
+		return ErrUnderpriced
+	}
+	// Drop all transactions under the price floor set by governance
+	if !free && pool.govGasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
 	if pool.currentState.GetNonce(from) > tx.Nonce() {
 		return ErrNonceTooLow
 	}
+	// Ensure the transaction is authorized by the keys controlling the sender
+	var signers [][]byte
+	if tx.MultisigSender() != nil {
+		if signers, err = types.MultisigSigners(pool.signer, tx); err != nil {
+			return ErrInvalidSender
+		}
+	}
+	if err := CheckMultisig(pool.currentState, from, signers); err != nil {
+		return err
+	}
 	// Transactor should have enough funds to cover the costs
-	// cost == V + GP * GL
-	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
+	// cost == V + GP * GL, or only V if the gas is sponsored. Balance locked
+	// by vesting schedules can't be spent.
+	if SpendableBalance(pool.currentState, from, pool.currentTime).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
+	// Sponsored transactions need a valid co-signature of a fee payer able to
+	// cover the gas. The fee payer's nonce is left untouched, the signature is
+	// bound to the sender's nonce instead.
+	if tx.FeePayer() != nil {
+		payer, err := types.FeePayerSender(pool.signer, tx)
+		if err != nil {
+			return ErrInvalidFeePayer
+		}
+		if payer == from {
+			return ErrFeePayerIsSender
+		}
+		// The gas of all sponsored transactions in the pool is committed, apart
+		// from the one this transaction replaces
+		cost := pool.all.Sponsored(payer)
+		if old := pool.replaced(from, tx.Nonce()); old != nil && old.FeePayer() != nil && *old.FeePayer() == payer {
+			cost.Sub(cost, old.GasCost())
+		}
+		if SpendableBalance(pool.currentState, payer, pool.currentTime).Cmp(cost.Add(cost, tx.GasCost())) < 0 {
+			return ErrInsufficientFeePayerFunds
+		}
+	}
 	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil)
 	if err != nil {
 		return err
@@ -611,16 +753,17 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		invalidTxCounter.Inc(1)
 		return false, err
 	}
-	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+	// If the transaction's lane is full, discard underpriced transactions of the lane
+	if count, slots := pool.laneUsage(tx); count >= slots {
 		// If the new transaction is underpriced, don't accept it
-		if !local && pool.priced.Underpriced(tx, pool.locals) {
+		priced := pool.pricedList(tx)
+		if !local && priced.Underpriced(tx, pool.locals) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(pool.all.Count()-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
+		drop := priced.Discard(count-slots+1, pool.locals)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
@@ -639,11 +782,11 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		// New transaction is better, replace old one
 		if old != nil {
 			pool.all.Remove(old.Hash())
-			pool.priced.Removed()
+			pool.pricedList(old).Removed()
 			pendingReplaceCounter.Inc(1)
 		}
 		pool.all.Add(tx)
-		pool.priced.Put(tx)
+		pool.pricedList(tx).Put(tx)
 		pool.journalTx(from, tx)
 
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
@@ -686,12 +829,12 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	// Discard any previous transaction and mark this
 	if old != nil {
 		pool.all.Remove(old.Hash())
-		pool.priced.Removed()
+		pool.pricedList(old).Removed()
 		queuedReplaceCounter.Inc(1)
 	}
 	if pool.all.Get(hash) == nil {
 		pool.all.Add(tx)
-		pool.priced.Put(tx)
+		pool.pricedList(tx).Put(tx)
 	}
 	return old != nil, nil
 }
@@ -722,7 +865,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if !inserted {
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
-		pool.priced.Removed()
+		pool.pricedList(tx).Removed()
 
 		pendingDiscardCounter.Inc(1)
 		return false
@@ -730,14 +873,14 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
 		pool.all.Remove(old.Hash())
-		pool.priced.Removed()
+		pool.pricedList(old).Removed()
 
 		pendingReplaceCounter.Inc(1)
 	}
 	// Failsafe to work around direct pending inserts (tests)
 	if pool.all.Get(hash) == nil {
 		pool.all.Add(tx)
-		pool.priced.Put(tx)
+		pool.pricedList(tx).Put(tx)
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
@@ -864,7 +1007,7 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 	// Remove it from the list of known transactions
 	pool.all.Remove(hash)
 	if outofbound {
-		pool.priced.Removed()
+		pool.pricedList(tx).Removed()
 	}
 	// Remove the transaction from the pending lists and reset the account nonce
 	if pending := pool.pending[addr]; pending != nil {
@@ -919,15 +1062,15 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			hash := tx.Hash()
 			log.Trace("Removed old queued transaction", "hash", hash)
 			pool.all.Remove(hash)
-			pool.priced.Removed()
+			pool.pricedList(tx).Removed()
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
-		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
+		drops, _ := list.Filter(SpendableBalance(pool.currentState, addr, pool.currentTime), pool.currentMaxGas)
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
 			pool.all.Remove(hash)
-			pool.priced.Removed()
+			pool.pricedList(tx).Removed()
 			queuedNofundsCounter.Inc(1)
 		}
 		// Gather all executable transactions and promote them
@@ -943,7 +1086,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
-				pool.priced.Removed()
+				pool.pricedList(tx).Removed()
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
@@ -957,10 +1100,11 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 	if len(promoted) > 0 {
 		go pool.txFeed.Send(NewTxsEvent{promoted})
 	}
-	// If the pending limit is overflown, start equalizing allowances
+	// If the pending limit of the default lane is overflown, start equalizing
+	// allowances. Only default lane transactions count and are evicted.
 	pending := uint64(0)
 	for _, list := range pool.pending {
-		pending += uint64(list.Len())
+		pending += uint64(list.Len() - pool.laneLen(list))
 	}
 	if pending > pool.config.GlobalSlots {
 		pendingBeforeCap := pending
@@ -968,8 +1112,8 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		spammers := prque.New()
 		for addr, list := range pool.pending {
 			// Only evict transactions from high rollers
-			if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
-				spammers.Push(addr, float32(list.Len()))
+			if n := list.Len() - pool.laneLen(list); !pool.locals.contains(addr) && uint64(n) > pool.config.AccountSlots {
+				spammers.Push(addr, float32(n))
 			}
 		}
 		// Gradually drop transactions from offenders
@@ -982,56 +1126,44 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			// Equalize balances until all the same or below threshold
 			if len(offenders) > 1 {
 				// Calculate the equalization threshold for all current offenders
-				threshold := pool.pending[offender.(common.Address)].Len()
+				threshold := pool.defaultLen(offender.(common.Address))
 
 				// Iteratively reduce all offenders until below limit or threshold reached
-				for pending > pool.config.GlobalSlots && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
+				for pending > pool.config.GlobalSlots && pool.defaultLen(offenders[len(offenders)-2]) > threshold {
+					capped := false
 					for i := 0; i < len(offenders)-1; i++ {
-						list := pool.pending[offenders[i]]
-						for _, tx := range list.Cap(list.Len() - 1) {
-							// Drop the transaction from the global pools too
-							hash := tx.Hash()
-							pool.all.Remove(hash)
-							pool.priced.Removed()
-
-							// Update the account nonce to the dropped transaction
-							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i]) > nonce {
-								pool.pendingState.SetNonce(offenders[i], nonce)
-							}
-							log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+						if pool.defaultLen(offenders[i]) > threshold && pool.capPending(offenders[i]) {
+							capped = true
+							pending--
 						}
-						pending--
+					}
+					if !capped {
+						break
 					}
 				}
 			}
 		}
 		// If still above threshold, reduce to limit or min allowance
 		if pending > pool.config.GlobalSlots && len(offenders) > 0 {
-			for pending > pool.config.GlobalSlots && uint64(pool.pending[offenders[len(offenders)-1]].Len()) > pool.config.AccountSlots {
+			for pending > pool.config.GlobalSlots && uint64(pool.defaultLen(offenders[len(offenders)-1])) > pool.config.AccountSlots {
+				capped := false
 				for _, addr := range offenders {
-					list := pool.pending[addr]
-					for _, tx := range list.Cap(list.Len() - 1) {
-						// Drop the transaction from the global pools too
-						hash := tx.Hash()
-						pool.all.Remove(hash)
-						pool.priced.Removed()
-
-						// Update the account nonce to the dropped transaction
-						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
-							pool.pendingState.SetNonce(addr, nonce)
-						}
-						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+					if uint64(pool.defaultLen(addr)) > pool.config.AccountSlots && pool.capPending(addr) {
+						capped = true
+						pending--
 					}
-					pending--
+				}
+				if !capped {
+					break
 				}
 			}
 		}
 		pendingRateLimitCounter.Inc(int64(pendingBeforeCap - pending))
 	}
-	// If we've queued more transactions than the hard limit, drop oldest ones
+	// If we've queued more default lane transactions than the hard limit, drop oldest ones
 	queued := uint64(0)
 	for _, list := range pool.queue {
-		queued += uint64(list.Len())
+		queued += uint64(list.Len() - pool.laneLen(list))
 	}
 	if queued > pool.config.GlobalQueue {
 		// Sort all accounts with queued transactions by heartbeat
@@ -1051,9 +1183,11 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			addresses = addresses[:len(addresses)-1]
 
 			// Drop all transactions if they are less than the overflow
-			if size := uint64(list.Len()); size <= drop {
+			if size := uint64(list.Len() - pool.laneLen(list)); size <= drop {
 				for _, tx := range list.Flatten() {
-					pool.removeTx(tx.Hash(), true)
+					if pool.lane(tx) == nil {
+						pool.removeTx(tx.Hash(), true)
+					}
 				}
 				drop -= size
 				queuedRateLimitCounter.Inc(int64(size))
@@ -1062,6 +1196,9 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			// Otherwise drop only last few transactions
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+				if pool.lane(txs[i]) != nil {
+					continue
+				}
 				pool.removeTx(txs[i].Hash(), true)
 				drop--
 				queuedRateLimitCounter.Inc(1)
@@ -1083,15 +1220,15 @@ func (pool *TxPool) demoteUnexecutables() {
 			hash := tx.Hash()
 			log.Trace("Removed old pending transaction", "hash", hash)
 			pool.all.Remove(hash)
-			pool.priced.Removed()
+			pool.pricedList(tx).Removed()
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
-		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
+		drops, invalids := list.Filter(SpendableBalance(pool.currentState, addr, pool.currentTime), pool.currentMaxGas)
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
-			pool.priced.Removed()
+			pool.pricedList(tx).Removed()
 			pendingNofundsCounter.Inc(1)
 		}
 		for _, tx := range invalids {
@@ -1113,6 +1250,30 @@ func (pool *TxPool) demoteUnexecutables() {
 			delete(pool.beats, addr)
 		}
 	}
+	// Drop sponsored transactions, the highest nonces first, until their fee
+	// payers can cover the gas of all remaining ones
+	for _, payer := range pool.all.Payers() {
+		balance := SpendableBalance(pool.currentState, payer, pool.currentTime)
+		if balance.Cmp(pool.all.Sponsored(payer)) >= 0 {
+			continue
+		}
+		var txs types.Transactions
+		pool.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+			if tx.FeePayer() != nil && *tx.FeePayer() == payer {
+				txs = append(txs, tx)
+			}
+			return true
+		})
+		sort.Sort(sort.Reverse(types.TxByNonce(txs)))
+		for _, tx := range txs {
+			if balance.Cmp(pool.all.Sponsored(payer)) >= 0 {
+				break
+			}
+			log.Trace("Removed unsponsored transaction", "hash", tx.Hash(), "payer", payer)
+			pool.removeTx(tx.Hash(), true)
+			pendingNofundsCounter.Inc(1)
+		}
+	}
 }
 
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
@@ -1173,14 +1334,18 @@ func (as *accountSet) add(addr common.Address) {
 // peeking into the pool in TxPool.Get without having to acquire the widely scoped
 // TxPool.mu mutex.
 type txLookup struct {
-	all  map[common.Hash]*types.Transaction
-	lock sync.RWMutex
+	all       map[common.Hash]*types.Transaction
+	counts    map[uint]int                // Number of transactions of each type
+	sponsored map[common.Address]*big.Int // Gas cost committed by each fee payer
+	lock      sync.RWMutex
 }
 
 // newTxLookup returns a new txLookup structure.
 func newTxLookup() *txLookup {
 	return &txLookup{
-		all: make(map[common.Hash]*types.Transaction),
+		all:       make(map[common.Hash]*types.Transaction),
+		counts:    make(map[uint]int),
+		sponsored: make(map[common.Address]*big.Int),
 	}
 }
 
@@ -1212,11 +1377,52 @@ func (t *txLookup) Count() int {
 	return len(t.all)
 }
 
+// CountType returns the current number of items of a transaction type in the lookup.
+func (t *txLookup) CountType(typ uint) int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.counts[typ]
+}
+
+// Sponsored returns the total gas cost of the transactions in the lookup paid
+// by a fee payer.
+func (t *txLookup) Sponsored(payer common.Address) *big.Int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if cost := t.sponsored[payer]; cost != nil {
+		return new(big.Int).Set(cost)
+	}
+	return new(big.Int)
+}
+
+// Payers returns the fee payers of the transactions in the lookup.
+func (t *txLookup) Payers() []common.Address {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	payers := make([]common.Address, 0, len(t.sponsored))
+	for payer := range t.sponsored {
+		payers = append(payers, payer)
+	}
+	return payers
+}
+
 // Add adds a transaction to the lookup.
 func (t *txLookup) Add(tx *types.Transaction) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	if _, ok := t.all[tx.Hash()]; !ok {
+		t.counts[tx.Type()]++
+		if payer := tx.FeePayer(); payer != nil {
+			if t.sponsored[*payer] == nil {
+				t.sponsored[*payer] = new(big.Int)
+			}
+			t.sponsored[*payer].Add(t.sponsored[*payer], tx.GasCost())
+		}
+	}
 	t.all[tx.Hash()] = tx
 }
 
@@ -1225,5 +1431,13 @@ func (t *txLookup) Remove(hash common.Hash) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	delete(t.all, hash)
+	if tx, ok := t.all[hash]; ok {
+		t.counts[tx.Type()]--
+		if payer := tx.FeePayer(); payer != nil {
+			if cost := t.sponsored[*payer].Sub(t.sponsored[*payer], tx.GasCost()); cost.Sign() == 0 {
+				delete(t.sponsored, *payer)
+			}
+		}
+		delete(t.all, hash)
+	}
 }
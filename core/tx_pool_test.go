@@ -53,7 +53,7 @@ type testBlockChain struct {
 func (bc *testBlockChain) CurrentBlock() *types.Block {
 	return types.NewBlock(&types.Header{
 		GasLimit: bc.gasLimit,
-	}, nil, nil, nil)
+	}, nil, nil)
 }
 
 func (bc *testBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
@@ -73,7 +73,7 @@ func transaction(nonce uint64, gaslimit uint64, key *ecdsa.PrivateKey) *types.Tr
 }
 
 func pricedTransaction(nonce uint64, gaslimit uint64, gasprice *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
-	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, gasprice, types.TxTypeTransfer,nil), types.EIP155Signer{}, key)
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, gasprice, types.TxTypeTransfer,nil), types.NewEIP155Signer(params.TestChainConfig.ChainId), key)
 	return tx
 }
 
@@ -97,7 +97,11 @@ func validateTxPoolInternals(pool *TxPool) error {
 	if total := pool.all.Count(); total != pending+queued {
 		return fmt.Errorf("total transaction count %d != %d pending + %d queued", total, pending, queued)
 	}
-	if priced := pool.priced.items.Len() - pool.priced.stales; priced != pending+queued {
+	priced := pool.priced.items.Len() - pool.priced.stales
+	for _, lane := range pool.lanes {
+		priced += lane.priced.items.Len() - lane.priced.stales
+	}
+	if priced != pending+queued {
 		return fmt.Errorf("total priced transaction count %d != %d pending + %d queued", priced, pending, queued)
 	}
 	// Ensure the next nonce to assign is the correct one
@@ -126,7 +130,7 @@ func validateEvents(events chan NewTxsEvent, count int) error {
 		case ev := <-events:
 			received = append(received, ev.Txs...)
 		case <-time.After(time.Second):
-			return fmt.Errorf("event #%d not fired", received)
+			return fmt.Errorf("event #%d not fired", len(received))
 		}
 	}
 	if len(received) > count {
@@ -145,7 +149,7 @@ func validateEvents(events chan NewTxsEvent, count int) error {
 }
 
 func deriveSender(tx *types.Transaction) (common.Address, error) {
-	return types.Sender(types.EIP155Signer{}, tx)
+	return types.Sender(types.NewEIP155Signer(params.TestChainConfig.ChainId), tx)
 }
 
 type testChain struct {
@@ -319,7 +323,7 @@ func TestTransactionNegativeValue(t *testing.T) {
 	pool, key := setupTxPool()
 	defer pool.Stop()
 
-	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(-1), 100, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(-1), 100, big.NewInt(1), types.TxTypeTransfer, nil), types.NewEIP155Signer(params.TestChainConfig.ChainId), key)
 	from, _ := deriveSender(tx)
 	pool.currentState.AddBalance(from, big.NewInt(1))
 	if err := pool.AddRemote(tx); err != ErrNegativeValue {
@@ -372,7 +376,7 @@ func TestTransactionDoubleNonce(t *testing.T) {
 	}
 	resetState()
 
-	signer := types.EIP155Signer{}
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
 	tx1, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 100000, big.NewInt(1),types.TxTypeTransfer, nil), signer, key)
 	tx2, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 1000000, big.NewInt(2), types.TxTypeTransfer,nil), signer, key)
 	tx3, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 1000000, big.NewInt(1), types.TxTypeTransfer,nil), signer, key)
@@ -1488,6 +1492,229 @@ func TestTransactionPoolStableUnderpricing(t *testing.T) {
 	}
 }
 
+// Tests that transactions of a dedicated lane only compete for slots with each
+// other, are accepted above the lane's own price limit and can't be evicted by a
+// flood of default lane transactions.
+func TestTransactionPoolLanes(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the lanes with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+	config.Lanes = []TxLaneConfig{{Type: types.TxTypeVote, Slots: 2, PriceLimit: 1}}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pool.SetGasPrice(big.NewInt(10))
+
+	keys := make([]*ecdsa.PrivateKey, 9)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(10000000))
+	}
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
+	typed := func(txType uint, nonce uint64, price int64, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 100000, big.NewInt(price), txType, nil), signer, key)
+		return tx
+	}
+	vote := func(nonce uint64, price int64, key *ecdsa.PrivateKey) *types.Transaction {
+		return typed(types.TxTypeVote, nonce, price, key)
+	}
+	// Votes are accepted above the lane's price limit, transfers only above the pool's
+	if err := pool.AddRemote(typed(types.TxTypeTransfer, 0, 1, keys[0])); err != ErrUnderpriced {
+		t.Fatalf("cheap transfer error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := pool.AddRemote(vote(0, 1, keys[1])); err != nil {
+		t.Fatalf("failed to add cheap vote: %v", err)
+	}
+	// Fill up the default lane, which must not affect the vote lane
+	for i := 4; i < len(keys); i++ {
+		if err := pool.AddRemote(typed(types.TxTypeTransfer, 0, int64(20+i), keys[i])); err != nil {
+			t.Fatalf("failed to add transfer #%d: %v", i, err)
+		}
+	}
+	if err := pool.AddRemote(vote(0, 2, keys[2])); err != nil {
+		t.Fatalf("failed to add vote into full pool: %v", err)
+	}
+	if count := pool.all.CountType(types.TxTypeVote); count != 2 {
+		t.Fatalf("vote count mismatch: have %d, want %d", count, 2)
+	}
+	// The vote lane is full, cheap votes are rejected and expensive ones evict votes only
+	if err := pool.AddRemote(vote(0, 1, keys[3])); err != ErrUnderpriced {
+		t.Fatalf("underpriced vote error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := pool.AddRemote(vote(0, 3, keys[3])); err != nil {
+		t.Fatalf("failed to add well priced vote: %v", err)
+	}
+	if pool.Get(vote(0, 1, keys[1]).Hash()) != nil {
+		t.Fatalf("cheapest vote not evicted")
+	}
+	if count := pool.all.CountType(types.TxTypeVote); count != 2 {
+		t.Fatalf("vote count mismatch: have %d, want %d", count, 2)
+	}
+	if count := pool.all.CountType(types.TxTypeTransfer); count != 4 {
+		t.Fatalf("transfer count mismatch: have %d, want %d", count, 4)
+	}
+	if lane := pool.Lane(vote(0, 3, keys[3])); lane != "vote" {
+		t.Fatalf("vote lane mismatch: have %s, want %s", lane, "vote")
+	}
+	if lane := pool.Lane(typed(types.TxTypeTransfer, 0, 1, keys[0])); lane != DefaultTxLane {
+		t.Fatalf("transfer lane mismatch: have %s, want %s", lane, DefaultTxLane)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the fairness cap of the pending default lane never evicts lane
+// transactions, even if they are the highest nonce ones of an account.
+func TestTransactionPendingLaneFairness(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the pending limits with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 4
+	config.AccountSlots = 2
+	config.Lanes = []TxLaneConfig{{Type: types.TxTypeVote, Slots: 8}}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	voter, _ := crypto.GenerateKey()
+	spammer, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(voter.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(spammer.PublicKey), big.NewInt(1000000000))
+
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
+	typed := func(txType uint, nonce uint64, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 100000, big.NewInt(1), txType, nil), signer, key)
+		return tx
+	}
+	// Overflow the default lane with both accounts, the voter ending in votes
+	var txs, votes types.Transactions
+	for i := uint64(0); i < 4; i++ {
+		txs = append(txs, typed(types.TxTypeTransfer, i, voter))
+	}
+	for i := uint64(4); i < 6; i++ {
+		votes = append(votes, typed(types.TxTypeVote, i, voter))
+	}
+	for i := uint64(0); i < 6; i++ {
+		txs = append(txs, typed(types.TxTypeTransfer, i, spammer))
+	}
+	pool.AddRemotes(append(txs, votes...))
+
+	for i, vote := range votes {
+		if pool.pending[crypto.PubkeyToAddress(voter.PublicKey)].txs.Get(vote.Nonce()) == nil {
+			t.Errorf("vote #%d evicted from pending", i)
+		}
+	}
+	// The votes shield the voter's transfers, so only the spammer is capped
+	if pending := pool.pending[crypto.PubkeyToAddress(voter.PublicKey)].Len(); pending != 6 {
+		t.Errorf("voter pending transactions mismatch: have %d, want %d", pending, 6)
+	}
+	if pending := pool.pending[crypto.PubkeyToAddress(spammer.PublicKey)].Len(); pending != int(config.AccountSlots) {
+		t.Errorf("spammer pending transactions mismatch: have %d, want %d", pending, config.AccountSlots)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the resource quota only makes transactions free as long as it also
+// covers the pending transactions of the payer.
+func TestTransactionPoolResourceQuota(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := *params.TestChainConfig
+	config.Resource = &params.ResourceConfig{Window: 1, BlockGas: 100000, BlockBandwidth: 1000}
+
+	pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(1000000))
+	addStake(pool.currentState, addr, big.NewInt(1))
+
+	// The quota covers four free transfers, the fifth has to pay
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		if err := pool.AddRemote(pricedTransaction(nonce, 21000, new(big.Int), key)); err != nil {
+			t.Fatalf("failed to add free transaction #%d: %v", nonce, err)
+		}
+	}
+	if err := pool.AddRemote(pricedTransaction(4, 21000, new(big.Int), key)); err != ErrUnderpriced {
+		t.Fatalf("over-quota transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := pool.AddRemote(pricedTransaction(4, 21000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("failed to add paid transaction: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 5 {
+		t.Fatalf("pending transactions mismatch: have %d, want %d", pending, 5)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that a fee payer has to cover the gas of all the transactions it sponsors
+// in the pool, and that sponsored transactions are dropped once it can't anymore.
+func TestTransactionPoolSponsoredFunds(t *testing.T) {
+	t.Parallel()
+
+	pool, payerKey := setupTxPool()
+	defer pool.Stop()
+
+	payer := crypto.PubkeyToAddress(payerKey.PublicKey)
+	pool.currentState.AddBalance(payer, big.NewInt(2*21000))
+
+	sponsored := func(key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 21000, big.NewInt(1), types.TxTypeTransfer, nil).WithFeePayer(payer), pool.signer, key)
+		tx, _ = types.SignFeePayer(tx, pool.signer, payerKey)
+		return tx
+	}
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000))
+	}
+	// The payer covers the gas of two transactions, the third is rejected
+	for i := 0; i < 2; i++ {
+		if err := pool.AddRemote(sponsored(keys[i])); err != nil {
+			t.Fatalf("failed to add sponsored transaction #%d: %v", i, err)
+		}
+	}
+	if err := pool.AddRemote(sponsored(keys[2])); err != ErrInsufficientFeePayerFunds {
+		t.Fatalf("over-committed transaction error mismatch: have %v, want %v", err, ErrInsufficientFeePayerFunds)
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatch: have %d, want %d", pending, 2)
+	}
+	// Drain the payer to cover a single transaction, the other is dropped
+	pool.currentState.SubBalance(payer, big.NewInt(21000))
+	pool.lockedReset(nil, nil)
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("pool size mismatch: have %d pending, %d queued, want 1 pending", pending, queued)
+	}
+	if cost := pool.all.Sponsored(payer); cost.Cmp(big.NewInt(21000)) != 0 {
+		t.Fatalf("committed gas cost mismatch: have %v, want %v", cost, 21000)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that the pool rejects replacement transactions that don't meet the minimum
 // price bump required.
 func TestTransactionReplacement(t *testing.T) {
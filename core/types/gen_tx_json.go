@@ -25,6 +25,12 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 		V            *hexutil.Big    `json:"v" gencodec:"required"`
 		R            *hexutil.Big    `json:"r" gencodec:"required"`
 		S            *hexutil.Big    `json:"s" gencodec:"required"`
+		FeePayer     *common.Address `json:"feePayer,omitempty"  rlp:"-"`
+		FeePayerV    *hexutil.Big    `json:"feePayerV,omitempty" rlp:"-"`
+		FeePayerR    *hexutil.Big    `json:"feePayerR,omitempty" rlp:"-"`
+		FeePayerS    *hexutil.Big    `json:"feePayerS,omitempty" rlp:"-"`
+		MultisigSender *common.Address `json:"multisigSender,omitempty" rlp:"-"`
+		Signatures     []hexutil.Bytes `json:"signatures,omitempty"     rlp:"-"`
 		Hash         *common.Hash    `json:"hash" rlp:"-"`
 	}
 	var enc txdata
@@ -38,6 +44,17 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 	enc.V = (*hexutil.Big)(t.V)
 	enc.R = (*hexutil.Big)(t.R)
 	enc.S = (*hexutil.Big)(t.S)
+	enc.FeePayer = t.FeePayer
+	enc.FeePayerV = (*hexutil.Big)(t.FeePayerV)
+	enc.FeePayerR = (*hexutil.Big)(t.FeePayerR)
+	enc.FeePayerS = (*hexutil.Big)(t.FeePayerS)
+	enc.MultisigSender = t.MultisigSender
+	if t.Signatures != nil {
+		enc.Signatures = make([]hexutil.Bytes, len(t.Signatures))
+		for k, v := range t.Signatures {
+			enc.Signatures[k] = v
+		}
+	}
 	enc.Hash = t.Hash
 	return json.Marshal(&enc)
 }
@@ -50,10 +67,16 @@ func (t *txdata) UnmarshalJSON(input []byte) error {
 		Recipient    *common.Address `json:"to"       rlp:"nil"`
 		Amount       *hexutil.Big    `json:"value"    gencodec:"required"`
 		Payload      *hexutil.Bytes  `json:"input"    gencodec:"required"`
-		TxType       *hexutil.Uint    `json:"type"     gencodec:"required"`
+		TxType       *uint           `json:"type"     gencodec:"required"`
 		V            *hexutil.Big    `json:"v" gencodec:"required"`
 		R            *hexutil.Big    `json:"r" gencodec:"required"`
 		S            *hexutil.Big    `json:"s" gencodec:"required"`
+		FeePayer     *common.Address `json:"feePayer,omitempty"  rlp:"-"`
+		FeePayerV    *hexutil.Big    `json:"feePayerV,omitempty" rlp:"-"`
+		FeePayerR    *hexutil.Big    `json:"feePayerR,omitempty" rlp:"-"`
+		FeePayerS    *hexutil.Big    `json:"feePayerS,omitempty" rlp:"-"`
+		MultisigSender *common.Address `json:"multisigSender,omitempty" rlp:"-"`
+		Signatures     []hexutil.Bytes `json:"signatures,omitempty"     rlp:"-"`
 		Hash         *common.Hash    `json:"hash" rlp:"-"`
 	}
 	var dec txdata
@@ -84,9 +107,9 @@ func (t *txdata) UnmarshalJSON(input []byte) error {
 	}
 	t.Payload = *dec.Payload
 	if dec.TxType == nil {
-		return errors.New("missing required field 'v' for txdata")
+		return errors.New("missing required field 'type' for txdata")
 	}
-	t.TxType = uint(*dec.TxType)
+	t.TxType = *dec.TxType
 	if dec.V == nil {
 		return errors.New("missing required field 'v' for txdata")
 	}
@@ -99,6 +122,27 @@ func (t *txdata) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 's' for txdata")
 	}
 	t.S = (*big.Int)(dec.S)
+	if dec.FeePayer != nil {
+		t.FeePayer = dec.FeePayer
+	}
+	if dec.FeePayerV != nil {
+		t.FeePayerV = (*big.Int)(dec.FeePayerV)
+	}
+	if dec.FeePayerR != nil {
+		t.FeePayerR = (*big.Int)(dec.FeePayerR)
+	}
+	if dec.FeePayerS != nil {
+		t.FeePayerS = (*big.Int)(dec.FeePayerS)
+	}
+	if dec.MultisigSender != nil {
+		t.MultisigSender = dec.MultisigSender
+	}
+	if dec.Signatures != nil {
+		t.Signatures = make([][]byte, len(dec.Signatures))
+		for k, v := range dec.Signatures {
+			t.Signatures[k] = v
+		}
+	}
 	if dec.Hash != nil {
 		t.Hash = dec.Hash
 	}
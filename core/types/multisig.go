@@ -0,0 +1,175 @@
+package types
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/crypto"
+)
+
+// MaxMultisigKeys is the maximum number of keys a multi-signature account can have.
+const MaxMultisigKeys = 16
+
+var (
+	ErrNotMultisig           = errors.New("transaction is not a multi-signature transaction")
+	ErrNoMultisigSignatures  = errors.New("multi-signature transaction without signatures")
+	ErrTooManyMultisigKeys   = errors.New("too many multi-signature keys")
+	ErrInvalidMultisigKey    = errors.New("invalid multi-signature key")
+	ErrDuplicateMultisigKey  = errors.New("duplicate multi-signature key")
+	ErrUnreachableThreshold  = errors.New("multi-signature threshold above total key weight")
+	ErrZeroMultisigThreshold = errors.New("zero multi-signature threshold")
+)
+
+// MultisigKey is a public key allowed to sign for a multi-signature account,
+// together with the weight its signature contributes towards the threshold.
+type MultisigKey struct {
+	PubKey []byte `json:"pubKey"` // Compressed secp256k1 public key
+	Weight uint64 `json:"weight"`
+}
+
+// MultisigSetup is the payload of a TxTypeMultisigSetup transaction, replacing
+// the signing keys and threshold of the sending account.
+type MultisigSetup struct {
+	Keys      []MultisigKey `json:"keys"`
+	Threshold uint64        `json:"threshold"`
+}
+
+// Validate checks that the threshold can be reached by the keys and that all
+// keys are well formed and unique.
+func (m *MultisigSetup) Validate() error {
+	if m.Threshold == 0 {
+		return ErrZeroMultisigThreshold
+	}
+	if len(m.Keys) > MaxMultisigKeys {
+		return ErrTooManyMultisigKeys
+	}
+	var total uint64
+	for i, key := range m.Keys {
+		if len(key.PubKey) != 33 || key.Weight == 0 {
+			return ErrInvalidMultisigKey
+		}
+		if _, err := crypto.DecompressPubkey(key.PubKey); err != nil {
+			return ErrInvalidMultisigKey
+		}
+		for _, prev := range m.Keys[:i] {
+			if bytes.Equal(prev.PubKey, key.PubKey) {
+				return ErrDuplicateMultisigKey
+			}
+		}
+		if total+key.Weight < total {
+			return ErrInvalidMultisigKey
+		}
+		total += key.Weight
+	}
+	if total < m.Threshold {
+		return ErrUnreachableThreshold
+	}
+	return nil
+}
+
+// MultisigWeight sums up the weights of the keys that produced one of the given
+// signer public keys. Every key is counted at most once.
+func MultisigWeight(keys []MultisigKey, signers [][]byte) uint64 {
+	var weight uint64
+	for _, key := range keys {
+		for _, signer := range signers {
+			if bytes.Equal(key.PubKey, signer) {
+				weight += key.Weight
+				break
+			}
+		}
+	}
+	return weight
+}
+
+// MultisigSender returns the multi-signature account a transaction is sent from,
+// or nil if the transaction is signed by a single key.
+func (tx *Transaction) MultisigSender() *common.Address {
+	if tx.data.MultisigSender == nil {
+		return nil
+	}
+	from := *tx.data.MultisigSender
+	return &from
+}
+
+// WithMultisigSender returns a new unsigned transaction sent from the given
+// multi-signature account. Signatures are collected with WithMultisigSignature.
+func (tx *Transaction) WithMultisigSender(from common.Address) *Transaction {
+	cpy := &Transaction{data: tx.data}
+	cpy.data.MultisigSender = &from
+	cpy.data.Signatures = nil
+	cpy.data.V, cpy.data.R, cpy.data.S = new(big.Int), new(big.Int), new(big.Int)
+	return cpy
+}
+
+// WithMultisigSignature returns a new transaction with the given signature added
+// to the already collected ones. The signature needs to be in the [R || S || V]
+// format where V is 0 or 1.
+func (tx *Transaction) WithMultisigSignature(sig []byte) (*Transaction, error) {
+	if tx.data.MultisigSender == nil {
+		return nil, ErrNotMultisig
+	}
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("wrong size for signature: got %d, want 65", len(sig))
+	}
+	cpy := &Transaction{data: tx.data}
+	cpy.data.Signatures = make([][]byte, len(tx.data.Signatures), len(tx.data.Signatures)+1)
+	copy(cpy.data.Signatures, tx.data.Signatures)
+	cpy.data.Signatures = append(cpy.data.Signatures, common.CopyBytes(sig))
+	return cpy, nil
+}
+
+// MultisigSignatures returns the signatures collected for a multi-signature
+// transaction.
+func (tx *Transaction) MultisigSignatures() [][]byte {
+	sigs := make([][]byte, len(tx.data.Signatures))
+	for i, sig := range tx.data.Signatures {
+		sigs[i] = common.CopyBytes(sig)
+	}
+	return sigs
+}
+
+// SignMultisig adds the signature of the given key to a multi-signature transaction.
+func SignMultisig(tx *Transaction, s Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
+	if tx.data.MultisigSender == nil {
+		return nil, ErrNotMultisig
+	}
+	h := s.Hash(tx)
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithMultisigSignature(sig)
+}
+
+// MultisigSigners recovers the compressed public keys of all signatures of a
+// multi-signature transaction. Whether they are allowed to sign for the sending
+// account, and carry enough weight to do so, depends on the account state.
+func MultisigSigners(s Signer, tx *Transaction) ([][]byte, error) {
+	if tx.data.MultisigSender == nil {
+		return nil, ErrNotMultisig
+	}
+	if len(tx.data.Signatures) == 0 {
+		return nil, ErrNoMultisigSignatures
+	}
+	if len(tx.data.Signatures) > MaxMultisigKeys {
+		return nil, ErrTooManyMultisigKeys
+	}
+	h := s.Hash(tx)
+	signers := make([][]byte, len(tx.data.Signatures))
+	for i, sig := range tx.data.Signatures {
+		if len(sig) != 65 || !crypto.ValidateSignatureValues(sig[64], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])) {
+			return nil, ErrInvalidSig
+		}
+		pub, err := crypto.SigToPub(h[:], sig)
+		if err != nil {
+			return nil, err
+		}
+		signers[i] = crypto.CompressPubkey(pub)
+	}
+	return signers, nil
+}
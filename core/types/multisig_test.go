@@ -0,0 +1,89 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/rlp"
+)
+
+// Tests that multi-signature transactions survive an RLP round trip and that the
+// weight of their signers is computed against the account keys.
+func TestMultisigTransaction(t *testing.T) {
+	var (
+		signer = NewEIP155Signer(big.NewInt(1))
+		from   = common.HexToAddress("0x0123")
+		keys   = make([]*ecdsa.PrivateKey, 3)
+		setup  = MultisigSetup{Threshold: 3}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		setup.Keys = append(setup.Keys, MultisigKey{PubKey: crypto.CompressPubkey(&keys[i].PublicKey), Weight: uint64(i + 1)})
+	}
+	if err := setup.Validate(); err != nil {
+		t.Fatalf("valid setup rejected: %v", err)
+	}
+	tx := NewTransaction(0, common.HexToAddress("0x0456"), big.NewInt(10), 21000, big.NewInt(1), TxTypeTransfer, nil).WithMultisigSender(from)
+	if _, err := Sender(signer, tx); err != ErrNoMultisigSignatures {
+		t.Fatalf("unsigned transaction error mismatch: have %v, want %v", err, ErrNoMultisigSignatures)
+	}
+	// Sign with the two lightest keys, reaching the threshold exactly
+	var err error
+	for _, key := range keys[:2] {
+		if tx, err = SignMultisig(tx, signer, key); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+	}
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	dec, err := decodeTx(enc)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if dec.Hash() != tx.Hash() {
+		t.Errorf("hash mismatch after round trip: have %x, want %x", dec.Hash(), tx.Hash())
+	}
+	if sender, err := Sender(signer, dec); err != nil || sender != from {
+		t.Fatalf("sender mismatch: have %x, %v, want %x", sender, err, from)
+	}
+	signers, err := MultisigSigners(signer, dec)
+	if err != nil {
+		t.Fatalf("failed to recover signers: %v", err)
+	}
+	if weight := MultisigWeight(setup.Keys, signers); weight != 3 {
+		t.Errorf("weight mismatch: have %d, want %d", weight, 3)
+	}
+	// Duplicate signatures must not be counted twice
+	dup, _ := SignMultisig(dec, signer, keys[1])
+	signers, _ = MultisigSigners(signer, dup)
+	if weight := MultisigWeight(setup.Keys, signers); weight != 3 {
+		t.Errorf("duplicate signature counted: weight %d, want %d", weight, 3)
+	}
+}
+
+func TestMultisigSetupValidation(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	pub := crypto.CompressPubkey(&key.PublicKey)
+
+	tests := []struct {
+		setup MultisigSetup
+		err   error
+	}{
+		{MultisigSetup{Keys: []MultisigKey{{pub, 1}}, Threshold: 0}, ErrZeroMultisigThreshold},
+		{MultisigSetup{Keys: []MultisigKey{{pub, 1}}, Threshold: 2}, ErrUnreachableThreshold},
+		{MultisigSetup{Keys: []MultisigKey{{pub, 1}, {pub, 1}}, Threshold: 2}, ErrDuplicateMultisigKey},
+		{MultisigSetup{Keys: []MultisigKey{{pub[1:], 1}}, Threshold: 1}, ErrInvalidMultisigKey},
+		{MultisigSetup{Keys: []MultisigKey{{pub, 0}}, Threshold: 1}, ErrInvalidMultisigKey},
+		{MultisigSetup{Keys: make([]MultisigKey, MaxMultisigKeys+1), Threshold: 1}, ErrTooManyMultisigKeys},
+	}
+	for i, tt := range tests {
+		if err := tt.setup.Validate(); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/yooba-team/yooba/common/math"
+)
+
+// ResourceUsage is the gas and bandwidth an account consumed from its resource
+// quota, decaying linearly to zero over the usage window. The amounts are as of
+// the block they were last updated in.
+type ResourceUsage struct {
+	Gas       uint64
+	Bandwidth uint64
+	Block     uint64
+}
+
+// Decayed returns the usage left at the given block number, after the decay
+// over a window of the given number of blocks.
+func (u ResourceUsage) Decayed(number, window uint64) ResourceUsage {
+	decayed := ResourceUsage{Block: number}
+	if number < u.Block {
+		number = u.Block
+	}
+	if elapsed := number - u.Block; elapsed < window {
+		decayed.Gas = mulDiv(u.Gas, window-elapsed, window)
+		decayed.Bandwidth = mulDiv(u.Bandwidth, window-elapsed, window)
+	}
+	return decayed
+}
+
+// Add returns the usage at the given block number after consuming the given gas
+// and bandwidth on top of the decayed prior usage.
+func (u ResourceUsage) Add(number, window, gas, bandwidth uint64) ResourceUsage {
+	usage := u.Decayed(number, window)
+	if sum, overflow := math.SafeAdd(usage.Gas, gas); !overflow {
+		usage.Gas = sum
+	} else {
+		usage.Gas = math.MaxUint64
+	}
+	if sum, overflow := math.SafeAdd(usage.Bandwidth, bandwidth); !overflow {
+		usage.Bandwidth = sum
+	} else {
+		usage.Bandwidth = math.MaxUint64
+	}
+	return usage
+}
+
+// mulDiv returns x*num/denom without overflowing the intermediate product.
+func mulDiv(x, num, denom uint64) uint64 {
+	r := new(big.Int).SetUint64(x)
+	r.Mul(r, new(big.Int).SetUint64(num))
+	return r.Div(r, new(big.Int).SetUint64(denom)).Uint64()
+}
@@ -0,0 +1,31 @@
+package types
+
+import "testing"
+
+func TestResourceUsageDecay(t *testing.T) {
+	usage := ResourceUsage{Gas: 1000, Bandwidth: 400, Block: 100}
+	tests := []struct {
+		number         uint64
+		gas, bandwidth uint64
+	}{
+		{50, 1000, 400}, // before the last update
+		{100, 1000, 400},
+		{125, 750, 300},
+		{150, 500, 200},
+		{199, 10, 4},
+		{200, 0, 0}, // fully decayed
+		{1000, 0, 0},
+	}
+	for _, tt := range tests {
+		decayed := usage.Decayed(tt.number, 100)
+		if decayed.Gas != tt.gas || decayed.Bandwidth != tt.bandwidth {
+			t.Errorf("block %d: usage mismatch: have %d/%d, want %d/%d", tt.number, decayed.Gas, decayed.Bandwidth, tt.gas, tt.bandwidth)
+		}
+	}
+	if added := usage.Add(150, 100, 100, 50); added.Gas != 600 || added.Bandwidth != 250 || added.Block != 150 {
+		t.Errorf("added usage mismatch: have %+v", added)
+	}
+	if added := usage.Add(100, 100, ^uint64(0), 0); added.Gas != ^uint64(0) {
+		t.Errorf("overflowing usage not capped: have %d", added.Gas)
+	}
+}
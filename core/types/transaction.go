@@ -33,8 +33,12 @@ import (
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
 
 var (
-	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
-	errNoSigner   = errors.New("missing signing methods")
+	ErrInvalidSig      = errors.New("invalid transaction v, r, s values")
+	ErrInvalidFeePayer = errors.New("fee payer signature doesn't match fee payer")
+	ErrNoFeePayer      = errors.New("transaction has no fee payer")
+	errNoSigner        = errors.New("missing signing methods")
+
+	errSponsoredMultisig = errors.New("multi-signature transactions can't be sponsored")
 )
 
 // deriveSigner makes a *best* guess about which signer to use.
@@ -48,6 +52,13 @@ const (
 	TxTypeVote
 	TxTypeContract
 	TxTypeWitness
+	TxTypeProposal
+	TxTypeProposalVote
+	TxTypeMultisigSetup
+	TxTypeVestingGrant
+	TxTypeVestingRevoke
+	TxTypeResourceStake
+	TxTypeResourceUnstake
 )
 
 
@@ -73,10 +84,60 @@ type txdata struct {
 	R *big.Int `json:"r" gencodec:"required"`
 	S *big.Int `json:"s" gencodec:"required"`
 
+	// Fee payer of sponsored transactions, nil if the sender pays the gas.
+	// These fields are only part of the RLP encoding if FeePayer is set.
+	FeePayer  *common.Address `json:"feePayer,omitempty"  rlp:"-"`
+	FeePayerV *big.Int        `json:"feePayerV,omitempty" rlp:"-"`
+	FeePayerR *big.Int        `json:"feePayerR,omitempty" rlp:"-"`
+	FeePayerS *big.Int        `json:"feePayerS,omitempty" rlp:"-"`
+
+	// Sending account and signatures of multi-signature transactions, nil if
+	// the transaction is signed by the single key of the sender.
+	MultisigSender *common.Address `json:"multisigSender,omitempty" rlp:"-"`
+	Signatures     [][]byte        `json:"signatures,omitempty"     rlp:"-"`
+
 	// This is only used when marshaling to JSON.
 	Hash *common.Hash `json:"hash" rlp:"-"`
 }
 
+// Number of RLP list elements of sponsored and multi-signature transactions.
+const (
+	sponsoredTxFields = 14
+	multisigTxFields  = 12
+)
+
+// sponsoredTxdata is the RLP encoding of a transaction whose gas is paid by a
+// fee payer instead of the sender.
+type sponsoredTxdata struct {
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     uint64
+	Recipient    *common.Address `rlp:"nil"`
+	Amount       *big.Int
+	Payload      []byte
+	TxType       uint
+	V, R, S      *big.Int
+
+	FeePayer                        common.Address
+	FeePayerV, FeePayerR, FeePayerS *big.Int
+}
+
+// multisigTxdata is the RLP encoding of a transaction sent from a
+// multi-signature account.
+type multisigTxdata struct {
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     uint64
+	Recipient    *common.Address `rlp:"nil"`
+	Amount       *big.Int
+	Payload      []byte
+	TxType       uint
+	V, R, S      *big.Int
+
+	MultisigSender common.Address
+	Signatures     [][]byte
+}
+
 type txdataMarshaling struct {
 	AccountNonce hexutil.Uint64
 	Price        *hexutil.Big
@@ -84,9 +145,13 @@ type txdataMarshaling struct {
 	Amount       *hexutil.Big
 	Payload      hexutil.Bytes
 	TxType       uint
+	Signatures   []hexutil.Bytes
 	V            *hexutil.Big
 	R            *hexutil.Big
 	S            *hexutil.Big
+	FeePayerV    *hexutil.Big
+	FeePayerR    *hexutil.Big
+	FeePayerS    *hexutil.Big
 }
 
 func NewTransaction(nonce uint64, to common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int,txType uint,data []byte) *Transaction {
@@ -144,17 +209,107 @@ func isProtectedV(V *big.Int) bool {
 
 // EncodeRLP implements rlp.Encoder
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.data.MultisigSender != nil {
+		if tx.data.FeePayer != nil {
+			return errSponsoredMultisig
+		}
+		return rlp.Encode(w, &multisigTxdata{
+			AccountNonce:   tx.data.AccountNonce,
+			Price:          tx.data.Price,
+			GasLimit:       tx.data.GasLimit,
+			Recipient:      tx.data.Recipient,
+			Amount:         tx.data.Amount,
+			Payload:        tx.data.Payload,
+			TxType:         tx.data.TxType,
+			V:              tx.data.V,
+			R:              tx.data.R,
+			S:              tx.data.S,
+			MultisigSender: *tx.data.MultisigSender,
+			Signatures:     tx.data.Signatures,
+		})
+	}
+	if tx.data.FeePayer == nil {
+		return rlp.Encode(w, &tx.data)
+	}
+	return rlp.Encode(w, &sponsoredTxdata{
+		AccountNonce: tx.data.AccountNonce,
+		Price:        tx.data.Price,
+		GasLimit:     tx.data.GasLimit,
+		Recipient:    tx.data.Recipient,
+		Amount:       tx.data.Amount,
+		Payload:      tx.data.Payload,
+		TxType:       tx.data.TxType,
+		V:            tx.data.V,
+		R:            tx.data.R,
+		S:            tx.data.S,
+		FeePayer:     *tx.data.FeePayer,
+		FeePayerV:    tx.data.FeePayerV,
+		FeePayerR:    tx.data.FeePayerR,
+		FeePayerS:    tx.data.FeePayerS,
+	})
 }
 
 // DecodeRLP implements rlp.Decoder
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
+	raw, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	content, _, err := rlp.SplitList(raw)
+	if err != nil {
+		return err
+	}
+	fields, err := rlp.CountValues(content)
+	if err != nil {
+		return err
+	}
+	// Plain transactions are decoded directly, sponsored and multi-signature
+	// ones carry their additional fields after the plain ones.
+	switch fields {
+	case multisigTxFields:
+		var dec multisigTxdata
+		if err = rlp.DecodeBytes(raw, &dec); err == nil {
+			tx.data = txdata{
+				AccountNonce:   dec.AccountNonce,
+				Price:          dec.Price,
+				GasLimit:       dec.GasLimit,
+				Recipient:      dec.Recipient,
+				Amount:         dec.Amount,
+				Payload:        dec.Payload,
+				TxType:         dec.TxType,
+				V:              dec.V,
+				R:              dec.R,
+				S:              dec.S,
+				MultisigSender: &dec.MultisigSender,
+				Signatures:     dec.Signatures,
+			}
+		}
+	case sponsoredTxFields:
+		var dec sponsoredTxdata
+		if err = rlp.DecodeBytes(raw, &dec); err == nil {
+			tx.data = txdata{
+				AccountNonce: dec.AccountNonce,
+				Price:        dec.Price,
+				GasLimit:     dec.GasLimit,
+				Recipient:    dec.Recipient,
+				Amount:       dec.Amount,
+				Payload:      dec.Payload,
+				TxType:       dec.TxType,
+				V:            dec.V,
+				R:            dec.R,
+				S:            dec.S,
+				FeePayer:     &dec.FeePayer,
+				FeePayerV:    dec.FeePayerV,
+				FeePayerR:    dec.FeePayerR,
+				FeePayerS:    dec.FeePayerS,
+			}
+		}
+	default:
+		err = rlp.DecodeBytes(raw, &tx.data)
+	}
 	if err == nil {
-		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		tx.size.Store(common.StorageSize(len(raw)))
 	}
-
 	return err
 }
 
@@ -172,6 +327,11 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 	if err := dec.UnmarshalJSON(input); err != nil {
 		return err
 	}
+	// Multi-signature transactions carry their signatures separately
+	if dec.MultisigSender != nil {
+		*tx = Transaction{data: dec}
+		return nil
+	}
 	var V byte
 	if isProtectedV(dec.V) {
 		chainID := deriveChainId(dec.V).Uint64()
@@ -194,6 +354,16 @@ func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 func (tx *Transaction) Type() uint      { return tx.data.TxType }
 func (tx *Transaction) CheckNonce() bool   { return true }
 
+// FeePayer returns the account paying the gas of a sponsored transaction. It
+// returns nil if the gas is paid by the sender.
+func (tx *Transaction) FeePayer() *common.Address {
+	if tx.data.FeePayer == nil {
+		return nil
+	}
+	payer := *tx.data.FeePayer
+	return &payer
+}
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *Transaction) To() *common.Address {
@@ -222,7 +392,7 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	rlp.Encode(&c, tx)
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
@@ -246,7 +416,22 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 
 	var err error
 	msg.from, err = Sender(s, tx)
-	return msg, err
+	if err != nil {
+		return msg, err
+	}
+	if tx.data.MultisigSender != nil {
+		if msg.signers, err = MultisigSigners(s, tx); err != nil {
+			return msg, err
+		}
+	}
+	if tx.data.FeePayer != nil {
+		payer, err := FeePayerSender(s, tx)
+		if err != nil {
+			return msg, err
+		}
+		msg.feePayer = &payer
+	}
+	return msg, nil
 }
 
 // WithSignature returns a new transaction with the given signature.
@@ -261,17 +446,59 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	return cpy, nil
 }
 
-// Cost returns amount + gasprice * gaslimit.
+// WithFeePayer returns a new unsigned transaction whose gas is paid by payer.
+// Both the sender and the fee payer have to sign the returned transaction.
+func (tx *Transaction) WithFeePayer(payer common.Address) *Transaction {
+	cpy := &Transaction{data: tx.data}
+	cpy.data.FeePayer = &payer
+	cpy.data.V, cpy.data.R, cpy.data.S = new(big.Int), new(big.Int), new(big.Int)
+	cpy.data.FeePayerV, cpy.data.FeePayerR, cpy.data.FeePayerS = new(big.Int), new(big.Int), new(big.Int)
+	return cpy
+}
+
+// WithFeePayerSignature returns a new transaction with the given fee payer
+// signature. The signature needs to be in the [R || S || V] format where V is
+// 0 or 1.
+func (tx *Transaction) WithFeePayerSignature(sig []byte) (*Transaction, error) {
+	if tx.data.FeePayer == nil {
+		return nil, ErrNoFeePayer
+	}
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("wrong size for signature: got %d, want 65", len(sig))
+	}
+	cpy := &Transaction{data: tx.data}
+	cpy.data.FeePayerR = new(big.Int).SetBytes(sig[:32])
+	cpy.data.FeePayerS = new(big.Int).SetBytes(sig[32:64])
+	cpy.data.FeePayerV = new(big.Int).SetBytes([]byte{sig[64] + 27})
+	return cpy, nil
+}
+
+// Cost returns the funds the sender needs: amount + gasprice * gaslimit, or
+// only the amount if the gas is paid by a fee payer.
 func (tx *Transaction) Cost() *big.Int {
-	total := new(big.Int).Mul(tx.data.Price, new(big.Int).SetUint64(tx.data.GasLimit))
+	if tx.data.FeePayer != nil {
+		return new(big.Int).Set(tx.data.Amount)
+	}
+	total := tx.GasCost()
 	total.Add(total, tx.data.Amount)
 	return total
 }
 
+// GasCost returns gasprice * gaslimit.
+func (tx *Transaction) GasCost() *big.Int {
+	return new(big.Int).Mul(tx.data.Price, new(big.Int).SetUint64(tx.data.GasLimit))
+}
+
 func (tx *Transaction) RawSignatureValues() (*big.Int, *big.Int, *big.Int) {
 	return tx.data.V, tx.data.R, tx.data.S
 }
 
+// RawFeePayerSignatureValues returns the signature of the fee payer, or nils
+// if the transaction isn't sponsored.
+func (tx *Transaction) RawFeePayerSignatureValues() (*big.Int, *big.Int, *big.Int) {
+	return tx.data.FeePayerV, tx.data.FeePayerR, tx.data.FeePayerS
+}
+
 func (tx *Transaction) String() string {
 	var from, to string
 	if tx.data.V != nil {
@@ -292,7 +519,7 @@ func (tx *Transaction) String() string {
 	} else {
 		to = fmt.Sprintf("%x", tx.data.Recipient[:])
 	}
-	enc, _ := rlp.EncodeToBytes(&tx.data)
+	enc, _ := rlp.EncodeToBytes(tx)
 	return fmt.Sprintf(`
 	TX(%x)
 	Contract: %v
@@ -459,6 +686,8 @@ type Message struct {
 	gasPrice   *big.Int
 	data       []byte
 	txType     uint
+	feePayer   *common.Address
+	signers    [][]byte
 	checkNonce bool
 }
 
@@ -485,3 +714,10 @@ func (m Message) Nonce() uint64        { return m.nonce }
 func (m Message) Data() []byte         { return m.data }
 func (m Message) Type() uint         { return m.txType }
 func (m Message) CheckNonce() bool     { return m.checkNonce }
+
+// FeePayer returns the account paying the gas, or nil if the sender pays it.
+func (m Message) FeePayer() *common.Address { return m.feePayer }
+
+// MultisigSigners returns the compressed public keys that signed a message sent
+// from a multi-signature account, or nil if it was signed by a single key.
+func (m Message) MultisigSigners() [][]byte { return m.signers }
@@ -25,6 +25,7 @@ import (
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/crypto"
 	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/rlp"
 )
 
 var (
@@ -81,6 +82,51 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 	return addr, nil
 }
 
+// SignFeePayer co-signs a sponsored transaction as its fee payer. The sender
+// has to sign the transaction first, as the fee payer signature covers it.
+func SignFeePayer(tx *Transaction, s Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
+	if tx.data.FeePayer == nil {
+		return nil, ErrNoFeePayer
+	}
+	h := FeePayerHash(s, tx)
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithFeePayerSignature(sig)
+}
+
+// FeePayerHash returns the hash to be signed by the fee payer of a sponsored
+// transaction. It commits to the sender's signature, so a fee payer signature
+// can't be reused for any other transaction of the sender.
+func FeePayerHash(s Signer, tx *Transaction) common.Hash {
+	return rlpHash([]interface{}{
+		s.Hash(tx),
+		tx.data.V,
+		tx.data.R,
+		tx.data.S,
+	})
+}
+
+// FeePayerSender returns the fee payer of a sponsored transaction, after
+// verifying that the fee payer signature was made by the declared fee payer.
+func FeePayerSender(s Signer, tx *Transaction) (common.Address, error) {
+	if tx.data.FeePayer == nil {
+		return common.Address{}, ErrNoFeePayer
+	}
+	if tx.data.FeePayerV == nil || tx.data.FeePayerR == nil || tx.data.FeePayerS == nil {
+		return common.Address{}, ErrInvalidSig
+	}
+	payer, err := recoverPlain(FeePayerHash(s, tx), tx.data.FeePayerR, tx.data.FeePayerS, tx.data.FeePayerV)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if payer != *tx.data.FeePayer {
+		return common.Address{}, ErrInvalidFeePayer
+	}
+	return payer, nil
+}
+
 // Signer encapsulates transaction signature handling. Note that this interface is not a
 // stable API and may change at any time to accommodate new protocol rules.
 type Signer interface {
@@ -118,6 +164,9 @@ func (s EIP155Signer) Equal(s2 Signer) bool {
 var big8 = big.NewInt(8)
 
 func (s EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
+	if tx.data.MultisigSender != nil {
+		return multisigSender(s, tx)
+	}
 	if tx.ChainId().Cmp(s.chainId) != 0 {
 		return common.Address{}, ErrInvalidChainId
 	}
@@ -146,7 +195,22 @@ func (s EIP155Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
-	return rlpHash([]interface{}{
+	return rlpHash(s.signingFields(tx))
+}
+
+// SigningPayload returns the RLP encoding of the fields signed by the sender,
+// whose Keccak256 hash is Hash. Signers holding the key out of process, like
+// hardware wallets, hash and sign it themselves.
+func (s EIP155Signer) SigningPayload(tx *Transaction) ([]byte, error) {
+	return rlp.EncodeToBytes(s.signingFields(tx))
+}
+
+// signingFields returns the fields covered by the sender's signature. Yooba's
+// additions are only appended if set, so plain transfers are signed the same
+// way as by any EIP-155 signer. The type decides how a transaction executes, so
+// it is signed for all but transfers and can't be changed after signing.
+func (s EIP155Signer) signingFields(tx *Transaction) []interface{} {
+	fields := []interface{}{
 		tx.data.AccountNonce,
 		tx.data.Price,
 		tx.data.GasLimit,
@@ -154,7 +218,17 @@ func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
 		tx.data.Amount,
 		tx.data.Payload,
 		s.chainId, uint(0), uint(0),
-	})
+	}
+	if tx.data.TxType != TxTypeTransfer {
+		fields = append(fields, tx.data.TxType)
+	}
+	if tx.data.FeePayer != nil {
+		fields = append(fields, *tx.data.FeePayer)
+	}
+	if tx.data.MultisigSender != nil {
+		fields = append(fields, *tx.data.MultisigSender)
+	}
+	return fields
 }
 
 // HomesteadTransaction implements TransactionInterface using the
@@ -173,6 +247,9 @@ func (hs HomesteadSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v
 }
 
 func (hs HomesteadSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.data.MultisigSender != nil {
+		return multisigSender(hs, tx)
+	}
 	return recoverPlain(hs.Hash(tx), tx.data.R, tx.data.S, tx.data.V)
 }
 
@@ -198,20 +275,43 @@ func (fs FrontierSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v *
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
-	return rlpHash([]interface{}{
+	fields := []interface{}{
 		tx.data.AccountNonce,
 		tx.data.Price,
 		tx.data.GasLimit,
 		tx.data.Recipient,
 		tx.data.Amount,
 		tx.data.Payload,
-	})
+	}
+	if tx.data.TxType != TxTypeTransfer {
+		fields = append(fields, tx.data.TxType)
+	}
+	if tx.data.FeePayer != nil {
+		fields = append(fields, *tx.data.FeePayer)
+	}
+	if tx.data.MultisigSender != nil {
+		fields = append(fields, *tx.data.MultisigSender)
+	}
+	return rlpHash(fields)
 }
 
 func (fs FrontierSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.data.MultisigSender != nil {
+		return multisigSender(fs, tx)
+	}
 	return recoverPlain(fs.Hash(tx), tx.data.R, tx.data.S, tx.data.V)
 }
 
+// multisigSender returns the declared sender of a multi-signature transaction
+// after checking that all its signatures are well formed. The signers still
+// have to be checked against the keys of the sending account.
+func multisigSender(s Signer, tx *Transaction) (common.Address, error) {
+	if _, err := MultisigSigners(s, tx); err != nil {
+		return common.Address{}, err
+	}
+	return *tx.data.MultisigSender, nil
+}
+
 func recoverPlain(sighash common.Hash, R, S, Vb *big.Int) (common.Address, error) {
 	if Vb.BitLen() > 8 {
 		return common.Address{}, ErrInvalidSig
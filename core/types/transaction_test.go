@@ -48,18 +48,18 @@ var (
 		TxTypeTransfer,
 		common.FromHex("5544"),
 	).WithSignature(
-		EIP155Signer{},
+		HomesteadSigner{},
 		common.Hex2Bytes("98ff921201554726367d2be8c804a7ff89ccf285ebc57dff8ae4c44b9c19ac4a8887321be575c8095f789dd4c743dfe42c1820f9231f98a962b210e3ac2452a301"),
 	)
 )
 
 func TestTransactionSigHash(t *testing.T) {
-	var eip155signer EIP155Signer
-	if eip155signer.Hash(emptyTx) != common.HexToHash("c775b99e7ad12f50d819fcd602390467e28141316969f4b57f0626f74fe3b386") {
-		t.Errorf("empty transaction hash mismatch, got %x", emptyTx.Hash())
+	var signer FrontierSigner
+	if h := signer.Hash(emptyTx); h != common.HexToHash("c775b99e7ad12f50d819fcd602390467e28141316969f4b57f0626f74fe3b386") {
+		t.Errorf("empty transaction hash mismatch, got %x", h)
 	}
-	if eip155signer.Hash(rightvrsTx) != common.HexToHash("fe7a79529ed5f7c3375d06b26b186a8644e0e16c373d7a12be41c62d6042b77a") {
-		t.Errorf("RightVRS transaction hash mismatch, got %x", rightvrsTx.Hash())
+	if h := signer.Hash(rightvrsTx); h != common.HexToHash("fe7a79529ed5f7c3375d06b26b186a8644e0e16c373d7a12be41c62d6042b77a") {
+		t.Errorf("RightVRS transaction hash mismatch, got %x", h)
 	}
 }
 
@@ -68,7 +68,7 @@ func TestTransactionEncode(t *testing.T) {
 	if err != nil {
 		t.Fatalf("encode error: %v", err)
 	}
-	should := common.FromHex("f86103018207d094b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a8255441ca098ff921201554726367d2be8c804a7ff89ccf285ebc57dff8ae4c44b9c19ac4aa08887321be575c8095f789dd4c743dfe42c1820f9231f98a962b210e3ac2452a3")
+	should := common.FromHex("f86203018207d094b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544801ca098ff921201554726367d2be8c804a7ff89ccf285ebc57dff8ae4c44b9c19ac4aa08887321be575c8095f789dd4c743dfe42c1820f9231f98a962b210e3ac2452a3")
 	if !bytes.Equal(txb, should) {
 		t.Errorf("encoded RLP mismatch, got %x", txb)
 	}
@@ -89,13 +89,13 @@ func defaultTestKey() (*ecdsa.PrivateKey, common.Address) {
 
 func TestRecipientEmpty(t *testing.T) {
 	_, addr := defaultTestKey()
-	tx, err := decodeTx(common.Hex2Bytes("f8498080808080011ca09b16de9d5bdee2cf56c28d16275a4da68cd30273e2525f3959f5d62557489921a0372ebd8fb3345f7db7b5a86d42e24d36e983e259b0664ceb8c227ec9af572f3d"))
+	tx, err := decodeTx(common.Hex2Bytes("f84a808080808001801ca09b16de9d5bdee2cf56c28d16275a4da68cd30273e2525f3959f5d62557489921a0372ebd8fb3345f7db7b5a86d42e24d36e983e259b0664ceb8c227ec9af572f3d"))
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
 	}
 
-	from, err := Sender(EIP155Signer{}, tx)
+	from, err := Sender(HomesteadSigner{}, tx)
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
@@ -108,13 +108,13 @@ func TestRecipientEmpty(t *testing.T) {
 func TestRecipientNormal(t *testing.T) {
 	_, addr := defaultTestKey()
 
-	tx, err := decodeTx(common.Hex2Bytes("f85d80808094000000000000000000000000000000000000000080011ca0527c0d8f5c63f7b9f41324a7c8a563ee1190bcbf0dac8ab446291bdbf32f5c79a0552c4ef0a09a04395074dab9ed34d3fbfb843c2f2546cc30fe89ec143ca94ca6"))
+	tx, err := decodeTx(common.Hex2Bytes("f85e8080809400000000000000000000000000000000000000008001801ca0527c0d8f5c63f7b9f41324a7c8a563ee1190bcbf0dac8ab446291bdbf32f5c79a0552c4ef0a09a04395074dab9ed34d3fbfb843c2f2546cc30fe89ec143ca94ca6"))
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
 	}
 
-	from, err := Sender(EIP155Signer{}, tx)
+	from, err := Sender(HomesteadSigner{}, tx)
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
@@ -135,7 +135,7 @@ func TestTransactionPriceNonceSort(t *testing.T) {
 		keys[i], _ = crypto.GenerateKey()
 	}
 
-	signer := EIP155Signer{}
+	signer := NewEIP155Signer(common.Big1)
 	// Generate a batch of transactions with overlapping values, but shifted nonces
 	groups := map[common.Address]Transactions{}
 	for start, key := range keys {
@@ -220,3 +220,56 @@ func TestTransactionJSON(t *testing.T) {
 		}
 	}
 }
+
+// Tests that sponsored transactions survive an RLP round trip and that both the
+// sender and the fee payer can be recovered from them.
+func TestSponsoredTransaction(t *testing.T) {
+	var (
+		signer      = NewEIP155Signer(big.NewInt(1))
+		key, from   = defaultTestKey()
+		payerKey, _ = crypto.GenerateKey()
+		payer       = crypto.PubkeyToAddress(payerKey.PublicKey)
+	)
+	tx := NewTransaction(0, common.HexToAddress("0x0100"), big.NewInt(10), 21000, big.NewInt(2), TxTypeGoods, nil).WithFeePayer(payer)
+	tx, err := SignTx(tx, signer, key)
+	if err != nil {
+		t.Fatalf("failed to sign as sender: %v", err)
+	}
+	if _, err := FeePayerSender(signer, tx); err == nil {
+		t.Fatalf("fee payer recovered before co-signing")
+	}
+	tx, err = SignFeePayer(tx, signer, payerKey)
+	if err != nil {
+		t.Fatalf("failed to co-sign as fee payer: %v", err)
+	}
+	if cost := tx.Cost(); cost.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("sender cost mismatch: have %v, want %v", cost, 10)
+	}
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	dec, err := decodeTx(enc)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if dec.Hash() != tx.Hash() {
+		t.Errorf("hash mismatch after round trip: have %x, want %x", dec.Hash(), tx.Hash())
+	}
+	if sender, err := Sender(signer, dec); err != nil || sender != from {
+		t.Errorf("sender mismatch: have %x, %v, want %x", sender, err, from)
+	}
+	if sponsor, err := FeePayerSender(signer, dec); err != nil || sponsor != payer {
+		t.Errorf("fee payer mismatch: have %x, %v, want %x", sponsor, err, payer)
+	}
+	// Swapping the declared fee payer must invalidate both signatures
+	forged := *dec.FeePayer()
+	forged[0]++
+	dec.data.FeePayer = &forged
+	if sender, _ := Sender(signer, &Transaction{data: dec.data}); sender == from {
+		t.Errorf("sender signature still valid for different fee payer")
+	}
+	if _, err := FeePayerSender(signer, dec); err == nil {
+		t.Errorf("fee payer signature accepted for different fee payer")
+	}
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/math"
+)
+
+//go:generate gencodec -type VestingSchedule -field-override vestingScheduleMarshaling -out gen_vesting_json.go
+
+var (
+	ErrInvalidVestingAmount   = errors.New("vesting amount must be positive")
+	ErrInvalidVestingDuration = errors.New("vesting duration must be positive and not shorter than the cliff")
+)
+
+// VestingSchedule locks part of an account balance and releases it linearly
+// over time. Nothing is released before the cliff has passed, everything after
+// the duration. A schedule with a cliff equal to its duration is a plain time
+// lock. Times are unix timestamps compared against the block time.
+type VestingSchedule struct {
+	Amount    *big.Int       `json:"amount"    gencodec:"required"` // Balance locked at Start
+	Start     uint64         `json:"start"     gencodec:"required"`
+	Cliff     uint64         `json:"cliff"`                         // Seconds after Start until the first release
+	Duration  uint64         `json:"duration"  gencodec:"required"` // Seconds after Start until the full release
+	Revocable bool           `json:"revocable"`
+	Revoker   common.Address `json:"revoker"` // Account allowed to take back the locked balance
+}
+
+type vestingScheduleMarshaling struct {
+	Amount   *math.HexOrDecimal256
+	Start    math.HexOrDecimal64
+	Cliff    math.HexOrDecimal64
+	Duration math.HexOrDecimal64
+}
+
+// VestingGrant is the payload of a TxTypeVestingGrant transaction. The value of
+// the transaction is transferred to the recipient and locked according to the
+// schedule, revocable by the sender if requested.
+type VestingGrant struct {
+	Start     uint64
+	Cliff     uint64
+	Duration  uint64
+	Revocable bool
+}
+
+// Validate checks the schedule for sanity.
+func (v *VestingSchedule) Validate() error {
+	if v.Amount == nil || v.Amount.Sign() <= 0 {
+		return ErrInvalidVestingAmount
+	}
+	if v.Duration == 0 || v.Cliff > v.Duration || v.Start+v.Duration < v.Start {
+		return ErrInvalidVestingDuration
+	}
+	return nil
+}
+
+// Locked returns the part of the amount that is still locked at the given time.
+func (v *VestingSchedule) Locked(time uint64) *big.Int {
+	switch {
+	case time < v.Start+v.Cliff:
+		return new(big.Int).Set(v.Amount)
+	case time >= v.Start+v.Duration:
+		return new(big.Int)
+	}
+	released := new(big.Int).Mul(v.Amount, new(big.Int).SetUint64(time-v.Start))
+	released.Div(released, new(big.Int).SetUint64(v.Duration))
+	return released.Sub(v.Amount, released)
+}
+
+// Expired returns whether the full amount is released at the given time.
+func (v *VestingSchedule) Expired(time uint64) bool {
+	return time >= v.Start+v.Duration
+}
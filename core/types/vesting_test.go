@@ -0,0 +1,52 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestVestingScheduleLocked(t *testing.T) {
+	schedule := VestingSchedule{Amount: big.NewInt(1000), Start: 100, Cliff: 25, Duration: 100}
+	if err := schedule.Validate(); err != nil {
+		t.Fatalf("valid schedule rejected: %v", err)
+	}
+	tests := []struct {
+		time   uint64
+		locked int64
+	}{
+		{0, 1000},   // before the start
+		{124, 1000}, // before the cliff
+		{125, 750},  // at the cliff, the elapsed part is released at once
+		{150, 500},
+		{199, 10},
+		{200, 0}, // fully released
+		{1000, 0},
+	}
+	for _, tt := range tests {
+		if locked := schedule.Locked(tt.time); locked.Cmp(big.NewInt(tt.locked)) != 0 {
+			t.Errorf("time %d: locked mismatch: have %v, want %v", tt.time, locked, tt.locked)
+		}
+	}
+	if schedule.Expired(199) || !schedule.Expired(200) {
+		t.Errorf("expiry mismatch")
+	}
+}
+
+func TestVestingScheduleValidation(t *testing.T) {
+	tests := []struct {
+		schedule VestingSchedule
+		err      error
+	}{
+		{VestingSchedule{Amount: nil, Duration: 1}, ErrInvalidVestingAmount},
+		{VestingSchedule{Amount: big.NewInt(0), Duration: 1}, ErrInvalidVestingAmount},
+		{VestingSchedule{Amount: big.NewInt(1), Duration: 0}, ErrInvalidVestingDuration},
+		{VestingSchedule{Amount: big.NewInt(1), Cliff: 2, Duration: 1}, ErrInvalidVestingDuration},
+		{VestingSchedule{Amount: big.NewInt(1), Start: ^uint64(0), Duration: 1}, ErrInvalidVestingDuration},
+		{VestingSchedule{Amount: big.NewInt(1), Cliff: 1, Duration: 1}, nil}, // plain time lock
+	}
+	for i, tt := range tests {
+		if err := tt.schedule.Validate(); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
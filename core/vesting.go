@@ -0,0 +1,152 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/rlp"
+)
+
+var (
+	errVestingRecipient   = errors.New("vesting transaction without recipient")
+	errVestingRevokeValue = errors.New("vesting revocation can't transfer value")
+	errNothingToRevoke    = errors.New("no vesting schedule revocable by sender")
+)
+
+// LockedBalance returns the part of the balance of addr that is locked by its
+// vesting schedules at the given time.
+func LockedBalance(db vm.StateDB, addr common.Address, time uint64) *big.Int {
+	locked := new(big.Int)
+	for _, schedule := range db.GetVesting(addr) {
+		locked.Add(locked, schedule.Locked(time))
+	}
+	if balance := db.GetBalance(addr); locked.Cmp(balance) > 0 {
+		locked.Set(balance)
+	}
+	return locked
+}
+
+// SpendableBalance returns the part of the balance of addr that may be spent at
+// the given time, i.e. the balance minus the amount locked by vesting schedules.
+func SpendableBalance(db vm.StateDB, addr common.Address, time uint64) *big.Int {
+	return new(big.Int).Sub(db.GetBalance(addr), LockedBalance(db, addr, time))
+}
+
+// checkSpendable makes sure that neither the gas nor the transferred value are
+// paid from balances locked by vesting schedules. Accounts without locked funds
+// are left to the regular balance checks.
+func (st *StateTransition) checkSpendable() error {
+	var (
+		time  = st.evm.Time.Uint64()
+		from  = st.msg.From()
+		payer = st.payer()
+		gas   = new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.gasPrice)
+	)
+	if payer == from {
+		return checkSpendable(st.state, from, time, new(big.Int).Add(gas, st.value))
+	}
+	if err := checkSpendable(st.state, payer, time, gas); err != nil {
+		return err
+	}
+	return checkSpendable(st.state, from, time, st.value)
+}
+
+// checkSpendable returns ErrLockedBalance if the given amount can only be paid
+// by touching the vesting locked balance of addr.
+func checkSpendable(db vm.StateDB, addr common.Address, time uint64, amount *big.Int) error {
+	locked := LockedBalance(db, addr, time)
+	if locked.Sign() == 0 {
+		return nil
+	}
+	if new(big.Int).Sub(db.GetBalance(addr), locked).Cmp(amount) < 0 {
+		return ErrLockedBalance
+	}
+	return nil
+}
+
+// applyVestingGrant transfers the value of the message to the recipient, locked
+// by the schedule in the message payload.
+func (st *StateTransition) applyVestingGrant() error {
+	if st.msg.To() == nil {
+		return errVestingRecipient
+	}
+	grant := new(types.VestingGrant)
+	if err := rlp.DecodeBytes(st.data, grant); err != nil {
+		return err
+	}
+	var (
+		from     = st.msg.From()
+		to       = st.to()
+		time     = st.evm.Time.Uint64()
+		schedule = types.VestingSchedule{
+			Amount:    new(big.Int).Set(st.value),
+			Start:     grant.Start,
+			Cliff:     grant.Cliff,
+			Duration:  grant.Duration,
+			Revocable: grant.Revocable,
+		}
+	)
+	if grant.Revocable {
+		schedule.Revoker = from
+	}
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if !st.evm.CanTransfer(st.state, from, st.value) {
+		return vm.ErrInsufficientBalance
+	}
+	st.evm.Transfer(st.state, from, to, st.value)
+	addVesting(st.state, to, time, schedule)
+	return nil
+}
+
+// addVesting adds a schedule to the vesting schedules of addr, dropping the ones
+// fully released at the given time.
+func addVesting(db vm.StateDB, addr common.Address, time uint64, schedule types.VestingSchedule) {
+	var schedules []types.VestingSchedule
+	for _, prev := range db.GetVesting(addr) {
+		if !prev.Expired(time) {
+			schedules = append(schedules, prev)
+		}
+	}
+	db.SetVesting(addr, append(schedules, schedule))
+}
+
+// applyVestingRevoke returns the still locked balance of all schedules of the
+// recipient revocable by the sender back to the sender. The already released
+// balance stays with the recipient.
+func (st *StateTransition) applyVestingRevoke() error {
+	if st.msg.To() == nil {
+		return errVestingRecipient
+	}
+	if st.value.Sign() != 0 {
+		return errVestingRevokeValue
+	}
+	var (
+		from      = st.msg.From()
+		to        = st.to()
+		time      = st.evm.Time.Uint64()
+		reclaimed = new(big.Int)
+		kept      []types.VestingSchedule
+	)
+	schedules := st.state.GetVesting(to)
+	for _, schedule := range schedules {
+		if schedule.Revocable && schedule.Revoker == from {
+			reclaimed.Add(reclaimed, schedule.Locked(time))
+			continue
+		}
+		kept = append(kept, schedule)
+	}
+	if len(kept) == len(schedules) {
+		return errNothingToRevoke
+	}
+	if balance := st.state.GetBalance(to); reclaimed.Cmp(balance) > 0 {
+		reclaimed.Set(balance)
+	}
+	st.state.SetVesting(to, kept)
+	st.evm.Transfer(st.state, to, from, reclaimed)
+	return nil
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// Tests that balances locked by vesting schedules can't be moved by contract
+// code, neither by calls nor by destructing the contract.
+func TestVestingLockedTransfer(t *testing.T) {
+	var (
+		vested = common.HexToAddress("0xc0de")
+		bob    = common.HexToAddress("0xb0b")
+	)
+	// The contract self destructs, sending its balance to bob
+	code := append(append([]byte{byte(vm.PUSH20)}, bob[:]...), byte(vm.SELFDESTRUCT))
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(yoobadb.NewMemDatabase()))
+	statedb.SetCode(vested, code)
+	statedb.AddBalance(vested, big.NewInt(1000))
+	statedb.SetVesting(vested, []types.VestingSchedule{{Amount: big.NewInt(600), Start: 100, Duration: 100}})
+
+	newEVM := func(time int64) (*vm.EVM, *state.StateDB) {
+		statedb := statedb.Copy()
+		msg := types.NewMessage(bob, &vested, 0, new(big.Int), 100000, new(big.Int), types.TxTypeTransfer, nil, false)
+		header := &types.Header{Number: big.NewInt(1), Time: big.NewInt(time), GasLimit: 1000000}
+		return vm.NewEVM(NewEVMContext(msg, header, nil, &common.Address{}), statedb, params.TestChainConfig, vm.Config{}), statedb
+	}
+	// Halfway through the schedule, 300 of the balance is still locked
+	evm, _ := newEVM(150)
+	if _, _, err := evm.Call(vm.AccountRef(vested), bob, nil, 100000, big.NewInt(701)); err != vm.ErrInsufficientBalance {
+		t.Errorf("locked balance transfer error mismatch: have %v, want %v", err, vm.ErrInsufficientBalance)
+	}
+	if _, _, err := evm.Call(vm.AccountRef(vested), bob, nil, 100000, big.NewInt(700)); err != nil {
+		t.Errorf("failed to transfer spendable balance: %v", err)
+	}
+	evm, db := newEVM(150)
+	if _, _, err := evm.Call(vm.AccountRef(bob), vested, nil, 100000, new(big.Int)); err != vm.ErrInsufficientBalance {
+		t.Errorf("locked balance self destruct error mismatch: have %v, want %v", err, vm.ErrInsufficientBalance)
+	}
+	if balance := db.GetBalance(bob); balance.Sign() != 0 {
+		t.Errorf("locked balance released by self destruct: %v", balance)
+	}
+	// Once everything is released, the contract may self destruct
+	evm, db = newEVM(200)
+	if _, _, err := evm.Call(vm.AccountRef(bob), vested, nil, 100000, new(big.Int)); err != nil {
+		t.Errorf("failed to self destruct: %v", err)
+	}
+	if balance := db.GetBalance(bob); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("self destruct balance mismatch: have %v, want %v", balance, 1000)
+	}
+}
@@ -16,7 +16,16 @@
 
 package vm
 
-import "testing"
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/params"
+)
 
 func TestMemoryGasCost(t *testing.T) {
 	//size := uint64(math.MaxUint64 - 64)
@@ -34,3 +43,126 @@ func TestMemoryGasCost(t *testing.T) {
 		t.Error("expected error")
 	}
 }
+
+var eip1283Tests = []struct {
+	code     string
+	gasUsed  uint64
+	refund   uint64
+	original byte
+}{
+	{"0x60006000556000600055", 412, 0, 0},
+	{"0x60006000556001600055", 20212, 0, 0},
+	{"0x60016000556000600055", 20212, 19800, 0},
+	{"0x60016000556002600055", 20212, 0, 0},
+	{"0x60016000556001600055", 20212, 0, 0},
+	{"0x60006000556000600055", 5212, 15000, 1},
+	{"0x60006000556001600055", 5212, 4800, 1},
+	{"0x60006000556002600055", 5212, 0, 1},
+	{"0x60026000556000600055", 5212, 15000, 1},
+	{"0x60026000556003600055", 5212, 0, 1},
+	{"0x60026000556001600055", 5212, 4800, 1},
+	{"0x60026000556002600055", 5212, 0, 1},
+	{"0x60016000556000600055", 5212, 15000, 1},
+	{"0x60016000556002600055", 5212, 0, 1},
+	{"0x60016000556001600055", 412, 0, 1},
+	{"0x600160005560006000556001600055", 40218, 19800, 0},
+	{"0x600060005560016000556000600055", 10218, 19800, 1},
+}
+
+// sstoreStateDB is a minimal StateDB tracking a single contract's code,
+// committed and dirty storage and the refund counter.
+type sstoreStateDB struct {
+	NoopStateDB
+
+	code   []byte
+	origin map[common.Hash]common.Hash
+	dirty  map[common.Hash]common.Hash
+	refund uint64
+}
+
+func (db *sstoreStateDB) Exist(common.Address) bool     { return true }
+func (db *sstoreStateDB) GetCode(common.Address) []byte { return db.code }
+func (db *sstoreStateDB) GetCodeHash(common.Address) common.Hash {
+	return crypto.Keccak256Hash(db.code)
+}
+func (db *sstoreStateDB) AddRefund(gas uint64) { db.refund += gas }
+func (db *sstoreStateDB) SubRefund(gas uint64) { db.refund -= gas }
+func (db *sstoreStateDB) GetRefund() uint64    { return db.refund }
+
+func (db *sstoreStateDB) GetCommittedState(addr common.Address, key common.Hash) common.Hash {
+	return db.origin[key]
+}
+
+func (db *sstoreStateDB) GetState(addr common.Address, key common.Hash) common.Hash {
+	if value, ok := db.dirty[key]; ok {
+		return value
+	}
+	return db.origin[key]
+}
+
+func (db *sstoreStateDB) SetState(addr common.Address, key, value common.Hash) {
+	db.dirty[key] = value
+}
+
+// TestEIP1283 checks the net gas metering of SSTORE introduced with the
+// Constantinople fork against the reference vectors.
+func TestEIP1283(t *testing.T) {
+	for i, tt := range eip1283Tests {
+		address := common.BytesToAddress([]byte("contract"))
+
+		statedb := &sstoreStateDB{
+			code:   hexutil.MustDecode(tt.code),
+			origin: map[common.Hash]common.Hash{{}: common.BytesToHash([]byte{tt.original})},
+			dirty:  make(map[common.Hash]common.Hash),
+		}
+
+		config := &params.ChainConfig{
+			ChainId:             big.NewInt(1),
+			ByzantiumBlock:      new(big.Int),
+			ConstantinopleBlock: new(big.Int),
+		}
+		vmctx := Context{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: new(big.Int),
+		}
+		vmenv := NewEVM(vmctx, statedb, config, Config{})
+
+		_, gas, err := vmenv.Call(AccountRef(common.Address{}), address, nil, tt.gasUsed, new(big.Int))
+		if err != nil {
+			t.Errorf("test %d: execution failed: %v", i, err)
+		}
+		if gas != 0 {
+			t.Errorf("test %d: gas used mismatch: have %v, want %v", i, tt.gasUsed-gas, tt.gasUsed)
+		}
+		if refund := vmenv.StateDB.GetRefund(); refund != tt.refund {
+			t.Errorf("test %d: gas refund mismatch: have %v, want %v", i, refund, tt.refund)
+		}
+	}
+}
+
+// TestCreate2Address checks the CREATE2 address derivation against the
+// reference vectors of EIP-1014.
+func TestCreate2Address(t *testing.T) {
+	tests := []struct {
+		origin, salt, code, expected string
+	}{
+		{"0x0000000000000000000000000000000000000000", "0x0000000000000000000000000000000000000000000000000000000000000000", "0x00", "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"},
+		{"0xdeadbeef00000000000000000000000000000000", "0x0000000000000000000000000000000000000000000000000000000000000000", "0x00", "0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3"},
+		{"0xdeadbeef00000000000000000000000000000000", "0x000000000000000000000000feed000000000000000000000000000000000000", "0x00", "0xD04116cDd17beBE565EB2422F2497E06cC1C9833"},
+		{"0x0000000000000000000000000000000000000000", "0x0000000000000000000000000000000000000000000000000000000000000000", "0xdeadbeef", "0x70f2b2914A2a4b783FaEFb75f459A580616Fcb5e"},
+		{"0x00000000000000000000000000000000deadbeef", "0x00000000000000000000000000000000000000000000000000000000cafebabe", "0xdeadbeef", "0x60f3f640a8508fC6a86d45DF051962668E1e8AC7"},
+		{"0x00000000000000000000000000000000deadbeef", "0x00000000000000000000000000000000000000000000000000000000cafebabe", "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef", "0x1d8bfDC5D46DC4f61D6b6115972536eBE6A8854C"},
+		{"0x0000000000000000000000000000000000000000", "0x0000000000000000000000000000000000000000000000000000000000000000", "0x", "0xE33C0C7F7df4809055C3ebA6c09CFe4BaF1BD9e0"},
+	}
+	for i, tt := range tests {
+		origin := common.HexToAddress(tt.origin)
+		salt := common.HexToHash(tt.salt)
+		code := hexutil.MustDecode(tt.code)
+
+		address := crypto.CreateAddress2(origin, salt, code)
+		if expected := common.HexToAddress(tt.expected); !bytes.Equal(address.Bytes(), expected.Bytes()) {
+			t.Errorf("test %d: create2 address mismatch: have %x, want %x", i, address, expected)
+		}
+	}
+}
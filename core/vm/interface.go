@@ -37,14 +37,27 @@ type StateDB interface {
 	GetNonce(common.Address) uint64
 	SetNonce(common.Address, uint64)
 
+	GetMultisig(common.Address) ([]types.MultisigKey, uint64)
+	SetMultisig(common.Address, []types.MultisigKey, uint64)
+
+	GetVesting(common.Address) []types.VestingSchedule
+	SetVesting(common.Address, []types.VestingSchedule)
+
+	GetStake(common.Address) *big.Int
+	SetStake(common.Address, *big.Int)
+	GetResources(common.Address) types.ResourceUsage
+	SetResources(common.Address, types.ResourceUsage)
+
 	GetCodeHash(common.Address) common.Hash
 	GetCode(common.Address) []byte
 	SetCode(common.Address, []byte)
 	GetCodeSize(common.Address) int
 
 	AddRefund(uint64)
+	SubRefund(uint64)
 	GetRefund() uint64
 
+	GetCommittedState(common.Address, common.Hash) common.Hash
 	GetState(common.Address, common.Hash) common.Hash
 	SetState(common.Address, common.Hash, common.Hash)
 
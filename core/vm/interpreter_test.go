@@ -0,0 +1,43 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/yooba-team/yooba/params"
+)
+
+// Tests that the instruction set is picked by the fork blocks of the chain and
+// that chains without fork blocks keep the instructions they ran from genesis.
+func TestInterpreterForkInstructions(t *testing.T) {
+	tests := []struct {
+		byzantium, constantinople *big.Int
+		number                    int64
+		valid, invalid            []OpCode
+	}{
+		// Chains without fork blocks (mainnet, testnet, rinkeby)
+		{nil, nil, 0, []OpCode{REVERT, STATICCALL, RETURNDATASIZE, RETURNDATACOPY, SHL, SHR, SAR}, []OpCode{EXTCODEHASH, CREATE2}},
+		{nil, nil, 1000000, []OpCode{REVERT, STATICCALL, SHL, SHR, SAR}, []OpCode{EXTCODEHASH, CREATE2}},
+
+		// Chains scheduling the forks explicitly
+		{big.NewInt(0), big.NewInt(5), 4, []OpCode{REVERT, STATICCALL}, []OpCode{SHL, EXTCODEHASH, CREATE2}},
+		{big.NewInt(0), big.NewInt(5), 5, []OpCode{REVERT, STATICCALL, SHL, SHR, SAR, EXTCODEHASH, CREATE2}, nil},
+		{nil, big.NewInt(5), 4, []OpCode{REVERT, SHL}, []OpCode{EXTCODEHASH, CREATE2}},
+		{nil, big.NewInt(5), 5, []OpCode{REVERT, SHL, EXTCODEHASH, CREATE2}, nil},
+	}
+	for i, tt := range tests {
+		config := &params.ChainConfig{ChainId: big.NewInt(1), ByzantiumBlock: tt.byzantium, ConstantinopleBlock: tt.constantinople}
+		evm := NewEVM(Context{BlockNumber: big.NewInt(tt.number)}, nil, config, Config{})
+
+		for _, op := range tt.valid {
+			if !evm.interpreter.cfg.JumpTable[op].valid {
+				t.Errorf("test %d: %v not valid at block %d", i, op, tt.number)
+			}
+		}
+		for _, op := range tt.invalid {
+			if evm.interpreter.cfg.JumpTable[op].valid {
+				t.Errorf("test %d: %v valid at block %d", i, op, tt.number)
+			}
+		}
+	}
+}
@@ -51,12 +51,22 @@ func (NoopStateDB) AddBalance(common.Address, *big.Int)
 func (NoopStateDB) GetBalance(common.Address) *big.Int                                 { return nil }
 func (NoopStateDB) GetNonce(common.Address) uint64                                     { return 0 }
 func (NoopStateDB) SetNonce(common.Address, uint64)                                    {}
+func (NoopStateDB) GetMultisig(common.Address) ([]types.MultisigKey, uint64)           { return nil, 0 }
+func (NoopStateDB) SetMultisig(common.Address, []types.MultisigKey, uint64)            {}
+func (NoopStateDB) GetVesting(common.Address) []types.VestingSchedule                  { return nil }
+func (NoopStateDB) SetVesting(common.Address, []types.VestingSchedule)                 {}
+func (NoopStateDB) GetStake(common.Address) *big.Int                                   { return nil }
+func (NoopStateDB) SetStake(common.Address, *big.Int)                                  {}
+func (NoopStateDB) GetResources(common.Address) types.ResourceUsage                    { return types.ResourceUsage{} }
+func (NoopStateDB) SetResources(common.Address, types.ResourceUsage)                   {}
 func (NoopStateDB) GetCodeHash(common.Address) common.Hash                             { return common.Hash{} }
 func (NoopStateDB) GetCode(common.Address) []byte                                      { return nil }
 func (NoopStateDB) SetCode(common.Address, []byte)                                     {}
 func (NoopStateDB) GetCodeSize(common.Address) int                                     { return 0 }
 func (NoopStateDB) AddRefund(uint64)                                                   {}
+func (NoopStateDB) SubRefund(uint64)                                                   {}
 func (NoopStateDB) GetRefund() uint64                                                  { return 0 }
+func (NoopStateDB) GetCommittedState(common.Address, common.Hash) common.Hash          { return common.Hash{} }
 func (NoopStateDB) GetState(common.Address, common.Hash) common.Hash                   { return common.Hash{} }
 func (NoopStateDB) SetState(common.Address, common.Hash, common.Hash)                  {}
 func (NoopStateDB) Suicide(common.Address) bool                                        { return false }
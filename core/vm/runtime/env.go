@@ -24,7 +24,7 @@ import (
 
 func NewEnv(cfg *Config) *vm.EVM {
 	context := vm.Context{
-		CanTransfer: core.CanTransfer,
+		CanTransfer: core.CanTransferAt(cfg.Time.Uint64()),
 		Transfer:    core.Transfer,
 		GetHash:     func(uint64) common.Hash { return common.Hash{} },
 
@@ -0,0 +1,967 @@
+// Package graphql provides a GraphQL interface to Yooba node data.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/internal/ethapi"
+	"github.com/yooba-team/yooba/rpc"
+)
+
+// maxBlocks is the maximum number of blocks a single blocks query may return.
+const maxBlocks = 1024
+
+var errBlockNotFound = errors.New("block not found")
+
+// Long is a 64 bit unsigned integer, such as a block number or an amount of gas.
+type Long uint64
+
+// ImplementsGraphQLType returns true if Long implements the provided GraphQL type.
+func (l Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		value, err := strconv.ParseUint(input, 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid Long %q: %v", input, err)
+		}
+		*l = Long(value)
+	case int32:
+		if input < 0 {
+			return fmt.Errorf("negative Long %d", input)
+		}
+		*l = Long(input)
+	case float64:
+		if input < 0 || input != float64(uint64(input)) {
+			return fmt.Errorf("invalid Long %v", input)
+		}
+		*l = Long(input)
+	default:
+		return fmt.Errorf("unexpected type %T for Long", input)
+	}
+	return nil
+}
+
+// blockNumberOr returns the block number given as a query argument, or the
+// fallback if none was given.
+func blockNumberOr(number *Long, fallback rpc.BlockNumber) rpc.BlockNumber {
+	if number == nil {
+		return fallback
+	}
+	return rpc.BlockNumber(*number)
+}
+
+// BlockNumberArgs are the arguments of the fields resolving an account at a
+// block, by default the latest one.
+type BlockNumberArgs struct {
+	Block *Long
+}
+
+// Account represents a Yooba account at a particular block.
+type Account struct {
+	backend     ethapi.Backend
+	address     common.Address
+	blockNumber rpc.BlockNumber
+}
+
+// getState fetches the StateDB object for an account.
+func (a *Account) getState(ctx context.Context) (*state.StateDB, error) {
+	state, _, err := a.backend.StateAndHeaderByNumber(ctx, a.blockNumber)
+	if state == nil && err == nil {
+		err = errBlockNotFound
+	}
+	return state, err
+}
+
+// getAccount fetches the profile of an account, nil if it doesn't exist.
+func (a *Account) getAccount(ctx context.Context) (*state.Account, error) {
+	state, err := a.getState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return state.GetAccount(a.address), nil
+}
+
+func (a *Account) Address(ctx context.Context) (common.Address, error) {
+	return a.address, nil
+}
+
+func (a *Account) Balance(ctx context.Context) (hexutil.Big, error) {
+	state, err := a.getState(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*state.GetBalance(a.address)), nil
+}
+
+func (a *Account) TransactionCount(ctx context.Context) (Long, error) {
+	state, err := a.getState(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(state.GetNonce(a.address)), nil
+}
+
+func (a *Account) Code(ctx context.Context) (hexutil.Bytes, error) {
+	state, err := a.getState(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(state.GetCode(a.address)), nil
+}
+
+func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash }) (common.Hash, error) {
+	state, err := a.getState(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return state.GetState(a.address, args.Slot), nil
+}
+
+func (a *Account) Homepage(ctx context.Context) (string, error) {
+	account, err := a.getAccount(ctx)
+	if account == nil {
+		return "", err
+	}
+	return account.Homepage, nil
+}
+
+func (a *Account) Name(ctx context.Context) (string, error) {
+	account, err := a.getAccount(ctx)
+	if account == nil {
+		return "", err
+	}
+	return account.AccountName, nil
+}
+
+func (a *Account) IsStore(ctx context.Context) (bool, error) {
+	account, err := a.getAccount(ctx)
+	if account == nil {
+		return false, err
+	}
+	return account.IsStore, nil
+}
+
+func (a *Account) Score(ctx context.Context) (int32, error) {
+	account, err := a.getAccount(ctx)
+	if account == nil {
+		return 0, err
+	}
+	return int32(account.Score), nil
+}
+
+func (a *Account) GoodsRoot(ctx context.Context) (common.Hash, error) {
+	account, err := a.getAccount(ctx)
+	if account == nil {
+		return common.Hash{}, err
+	}
+	return account.Goodsurl, nil
+}
+
+func (a *Account) OrdersRoot(ctx context.Context) (common.Hash, error) {
+	account, err := a.getAccount(ctx)
+	if account == nil {
+		return common.Hash{}, err
+	}
+	return account.Ordersurl, nil
+}
+
+func (a *Account) HistoryRoot(ctx context.Context) (common.Hash, error) {
+	account, err := a.getAccount(ctx)
+	if account == nil {
+		return common.Hash{}, err
+	}
+	return account.Historyurl, nil
+}
+
+func (a *Account) Stake(ctx context.Context) (hexutil.Big, error) {
+	state, err := a.getState(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*bigOrZero(state.GetStake(a.address))), nil
+}
+
+// Log represents an individual log message. All arguments are mandatory.
+type Log struct {
+	backend     ethapi.Backend
+	transaction *Transaction
+	log         *types.Log
+}
+
+func (l *Log) Transaction(ctx context.Context) *Transaction {
+	return l.transaction
+}
+
+func (l *Log) Account(ctx context.Context, args BlockNumberArgs) *Account {
+	return &Account{
+		backend:     l.backend,
+		address:     l.log.Address,
+		blockNumber: blockNumberOr(args.Block, rpc.LatestBlockNumber),
+	}
+}
+
+func (l *Log) Index(ctx context.Context) int32 {
+	return int32(l.log.Index)
+}
+
+func (l *Log) Topics(ctx context.Context) []common.Hash {
+	return l.log.Topics
+}
+
+func (l *Log) Data(ctx context.Context) hexutil.Bytes {
+	return hexutil.Bytes(l.log.Data)
+}
+
+// Transaction represents a Yooba transaction, resolved lazily from its hash or
+// the block containing it.
+type Transaction struct {
+	backend ethapi.Backend
+	hash    common.Hash
+	index   uint64
+
+	lock  sync.Mutex // Guards the lazily resolved fields below
+	tx    *types.Transaction
+	block *Block
+}
+
+// resolve returns the internal transaction object, fetching it if needed. It
+// returns nil if the transaction is neither in the chain nor in the pool.
+func (t *Transaction) resolve(ctx context.Context) (*types.Transaction, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.tx != nil {
+		return t.tx, nil
+	}
+	// Transactions of a known block are picked from its body
+	if t.block != nil {
+		block, err := t.block.resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if txs := block.Transactions(); t.index < uint64(len(txs)) {
+			t.tx = txs[t.index]
+		}
+		return t.tx, nil
+	}
+	// Otherwise look it up in the chain, falling back to the pool
+	if tx, blockHash, _, index := rawdb.ReadTransaction(t.backend.ChainDb(), t.hash); tx != nil {
+		t.tx, t.index = tx, index
+		t.block = &Block{backend: t.backend, hash: blockHash}
+		return t.tx, nil
+	}
+	t.tx = t.backend.GetPoolTransaction(t.hash)
+	return t.tx, nil
+}
+
+// getBlock returns the block containing the transaction, nil if it's pending.
+func (t *Transaction) getBlock(ctx context.Context) (*Block, error) {
+	if _, err := t.resolve(ctx); err != nil {
+		return nil, err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.block, nil
+}
+
+// getReceipt returns the receipt of the transaction, nil if it's pending.
+func (t *Transaction) getReceipt(ctx context.Context) (*types.Receipt, error) {
+	block, err := t.getBlock(ctx)
+	if block == nil {
+		return nil, err
+	}
+	receipts, err := block.resolveReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.index >= uint64(len(receipts)) {
+		return nil, nil
+	}
+	return receipts[t.index], nil
+}
+
+// account returns the resolver of an account referenced by the transaction.
+func (t *Transaction) account(address common.Address, args BlockNumberArgs) *Account {
+	return &Account{
+		backend:     t.backend,
+		address:     address,
+		blockNumber: blockNumberOr(args.Block, rpc.LatestBlockNumber),
+	}
+}
+
+func (t *Transaction) Hash(ctx context.Context) common.Hash {
+	return t.hash
+}
+
+func (t *Transaction) InputData(ctx context.Context) (hexutil.Bytes, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(tx.Data()), nil
+}
+
+func (t *Transaction) Gas(ctx context.Context) (Long, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return 0, err
+	}
+	return Long(tx.Gas()), nil
+}
+
+func (t *Transaction) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*tx.GasPrice()), nil
+}
+
+func (t *Transaction) Value(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*tx.Value()), nil
+}
+
+func (t *Transaction) Nonce(ctx context.Context) (Long, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return 0, err
+	}
+	return Long(tx.Nonce()), nil
+}
+
+func (t *Transaction) Type(ctx context.Context) (int32, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return 0, err
+	}
+	return int32(tx.Type()), nil
+}
+
+func (t *Transaction) To(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || tx.To() == nil {
+		return nil, err
+	}
+	return t.account(*tx.To(), args), nil
+}
+
+func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+	if err != nil {
+		return nil, err
+	}
+	return t.account(from, args), nil
+}
+
+func (t *Transaction) FeePayer(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || tx.FeePayer() == nil {
+		return nil, err
+	}
+	return t.account(*tx.FeePayer(), args), nil
+}
+
+func (t *Transaction) Block(ctx context.Context) (*Block, error) {
+	return t.getBlock(ctx)
+}
+
+func (t *Transaction) Index(ctx context.Context) (*int32, error) {
+	block, err := t.getBlock(ctx)
+	if block == nil {
+		return nil, err
+	}
+	index := int32(t.index)
+	return &index, nil
+}
+
+func (t *Transaction) Status(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil || len(receipt.PostState) != 0 {
+		return nil, err
+	}
+	status := Long(receipt.Status)
+	return &status, nil
+}
+
+func (t *Transaction) GasUsed(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	gasUsed := Long(receipt.GasUsed)
+	return &gasUsed, nil
+}
+
+func (t *Transaction) CumulativeGasUsed(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	gasUsed := Long(receipt.CumulativeGasUsed)
+	return &gasUsed, nil
+}
+
+func (t *Transaction) CreatedContract(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil || receipt.ContractAddress == (common.Address{}) {
+		return nil, err
+	}
+	return t.account(receipt.ContractAddress, args), nil
+}
+
+func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := make([]*Log, 0, len(receipt.Logs))
+	for _, log := range receipt.Logs {
+		ret = append(ret, &Log{backend: t.backend, transaction: t, log: log})
+	}
+	return &ret, nil
+}
+
+// Block represents a Yooba block, resolved lazily from its number or hash.
+type Block struct {
+	backend ethapi.Backend
+	num     *rpc.BlockNumber
+	hash    common.Hash
+
+	lock     sync.Mutex // Guards the lazily resolved fields below
+	header   *types.Header
+	block    *types.Block
+	receipts []*types.Receipt
+}
+
+// resolve returns the internal block object, fetching it if needed.
+func (b *Block) resolve(ctx context.Context) (*types.Block, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.block != nil {
+		return b.block, nil
+	}
+	var err error
+	if b.hash != (common.Hash{}) {
+		b.block, err = b.backend.GetBlock(ctx, b.hash)
+	} else {
+		b.block, err = b.backend.BlockByNumber(ctx, *b.num)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.block == nil {
+		return nil, errBlockNotFound
+	}
+	b.header, b.hash = b.block.Header(), b.block.Hash()
+	return b.block, nil
+}
+
+// resolveHeader returns the internal header object, fetching only the header
+// if the block is requested by number.
+func (b *Block) resolveHeader(ctx context.Context) (*types.Header, error) {
+	b.lock.Lock()
+	header, hash := b.header, b.hash
+	b.lock.Unlock()
+
+	switch {
+	case header != nil:
+		return header, nil
+	case hash != (common.Hash{}):
+		block, err := b.resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return block.Header(), nil
+	}
+	header, err := b.backend.HeaderByNumber(ctx, *b.num)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errBlockNotFound
+	}
+	b.lock.Lock()
+	b.header = header
+	b.lock.Unlock()
+
+	return header, nil
+}
+
+// resolveReceipts returns the receipts of the block, fetching them if needed.
+func (b *Block) resolveReceipts(ctx context.Context) ([]*types.Receipt, error) {
+	hash, err := b.Hash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.receipts == nil {
+		if b.receipts, err = b.backend.GetReceipts(ctx, hash); err != nil {
+			return nil, err
+		}
+	}
+	return b.receipts, nil
+}
+
+func (b *Block) Number(ctx context.Context) (Long, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(header.Number.Uint64()), nil
+}
+
+func (b *Block) Hash(ctx context.Context) (common.Hash, error) {
+	b.lock.Lock()
+	hash := b.hash
+	b.lock.Unlock()
+
+	if hash == (common.Hash{}) {
+		header, err := b.resolveHeader(ctx)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		hash = header.Hash()
+
+		b.lock.Lock()
+		b.hash = hash
+		b.lock.Unlock()
+	}
+	return hash, nil
+}
+
+func (b *Block) GasLimit(ctx context.Context) (Long, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(header.GasLimit), nil
+}
+
+func (b *Block) GasUsed(ctx context.Context) (Long, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(header.GasUsed), nil
+}
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header.Number.Sign() == 0 {
+		return nil, err
+	}
+	num := rpc.BlockNumber(header.Number.Uint64() - 1)
+	return &Block{backend: b.backend, num: &num, hash: header.ParentHash}, nil
+}
+
+func (b *Block) Nonce(ctx context.Context) (hexutil.Bytes, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(header.Nonce[:]), nil
+}
+
+func (b *Block) TransactionsRoot(ctx context.Context) (common.Hash, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.TxHash, nil
+}
+
+func (b *Block) StateRoot(ctx context.Context) (common.Hash, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Root, nil
+}
+
+func (b *Block) ReceiptsRoot(ctx context.Context) (common.Hash, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.ReceiptHash, nil
+}
+
+func (b *Block) Miner(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		backend:     b.backend,
+		address:     header.Coinbase,
+		blockNumber: blockNumberOr(args.Block, rpc.LatestBlockNumber),
+	}, nil
+}
+
+func (b *Block) ExtraData(ctx context.Context) (hexutil.Bytes, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(header.Extra), nil
+}
+
+func (b *Block) Timestamp(ctx context.Context) (hexutil.Big, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*header.Time), nil
+}
+
+func (b *Block) LogsBloom(ctx context.Context) (hexutil.Bytes, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(header.Bloom.Bytes()), nil
+}
+
+func (b *Block) TransactionCount(ctx context.Context) (*int32, error) {
+	block, err := b.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	count := int32(len(block.Transactions()))
+	return &count, nil
+}
+
+func (b *Block) Transactions(ctx context.Context) (*[]*Transaction, error) {
+	block, err := b.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Transaction, 0, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		ret = append(ret, &Transaction{backend: b.backend, hash: tx.Hash(), tx: tx, block: b, index: uint64(i)})
+	}
+	return &ret, nil
+}
+
+func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) (*Transaction, error) {
+	block, err := b.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if args.Index < 0 || int(args.Index) >= len(txs) {
+		return nil, nil
+	}
+	tx := txs[args.Index]
+	return &Transaction{backend: b.backend, hash: tx.Hash(), tx: tx, block: b, index: uint64(args.Index)}, nil
+}
+
+// BlockFilterCriteria are the log filter criteria applied to a single block.
+type BlockFilterCriteria struct {
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+	Topics    *[][]common.Hash  // restricts matches to particular event topics, see filterLogs
+}
+
+// criteria unwraps the optional filter criteria.
+func (c *BlockFilterCriteria) criteria() ([]common.Address, [][]common.Hash) {
+	var (
+		addresses []common.Address
+		topics    [][]common.Hash
+	)
+	if c.Addresses != nil {
+		addresses = *c.Addresses
+	}
+	if c.Topics != nil {
+		topics = *c.Topics
+	}
+	return addresses, topics
+}
+
+// logs returns the logs of the block matching the filter criteria.
+func (b *Block) logs(ctx context.Context, addresses []common.Address, topics [][]common.Hash) ([]*Log, error) {
+	receipts, err := b.resolveReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ret []*Log
+	for i, receipt := range receipts {
+		var tx *Transaction
+		for _, log := range filterLogs(receipt.Logs, addresses, topics) {
+			if tx == nil {
+				tx = &Transaction{backend: b.backend, hash: receipt.TxHash, block: b, index: uint64(i)}
+			}
+			ret = append(ret, &Log{backend: b.backend, transaction: tx, log: log})
+		}
+	}
+	return ret, nil
+}
+
+func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
+	addresses, topics := args.Filter.criteria()
+	return b.logs(ctx, addresses, topics)
+}
+
+func (b *Block) Account(ctx context.Context, args struct{ Address common.Address }) (*Account, error) {
+	number, err := b.Number(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{backend: b.backend, address: args.Address, blockNumber: rpc.BlockNumber(number)}, nil
+}
+
+// Pending represents the pending state of the chain.
+type Pending struct {
+	backend ethapi.Backend
+}
+
+func (p *Pending) TransactionCount(ctx context.Context) (int32, error) {
+	txs, err := p.backend.GetPoolTransactions()
+	return int32(len(txs)), err
+}
+
+func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
+	txs, err := p.backend.GetPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Transaction, 0, len(txs))
+	for _, tx := range txs {
+		ret = append(ret, &Transaction{backend: p.backend, hash: tx.Hash(), tx: tx})
+	}
+	return &ret, nil
+}
+
+func (p *Pending) Account(ctx context.Context, args struct{ Address common.Address }) *Account {
+	return &Account{backend: p.backend, address: args.Address, blockNumber: rpc.PendingBlockNumber}
+}
+
+// Resolver is the top-level object in the GraphQL hierarchy.
+type Resolver struct {
+	backend ethapi.Backend
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *Long
+	Hash   *common.Hash
+}) (*Block, error) {
+	var block *Block
+	switch {
+	case args.Hash != nil:
+		block = &Block{backend: r.backend, hash: *args.Hash}
+	default:
+		num := blockNumberOr(args.Number, rpc.LatestBlockNumber)
+		block = &Block{backend: r.backend, num: &num}
+	}
+	// Report missing blocks as null rather than failing the query
+	if _, err := block.resolveHeader(ctx); err != nil {
+		if err == errBlockNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return block, nil
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From Long
+	To   *Long
+}) ([]*Block, error) {
+	to := args.To
+	if to == nil {
+		header, err := r.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		latest := Long(header.Number.Uint64())
+		to = &latest
+	}
+	if *to < args.From {
+		return []*Block{}, nil
+	}
+	if *to-args.From >= maxBlocks {
+		return nil, fmt.Errorf("block range too large, at most %d blocks can be queried", maxBlocks)
+	}
+	ret := make([]*Block, 0, *to-args.From+1)
+	for i := args.From; i <= *to; i++ {
+		num := rpc.BlockNumber(i)
+		ret = append(ret, &Block{backend: r.backend, num: &num})
+	}
+	return ret, nil
+}
+
+func (r *Resolver) Pending(ctx context.Context) *Pending {
+	return &Pending{r.backend}
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
+	tx := &Transaction{backend: r.backend, hash: args.Hash}
+	if resolved, err := tx.resolve(ctx); err != nil || resolved == nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// FilterCriteria are the log filter criteria applied to a range of blocks.
+type FilterCriteria struct {
+	FromBlock *Long             // beginning of the queried range, nil means latest block
+	ToBlock   *Long             // end of the range, nil means latest block
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+	Topics    *[][]common.Hash  // restricts matches to particular event topics, see filterLogs
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	filter := BlockFilterCriteria{Addresses: args.Filter.Addresses, Topics: args.Filter.Topics}
+	addresses, topics := filter.criteria()
+
+	latest, err := r.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	from, to := latest.Number.Uint64(), latest.Number.Uint64()
+	if args.Filter.FromBlock != nil {
+		from = uint64(*args.Filter.FromBlock)
+	}
+	if args.Filter.ToBlock != nil {
+		to = uint64(*args.Filter.ToBlock)
+	}
+	// Only fetch the receipts of the blocks whose bloom filter matches
+	var ret []*Log
+	for number := from; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		header, err := r.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			break
+		}
+		if !bloomFilter(header.Bloom, addresses, topics) {
+			continue
+		}
+		block := &Block{backend: r.backend, hash: header.Hash(), header: header}
+		logs, err := block.logs(ctx, addresses, topics)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, logs...)
+	}
+	if ret == nil {
+		ret = []*Log{}
+	}
+	return ret, nil
+}
+
+func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	price, err := r.backend.SuggestPrice(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*price), nil
+}
+
+func (r *Resolver) ProtocolVersion(ctx context.Context) (int32, error) {
+	return int32(r.backend.ProtocolVersion()), nil
+}
+
+// filterLogs returns the logs created by one of the addresses, if any, whose
+// topics match the given ones position by position. Empty positions match any
+// topic, others any of the topics they list.
+func filterLogs(logs []*types.Log, addresses []common.Address, topics [][]common.Hash) []*types.Log {
+	var ret []*types.Log
+Logs:
+	for _, log := range logs {
+		if len(addresses) > 0 && !includes(addresses, log.Address) {
+			continue
+		}
+		if len(topics) > len(log.Topics) {
+			continue
+		}
+		for i, sub := range topics {
+			match := len(sub) == 0
+			for _, topic := range sub {
+				if log.Topics[i] == topic {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue Logs
+			}
+		}
+		ret = append(ret, log)
+	}
+	return ret
+}
+
+// bloomFilter returns whether a block with the given bloom may contain logs
+// matching the filter criteria.
+func bloomFilter(bloom types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		var included bool
+		for _, addr := range addresses {
+			if types.BloomLookup(bloom, addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, sub := range topics {
+		included := len(sub) == 0
+		for _, topic := range sub {
+			if types.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
+
+func includes(addresses []common.Address, a common.Address) bool {
+	for _, addr := range addresses {
+		if addr == a {
+			return true
+		}
+	}
+	return false
+}
+
+// bigOrZero returns the integer, zero if nil.
+func bigOrZero(n *big.Int) *big.Int {
+	if n == nil {
+		return new(big.Int)
+	}
+	return n
+}
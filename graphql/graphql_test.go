@@ -0,0 +1,269 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/internal/ethapi"
+	"github.com/yooba-team/yooba/rpc"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testStore   = common.HexToAddress("0x0000000000000000000000000000000000000777")
+	testTopic   = common.HexToHash("0x01")
+	testChainID = big.NewInt(1)
+)
+
+// testBackend serves a two block chain, only implementing the parts of the
+// backend the resolvers use.
+type testBackend struct {
+	ethapi.Backend
+
+	db       *yoobadb.MemDatabase
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+	state    *state.StateDB
+	pool     types.Transactions
+}
+
+func newTestBackend(t *testing.T) *testBackend {
+	db := yoobadb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetBalance(testAddr, big.NewInt(1000000))
+	statedb.SetNonce(testAddr, 1)
+
+	store := statedb.GetOrNewStateObject(testStore)
+	store.SetHomepage("https://store.example")
+	store.SetStoreStatus(true)
+	store.SetScore(5)
+	store.SetGoodsurl(common.HexToHash("0x600d"))
+	store.SetOrdersurl(common.HexToHash("0x0dd5"))
+
+	signer := types.NewEIP155Signer(testChainID)
+	tx, err := types.SignTx(types.NewTransaction(0, testStore, big.NewInt(10), 50000, big.NewInt(1), types.TxTypeTransfer, nil), signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := types.SignTx(types.NewTransaction(1, testStore, big.NewInt(20), 50000, big.NewInt(1), types.TxTypeTransfer, nil), signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := types.NewReceipt(nil, false, 21000)
+	receipt.TxHash, receipt.GasUsed = tx.Hash(), 21000
+	receipt.Logs = []*types.Log{{Address: testStore, Topics: []common.Hash{testTopic}, Data: []byte{0x42}, TxHash: tx.Hash()}}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+	genesis := types.NewBlock(&types.Header{Number: big.NewInt(0), Time: big.NewInt(0)}, nil, nil)
+	block := types.NewBlock(&types.Header{
+		ParentHash: genesis.Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   1000000,
+		GasUsed:    21000,
+		Time:       big.NewInt(10),
+		Coinbase:   testAddr,
+		Extra:      []byte("yooba"),
+	}, []*types.Transaction{tx}, []*types.Receipt{receipt})
+
+	for _, b := range []*types.Block{genesis, block} {
+		rawdb.WriteBlock(db, b)
+		rawdb.WriteCanonicalHash(db, b.Hash(), b.NumberU64())
+		rawdb.WriteTxLookupEntries(db, b)
+	}
+	return &testBackend{
+		db:       db,
+		blocks:   []*types.Block{genesis, block},
+		receipts: map[common.Hash]types.Receipts{genesis.Hash(): {}, block.Hash(): {receipt}},
+		state:    statedb,
+		pool:     types.Transactions{pending},
+	}
+}
+
+func (b *testBackend) blockByNumber(number rpc.BlockNumber) *types.Block {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.blocks[len(b.blocks)-1]
+	}
+	if int(number) < len(b.blocks) {
+		return b.blocks[number]
+	}
+	return nil
+}
+
+func (b *testBackend) ChainDb() yoobadb.Database { return b.db }
+func (b *testBackend) ProtocolVersion() int      { return 63 }
+
+func (b *testBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(18), nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if block := b.blockByNumber(number); block != nil {
+		return block.Header(), nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	return b.blockByNumber(number), nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	block := b.blockByNumber(number)
+	if block == nil {
+		return nil, nil, nil
+	}
+	return b.state, block.Header(), nil
+}
+
+func (b *testBackend) GetPoolTransactions() (types.Transactions, error) {
+	return b.pool, nil
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	for _, tx := range b.pool {
+		if tx.Hash() == hash {
+			return tx
+		}
+	}
+	return nil
+}
+
+func TestBuildSchema(t *testing.T) {
+	// Make sure the schema can be parsed and matched up to the object model.
+	if _, err := newHandler(nil); err != nil {
+		t.Errorf("Could not construct GraphQL handler: %v", err)
+	}
+}
+
+// query posts a GraphQL query to the handler, returning the decoded response.
+func query(t *testing.T, handler http.Handler, query string) map[string]interface{} {
+	body, _ := json.Marshal(map[string]string{"query": query})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/", bytes.NewReader(body)))
+
+	var result struct {
+		Data   map[string]interface{}
+		Errors []struct{ Message string }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("invalid response %s: %v", rec.Body, err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("query failed: %v", result.Errors)
+	}
+	return result.Data
+}
+
+// get follows a path of fields through a decoded response.
+func get(data interface{}, path ...interface{}) interface{} {
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			data = data.(map[string]interface{})[step]
+		case int:
+			data = data.([]interface{})[step]
+		}
+	}
+	return data
+}
+
+func TestQueries(t *testing.T) {
+	backend := newTestBackend(t)
+	handler, err := newHandler(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, tx := backend.blocks[1], backend.blocks[1].Transactions()[0]
+
+	// Blocks resolve their transactions, receipts and accounts
+	data := query(t, handler, `{ block { number hash parent { number } miner { address balance }
+		transactions { hash index from { address transactionCount } to { address } status gasUsed logs { data } } } }`)
+	if number := get(data, "block", "number"); number != float64(1) {
+		t.Errorf("block number mismatch: have %v, want 1", number)
+	}
+	if hash := get(data, "block", "hash"); hash != block.Hash().Hex() {
+		t.Errorf("block hash mismatch: have %v, want %s", hash, block.Hash().Hex())
+	}
+	if parent := get(data, "block", "parent", "number"); parent != float64(0) {
+		t.Errorf("parent number mismatch: have %v, want 0", parent)
+	}
+	if balance := get(data, "block", "miner", "balance"); balance != "0xf4240" {
+		t.Errorf("miner balance mismatch: have %v, want 0xf4240", balance)
+	}
+	if from := get(data, "block", "transactions", 0, "from", "address"); !strings.EqualFold(from.(string), testAddr.Hex()) {
+		t.Errorf("sender mismatch: have %v, want %s", from, testAddr.Hex())
+	}
+	if status := get(data, "block", "transactions", 0, "status"); status != float64(1) {
+		t.Errorf("status mismatch: have %v, want 1", status)
+	}
+	if logData := get(data, "block", "transactions", 0, "logs", 0, "data"); logData != "0x42" {
+		t.Errorf("log data mismatch: have %v, want 0x42", logData)
+	}
+	// Transactions are found by hash, both mined and pending ones
+	data = query(t, handler, `{ transaction(hash: "`+tx.Hash().Hex()+`") { block { number } gasUsed cumulativeGasUsed } }`)
+	if number := get(data, "transaction", "block", "number"); number != float64(1) {
+		t.Errorf("transaction block mismatch: have %v, want 1", number)
+	}
+	data = query(t, handler, `{ transaction(hash: "`+backend.pool[0].Hash().Hex()+`") { nonce block { number } status } }`)
+	if block := get(data, "transaction", "block"); block != nil {
+		t.Errorf("pending transaction has block %v", block)
+	}
+	if nonce := get(data, "transaction", "nonce"); nonce != float64(1) {
+		t.Errorf("pending nonce mismatch: have %v, want 1", nonce)
+	}
+	// Unknown blocks and transactions resolve to null
+	data = query(t, handler, `{ block(number: 100) { hash } transaction(hash: "0x0000000000000000000000000000000000000000000000000000000000000001") { hash } }`)
+	if data["block"] != nil || data["transaction"] != nil {
+		t.Errorf("unknown objects resolved: %v", data)
+	}
+	// Logs are filtered by address and topic over block ranges
+	data = query(t, handler, `{ logs(filter: {fromBlock: 0, addresses: ["`+testStore.Hex()+`"], topics: [["`+testTopic.Hex()+`"]]}) { data transaction { hash } } }`)
+	if logs := get(data, "logs").([]interface{}); len(logs) != 1 {
+		t.Fatalf("log count mismatch: have %d, want 1", len(logs))
+	}
+	if hash := get(data, "logs", 0, "transaction", "hash"); hash != tx.Hash().Hex() {
+		t.Errorf("log transaction mismatch: have %v, want %s", hash, tx.Hash().Hex())
+	}
+	data = query(t, handler, `{ logs(filter: {fromBlock: 0, topics: [["`+common.HexToHash("0x02").Hex()+`"]]}) { data } }`)
+	if logs := get(data, "logs").([]interface{}); len(logs) != 0 {
+		t.Errorf("mismatching logs returned: %v", logs)
+	}
+	// Accounts expose the Yooba profile fields
+	data = query(t, handler, `{ pending { transactionCount account(address: "`+testStore.Hex()+`") { homepage isStore score goodsRoot ordersRoot } } }`)
+	if count := get(data, "pending", "transactionCount"); count != float64(1) {
+		t.Errorf("pending count mismatch: have %v, want 1", count)
+	}
+	account := get(data, "pending", "account").(map[string]interface{})
+	if account["homepage"] != "https://store.example" || account["isStore"] != true || account["score"] != float64(5) {
+		t.Errorf("profile mismatch: %v", account)
+	}
+	if account["goodsRoot"] != common.HexToHash("0x600d").Hex() || account["ordersRoot"] != common.HexToHash("0x0dd5").Hex() {
+		t.Errorf("goods and orders roots mismatch: %v", account)
+	}
+}
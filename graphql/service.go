@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/yooba-team/yooba/internal/ethapi"
+	"github.com/yooba-team/yooba/les"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/node"
+	"github.com/yooba-team/yooba/p2p"
+	"github.com/yooba-team/yooba/rpc"
+	"github.com/yooba-team/yooba/yoo"
+)
+
+// Service encapsulates a GraphQL service.
+type Service struct {
+	endpoint string         // The host:port endpoint for this service
+	cors     []string       // Allowed CORS domains
+	vhosts   []string       // Recognised vhosts
+	backend  ethapi.Backend // The backend that queries will operate on
+	auth     *rpc.JWTAuth   // Authenticator of the queries (nil = unauthenticated)
+	handler  http.Handler   // The `http.Handler` used to answer queries
+	listener net.Listener   // The listening socket
+}
+
+// New constructs a new GraphQL service instance. If auth is not nil, queries have
+// to carry a JSON web token permitting the "graphql" namespace.
+func New(backend ethapi.Backend, endpoint string, cors, vhosts []string, auth *rpc.JWTAuth) (*Service, error) {
+	return &Service{
+		endpoint: endpoint,
+		cors:     cors,
+		vhosts:   vhosts,
+		backend:  backend,
+		auth:     auth,
+	}, nil
+}
+
+// Protocols returns the list of protocols exported by this service.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs returns the list of APIs exported by this service.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start is called after all services have been constructed and the networking
+// layer was also initialized to spawn any goroutines required by the service.
+func (s *Service) Start(server *p2p.Server) error {
+	var err error
+	if s.handler, err = newHandler(s.backend); err != nil {
+		return err
+	}
+	if s.listener, err = net.Listen("tcp", s.endpoint); err != nil {
+		return err
+	}
+	handler := s.handler
+	if s.auth != nil {
+		handler = rpc.NewAuthenticatedHandler(handler, s.auth, "graphql")
+	}
+	go (&http.Server{Handler: rpc.NewHTTPHandlerStack(handler, s.cors, s.vhosts)}).Serve(s.listener)
+	log.Info("GraphQL endpoint opened", "url", fmt.Sprintf("http://%s", s.endpoint), "cors", strings.Join(s.cors, ","), "vhosts", strings.Join(s.vhosts, ","), "auth", s.auth != nil)
+	return nil
+}
+
+// Stop terminates all goroutines belonging to the service, blocking until they
+// are all terminated.
+func (s *Service) Stop() error {
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+		log.Info("GraphQL endpoint closed", "url", fmt.Sprintf("http://%s", s.endpoint))
+	}
+	return nil
+}
+
+// newHandler returns a new `http.Handler` that will answer GraphQL queries
+// POSTed to it.
+func newHandler(backend ethapi.Backend) (http.Handler, error) {
+	parsed, err := graphql.ParseSchema(schema, &Resolver{backend})
+	if err != nil {
+		return nil, err
+	}
+	return &relay.Handler{Schema: parsed}, nil
+}
+
+// RegisterGraphQLService constructs a GraphQL service backed by the full or light
+// Yooba service of the node and registers it. If jwtSecret is not empty, it is the
+// path of the secret authenticating the queries, the same as the RPC endpoints'.
+func RegisterGraphQLService(stack *node.Node, endpoint string, cors, vhosts []string, jwtSecret string) error {
+	var auth *rpc.JWTAuth
+	if jwtSecret != "" {
+		secret, err := rpc.LoadJWTSecret(jwtSecret)
+		if err != nil {
+			return err
+		}
+		if auth, err = rpc.NewJWTAuth(secret); err != nil {
+			return err
+		}
+	}
+	return stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Try to construct the GraphQL service backed by a full node
+		var fullServ *yoo.FullYooba
+		if err := ctx.Service(&fullServ); err == nil {
+			return New(fullServ.ApiBackend, endpoint, cors, vhosts, auth)
+		}
+		// Try to construct the GraphQL service backed by a light node
+		var lightServ *les.LightYooba
+		if err := ctx.Service(&lightServ); err == nil {
+			return New(lightServ.ApiBackend, endpoint, cors, vhosts, auth)
+		}
+		// Well, this should not have happened, bail out
+		return nil, errors.New("no Yooba service")
+	})
+}
@@ -22,15 +22,17 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"strings"
 	"time"
 
 	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/accounts/hdwallet"
 	"github.com/yooba-team/yooba/accounts/keystore"
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/hexutil"
 	"github.com/yooba-team/yooba/common/math"
 	"github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/governance"
+	"github.com/yooba-team/yooba/core/state"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/core/vm"
 	"github.com/yooba-team/yooba/crypto"
@@ -39,8 +41,6 @@ import (
 	"github.com/yooba-team/yooba/params"
 	"github.com/yooba-team/yooba/rlp"
 	"github.com/yooba-team/yooba/rpc"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/yooba-team/yooba/core/rawdb"
 )
@@ -132,15 +132,44 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	return content
 }
 
-// Status returns the number of pending and queued transaction in the pool.
-func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
+// Status returns the number of pending and queued transaction in the pool, both
+// in total and per lane.
+func (s *PublicTxPoolAPI) Status() map[string]interface{} {
 	pending, queue := s.b.Stats()
-	return map[string]hexutil.Uint{
+
+	lanes := make(map[string]map[string]hexutil.Uint)
+	count := func(kind string, content map[common.Address]types.Transactions) {
+		for _, txs := range content {
+			for _, tx := range txs {
+				lane := s.b.TxPoolLane(tx)
+				if lanes[lane] == nil {
+					lanes[lane] = map[string]hexutil.Uint{"pending": 0, "queued": 0}
+				}
+				lanes[lane][kind]++
+			}
+		}
+	}
+	pendingTxs, queueTxs := s.b.TxPoolContent()
+	count("pending", pendingTxs)
+	count("queued", queueTxs)
+
+	return map[string]interface{}{
 		"pending": hexutil.Uint(pending),
 		"queued":  hexutil.Uint(queue),
+		"lanes":   lanes,
 	}
 }
 
+// Resources returns the resource quota of the given address and its usage as of
+// the next block, or nil if the resource fee mode is not enabled.
+func (s *PublicTxPoolAPI) Resources(address common.Address) map[string]interface{} {
+	res := s.b.TxPoolResources(address)
+	if res == nil {
+		return nil
+	}
+	return formatResources(res)
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
@@ -150,12 +179,13 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	}
 	pending, queue := s.b.TxPoolContent()
 
-	// Define a formatter to flatten a transaction into a string
+	// Define a formatter to flatten a transaction into a string, tagged with its lane
 	var format = func(tx *types.Transaction) string {
+		lane := s.b.TxPoolLane(tx)
 		if to := tx.To(); to != nil {
-			return fmt.Sprintf("%s: %v wei + %v gas × %v wei", tx.To().Hex(), tx.Value(), tx.Gas(), tx.GasPrice())
+			return fmt.Sprintf("[%s] %s: %v wei + %v gas × %v wei", lane, tx.To().Hex(), tx.Value(), tx.Gas(), tx.GasPrice())
 		}
-		return fmt.Sprintf("contract creation: %v wei + %v gas × %v wei", tx.Value(), tx.Gas(), tx.GasPrice())
+		return fmt.Sprintf("[%s] contract creation: %v wei + %v gas × %v wei", lane, tx.Value(), tx.Gas(), tx.GasPrice())
 	}
 	// Flatten the pending transactions
 	for account, txs := range pending {
@@ -288,6 +318,48 @@ func (s *PrivateAccountAPI) DeriveAccount(url string, path string, pin *bool) (a
 	return wallet.Derive(derivPath, *pin)
 }
 
+// hdWallet is the JSON representation of a newly created or imported HD wallet.
+type hdWallet struct {
+	URL      string             `json:"url"`
+	Mnemonic string             `json:"mnemonic,omitempty"`
+	Accounts []accounts.Account `json:"accounts"`
+}
+
+// NewHDWallet generates a new BIP-39 mnemonic and stores the HD wallet derived
+// from it, encrypted with password. The mnemonic is returned for backup and is
+// not stored, the optional mnemonic passphrase is needed alongside it to restore
+// the wallet.
+func (s *PrivateAccountAPI) NewHDWallet(password string, mnemonicPassphrase *string) (*hdWallet, error) {
+	passphrase := ""
+	if mnemonicPassphrase != nil {
+		passphrase = *mnemonicPassphrase
+	}
+	wallet, mnemonic, err := fetchHDHub(s.am).NewWallet(password, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &hdWallet{URL: wallet.URL().String(), Mnemonic: mnemonic, Accounts: wallet.Accounts()}, nil
+}
+
+// ImportMnemonic restores the HD wallet derived from an existing BIP-39 mnemonic
+// and optional mnemonic passphrase, encrypting its seed with password.
+func (s *PrivateAccountAPI) ImportMnemonic(mnemonic string, password string, mnemonicPassphrase *string) (*hdWallet, error) {
+	passphrase := ""
+	if mnemonicPassphrase != nil {
+		passphrase = *mnemonicPassphrase
+	}
+	wallet, err := fetchHDHub(s.am).ImportMnemonic(mnemonic, passphrase, password)
+	if err != nil {
+		return nil, err
+	}
+	return &hdWallet{URL: wallet.URL().String(), Accounts: wallet.Accounts()}, nil
+}
+
+// fetchHDHub retrieves the software HD wallet backend from the account manager.
+func fetchHDHub(am *accounts.Manager) *hdwallet.Hub {
+	return am.Backends(hdwallet.HubType)[0].(*hdwallet.Hub)
+}
+
 // NewAccount will create a new account and returns the address for the new account.
 func (s *PrivateAccountAPI) NewAccount(password string) (common.Address, error) {
 	acc, err := fetchKeystore(s.am).NewAccount(password)
@@ -402,6 +474,33 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 	return &SignTransactionResult{data, signed}, nil
 }
 
+// SignMultisigTransaction adds the signature of signer to the given RLP encoded
+// transaction of a multi-signature account, decrypting the key with passwd. The
+// transaction is returned in RLP-form with all signatures collected so far.
+func (s *PrivateAccountAPI) SignMultisigTransaction(ctx context.Context, signer common.Address, encodedTx hexutil.Bytes, passwd string) (*SignTransactionResult, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return nil, err
+	}
+	if tx.MultisigSender() == nil {
+		return nil, types.ErrNotMultisig
+	}
+	account := accounts.Account{Address: signer}
+	wallet, err := s.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := wallet.SignTxWithPassphrase(account, passwd, tx, s.b.ChainConfig().ChainId)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{data, signed}, nil
+}
+
 // signHash is a helper function that calculates a hash for the given message that can be
 // safely used to calculate a signature from.
 //
@@ -440,6 +539,33 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	return signature, nil
 }
 
+// SignTypedData calculates an ECDSA signature of EIP-712 typed structured data:
+// keccak256("\x19\x01" ‖ hashStruct(domain) ‖ hashStruct(message))
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+//
+// The key used to calculate the signature is decrypted with the given password.
+func (s *PrivateAccountAPI) SignTypedData(ctx context.Context, data TypedData, addr common.Address, passwd string) (hexutil.Bytes, error) {
+	sighash, err := data.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashWithPassphrase(account, passwd, sighash)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
 // EcRecover returns the address for the account that was used to create the signature.
 // Note, this function is compatible with eth_sign and personal_sign. As such it recovers
 // the address of:
@@ -551,6 +677,203 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	return res[:], state.Error()
 }
 
+// GetGovernanceParams returns the chain parameters set by on-chain governance
+// that are effective at the given block number.
+func (s *PublicBlockChainAPI) GetGovernanceParams(ctx context.Context, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	gov := governance.ReadParams(state, header.Number)
+	fields := map[string]interface{}{
+		governance.GasLimitBoundDivisor.String(): hexutil.Uint64(gov.GasLimitBoundDivisor),
+		governance.MinGasLimit.String():          hexutil.Uint64(gov.MinGasLimit),
+		governance.MinGasPrice.String():          (*hexutil.Big)(gov.MinGasPrice),
+		governance.BlockReward.String():          (*hexutil.Big)(gov.BlockReward),
+		"proposalCount":                          hexutil.Uint64(governance.ProposalCount(state)),
+	}
+	return fields, state.Error()
+}
+
+// GetMultisig returns the signing keys and threshold of a multi-signature account
+// in the state of the given block number, or nil for regular accounts.
+func (s *PublicBlockChainAPI) GetMultisig(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	keys, threshold := state.GetMultisig(address)
+	if threshold == 0 {
+		return nil, state.Error()
+	}
+	rpcKeys := make([]map[string]interface{}, len(keys))
+	for i, key := range keys {
+		rpcKeys[i] = map[string]interface{}{
+			"pubKey": hexutil.Bytes(key.PubKey),
+			"weight": hexutil.Uint64(key.Weight),
+		}
+	}
+	fields := map[string]interface{}{
+		"keys":      rpcKeys,
+		"threshold": hexutil.Uint64(threshold),
+	}
+	return fields, state.Error()
+}
+
+// AccountResult is the result of GetProof, an EIP-1186 style account proof
+// extended by the Yooba specific account fields.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	IsStore      bool            `json:"isStore"`
+	Score        int8            `json:"score"`
+	GoodsRoot    common.Hash     `json:"goodsRoot"`
+	HistoryRoot  common.Hash     `json:"historyRoot"`
+	OrdersRoot   common.Hash     `json:"ordersRoot"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the Merkle proof of a single storage slot in an AccountResult.
+type StorageResult struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetProof returns the Merkle proof of the given account and optionally some of
+// its storage slots in the state of the given block number.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []common.Hash, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	accountProof, err := state.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	result := &AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      new(hexutil.Big),
+		CodeHash:     crypto.Keccak256Hash(nil),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: make([]StorageResult, len(storageKeys)),
+	}
+	account := state.GetAccount(address)
+	if account != nil {
+		result.Balance = (*hexutil.Big)(account.Balance)
+		result.CodeHash = common.BytesToHash(account.CodeHash)
+		result.Nonce = hexutil.Uint64(account.Nonce)
+		result.StorageHash = account.Root
+		result.IsStore = account.IsStore
+		result.Score = account.Score
+		result.GoodsRoot = account.Goodsurl
+		result.HistoryRoot = account.Historyurl
+		result.OrdersRoot = account.Ordersurl
+	}
+	for i, key := range storageKeys {
+		// Non-existent accounts have no storage, the proof of the account's
+		// absence implies the absence of the slot.
+		result.StorageProof[i] = StorageResult{Key: key, Value: new(hexutil.Big), Proof: []hexutil.Bytes{}}
+		if account == nil {
+			continue
+		}
+		proof, err := state.GetStorageProof(address, key)
+		if err != nil {
+			return nil, err
+		}
+		result.StorageProof[i].Value = (*hexutil.Big)(state.GetState(address, key).Big())
+		result.StorageProof[i].Proof = toHexSlice(proof)
+	}
+	return result, state.Error()
+}
+
+// toHexSlice converts a list of byte slices into their hex encoded form.
+func toHexSlice(b [][]byte) []hexutil.Bytes {
+	r := make([]hexutil.Bytes, len(b))
+	for i := range b {
+		r[i] = hexutil.Bytes(b[i])
+	}
+	return r
+}
+
+// GetVesting returns the vesting schedules of the given address together with
+// its locked and spendable balance at the time of the given block number.
+func (s *PublicBlockChainAPI) GetVesting(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	schedules := state.GetVesting(address)
+	if schedules == nil {
+		schedules = []types.VestingSchedule{}
+	}
+	time := header.Time.Uint64()
+	fields := map[string]interface{}{
+		"schedules": schedules,
+		"locked":    (*hexutil.Big)(core.LockedBalance(state, address, time)),
+		"spendable": (*hexutil.Big)(core.SpendableBalance(state, address, time)),
+	}
+	return fields, state.Error()
+}
+
+// GetResources returns the resource quota of the given address and its usage at
+// the given block number, or nil if the resource fee mode is not enabled.
+func (s *PublicBlockChainAPI) GetResources(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	config := s.b.ChainConfig().Resource
+	if config == nil {
+		return nil, nil
+	}
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	return formatResources(core.AccountResources(config, state, address, header.Number.Uint64())), state.Error()
+}
+
+// formatResources converts a resource quota into its RPC representation.
+func formatResources(res *core.Resources) map[string]interface{} {
+	return map[string]interface{}{
+		"stake":          (*hexutil.Big)(res.Stake),
+		"gasQuota":       hexutil.Uint64(res.GasQuota),
+		"gasUsed":        hexutil.Uint64(res.Usage.Gas),
+		"gasLeft":        hexutil.Uint64(res.GasLeft()),
+		"bandwidthQuota": hexutil.Uint64(res.BandwidthQuota),
+		"bandwidthUsed":  hexutil.Uint64(res.Usage.Bandwidth),
+		"bandwidthLeft":  hexutil.Uint64(res.BandwidthLeft()),
+	}
+}
+
+// GetProposal returns the governance proposal with the given id as stored in
+// the state of the given block number.
+func (s *PublicBlockChainAPI) GetProposal(ctx context.Context, id hexutil.Uint64, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	proposal := governance.GetProposal(state, uint64(id))
+	if proposal == nil {
+		return nil, state.Error()
+	}
+	fields := map[string]interface{}{
+		"id":         hexutil.Uint64(proposal.Id),
+		"proposer":   proposal.Proposer,
+		"param":      proposal.Param.String(),
+		"value":      (*hexutil.Big)(proposal.Value),
+		"deposit":    (*hexutil.Big)(proposal.Deposit),
+		"activation": hexutil.Uint64(proposal.Activation),
+		"deadline":   hexutil.Uint64(proposal.Deadline),
+		"yeas":       hexutil.Uint64(proposal.Yeas),
+		"nays":       hexutil.Uint64(proposal.Nays),
+		"status":     proposal.Status.String(),
+	}
+	return fields, state.Error()
+}
+
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
 	From     common.Address  `json:"from"`
@@ -562,34 +885,125 @@ type CallArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+// ToMessage converts the call arguments into an unchecked message, using default
+// values for the gas allowance and price if none were set.
+func (args *CallArgs) ToMessage() types.Message {
+	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
+	if gas == 0 {
+		gas = math.MaxUint64 / 2
+	}
+	if gasPrice.Sign() == 0 {
+		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
+	}
+	return types.NewMessage(args.From, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.TxType, args.Data, false)
+}
 
-	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
-	if state == nil || err != nil {
-		return nil, 0, false, err
+// OverrideAccount specifies the account fields to replace while executing a
+// call. The storage can either be replaced entirely by State, or individual
+// slots can be changed by StateDiff, but not both at once.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64              `json:"nonce"`
+	Code      *hexutil.Bytes               `json:"code"`
+	Balance   **hexutil.Big                `json:"balance"`
+	State     *map[common.Hash]common.Hash `json:"state"`
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// StateOverride is the set of accounts to override while executing a call.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply writes the overridden account fields into the given state.
+func (diff *StateOverride) Apply(state *state.StateDB) error {
+	if diff == nil {
+		return nil
+	}
+	for addr, account := range *diff {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		if account.Nonce != nil {
+			state.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			state.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			state.SetBalance(addr, (*big.Int)(*account.Balance))
+		}
+		if account.State != nil {
+			state.SetStorage(addr, *account.State)
+		}
+		if account.StateDiff != nil {
+			for key, value := range *account.StateDiff {
+				state.SetState(addr, key, value)
+			}
+		}
+	}
+	return nil
+}
+
+// BlockOverrides specifies the header fields to replace while executing a call.
+type BlockOverrides struct {
+	Number   *hexutil.Big    `json:"number"`
+	Time     *hexutil.Big    `json:"time"`
+	GasLimit *hexutil.Uint64 `json:"gasLimit"`
+	Coinbase *common.Address `json:"coinbase"`
+}
+
+// Apply returns a copy of the given header with the overridden fields replaced.
+func (diff *BlockOverrides) Apply(header *types.Header) *types.Header {
+	header = types.CopyHeader(header)
+	if diff == nil {
+		return header
+	}
+	if diff.Number != nil {
+		header.Number = new(big.Int).Set(diff.Number.ToInt())
 	}
+	if diff.Time != nil {
+		header.Time = new(big.Int).Set(diff.Time.ToInt())
+	}
+	if diff.GasLimit != nil {
+		header.GasLimit = uint64(*diff.GasLimit)
+	}
+	if diff.Coinbase != nil {
+		header.Coinbase = *diff.Coinbase
+	}
+	return header
+}
+
+// PrepareCall assembles the message of a call and the EVM to execute it with on
+// top of the given state and header. The sender defaults to the first account
+// of the node and the overrides are applied last, as the EVM setup of the
+// backend funds the sender.
+func PrepareCall(ctx context.Context, b Backend, args CallArgs, state *state.StateDB, header *types.Header, overrides *StateOverride, blockOverrides *BlockOverrides, vmCfg vm.Config) (types.Message, *vm.EVM, func() error, error) {
 	// Set sender address or use a default if none specified
-	addr := args.From
-	if addr == (common.Address{}) {
-		if wallets := s.b.AccountManager().Wallets(); len(wallets) > 0 {
+	if args.From == (common.Address{}) {
+		if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
 			if accounts := wallets[0].Accounts(); len(accounts) > 0 {
-				addr = accounts[0].Address
+				args.From = accounts[0].Address
 			}
 		}
 	}
-	// Set default gas & gas price if none were set
-	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
-	if gas == 0 {
-		gas = math.MaxUint64 / 2
+	// Create new call message
+	msg := args.ToMessage()
+
+	evm, vmError, err := b.GetEVM(ctx, msg, state, blockOverrides.Apply(header), vmCfg)
+	if err != nil {
+		return types.Message{}, nil, nil, err
 	}
-	if gasPrice.Sign() == 0 {
-		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
+	if err := overrides.Apply(state); err != nil {
+		return types.Message{}, nil, nil, err
 	}
+	return msg, evm, vmError, nil
+}
 
-	// Create new call message
-	msg := types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice,args.TxType ,args.Data, false)
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, 0, false, err
+	}
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
 	var cancel context.CancelFunc
@@ -602,8 +1016,8 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// this makes sure resources are cleaned up.
 	defer cancel()
 
-	// Get a new instance of the EVM.
-	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg)
+	// Get a new instance of the EVM with the requested overrides in place
+	msg, evm, vmError, err := PrepareCall(ctx, s.b, args, state, header, overrides, blockOverrides, vmCfg)
 	if err != nil {
 		return nil, 0, false, err
 	}
@@ -626,14 +1040,17 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{}, 5*time.Second)
+//
+// Optionally, account fields and header fields can be overridden for the call.
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (hexutil.Bytes, error) {
+	result, _, _, err := s.doCall(ctx, args, blockNr, overrides, blockOverrides, vm.Config{}, 5*time.Second)
 	return (hexutil.Bytes)(result), err
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
-// given transaction against the current pending block.
-func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
+// given transaction against the current pending block, optionally with account
+// and header fields overridden.
+func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs, overrides *StateOverride, blockOverrides *BlockOverrides) (hexutil.Uint64, error) {
 	// Binary search the gas requirement, as it may be higher than the amount used
 	var (
 		lo  uint64 = params.TxGas - 1
@@ -656,7 +1073,7 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	executable := func(gas uint64) bool {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
+		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, overrides, blockOverrides, vm.Config{}, 0)
 		if err != nil || failed {
 			return false
 		}
@@ -811,6 +1228,11 @@ type RPCTransaction struct {
 	V                *hexutil.Big    `json:"v"`
 	R                *hexutil.Big    `json:"r"`
 	S                *hexutil.Big    `json:"s"`
+	FeePayer         *common.Address `json:"feePayer,omitempty"`
+	FeePayerV        *hexutil.Big    `json:"feePayerV,omitempty"`
+	FeePayerR        *hexutil.Big    `json:"feePayerR,omitempty"`
+	FeePayerS        *hexutil.Big    `json:"feePayerS,omitempty"`
+	Signatures       []hexutil.Bytes `json:"signatures,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -833,6 +1255,16 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		R:        (*hexutil.Big)(r),
 		S:        (*hexutil.Big)(s),
 	}
+	if payer := tx.FeePayer(); payer != nil {
+		v, r, s := tx.RawFeePayerSignatureValues()
+		result.FeePayer = payer
+		result.FeePayerV, result.FeePayerR, result.FeePayerS = (*hexutil.Big)(v), (*hexutil.Big)(r), (*hexutil.Big)(s)
+	}
+	if tx.MultisigSender() != nil {
+		for _, sig := range tx.MultisigSignatures() {
+			result.Signatures = append(result.Signatures, sig)
+		}
+	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = blockHash
 		result.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(blockNumber))
@@ -1048,6 +1480,7 @@ type SendTxArgs struct {
 	Value    *hexutil.Big    `json:"value"`
 	Nonce    *hexutil.Uint64 `json:"nonce"`
 	TxType   uint            `json:"type"`
+	FeePayer *common.Address `json:"feePayer"`
 	// We accept "data" and "input" for backwards-compatibility reasons. "input" is the
 	// newer name and should be preferred by clients.
 	Data  *hexutil.Bytes `json:"data"`
@@ -1102,10 +1535,16 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	} else if args.Input != nil {
 		input = *args.Input
 	}
+	var tx *types.Transaction
 	if args.To == nil {
-		return types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
+		tx = types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
+	} else {
+		tx = types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), args.TxType, input)
 	}
-	return types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), args.TxType,input)
+	if args.FeePayer != nil {
+		tx = tx.WithFeePayer(*args.FeePayer)
+	}
+	return tx
 }
 
 // submitTransaction is a helper function that submits tx to txPool and logs a message.
@@ -1160,6 +1599,14 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return common.Hash{}, err
 	}
+	// Sponsored transactions can only be submitted here if the node also holds
+	// the fee payer key, otherwise use SignTransaction and have the fee payer
+	// co-sign the result.
+	if args.FeePayer != nil {
+		if signed, err = s.signAsFeePayer(signed); err != nil {
+			return common.Hash{}, err
+		}
+	}
 	return submitTransaction(ctx, s.b, signed)
 }
 
@@ -1231,6 +1678,104 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	return &SignTransactionResult{data, tx}, nil
 }
 
+// ProposeMultisigTransaction assembles an unsigned transaction sent from the
+// multi-signature account args.From and returns it in RLP-form. The key holders
+// of the account add their signatures with SignMultisigTransaction, after which
+// it can be submitted with SendRawTransaction.
+func (s *PublicTransactionPoolAPI) ProposeMultisigTransaction(ctx context.Context, args SendTxArgs) (*SignTransactionResult, error) {
+	if err := args.setDefaults(ctx, s.b); err != nil {
+		return nil, err
+	}
+	tx := args.toTransaction().WithMultisigSender(args.From)
+
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{data, tx}, nil
+}
+
+// SignMultisigTransaction adds the signature of signer to the given RLP encoded
+// transaction of a multi-signature account. The node needs to have the private
+// key of signer and it needs to be unlocked.
+func (s *PublicTransactionPoolAPI) SignMultisigTransaction(ctx context.Context, signer common.Address, encodedTx hexutil.Bytes) (*SignTransactionResult, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return nil, err
+	}
+	if tx.MultisigSender() == nil {
+		return nil, types.ErrNotMultisig
+	}
+	signed, err := s.sign(signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{data, signed}, nil
+}
+
+// signAsFeePayer co-signs a sponsored transaction, already signed by its sender,
+// with the key of its fee payer.
+func (s *PublicTransactionPoolAPI) signAsFeePayer(tx *types.Transaction) (*types.Transaction, error) {
+	payer := tx.FeePayer()
+	if payer == nil {
+		return nil, types.ErrNoFeePayer
+	}
+	// Make sure the fee payer only ever sponsors properly signed transactions
+	signer := types.NewEIP155Signer(s.b.ChainConfig().ChainId)
+	if _, err := types.Sender(signer, tx); err != nil {
+		return nil, err
+	}
+	// Look up the wallet containing the fee payer and co-sign
+	account := accounts.Account{Address: *payer}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := wallet.SignHash(account, types.FeePayerHash(signer, tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithFeePayerSignature(sig)
+}
+
+// SignTransactionAsFeePayer co-signs the given RLP encoded sponsored transaction
+// with the fee payer account named in it. The transaction must already be signed
+// by its sender and the node needs to have the unlocked fee payer key.
+func (s *PublicTransactionPoolAPI) SignTransactionAsFeePayer(ctx context.Context, encodedTx hexutil.Bytes) (*SignTransactionResult, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return nil, err
+	}
+	tx, err := s.signAsFeePayer(tx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{data, tx}, nil
+}
+
+// SendTransactionAsFeePayer co-signs the given RLP encoded sponsored transaction
+// with the fee payer account named in it and submits it to the transaction pool.
+func (s *PublicTransactionPoolAPI) SendTransactionAsFeePayer(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := s.signAsFeePayer(tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, s.b, tx)
+}
+
 // PendingTransactions returns the transactions that are in the transaction pool
 // and have a from address that is one of the accounts this node manages.
 func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, error) {
@@ -1345,32 +1890,16 @@ func NewPrivateDebugAPI(b Backend) *PrivateDebugAPI {
 	return &PrivateDebugAPI{b: b}
 }
 
-// ChaindbProperty returns leveldb properties of the chain database.
+// ChaindbProperty returns properties of the chain database, as understood by
+// its engine.
 func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
-	if !ok {
-		return "", fmt.Errorf("chaindbProperty does not work for memory databases")
-	}
-	if property == "" {
-		property = "leveldb.stats"
-	} else if !strings.HasPrefix(property, "leveldb.") {
-		property = "leveldb." + property
-	}
-	return ldb.LDB().GetProperty(property)
+	return api.b.ChainDb().Stat(property)
 }
 
 func (api *PrivateDebugAPI) ChaindbCompact() error {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
-	if !ok {
-		return fmt.Errorf("chaindbCompact does not work for memory databases")
-	}
 	for b := byte(0); b < 255; b++ {
 		log.Info("Compacting chain database", "range", fmt.Sprintf("0x%0.2X-0x%0.2X", b, b+1))
-		err := ldb.LDB().CompactRange(util.Range{Start: []byte{b}, Limit: []byte{b + 1}})
+		err := api.b.ChainDb().Compact([]byte{b}, []byte{b + 1})
 		if err != nil {
 			log.Error("Database compaction failed", "err", err)
 			return err
@@ -0,0 +1,142 @@
+package ethapi
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/consensus/dpos"
+	"github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/rpc"
+)
+
+// chainHeadChanSize is the size of the channels listening to chain head events
+// of the subscriptions derived from the state of new heads.
+const chainHeadChanSize = 10
+
+// PublicDposAPI provides access to the block producers, their schedule and the
+// votes cast for them.
+type PublicDposAPI struct {
+	b Backend
+}
+
+// NewPublicDposAPI creates a new dpos API.
+func NewPublicDposAPI(b Backend) *PublicDposAPI {
+	return &PublicDposAPI{b}
+}
+
+// ProducerSchedule is the set of producers taking turns to seal blocks starting
+// at a given block.
+type ProducerSchedule struct {
+	Number    *hexutil.Big     `json:"number"`
+	Producers []*dpos.Producer `json:"producers"`
+}
+
+// producerSchedule returns the producer schedule effective in the given state.
+func producerSchedule(db *state.StateDB, config *params.ChainConfig) *ProducerSchedule {
+	// Elected schedules take effect in the block after the election
+	number := dpos.ScheduleNumber(db)
+	if number > 0 {
+		number++
+	}
+	addrs := dpos.ActiveProducers(db, config)
+
+	producers := make([]*dpos.Producer, len(addrs))
+	for i, addr := range addrs {
+		// The producers of the chain config needn't have registered
+		if producers[i] = dpos.GetProducer(db, addr); producers[i] == nil {
+			producers[i] = &dpos.Producer{Address: addr, IsActive: true, LastProduceTime: new(big.Int)}
+		}
+	}
+	return &ProducerSchedule{Number: (*hexutil.Big)(new(big.Int).SetUint64(number)), Producers: producers}
+}
+
+// GetProducers returns all the registered block producers, active or not, in
+// registration order.
+func (s *PublicDposAPI) GetProducers(ctx context.Context, blockNr rpc.BlockNumber) ([]*dpos.Producer, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	return dpos.GetProducers(state), state.Error()
+}
+
+// GetProducer returns the registration of the given block producer, nil if it
+// never registered.
+func (s *PublicDposAPI) GetProducer(ctx context.Context, producer common.Address, blockNr rpc.BlockNumber) (*dpos.Producer, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	return dpos.GetProducer(state, producer), state.Error()
+}
+
+// GetProducerSchedule returns the producer schedule in effect at the given block.
+func (s *PublicDposAPI) GetProducerSchedule(ctx context.Context, blockNr rpc.BlockNumber) (*ProducerSchedule, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	return producerSchedule(state, s.b.ChainConfig()), state.Error()
+}
+
+// GetVotes returns the votes cast by the given account.
+func (s *PublicDposAPI) GetVotes(ctx context.Context, owner common.Address, blockNr rpc.BlockNumber) ([]*dpos.Vote, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	votes := []*dpos.Vote{}
+	if vote := dpos.GetVote(state, owner); vote != nil {
+		votes = append(votes, vote)
+	}
+	return votes, state.Error()
+}
+
+// ProducerSchedule creates a subscription that fires with the new producer
+// schedule every time a new head elects a different one.
+func (s *PublicDposAPI) ProducerSchedule(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return &rpc.Subscription{}, err
+	}
+	last := dpos.ScheduleNumber(state)
+
+	heads := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	headsSub := s.b.SubscribeChainHeadEvent(heads)
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer headsSub.Unsubscribe()
+
+		for {
+			select {
+			case head := <-heads:
+				state, _, err := s.b.StateAndHeaderByNumber(context.Background(), rpc.BlockNumber(head.Block.NumberU64()))
+				if state == nil || err != nil {
+					log.Debug("Failed to retrieve state of new head", "number", head.Block.Number(), "err", err)
+					continue
+				}
+				if number := dpos.ScheduleNumber(state); number != last {
+					last = number
+					notifier.Notify(rpcSub.ID, producerSchedule(state, s.b.ChainConfig()))
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
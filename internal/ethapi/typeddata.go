@@ -0,0 +1,491 @@
+package ethapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/common/math"
+	"github.com/yooba-team/yooba/crypto"
+)
+
+// domainType is the name of the struct type of the EIP-712 domain separator.
+const domainType = "EIP712Domain"
+
+var (
+	// typedIntRegexp matches the integer types of typed data, capturing their size.
+	typedIntRegexp = regexp.MustCompile(`^u?int([0-9]*)$`)
+
+	// typedBytesRegexp matches the fixed size byte array types of typed data,
+	// capturing their size.
+	typedBytesRegexp = regexp.MustCompile(`^bytes([0-9]+)$`)
+
+	// typedArrayRegexp matches the array types of typed data, capturing their
+	// element type and their length, empty for dynamic arrays.
+	typedArrayRegexp = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+
+	errTypedDataDomain = errors.New("typed data without " + domainType + " type")
+)
+
+// TypedDataField is a field of a struct type of EIP-712 typed data.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataTypes maps the names of the struct types of typed data to their
+// fields, in the order they are encoded in.
+type TypedDataTypes map[string][]TypedDataField
+
+// TypedData is EIP-712 typed structured data, signed as
+// keccak256("\x19\x01" ‖ hashStruct(domain) ‖ hashStruct(message)) so signers
+// can be shown what they sign instead of an opaque hash.
+type TypedData struct {
+	Types       TypedDataTypes         `json:"types"`
+	PrimaryType string                 `json:"primaryType"`
+	Domain      map[string]interface{} `json:"domain"`
+	Message     map[string]interface{} `json:"message"`
+}
+
+// NameValueType is a decoded field of typed data, for showing it to the signer.
+// The value of struct fields is the list of their decoded fields, the value of
+// arrays the list of their decoded elements.
+type NameValueType struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// SigHash validates the typed data and returns the hash to sign.
+func (td *TypedData) SigHash() ([]byte, error) {
+	if err := td.Validate(); err != nil {
+		return nil, err
+	}
+	domainSeparator, err := td.HashStruct(domainType, td.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("domain: %v", err)
+	}
+	messageHash, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, fmt.Errorf("message: %v", err)
+	}
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
+}
+
+// Validate checks that the primary and domain types are defined and that all
+// fields of all types are of a known type.
+func (td *TypedData) Validate() error {
+	if _, ok := td.Types[domainType]; !ok {
+		return errTypedDataDomain
+	}
+	if td.PrimaryType == domainType {
+		return fmt.Errorf("primary type %s is reserved", domainType)
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok {
+		return fmt.Errorf("unknown primary type %q", td.PrimaryType)
+	}
+	for name, fields := range td.Types {
+		if name == "" || strings.ContainsAny(name, "()[], ") {
+			return fmt.Errorf("invalid type name %q", name)
+		}
+		seen := make(map[string]bool)
+		for _, field := range fields {
+			if field.Name == "" || seen[field.Name] {
+				return fmt.Errorf("type %s: missing or duplicate field name %q", name, field.Name)
+			}
+			seen[field.Name] = true
+
+			if !td.knownType(field.Type) {
+				return fmt.Errorf("type %s: field %s of unknown type %q", name, field.Name, field.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// knownType returns whether typ is a struct type of the typed data, an atomic
+// or dynamic type, or an array of one of them.
+func (td *TypedData) knownType(typ string) bool {
+	if match := typedArrayRegexp.FindStringSubmatch(typ); match != nil {
+		return td.knownType(match[1])
+	}
+	if _, ok := td.Types[typ]; ok {
+		return true
+	}
+	switch typ {
+	case "address", "bool", "string", "bytes":
+		return true
+	}
+	if match := typedBytesRegexp.FindStringSubmatch(typ); match != nil {
+		size, _ := strconv.Atoi(match[1])
+		return size >= 1 && size <= 32
+	}
+	if match := typedIntRegexp.FindStringSubmatch(typ); match != nil {
+		_, err := intSize(match[1])
+		return err == nil
+	}
+	return false
+}
+
+// TypeHash returns the keccak256 hash of the encoding of the named type.
+func (td *TypedData) TypeHash(primaryType string) []byte {
+	return crypto.Keccak256(td.EncodeType(primaryType))
+}
+
+// EncodeType encodes the named type as its signature followed by the ones of
+// the struct types it references, sorted by name, such as
+// "Mail(Person from,Person to,string contents)Person(string name,address wallet)".
+func (td *TypedData) EncodeType(primaryType string) []byte {
+	deps := td.dependencies(primaryType, make(map[string]bool))
+	sort.Strings(deps[1:])
+
+	var buffer bytes.Buffer
+	for _, dep := range deps {
+		buffer.WriteString(dep)
+		buffer.WriteString("(")
+		for i, field := range td.Types[dep] {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString(field.Type)
+			buffer.WriteString(" ")
+			buffer.WriteString(field.Name)
+		}
+		buffer.WriteString(")")
+	}
+	return buffer.Bytes()
+}
+
+// dependencies returns the named type followed by all the struct types it
+// references, recursively.
+func (td *TypedData) dependencies(primaryType string, found map[string]bool) []string {
+	primaryType = baseType(primaryType)
+	if found[primaryType] {
+		return nil
+	}
+	if _, ok := td.Types[primaryType]; !ok {
+		return nil
+	}
+	found[primaryType] = true
+
+	deps := []string{primaryType}
+	for _, field := range td.Types[primaryType] {
+		deps = append(deps, td.dependencies(field.Type, found)...)
+	}
+	return deps
+}
+
+// HashStruct returns the keccak256 hash of the encoding of data as the named
+// struct type.
+func (td *TypedData) HashStruct(primaryType string, data map[string]interface{}) ([]byte, error) {
+	encoded, err := td.EncodeData(primaryType, data)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+// EncodeData encodes data as the named struct type: the type hash followed by
+// the 32 byte encoding of every field. Data with missing or unknown fields is
+// rejected, so the signer sees exactly what it signs.
+func (td *TypedData) EncodeData(primaryType string, data map[string]interface{}) ([]byte, error) {
+	fields := td.Types[primaryType]
+	if len(data) != len(fields) {
+		for name := range data {
+			if !hasField(fields, name) {
+				return nil, fmt.Errorf("%s: unknown field %q", primaryType, name)
+			}
+		}
+	}
+	buffer := bytes.NewBuffer(td.TypeHash(primaryType))
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s: missing field %q", primaryType, field.Name)
+		}
+		encoded, err := td.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", primaryType, field.Name, err)
+		}
+		buffer.Write(encoded)
+	}
+	return buffer.Bytes(), nil
+}
+
+// encodeValue encodes a value of the given type as 32 bytes: structs, arrays
+// and dynamic types by their hash, atomic types padded to 32 bytes.
+func (td *TypedData) encodeValue(typ string, value interface{}) ([]byte, error) {
+	if match := typedArrayRegexp.FindStringSubmatch(typ); match != nil {
+		elems, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid array %v", value)
+		}
+		if match[2] != "" {
+			if length, _ := strconv.Atoi(match[2]); length != len(elems) {
+				return nil, fmt.Errorf("array length mismatch: have %d, want %d", len(elems), length)
+			}
+		}
+		var buffer bytes.Buffer
+		for i, elem := range elems {
+			encoded, err := td.encodeValue(match[1], elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			buffer.Write(encoded)
+		}
+		return crypto.Keccak256(buffer.Bytes()), nil
+	}
+	if _, ok := td.Types[typ]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid %s struct %v", typ, value)
+		}
+		return td.HashStruct(typ, data)
+	}
+	return encodeAtomic(typ, value)
+}
+
+// encodeAtomic encodes a value of a non-struct, non-array type.
+func encodeAtomic(typ string, value interface{}) ([]byte, error) {
+	switch typ {
+	case "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(str).Bytes(), 32), nil
+
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool %v", value)
+		}
+		if b {
+			return math.PaddedBigBytes(common.Big1, 32), nil
+		}
+		return make([]byte, 32), nil
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid string %v", value)
+		}
+		return crypto.Keccak256([]byte(str)), nil
+
+	case "bytes":
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(blob), nil
+	}
+	if match := typedBytesRegexp.FindStringSubmatch(typ); match != nil {
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if size, _ := strconv.Atoi(match[1]); len(blob) != size {
+			return nil, fmt.Errorf("%s length mismatch: have %d bytes", typ, len(blob))
+		}
+		return common.RightPadBytes(blob, 32), nil
+	}
+	if match := typedIntRegexp.FindStringSubmatch(typ); match != nil {
+		size, err := intSize(match[1])
+		if err != nil {
+			return nil, err
+		}
+		n, err := parseInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(typ, "u") {
+			if n.Sign() < 0 || n.BitLen() > size {
+				return nil, fmt.Errorf("%v out of %s range", n, typ)
+			}
+		} else {
+			limit := new(big.Int).Lsh(common.Big1, uint(size-1))
+			if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+				return nil, fmt.Errorf("%v out of %s range", n, typ)
+			}
+		}
+		return math.PaddedBigBytes(math.U256(new(big.Int).Set(n)), 32), nil
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+// Format decodes the message of the typed data for showing it to the signer.
+func (td *TypedData) Format() ([]*NameValueType, error) {
+	return td.formatData(td.PrimaryType, td.Message)
+}
+
+// FormatDomain decodes the domain of the typed data for showing it to the signer.
+func (td *TypedData) FormatDomain() ([]*NameValueType, error) {
+	return td.formatData(domainType, td.Domain)
+}
+
+// formatData decodes data of the named struct type.
+func (td *TypedData) formatData(primaryType string, data map[string]interface{}) ([]*NameValueType, error) {
+	var output []*NameValueType
+	for _, field := range td.Types[primaryType] {
+		value, err := td.formatValue(field.Type, data[field.Name])
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", primaryType, field.Name, err)
+		}
+		output = append(output, &NameValueType{Name: field.Name, Type: field.Type, Value: value})
+	}
+	return output, nil
+}
+
+// formatValue decodes a value of the given type, printing integers in decimal
+// and addresses with their checksum.
+func (td *TypedData) formatValue(typ string, value interface{}) (interface{}, error) {
+	if match := typedArrayRegexp.FindStringSubmatch(typ); match != nil {
+		elems, _ := value.([]interface{})
+		output := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			formatted, err := td.formatValue(match[1], elem)
+			if err != nil {
+				return nil, err
+			}
+			output[i] = formatted
+		}
+		return output, nil
+	}
+	if _, ok := td.Types[typ]; ok {
+		data, _ := value.(map[string]interface{})
+		return td.formatData(typ, data)
+	}
+	switch {
+	case typ == "address":
+		if str, ok := value.(string); ok && common.IsHexAddress(str) {
+			return common.HexToAddress(str).Hex(), nil
+		}
+	case typedIntRegexp.MatchString(typ):
+		n, err := parseInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		return n.String(), nil
+	}
+	return value, nil
+}
+
+// Pprint returns the decoded fields indented by depth, one per line.
+func (nvt *NameValueType) Pprint(depth int) string {
+	var output bytes.Buffer
+	output.WriteString(strings.Repeat(" ", depth*2))
+	output.WriteString(fmt.Sprintf("%s [%s]: ", nvt.Name, nvt.Type))
+	pprintValue(&output, nvt.Value, depth)
+	return output.String()
+}
+
+// pprintValue writes a decoded value, nested fields and elements on their own
+// lines.
+func pprintValue(output *bytes.Buffer, value interface{}, depth int) {
+	switch v := value.(type) {
+	case []*NameValueType:
+		output.WriteString("\n")
+		for _, field := range v {
+			output.WriteString(field.Pprint(depth + 1))
+		}
+	case []interface{}:
+		output.WriteString("\n")
+		for i, elem := range v {
+			output.WriteString(strings.Repeat(" ", (depth+1)*2))
+			output.WriteString(fmt.Sprintf("%d: ", i))
+			pprintValue(output, elem, depth+1)
+		}
+	default:
+		output.WriteString(fmt.Sprintf("%q\n", fmt.Sprint(v)))
+	}
+}
+
+// baseType strips all array suffixes of a type.
+func baseType(typ string) string {
+	for {
+		match := typedArrayRegexp.FindStringSubmatch(typ)
+		if match == nil {
+			return typ
+		}
+		typ = match[1]
+	}
+}
+
+// hasField returns whether fields contains one with the given name.
+func hasField(fields []TypedDataField, name string) bool {
+	for _, field := range fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// intSize parses the size of an integer type, 256 bits if omitted.
+func intSize(size string) (int, error) {
+	if size == "" {
+		return 256, nil
+	}
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 8 || n > 256 || n%8 != 0 {
+		return 0, fmt.Errorf("invalid integer size %q", size)
+	}
+	return n, nil
+}
+
+// parseInteger parses an integer given as a JSON number or as a decimal or hex
+// string, as JSON numbers can't hold large integers exactly.
+func parseInteger(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("invalid integer %v", v)
+		}
+		return big.NewInt(int64(v)), nil
+	case json.Number:
+		return parseIntegerString(string(v))
+	case string:
+		return parseIntegerString(v)
+	}
+	return nil, fmt.Errorf("invalid integer %v", value)
+}
+
+// parseIntegerString parses a possibly negative decimal or hex integer.
+func parseIntegerString(str string) (*big.Int, error) {
+	neg := strings.HasPrefix(str, "-")
+	n, ok := math.ParseBig256(strings.TrimPrefix(str, "-"))
+	if !ok || str == "" {
+		return nil, fmt.Errorf("invalid integer %q", str)
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+// parseBytes parses a byte array given as a hex string.
+func parseBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case hexutil.Bytes:
+		return v, nil
+	case string:
+		blob, err := hexutil.Decode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes %q: %v", v, err)
+		}
+		return blob, nil
+	}
+	return nil, fmt.Errorf("invalid bytes %v", value)
+}
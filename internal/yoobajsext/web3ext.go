@@ -408,6 +408,12 @@ yoobajs._extend({
 			call: 'eth_getRawTransactionByHash',
 			params: 1
 		}),
+		new yoobajs._extend.Method({
+			name: 'getResources',
+			call: 'eth_getResources',
+			params: 2,
+			inputFormatter: [yoobajs._extend.formatters.inputAddressFormatter, yoobajs._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new yoobajs._extend.Method({
 			name: 'getRawTransactionFromBlock',
 			call: function(args) {
@@ -498,6 +504,12 @@ yoobajs._extend({
 			params: 3,
 			inputFormatter: [null, yoobajs._extend.formatters.inputAddressFormatter, null]
 		}),
+		new yoobajs._extend.Method({
+			name: 'signTypedData',
+			call: 'personal_signTypedData',
+			params: 3,
+			inputFormatter: [null, yoobajs._extend.formatters.inputAddressFormatter, null]
+		}),
 		new yoobajs._extend.Method({
 			name: 'ecRecover',
 			call: 'personal_ecRecover',
@@ -513,6 +525,16 @@ yoobajs._extend({
 			call: 'personal_deriveAccount',
 			params: 3
 		}),
+		new yoobajs._extend.Method({
+			name: 'newHDWallet',
+			call: 'personal_newHDWallet',
+			params: 2
+		}),
+		new yoobajs._extend.Method({
+			name: 'importMnemonic',
+			call: 'personal_importMnemonic',
+			params: 3
+		}),
 		new yoobajs._extend.Method({
 			name: 'signTransaction',
 			call: 'personal_signTransaction',
@@ -589,7 +611,14 @@ yoobajs._extend({
 const TxPool_JS = `
 yoobajs._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new yoobajs._extend.Method({
+			name: 'resources',
+			call: 'txpool_resources',
+			params: 1,
+			inputFormatter: [yoobajs._extend.formatters.inputAddressFormatter]
+		}),
+	],
 	properties:
 	[
 		new yoobajs._extend.Property({
@@ -606,6 +635,10 @@ yoobajs._extend({
 			outputFormatter: function(status) {
 				status.pending = yoobajs._extend.utils.toDecimal(status.pending);
 				status.queued = yoobajs._extend.utils.toDecimal(status.queued);
+				for (var lane in status.lanes) {
+					status.lanes[lane].pending = yoobajs._extend.utils.toDecimal(status.lanes[lane].pending);
+					status.lanes[lane].queued = yoobajs._extend.utils.toDecimal(status.lanes[lane].queued);
+				}
 				return status;
 			}
 		}),
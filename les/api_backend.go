@@ -132,6 +132,14 @@ func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.yoo.txPool.Content()
 }
 
+func (b *LesApiBackend) TxPoolLane(tx *types.Transaction) string {
+	return core.DefaultTxLane
+}
+
+func (b *LesApiBackend) TxPoolResources(addr common.Address) *core.Resources {
+	return nil
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.yoo.txPool.SubscribeNewTxsEvent(ch)
 }
@@ -357,6 +357,17 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 		return core.ErrNonceTooLow
 	}
 
+	// Check the transaction is authorized by the keys controlling the sender
+	var signers [][]byte
+	if tx.MultisigSender() != nil {
+		if signers, err = types.MultisigSigners(pool.signer, tx); err != nil {
+			return core.ErrInvalidSender
+		}
+	}
+	if err := core.CheckMultisig(currentState, from, signers); err != nil {
+		return err
+	}
+
 	// Check the transaction doesn't exceed the current
 	// block limit gas.
 	header := pool.chain.GetHeaderByHash(pool.head)
@@ -372,11 +383,27 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	}
 
 	// Transactor should have enough funds to cover the costs
-	// cost == V + GP * GL
-	if b := currentState.GetBalance(from); b.Cmp(tx.Cost()) < 0 {
+	// cost == V + GP * GL, or only V if the gas is sponsored. Balance locked
+	// by vesting schedules can't be spent.
+	time := pool.chain.CurrentHeader().Time.Uint64()
+	if b := core.SpendableBalance(currentState, from, time); b.Cmp(tx.Cost()) < 0 {
 		return core.ErrInsufficientFunds
 	}
 
+	// The fee payer of a sponsored transaction has to cover the gas
+	if tx.FeePayer() != nil {
+		payer, err := types.FeePayerSender(pool.signer, tx)
+		if err != nil {
+			return core.ErrInvalidFeePayer
+		}
+		if payer == from {
+			return core.ErrFeePayerIsSender
+		}
+		if b := core.SpendableBalance(currentState, payer, time); b.Cmp(tx.GasCost()) < 0 {
+			return core.ErrInsufficientFeePayerFunds
+		}
+	}
+
 	// Should supply enough intrinsic gas
 	gas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil)
 	if err != nil {
@@ -0,0 +1,199 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yooba-team/yooba/metrics"
+)
+
+var (
+	// quantiles are the percentiles reported for histograms and timers.
+	quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+	// labelEscaper escapes label values as required by the text format.
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// collector renders metrics in the Prometheus text exposition format, adding
+// a constant set of labels to every sample.
+type collector struct {
+	buff   *bytes.Buffer
+	labels string // Rendered constant labels, without braces
+}
+
+// newCollector creates a collector adding the given labels to every sample.
+func newCollector(labels map[string]string) *collector {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rendered := make([]string, len(keys))
+	for i, key := range keys {
+		rendered[i] = renderLabel(key, labels[key])
+	}
+	return &collector{
+		buff:   new(bytes.Buffer),
+		labels: strings.Join(rendered, ","),
+	}
+}
+
+// collect renders all metrics of the registry, sorted by name.
+func (c *collector) collect(registry metrics.Registry) {
+	all := make(map[string]interface{})
+	registry.Each(func(name string, metric interface{}) {
+		all[name] = metric
+	})
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch m := all[name].(type) {
+		case metrics.Counter:
+			c.addCounter(name, m.Snapshot())
+		case metrics.Gauge:
+			c.addGauge(name, m.Snapshot())
+		case metrics.GaugeFloat64:
+			c.addGaugeFloat64(name, m.Snapshot())
+		case metrics.Histogram:
+			c.addHistogram(name, m.Snapshot())
+		case metrics.Meter:
+			c.addMeter(name, m.Snapshot())
+		case metrics.Timer:
+			c.addTimer(name, m.Snapshot())
+		case metrics.ResettingTimer:
+			c.addResettingTimer(name, m.Snapshot())
+		}
+	}
+}
+
+// addCounter renders a counter. Counters of the registry can be decremented, so
+// they are exposed as gauges.
+func (c *collector) addCounter(name string, m metrics.Counter) {
+	c.writeGauge(SanitizeName(name), float64(m.Count()))
+}
+
+func (c *collector) addGauge(name string, m metrics.Gauge) {
+	c.writeGauge(SanitizeName(name), float64(m.Value()))
+}
+
+func (c *collector) addGaugeFloat64(name string, m metrics.GaugeFloat64) {
+	c.writeGauge(SanitizeName(name), m.Value())
+}
+
+// addMeter renders the number of marked events as a counter, and the moving
+// average rates as gauges.
+func (c *collector) addMeter(name string, m metrics.Meter) {
+	name = SanitizeName(name)
+
+	c.writeType(name+"_total", "counter")
+	c.writeSample(name+"_total", "", float64(m.Count()))
+	c.writeGauge(name+"_rate1", m.Rate1())
+	c.writeGauge(name+"_rate5", m.Rate5())
+	c.writeGauge(name+"_rate15", m.Rate15())
+	c.writeGauge(name+"_rate_mean", m.RateMean())
+}
+
+func (c *collector) addHistogram(name string, m metrics.Histogram) {
+	c.writeSummary(SanitizeName(name), m.Count(), float64(m.Sum()), m.Percentiles(quantiles))
+}
+
+// addTimer renders a timer as a summary of the timed durations in nanoseconds.
+func (c *collector) addTimer(name string, m metrics.Timer) {
+	c.writeSummary(SanitizeName(name), m.Count(), float64(m.Sum()), m.Percentiles(quantiles))
+}
+
+// addResettingTimer renders a resetting timer as a summary of the durations
+// timed since the previous snapshot, in nanoseconds.
+func (c *collector) addResettingTimer(name string, m metrics.ResettingTimer) {
+	values := m.Values()
+	if len(values) == 0 {
+		return
+	}
+	var sum float64
+	for _, value := range values {
+		sum += float64(value)
+	}
+	// Resetting timers take their percentiles in the 0-100 range
+	scaled := make([]float64, len(quantiles))
+	for i, q := range quantiles {
+		scaled[i] = q * 100
+	}
+	ps := m.Percentiles(scaled)
+	percentiles := make([]float64, len(ps))
+	for i, p := range ps {
+		percentiles[i] = float64(p)
+	}
+	c.writeSummary(SanitizeName(name), int64(len(values)), sum, percentiles)
+}
+
+func (c *collector) writeGauge(name string, value float64) {
+	c.writeType(name, "gauge")
+	c.writeSample(name, "", value)
+}
+
+func (c *collector) writeSummary(name string, count int64, sum float64, percentiles []float64) {
+	c.writeType(name, "summary")
+	for i, q := range quantiles {
+		c.writeSample(name, renderLabel("quantile", strconv.FormatFloat(q, 'g', -1, 64)), percentiles[i])
+	}
+	c.writeSample(name+"_sum", "", sum)
+	c.writeSample(name+"_count", "", float64(count))
+}
+
+func (c *collector) writeType(name, typ string) {
+	fmt.Fprintf(c.buff, "# TYPE %s %s\n", name, typ)
+}
+
+// writeSample writes a sample line with the constant labels and the given
+// extra labels, if any.
+func (c *collector) writeSample(name, labels string, value float64) {
+	switch {
+	case c.labels != "" && labels != "":
+		labels = c.labels + "," + labels
+	case c.labels != "":
+		labels = c.labels
+	}
+	if labels != "" {
+		fmt.Fprintf(c.buff, "%s{%s} %s\n", name, labels, formatValue(value))
+	} else {
+		fmt.Fprintf(c.buff, "%s %s\n", name, formatValue(value))
+	}
+}
+
+// SanitizeName converts a metric or label name of the registry into a valid
+// Prometheus name by replacing all invalid characters, such as the path
+// separators of the registry names, with underscores.
+func SanitizeName(name string) string {
+	sanitized := []byte(name)
+	for i, c := range sanitized {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			sanitized[i] = '_'
+		}
+	}
+	if len(sanitized) > 0 && name[0] >= '0' && name[0] <= '9' {
+		return "_" + name[:1] + string(sanitized[1:])
+	}
+	return string(sanitized)
+}
+
+// renderLabel renders a label pair, sanitizing the name and escaping the value.
+func renderLabel(name, value string) string {
+	return fmt.Sprintf(`%s="%s"`, strings.Replace(SanitizeName(name), ":", "_", -1), labelEscaper.Replace(value))
+}
+
+// formatValue formats a sample value as required by the text format.
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,109 @@
+package prometheus
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yooba-team/yooba/metrics"
+)
+
+func TestMain(m *testing.M) {
+	metrics.Enabled = true
+	m.Run()
+}
+
+func TestCollector(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	metrics.NewRegisteredCounter("test/counter", registry).Inc(12345)
+	metrics.NewRegisteredGauge("test/gauge", registry).Update(23456)
+	metrics.NewRegisteredGaugeFloat64("test/gauge_float64", registry).Update(34567.89)
+
+	histogram := metrics.NewRegisteredHistogram("test/histogram", registry, metrics.NewUniformSample(1028))
+	histogram.Update(1)
+	histogram.Update(3)
+
+	meter := metrics.NewRegisteredMeter("test/meter", registry)
+	defer meter.Stop()
+	meter.Mark(9999999)
+
+	timer := metrics.NewRegisteredTimer("test/timer", registry)
+	defer timer.Stop()
+	timer.Update(20 * time.Millisecond)
+	timer.Update(40 * time.Millisecond)
+
+	resettingTimer := metrics.NewRegisteredResettingTimer("test/resetting_timer", registry)
+	resettingTimer.Update(10 * time.Millisecond)
+	resettingTimer.Update(30 * time.Millisecond)
+
+	metrics.NewRegisteredResettingTimer("test/empty_resetting_timer", registry)
+	metrics.NewRegisteredCounter("0x/odd-name.with:chars", registry).Inc(1)
+
+	c := newCollector(map[string]string{"node": `a "quoted" name`, "network": "main"})
+	c.collect(registry)
+
+	want := []string{
+		`# TYPE _0x_odd_name_with:chars gauge`,
+		`_0x_odd_name_with:chars{network="main",node="a \"quoted\" name"} 1`,
+		`# TYPE test_counter gauge`,
+		`test_counter{network="main",node="a \"quoted\" name"} 12345`,
+		`test_gauge{network="main",node="a \"quoted\" name"} 23456`,
+		`test_gauge_float64{network="main",node="a \"quoted\" name"} 34567.89`,
+		`# TYPE test_histogram summary`,
+		`test_histogram{network="main",node="a \"quoted\" name",quantile="0.5"} 2`,
+		`test_histogram_sum{network="main",node="a \"quoted\" name"} 4`,
+		`test_histogram_count{network="main",node="a \"quoted\" name"} 2`,
+		`# TYPE test_meter_total counter`,
+		`test_meter_total{network="main",node="a \"quoted\" name"} 9999999`,
+		`# TYPE test_meter_rate1 gauge`,
+		`test_resetting_timer{network="main",node="a \"quoted\" name",quantile="0.99"} 30000000`,
+		`test_resetting_timer_count{network="main",node="a \"quoted\" name"} 2`,
+		`test_timer{network="main",node="a \"quoted\" name",quantile="0.5"} 30000000`,
+		`test_timer_sum{network="main",node="a \"quoted\" name"} 60000000`,
+	}
+	output := c.buff.String()
+	for _, line := range want {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("missing line %q in output:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, "empty_resetting_timer") {
+		t.Errorf("empty resetting timer rendered:\n%s", output)
+	}
+	// Metrics must be rendered sorted by name
+	if strings.Index(output, "test_counter") > strings.Index(output, "test_gauge") {
+		t.Errorf("metrics not sorted:\n%s", output)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.NewRegisteredGauge("chain/head", registry).Update(42)
+
+	rec := httptest.NewRecorder()
+	Handler(registry, nil).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/metrics/prometheus", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("content type mismatch: have %s", ct)
+	}
+	if have, want := rec.Body.String(), "# TYPE chain_head gauge\nchain_head 42\n"; have != want {
+		t.Errorf("output mismatch: have %q, want %q", have, want)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"chain/inserts":            "chain_inserts",
+		"p2p/InboundTraffic":       "p2p_InboundTraffic",
+		"yoo/downloader/bodies.in": "yoo_downloader_bodies_in",
+		"1st-metric":               "_1st_metric",
+		"ns:metric":                "ns:metric",
+	}
+	for name, want := range tests {
+		if have := SanitizeName(name); have != want {
+			t.Errorf("%s: have %s, want %s", name, have, want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Package prometheus exposes the metrics of a registry to Prometheus scrapers.
+package prometheus
+
+import (
+	"net/http"
+
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/metrics"
+)
+
+// Handler returns an HTTP handler serving the metrics of the registry in the
+// Prometheus text exposition format, with the given labels added to every
+// sample.
+func Handler(registry metrics.Registry, labels map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := newCollector(labels)
+		c.collect(registry)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if _, err := w.Write(c.buff.Bytes()); err != nil {
+			log.Debug("Failed to write metrics", "err", err)
+		}
+	})
+}
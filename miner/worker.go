@@ -18,6 +18,7 @@ package miner
 
 import (
 	"math/big"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/consensus"
 	"github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/governance"
 	"github.com/yooba-team/yooba/core/state"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/core/vm"
@@ -381,11 +383,16 @@ func (self *worker) commitNewWork() {
 		time.Sleep(wait)
 	}
 
-	num := parent.Number()
+	num := new(big.Int).Add(parent.Number(), common.Big1)
+	gasLimit := core.CalcGasLimit(parent)
+	if statedb, err := self.chain.StateAt(parent.Root()); err == nil {
+		gov := governance.ReadParams(statedb, num)
+		gasLimit = core.CalcGovernedGasLimit(parent, gov.GasLimitBoundDivisor, gov.MinGasLimit)
+	}
 	header := &types.Header{
 		ParentHash: parent.Hash(),
-		Number:     num.Add(num, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent),
+		Number:     num,
+		GasLimit:   gasLimit,
 		Extra:      self.extra,
 		Time:       big.NewInt(tstamp),
 	}
@@ -410,6 +417,11 @@ func (self *worker) commitNewWork() {
 		log.Error("Failed to fetch pending transactions", "err", err)
 		return
 	}
+	// Fill the block space reserved for the consensus critical lanes first, then
+	// let everything left compete for the rest of the block by price
+	if reserves := self.yoo.TxPool().Reservations(); len(reserves) > 0 {
+		pending = work.commitReserved(self.mux, pending, reserves, self.chain, self.coinbase)
+	}
 	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
 	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
 
@@ -440,6 +452,58 @@ func (self *worker) updateSnapshot() {
 	self.snapshotState = self.current.state.Copy()
 }
 
+// commitReserved commits the transactions of the lanes with block space reserved,
+// each limited to its own reserved share of the block gas limit. Only the
+// transactions at the head of an account's nonce ordered list can be committed
+// in advance. The pending transactions not included yet are returned.
+func (env *Work) commitReserved(mux *event.TypeMux, pending map[common.Address]types.Transactions, reserves map[uint]uint64, bc *core.BlockChain, coinbase common.Address) map[common.Address]types.Transactions {
+	// Fill the lanes one by one in a deterministic order
+	lanes := make([]uint, 0, len(reserves))
+	for typ := range reserves {
+		lanes = append(lanes, typ)
+	}
+	sort.Slice(lanes, func(i, j int) bool { return lanes[i] < lanes[j] })
+
+	for _, typ := range lanes {
+		// Gather the lane's transactions at the head of each account
+		critical := make(map[common.Address]types.Transactions)
+		for addr, txs := range pending {
+			n := 0
+			for n < len(txs) && txs[n].Type() == typ {
+				n++
+			}
+			if n > 0 {
+				critical[addr] = txs[:n:n]
+			}
+		}
+		if len(critical) == 0 {
+			continue
+		}
+		reserved := env.header.GasLimit / 100 * reserves[typ]
+		if left := env.header.GasLimit - env.header.GasUsed; reserved > left {
+			reserved = left
+		}
+		env.gasPool = new(core.GasPool).AddGas(reserved)
+		env.commitTransactions(mux, types.NewTransactionsByPriceAndNonce(env.signer, critical), bc, coinbase)
+
+		// Drop the committed transactions from the pending set
+		rest := make(map[common.Address]types.Transactions, len(pending))
+		for addr, txs := range pending {
+			nonce := env.state.GetNonce(addr)
+			for len(txs) > 0 && txs[0].Nonce() < nonce {
+				txs = txs[1:]
+			}
+			if len(txs) > 0 {
+				rest[addr] = txs
+			}
+		}
+		pending = rest
+	}
+	// Leave the unused reserved space to everything else
+	env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit - env.header.GasUsed)
+	return pending
+}
+
 func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
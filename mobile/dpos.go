@@ -0,0 +1,161 @@
+// Contains wrappers for the DPoS producers and votes and their client methods.
+
+package yooba
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/consensus/dpos"
+	"github.com/yooba-team/yooba/yooclient"
+)
+
+// Producer represents a registered block producer.
+type Producer struct {
+	producer *dpos.Producer
+}
+
+func (p *Producer) GetAddress() *Address        { return &Address{p.producer.Address} }
+func (p *Producer) GetTotalVotesCount() int64   { return int64(p.producer.TotalVotesCount) }
+func (p *Producer) GetTotalProduced() int64     { return int64(p.producer.TotalProduced) }
+func (p *Producer) IsActive() bool              { return p.producer.IsActive }
+func (p *Producer) GetUrl() string              { return p.producer.Url }
+func (p *Producer) GetLocation() string         { return p.producer.Location }
+func (p *Producer) GetLastProduceTime() *BigInt { return &BigInt{p.producer.LastProduceTime} }
+
+// Producers represents a slice of block producers.
+type Producers struct{ producers []*dpos.Producer }
+
+// Size returns the number of producers in the slice.
+func (p *Producers) Size() int {
+	return len(p.producers)
+}
+
+// Get returns the producer at the given index from the slice.
+func (p *Producers) Get(index int) (producer *Producer, _ error) {
+	if index < 0 || index >= len(p.producers) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &Producer{p.producers[index]}, nil
+}
+
+// ProducerSchedule represents the producers taking turns to seal blocks.
+type ProducerSchedule struct {
+	schedule *yooclient.ProducerSchedule
+}
+
+func (s *ProducerSchedule) GetNumber() int64         { return s.schedule.Number.Int64() }
+func (s *ProducerSchedule) GetProducers() *Producers { return &Producers{s.schedule.Producers} }
+
+// Vote represents the votes cast by an account for a set of producers.
+type Vote struct {
+	vote *dpos.Vote
+}
+
+func (v *Vote) GetOwner() *Address      { return &Address{v.vote.Owner} }
+func (v *Vote) GetVoteId() string       { return v.vote.VoteId }
+func (v *Vote) GetStaked() int64        { return v.vote.Staked }
+func (v *Vote) GetLastWeight() float64  { return v.vote.LastWeight }
+func (v *Vote) GetVoteStartTime() int64 { return v.vote.VoteStartTime }
+func (v *Vote) GetExpireTime() int64    { return v.vote.ExpireTime }
+
+// GetProducers returns the producers voted for.
+func (v *Vote) GetProducers() *Producers {
+	producers := make([]*dpos.Producer, len(v.vote.Producers))
+	for i := range v.vote.Producers {
+		producers[i] = &v.vote.Producers[i]
+	}
+	return &Producers{producers}
+}
+
+// Votes represents a slice of votes.
+type Votes struct{ votes []*dpos.Vote }
+
+// Size returns the number of votes in the slice.
+func (v *Votes) Size() int {
+	return len(v.votes)
+}
+
+// Get returns the vote at the given index from the slice.
+func (v *Votes) Get(index int) (vote *Vote, _ error) {
+	if index < 0 || index >= len(v.votes) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &Vote{v.votes[index]}, nil
+}
+
+// GetProducers returns all the registered block producers.
+// The block number can be <0, in which case the producers are taken from the latest known block.
+func (ec *YoobaClient) GetProducers(ctx *Context, number int64) (producers *Producers, _ error) {
+	if number < 0 {
+		rawProducers, err := ec.client.Producers(ctx.context, nil)
+		return &Producers{rawProducers}, err
+	}
+	rawProducers, err := ec.client.Producers(ctx.context, big.NewInt(number))
+	return &Producers{rawProducers}, err
+}
+
+// GetProducer returns the registration of the given block producer.
+// The block number can be <0, in which case the producer is taken from the latest known block.
+func (ec *YoobaClient) GetProducer(ctx *Context, address *Address, number int64) (producer *Producer, _ error) {
+	if number < 0 {
+		rawProducer, err := ec.client.ProducerAt(ctx.context, address.address, nil)
+		return &Producer{rawProducer}, err
+	}
+	rawProducer, err := ec.client.ProducerAt(ctx.context, address.address, big.NewInt(number))
+	return &Producer{rawProducer}, err
+}
+
+// GetProducerSchedule returns the producer schedule in effect at the given block.
+// The block number can be <0, in which case the schedule of the latest known block is returned.
+func (ec *YoobaClient) GetProducerSchedule(ctx *Context, number int64) (schedule *ProducerSchedule, _ error) {
+	if number < 0 {
+		rawSchedule, err := ec.client.ProducerSchedule(ctx.context, nil)
+		return &ProducerSchedule{rawSchedule}, err
+	}
+	rawSchedule, err := ec.client.ProducerSchedule(ctx.context, big.NewInt(number))
+	return &ProducerSchedule{rawSchedule}, err
+}
+
+// GetVotes returns the votes cast by the given account.
+// The block number can be <0, in which case the votes are taken from the latest known block.
+func (ec *YoobaClient) GetVotes(ctx *Context, owner *Address, number int64) (votes *Votes, _ error) {
+	if number < 0 {
+		rawVotes, err := ec.client.VotesOf(ctx.context, owner.address, nil)
+		return &Votes{rawVotes}, err
+	}
+	rawVotes, err := ec.client.VotesOf(ctx.context, owner.address, big.NewInt(number))
+	return &Votes{rawVotes}, err
+}
+
+// ProducerScheduleHandler is a client-side subscription callback to invoke on
+// events and subscription failure.
+type ProducerScheduleHandler interface {
+	OnProducerSchedule(schedule *ProducerSchedule)
+	OnError(failure string)
+}
+
+// SubscribeProducerSchedule subscribes to notifications about changes of the
+// producer schedule.
+func (ec *YoobaClient) SubscribeProducerSchedule(ctx *Context, handler ProducerScheduleHandler, buffer int) (sub *Subscription, _ error) {
+	// Subscribe to the event internally
+	ch := make(chan *yooclient.ProducerSchedule, buffer)
+	rawSub, err := ec.client.SubscribeProducerSchedule(ctx.context, ch)
+	if err != nil {
+		return nil, err
+	}
+	// Start up a dispatcher to feed into the callback
+	go func() {
+		for {
+			select {
+			case schedule := <-ch:
+				handler.OnProducerSchedule(&ProducerSchedule{schedule})
+
+			case err := <-rawSub.Err():
+				handler.OnError(err.Error())
+				return
+			}
+		}
+	}()
+	return &Subscription{rawSub}, nil
+}
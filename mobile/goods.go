@@ -0,0 +1,199 @@
+// Contains wrappers for the account profiles, goods and orders and their client
+// methods.
+
+package yooba
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/yooclient"
+)
+
+// Profile represents the public profile of an account.
+type Profile struct {
+	profile *yooclient.Profile
+}
+
+func (p *Profile) GetName() string       { return p.profile.Name }
+func (p *Profile) GetHomepage() string   { return p.profile.Homepage }
+func (p *Profile) IsStore() bool         { return p.profile.IsStore }
+func (p *Profile) GetScore() int         { return int(p.profile.Score) }
+func (p *Profile) GetGoodsRoot() *Hash   { return &Hash{p.profile.GoodsRoot} }
+func (p *Profile) GetHistoryRoot() *Hash { return &Hash{p.profile.HistoryRoot} }
+func (p *Profile) GetOrdersRoot() *Hash  { return &Hash{p.profile.OrdersRoot} }
+
+// Goods represents goods offered by a store.
+type Goods struct {
+	goods *types.Goods
+}
+
+func (g *Goods) GetHash() *Hash         { return &Hash{g.goods.GoodsHash} }
+func (g *Goods) GetDescription() string { return g.goods.Description }
+func (g *Goods) GetContract() *Address  { return &Address{g.goods.Contract} }
+func (g *Goods) GetOwner() *Address     { return &Address{g.goods.Owner} }
+func (g *Goods) GetPrice() int64        { return int64(g.goods.Price.Price) }
+func (g *Goods) GetUrl() string         { return g.goods.Url }
+func (g *Goods) GetCreateTime() *BigInt { return &BigInt{g.goods.CreateTime} }
+func (g *Goods) GetStartTime() *BigInt  { return &BigInt{g.goods.StartTime} }
+func (g *Goods) GetEndTime() *BigInt    { return &BigInt{g.goods.EndTime} }
+func (g *Goods) GetExtra() []byte       { return g.goods.Extra }
+func (g *Goods) GetNonce() *Nonce       { return &Nonce{g.goods.Nonce} }
+
+// GoodsList represents a slice of goods.
+type GoodsList struct{ goods []*types.Goods }
+
+// Size returns the number of goods in the slice.
+func (g *GoodsList) Size() int {
+	return len(g.goods)
+}
+
+// Get returns the goods at the given index from the slice.
+func (g *GoodsList) Get(index int) (goods *Goods, _ error) {
+	if index < 0 || index >= len(g.goods) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &Goods{g.goods[index]}, nil
+}
+
+// Order represents an order of goods.
+type Order struct {
+	order *types.Order
+}
+
+func (o *Order) GetHash() *Hash         { return &Hash{o.order.OrderHash} }
+func (o *Order) GetCreator() *Address   { return &Address{o.order.Creator} }
+func (o *Order) GetCreateTime() *BigInt { return &BigInt{o.order.CreateTime} }
+func (o *Order) GetStatus() int         { return o.order.Status }
+func (o *Order) GetExtra() []byte       { return o.order.Extra }
+func (o *Order) GetNonce() *Nonce       { return &Nonce{o.order.Nonce} }
+
+// GetGoodsList returns the ordered goods.
+func (o *Order) GetGoodsList() *GoodsList {
+	goods := make([]*types.Goods, len(o.order.GoodsList))
+	for i := range o.order.GoodsList {
+		goods[i] = &o.order.GoodsList[i]
+	}
+	return &GoodsList{goods}
+}
+
+// Orders represents a slice of orders.
+type Orders struct{ orders []*types.Order }
+
+// Size returns the number of orders in the slice.
+func (o *Orders) Size() int {
+	return len(o.orders)
+}
+
+// Get returns the order at the given index from the slice.
+func (o *Orders) Get(index int) (order *Order, _ error) {
+	if index < 0 || index >= len(o.orders) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &Order{o.orders[index]}, nil
+}
+
+// OrderStatus represents a status change of an order.
+type OrderStatus struct {
+	status *yooclient.OrderStatus
+}
+
+func (s *OrderStatus) GetOrderHash() *Hash   { return &Hash{s.status.OrderHash} }
+func (s *OrderStatus) GetCreator() *Address  { return &Address{s.status.Creator} }
+func (s *OrderStatus) GetStatus() int        { return s.status.Status }
+func (s *OrderStatus) GetBlockNumber() int64 { return int64(s.status.BlockNumber) }
+func (s *OrderStatus) GetTxHash() *Hash      { return &Hash{s.status.TxHash} }
+
+// OrderStatusQuery contains options for order status filtering.
+type OrderStatusQuery struct {
+	query yooclient.OrderStatusQuery
+}
+
+// NewOrderStatusQuery creates an empty query matching all order status updates.
+func NewOrderStatusQuery() *OrderStatusQuery {
+	return new(OrderStatusQuery)
+}
+
+func (q *OrderStatusQuery) GetOrders() *Hashes      { return &Hashes{q.query.Orders} }
+func (q *OrderStatusQuery) GetCreators() *Addresses { return &Addresses{q.query.Creators} }
+
+func (q *OrderStatusQuery) SetOrders(orders *Hashes)        { q.query.Orders = orders.hashes }
+func (q *OrderStatusQuery) SetCreators(creators *Addresses) { q.query.Creators = creators.addresses }
+
+// GetAccountProfile returns the profile of the given account.
+// The block number can be <0, in which case the profile is taken from the latest known block.
+func (ec *YoobaClient) GetAccountProfile(ctx *Context, account *Address, number int64) (profile *Profile, _ error) {
+	if number < 0 {
+		rawProfile, err := ec.client.AccountProfile(ctx.context, account.address, nil)
+		return &Profile{rawProfile}, err
+	}
+	rawProfile, err := ec.client.AccountProfile(ctx.context, account.address, big.NewInt(number))
+	return &Profile{rawProfile}, err
+}
+
+// GetGoodsByHash returns the goods with the given hash.
+func (ec *YoobaClient) GetGoodsByHash(ctx *Context, hash *Hash) (goods *Goods, _ error) {
+	rawGoods, err := ec.client.GoodsByHash(ctx.context, hash.hash)
+	return &Goods{rawGoods}, err
+}
+
+// GetGoodsOf returns the goods offered by the given store account.
+// The block number can be <0, in which case the goods are taken from the latest known block.
+func (ec *YoobaClient) GetGoodsOf(ctx *Context, owner *Address, number int64) (goods *GoodsList, _ error) {
+	if number < 0 {
+		rawGoods, err := ec.client.GoodsOf(ctx.context, owner.address, nil)
+		return &GoodsList{rawGoods}, err
+	}
+	rawGoods, err := ec.client.GoodsOf(ctx.context, owner.address, big.NewInt(number))
+	return &GoodsList{rawGoods}, err
+}
+
+// GetOrderByHash returns the order with the given hash.
+func (ec *YoobaClient) GetOrderByHash(ctx *Context, hash *Hash) (order *Order, _ error) {
+	rawOrder, err := ec.client.OrderByHash(ctx.context, hash.hash)
+	return &Order{rawOrder}, err
+}
+
+// GetOrdersOf returns the orders created by the given account.
+// The block number can be <0, in which case the orders are taken from the latest known block.
+func (ec *YoobaClient) GetOrdersOf(ctx *Context, creator *Address, number int64) (orders *Orders, _ error) {
+	if number < 0 {
+		rawOrders, err := ec.client.OrdersOf(ctx.context, creator.address, nil)
+		return &Orders{rawOrders}, err
+	}
+	rawOrders, err := ec.client.OrdersOf(ctx.context, creator.address, big.NewInt(number))
+	return &Orders{rawOrders}, err
+}
+
+// OrderStatusHandler is a client-side subscription callback to invoke on events
+// and subscription failure.
+type OrderStatusHandler interface {
+	OnOrderStatus(status *OrderStatus)
+	OnError(failure string)
+}
+
+// SubscribeOrderStatus subscribes to the status updates of the orders matching
+// the given query.
+func (ec *YoobaClient) SubscribeOrderStatus(ctx *Context, query *OrderStatusQuery, handler OrderStatusHandler, buffer int) (sub *Subscription, _ error) {
+	// Subscribe to the event internally
+	ch := make(chan *yooclient.OrderStatus, buffer)
+	rawSub, err := ec.client.SubscribeOrderStatus(ctx.context, query.query, ch)
+	if err != nil {
+		return nil, err
+	}
+	// Start up a dispatcher to feed into the callback
+	go func() {
+		for {
+			select {
+			case status := <-ch:
+				handler.OnOrderStatus(&OrderStatus{status})
+
+			case err := <-rawSub.Err():
+				handler.OnError(err.Error())
+				return
+			}
+		}
+	}()
+	return &Subscription{rawSub}, nil
+}
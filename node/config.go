@@ -26,6 +26,8 @@ import (
 	"strings"
 
 	"github.com/yooba-team/yooba/accounts"
+	"github.com/yooba-team/yooba/accounts/external"
+	"github.com/yooba-team/yooba/accounts/hdwallet"
 	"github.com/yooba-team/yooba/accounts/keystore"
 	"github.com/yooba-team/yooba/accounts/usbwallet"
 	"github.com/yooba-team/yooba/common"
@@ -33,6 +35,7 @@ import (
 	"github.com/yooba-team/yooba/log"
 	"github.com/yooba-team/yooba/p2p"
 	"github.com/yooba-team/yooba/p2p/discover"
+	"github.com/yooba-team/yooba/rpc"
 )
 
 const (
@@ -66,6 +69,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DatabaseEngine selects the backend of the databases opened by services,
+	// "leveldb" or "bolt". If empty, existing databases are opened with the
+	// engine they were created with and new ones use LevelDB.
+	DatabaseEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -85,6 +93,10 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the endpoint of an external signer, such as clef, to
+	// delegate block and transaction signing to.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -145,6 +157,42 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// GraphQLHost is the host interface on which to start the GraphQL server. If
+	// this field is empty, no GraphQL endpoint will be started.
+	GraphQLHost string `toml:",omitempty"`
+
+	// GraphQLPort is the TCP port number on which to start the GraphQL server.
+	GraphQLPort int `toml:",omitempty"`
+
+	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
+	// clients of the GraphQL server.
+	GraphQLCors []string `toml:",omitempty"`
+
+	// GraphQLVirtualHosts is the list of virtual hostnames which are allowed on
+	// incoming requests to the GraphQL server, see HTTPVirtualHosts.
+	GraphQLVirtualHosts []string `toml:",omitempty"`
+
+	// JWTSecret is the path of a file holding the hex encoded HS256 secret that
+	// authenticates HTTP and websocket RPC requests, and GraphQL queries. Requests must carry a JSON web
+	// token signed with it, whose claims restrict the namespaces and methods they
+	// may call. If this field is empty, requests are not authenticated.
+	JWTSecret string `toml:",omitempty"`
+
+	// RPCLimits bound the resources the requests of a single IPC, HTTP or websocket
+	// connection may consume, protecting public endpoints from abusive clients.
+	RPCLimits rpc.Limits `toml:",omitempty"`
+
+	// MetricsHost is the host interface on which to serve the metrics registry to
+	// Prometheus scrapers. If this field is empty, no metrics endpoint is started.
+	MetricsHost string `toml:",omitempty"`
+
+	// MetricsPort is the TCP port number on which to serve the metrics.
+	MetricsPort int `toml:",omitempty"`
+
+	// MetricsLabels are added to every sample served on the metrics endpoint, to
+	// tell apart the nodes scraped by a single Prometheus server.
+	MetricsLabels map[string]string `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 }
@@ -218,6 +266,24 @@ func (c *Config) WSEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.WSHost, c.WSPort)
 }
 
+// GraphQLEndpoint resolves the GraphQL endpoint based on the configured host
+// interface and port parameters.
+func (c *Config) GraphQLEndpoint() string {
+	if c.GraphQLHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.GraphQLHost, c.GraphQLPort)
+}
+
+// MetricsEndpoint resolves the metrics endpoint based on the configured host
+// interface and port parameters.
+func (c *Config) MetricsEndpoint() string {
+	if c.MetricsHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.MetricsHost, c.MetricsPort)
+}
+
 // DefaultWSEndpoint returns the websocket endpoint used by default.
 func DefaultWSEndpoint() string {
 	config := &Config{WSHost: DefaultWSHost, WSPort: DefaultWSPort}
@@ -418,6 +484,7 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	// Assemble the account manager and supported backends
 	backends := []accounts.Backend{
 		keystore.NewKeyStore(keydir, scryptN, scryptP),
+		hdwallet.NewHub(filepath.Join(keydir, "hd"), scryptN, scryptP),
 	}
 	if !conf.NoUSB {
 		// Start a USB hub for Ledger hardware wallets
@@ -433,5 +500,13 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 			backends = append(backends, trezorhub)
 		}
 	}
+	if conf.ExternalSigner != "" {
+		// Delegate signing to the external signer, keeping the local keys available
+		extapi, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("error connecting to external signer: %v", err)
+		}
+		backends = append(backends, extapi)
+	}
 	return accounts.NewManager(backends...), ephemeral, nil
 }
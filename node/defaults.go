@@ -31,6 +31,11 @@ const (
 	DefaultHTTPPort = 9687        // Default TCP port for the HTTP RPC server
 	DefaultWSHost   = "localhost" // Default host interface for the websocket RPC server
 	DefaultWSPort   = 9688        // Default TCP port for the websocket RPC server
+
+	DefaultGraphQLHost = "localhost" // Default host interface for the GraphQL server
+	DefaultGraphQLPort = 9689        // Default TCP port for the GraphQL server
+
+	DefaultMetricsPort = 6060 // Default TCP port for the Prometheus metrics endpoint
 )
 
 // DefaultConfig contains reasonable default settings.
@@ -40,6 +45,8 @@ var DefaultConfig = Config{
 	HTTPModules: []string{"net", "yoobajs"},
 	WSPort:      DefaultWSPort,
 	WSModules:   []string{"net", "yoobajs"},
+	GraphQLPort: DefaultGraphQLPort,
+	MetricsPort: DefaultMetricsPort,
 	P2P: p2p.Config{
 		ListenAddr: ":31318",
 		MaxPeers:   25,
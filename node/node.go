@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -31,6 +32,8 @@ import (
 	"github.com/yooba-team/yooba/event"
 	"github.com/yooba-team/yooba/internal/debug"
 	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/metrics"
+	"github.com/yooba-team/yooba/metrics/prometheus"
 	"github.com/yooba-team/yooba/p2p"
 	"github.com/yooba-team/yooba/rpc"
 	"github.com/prometheus/prometheus/util/flock"
@@ -67,6 +70,11 @@ type Node struct {
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	rpcAuth *rpc.JWTAuth // Authenticator of HTTP and websocket RPC requests (nil = unauthenticated)
+
+	metricsEndpoint string       // Prometheus metrics endpoint (interface + port) to listen at (empty = disabled)
+	metricsListener net.Listener // Prometheus metrics listener socket to serve scrapes
+
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
 
@@ -116,6 +124,7 @@ func New(conf *Config) (*Node, error) {
 		ipcEndpoint:       conf.IPCEndpoint(),
 		httpEndpoint:      conf.HTTPEndpoint(),
 		wsEndpoint:        conf.WSEndpoint(),
+		metricsEndpoint:   conf.MetricsEndpoint(),
 		eventmux:          new(event.TypeMux),
 		log:               conf.Logger,
 	}, nil
@@ -211,7 +220,7 @@ func (n *Node) Start() error {
 		// Mark the service started for potential cleanup
 		started = append(started, kind)
 	}
-	// Lastly start the configured RPC interfaces
+	// Lastly start the configured RPC interfaces and the metrics endpoint
 	if err := n.startRPC(services); err != nil {
 		for _, service := range services {
 			service.Stop()
@@ -219,6 +228,17 @@ func (n *Node) Start() error {
 		running.Stop()
 		return err
 	}
+	if err := n.startMetrics(n.metricsEndpoint); err != nil {
+		n.stopWS()
+		n.stopHTTP()
+		n.stopIPC()
+		n.stopInProc()
+		for _, service := range services {
+			service.Stop()
+		}
+		running.Stop()
+		return err
+	}
 	// Finish initializing the startup
 	n.services = services
 	n.server = running
@@ -255,6 +275,16 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
 	}
+	// Load the secret authenticating remote requests, if any
+	if n.config.JWTSecret != "" {
+		secret, err := rpc.LoadJWTSecret(n.config.JWTSecret)
+		if err != nil {
+			return err
+		}
+		if n.rpcAuth, err = rpc.NewJWTAuth(secret); err != nil {
+			return err
+		}
+	}
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
 		return err
@@ -309,6 +339,7 @@ func (n *Node) startIPC(apis []rpc.API) error {
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetLimits(n.config.RPCLimits)
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			return err
@@ -377,6 +408,7 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetLimits(n.config.RPCLimits)
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -393,8 +425,8 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, vhosts, handler).Serve(listener)
-	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
+	go rpc.NewAuthenticatedHTTPServer(cors, vhosts, n.rpcAuth, handler).Serve(listener)
+	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","), "auth", n.rpcAuth != nil)
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
 	n.httpListener = listener
@@ -430,6 +462,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetLimits(n.config.RPCLimits)
 	for _, api := range apis {
 		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -446,8 +479,8 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewWSServer(wsOrigins, handler).Serve(listener)
-	n.log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", listener.Addr()))
+	go rpc.NewAuthenticatedWSServer(wsOrigins, n.rpcAuth, handler).Serve(listener)
+	n.log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", listener.Addr()), "auth", n.rpcAuth != nil)
 
 	// All listeners booted successfully
 	n.wsEndpoint = endpoint
@@ -471,6 +504,37 @@ func (n *Node) stopWS() {
 	}
 }
 
+// startMetrics initializes and starts the HTTP endpoint serving the metrics
+// registry to Prometheus scrapers.
+func (n *Node) startMetrics(endpoint string) error {
+	// Short circuit if the metrics endpoint isn't being exposed
+	if endpoint == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/metrics/prometheus", prometheus.Handler(metrics.DefaultRegistry, n.config.MetricsLabels))
+	go http.Serve(listener, mux)
+	n.log.Info("Metrics endpoint opened", "url", fmt.Sprintf("http://%s/debug/metrics/prometheus", endpoint))
+
+	n.metricsEndpoint = endpoint
+	n.metricsListener = listener
+	return nil
+}
+
+// stopMetrics terminates the metrics endpoint.
+func (n *Node) stopMetrics() {
+	if n.metricsListener != nil {
+		n.metricsListener.Close()
+		n.metricsListener = nil
+
+		n.log.Info("Metrics endpoint closed", "url", fmt.Sprintf("http://%s/debug/metrics/prometheus", n.metricsEndpoint))
+	}
+}
+
 // Stop terminates a running node along with all it's services. In the node was
 // not started, an error is returned.
 func (n *Node) Stop() error {
@@ -483,6 +547,7 @@ func (n *Node) Stop() error {
 	}
 
 	// Terminate the API, services and the p2p server.
+	n.stopMetrics()
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
@@ -645,7 +710,7 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (yoobadb.Database,
 	if n.config.DataDir == "" {
 		return yoobadb.NewMemDatabase(), nil
 	}
-	return yoobadb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
+	return yoobadb.Open(n.config.DatabaseEngine, n.config.resolvePath(name), cache, handles)
 }
 
 // ResolvePath returns the absolute path of a resource in the instance directory.
@@ -43,7 +43,7 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (yo
 	if ctx.config.DataDir == "" {
 		return yoobadb.NewMemDatabase(), nil
 	}
-	db, err := yoobadb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
+	db, err := yoobadb.Open(ctx.config.DatabaseEngine, ctx.config.resolvePath(name), cache, handles)
 	if err != nil {
 		return nil, err
 	}
@@ -24,18 +24,27 @@ import (
 )
 
 var (
-	MainnetGenesisHash = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3") // Mainnet genesis hash to enforce below configs on
-	TestnetGenesisHash = common.HexToHash("0x41941023680923e0fe4d74a34bdac8141f2540e3ae90623718e47d66d1ca4a2d") // Testnet genesis hash to enforce below configs on
+	MainnetGenesisHash = common.HexToHash("0xd9d7b896f772ff0639670a7aa6ad4e233204e0749a742d6013d5bc06b51befe7") // Mainnet genesis hash to enforce below configs on
+	TestnetGenesisHash = common.HexToHash("0x617ff4d2011c356c97042ee6b3eddde91f3d5fc7474e2d11f322f2b5585513fc") // Testnet genesis hash to enforce below configs on
 )
 
 var (
 	// MainnetChainConfig is the chain parameters to run a node on the main network.
+	//
+	// The producer seal fork is not scheduled on the main network yet. It is set
+	// in a release after the fork activated on the test network, at a block far
+	// enough ahead for every producer to upgrade and authorize its signer.
 	MainnetChainConfig = &ChainConfig{
 		ChainId:        big.NewInt(1),
 		Ethash: new(EthashConfig),
 	}
 
 	// TestnetChainConfig contains the chain parameters to run a node on the Ropsten test network.
+	//
+	// The producer seal fork is not scheduled on the test network yet. It is set
+	// in a release announced to the testnet producers, at a block far enough ahead
+	// for all of them to upgrade and authorize their signers, before the main
+	// network is scheduled.
 	TestnetChainConfig = &ChainConfig{
 		ChainId:        big.NewInt(3),
 		Ethash: new(EthashConfig),
@@ -56,16 +65,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0),new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, nil, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Yooba core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0),new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -77,14 +86,21 @@ var (
 type ChainConfig struct {
 	ChainId *big.Int `json:"chainId"` // Chain id identifies the current chain and is used for replay protection
 	ByzantiumBlock *big.Int `json:"byzantiumBlock,omitempty"` // Byzantium switch block (nil = no fork, 0 = already on byzantium)
+	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
+	SealBlock           *big.Int `json:"sealBlock,omitempty"`           // Producer seal switch block (nil = no fork, 0 = already activated)
 
 	// Various consensus engines
 	Ethash *EthashConfig `json:"dpos,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
+
+	// Resource fee mode, nil if transactions are paid by gas price only
+	Resource *ResourceConfig `json:"resource,omitempty"`
 }
 
-// EthashConfig is the consensus engine configs for proof-of-work based sealing.
-type EthashConfig struct{}
+// EthashConfig is the consensus engine configs for dpos based sealing.
+type EthashConfig struct {
+	Producers []common.Address `json:"producers,omitempty"` // Initial block producers, active until the first producer election
+}
 
 // String implements the stringer interface, returning the consensus engine details.
 func (c *EthashConfig) String() string {
@@ -102,6 +118,17 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// ResourceConfig is the configuration of the resource fee mode. Accounts stake
+// YOO to get a share of the gas and bandwidth of every block proportional to
+// their stake. Transactions within the quota of their payer are free, the ones
+// exceeding it are paid by gas price as usual.
+type ResourceConfig struct {
+	Window         uint64 `json:"window"`         // Number of blocks over which resource usage decays
+	BlockGas       uint64 `json:"blockGas"`       // Gas per block shared among the stakers
+	BlockBandwidth uint64 `json:"blockBandwidth"` // Transaction bytes per block shared among the stakers
+	UnstakeDelay   uint64 `json:"unstakeDelay"`   // Seconds unstaked funds stay locked before being spendable
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -113,8 +140,11 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Byzantium: %v Constantinople: %v Seal: %v Engine: %v}",
 		c.ChainId,
+		c.ByzantiumBlock,
+		c.ConstantinopleBlock,
+		c.SealBlock,
 		engine,
 	)
 }
@@ -124,12 +154,25 @@ func (c *ChainConfig) IsByzantium(num *big.Int) bool {
 	return isForked(c.ByzantiumBlock, num)
 }
 
-// GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
+// IsConstantinople returns whether num is either equal to the Constantinople fork block or greater.
+func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
+	return isForked(c.ConstantinopleBlock, num)
+}
+
+// IsSeal returns whether num is either equal to the producer seal fork block or
+// greater, from which on every block has to be sealed by its producer.
+func (c *ChainConfig) IsSeal(num *big.Int) bool {
+	return isForked(c.SealBlock, num)
+}
+
+// GasTable returns the gas table corresponding to the current phase (EIP158 or Constantinople).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
 func (c *ChainConfig) GasTable(num *big.Int) GasTable {
-		return GasTableEIP158
-
+	if c.IsConstantinople(num) {
+		return GasTableConstantinople
+	}
+	return GasTableEIP158
 }
 
 // CheckCompatible checks whether scheduled fork transitions have been imported
@@ -154,6 +197,12 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ByzantiumBlock, newcfg.ByzantiumBlock, head) {
 		return newCompatError("Byzantium fork block", c.ByzantiumBlock, newcfg.ByzantiumBlock)
 	}
+	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
+		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
+	}
+	if isForkIncompatible(c.SealBlock, newcfg.SealBlock, head) {
+		return newCompatError("Seal fork block", c.SealBlock, newcfg.SealBlock)
+	}
 	return nil
 }
 
@@ -219,7 +268,7 @@ func (err *ConfigCompatError) Error() string {
 // phases.
 type Rules struct {
 	ChainId                                   *big.Int
-	IsByzantium                               bool
+	IsByzantium, IsConstantinople             bool
 }
 
 func (c *ChainConfig) Rules(num *big.Int) Rules {
@@ -227,5 +276,5 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 	if chainId == nil {
 		chainId = new(big.Int)
 	}
-	return Rules{ChainId: new(big.Int).Set(chainId), IsByzantium: c.IsByzantium(num)}
+	return Rules{ChainId: new(big.Int).Set(chainId), IsByzantium: c.IsByzantium(num), IsConstantinople: c.IsConstantinople(num)}
 }
@@ -17,6 +17,7 @@
 package params
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
 )
@@ -30,6 +31,34 @@ func TestCheckCompatible(t *testing.T) {
 	tests := []test{
 		{stored: AllEthashProtocolChanges, new: AllEthashProtocolChanges, head: 0, wantErr: nil},
 		{stored: AllEthashProtocolChanges, new: AllEthashProtocolChanges, head: 100, wantErr: nil},
+		{
+			stored:  &ChainConfig{ConstantinopleBlock: big.NewInt(10)},
+			new:     &ChainConfig{ConstantinopleBlock: big.NewInt(20)},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{ConstantinopleBlock: big.NewInt(10)},
+			new:    &ChainConfig{ConstantinopleBlock: big.NewInt(20)},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "Constantinople fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{ByzantiumBlock: big.NewInt(30), ConstantinopleBlock: big.NewInt(40)},
+			new:    &ChainConfig{ByzantiumBlock: big.NewInt(30), ConstantinopleBlock: nil},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Constantinople fork block",
+				StoredConfig: big.NewInt(40),
+				NewConfig:    nil,
+				RewindTo:     39,
+			},
+		},
 	}
 
 	for _, test := range tests {
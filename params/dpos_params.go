@@ -0,0 +1,21 @@
+package params
+
+import "github.com/yooba-team/yooba/common"
+
+// These are the parameters of the DPoS producer election. Candidates register
+// with the producer registry, stakers vote for them and the best voted
+// candidates take turns producing blocks until the next schedule update.
+
+var (
+	// ProducerRegistryAddress is the system account whose storage holds the
+	// registered producers, the votes cast for them and the active schedule. Its
+	// balance is the stake locked by all votes.
+	ProducerRegistryAddress = common.HexToAddress("0x0000000000000000000000000000000000000102")
+)
+
+const (
+	ProducerScheduleInterval uint64 = 3600 // Number of blocks between two producer schedule updates (~1 hour).
+	MaxActiveProducers       int    = 21   // Maximum number of producers in a schedule.
+	MaxVotedProducers        int    = 30   // Maximum number of producers a single vote can be cast for.
+	MaxProducerInfoSize      int    = 256  // Maximum length of the url and location of a producer.
+)
@@ -0,0 +1,32 @@
+package params
+
+import (
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+)
+
+// These are the parameters of the on-chain governance module. Every value that
+// governance can change is read from the governance state, the compile-time
+// constants only serve as defaults until a proposal overrides them.
+
+var (
+	// GovernanceAddress is the system account whose storage holds the governance
+	// proposals and the currently active chain parameters.
+	GovernanceAddress = common.HexToAddress("0x0000000000000000000000000000000000000100")
+
+	// GovernanceProposalDeposit is the minimum stake a proposer has to lock when
+	// submitting a parameter-change proposal. It is returned once the proposal
+	// is approved and forfeited otherwise.
+	GovernanceProposalDeposit = new(big.Int).Mul(big.NewInt(10000), big.NewInt(Ether))
+)
+
+const (
+	GovernanceVotingPeriod uint64 = 604800 // Number of blocks a proposal stays open for producer votes (~7 days).
+
+	// Bounds of the governed gas limit bound divisor. The lower one limits gas
+	// limit swings between blocks, the upper one keeps the allowed change of a
+	// MinGasLimit block at two gas or more, so the gas limit can always move.
+	MinGovernedGasLimitBoundDivisor uint64 = 64
+	MaxGovernedGasLimitBoundDivisor uint64 = 2048
+)
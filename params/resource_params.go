@@ -0,0 +1,16 @@
+package params
+
+import "github.com/yooba-team/yooba/common"
+
+// These are the parameters of the resource fee mode, enabled by the resource
+// section of the chain config.
+
+var (
+	// ResourceAddress is the system account holding the funds staked for block
+	// resources. Its stake is the total stake of all accounts.
+	ResourceAddress = common.HexToAddress("0x0000000000000000000000000000000000000101")
+)
+
+const (
+	TxBandwidth uint64 = 128 // Bandwidth charged to every transaction on top of its payload, covering signature and envelope.
+)
@@ -0,0 +1,173 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/yooba-team/yooba/common/hexutil"
+)
+
+const (
+	// jwtSecretLength is the minimum length of the HS256 shared secret.
+	jwtSecretLength = 32
+
+	// jwtIssuedAtWindow is how far the issuance time of tokens without an expiry
+	// may be from the local time, so a leaked token can't be replayed forever.
+	jwtIssuedAtWindow = 60 * time.Second
+)
+
+var (
+	errJWTMissing   = errors.New("missing bearer token")
+	errJWTLifetime  = errors.New("token without expiry or issuance time")
+	errJWTStale     = errors.New("token issuance time too far from the current time")
+	errJWTAlgorithm = errors.New("token not signed with HS256")
+)
+
+// JWTClaims are the claims of the JSON web tokens authenticating RPC requests.
+// Tokens restrict the calls they authenticate to the listed namespaces, such as
+// "yoo", and methods, such as "admin_peers". Tokens listing neither may call all
+// methods exposed on the endpoint.
+//
+// Tokens must either expire or be used right after their issuance.
+type JWTClaims struct {
+	jwt.StandardClaims
+	Namespaces []string `json:"namespaces,omitempty"`
+	Methods    []string `json:"methods,omitempty"`
+}
+
+// Valid implements jwt.Claims, checking the lifetime of the token.
+func (c *JWTClaims) Valid() error {
+	if err := c.StandardClaims.Valid(); err != nil {
+		return err
+	}
+	if c.ExpiresAt == 0 {
+		if c.IssuedAt == 0 {
+			return errJWTLifetime
+		}
+		if issued := time.Unix(c.IssuedAt, 0); time.Since(issued) > jwtIssuedAtWindow || time.Until(issued) > jwtIssuedAtWindow {
+			return errJWTStale
+		}
+	}
+	return nil
+}
+
+// expired returns whether a token with an expiry is past it.
+func (c *JWTClaims) expired() bool {
+	return c.ExpiresAt != 0 && time.Now().Unix() > c.ExpiresAt
+}
+
+// allowed returns whether the token may call the method of the namespace.
+func (c *JWTClaims) allowed(namespace, method string) bool {
+	if len(c.Namespaces) == 0 && len(c.Methods) == 0 {
+		return true
+	}
+	for _, allowed := range c.Namespaces {
+		if allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	for _, allowed := range c.Methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTAuth authenticates HTTP and websocket RPC requests carrying a JSON web
+// token signed with a shared HS256 secret in their Authorization header.
+type JWTAuth struct {
+	secret []byte
+}
+
+// NewJWTAuth creates an authenticator of tokens signed with the given secret.
+func NewJWTAuth(secret []byte) (*JWTAuth, error) {
+	if len(secret) < jwtSecretLength {
+		return nil, fmt.Errorf("JWT secret too short: have %d bytes, want at least %d", len(secret), jwtSecretLength)
+	}
+	return &JWTAuth{secret: secret}, nil
+}
+
+// LoadJWTSecret reads a hex encoded HS256 secret from a file.
+func LoadJWTSecret(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	hex := string(bytes.TrimSpace(data))
+	if !strings.HasPrefix(hex, "0x") {
+		hex = "0x" + hex
+	}
+	secret, err := hexutil.Decode(hex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT secret in %s: %v", path, err)
+	}
+	return secret, nil
+}
+
+// IssueJWT signs a token with the given claims, for clients of authenticated
+// endpoints.
+func IssueJWT(secret []byte, claims JWTClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).SignedString(secret)
+}
+
+// authenticate verifies the bearer token of the request, returning its claims.
+func (auth *JWTAuth) authenticate(r *http.Request) (*JWTClaims, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, errJWTMissing
+	}
+	claims := new(JWTClaims)
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(header, "Bearer "), claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errJWTAlgorithm
+		}
+		return auth.secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// authCodec is a server codec rejecting the requests not permitted by the
+// claims of the token the connection was authenticated with.
+type authCodec struct {
+	ServerCodec
+	claims *JWTClaims
+}
+
+// ReadRequestHeaders implements ServerCodec, marking the requests the token may
+// not make as failed.
+func (c *authCodec) ReadRequestHeaders() ([]rpcRequest, bool, Error) {
+	reqs, batch, err := c.ServerCodec.ReadRequestHeaders()
+	if err != nil {
+		return reqs, batch, err
+	}
+	for i, req := range reqs {
+		if req.err != nil {
+			continue
+		}
+		// Subscriptions are named by their namespace, unsubscriptions fully
+		namespace, method := req.service, req.service+serviceMethodSeparator+req.method
+		switch {
+		case req.isPubSub && strings.HasSuffix(req.method, unsubscribeMethodSuffix):
+			namespace, method = strings.TrimSuffix(req.method, unsubscribeMethodSuffix), req.method
+		case req.isPubSub:
+			method = req.service + subscribeMethodSuffix
+		}
+		switch {
+		case c.claims.expired():
+			reqs[i].err = &unauthorizedError{"token expired"}
+		case namespace != MetadataApi && !c.claims.allowed(namespace, method):
+			reqs[i].err = &unauthorizedError{fmt.Sprintf("token may not call %s", method)}
+		}
+	}
+	return reqs, batch, nil
+}
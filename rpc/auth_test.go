@@ -0,0 +1,216 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/net/websocket"
+)
+
+var testJWTSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// bearerTransport adds a bearer token to all HTTP requests.
+type bearerTransport struct {
+	token string
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newAuthTestServer(t *testing.T) *Server {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("admin", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func issueTestJWT(t *testing.T, secret []byte, claims JWTClaims) string {
+	token, err := IssueJWT(secret, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// Tests that authenticated HTTP endpoints reject requests without a valid token
+// and restrict the methods tokens may call to their claims.
+func TestJWTAuthHTTP(t *testing.T) {
+	auth, err := NewJWTAuth(testJWTSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newAuthTestServer(t)
+	defer server.Stop()
+
+	hs := httptest.NewServer(NewAuthenticatedHTTPServer(nil, []string{"*"}, auth, server).Handler)
+	defer hs.Close()
+
+	call := func(token string, method string) error {
+		client, err := DialHTTPWithClient(hs.URL, &http.Client{Transport: &bearerTransport{token}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		var result Result
+		if method == "rpc_modules" {
+			var modules map[string]string
+			return client.Call(&modules, method)
+		}
+		return client.Call(&result, method, "hello", 10, &Args{"world"})
+	}
+	expiry := time.Now().Add(time.Hour).Unix()
+
+	// Tokens without restrictions may call everything
+	all := issueTestJWT(t, testJWTSecret, JWTClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: expiry}})
+	if err := call(all, "admin_echo"); err != nil {
+		t.Fatalf("unrestricted token rejected: %v", err)
+	}
+	// Tokens restricted to namespaces and methods may only call those
+	restricted := issueTestJWT(t, testJWTSecret, JWTClaims{
+		StandardClaims: jwt.StandardClaims{ExpiresAt: expiry},
+		Namespaces:     []string{"test"},
+		Methods:        []string{"admin_echoWithCtx"},
+	})
+	for method, allowed := range map[string]bool{
+		"test_echo":         true,
+		"admin_echoWithCtx": true,
+		"rpc_modules":       true,
+		"admin_echo":        false,
+	} {
+		err := call(restricted, method)
+		if allowed && err != nil {
+			t.Errorf("%s: call rejected: %v", method, err)
+		}
+		if !allowed && (err == nil || !strings.Contains(err.Error(), "token may not call")) {
+			t.Errorf("%s: call not rejected: %v", method, err)
+		}
+	}
+	// Fresh tokens without expiry are accepted, but not stale ones
+	fresh := issueTestJWT(t, testJWTSecret, JWTClaims{StandardClaims: jwt.StandardClaims{IssuedAt: time.Now().Unix()}})
+	if err := call(fresh, "test_echo"); err != nil {
+		t.Errorf("fresh token rejected: %v", err)
+	}
+	// Invalid tokens are refused outright
+	invalid := map[string]string{
+		"missing":      "",
+		"expired":      issueTestJWT(t, testJWTSecret, JWTClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(-time.Minute).Unix()}}),
+		"stale":        issueTestJWT(t, testJWTSecret, JWTClaims{StandardClaims: jwt.StandardClaims{IssuedAt: time.Now().Add(-time.Hour).Unix()}}),
+		"no lifetime":  issueTestJWT(t, testJWTSecret, JWTClaims{}),
+		"wrong secret": issueTestJWT(t, []byte("fedcba9876543210fedcba9876543210"), JWTClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: expiry}}),
+	}
+	for name, token := range invalid {
+		if err := call(token, "test_echo"); err == nil || !strings.Contains(err.Error(), "401") {
+			t.Errorf("%s token: call not refused: %v", name, err)
+		}
+	}
+}
+
+// Tests that authenticated websocket endpoints refuse connections without a
+// valid token and restrict the requests of the others to their claims.
+func TestJWTAuthWebsocket(t *testing.T) {
+	auth, err := NewJWTAuth(testJWTSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newAuthTestServer(t)
+	defer server.Stop()
+
+	hs := httptest.NewServer(server.AuthenticatedWebsocketHandler([]string{"*"}, auth))
+	defer hs.Close()
+
+	dial := func(token string) (*Client, error) {
+		config, err := websocket.NewConfig("ws://"+hs.Listener.Addr().String(), "http://localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			config.Header.Set("Authorization", "Bearer "+token)
+		}
+		return newClient(context.Background(), func(ctx context.Context) (net.Conn, error) {
+			return wsDialContext(ctx, config)
+		})
+	}
+	if _, err := dial(""); err == nil {
+		t.Fatalf("unauthenticated connection accepted")
+	}
+	token := issueTestJWT(t, testJWTSecret, JWTClaims{
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+		Namespaces:     []string{"test"},
+	})
+	client, err := dial(token)
+	if err != nil {
+		t.Fatalf("authenticated connection refused: %v", err)
+	}
+	defer client.Close()
+
+	var result Result
+	if err := client.Call(&result, "test_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Errorf("permitted call rejected: %v", err)
+	}
+	if err := client.Call(&result, "admin_echo", "hello", 10, &Args{"world"}); err == nil {
+		t.Errorf("forbidden call not rejected")
+	}
+}
+
+func TestJWTSecretLength(t *testing.T) {
+	if _, err := NewJWTAuth(testJWTSecret[:31]); err == nil {
+		t.Errorf("short secret accepted")
+	}
+}
+
+// Tests that handlers of other query languages only serve requests carrying a
+// valid token permitting their namespace.
+func TestJWTAuthHandler(t *testing.T) {
+	auth, err := NewJWTAuth(testJWTSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(NewAuthenticatedHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}), auth, "graphql"))
+	defer hs.Close()
+
+	status := func(token string) int {
+		req, _ := http.NewRequest(http.MethodPost, hs.URL, strings.NewReader("{}"))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	expiry := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusUnauthorized},
+		{issueTestJWT(t, []byte("fedcba9876543210fedcba9876543210"), JWTClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: expiry}}), http.StatusUnauthorized},
+		{issueTestJWT(t, testJWTSecret, JWTClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(-time.Minute).Unix()}}), http.StatusUnauthorized},
+		{issueTestJWT(t, testJWTSecret, JWTClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: expiry}, Namespaces: []string{"yoo"}}), http.StatusForbidden},
+		{issueTestJWT(t, testJWTSecret, JWTClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: expiry}, Namespaces: []string{"graphql"}}), http.StatusOK},
+		{issueTestJWT(t, testJWTSecret, JWTClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: expiry}}), http.StatusOK},
+	}
+	for i, tt := range tests {
+		if have := status(tt.token); have != tt.want {
+			t.Errorf("test %d: status mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
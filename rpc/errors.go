@@ -58,6 +58,29 @@ func (e *callbackError) ErrorCode() int { return -32000 }
 
 func (e *callbackError) Error() string { return e.message }
 
+// request not permitted by the credentials of the caller
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string { return e.message }
+
+// request exceeds a resource limit of the server
+type limitExceededError struct{ message string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return e.message }
+
+// request exceeds the execution time limit of its method
+type timeoutError struct{ method string }
+
+func (e *timeoutError) ErrorCode() int { return -32002 }
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s exceeded its execution time limit", e.method)
+}
+
 // issued when a request is received after the server is issued to stop.
 type shutdownError struct{}
 
@@ -132,6 +132,12 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	if err != nil {
 		return nil, err
 	}
+	// Report refused requests, such as unauthenticated ones, with their reason
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		reason, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(reason))
+	}
 	return resp.Body, nil
 }
 
@@ -150,14 +156,65 @@ func (t *httpReadWriteNopCloser) Close() error {
 //
 // Deprecated: Server implements http.Handler
 func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
+	return NewAuthenticatedHTTPServer(cors, vhosts, nil, srv)
+}
+
+// NewAuthenticatedHTTPServer creates a new HTTP RPC server around an API provider,
+// only serving the requests authenticated by auth, if not nil.
+func NewAuthenticatedHTTPServer(cors []string, vhosts []string, auth *JWTAuth, srv *Server) *http.Server {
+	var handler http.Handler = srv
+	if auth != nil {
+		handler = &httpAuthHandler{auth: auth, srv: srv}
+	}
+	return &http.Server{Handler: NewHTTPHandlerStack(handler, cors, vhosts)}
+}
+
+// NewHTTPHandlerStack wraps an HTTP handler, such as one serving a different
+// query language than JSON-RPC, with the same CORS and virtual host checks as
+// the RPC server.
+func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string) http.Handler {
 	// Wrap the CORS-handler within a host-handler
 	handler := newCorsHandler(srv, cors)
-	handler = newVHostHandler(vhosts, handler)
-	return &http.Server{Handler: handler}
+	return newVHostHandler(vhosts, handler)
+}
+
+// NewAuthenticatedHandler wraps an HTTP handler, such as one serving a different
+// query language than JSON-RPC, only passing on the requests carrying a valid
+// token whose claims permit the given namespace.
+func NewAuthenticatedHandler(handler http.Handler, auth *JWTAuth, namespace string) http.Handler {
+	return &authHandler{auth: auth, namespace: namespace, handler: handler}
+}
+
+// authHandler is a handler passing on only the requests carrying a valid token
+// permitting its namespace.
+type authHandler struct {
+	auth      *JWTAuth
+	namespace string
+	handler   http.Handler
+}
+
+// ServeHTTP serves authenticated requests, implements http.Handler
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.auth.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !claims.allowed(h.namespace, h.namespace) {
+		http.Error(w, fmt.Sprintf("token may not call %s", h.namespace), http.StatusForbidden)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
 }
 
 // ServeHTTP serves JSON-RPC requests over HTTP.
 func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.serveHTTP(w, r, nil)
+}
+
+// serveHTTP serves a JSON-RPC request over HTTP, restricted to the methods the
+// claims of its token permit, if any.
+func (srv *Server) serveHTTP(w http.ResponseWriter, r *http.Request, claims *JWTClaims) {
 	// Permit dumb empty requests for remote health-checks (AWS)
 	if r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" {
 		return
@@ -169,13 +226,33 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// All checks passed, create a codec that reads direct from the request body
 	// untilEOF and writes the response to w and order the server to process a
 	// single request.
-	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+	var codec ServerCodec = NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+	if claims != nil {
+		codec = &authCodec{ServerCodec: codec, claims: claims}
+	}
 	defer codec.Close()
 
 	w.Header().Set("content-type", contentType)
 	srv.ServeSingleRequest(codec, OptionMethodInvocation)
 }
 
+// httpAuthHandler is a handler serving only the JSON-RPC requests carrying a
+// valid token.
+type httpAuthHandler struct {
+	auth *JWTAuth
+	srv  *Server
+}
+
+// ServeHTTP serves authenticated JSON-RPC requests over HTTP, implements http.Handler
+func (h *httpAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.auth.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	h.srv.serveHTTP(w, r, claims)
+}
+
 // validateRequest returns a non-zero response code and error message if the
 // request is invalid.
 func validateRequest(r *http.Request) (int, error) {
@@ -194,7 +271,7 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
@@ -305,21 +305,33 @@ func parsePositionalArguments(rawArgs json.RawMessage, types []reflect.Type) ([]
 // CreateResponse will create a JSON-RPC success response with the given id and reply as result.
 func (c *jsonCodec) CreateResponse(id interface{}, reply interface{}) interface{} {
 	if isHexNum(reflect.TypeOf(reply)) {
-		return &jsonSuccessResponse{Version: jsonrpcVersion, Id: id, Result: fmt.Sprintf(`%#x`, reply)}
+		return c.encodeResponse(id, &jsonSuccessResponse{Version: jsonrpcVersion, Id: id, Result: fmt.Sprintf(`%#x`, reply)})
 	}
-	return &jsonSuccessResponse{Version: jsonrpcVersion, Id: id, Result: reply}
+	return c.encodeResponse(id, &jsonSuccessResponse{Version: jsonrpcVersion, Id: id, Result: reply})
 }
 
 // CreateErrorResponse will create a JSON-RPC error response with the given id and error.
 func (c *jsonCodec) CreateErrorResponse(id interface{}, err Error) interface{} {
-	return &jsonErrResponse{Version: jsonrpcVersion, Id: id, Error: jsonError{Code: err.ErrorCode(), Message: err.Error()}}
+	return c.encodeResponse(id, &jsonErrResponse{Version: jsonrpcVersion, Id: id, Error: jsonError{Code: err.ErrorCode(), Message: err.Error()}})
 }
 
 // CreateErrorResponseWithInfo will create a JSON-RPC error response with the given id and error.
 // info is optional and contains additional information about the error. When an empty string is passed it is ignored.
 func (c *jsonCodec) CreateErrorResponseWithInfo(id interface{}, err Error, info interface{}) interface{} {
-	return &jsonErrResponse{Version: jsonrpcVersion, Id: id,
-		Error: jsonError{Code: err.ErrorCode(), Message: err.Error(), Data: info}}
+	return c.encodeResponse(id, &jsonErrResponse{Version: jsonrpcVersion, Id: id,
+		Error: jsonError{Code: err.ErrorCode(), Message: err.Error(), Data: info}})
+}
+
+// encodeResponse encodes a response when it's created, so its size is known for
+// the response size limit without encoding it again. Responses which can't be
+// encoded are replaced by an error.
+func (c *jsonCodec) encodeResponse(id interface{}, res interface{}) interface{} {
+	enc, err := json.Marshal(res)
+	if err != nil {
+		cerr := &callbackError{err.Error()}
+		enc, _ = json.Marshal(&jsonErrResponse{Version: jsonrpcVersion, Id: id, Error: jsonError{Code: cerr.ErrorCode(), Message: cerr.Error()}})
+	}
+	return json.RawMessage(enc)
 }
 
 // CreateNotification will create a JSON-RPC notification with the given subscription id and event as params.
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yooba-team/yooba/metrics"
+)
+
+var (
+	batchLimitMeter        = metrics.NewRegisteredMeter("rpc/limits/batch", nil)
+	responseLimitMeter     = metrics.NewRegisteredMeter("rpc/limits/response", nil)
+	concurrencyLimitMeter  = metrics.NewRegisteredMeter("rpc/limits/concurrency", nil)
+	subscriptionLimitMeter = metrics.NewRegisteredMeter("rpc/limits/subscriptions", nil)
+	timeoutLimitMeter      = metrics.NewRegisteredMeter("rpc/limits/timeout", nil)
+)
+
+// Limits bound the resources the requests of a single connection may consume.
+// Zero values disable the respective limits.
+type Limits struct {
+	BatchItems         int                      // Maximum number of requests in a batch
+	ResponseSize       int                      // Maximum size of a response in bytes, in total for batches
+	ConcurrentRequests int                      // Maximum number of requests executed concurrently per connection
+	Subscriptions      int                      // Maximum number of subscriptions per connection
+	ExecutionTimeout   time.Duration            // Maximum execution time of method calls
+	MethodTimeouts     map[string]time.Duration // Maximum execution time of specific methods, such as "yoo_getLogs"
+}
+
+// timeout returns the maximum execution time of the named method.
+func (l *Limits) timeout(method string) time.Duration {
+	if timeout, ok := l.MethodTimeouts[method]; ok {
+		return timeout
+	}
+	return l.ExecutionTimeout
+}
+
+// SetLimits configures the limits of the requests served, which must be done
+// before serving any.
+func (s *Server) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// checkBatch fails all requests of a batch exceeding the batch length limit.
+func (s *Server) checkBatch(reqs []*serverRequest) {
+	if s.limits.BatchItems == 0 || len(reqs) <= s.limits.BatchItems {
+		return
+	}
+	batchLimitMeter.Mark(1)
+
+	err := &limitExceededError{fmt.Sprintf("batch of %d requests exceeds limit of %d", len(reqs), s.limits.BatchItems)}
+	for _, req := range reqs {
+		req.err = err
+	}
+}
+
+// responseLimitError is the error replacing the responses to a request beyond
+// the response size limit.
+func (s *Server) responseLimitError() Error {
+	return &limitExceededError{fmt.Sprintf("response size exceeds limit of %d bytes", s.limits.ResponseSize)}
+}
+
+// checkResponse adds the size of a response to the total size of the responses
+// to a (batch) request, replacing it with an error if that exceeds the response
+// size limit. It returns the response to write, the new total size and whether
+// the response was kept.
+func (s *Server) checkResponse(codec ServerCodec, req *serverRequest, response interface{}, size int) (interface{}, int, bool) {
+	if s.limits.ResponseSize == 0 || size > s.limits.ResponseSize {
+		return response, size, true
+	}
+	// Responses are encoded by the codec when created, only measure the ones of
+	// codecs that don't
+	encoded, ok := response.(json.RawMessage)
+	if !ok {
+		var err error
+		if encoded, err = json.Marshal(response); err != nil {
+			return response, size, true
+		}
+	}
+	if size += len(encoded); size > s.limits.ResponseSize {
+		responseLimitMeter.Mark(1)
+		return codec.CreateErrorResponse(&req.id, s.responseLimitError()), size, false
+	}
+	return response, size, true
+}
@@ -0,0 +1,188 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// LimitTestService is a service creating subscriptions which never fire.
+type LimitTestService struct{}
+
+func (s *LimitTestService) Idle(ctx context.Context) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	return notifier.CreateSubscription(), nil
+}
+
+// Stall blocks for the given duration, ignoring its execution time limit.
+func (s *LimitTestService) Stall(duration time.Duration) {
+	time.Sleep(duration)
+}
+
+func newLimitTestClient(t *testing.T, limits Limits) (*Server, *Client) {
+	server := newTestServer("test", new(Service))
+	if err := server.RegisterName("limit", new(LimitTestService)); err != nil {
+		t.Fatal(err)
+	}
+	server.SetLimits(limits)
+	return server, DialInProc(server)
+}
+
+// checkErrorCode fails the test unless err is an RPC error with the given code.
+func checkErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected error with code %d, got none", code)
+	}
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != code {
+		t.Fatalf("expected error with code %d, got %v", code, err)
+	}
+}
+
+func echoBatch(n int, str string) []BatchElem {
+	batch := make([]BatchElem, n)
+	for i := range batch {
+		batch[i] = BatchElem{Method: "test_echo", Args: []interface{}{str, i, &Args{"x"}}, Result: new(Result)}
+	}
+	return batch
+}
+
+func TestLimitsBatchItems(t *testing.T) {
+	server, client := newLimitTestClient(t, Limits{BatchItems: 2})
+	defer server.Stop()
+	defer client.Close()
+
+	batch := echoBatch(2, "hello")
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	for i, elem := range batch {
+		if elem.Error != nil {
+			t.Errorf("request %d within limit failed: %v", i, elem.Error)
+		}
+	}
+	batch = echoBatch(3, "hello")
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	for _, elem := range batch {
+		checkErrorCode(t, elem.Error, -32005)
+	}
+}
+
+func TestLimitsResponseSize(t *testing.T) {
+	server, client := newLimitTestClient(t, Limits{ResponseSize: 256})
+	defer server.Stop()
+	defer client.Close()
+
+	var result Result
+	if err := client.Call(&result, "test_echo", "hello", 1, &Args{"x"}); err != nil {
+		t.Fatalf("small response rejected: %v", err)
+	}
+	err := client.Call(&result, "test_echo", strings.Repeat("a", 512), 1, &Args{"x"})
+	checkErrorCode(t, err, -32005)
+
+	// Batches are limited in total, the requests beyond the limit failing
+	batch := echoBatch(4, strings.Repeat("a", 64))
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch[0].Error != nil {
+		t.Fatalf("first response within limit failed: %v", batch[0].Error)
+	}
+	checkErrorCode(t, batch[len(batch)-1].Error, -32005)
+}
+
+func TestLimitsExecutionTimeout(t *testing.T) {
+	server, client := newLimitTestClient(t, Limits{
+		ExecutionTimeout: 50 * time.Millisecond,
+		MethodTimeouts:   map[string]time.Duration{"test_sleep": time.Second},
+	})
+	defer server.Stop()
+	defer client.Close()
+
+	// Method specific limits override the default one
+	if err := client.Call(nil, "test_sleep", 100*time.Millisecond); err != nil {
+		t.Fatalf("call within method limit failed: %v", err)
+	}
+	start := time.Now()
+	err := client.Call(nil, "test_sleep", 5*time.Second)
+	checkErrorCode(t, err, -32002)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("call not aborted at its limit, took %v", elapsed)
+	}
+}
+
+func TestLimitsConcurrentRequests(t *testing.T) {
+	server, client := newLimitTestClient(t, Limits{ConcurrentRequests: 1})
+	defer server.Stop()
+	defer client.Close()
+
+	errc := make(chan error, 1)
+	go func() { errc <- client.Call(nil, "test_sleep", 500*time.Millisecond) }()
+	time.Sleep(100 * time.Millisecond)
+
+	var result Result
+	err := client.Call(&result, "test_echo", "hello", 1, &Args{"x"})
+	checkErrorCode(t, err, -32005)
+
+	if err := <-errc; err != nil {
+		t.Fatalf("request within limit failed: %v", err)
+	}
+	// The slot is released right after the response is written
+	time.Sleep(50 * time.Millisecond)
+	if err := client.Call(&result, "test_echo", "hello", 1, &Args{"x"}); err != nil {
+		t.Fatalf("request after slot release failed: %v", err)
+	}
+}
+
+// Tests that calls abandoned at their execution time limit keep their slot until
+// they actually return.
+func TestLimitsConcurrentTimeout(t *testing.T) {
+	server, client := newLimitTestClient(t, Limits{ConcurrentRequests: 1, ExecutionTimeout: 50 * time.Millisecond})
+	defer server.Stop()
+	defer client.Close()
+
+	err := client.Call(nil, "limit_stall", 500*time.Millisecond)
+	checkErrorCode(t, err, -32002)
+
+	var result Result
+	err = client.Call(&result, "test_echo", "hello", 1, &Args{"x"})
+	checkErrorCode(t, err, -32005)
+
+	// Methods honouring the context return at the limit, releasing their slot
+	time.Sleep(time.Second)
+	err = client.Call(nil, "test_sleep", 5*time.Second)
+	checkErrorCode(t, err, -32002)
+
+	time.Sleep(50 * time.Millisecond)
+	if err := client.Call(&result, "test_echo", "hello", 1, &Args{"x"}); err != nil {
+		t.Fatalf("request after slot release failed: %v", err)
+	}
+}
+
+func TestLimitsSubscriptions(t *testing.T) {
+	server, client := newLimitTestClient(t, Limits{Subscriptions: 1})
+	defer server.Stop()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := client.Subscribe(ctx, "limit", make(chan int), "idle")
+	if err != nil {
+		t.Fatalf("subscription within limit failed: %v", err)
+	}
+	_, err = client.Subscribe(ctx, "limit", make(chan int), "idle")
+	checkErrorCode(t, err, -32005)
+
+	// Unsubscribing frees up room for new subscriptions
+	sub.Unsubscribe()
+	if _, err := client.Subscribe(ctx, "limit", make(chan int), "idle"); err != nil {
+		t.Fatalf("subscription after unsubscribe failed: %v", err)
+	}
+}
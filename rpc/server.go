@@ -157,6 +157,12 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 	s.codecs.Add(codec)
 	s.codecsMu.Unlock()
 
+	// limit the number of requests of the connection executing concurrently
+	var slots chan struct{}
+	if s.limits.ConcurrentRequests > 0 {
+		slots = make(chan struct{}, s.limits.ConcurrentRequests)
+	}
+
 	// test if the server is ordered to stop
 	for atomic.LoadInt32(&s.run) == 1 {
 		reqs, batch, err := s.readRequest(codec)
@@ -186,6 +192,8 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 			}
 			return nil
 		}
+		s.checkBatch(reqs)
+
 		// If a single shot request is executing, run and return immediately
 		if singleShot {
 			if batch {
@@ -196,10 +204,38 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 			return nil
 		}
 		// For multi-shot connections, start a goroutine to serve and loop back
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			default:
+				concurrencyLimitMeter.Mark(1)
+				err := &limitExceededError{fmt.Sprintf("more than %d concurrent requests", s.limits.ConcurrentRequests)}
+				for _, req := range reqs {
+					req.err = err
+				}
+				if batch {
+					s.execBatch(ctx, codec, reqs)
+				} else {
+					s.exec(ctx, codec, reqs[0])
+				}
+				continue
+			}
+		}
 		pend.Add(1)
 
 		go func(reqs []*serverRequest, batch bool) {
+			// Calls abandoned at their execution time limit keep running, only
+			// release the slot once they actually returned
+			calls := new(sync.WaitGroup)
+			if slots != nil {
+				defer func() {
+					calls.Wait()
+					<-slots
+				}()
+			}
 			defer pend.Done()
+
+			ctx := context.WithValue(ctx, callsKey{}, calls)
 			if batch {
 				s.execBatch(ctx, codec, reqs)
 			} else {
@@ -277,6 +313,12 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	if req.callb.isSubscribe {
+		if s.limits.Subscriptions > 0 {
+			if notifier, supported := NotifierFromContext(ctx); supported && notifier.count() >= s.limits.Subscriptions {
+				subscriptionLimitMeter.Mark(1)
+				return codec.CreateErrorResponse(&req.id, &limitExceededError{fmt.Sprintf("more than %d subscriptions", s.limits.Subscriptions)}), nil
+			}
+		}
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
 			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
@@ -299,6 +341,13 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, rpcErr), nil
 	}
 
+	method := req.svcname + serviceMethodSeparator + formatName(req.callb.method.Name)
+	timeout := s.limits.timeout(method)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	arguments := []reflect.Value{req.callb.rcvr}
 	if req.callb.hasCtx {
 		arguments = append(arguments, reflect.ValueOf(ctx))
@@ -308,7 +357,15 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	// execute RPC method and return result
-	reply := req.callb.method.Func.Call(arguments)
+	var reply []reflect.Value
+	if timeout > 0 {
+		var err Error
+		if reply, err = s.call(ctx, method, req.callb, arguments); err != nil {
+			return codec.CreateErrorResponse(&req.id, err), nil
+		}
+	} else {
+		reply = req.callb.method.Func.Call(arguments)
+	}
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
@@ -323,6 +380,48 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
 }
 
+// callsKey is used to store the wait group of the method calls running on
+// behalf of a request within its context.
+type callsKey struct{}
+
+// call executes an RPC method on a separate goroutine, abandoning it once the
+// context, carrying the execution time limit of the method, is done. Methods
+// accepting a context are cancelled along with it, others run to completion
+// while tracked by the calls wait group of the request.
+func (s *Server) call(ctx context.Context, method string, callb *callback, arguments []reflect.Value) ([]reflect.Value, Error) {
+	calls, _ := ctx.Value(callsKey{}).(*sync.WaitGroup)
+	if calls != nil {
+		calls.Add(1)
+	}
+	done := make(chan []reflect.Value, 1)
+	go func() {
+		if calls != nil {
+			defer calls.Done()
+		}
+		defer func() {
+			if err := recover(); err != nil {
+				const size = 64 << 10
+				buf := make([]byte, size)
+				buf = buf[:runtime.Stack(buf, false)]
+				log.Error(string(buf))
+				close(done)
+			}
+		}()
+		done <- callb.method.Func.Call(arguments)
+	}()
+
+	select {
+	case reply, ok := <-done:
+		if !ok {
+			return nil, &callbackError{fmt.Sprintf("%s crashed", method)}
+		}
+		return reply, nil
+	case <-ctx.Done():
+		timeoutLimitMeter.Mark(1)
+		return nil, &timeoutError{method}
+	}
+}
+
 // exec executes the given request and writes the result back using the codec.
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
 	var response interface{}
@@ -332,6 +431,11 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 	} else {
 		response, callback = s.handle(ctx, codec, req)
 	}
+	// subscriptions whose id didn't fit the response limit are never activated
+	var ok bool
+	if response, _, ok = s.checkResponse(codec, req, response, 0); !ok {
+		callback = nil
+	}
 
 	if err := codec.Write(response); err != nil {
 		log.Error(fmt.Sprintf("%v\n", err))
@@ -349,14 +453,21 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*serverRequest) {
 	responses := make([]interface{}, len(requests))
 	var callbacks []func()
+	var size int
 	for i, req := range requests {
+		// skip the remaining requests once the responses exceed the size limit
+		if s.limits.ResponseSize > 0 && size > s.limits.ResponseSize {
+			req.err = s.responseLimitError()
+		}
+		var callback func()
 		if req.err != nil {
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
 		} else {
-			var callback func()
-			if responses[i], callback = s.handle(ctx, codec, req); callback != nil {
-				callbacks = append(callbacks, callback)
-			}
+			responses[i], callback = s.handle(ctx, codec, req)
+		}
+		var ok bool
+		if responses[i], size, ok = s.checkResponse(codec, req, responses[i], size); ok && callback != nil {
+			callbacks = append(callbacks, callback)
 		}
 	}
 
@@ -133,3 +133,11 @@ func (n *Notifier) activate(id ID, namespace string) {
 		delete(n.inactive, id)
 	}
 }
+
+// count returns the number of subscriptions created on the connection, whether
+// or not activated yet.
+func (n *Notifier) count() int {
+	n.subMu.RLock()
+	defer n.subMu.RUnlock()
+	return len(n.active) + len(n.inactive)
+}
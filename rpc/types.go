@@ -17,12 +17,14 @@
 package rpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
 	"strings"
 	"sync"
 
+	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/hexutil"
 	"gopkg.in/fatih/set.v0"
 )
@@ -74,6 +76,8 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   *set.Set
+
+	limits Limits
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -163,3 +167,82 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 func (bn BlockNumber) Int64() int64 {
 	return (int64)(bn)
 }
+
+// BlockNumberOrHash selects a block either by number, including the special
+// "latest", "earliest" and "pending" tags, or by hash.
+type BlockNumberOrHash struct {
+	BlockNumber *BlockNumber `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash `json:"blockHash,omitempty"`
+}
+
+// UnmarshalJSON parses the given JSON fragment into a BlockNumberOrHash. It
+// supports everything BlockNumber does, 32 byte hex hashes and objects with
+// either a "blockNumber" or a "blockHash" field.
+func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
+	type erased BlockNumberOrHash
+	var e erased
+	if err := json.Unmarshal(data, &e); err == nil {
+		if e.BlockNumber != nil && e.BlockHash != nil {
+			return fmt.Errorf("cannot specify both blockHash and blockNumber")
+		}
+		if e.BlockNumber == nil && e.BlockHash == nil {
+			return fmt.Errorf("neither blockHash nor blockNumber specified")
+		}
+		*bnh = BlockNumberOrHash(e)
+		return nil
+	}
+	var input string
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	if len(input) == 2+2*common.HashLength {
+		hash := new(common.Hash)
+		if err := hash.UnmarshalText([]byte(input)); err != nil {
+			return err
+		}
+		*bnh = BlockNumberOrHash{BlockHash: hash}
+		return nil
+	}
+	number := new(BlockNumber)
+	if err := number.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*bnh = BlockNumberOrHash{BlockNumber: number}
+	return nil
+}
+
+// Number returns the selected block number, if the block is selected by number.
+func (bnh *BlockNumberOrHash) Number() (BlockNumber, bool) {
+	if bnh.BlockNumber != nil {
+		return *bnh.BlockNumber, true
+	}
+	return BlockNumber(0), false
+}
+
+// Hash returns the selected block hash, if the block is selected by hash.
+func (bnh *BlockNumberOrHash) Hash() (common.Hash, bool) {
+	if bnh.BlockHash != nil {
+		return *bnh.BlockHash, true
+	}
+	return common.Hash{}, false
+}
+
+func (bnh BlockNumberOrHash) String() string {
+	if bnh.BlockNumber != nil {
+		return fmt.Sprintf("#%d", *bnh.BlockNumber)
+	}
+	if bnh.BlockHash != nil {
+		return bnh.BlockHash.Hex()
+	}
+	return "nil"
+}
+
+// BlockNumberOrHashWithNumber selects a block by number.
+func BlockNumberOrHashWithNumber(number BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockNumber: &number}
+}
+
+// BlockNumberOrHashWithHash selects a block by hash.
+func BlockNumberOrHashWithHash(hash common.Hash) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockHash: &hash}
+}
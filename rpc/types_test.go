@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/math"
 )
 
@@ -64,3 +65,51 @@ func TestBlockNumberJSONUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestBlockNumberOrHashJSONUnmarshal(t *testing.T) {
+	hash := common.HexToHash("0x0102030405060708091011121314151617181920212223242526272829303132")
+	tests := []struct {
+		input    string
+		mustFail bool
+		expected BlockNumberOrHash
+	}{
+		0:  {`"0x"`, true, BlockNumberOrHash{}},
+		1:  {`"0x0"`, false, BlockNumberOrHashWithNumber(0)},
+		2:  {`"0x12"`, false, BlockNumberOrHashWithNumber(18)},
+		3:  {`"0x8000000000000000"`, true, BlockNumberOrHash{}},
+		4:  {`"pending"`, false, BlockNumberOrHashWithNumber(PendingBlockNumber)},
+		5:  {`"latest"`, false, BlockNumberOrHashWithNumber(LatestBlockNumber)},
+		6:  {`"earliest"`, false, BlockNumberOrHashWithNumber(EarliestBlockNumber)},
+		7:  {`"` + hash.Hex() + `"`, false, BlockNumberOrHashWithHash(hash)},
+		8:  {`"` + hash.Hex()[:65] + `"`, true, BlockNumberOrHash{}},
+		9:  {`{"blockNumber":"0x1"}`, false, BlockNumberOrHashWithNumber(1)},
+		10: {`{"blockNumber":"latest"}`, false, BlockNumberOrHashWithNumber(LatestBlockNumber)},
+		11: {`{"blockHash":"` + hash.Hex() + `"}`, false, BlockNumberOrHashWithHash(hash)},
+		12: {`{"blockNumber":"0x1","blockHash":"` + hash.Hex() + `"}`, true, BlockNumberOrHash{}},
+		13: {`{}`, true, BlockNumberOrHash{}},
+		14: {`someString`, true, BlockNumberOrHash{}},
+	}
+
+	for i, test := range tests {
+		var bnh BlockNumberOrHash
+		err := json.Unmarshal([]byte(test.input), &bnh)
+		if test.mustFail && err == nil {
+			t.Errorf("Test %d should fail", i)
+			continue
+		}
+		if !test.mustFail && err != nil {
+			t.Errorf("Test %d should pass but got err: %v", i, err)
+			continue
+		}
+		if test.mustFail {
+			continue
+		}
+		number, isNumber := bnh.Number()
+		wantNumber, wantIsNumber := test.expected.Number()
+		hash, isHash := bnh.Hash()
+		wantHash, wantIsHash := test.expected.Hash()
+		if number != wantNumber || isNumber != wantIsNumber || hash != wantHash || isHash != wantIsHash {
+			t.Errorf("Test %d got unexpected value, want %+v, got %+v", i, test.expected, bnh)
+		}
+	}
+}
@@ -37,10 +37,38 @@ import (
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
 // To allow connections with any origin, pass "*".
 func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
+	return srv.AuthenticatedWebsocketHandler(allowedOrigins, nil)
+}
+
+// AuthenticatedWebsocketHandler returns a handler that serves JSON-RPC to WebSocket
+// connections, only accepting the connections authenticated by auth, if not nil.
+// The token a connection was opened with restricts all of its requests.
+func (srv *Server) AuthenticatedWebsocketHandler(allowedOrigins []string, auth *JWTAuth) http.Handler {
+	validator := wsHandshakeValidator(allowedOrigins)
 	return websocket.Server{
-		Handshake: wsHandshakeValidator(allowedOrigins),
+		Handshake: func(cfg *websocket.Config, req *http.Request) error {
+			if err := validator(cfg, req); err != nil {
+				return err
+			}
+			if auth != nil {
+				if _, err := auth.authenticate(req); err != nil {
+					log.Debug("Rejected unauthenticated WS-RPC connection", "err", err)
+					return err
+				}
+			}
+			return nil
+		},
 		Handler: func(conn *websocket.Conn) {
-			srv.ServeCodec(NewJSONCodec(conn), OptionMethodInvocation|OptionSubscriptions)
+			var codec ServerCodec = NewJSONCodec(conn)
+			if auth != nil {
+				claims, err := auth.authenticate(conn.Request())
+				if err != nil {
+					conn.Close()
+					return
+				}
+				codec = &authCodec{ServerCodec: codec, claims: claims}
+			}
+			srv.ServeCodec(codec, OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
 }
@@ -52,6 +80,12 @@ func NewWSServer(allowedOrigins []string, srv *Server) *http.Server {
 	return &http.Server{Handler: srv.WebsocketHandler(allowedOrigins)}
 }
 
+// NewAuthenticatedWSServer creates a new websocket RPC server around an API
+// provider, only accepting the connections authenticated by auth, if not nil.
+func NewAuthenticatedWSServer(allowedOrigins []string, auth *JWTAuth, srv *Server) *http.Server {
+	return &http.Server{Handler: srv.AuthenticatedWebsocketHandler(allowedOrigins, auth)}
+}
+
 // wsHandshakeValidator returns a handler that verifies the origin during the
 // websocket upgrade process. When a '*' is specified as an allowed origins all
 // connections are accepted.
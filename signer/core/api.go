@@ -30,6 +30,8 @@ import (
 	"github.com/yooba-team/yooba/accounts/usbwallet"
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/consensus/dpos"
+	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/crypto"
 	"github.com/yooba-team/yooba/internal/ethapi"
 	"github.com/yooba-team/yooba/log"
@@ -44,8 +46,14 @@ type ExternalAPI interface {
 	New(ctx context.Context) (accounts.Account, error)
 	// SignTransaction request to sign the specified transaction
 	SignTransaction(ctx context.Context, args SendTxArgs, methodSelector *string) (*ethapi.SignTransactionResult, error)
+	// SignMultisigTransaction request to add a signature to the transaction of a multi-signature account
+	SignMultisigTransaction(ctx context.Context, signer common.MixedcaseAddress, encodedTx hexutil.Bytes) (*ethapi.SignTransactionResult, error)
 	// Sign - request to sign the given data (plus prefix)
 	Sign(ctx context.Context, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error)
+	// SignTypedData - request to sign the given EIP-712 typed structured data
+	SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data ethapi.TypedData) (hexutil.Bytes, error)
+	// SignHeader - request to seal a block header produced by the given account
+	SignHeader(ctx context.Context, addr common.MixedcaseAddress, header *types.Header) (hexutil.Bytes, error)
 	// EcRecover - request to perform ecrecover
 	EcRecover(ctx context.Context, data, sig hexutil.Bytes) (common.Address, error)
 	// Export - request to export an account
@@ -160,6 +168,11 @@ type (
 		Message string                  `json:"message"`
 		Hash    hexutil.Bytes           `json:"hash"`
 		Meta    Metadata                `json:"meta"`
+
+		// Typed data requests carry the data and its decoded domain and message
+		TypedData *ethapi.TypedData       `json:"typed_data,omitempty"`
+		Domain    []*ethapi.NameValueType `json:"domain,omitempty"`
+		Fields    []*ethapi.NameValueType `json:"fields,omitempty"`
 	}
 	SignDataResponse struct {
 		Approved bool `json:"approved"`
@@ -187,7 +200,17 @@ type (
 	}
 )
 
-var ErrRequestDenied = errors.New("Request denied")
+var (
+	ErrRequestDenied = errors.New("Request denied")
+
+	// ErrHeaderProducer is returned if a header is requested to be sealed by an
+	// account other than its producer.
+	ErrHeaderProducer = errors.New("header not produced by the signing account")
+
+	// ErrMultisigModified is returned if the UI changes a multi-signature
+	// transaction, which would invalidate the already collected signatures.
+	ErrMultisigModified = errors.New("multi-signature transaction can't be modified")
+)
 
 // NewSignerAPI creates a new API that can be used for Account management.
 // ksLocation specifies the directory where to store the password protected private
@@ -308,6 +331,10 @@ func logDiff(original *SignTxRequest, new *SignTxResponse) bool {
 		modified = true
 		log.Info("Nonce changed by UI", "was", n0, "is", n1)
 	}
+	if t0, t1 := original.Transaction.Type, new.Transaction.Type; t0 != t1 {
+		modified = true
+		log.Info("Type changed by UI", "was", t0, "is", t1)
+	}
 	return modified
 }
 
@@ -366,6 +393,72 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 
 }
 
+// SignMultisigTransaction adds the signature of signer to a transaction of a
+// multi-signature account, next to the ones collected from other key holders,
+// and returns it both as json and rlp-encoded form
+func (api *SignerAPI) SignMultisigTransaction(ctx context.Context, signer common.MixedcaseAddress, encodedTx hexutil.Bytes) (*ethapi.SignTransactionResult, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return nil, err
+	}
+	from := tx.MultisigSender()
+	if from == nil {
+		return nil, types.ErrNotMultisig
+	}
+	// Present the transaction to the UI like any other signing request
+	input := hexutil.Bytes(tx.Data())
+	args := SendTxArgs{
+		From:     common.NewMixedcaseAddress(*from),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Type:     hexutil.Uint64(tx.Type()),
+		Input:    &input,
+	}
+	if to := tx.To(); to != nil {
+		recipient := common.NewMixedcaseAddress(*to)
+		args.To = &recipient
+	}
+	msgs, err := api.validator.ValidateTransaction(&args, nil)
+	if err != nil {
+		return nil, err
+	}
+	req := SignTxRequest{
+		Transaction: args,
+		Meta:        MetadataFromContext(ctx),
+		Callinfo:    msgs.Messages,
+	}
+	result, err := api.UI.ApproveTx(&req)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Approved {
+		return nil, ErrRequestDenied
+	}
+	if logDiff(&req, &result) {
+		return nil, ErrMultisigModified
+	}
+	acc := accounts.Account{Address: signer.Address()}
+	wallet, err := api.am.Find(acc)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := wallet.SignTxWithPassphrase(acc, result.Password, tx, api.chainID)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	rlpdata, err := rlp.EncodeToBytes(signedTx)
+	if err != nil {
+		return nil, err
+	}
+	response := ethapi.SignTransactionResult{Raw: rlpdata, Tx: signedTx}
+
+	api.UI.OnApprovedTx(response)
+	return &response, nil
+}
+
 // Sign calculates an Ethereum ECDSA signature for:
 // keccack256("\x19Ethereum Signed Message:\n" + len(message) + message))
 //
@@ -404,6 +497,90 @@ func (api *SignerAPI) Sign(ctx context.Context, addr common.MixedcaseAddress, da
 	return signature, nil
 }
 
+// SignTypedData calculates an ECDSA signature of EIP-712 typed structured data:
+// keccak256("\x19\x01" ‖ hashStruct(domain) ‖ hashStruct(message))
+//
+// The decoded domain and message are shown to the user for approval, so they
+// know what they sign, unlike for opaque data.
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+func (api *SignerAPI) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data ethapi.TypedData) (hexutil.Bytes, error) {
+	sighash, err := data.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	domain, err := data.FormatDomain()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := data.Format()
+	if err != nil {
+		return nil, err
+	}
+	rawdata, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("Typed %s data", data.PrimaryType)
+	req := &SignDataRequest{Address: addr, Rawdata: rawdata, Message: msg, Hash: sighash, Meta: MetadataFromContext(ctx),
+		TypedData: &data, Domain: domain, Fields: fields}
+	res, err := api.UI.ApproveSignData(req)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: addr.Address()}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashWithPassphrase(account, res.Password, sighash)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
+// SignHeader seals a block header produced by the given account, returning the
+// producer seal over dpos.SealHash(header) in the [R || S || V] format where V
+// is 0 or 1. The hash is derived here rather than accepted from the caller, so
+// the approval is for a block and never for an opaque hash.
+func (api *SignerAPI) SignHeader(ctx context.Context, addr common.MixedcaseAddress, header *types.Header) (hexutil.Bytes, error) {
+	if header.Coinbase != addr.Address() {
+		return nil, ErrHeaderProducer
+	}
+	sighash := dpos.SealHash(header)
+	rawdata, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("Block #%v with parent %x produced by %s", header.Number, header.ParentHash, header.Coinbase.Hex())
+	req := &SignDataRequest{Address: addr, Rawdata: rawdata, Message: msg, Hash: sighash[:], Meta: MetadataFromContext(ctx)}
+	res, err := api.UI.ApproveSignData(req)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: addr.Address()}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashWithPassphrase(account, res.Password, sighash[:])
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	return signature, nil
+}
+
 // EcRecover returns the address for the Account that was used to create the signature.
 // Note, this function is compatible with eth_sign and personal_sign. As such it recovers
 // the address of:
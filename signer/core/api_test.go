@@ -19,6 +19,7 @@ package core
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -32,6 +33,7 @@ import (
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/hexutil"
 	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
 	"github.com/yooba-team/yooba/internal/ethapi"
 	"github.com/yooba-team/yooba/rlp"
 )
@@ -254,6 +256,116 @@ func TestSignData(t *testing.T) {
 		t.Errorf("Expected 65 byte signature (got %d bytes)", len(h))
 	}
 }
+// jsonTypedData is the example typed data of EIP-712.
+const jsonTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestSignTypedData(t *testing.T) {
+	var data ethapi.TypedData
+	if err := json.Unmarshal([]byte(jsonTypedData), &data); err != nil {
+		t.Fatal(err)
+	}
+	// Check the hashing against the test vectors of EIP-712
+	if have, want := string(data.EncodeType("Mail")), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; have != want {
+		t.Errorf("type encoding mismatch: have %s, want %s", have, want)
+	}
+	domainSeparator, err := data.HashStruct("EIP712Domain", data.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := hexutil.Encode(domainSeparator), "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"; have != want {
+		t.Errorf("domain separator mismatch: have %s, want %s", have, want)
+	}
+	sighash, err := data.SigHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := hexutil.Encode(sighash), "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; have != want {
+		t.Errorf("signature hash mismatch: have %s, want %s", have, want)
+	}
+	// Sign the typed data and check the decoded fields shown to the user
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0].Address)
+
+	control <- "No way"
+	if _, err := api.SignTypedData(context.Background(), a, data); err != ErrRequestDenied {
+		t.Errorf("Expected ErrRequestDenied! %v", err)
+	}
+	control <- "Y"
+	control <- "apassword"
+	sig, err := api.SignTypedData(context.Background(), a, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+		t.Fatalf("invalid signature %x", sig)
+	}
+	sig[64] -= 27
+	pubkey, err := crypto.SigToPub(sighash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != list[0].Address {
+		t.Errorf("signer mismatch: have %x, want %x", signer, list[0].Address)
+	}
+	fields, err := data.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 3 || fields[0].Name != "from" || fields[2].Value != "Hello, Bob!" {
+		t.Fatalf("invalid decoded fields %v", fields)
+	}
+	from := fields[0].Value.([]*ethapi.NameValueType)
+	if from[1].Value != "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826" {
+		t.Errorf("decoded wallet mismatch: have %v", from[1].Value)
+	}
+	// Reject data not matching its types
+	delete(data.Message, "contents")
+	if _, err := data.SigHash(); err == nil {
+		t.Errorf("expected error for message with missing field")
+	}
+	data.Message["contents"] = "Hello, Bob!"
+	data.Message["extra"] = "not signed"
+	if _, err := data.SigHash(); err == nil {
+		t.Errorf("expected error for message with unknown field")
+	}
+}
+
 func mkTestTx(from common.MixedcaseAddress) SendTxArgs {
 	to := common.NewMixedcaseAddress(common.HexToAddress("0x1337"))
 	gas := hexutil.Uint64(21000)
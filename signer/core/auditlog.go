@@ -24,6 +24,7 @@ import (
 	"github.com/yooba-team/yooba/accounts"
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/internal/ethapi"
 	"github.com/yooba-team/yooba/log"
 )
@@ -64,6 +65,19 @@ func (l *AuditLogger) SignTransaction(ctx context.Context, args SendTxArgs, meth
 	return res, e
 }
 
+func (l *AuditLogger) SignMultisigTransaction(ctx context.Context, signer common.MixedcaseAddress, encodedTx hexutil.Bytes) (*ethapi.SignTransactionResult, error) {
+	l.log.Info("SignMultisigTransaction", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"signer", signer.String(), "tx", common.Bytes2Hex(encodedTx))
+
+	res, e := l.api.SignMultisigTransaction(ctx, signer, encodedTx)
+	if res != nil {
+		l.log.Info("SignMultisigTransaction", "type", "response", "data", common.Bytes2Hex(res.Raw), "error", e)
+	} else {
+		l.log.Info("SignMultisigTransaction", "type", "response", "data", res, "error", e)
+	}
+	return res, e
+}
+
 func (l *AuditLogger) Sign(ctx context.Context, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
 	l.log.Info("Sign", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"addr", addr.String(), "data", common.Bytes2Hex(data))
@@ -72,6 +86,22 @@ func (l *AuditLogger) Sign(ctx context.Context, addr common.MixedcaseAddress, da
 	return b, e
 }
 
+func (l *AuditLogger) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data ethapi.TypedData) (hexutil.Bytes, error) {
+	l.log.Info("SignTypedData", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "primary", data.PrimaryType, "domain", data.Domain, "message", data.Message)
+	b, e := l.api.SignTypedData(ctx, addr, data)
+	l.log.Info("SignTypedData", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	return b, e
+}
+
+func (l *AuditLogger) SignHeader(ctx context.Context, addr common.MixedcaseAddress, header *types.Header) (hexutil.Bytes, error) {
+	l.log.Info("SignHeader", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "number", header.Number, "parent", header.ParentHash.Hex())
+	b, e := l.api.SignHeader(ctx, addr, header)
+	l.log.Info("SignHeader", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	return b, e
+}
+
 func (l *AuditLogger) EcRecover(ctx context.Context, data, sig hexutil.Bytes) (common.Address, error) {
 	l.log.Info("EcRecover", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"data", common.Bytes2Hex(data))
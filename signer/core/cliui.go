@@ -26,6 +26,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/yooba-team/yooba/common"
+	yoocore "github.com/yooba-team/yooba/core"
 	"github.com/yooba-team/yooba/internal/ethapi"
 	"github.com/yooba-team/yooba/log"
 	"golang.org/x/crypto/ssh/terminal"
@@ -113,6 +114,9 @@ func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 	}
 	fmt.Printf("from:  %v\n", request.Transaction.From.String())
 	fmt.Printf("value: %v wei\n", weival)
+	if name, ok := yoocore.TxTypeName(uint(request.Transaction.Type)); ok {
+		fmt.Printf("type:  %v\n", name)
+	}
 	if request.Transaction.Data != nil {
 		d := *request.Transaction.Data
 		if len(d) > 0 {
@@ -144,7 +148,19 @@ func (ui *CommandlineUI) ApproveSignData(request *SignDataRequest) (SignDataResp
 	fmt.Printf("-------- Sign data request--------------\n")
 	fmt.Printf("Account:  %s\n", request.Address.String())
 	fmt.Printf("message:  \n%q\n", request.Message)
-	fmt.Printf("raw data: \n%v\n", request.Rawdata)
+	if request.TypedData != nil {
+		// Show the decoded typed data instead of its JSON encoding
+		fmt.Printf("domain:\n")
+		for _, field := range request.Domain {
+			fmt.Print(field.Pprint(1))
+		}
+		fmt.Printf("%s:\n", request.TypedData.PrimaryType)
+		for _, field := range request.Fields {
+			fmt.Print(field.Pprint(1))
+		}
+	} else {
+		fmt.Printf("raw data: \n%v\n", request.Rawdata)
+	}
 	fmt.Printf("message hash:  %v\n", request.Hash)
 	fmt.Printf("-------------------------------------------\n")
 	showMetadata(request.Meta)
@@ -23,6 +23,8 @@ import (
 	"math/big"
 
 	"github.com/yooba-team/yooba/common"
+	yoocore "github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/types"
 )
 
 // The validation package contains validation checks for transactions
@@ -120,6 +122,20 @@ func (v *Validator) validate(msgs *ValidationMessages, txargs *SendTxArgs, metho
 	if txargs.Data != nil {
 		data = *txargs.Data
 	}
+	// Native transactions carry RLP payloads instead of ABI call data
+	switch typ := uint(txargs.Type); typ {
+	case types.TxTypeTransfer, types.TxTypeContract:
+	default:
+		name, ok := yoocore.TxTypeName(typ)
+		if !ok {
+			return fmt.Errorf("Unknown transaction type %d", typ)
+		}
+		if txargs.To == nil {
+			return fmt.Errorf("Native %s transaction without recipient", name)
+		}
+		msgs.info(fmt.Sprintf("Tx is a native %s transaction", name))
+		return nil
+	}
 
 	if txargs.To == nil {
 		//Contract creation should contain sufficient data to deploy a contract
@@ -63,6 +63,7 @@ func dummyTxArgs(t txtestcase) *SendTxArgs {
 		Nonce:    n,
 		GasPrice: gasPrice,
 		Gas:      gas,
+		Type:     toHexUint(t.typ),
 		Data:     data,
 		Input:    input,
 	}
@@ -70,6 +71,7 @@ func dummyTxArgs(t txtestcase) *SendTxArgs {
 
 type txtestcase struct {
 	from, to, n, g, gp, value, d, i string
+	typ                             string
 	expectErr                       bool
 	numMessages                     int
 }
@@ -108,6 +110,15 @@ func TestValidator(t *testing.T) {
 		// Small payload for create
 		{from: "000000000000000000000000000000000000dead", to: "",
 			n: "0x01", g: "0x20", gp: "0x40", value: "0x01", d: "0x01", numMessages: 1},
+		// Native vote with an RLP payload, not mistaken for ABI call data
+		{from: "000000000000000000000000000000000000dead", to: "0x000000000000000000000000000000000000dEaD",
+			n: "0x01", g: "0x20", gp: "0x40", value: "0x00", d: "0x0102", typ: "0x02", numMessages: 1},
+		// Native transaction without recipient
+		{from: "000000000000000000000000000000000000dead", to: "",
+			n: "0x01", g: "0x20", gp: "0x40", value: "0x00", d: "0x0102", typ: "0x02", expectErr: true},
+		// Unknown transaction type
+		{from: "000000000000000000000000000000000000dead", to: "0x000000000000000000000000000000000000dEaD",
+			n: "0x01", g: "0x20", gp: "0x40", value: "0x00", typ: "0x63", expectErr: true},
 	}
 	for i, test := range testcases {
 		msgs, err := v.ValidateTransaction(dummyTxArgs(test), nil)
@@ -464,7 +464,7 @@ func dummySigned(value *big.Int) *types.Transaction {
 	gas := uint64(21000)
 	gasPrice := big.NewInt(2000000)
 	data := make([]byte, 0)
-	return types.NewTransaction(3, to, value, gas, gasPrice, data)
+	return types.NewTransaction(3, to, value, gas, gasPrice, types.TxTypeTransfer, data)
 
 }
 func TestLimitWindow(t *testing.T) {
@@ -629,3 +629,63 @@ function ApproveSignData(r){
 		t.Fatalf("Expected approved")
 	}
 }
+
+func TestSignTypedData(t *testing.T) {
+
+	js := `function ApproveSignData(r){
+    if(r.typed_data && r.typed_data.primaryType == "Order")
+    {
+        if(r.typed_data.domain.name == "Storefront" && r.typed_data.message.amount <= 100){
+            return "Approve"
+        }
+        return "Reject"
+    }
+    // Otherwise goes to manual processing
+}`
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Errorf("Couldn't create evaluator %v", err)
+		return
+	}
+	addr, _ := mixAddr("0x694267f14675d7e1b9494fd8d72fefe1755710fa")
+	order := func(amount float64) *core.SignDataRequest {
+		data := &ethapi.TypedData{
+			Types: ethapi.TypedDataTypes{
+				"EIP712Domain": {{Name: "name", Type: "string"}},
+				"Order":        {{Name: "item", Type: "string"}, {Name: "amount", Type: "uint256"}},
+			},
+			PrimaryType: "Order",
+			Domain:      map[string]interface{}{"name": "Storefront"},
+			Message:     map[string]interface{}{"item": "shoes", "amount": amount},
+		}
+		hash, err := data.SigHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields, err := data.Format()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &core.SignDataRequest{
+			Address:   *addr,
+			Hash:      hash,
+			Meta:      core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+			TypedData: data,
+			Fields:    fields,
+		}
+	}
+	resp, err := r.ApproveSignData(order(42))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !resp.Approved {
+		t.Fatalf("Expected approved")
+	}
+	resp, err = r.ApproveSignData(order(1000))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if resp.Approved {
+		t.Fatalf("Expected rejected")
+	}
+}
@@ -51,10 +51,20 @@ var Forks = map[string]*params.ChainConfig{
 	"HomesteadToDaoAt5": {
 		ChainId:        big.NewInt(1),
 	},
+	"Constantinople": {
+		ChainId:             big.NewInt(1),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+	},
 	"EIP158ToByzantiumAt5": {
 		ChainId:        big.NewInt(1),
 		ByzantiumBlock: big.NewInt(5),
 	},
+	"ByzantiumToConstantinopleAt5": {
+		ChainId:             big.NewInt(1),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(5),
+	},
 }
 
 // UnsupportedForkError is returned when a test requests a fork that isn't implemented.
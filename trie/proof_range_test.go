@@ -0,0 +1,165 @@
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// rangeEntry is a leaf of a trie used for range proof testing.
+type rangeEntry struct {
+	k, v []byte
+}
+
+// randomRangeTrie creates a trie of n random leaves with 32 byte keys, returning
+// the leaves sorted by key.
+func randomRangeTrie(n int) (*Trie, []rangeEntry) {
+	trie := &Trie{db: NewDatabase(yoobadb.NewMemDatabase())}
+	entries := make([]rangeEntry, 0, n)
+	seen := make(map[string]bool)
+	for len(entries) < n {
+		key := make([]byte, 32)
+		rand.Read(key)
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+
+		value := make([]byte, 1+rand.Intn(40))
+		rand.Read(value)
+
+		trie.Update(key, value)
+		entries = append(entries, rangeEntry{key, value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].k, entries[j].k) < 0 })
+	return trie, entries
+}
+
+// proveRange creates the edge proofs of a range starting at origin and ending at
+// the last of the given entries.
+func proveRange(t *testing.T, trie *Trie, origin []byte, entries []rangeEntry) *yoobadb.MemDatabase {
+	proof := yoobadb.NewMemDatabase()
+	if err := trie.Prove(origin, 0, proof); err != nil {
+		t.Fatalf("failed to prove origin: %v", err)
+	}
+	if len(entries) > 0 {
+		if err := trie.Prove(entries[len(entries)-1].k, 0, proof); err != nil {
+			t.Fatalf("failed to prove last key: %v", err)
+		}
+	}
+	return proof
+}
+
+// nextKey returns the key following the given one, or nil if the last byte of
+// the key can't be incremented.
+func nextKey(key []byte) []byte {
+	if key[len(key)-1] == 0xff {
+		return nil
+	}
+	next := common.CopyBytes(key)
+	next[len(next)-1]++
+	return next
+}
+
+func splitEntries(entries []rangeEntry) ([][]byte, [][]byte) {
+	keys, values := make([][]byte, len(entries)), make([][]byte, len(entries))
+	for i, entry := range entries {
+		keys[i], values[i] = entry.k, entry.v
+	}
+	return keys, values
+}
+
+// Tests that random ranges with existent and non-existent origins are proven
+// correctly, and that the continuation flag is reported correctly.
+func TestRangeProof(t *testing.T) {
+	trie, entries := randomRangeTrie(500)
+	root := trie.Hash()
+
+	for i := 0; i < 200; i++ {
+		start := rand.Intn(len(entries))
+		end := start + 1 + rand.Intn(len(entries)-start)
+		chunk := entries[start:end]
+
+		origins := [][]byte{chunk[0].k}
+		if start > 0 {
+			// Any key between the previous leaf and the first one is a valid origin
+			if origin := nextKey(entries[start-1].k); origin != nil && !bytes.Equal(origin, chunk[0].k) {
+				origins = append(origins, origin)
+			}
+		}
+		for _, origin := range origins {
+			keys, values := splitEntries(chunk)
+			more, err := VerifyRangeProof(root, origin, keys, values, proveRange(t, trie, origin, chunk))
+			if err != nil {
+				t.Fatalf("range [%d, %d): failed to verify proof: %v", start, end, err)
+			}
+			if more != (end < len(entries)) {
+				t.Fatalf("range [%d, %d): continuation mismatch: have %v, want %v", start, end, more, end < len(entries))
+			}
+		}
+	}
+}
+
+// Tests that the whole trie can be verified without any proof, and that an
+// empty range after the last leaf is proven to be the end of the trie.
+func TestRangeProofEdges(t *testing.T) {
+	trie, entries := randomRangeTrie(100)
+	root := trie.Hash()
+
+	keys, values := splitEntries(entries)
+	if _, err := VerifyRangeProof(root, nil, keys, values, nil); err != nil {
+		t.Fatalf("failed to verify whole trie: %v", err)
+	}
+	if _, err := VerifyRangeProof(root, nil, keys[1:], values[1:], nil); err == nil {
+		t.Fatalf("incomplete trie accepted without proof")
+	}
+	origin := nextKey(entries[len(entries)-1].k)
+	if origin == nil {
+		t.Skip("last leaf has the largest key of its length")
+	}
+	if more, err := VerifyRangeProof(root, origin, nil, nil, proveRange(t, trie, origin, nil)); err != nil || more {
+		t.Fatalf("empty tail range: have more %v, err %v", more, err)
+	}
+	origin = entries[len(entries)-2].k
+	if _, err := VerifyRangeProof(root, origin, nil, nil, proveRange(t, trie, origin, nil)); err == nil {
+		t.Fatalf("empty range accepted in front of existing leaves")
+	}
+}
+
+// Tests that tampered ranges are rejected.
+func TestBadRangeProof(t *testing.T) {
+	trie, entries := randomRangeTrie(500)
+	root := trie.Hash()
+
+	for i := 0; i < 100; i++ {
+		start := rand.Intn(len(entries) - 3)
+		end := start + 3 + rand.Intn(len(entries)-start-3)
+		chunk := entries[start:end]
+
+		keys, values := splitEntries(chunk)
+		proof := proveRange(t, trie, keys[0], chunk)
+
+		index := rand.Intn(len(keys))
+		switch rand.Intn(3) {
+		case 0:
+			// Modify a value
+			values[index] = append(common.CopyBytes(values[index]), 0x01)
+		case 1:
+			// Drop an inner leaf
+			index = 1 + rand.Intn(len(keys)-2)
+			keys = append(keys[:index:index], keys[index+1:]...)
+			values = append(values[:index:index], values[index+1:]...)
+		case 2:
+			// Swap two neighbouring leaves
+			index = rand.Intn(len(keys) - 1)
+			keys[index], keys[index+1] = keys[index+1], keys[index]
+		}
+		if _, err := VerifyRangeProof(root, keys[0], keys, values, proof); err == nil {
+			t.Fatalf("range [%d, %d): tampered range accepted", start, end)
+		}
+	}
+}
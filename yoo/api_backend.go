@@ -185,6 +185,14 @@ func (b *YooApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.yooba.TxPool().Content()
 }
 
+func (b *YooApiBackend) TxPoolLane(tx *types.Transaction) string {
+	return b.yooba.TxPool().Lane(tx)
+}
+
+func (b *YooApiBackend) TxPoolResources(addr common.Address) *core.Resources {
+	return b.yooba.TxPool().ResourceQuota(addr)
+}
+
 func (b *YooApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.yooba.TxPool().SubscribeNewTxsEvent(ch)
 }
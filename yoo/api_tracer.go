@@ -60,6 +60,14 @@ type TraceConfig struct {
 	Reexec  *uint64
 }
 
+// TraceCallConfig holds extra parameters to trace a call, overriding parts of
+// the state and header like yoo_call does.
+type TraceCallConfig struct {
+	TraceConfig
+	StateOverrides *ethapi.StateOverride
+	BlockOverrides *ethapi.BlockOverrides
+}
+
 // txTraceResult is the result of a single transaction trace.
 type txTraceResult struct {
 	Result interface{} `json:"result,omitempty"` // Trace results produced by the tracer
@@ -554,6 +562,55 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 	return api.traceTx(ctx, msg, vmctx, statedb, config)
 }
 
+// TraceCall traces a call as it would be executed on top of the given block,
+// without the need to send a transaction. The call is assembled like yoo_call
+// does.
+func (api *PrivateDebugAPI) TraceCall(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	// Retrieve the block and the state to execute the call on
+	var (
+		block   *types.Block
+		statedb *state.StateDB
+		err     error
+	)
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block = api.yoo.blockchain.GetBlockByHash(hash)
+	} else if number, _ := blockNrOrHash.Number(); number == rpc.PendingBlockNumber {
+		block, statedb = api.yoo.miner.Pending()
+	} else if number == rpc.LatestBlockNumber {
+		block = api.yoo.blockchain.CurrentBlock()
+	} else {
+		block = api.yoo.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash)
+	}
+	if statedb == nil {
+		if statedb, err = api.computeStateDB(block, reexec); err != nil {
+			return nil, err
+		}
+	}
+	// Assemble the message and its environment the same way yoo_call does
+	var (
+		traceConfig    *TraceConfig
+		stateOverrides *ethapi.StateOverride
+		blockOverrides *ethapi.BlockOverrides
+	)
+	if config != nil {
+		traceConfig = &config.TraceConfig
+		stateOverrides, blockOverrides = config.StateOverrides, config.BlockOverrides
+	}
+	msg, evm, _, err := ethapi.PrepareCall(ctx, api.yoo.ApiBackend, args, statedb, block.Header(), stateOverrides, blockOverrides, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	// Trace the call and return
+	return api.traceTx(ctx, msg, evm.Context, statedb, traceConfig)
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
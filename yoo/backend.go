@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -31,6 +32,7 @@ import (
 	"github.com/yooba-team/yooba/consensus"
 	"github.com/yooba-team/yooba/core"
 	"github.com/yooba-team/yooba/core/bloombits"
+	"github.com/yooba-team/yooba/core/state/pruner"
 	"github.com/yooba-team/yooba/core/types"
 	"github.com/yooba-team/yooba/core/vm"
 	"github.com/yooba-team/yooba/yoo/downloader"
@@ -112,6 +114,25 @@ func New(ctx *node.ServiceContext, config *Config) (*FullYooba, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Serve the ancient chain segment from the freezer
+	if dbdir := ctx.ResolvePath("chaindata"); dbdir != "" {
+		freezer := filepath.Join(dbdir, "ancient")
+		if config.DatabaseFreezer != "" {
+			freezer = ctx.ResolvePath(config.DatabaseFreezer)
+		}
+		frdb, err := rawdb.NewDatabaseWithFreezer(chainDb, freezer, config.FreezerThreshold)
+		if err != nil {
+			chainDb.Close()
+			return nil, err
+		}
+		chainDb = frdb
+	}
+	// Finish any state pruning interrupted before opening the chain
+	if ctx.ResolvePath("") != "" {
+		if err := pruner.RecoverPruning(ctx.ResolvePath(""), chainDb); err != nil {
+			return nil, err
+		}
+	}
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, config.Genesis)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
@@ -144,7 +165,7 @@ func New(ctx *node.ServiceContext, config *Config) (*FullYooba, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout, Snapshot: config.Snapshot}
 	)
 	yoo.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, yoo.chainConfig, vmConfig)
 	if err != nil {
@@ -229,6 +250,11 @@ func (yoo *FullYooba) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicEthereumAPI(yoo),
 			Public:    true,
+		}, {
+			Namespace: "dpos",
+			Version:   "1.0",
+			Service:   ethapi.NewPublicDposAPI(yoo.ApiBackend),
+			Public:    true,
 		}, {
 			Namespace: "yoo",
 			Version:   "1.0",
@@ -308,7 +334,28 @@ func (yoo *FullYooba) StartMining(local bool) error {
 		log.Error("Cannot start mining without yoobase", "err", err)
 		return fmt.Errorf("etherbase missing: %v", err)
 	}
-
+	// Let the engine seal the produced blocks with the key of the yoobase, which
+	// external signers only hand out through header signing requests. Local keys
+	// are only handed to the engine if they can sign (i.e. aren't locked).
+	if engine, ok := yoo.engine.(interface {
+		Authorize(common.Address, dpos.SignerFn)
+	}); ok {
+		account := accounts.Account{Address: eb}
+		wallet, err := yoo.accountManager.Find(account)
+		if err != nil {
+			log.Warn("Yoobase account unavailable locally, blocks past the seal fork can't be produced", "err", err)
+		} else if signer, ok := wallet.(interface {
+			SignHeader(accounts.Account, *types.Header) ([]byte, error)
+		}); ok {
+			engine.Authorize(eb, signer.SignHeader)
+		} else if _, err := wallet.SignHash(account, make([]byte, common.HashLength)); err != nil {
+			log.Warn("Yoobase account can't sign, blocks past the seal fork can't be produced", "err", err)
+		} else {
+			engine.Authorize(eb, func(account accounts.Account, header *types.Header) ([]byte, error) {
+				return wallet.SignHash(account, dpos.SealHash(header).Bytes())
+			})
+		}
+	}
 	if local {
 		// If local (CPU) mining is started, we can disable the transaction rejection
 		// mechanism introduced to speed sync times. CPU mining on mainnet is ludicrous
@@ -25,6 +25,7 @@ import (
 	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/common/hexutil"
 	"github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/rawdb"
 	"github.com/yooba-team/yooba/yoo/downloader"
 	"github.com/yooba-team/yooba/yoo/gasprice"
 	"github.com/yooba-team/yooba/params"
@@ -38,8 +39,11 @@ var DefaultConfig = Config{
 	DatabaseCache: 768,
 	TrieCache:     256,
 	TrieTimeout:   5 * time.Minute,
+	Snapshot:      true,
 	GasPrice:      big.NewInt(18 * params.Shannon),
 
+	FreezerThreshold: rawdb.DefaultFreezerThreshold,
+
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
@@ -67,6 +71,7 @@ type Config struct {
 	NetworkId uint64 // Network ID to use for selecting peers to connect to
 	SyncMode  downloader.SyncMode
 	NoPruning bool
+	Snapshot  bool // Whether to maintain a flat snapshot of the state for faster reads
 
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
@@ -76,6 +81,8 @@ type Config struct {
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	DatabaseFreezer    string `toml:",omitempty"` // Directory of the ancient block freezer, inside the chain database if empty
+	FreezerThreshold   uint64 // Number of recent blocks kept out of the freezer
 	TrieCache          int
 	TrieTimeout        time.Duration
 
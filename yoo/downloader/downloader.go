@@ -132,6 +132,7 @@ type Downloader struct {
 	stateSyncStart chan *stateSync
 	trackStateReq  chan *stateReq
 	stateCh        chan dataPack // [yoo/63] Channel receiving inbound node state data
+	snapCh         chan dataPack // [snap/1] Channel receiving inbound state ranges
 
 	// Cancellation and termination
 	cancelPeer string         // Identifier of the peer currently being used as the master (cancel on drop)
@@ -219,6 +220,7 @@ func New(mode SyncMode, stateDb yoobadb.Database, mux *event.TypeMux, chain Bloc
 		headerProcCh:   make(chan []*types.Header, 1),
 		quitCh:         make(chan struct{}),
 		stateCh:        make(chan dataPack),
+		snapCh:         make(chan dataPack),
 		stateSyncStart: make(chan *stateSync),
 		syncStatsState: stateSyncStats{
 			processed: rawdb.ReadFastTrieProgress(stateDb),
@@ -246,7 +248,7 @@ func (d *Downloader) Progress() yooba.SyncProgress {
 	switch d.mode {
 	case FullSync:
 		current = d.blockchain.CurrentBlock().NumberU64()
-	case FastSync:
+	case FastSync, SnapSync:
 		current = d.blockchain.CurrentFastBlock().NumberU64()
 	case LightSync:
 		current = d.lightchain.CurrentHeader().Number.Uint64()
@@ -437,7 +439,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash) (err erro
 
 	// Ensure our origin point is below any fast sync pivot point
 	pivot := uint64(0)
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		if height <= uint64(fsMinFullBlocks) {
 			origin = 0
 		} else {
@@ -448,7 +450,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash) (err erro
 		}
 	}
 	d.committed = 1
-	if d.mode == FastSync && pivot != 0 {
+	if (d.mode == FastSync || d.mode == SnapSync) && pivot != 0 {
 		d.committed = 0
 	}
 	// Initiate the sync using a concurrent header and content retrieval algorithm
@@ -461,9 +463,9 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash) (err erro
 		func() error { return d.fetchHeaders(p, origin+1, pivot) }, // Headers are always retrieved
 		func() error { return d.fetchBodies(origin + 1) },          // Bodies are retrieved during normal and fast sync
 		func() error { return d.fetchReceipts(origin + 1) },        // Receipts are retrieved during fast sync
-		func() error { return d.processHeaders(origin+1, pivot) },
+		func() error { return d.processHeaders(origin+1, pivot, height) },
 	}
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		fetchers = append(fetchers, func() error { return d.processFastSyncContent(latest) })
 	} else if d.mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
@@ -592,7 +594,7 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 
 	if d.mode == FullSync {
 		ceil = d.blockchain.CurrentBlock().NumberU64()
-	} else if d.mode == FastSync {
+	} else if d.mode == FastSync || d.mode == SnapSync {
 		ceil = d.blockchain.CurrentFastBlock().NumberU64()
 	}
 	if ceil >= MaxForkAncestry {
@@ -1153,8 +1155,9 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 
 // processHeaders takes batches of retrieved headers from an input channel and
 // keeps processing and scheduling them into the header chain and downloader's
-// queue until the stream ends or a failure occurs.
-func (d *Downloader) processHeaders(origin uint64, pivot uint64) error {
+// queue until the stream ends or a failure occurs. The stream has to reach the
+// head height announced by the peer.
+func (d *Downloader) processHeaders(origin uint64, pivot uint64, height uint64) error {
 	// Keep a count of uncertain headers to roll back
 	rollback := []*types.Header{}
 	defer func() {
@@ -1199,6 +1202,11 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64) error {
 					case <-d.cancelCh:
 					}
 				}
+				// If the headers stopped short of the announced head, the peer
+				// withheld them, keep the uncertain ones rolled back
+				if d.mode != FullSync && d.lightchain.CurrentHeader().Number.Uint64() < height {
+					return errStallingPeer
+				}
 				// Disable any rollback and return
 				rollback = nil
 				return nil
@@ -1220,7 +1228,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64) error {
 				chunk := headers[:limit]
 
 				// In case of header only syncing, validate the chunk immediately
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode != FullSync {
 					// Collect the yet unknown headers to mark them as uncertain
 					unknown := make([]*types.Header, 0, len(headers))
 					for _, header := range chunk {
@@ -1248,7 +1256,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64) error {
 					}
 				}
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
-				if d.mode == FullSync || d.mode == FastSync {
+				if d.mode != LightSync {
 					// If we've reached the allowed number of pending headers, stall a bit
 					for d.queue.PendingBlocks() >= maxQueuedHeaders || d.queue.PendingReceipts() >= maxQueuedHeaders {
 						select {
@@ -1481,6 +1489,9 @@ func (d *Downloader) commitPivotBlock(result *fetchResult) error {
 	if err := d.blockchain.FastSyncCommitHead(block.Hash()); err != nil {
 		return err
 	}
+	if d.mode == SnapSync {
+		rawdb.DeleteSnapSyncStatus(d.stateDB)
+	}
 	atomic.StoreInt32(&d.committed, 1)
 	return nil
 }
@@ -1506,6 +1517,24 @@ func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
 	return d.deliver(id, d.stateCh, &statePack{id, data}, stateInMeter, stateDropMeter)
 }
 
+// DeliverAccountRange injects a new range of accounts received from a remote
+// node, along with the merkle proofs of its edges.
+func (d *Downloader) DeliverAccountRange(id string, reqID uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) (err error) {
+	return d.deliver(id, d.snapCh, &accountPack{id, reqID, hashes, accounts, proof}, snapInMeter, snapDropMeter)
+}
+
+// DeliverStorageRanges injects a new batch of storage slot ranges received from
+// a remote node, along with the merkle proofs of the last range if partial.
+func (d *Downloader) DeliverStorageRanges(id string, reqID uint64, hashes [][]common.Hash, slots [][][]byte, proof [][]byte) (err error) {
+	return d.deliver(id, d.snapCh, &storagePack{id, reqID, hashes, slots, proof}, snapInMeter, snapDropMeter)
+}
+
+// DeliverByteCodes injects a new batch of contract codes received from a remote
+// node.
+func (d *Downloader) DeliverByteCodes(id string, reqID uint64, codes [][]byte) (err error) {
+	return d.deliver(id, d.snapCh, &codePack{id, reqID, codes}, snapInMeter, snapDropMeter)
+}
+
 // deliver injects a new batch of data received from a remote node.
 func (d *Downloader) deliver(id string, destCh chan dataPack, packet dataPack, inMeter, dropMeter metrics.Meter) (err error) {
 	// Update the delivery metrics for both good and failed deliveries
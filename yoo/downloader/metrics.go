@@ -40,4 +40,7 @@ var (
 
 	stateInMeter   = metrics.NewRegisteredMeter("yoo/downloader/states/in",nil)
 	stateDropMeter = metrics.NewRegisteredMeter("yoo/downloader/states/drop",nil)
+
+	snapInMeter   = metrics.NewRegisteredMeter("yoo/downloader/snap/in", nil)
+	snapDropMeter = metrics.NewRegisteredMeter("yoo/downloader/snap/drop", nil)
 )
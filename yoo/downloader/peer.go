@@ -42,6 +42,7 @@ var (
 	errAlreadyFetching   = errors.New("already fetching blocks from peer")
 	errAlreadyRegistered = errors.New("peer is already registered")
 	errNotRegistered     = errors.New("peer is not registered")
+	errNoSnapPeer        = errors.New("peer doesn't serve state ranges")
 )
 
 // peerConnection represents an active peer from which hashes and blocks are retrieved.
@@ -52,6 +53,7 @@ type peerConnection struct {
 	blockIdle   int32 // Current block activity state of the peer (idle = 0, active = 1)
 	receiptIdle int32 // Current receipt activity state of the peer (idle = 0, active = 1)
 	stateIdle   int32 // Current node data activity state of the peer (idle = 0, active = 1)
+	snapIdle    int32 // Current state range activity state of the peer (idle = 0, active = 1)
 
 	headerThroughput  float64 // Number of headers measured to be retrievable per second
 	blockThroughput   float64 // Number of blocks (bodies) measured to be retrievable per second
@@ -89,6 +91,16 @@ type Peer interface {
 	RequestNodeData([]common.Hash) error
 }
 
+// SnapPeer encapsulates the methods required to synchronise the state from a
+// remote peer in contiguous ranges. It's optional, only peers also implementing
+// it on top of Peer and running the state range protocol are used for snap sync.
+type SnapPeer interface {
+	SnapEnabled() bool
+	RequestAccountRange(id uint64, root common.Hash, origin, limit common.Hash, bytes uint64) error
+	RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error
+	RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error
+}
+
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
 type lightPeerWrapper struct {
 	peer LightPeer
@@ -133,6 +145,7 @@ func (p *peerConnection) Reset() {
 	atomic.StoreInt32(&p.blockIdle, 0)
 	atomic.StoreInt32(&p.receiptIdle, 0)
 	atomic.StoreInt32(&p.stateIdle, 0)
+	atomic.StoreInt32(&p.snapIdle, 0)
 
 	p.headerThroughput = 0
 	p.blockThroughput = 0
@@ -221,6 +234,60 @@ func (p *peerConnection) FetchNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// FetchAccountRange sends a range of accounts retrieval request to the remote
+// peer.
+func (p *peerConnection) FetchAccountRange(id uint64, root common.Hash, origin, limit common.Hash, bytes uint64) error {
+	snap, err := p.snapPeer()
+	if err != nil {
+		return err
+	}
+	if !atomic.CompareAndSwapInt32(&p.snapIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	go snap.RequestAccountRange(id, root, origin, limit, bytes)
+
+	return nil
+}
+
+// FetchStorageRanges sends a storage slot ranges retrieval request to the remote
+// peer.
+func (p *peerConnection) FetchStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	snap, err := p.snapPeer()
+	if err != nil {
+		return err
+	}
+	if !atomic.CompareAndSwapInt32(&p.snapIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	go snap.RequestStorageRanges(id, root, accounts, origin, limit, bytes)
+
+	return nil
+}
+
+// FetchByteCodes sends a contract code retrieval request to the remote peer.
+func (p *peerConnection) FetchByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	snap, err := p.snapPeer()
+	if err != nil {
+		return err
+	}
+	if !atomic.CompareAndSwapInt32(&p.snapIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	go snap.RequestByteCodes(id, hashes, bytes)
+
+	return nil
+}
+
+// snapPeer returns the state range retrieval methods of the remote peer, or an
+// error if it doesn't run the state range protocol (anymore).
+func (p *peerConnection) snapPeer() (SnapPeer, error) {
+	snap, ok := p.peer.(SnapPeer)
+	if !ok || !snap.SnapEnabled() {
+		return nil, errNoSnapPeer
+	}
+	return snap, nil
+}
+
 // SetHeadersIdle sets the peer to idle, allowing it to execute new header retrieval
 // requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
@@ -256,6 +323,12 @@ func (p *peerConnection) SetNodeDataIdle(delivered int) {
 	p.setIdle(p.stateStarted, delivered, &p.stateThroughput, &p.stateIdle)
 }
 
+// SetSnapIdle sets the peer to idle, allowing it to execute new state range
+// retrieval requests.
+func (p *peerConnection) SetSnapIdle() {
+	atomic.StoreInt32(&p.snapIdle, 0)
+}
+
 // setIdle sets the peer to idle, allowing it to execute new retrieval requests.
 // Its estimated retrieval throughput is updated with that measured just now.
 func (p *peerConnection) setIdle(started time.Time, delivered int, throughput *float64, idle *int32) {
@@ -521,6 +594,24 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
 	return ps.idlePeers(63, 64, idle, throughput)
 }
 
+// SnapIdlePeers retrieves a flat list of all the currently state-range-idle peers
+// capable of serving state ranges within the active peer set, ordered by their
+// reputation.
+func (ps *peerSet) SnapIdlePeers() ([]*peerConnection, int) {
+	idle := func(p *peerConnection) bool {
+		if snap, ok := p.peer.(SnapPeer); !ok || !snap.SnapEnabled() {
+			return false
+		}
+		return atomic.LoadInt32(&p.snapIdle) == 0
+	}
+	throughput := func(p *peerConnection) float64 {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.stateThroughput
+	}
+	return ps.idlePeers(63, 64, idle, throughput)
+}
+
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
 // protocol version constraints, using the provided function to check idleness.
 // The resulting set of peers are sorted by their measure throughput.
@@ -0,0 +1,695 @@
+package downloader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+const (
+	// snapAccountConcurrency is the number of chunks to split the account hash
+	// space into, to retrieve them concurrently.
+	snapAccountConcurrency = 16
+
+	// snapRequestBytes is the soft limit of the response size requested from a
+	// peer in a single state range request.
+	snapRequestBytes = 512 * 1024
+
+	// snapMaxStorageAccounts is the maximum number of accounts to request the
+	// storage slots of in a single request.
+	snapMaxStorageAccounts = 128
+
+	// snapMaxCodes is the maximum number of contract codes to request in a single
+	// request.
+	snapMaxCodes = 64
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+
+	// maxHash is the last hash of the hash space.
+	maxHash = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+	errInvalidRange = errors.New("invalid state range")
+)
+
+// snapStatus is the persisted progress of a snap sync, allowing it to resume
+// after a restart, even if the sync target moved in the meantime.
+type snapStatus struct {
+	Root  common.Hash    // Root of the account trie assembled so far
+	Tasks []*accountTask // Account ranges still to retrieve
+}
+
+// accountTask is a contiguous range of the account hash space to retrieve. All
+// accounts before Next are in the local account trie along with their storage
+// tries and codes.
+type accountTask struct {
+	Next common.Hash // Next account hash to retrieve
+	Last common.Hash // Last account hash of the range
+
+	pend  bool          // Whether an account range request is in flight
+	batch *accountBatch // Retrieved accounts waiting for their storage and codes
+}
+
+// accountBatch is a range of retrieved accounts. They are only inserted into the
+// account trie once all their storage slots and codes are retrieved too, so the
+// assembled trie never references missing state.
+type accountBatch struct {
+	hashes   []common.Hash
+	accounts [][]byte
+	cont     bool // Whether the task has more accounts after this batch
+
+	storage map[common.Hash]*storageTask // Storage tries still to retrieve, by account hash
+	codes   map[common.Hash]bool         // Contract codes still to retrieve, with their request status
+}
+
+// complete reports whether all storage slots and codes of the batch are retrieved.
+func (b *accountBatch) complete() bool {
+	return len(b.storage) == 0 && len(b.codes) == 0
+}
+
+// storageTask is the storage trie of an account being retrieved.
+type storageTask struct {
+	root common.Hash // Root of the storage trie
+	next common.Hash // Next slot hash to retrieve
+	trie *trie.Trie  // Storage trie assembled so far, nil if nothing retrieved yet
+	pend bool        // Whether a storage range request is in flight
+}
+
+// snapRequest is an in-flight state range request. Depending on the fields set,
+// it's a request for contract codes, storage slots or accounts.
+type snapRequest struct {
+	id    uint64
+	peer  *peerConnection
+	timer *time.Timer
+
+	task     *accountTask  // Task the request belongs to
+	origin   common.Hash   // Hash of the first account or storage slot requested
+	accounts []common.Hash // Accounts of the storage tries requested
+	codes    []common.Hash // Hashes of the contract codes requested
+}
+
+// snapSync retrieves the state of a given root in contiguous ranges of accounts
+// and storage slots proven against the root, along with the contract codes, and
+// assembles the tries locally. Accounts retrieved while the sync target moved
+// leave the assembled account trie stale, it's fixed up by healing it through
+// node data retrieval afterwards.
+type snapSync struct {
+	d    *Downloader // Downloader instance to access and manage current peerset
+	root common.Hash // State root currently being synced
+
+	triedb  *trie.Database // Database to assemble the tries in
+	accTrie *trie.Trie     // Account trie assembled so far
+	accRoot common.Hash    // Root of the account trie persisted so far
+	tasks   []*accountTask // Account ranges still to retrieve
+
+	reqs      map[uint64]*snapRequest // Currently in-flight requests
+	nextID    uint64                  // Identifier of the next request
+	stateless map[string]bool         // Peers not serving the synced state
+
+	accounts, slots, codes uint64             // Number of state entries retrieved
+	size                   common.StorageSize // Size of the state entries retrieved
+	start, logged          time.Time          // Timestamps for the progress reports
+
+	deliver chan dataPack     // Delivery channel multiplexing peer responses
+	timeout chan *snapRequest // Channel of the requests timed out
+	done    chan struct{}     // Channel to signal termination completion
+}
+
+// newSnapSync creates a new state range download scheduler. This method does
+// not yet start the sync. The user needs to call run to initiate.
+func newSnapSync(d *Downloader, root common.Hash) *snapSync {
+	return &snapSync{
+		d:         d,
+		root:      root,
+		triedb:    trie.NewDatabase(d.stateDB),
+		reqs:      make(map[uint64]*snapRequest),
+		nextID:    rand.Uint64(),
+		stateless: make(map[string]bool),
+		deliver:   make(chan dataPack),
+		timeout:   make(chan *snapRequest),
+		done:      make(chan struct{}),
+	}
+}
+
+// run retrieves the state ranges until all of them are assembled locally, or the
+// sync is canceled.
+func (s *snapSync) run(cancel chan struct{}) error {
+	defer close(s.done)
+
+	if s.root == emptyRoot {
+		return nil
+	}
+	s.loadStatus()
+	if len(s.tasks) > 0 {
+		log.Info("Starting state range sync", "root", s.root, "ranges", len(s.tasks))
+	}
+	s.start, s.logged = time.Now(), time.Now()
+
+	// Listen for new peers and departures to assign and cancel requests
+	newPeer := make(chan *peerConnection, 1024)
+	newPeerSub := s.d.peers.SubscribeNewPeers(newPeer)
+	defer newPeerSub.Unsubscribe()
+
+	peerDrop := make(chan *peerConnection, 1024)
+	peerDropSub := s.d.peers.SubscribePeerDrops(peerDrop)
+	defer peerDropSub.Unsubscribe()
+
+	quit := make(chan struct{})
+	defer func() {
+		close(quit)
+		for _, req := range s.reqs {
+			req.timer.Stop()
+			req.peer.SetSnapIdle()
+		}
+	}()
+	for len(s.tasks) > 0 {
+		s.assignTasks(quit)
+
+		select {
+		case <-newPeer:
+			// New peer arrived, try to assign it download tasks
+
+		case p := <-peerDrop:
+			for _, req := range s.reqs {
+				if req.peer.id == p.id {
+					req.timer.Stop()
+					s.revert(req)
+				}
+			}
+
+		case req := <-s.timeout:
+			// Skip the stale timeout if the response arrived simultaneously
+			if s.reqs[req.id] != req {
+				continue
+			}
+			req.peer.log.Debug("State range request timed out", "reqid", req.id)
+			s.revert(req)
+			req.peer.SetSnapIdle()
+
+		case pack := <-s.deliver:
+			if err := s.process(pack); err != nil {
+				return err
+			}
+
+		case <-cancel:
+			return errCancelStateFetch
+
+		case <-s.d.cancelCh:
+			return errCancelStateFetch
+		}
+	}
+	log.Info("Retrieved state ranges", "accounts", s.accounts, "slots", s.slots, "codes", s.codes, "size", s.size, "elapsed", common.PrettyDuration(time.Since(s.start)), "healing", s.accRoot != s.root)
+	return nil
+}
+
+// loadStatus resumes the progress of a previous sync, or splits the account
+// hash space into ranges to retrieve if there is none.
+func (s *snapSync) loadStatus() {
+	var status snapStatus
+	if blob := rawdb.ReadSnapSyncStatus(s.d.stateDB); blob != nil {
+		if err := rlp.DecodeBytes(blob, &status); err != nil {
+			log.Error("Failed to decode snap sync status", "err", err)
+		} else if s.accTrie, err = trie.New(status.Root, s.triedb); err != nil {
+			log.Error("Failed to open snap synced account trie", "root", status.Root, "err", err)
+		} else {
+			log.Debug("Resuming state range sync", "root", status.Root, "ranges", len(status.Tasks))
+			s.accRoot, s.tasks = status.Root, status.Tasks
+			return
+		}
+	}
+	s.accTrie, _ = trie.New(common.Hash{}, s.triedb)
+	s.accRoot, s.tasks = common.Hash{}, nil
+
+	step := new(big.Int).Sub(new(big.Int).Div(new(big.Int).Exp(common.Big2, common.Big256, nil), big.NewInt(snapAccountConcurrency)), common.Big1)
+	for next, i := (common.Hash{}), 0; i < snapAccountConcurrency; i++ {
+		last := common.BigToHash(new(big.Int).Add(next.Big(), step))
+		if i == snapAccountConcurrency-1 {
+			last = maxHash
+		}
+		s.tasks = append(s.tasks, &accountTask{Next: next, Last: last})
+		next = common.BigToHash(new(big.Int).Add(last.Big(), common.Big1))
+	}
+}
+
+// saveStatus persists the progress of the sync. It must only be called when the
+// account trie is flushed to disk.
+func (s *snapSync) saveStatus() {
+	blob, err := rlp.EncodeToBytes(&snapStatus{Root: s.accRoot, Tasks: s.tasks})
+	if err != nil {
+		log.Crit("Failed to encode snap sync status", "err", err)
+	}
+	rawdb.WriteSnapSyncStatus(s.d.stateDB, blob)
+}
+
+// assignTasks attempts to assign new requests to all idle peers. The contract
+// codes and storage slots of the retrieved account ranges are requested first,
+// as they hold off committing them.
+func (s *snapSync) assignTasks(quit chan struct{}) {
+	peers, _ := s.d.peers.SnapIdlePeers()
+	for _, p := range peers {
+		if s.stateless[p.id] {
+			continue
+		}
+		req := s.nextRequest()
+		if req == nil {
+			return
+		}
+		req.id, req.peer = s.nextID, p
+		s.nextID++
+
+		s.reqs[req.id] = req
+		req.timer = time.AfterFunc(s.d.requestTTL(), func() {
+			select {
+			case s.timeout <- req:
+			case <-quit:
+			}
+		})
+		var err error
+		switch {
+		case req.codes != nil:
+			err = p.FetchByteCodes(req.id, req.codes, snapRequestBytes)
+		case req.accounts != nil:
+			var origin []byte
+			if req.origin != (common.Hash{}) {
+				origin = req.origin[:]
+			}
+			err = p.FetchStorageRanges(req.id, s.root, req.accounts, origin, nil, snapRequestBytes)
+		default:
+			err = p.FetchAccountRange(req.id, s.root, req.origin, req.task.Last, snapRequestBytes)
+		}
+		if err != nil {
+			req.timer.Stop()
+			s.revert(req)
+		}
+	}
+}
+
+// nextRequest assembles the next request to send, or nil if all the remaining
+// data is already being requested.
+func (s *snapSync) nextRequest() *snapRequest {
+	for _, task := range s.tasks {
+		if task.batch == nil {
+			continue
+		}
+		var codes []common.Hash
+		for hash, pend := range task.batch.codes {
+			if !pend {
+				task.batch.codes[hash] = true
+				if codes = append(codes, hash); len(codes) == snapMaxCodes {
+					break
+				}
+			}
+		}
+		if len(codes) > 0 {
+			return &snapRequest{task: task, codes: codes}
+		}
+	}
+	for _, task := range s.tasks {
+		if task.batch == nil {
+			continue
+		}
+		// Large storage tries are retrieved alone, continuing where the last range ended
+		for account, st := range task.batch.storage {
+			if !st.pend && st.next != (common.Hash{}) {
+				st.pend = true
+				return &snapRequest{task: task, origin: st.next, accounts: []common.Hash{account}}
+			}
+		}
+		var accounts []common.Hash
+		for account, st := range task.batch.storage {
+			if !st.pend && st.next == (common.Hash{}) {
+				st.pend = true
+				if accounts = append(accounts, account); len(accounts) == snapMaxStorageAccounts {
+					break
+				}
+			}
+		}
+		if len(accounts) > 0 {
+			return &snapRequest{task: task, accounts: accounts}
+		}
+	}
+	for _, task := range s.tasks {
+		if !task.pend && task.batch == nil {
+			task.pend = true
+			return &snapRequest{task: task, origin: task.Next}
+		}
+	}
+	return nil
+}
+
+// revert drops an in-flight request, making its data available for retrieval
+// again.
+func (s *snapSync) revert(req *snapRequest) {
+	delete(s.reqs, req.id)
+
+	switch {
+	case req.codes != nil:
+		for _, hash := range req.codes {
+			if _, ok := req.task.batch.codes[hash]; ok {
+				req.task.batch.codes[hash] = false
+			}
+		}
+	case req.accounts != nil:
+		for _, account := range req.accounts {
+			if st := req.task.batch.storage[account]; st != nil {
+				st.pend = false
+			}
+		}
+	default:
+		req.task.pend = false
+	}
+}
+
+// process injects a delivered state range into the sync. Peers delivering
+// invalid data are dropped, errors are only returned for local failures.
+func (s *snapSync) process(pack dataPack) error {
+	var id uint64
+	switch pack := pack.(type) {
+	case *accountPack:
+		id = pack.id
+	case *storagePack:
+		id = pack.id
+	case *codePack:
+		id = pack.id
+	}
+	req := s.reqs[id]
+	if req == nil || req.peer.id != pack.PeerId() {
+		log.Debug("Unrequested state range", "peer", pack.PeerId(), "reqid", id, "len", pack.Items())
+		return nil
+	}
+	req.timer.Stop()
+	req.peer.SetSnapIdle()
+
+	// An empty response means the peer doesn't have the requested state
+	if pack.Items() == 0 {
+		if pack, ok := pack.(*accountPack); !ok || len(pack.proof) == 0 {
+			req.peer.log.Debug("Peer doesn't serve the synced state", "root", s.root)
+			s.stateless[req.peer.id] = true
+			s.revert(req)
+			return nil
+		}
+	}
+	var err error
+	switch pack := pack.(type) {
+	case *accountPack:
+		err = s.processAccounts(req, pack)
+	case *storagePack:
+		err = s.processStorage(req, pack)
+	case *codePack:
+		err = s.processCodes(req, pack)
+	}
+	if isRangeError(err) {
+		req.peer.log.Warn("Invalid state range delivered, dropping peer", "reqid", id, "err", err)
+		s.revert(req)
+		s.d.dropPeer(req.peer.id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	delete(s.reqs, id)
+
+	// Commit the batches completed by the delivery, in order to not keep them in
+	// memory longer than needed
+	for i := 0; i < len(s.tasks); i++ {
+		task := s.tasks[i]
+		if task.batch == nil || !task.batch.complete() {
+			continue
+		}
+		if err := s.commit(task); err != nil {
+			return err
+		}
+		if !task.batch.cont {
+			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+			i--
+		}
+		task.batch = nil
+		s.saveStatus()
+	}
+	if time.Since(s.logged) > 8*time.Second {
+		s.logged = time.Now()
+		log.Info("Syncing state ranges", "accounts", s.accounts, "slots", s.slots, "codes", s.codes, "size", s.size, "ranges", len(s.tasks), "elapsed", common.PrettyDuration(time.Since(s.start)))
+	}
+	return nil
+}
+
+// rangeError is an error caused by invalid data delivered by a peer.
+type rangeError struct {
+	err error
+}
+
+func (e *rangeError) Error() string {
+	return fmt.Sprintf("%v: %v", errInvalidRange, e.err)
+}
+
+// isRangeError reports whether the error was caused by the delivering peer.
+func isRangeError(err error) bool {
+	_, ok := err.(*rangeError)
+	return ok
+}
+
+// proofDB assembles a database of the delivered merkle proof nodes.
+func proofDB(proof [][]byte) *yoobadb.MemDatabase {
+	db := yoobadb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// processAccounts verifies a delivered account range against the requested one,
+// and schedules the retrieval of the storage tries and codes it references.
+func (s *snapSync) processAccounts(req *snapRequest, pack *accountPack) error {
+	if req.codes != nil || req.accounts != nil {
+		return &rangeError{errors.New("accounts delivered for other request")}
+	}
+	if len(pack.hashes) != len(pack.accounts) {
+		return &rangeError{fmt.Errorf("account hash count mismatch: %d hashes, %d accounts", len(pack.hashes), len(pack.accounts))}
+	}
+	keys := make([][]byte, len(pack.hashes))
+	for i := range pack.hashes {
+		keys[i] = pack.hashes[i][:]
+	}
+	cont, err := trie.VerifyRangeProof(s.root, req.origin[:], keys, pack.accounts, proofDB(pack.proof))
+	if err != nil {
+		return &rangeError{err}
+	}
+	// Crop the accounts past the end of the task's range
+	hashes, accounts := pack.hashes, pack.accounts
+	for i, hash := range hashes {
+		if cmp := bytes.Compare(hash[:], req.task.Last[:]); cmp >= 0 {
+			if cmp == 0 {
+				i++
+			}
+			hashes, accounts, cont = hashes[:i], accounts[:i], false
+			break
+		}
+	}
+	// Schedule the storage tries and codes not available locally yet
+	batch := &accountBatch{
+		hashes:   hashes,
+		accounts: accounts,
+		cont:     cont,
+		storage:  make(map[common.Hash]*storageTask),
+		codes:    make(map[common.Hash]bool),
+	}
+	for i, blob := range accounts {
+		var account state.Account
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			return &rangeError{err}
+		}
+		if account.Root != emptyRoot {
+			if ok, _ := s.d.stateDB.Has(account.Root[:]); !ok {
+				batch.storage[hashes[i]] = &storageTask{root: account.Root}
+			}
+		}
+		if hash := common.BytesToHash(account.CodeHash); hash != emptyCode {
+			if ok, _ := s.d.stateDB.Has(hash[:]); !ok {
+				batch.codes[hash] = false
+			}
+		}
+		s.size += common.StorageSize(common.HashLength + len(blob))
+	}
+	req.task.pend, req.task.batch = false, batch
+	return nil
+}
+
+// processStorage verifies the delivered storage slot ranges against the requested
+// storage tries, and inserts them into the locally assembled tries.
+func (s *snapSync) processStorage(req *snapRequest, pack *storagePack) error {
+	if req.accounts == nil {
+		return &rangeError{errors.New("storage delivered for other request")}
+	}
+	if len(pack.hashes) != len(pack.slots) || len(pack.hashes) > len(req.accounts) {
+		return &rangeError{fmt.Errorf("storage count mismatch: %d hashes, %d slots, %d requested", len(pack.hashes), len(pack.slots), len(req.accounts))}
+	}
+	batch := req.task.batch
+	defer func() {
+		for _, account := range req.accounts {
+			if st := batch.storage[account]; st != nil {
+				st.pend = false
+			}
+		}
+	}()
+	// Verify all ranges before touching any of the tries
+	conts := make([]bool, len(pack.hashes))
+	for i, hashes := range pack.hashes {
+		if len(hashes) != len(pack.slots[i]) {
+			return &rangeError{fmt.Errorf("slot hash count mismatch: %d hashes, %d slots", len(hashes), len(pack.slots[i]))}
+		}
+		st := batch.storage[req.accounts[i]]
+		if st == nil {
+			continue
+		}
+		keys := make([][]byte, len(hashes))
+		for j := range hashes {
+			keys[j] = hashes[j][:]
+		}
+		// Only the last range may be partial, the others must be complete tries
+		var err error
+		if i < len(pack.hashes)-1 || len(pack.proof) == 0 {
+			if req.origin != (common.Hash{}) {
+				return &rangeError{errors.New("missing storage range proof")}
+			}
+			_, err = trie.VerifyRangeProof(st.root, nil, keys, pack.slots[i], nil)
+		} else {
+			conts[i], err = trie.VerifyRangeProof(st.root, req.origin[:], keys, pack.slots[i], proofDB(pack.proof))
+		}
+		if err != nil {
+			return &rangeError{err}
+		}
+	}
+	for i, hashes := range pack.hashes {
+		account := req.accounts[i]
+		st := batch.storage[account]
+		if st == nil {
+			continue
+		}
+		if st.trie == nil {
+			st.trie, _ = trie.New(common.Hash{}, s.triedb)
+		}
+		for j, hash := range hashes {
+			if err := st.trie.TryUpdate(hash[:], pack.slots[i][j]); err != nil {
+				return err
+			}
+			s.size += common.StorageSize(common.HashLength + len(pack.slots[i][j]))
+		}
+		s.slots += uint64(len(hashes))
+
+		// Flush the trie assembled so far, even if partial, to keep memory in check
+		root, err := st.trie.Commit(nil)
+		if err != nil {
+			return err
+		}
+		if err := s.triedb.Commit(root, false); err != nil {
+			return err
+		}
+		if conts[i] {
+			st.next = incHash(hashes[len(hashes)-1])
+			continue
+		}
+		if root != st.root {
+			return fmt.Errorf("storage trie root mismatch: have %x, want %x", root, st.root)
+		}
+		delete(batch.storage, account)
+	}
+	return nil
+}
+
+// processCodes verifies the delivered contract codes against the requested ones
+// and stores them.
+func (s *snapSync) processCodes(req *snapRequest, pack *codePack) error {
+	if req.codes == nil {
+		return &rangeError{errors.New("codes delivered for other request")}
+	}
+	requested := make(map[common.Hash]bool, len(req.codes))
+	for _, hash := range req.codes {
+		requested[hash] = true
+	}
+	hashes := make([]common.Hash, len(pack.codes))
+	for i, code := range pack.codes {
+		if hashes[i] = crypto.Keccak256Hash(code); !requested[hashes[i]] {
+			return &rangeError{fmt.Errorf("unrequested code %x", hashes[i])}
+		}
+	}
+	batch := s.d.stateDB.NewBatch()
+	for i, code := range pack.codes {
+		if err := batch.Put(hashes[i][:], code); err != nil {
+			return err
+		}
+		s.size += common.StorageSize(len(code))
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	s.codes += uint64(len(pack.codes))
+
+	// Codes may be shared by accounts of multiple ranges, mark them done everywhere
+	for _, hash := range hashes {
+		for _, task := range s.tasks {
+			if task.batch != nil {
+				delete(task.batch.codes, hash)
+			}
+		}
+	}
+	for _, hash := range req.codes {
+		if _, ok := req.task.batch.codes[hash]; ok {
+			req.task.batch.codes[hash] = false
+		}
+	}
+	return nil
+}
+
+// commit inserts the accounts of a completed batch into the account trie, flushes
+// it to disk and moves the range of the task past the accounts.
+func (s *snapSync) commit(task *accountTask) error {
+	batch := task.batch
+	for i, hash := range batch.hashes {
+		if err := s.accTrie.TryUpdate(hash[:], batch.accounts[i]); err != nil {
+			return err
+		}
+	}
+	root, err := s.accTrie.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if err := s.triedb.Commit(root, false); err != nil {
+		return err
+	}
+	s.accRoot = root
+	s.accounts += uint64(len(batch.hashes))
+
+	if batch.cont {
+		task.Next = incHash(batch.hashes[len(batch.hashes)-1])
+	}
+	return nil
+}
+
+// incHash returns the hash following h. The last hash of the hash space wraps
+// around, which is never needed as no range continues after it.
+func incHash(h common.Hash) common.Hash {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,364 @@
+package downloader
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// snapTestPeer is a download tester peer also serving the states of the peer
+// database in ranges, capped at a few items per response to split them up.
+type snapTestPeer struct {
+	*downloadTesterPeer
+
+	items int // Maximum number of accounts, slots or codes served in a response
+
+	mu        sync.Mutex
+	budget    int           // Number of state range responses left, negative if unlimited
+	exhausted chan struct{} // Closed when the peer stops responding
+	origins   []common.Hash // Origins of the account ranges requested
+	heals     int           // Number of node data requests served
+}
+
+// newSnapPeer registers a new state range download source into the downloader,
+// responding to the given number of state range requests only.
+func (dl *downloadTester) newSnapPeer(id string, items int, budget int) *snapTestPeer {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	peer := &snapTestPeer{
+		downloadTesterPeer: &downloadTesterPeer{dl: dl, id: id},
+		items:              items,
+		budget:             budget,
+		exhausted:          make(chan struct{}),
+	}
+	dl.peerMissingStates[id] = make(map[common.Hash]bool)
+	if err := dl.downloader.RegisterPeer(id, 63, peer); err != nil {
+		panic(err)
+	}
+	return peer
+}
+
+// syncSnapState starts a snap sync of the given state root, as the downloader
+// does when the pivot block is reached.
+func (dl *downloadTester) syncSnapState(root common.Hash) *stateSync {
+	d := dl.downloader
+	d.cancelLock.Lock()
+	d.mode, d.cancelCh = SnapSync, make(chan struct{})
+	d.cancelLock.Unlock()
+
+	return d.syncState(root)
+}
+
+func (p *snapTestPeer) SnapEnabled() bool { return true }
+
+// serve consumes a response from the budget of the peer, reporting whether it
+// still responds.
+func (p *snapTestPeer) serve() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.budget == 0 {
+		return false
+	}
+	if p.budget--; p.budget == 0 {
+		close(p.exhausted)
+	}
+	return true
+}
+
+// prove collects the proofs of the edges of a range into a node list.
+func prove(tr *trie.Trie, origin common.Hash, keys []common.Hash) [][]byte {
+	db := yoobadb.NewMemDatabase()
+	tr.Prove(origin[:], 0, db)
+	if len(keys) > 0 {
+		tr.Prove(keys[len(keys)-1][:], 0, db)
+	}
+	var proof [][]byte
+	for _, key := range db.Keys() {
+		node, _ := db.Get(key)
+		proof = append(proof, node)
+	}
+	return proof
+}
+
+func (p *snapTestPeer) RequestAccountRange(id uint64, root common.Hash, origin, limit common.Hash, size uint64) error {
+	p.mu.Lock()
+	p.origins = append(p.origins, origin)
+	p.mu.Unlock()
+
+	if !p.serve() {
+		return nil
+	}
+	tr, err := trie.New(root, trie.NewDatabase(p.dl.peerDb))
+	if err != nil {
+		go p.dl.downloader.DeliverAccountRange(p.id, id, nil, nil, nil)
+		return nil
+	}
+	var (
+		hashes   []common.Hash
+		accounts [][]byte
+	)
+	it := trie.NewIterator(tr.NodeIterator(origin[:]))
+	for len(hashes) < p.items && it.Next() {
+		hashes = append(hashes, common.BytesToHash(it.Key))
+		accounts = append(accounts, common.CopyBytes(it.Value))
+
+		if bytes.Compare(it.Key, limit[:]) >= 0 {
+			break
+		}
+	}
+	go p.dl.downloader.DeliverAccountRange(p.id, id, hashes, accounts, prove(tr, origin, hashes))
+	return nil
+}
+
+func (p *snapTestPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, size uint64) error {
+	if !p.serve() {
+		return nil
+	}
+	triedb := trie.NewDatabase(p.dl.peerDb)
+	accTrie, err := trie.New(root, triedb)
+	if err != nil {
+		go p.dl.downloader.DeliverStorageRanges(p.id, id, nil, nil, nil)
+		return nil
+	}
+	var (
+		hashes [][]common.Hash
+		slots  [][][]byte
+		proof  [][]byte
+		served int
+	)
+	for i, hash := range accounts {
+		if served >= p.items {
+			break
+		}
+		var account state.Account
+		blob, _ := accTrie.TryGet(hash[:])
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			panic(err)
+		}
+		stTrie, _ := trie.New(account.Root, triedb)
+
+		var start common.Hash
+		if i == 0 {
+			start = common.BytesToHash(origin)
+		}
+		var (
+			keys []common.Hash
+			vals [][]byte
+			cut  bool
+		)
+		it := trie.NewIterator(stTrie.NodeIterator(start[:]))
+		for it.Next() {
+			if served >= p.items {
+				cut = true
+				break
+			}
+			keys = append(keys, common.BytesToHash(it.Key))
+			vals = append(vals, common.CopyBytes(it.Value))
+			served++
+		}
+		hashes, slots = append(hashes, keys), append(slots, vals)
+
+		if start != (common.Hash{}) || cut {
+			proof = prove(stTrie, start, keys)
+			break
+		}
+	}
+	go p.dl.downloader.DeliverStorageRanges(p.id, id, hashes, slots, proof)
+	return nil
+}
+
+func (p *snapTestPeer) RequestByteCodes(id uint64, hashes []common.Hash, size uint64) error {
+	if !p.serve() {
+		return nil
+	}
+	var codes [][]byte
+	for _, hash := range hashes {
+		if len(codes) == p.items {
+			break
+		}
+		if code, err := p.dl.peerDb.Get(hash[:]); err == nil {
+			codes = append(codes, code)
+		}
+	}
+	go p.dl.downloader.DeliverByteCodes(p.id, id, codes)
+	return nil
+}
+
+func (p *snapTestPeer) RequestNodeData(hashes []common.Hash) error {
+	p.mu.Lock()
+	p.heals++
+	p.mu.Unlock()
+
+	return p.downloadTesterPeer.RequestNodeData(hashes)
+}
+
+// makeSnapState creates a state in the peer database with plenty of accounts,
+// some of them with storage slots, one with a large storage trie and some with
+// shared contract codes.
+func (dl *downloadTester) makeSnapState(parent common.Hash, seed byte) common.Hash {
+	statedb, _ := state.New(parent, state.NewDatabase(dl.peerDb))
+	for i := 0; i < 256; i++ {
+		addr := common.BytesToAddress([]byte{byte(i), 0x01})
+		statedb.SetBalance(addr, big.NewInt(int64(i)+int64(seed)))
+
+		if i%4 == 0 {
+			for j := 0; j < i%7+1; j++ {
+				statedb.SetState(addr, common.Hash{byte(j), seed}, common.Hash{byte(i), byte(j)})
+			}
+		}
+		if i%5 == 0 {
+			statedb.SetCode(addr, []byte{byte(i % 3), seed})
+		}
+	}
+	large := common.Address{0xff}
+	for j := 0; j < 100; j++ {
+		statedb.SetState(large, common.Hash{byte(j)}, common.Hash{seed, byte(j)})
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		panic(err)
+	}
+	if err := statedb.Database().TrieDB().Commit(root, false); err != nil {
+		panic(err)
+	}
+	return root
+}
+
+// waitStateSync waits for a state sync to finish.
+func waitStateSync(t *testing.T, s *stateSync) {
+	errc := make(chan error, 1)
+	go func() { errc <- s.Wait() }()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("state sync failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("state sync timeout")
+	}
+}
+
+// assertState checks that all the nodes and codes of a state are available in
+// the local database.
+func assertState(t *testing.T, tester *downloadTester, root common.Hash) {
+	statedb, err := state.New(root, state.NewDatabase(tester.stateDb))
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("synced state incomplete: %v", it.Error)
+	}
+}
+
+// Tests that a state is assembled from its ranges, split up across many requests,
+// without needing to be healed.
+func TestSnapSync(t *testing.T) {
+	tester := newTester()
+	defer tester.terminate()
+
+	root := tester.makeSnapState(common.Hash{}, 0)
+	peer := tester.newSnapPeer("peer", 8, -1)
+
+	waitStateSync(t, tester.syncSnapState(root))
+	assertState(t, tester, root)
+
+	if peer.heals != 0 {
+		t.Errorf("healing requests mismatch: have %d, want 0", peer.heals)
+	}
+}
+
+// Tests that an interrupted snap sync resumes from its persisted progress, not
+// requesting the already retrieved ranges again.
+func TestSnapSyncResume(t *testing.T) {
+	tester := newTester()
+	defer tester.terminate()
+
+	root := tester.makeSnapState(common.Hash{}, 0)
+	status := interruptSnapSync(t, tester, root)
+
+	// A new sync only knows the persisted progress, make sure it's used
+	peer := tester.newSnapPeer("resumed", 8, -1)
+	waitStateSync(t, tester.syncSnapState(root))
+	assertState(t, tester, root)
+
+	for _, origin := range peer.origins {
+		resumed := false
+		for _, task := range status.Tasks {
+			if bytes.Compare(origin[:], task.Next[:]) >= 0 && bytes.Compare(origin[:], task.Last[:]) <= 0 {
+				resumed = true
+			}
+		}
+		if !resumed {
+			t.Errorf("retrieved account range requested again: origin %x", origin)
+		}
+	}
+	if peer.heals != 0 {
+		t.Errorf("healing requests mismatch: have %d, want 0", peer.heals)
+	}
+}
+
+// Tests that a snap sync resumed with a moved sync target heals the accounts
+// retrieved from the old state.
+func TestSnapSyncHealing(t *testing.T) {
+	tester := newTester()
+	defer tester.terminate()
+
+	old := tester.makeSnapState(common.Hash{}, 0)
+	interruptSnapSync(t, tester, old)
+
+	root := tester.makeSnapState(old, 1)
+	peer := tester.newSnapPeer("moved", 8, -1)
+	waitStateSync(t, tester.syncSnapState(root))
+	assertState(t, tester, root)
+
+	if peer.heals == 0 {
+		t.Errorf("stale state ranges not healed")
+	}
+}
+
+// interruptSnapSync runs a snap sync of the given root until its only peer stops
+// responding, and returns the progress persisted by then.
+func interruptSnapSync(t *testing.T, tester *downloadTester, root common.Hash) *snapStatus {
+	peer := tester.newSnapPeer("interrupted", 8, 12)
+	s := tester.syncSnapState(root)
+
+	select {
+	case <-peer.exhausted:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("state ranges not requested")
+	}
+	// Let the last response be processed before canceling
+	time.Sleep(100 * time.Millisecond)
+	if err := s.Cancel(); err != errCancelStateFetch {
+		t.Fatalf("cancel error mismatch: have %v, want %v", err, errCancelStateFetch)
+	}
+	tester.dropPeer(peer.id)
+
+	blob := rawdb.ReadSnapSyncStatus(tester.stateDb)
+	if blob == nil {
+		t.Fatalf("snap sync progress not persisted")
+	}
+	status := new(snapStatus)
+	if err := rlp.DecodeBytes(blob, status); err != nil {
+		t.Fatalf("failed to decode snap sync status: %v", err)
+	}
+	if status.Root == (common.Hash{}) || len(status.Tasks) == 0 {
+		t.Fatalf("snap sync progress mismatch: root %x, %d ranges left", status.Root, len(status.Tasks))
+	}
+	return status
+}
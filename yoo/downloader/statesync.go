@@ -80,6 +80,8 @@ func (d *Downloader) stateFetcher() {
 			}
 		case <-d.stateCh:
 			// Ignore state responses while no sync is running.
+		case <-d.snapCh:
+			// Ignore state range responses while no sync is running.
 		case <-d.quitCh:
 			return
 		}
@@ -93,7 +95,13 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 		active   = make(map[string]*stateReq) // Currently in-flight requests
 		finished []*stateReq                  // Completed or failed requests
 		timeout  = make(chan *stateReq)       // Timed out active requests
+
+		snapPacks []dataPack    // State range responses waiting for processing
+		snapDone  chan struct{} // Closed when the state range phase finishes
 	)
+	if s.snap != nil {
+		snapDone = s.snap.done
+	}
 	defer func() {
 		// Cancel active request timers on exit. Also set peers to idle so they're
 		// available for the next sync.
@@ -121,6 +129,14 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 			deliverReq = finished[0]
 			deliverReqCh = s.deliver
 		}
+		var (
+			deliverPack   dataPack
+			deliverPackCh chan dataPack
+		)
+		if len(snapPacks) > 0 {
+			deliverPack = snapPacks[0]
+			deliverPackCh = s.snap.deliver
+		}
 
 		select {
 		// The stateSync lifecycle:
@@ -137,6 +153,23 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 			finished[len(finished)-1] = nil
 			finished = finished[:len(finished)-1]
 
+		// Send the next state range response to the range phase of the sync:
+		case deliverPackCh <- deliverPack:
+			copy(snapPacks, snapPacks[1:])
+			snapPacks[len(snapPacks)-1] = nil
+			snapPacks = snapPacks[:len(snapPacks)-1]
+
+		case <-snapDone:
+			// State range phase finished, discard any late responses
+			snapDone, snapPacks = nil, nil
+
+		case pack := <-d.snapCh:
+			if snapDone == nil {
+				log.Debug("Unrequested state range", "peer", pack.PeerId(), "len", pack.Items())
+				continue
+			}
+			snapPacks = append(snapPacks, pack)
+
 		// Handle incoming state packs:
 		case pack := <-d.stateCh:
 			// Discard any data not requested (or previously timed out)
@@ -212,8 +245,10 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 // stateSync schedules requests for downloading a particular state trie defined
 // by a given state root.
 type stateSync struct {
-	d *Downloader // Downloader instance to access and manage current peerset
+	d    *Downloader // Downloader instance to access and manage current peerset
+	root common.Hash // State root currently being synced
 
+	snap   *snapSync                  // State range sync preceding the trie sync, if enabled
 	sched  *trie.Sync                 // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
 	tasks  map[common.Hash]*stateTask // Set of tasks currently queued for retrieval
@@ -237,21 +272,36 @@ type stateTask struct {
 // newStateSync creates a new state trie download scheduler. This method does not
 // yet start the sync. The user needs to call run to initiate.
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
-	return &stateSync{
+	s := &stateSync{
 		d:       d,
-		sched:   state.NewStateSync(root, d.stateDB),
+		root:    root,
 		keccak:  sha3.NewKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
 		deliver: make(chan *stateReq),
 		cancel:  make(chan struct{}),
 		done:    make(chan struct{}),
 	}
+	// Snap sync retrieves the state ranges first and only heals the trie after,
+	// so the trie scheduler can't be created until the ranges are in the database
+	if d.mode == SnapSync {
+		s.snap = newSnapSync(d, root)
+	} else {
+		s.sched = state.NewStateSync(root, d.stateDB)
+	}
+	return s
 }
 
 // run starts the task assignment and response processing loop, blocking until
 // it finishes, and finally notifying any goroutines waiting for the loop to
 // finish.
 func (s *stateSync) run() {
+	if s.snap != nil {
+		if s.err = s.snap.run(s.cancel); s.err != nil {
+			close(s.done)
+			return
+		}
+		s.sched = state.NewStateSync(s.root, s.d.stateDB)
+	}
 	s.err = s.loop()
 	close(s.done)
 }
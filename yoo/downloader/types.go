@@ -19,6 +19,7 @@ package downloader
 import (
 	"fmt"
 
+	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/core/types"
 )
 
@@ -73,3 +74,40 @@ type statePack struct {
 func (p *statePack) PeerId() string { return p.peerID }
 func (p *statePack) Items() int     { return len(p.states) }
 func (p *statePack) Stats() string  { return fmt.Sprintf("%d", len(p.states)) }
+
+// accountPack is a range of accounts returned by a peer.
+type accountPack struct {
+	peerID   string
+	id       uint64
+	hashes   []common.Hash
+	accounts [][]byte
+	proof    [][]byte
+}
+
+func (p *accountPack) PeerId() string { return p.peerID }
+func (p *accountPack) Items() int     { return len(p.accounts) }
+func (p *accountPack) Stats() string  { return fmt.Sprintf("%d", len(p.accounts)) }
+
+// storagePack is a batch of storage slot ranges returned by a peer.
+type storagePack struct {
+	peerID string
+	id     uint64
+	hashes [][]common.Hash
+	slots  [][][]byte
+	proof  [][]byte
+}
+
+func (p *storagePack) PeerId() string { return p.peerID }
+func (p *storagePack) Items() int     { return len(p.slots) }
+func (p *storagePack) Stats() string  { return fmt.Sprintf("%d", len(p.slots)) }
+
+// codePack is a batch of contract codes returned by a peer.
+type codePack struct {
+	peerID string
+	id     uint64
+	codes  [][]byte
+}
+
+func (p *codePack) PeerId() string { return p.peerID }
+func (p *codePack) Items() int     { return len(p.codes) }
+func (p *codePack) Stats() string  { return fmt.Sprintf("%d", len(p.codes)) }
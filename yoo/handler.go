@@ -65,6 +65,7 @@ type ProtocolManager struct {
 	networkId uint64
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whether fast sync retrieves the state in ranges (only meaningful with fast sync)
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	txpool      txPool
@@ -111,18 +112,21 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		quitSync:    make(chan struct{}),
 	}
 	// Figure out whether to allow fast sync or not
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
+	if (mode == downloader.FastSync || mode == downloader.SnapSync) && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
 		mode = downloader.FullSync
 	}
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		manager.fastSync = uint32(1)
 	}
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
 		// Skip protocol version if incompatible with the mode of operation
-		if mode == downloader.FastSync && version < eth63 {
+		if (mode == downloader.FastSync || mode == downloader.SnapSync) && version < eth63 {
 			continue
 		}
 		// Compatible; initialise the sub-protocol
@@ -156,6 +160,20 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
+	// Serve the state in ranges over a separate protocol running next to yoo
+	for i, version := range SnapProtocolVersions {
+		version := version // Closure for the run
+		manager.SubProtocols = append(manager.SubProtocols, p2p.Protocol{
+			Name:    SnapProtocolName,
+			Version: version,
+			Length:  SnapProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				manager.wg.Add(1)
+				defer manager.wg.Done()
+				return manager.handleSnap(newSnapPeer(int(version), p, newMeteredSnapWriter(rw)))
+			},
+		})
+	}
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
 
@@ -289,6 +307,120 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 }
 
+// handleSnap is the callback invoked to manage the life cycle of a snap peer.
+// The peer is attached to the yoo peer on the same connection, making it usable
+// for snap sync. When this function terminates, the peer is disconnected.
+func (pm *ProtocolManager) handleSnap(p *snapPeer) error {
+	p.Log().Debug("Snap peer connected", "name", p.Name())
+
+	if err := pm.peers.RegisterSnap(p); err != nil {
+		p.Log().Debug("Snap peer registration failed", "err", err)
+		return err
+	}
+	defer pm.peers.UnregisterSnap(p.id)
+
+	for {
+		if err := pm.handleSnapMsg(p); err != nil {
+			p.Log().Debug("Snap message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleSnapMsg is invoked whenever an inbound message is received from a remote
+// snap peer. The remote connection is torn down upon returning any error.
+func (pm *ProtocolManager) handleSnapMsg(p *snapPeer) error {
+	// Read the next message from the remote peer, and ensure it's fully consumed
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	// Handle the message depending on its contents
+	switch {
+	case msg.Code == GetAccountRangeMsg:
+		// Decode the account range query and serve it from the local state
+		var req getAccountRangeData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		accounts, proof := pm.serveAccountRange(&req)
+		return p.SendAccountRange(req.ID, accounts, proof)
+
+	case msg.Code == AccountRangeMsg:
+		// A range of accounts arrived to one of our previous requests
+		var res accountRangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		hashes := make([]common.Hash, len(res.Accounts))
+		accounts := make([][]byte, len(res.Accounts))
+		for i, account := range res.Accounts {
+			hashes[i], accounts[i] = account.Hash, account.Body
+		}
+		// Deliver all to the downloader
+		if err := pm.downloader.DeliverAccountRange(p.id, res.ID, hashes, accounts, res.Proof); err != nil {
+			log.Debug("Failed to deliver account range", "err", err)
+		}
+
+	case msg.Code == GetStorageRangesMsg:
+		// Decode the storage ranges query and serve it from the local state
+		var req getStorageRangesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		slots, proof := pm.serveStorageRanges(&req)
+		return p.SendStorageRanges(req.ID, slots, proof)
+
+	case msg.Code == StorageRangesMsg:
+		// Ranges of storage slots arrived to one of our previous requests
+		var res storageRangesData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		hashes := make([][]common.Hash, len(res.Slots))
+		slots := make([][][]byte, len(res.Slots))
+		for i, storage := range res.Slots {
+			hashes[i] = make([]common.Hash, len(storage))
+			slots[i] = make([][]byte, len(storage))
+			for j, slot := range storage {
+				hashes[i][j], slots[i][j] = slot.Hash, slot.Body
+			}
+		}
+		// Deliver all to the downloader
+		if err := pm.downloader.DeliverStorageRanges(p.id, res.ID, hashes, slots, res.Proof); err != nil {
+			log.Debug("Failed to deliver storage ranges", "err", err)
+		}
+
+	case msg.Code == GetByteCodesMsg:
+		// Decode the byte code query and serve it from the local database
+		var req getByteCodesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendByteCodes(req.ID, pm.serveByteCodes(&req))
+
+	case msg.Code == ByteCodesMsg:
+		// A batch of contract codes arrived to one of our previous requests
+		var res byteCodesData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// Deliver all to the downloader
+		if err := pm.downloader.DeliverByteCodes(p.id, res.ID, res.Codes); err != nil {
+			log.Debug("Failed to deliver byte codes", "err", err)
+		}
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+	return nil
+}
+
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
 func (pm *ProtocolManager) handleMsg(p *peer) error {
@@ -50,6 +50,10 @@ var (
 	reqReceiptInTrafficMeter  = metrics.NewRegisteredMeter("yoo/req/receipts/in/traffic", nil)
 	reqReceiptOutPacketsMeter = metrics.NewRegisteredMeter("yoo/req/receipts/out/packets", nil)
 	reqReceiptOutTrafficMeter = metrics.NewRegisteredMeter("yoo/req/receipts/out/traffic", nil)
+	reqSnapInPacketsMeter     = metrics.NewRegisteredMeter("yoo/req/snap/in/packets", nil)
+	reqSnapInTrafficMeter     = metrics.NewRegisteredMeter("yoo/req/snap/in/traffic", nil)
+	reqSnapOutPacketsMeter    = metrics.NewRegisteredMeter("yoo/req/snap/out/packets", nil)
+	reqSnapOutTrafficMeter    = metrics.NewRegisteredMeter("yoo/req/snap/out/traffic", nil)
 	miscInPacketsMeter        = metrics.NewRegisteredMeter("yoo/misc/in/packets", nil)
 	miscInTrafficMeter        = metrics.NewRegisteredMeter("yoo/misc/in/traffic", nil)
 	miscOutPacketsMeter       = metrics.NewRegisteredMeter("yoo/misc/out/packets", nil)
@@ -137,3 +141,50 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 	// Send the packet to the p2p layer
 	return rw.MsgReadWriter.WriteMsg(msg)
 }
+
+// meteredSnapReadWriter is a wrapper around the p2p.MsgReadWriter of the snap
+// protocol, accumulating the state range metrics.
+type meteredSnapReadWriter struct {
+	p2p.MsgReadWriter // Wrapped message stream to meter
+}
+
+// newMeteredSnapWriter wraps a snap p2p MsgReadWriter with metering support. If
+// the metrics system is disabled, this function returns the original object.
+func newMeteredSnapWriter(rw p2p.MsgReadWriter) p2p.MsgReadWriter {
+	if !metrics.Enabled {
+		return rw
+	}
+	return &meteredSnapReadWriter{MsgReadWriter: rw}
+}
+
+func (rw *meteredSnapReadWriter) ReadMsg() (p2p.Msg, error) {
+	// Read the message and short circuit in case of an error
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	// Account for the data traffic
+	packets, traffic := miscInPacketsMeter, miscInTrafficMeter
+	switch msg.Code {
+	case AccountRangeMsg, StorageRangesMsg, ByteCodesMsg:
+		packets, traffic = reqSnapInPacketsMeter, reqSnapInTrafficMeter
+	}
+	packets.Mark(1)
+	traffic.Mark(int64(msg.Size))
+
+	return msg, err
+}
+
+func (rw *meteredSnapReadWriter) WriteMsg(msg p2p.Msg) error {
+	// Account for the data traffic
+	packets, traffic := miscOutPacketsMeter, miscOutTrafficMeter
+	switch msg.Code {
+	case AccountRangeMsg, StorageRangesMsg, ByteCodesMsg:
+		packets, traffic = reqSnapOutPacketsMeter, reqSnapOutTrafficMeter
+	}
+	packets.Mark(1)
+	traffic.Mark(int64(msg.Size))
+
+	// Send the packet to the p2p layer
+	return rw.MsgReadWriter.WriteMsg(msg)
+}
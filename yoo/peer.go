@@ -34,6 +34,7 @@ var (
 	errClosed            = errors.New("peer set is closed")
 	errAlreadyRegistered = errors.New("peer is already registered")
 	errNotRegistered     = errors.New("peer is not registered")
+	errSnapNotRunning    = errors.New("peer doesn't run the snap protocol")
 )
 
 const (
@@ -81,6 +82,7 @@ type peer struct {
 	forkDrop *time.Timer // Timed connection dropper if forks aren't validated in time
 
 	head common.Hash
+	snap *snapPeer // Snap protocol peer on the same connection, if any
 	lock sync.RWMutex
 
 	knownTxs    *set.Set                  // Set of transaction hashes known to be known by this peer
@@ -322,6 +324,55 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	return p2p.Send(p.rw, GetReceiptsMsg, hashes)
 }
 
+// SnapEnabled reports whether the peer runs the snap protocol too, serving the
+// state in ranges.
+func (p *peer) SnapEnabled() bool {
+	return p.snapExt() != nil
+}
+
+// snapExt returns the snap protocol peer on the same connection, if any.
+func (p *peer) snapExt() *snapPeer {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.snap
+}
+
+// setSnapExt attaches or detaches the snap protocol peer on the same connection.
+func (p *peer) setSnapExt(snap *snapPeer) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.snap = snap
+}
+
+// RequestAccountRange fetches a range of accounts over the snap protocol.
+func (p *peer) RequestAccountRange(id uint64, root common.Hash, origin, limit common.Hash, bytes uint64) error {
+	snap := p.snapExt()
+	if snap == nil {
+		return errSnapNotRunning
+	}
+	return snap.RequestAccountRange(id, root, origin, limit, bytes)
+}
+
+// RequestStorageRanges fetches ranges of storage slots over the snap protocol.
+func (p *peer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	snap := p.snapExt()
+	if snap == nil {
+		return errSnapNotRunning
+	}
+	return snap.RequestStorageRanges(id, root, accounts, origin, limit, bytes)
+}
+
+// RequestByteCodes fetches contract bytecodes over the snap protocol.
+func (p *peer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	snap := p.snapExt()
+	if snap == nil {
+		return errSnapNotRunning
+	}
+	return snap.RequestByteCodes(id, hashes, bytes)
+}
+
 // Handshake executes the yoo protocol handshake, negotiating version number,
 // network IDs, difficulties, head and genesis blocks.
 func (p *peer) Handshake(network uint64, head common.Hash, genesis common.Hash) error {
@@ -390,18 +441,85 @@ func (p *peer) String() string {
 	)
 }
 
+// snapPeer is a remote peer running the snap protocol, which serves the state
+// in ranges next to the yoo protocol on the same connection.
+type snapPeer struct {
+	id string
+
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	version int // Protocol version negotiated
+}
+
+func newSnapPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *snapPeer {
+	return &snapPeer{
+		Peer:    p,
+		rw:      rw,
+		version: version,
+		id:      fmt.Sprintf("%x", p.ID().Bytes()[:8]),
+	}
+}
+
+// SendAccountRange sends a range of consecutive accounts along with the merkle
+// proofs of its edges, corresponding to the range requested.
+func (p *snapPeer) SendAccountRange(id uint64, accounts []*accountData, proof [][]byte) error {
+	return p2p.Send(p.rw, AccountRangeMsg, &accountRangeData{ID: id, Accounts: accounts, Proof: proof})
+}
+
+// SendStorageRanges sends the storage slot ranges of consecutive accounts along
+// with the merkle proofs of the last range if partial, corresponding to the
+// ranges requested.
+func (p *snapPeer) SendStorageRanges(id uint64, slots [][]*storageData, proof [][]byte) error {
+	return p2p.Send(p.rw, StorageRangesMsg, &storageRangesData{ID: id, Slots: slots, Proof: proof})
+}
+
+// SendByteCodes sends a batch of contract bytecodes, corresponding to the hashes
+// requested.
+func (p *snapPeer) SendByteCodes(id uint64, codes [][]byte) error {
+	return p2p.Send(p.rw, ByteCodesMsg, &byteCodesData{ID: id, Codes: codes})
+}
+
+// RequestAccountRange fetches a batch of consecutive accounts from the account
+// trie with the given root, starting at origin and ending at limit.
+func (p *snapPeer) RequestAccountRange(id uint64, root common.Hash, origin, limit common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching range of accounts", "reqid", id, "root", root, "origin", origin, "limit", limit, "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetAccountRangeMsg, &getAccountRangeData{ID: id, Root: root, Origin: origin, Limit: limit, Bytes: bytes})
+}
+
+// RequestStorageRanges fetches a batch of storage slots belonging to one or more
+// accounts of the state with the given root. If the slots of a single account
+// are requested, the range may start at origin and end at limit.
+func (p *snapPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	if len(accounts) == 1 && origin != nil {
+		p.Log().Debug("Fetching range of large storage slots", "reqid", id, "root", root, "account", accounts[0], "origin", common.BytesToHash(origin), "limit", common.BytesToHash(limit), "bytes", common.StorageSize(bytes))
+	} else {
+		p.Log().Debug("Fetching ranges of small storage slots", "reqid", id, "root", root, "accounts", len(accounts), "bytes", common.StorageSize(bytes))
+	}
+	return p2p.Send(p.rw, GetStorageRangesMsg, &getStorageRangesData{ID: id, Root: root, Accounts: accounts, Origin: origin, Limit: limit, Bytes: bytes})
+}
+
+// RequestByteCodes fetches a batch of contract bytecodes corresponding to the
+// hashes specified.
+func (p *snapPeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching set of byte codes", "reqid", id, "hashes", len(hashes), "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetByteCodesMsg, &getByteCodesData{ID: id, Hashes: hashes, Bytes: bytes})
+}
+
 // peerSet represents the collection of active peers currently participating in
 // the Yooba sub-protocol.
 type peerSet struct {
-	peers  map[string]*peer
-	lock   sync.RWMutex
-	closed bool
+	peers     map[string]*peer
+	snapPeers map[string]*snapPeer // Snap protocol peers, attached to the yoo peer with the same id
+	lock      sync.RWMutex
+	closed    bool
 }
 
 // newPeerSet creates a new peer set to track the active participants.
 func newPeerSet() *peerSet {
 	return &peerSet{
-		peers: make(map[string]*peer),
+		peers:     make(map[string]*peer),
+		snapPeers: make(map[string]*snapPeer),
 	}
 }
 
@@ -419,6 +537,9 @@ func (ps *peerSet) Register(p *peer) error {
 		return errAlreadyRegistered
 	}
 	ps.peers[p.id] = p
+	if snap := ps.snapPeers[p.id]; snap != nil {
+		p.setSnapExt(snap)
+	}
 	go p.broadcast()
 
 	return nil
@@ -440,6 +561,42 @@ func (ps *peerSet) Unregister(id string) error {
 	return nil
 }
 
+// RegisterSnap injects a new snap peer into the working set, attaching it to the
+// yoo peer on the same connection if already registered, or returns an error if
+// the snap peer is already known.
+func (ps *peerSet) RegisterSnap(p *snapPeer) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.closed {
+		return errClosed
+	}
+	if _, ok := ps.snapPeers[p.id]; ok {
+		return errAlreadyRegistered
+	}
+	ps.snapPeers[p.id] = p
+	if peer := ps.peers[p.id]; peer != nil {
+		peer.setSnapExt(p)
+	}
+	return nil
+}
+
+// UnregisterSnap removes a snap peer from the active set, detaching it from the
+// yoo peer on the same connection.
+func (ps *peerSet) UnregisterSnap(id string) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if _, ok := ps.snapPeers[id]; !ok {
+		return errNotRegistered
+	}
+	delete(ps.snapPeers, id)
+	if peer := ps.peers[id]; peer != nil {
+		peer.setSnapExt(nil)
+	}
+	return nil
+}
+
 // Peer retrieves the registered peer with the given id.
 func (ps *peerSet) Peer(id string) *peer {
 	ps.lock.RLock()
@@ -509,5 +666,8 @@ func (ps *peerSet) Close() {
 	for _, p := range ps.peers {
 		p.Disconnect(p2p.DiscQuitting)
 	}
+	for _, p := range ps.snapPeers {
+		p.Disconnect(p2p.DiscQuitting)
+	}
 	ps.closed = true
 }
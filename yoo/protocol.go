@@ -64,6 +64,33 @@ const (
 	ReceiptsMsg    = 0x10
 )
 
+// Constants to match up the state range protocol versions and messages
+const (
+	snap1 = 1
+)
+
+// SnapProtocolName is the official short name of the state range protocol used
+// during capability negotiation. It runs next to the yoo protocol, serving the
+// state in contiguous ranges to snap syncing peers.
+var SnapProtocolName = "snap"
+
+// Supported versions of the snap protocol (first is primary).
+var SnapProtocolVersions = []uint{snap1}
+
+// Number of implemented message corresponding to different snap protocol versions.
+var SnapProtocolLengths = []uint64{6}
+
+// snap protocol message codes
+const (
+	// Protocol messages belonging to snap/1
+	GetAccountRangeMsg  = 0x00
+	AccountRangeMsg     = 0x01
+	GetStorageRangesMsg = 0x02
+	StorageRangesMsg    = 0x03
+	GetByteCodesMsg     = 0x04
+	ByteCodesMsg        = 0x05
+)
+
 type errCode int
 
 const (
@@ -180,3 +207,65 @@ type blockBody struct {
 
 // blockBodiesData is the network packet for block content distribution.
 type blockBodiesData []*blockBody
+
+// getAccountRangeData represents an account range query.
+type getAccountRangeData struct {
+	ID     uint64      // Request ID to match up responses with
+	Root   common.Hash // Root hash of the account trie to serve
+	Origin common.Hash // Hash of the first account to retrieve
+	Limit  common.Hash // Hash of the last account to retrieve
+	Bytes  uint64      // Soft limit at which to stop returning data
+}
+
+// accountRangeData is the network packet for an account range, along with the
+// merkle proofs of its edges.
+type accountRangeData struct {
+	ID       uint64         // ID of the request this is a response for
+	Accounts []*accountData // List of consecutive accounts from the trie
+	Proof    [][]byte       // List of trie nodes proving the account range
+}
+
+// accountData represents a single account in an account range.
+type accountData struct {
+	Hash common.Hash  // Hash of the account
+	Body rlp.RawValue // Consensus encoding of the account
+}
+
+// getStorageRangesData represents a storage slot range query of one or more
+// accounts. The origin and limit only apply to the first and last account.
+type getStorageRangesData struct {
+	ID       uint64        // Request ID to match up responses with
+	Root     common.Hash   // Root hash of the account trie to serve
+	Accounts []common.Hash // Account hashes of the storage tries to serve
+	Origin   []byte        // Hash of the first storage slot to retrieve
+	Limit    []byte        // Hash of the last storage slot to retrieve
+	Bytes    uint64        // Soft limit at which to stop returning data
+}
+
+// storageRangesData is the network packet for the storage slot ranges of
+// consecutive accounts. Only the last range may be partial, in which case the
+// proof covers its edges.
+type storageRangesData struct {
+	ID    uint64           // ID of the request this is a response for
+	Slots [][]*storageData // Lists of consecutive storage slots per account
+	Proof [][]byte         // List of trie nodes proving the last slot range
+}
+
+// storageData represents a single storage slot in a storage range.
+type storageData struct {
+	Hash common.Hash // Hash of the storage slot
+	Body []byte      // Data content of the slot
+}
+
+// getByteCodesData represents a contract bytecode query.
+type getByteCodesData struct {
+	ID     uint64        // Request ID to match up responses with
+	Hashes []common.Hash // Code hashes to retrieve the code for
+	Bytes  uint64        // Soft limit at which to stop returning data
+}
+
+// byteCodesData is the network packet for contract bytecodes.
+type byteCodesData struct {
+	ID    uint64   // ID of the request this is a response for
+	Codes [][]byte // Requested contract bytecodes
+}
@@ -0,0 +1,193 @@
+package yoo
+
+import (
+	"bytes"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/trie"
+)
+
+const (
+	// maxStorageFetch is the maximum number of accounts whose storage slots are
+	// served in a single storage ranges response.
+	maxStorageFetch = 128
+
+	// maxCodeFetch is the maximum number of contract codes served in a single
+	// byte code response.
+	maxCodeFetch = 1024
+)
+
+// proofSet collects the nodes of merkle proofs, dropping the ones shared by
+// multiple proofs.
+type proofSet struct {
+	seen  map[string]struct{}
+	nodes [][]byte
+}
+
+func newProofSet() *proofSet {
+	return &proofSet{seen: make(map[string]struct{})}
+}
+
+// Put implements yoobadb.Putter, collecting a proof node.
+func (s *proofSet) Put(key []byte, value []byte) error {
+	if _, ok := s.seen[string(key)]; ok {
+		return nil
+	}
+	s.seen[string(key)] = struct{}{}
+	s.nodes = append(s.nodes, common.CopyBytes(value))
+	return nil
+}
+
+// responseLimit caps the response size requested by a peer to the local soft limit.
+func responseLimit(requested uint64) int {
+	if requested > softResponseLimit {
+		return softResponseLimit
+	}
+	return int(requested)
+}
+
+// serveAccountRange gathers the accounts of the state trie of the given root
+// starting at the requested origin, until the limit hash or the response size is
+// reached. The first account at or past the limit is included, proving there is
+// nothing more in the requested range. Nothing is returned if the state is not
+// available locally.
+func (pm *ProtocolManager) serveAccountRange(req *getAccountRangeData) ([]*accountData, [][]byte) {
+	tr, err := trie.New(req.Root, pm.blockchain.StateCache().TrieDB())
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		accounts []*accountData
+		size     int
+		limit    = responseLimit(req.Bytes)
+	)
+	it := trie.NewIterator(tr.NodeIterator(req.Origin[:]))
+	for size < limit && it.Next() {
+		hash := common.BytesToHash(it.Key)
+		accounts = append(accounts, &accountData{Hash: hash, Body: common.CopyBytes(it.Value)})
+		size += common.HashLength + len(it.Value)
+
+		if bytes.Compare(hash[:], req.Limit[:]) >= 0 {
+			break
+		}
+	}
+	if it.Err != nil {
+		return nil, nil
+	}
+	// Prove the edges of the range, so the peer can verify it's contiguous
+	proof := newProofSet()
+	if err := tr.Prove(req.Origin[:], 0, proof); err != nil {
+		return nil, nil
+	}
+	if len(accounts) > 0 {
+		if err := tr.Prove(accounts[len(accounts)-1].Hash[:], 0, proof); err != nil {
+			return nil, nil
+		}
+	}
+	return accounts, proof.nodes
+}
+
+// serveStorageRanges gathers the storage slots of the requested accounts in the
+// state of the given root. The origin only applies to the first account and the
+// limit to the last one. If the response size is reached, the last storage range
+// is cut short and proven, so the peer can continue it with another request.
+// At most maxStorageFetch accounts are served, empty storage tries not counting
+// towards the response size.
+func (pm *ProtocolManager) serveStorageRanges(req *getStorageRangesData) ([][]*storageData, [][]byte) {
+	triedb := pm.blockchain.StateCache().TrieDB()
+	accTrie, err := trie.New(req.Root, triedb)
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		slots [][]*storageData
+		proof [][]byte
+		size  int
+		limit = responseLimit(req.Bytes)
+	)
+	for i, hash := range req.Accounts {
+		if size >= limit || i >= maxStorageFetch {
+			break
+		}
+		blob, err := accTrie.TryGet(hash[:])
+		if err != nil || blob == nil {
+			return nil, nil
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			return nil, nil
+		}
+		stTrie, err := trie.New(account.Root, triedb)
+		if err != nil {
+			return nil, nil
+		}
+		var origin, last common.Hash
+		if i == 0 {
+			origin = common.BytesToHash(req.Origin)
+		}
+		if i == len(req.Accounts)-1 && len(req.Limit) > 0 {
+			last = common.BytesToHash(req.Limit)
+		}
+		var (
+			storage []*storageData
+			cut     bool
+		)
+		it := trie.NewIterator(stTrie.NodeIterator(origin[:]))
+		for it.Next() {
+			if size >= limit {
+				cut = true
+				break
+			}
+			slot := common.BytesToHash(it.Key)
+			storage = append(storage, &storageData{Hash: slot, Body: common.CopyBytes(it.Value)})
+			size += common.HashLength + len(it.Value)
+
+			if last != (common.Hash{}) && bytes.Compare(slot[:], last[:]) >= 0 {
+				cut = true
+				break
+			}
+		}
+		if it.Err != nil {
+			return nil, nil
+		}
+		slots = append(slots, storage)
+
+		// Partial ranges are proven and end the response, as only the last one may be
+		if origin != (common.Hash{}) || cut {
+			set := newProofSet()
+			if err := stTrie.Prove(origin[:], 0, set); err != nil {
+				return nil, nil
+			}
+			if len(storage) > 0 {
+				if err := stTrie.Prove(storage[len(storage)-1].Hash[:], 0, set); err != nil {
+					return nil, nil
+				}
+			}
+			proof = set.nodes
+			break
+		}
+	}
+	return slots, proof
+}
+
+// serveByteCodes gathers the requested contract codes available locally, until
+// the response size is reached.
+func (pm *ProtocolManager) serveByteCodes(req *getByteCodesData) [][]byte {
+	var (
+		codes [][]byte
+		size  int
+		limit = responseLimit(req.Bytes)
+	)
+	for _, hash := range req.Hashes {
+		if size >= limit || len(codes) >= maxCodeFetch {
+			break
+		}
+		if code, err := pm.blockchain.StateCache().ContractCode(common.Hash{}, hash); err == nil && len(code) > 0 {
+			codes = append(codes, code)
+			size += len(code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,142 @@
+package yoo
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/trie"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// newSnapTestManager creates a protocol manager serving the states committed by
+// the given function.
+func newSnapTestManager(t *testing.T, fill func(*state.StateDB)) (*ProtocolManager, *state.StateDB, common.Hash) {
+	db := yoobadb.NewMemDatabase()
+	(&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+
+	blockchain, err := core.NewBlockChain(db, nil, params.TestChainConfig, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	statedb, _ := state.New(common.Hash{}, blockchain.StateCache())
+	fill(statedb)
+
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := blockchain.StateCache().TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return &ProtocolManager{blockchain: blockchain}, statedb, root
+}
+
+// verifyRange checks a served range against the trie it was served from,
+// returning whether the trie has more elements after it.
+func verifyRange(t *testing.T, root common.Hash, origin common.Hash, keys []common.Hash, values [][]byte, proof [][]byte) bool {
+	proofDb := yoobadb.NewMemDatabase()
+	for _, node := range proof {
+		proofDb.Put(crypto.Keccak256(node), node)
+	}
+	blobs := make([][]byte, len(keys))
+	for i := range keys {
+		blobs[i] = keys[i][:]
+	}
+	cont, err := trie.VerifyRangeProof(root, origin[:], blobs, values, proofDb)
+	if err != nil {
+		t.Fatalf("invalid range served: %v", err)
+	}
+	return cont
+}
+
+// Tests that account ranges are cut short at the requested response size and
+// proven, so the remainder can be requested separately.
+func TestServeAccountRangeLimit(t *testing.T) {
+	pm, _, root := newSnapTestManager(t, func(statedb *state.StateDB) {
+		for i := 0; i < 64; i++ {
+			statedb.SetBalance(common.Address{byte(i)}, big.NewInt(int64(i+1)))
+		}
+	})
+	accounts, proof := pm.serveAccountRange(&getAccountRangeData{Root: root, Limit: common.HexToHash("0xff"), Bytes: 500})
+	if len(accounts) == 0 || len(accounts) == 64 {
+		t.Fatalf("account count mismatch: have %d, want partial range", len(accounts))
+	}
+	keys := make([]common.Hash, len(accounts))
+	values := make([][]byte, len(accounts))
+	for i, account := range accounts {
+		keys[i], values[i] = account.Hash, account.Body
+	}
+	if !verifyRange(t, root, common.Hash{}, keys, values, proof) {
+		t.Errorf("partial account range reported complete")
+	}
+	// Unavailable states are not served at all
+	if accounts, proof := pm.serveAccountRange(&getAccountRangeData{Root: common.Hash{0x01}, Bytes: 500}); accounts != nil || proof != nil {
+		t.Errorf("unavailable state served: %d accounts, %d proof nodes", len(accounts), len(proof))
+	}
+}
+
+// Tests that storage ranges are served for a limited number of accounts, even if
+// their storage tries are empty and don't count towards the response size.
+func TestServeStorageRangesAccountCap(t *testing.T) {
+	var hashes []common.Hash
+	pm, _, root := newSnapTestManager(t, func(statedb *state.StateDB) {
+		for i := 0; i < 2*maxStorageFetch; i++ {
+			addr := common.BytesToAddress(big.NewInt(int64(i + 1)).Bytes())
+			statedb.SetNonce(addr, 1)
+			hashes = append(hashes, crypto.Keccak256Hash(addr[:]))
+		}
+	})
+	slots, proof := pm.serveStorageRanges(&getStorageRangesData{Root: root, Accounts: hashes, Bytes: softResponseLimit})
+	if len(slots) != maxStorageFetch {
+		t.Errorf("storage range count mismatch: have %d, want %d", len(slots), maxStorageFetch)
+	}
+	if len(proof) != 0 {
+		t.Errorf("complete storage ranges proven: %d nodes", len(proof))
+	}
+}
+
+// Tests that the last storage range is cut short at the requested response size
+// and proven, so it can be continued with another request.
+func TestServeStorageRangesLimit(t *testing.T) {
+	owner := common.Address{0x01}
+	pm, statedb, root := newSnapTestManager(t, func(statedb *state.StateDB) {
+		for i := 0; i < 100; i++ {
+			statedb.SetState(owner, common.Hash{byte(i)}, common.Hash{0x01, byte(i)})
+		}
+	})
+	accounts := []common.Hash{crypto.Keccak256Hash(owner[:]), crypto.Keccak256Hash(common.Address{0x02}.Bytes())}
+
+	slots, proof := pm.serveStorageRanges(&getStorageRangesData{Root: root, Accounts: accounts, Bytes: 1000})
+	if len(slots) != 1 || len(slots[0]) == 0 || len(slots[0]) == 100 {
+		t.Fatalf("storage ranges mismatch: have %d ranges, want 1 partial", len(slots))
+	}
+	keys := make([]common.Hash, len(slots[0]))
+	values := make([][]byte, len(slots[0]))
+	for i, slot := range slots[0] {
+		keys[i], values[i] = slot.Hash, slot.Body
+	}
+	if !verifyRange(t, statedb.StorageTrie(owner).Hash(), common.Hash{}, keys, values, proof) {
+		t.Errorf("partial storage range reported complete")
+	}
+}
+
+// Tests that a limited number of contract codes is served in a response.
+func TestServeByteCodesCap(t *testing.T) {
+	code := []byte{0x60, 0x00}
+	pm, _, _ := newSnapTestManager(t, func(statedb *state.StateDB) {
+		statedb.SetCode(common.Address{0x01}, code)
+	})
+	hashes := make([]common.Hash, maxCodeFetch+1)
+	for i := range hashes {
+		hashes[i] = crypto.Keccak256Hash(code)
+	}
+	if codes := pm.serveByteCodes(&getByteCodesData{Hashes: hashes, Bytes: softResponseLimit}); len(codes) != maxCodeFetch {
+		t.Errorf("code count mismatch: have %d, want %d", len(codes), maxCodeFetch)
+	}
+}
@@ -0,0 +1,511 @@
+package yoobadb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// boltFile is the name of the single file holding a bolt database within
+	// its directory.
+	boltFile = "bolt.db"
+
+	// boltIteratorChunk is the number of entries an iterator loads at once.
+	boltIteratorChunk = 1024
+
+	// boltCompactTxSize is the amount of data copied per transaction when
+	// compacting a bolt database.
+	boltCompactTxSize = 64 * 1024 * 1024
+)
+
+// boltOptions are the options bolt databases are opened with.
+var boltOptions = &bolt.Options{
+	Timeout:      time.Second,
+	FreelistType: bolt.FreelistMapType,
+}
+
+// boltBucket is the bucket holding all entries of a bolt database.
+var boltBucket = []byte("yooba")
+
+// boltKey returns the key an entry is stored under. Bolt doesn't support empty
+// keys, so all keys are prefixed with a zero byte, keeping their order.
+func boltKey(key []byte) []byte {
+	return append([]byte{0}, key...)
+}
+
+// errNotFound is returned by BoltDatabase.Get for missing keys.
+var errNotFound = errors.New("not found")
+
+// BoltDatabase is a pure Go database backed by a single memory mapped B+tree
+// file. Compared to LevelDB it trades write throughput for predictable read
+// latency and needs no background compaction.
+//
+// Every transaction fsyncs the file when committed, so single writes are
+// coalesced into shared transactions and bulk writes should use batches.
+type BoltDatabase struct {
+	fn   string       // filename for reporting
+	db   *bolt.DB     // bolt instance, replaced by compaction
+	lock sync.RWMutex // Protects the instance against compaction
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// NewBoltDatabase opens the bolt database in the given directory, creating it if
+// it doesn't exist yet.
+func NewBoltDatabase(file string) (*BoltDatabase, error) {
+	logger := log.New("database", file)
+
+	if err := os.MkdirAll(file, 0700); err != nil {
+		return nil, err
+	}
+	// Drop the leftovers of an interrupted compaction, the database file itself
+	// is only replaced once the copy is complete
+	path := filepath.Join(file, boltFile)
+	os.Remove(path + ".compact")
+
+	db, err := bolt.Open(path, 0600, boltOptions)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	logger.Info("Opened bolt database")
+	return &BoltDatabase{
+		fn:  file,
+		db:  db,
+		log: logger,
+	}, nil
+}
+
+// Path returns the path to the database directory.
+func (db *BoltDatabase) Path() string {
+	return db.fn
+}
+
+// view runs the given function in a read transaction.
+func (db *BoltDatabase) view(fn func(*bolt.Tx) error) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.db.View(fn)
+}
+
+// update runs the given function in a write transaction.
+func (db *BoltDatabase) update(fn func(*bolt.Tx) error) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.db.Update(fn)
+}
+
+// batch runs the given function in a write transaction shared with concurrent
+// callers, saving a file sync each. The function may be run more than once.
+func (db *BoltDatabase) batch(fn func(*bolt.Tx) error) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.db.Batch(fn)
+}
+
+// Put stores the given value. Concurrent puts and deletes are committed
+// together, use a batch to write many entries at once.
+func (db *BoltDatabase) Put(key []byte, value []byte) error {
+	key, value = boltKey(key), common.CopyBytes(value)
+	return db.batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+}
+
+func (db *BoltDatabase) Has(key []byte) (bool, error) {
+	var has bool
+	err := db.view(func(tx *bolt.Tx) error {
+		key := boltKey(key)
+		k, _ := tx.Bucket(boltBucket).Cursor().Seek(key)
+		has = k != nil && bytes.Equal(k, key)
+		return nil
+	})
+	return has, err
+}
+
+// Get returns the given key if it's present. The value is copied out of the
+// memory map, so it stays valid after the read transaction.
+func (db *BoltDatabase) Get(key []byte) ([]byte, error) {
+	var dat []byte
+	err := db.view(func(tx *bolt.Tx) error {
+		key := boltKey(key)
+		k, v := tx.Bucket(boltBucket).Cursor().Seek(key)
+		if k == nil || !bytes.Equal(k, key) {
+			return errNotFound
+		}
+		dat = common.CopyBytes(v)
+		if dat == nil {
+			dat = []byte{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dat, nil
+}
+
+// Delete removes the given key. Like puts, concurrent deletes are committed
+// together.
+func (db *BoltDatabase) Delete(key []byte) error {
+	key = boltKey(key)
+	return db.batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+func (db *BoltDatabase) NewIterator() iterator.Iterator {
+	return db.NewIteratorWithPrefix(nil)
+}
+
+// NewIteratorWithPrefix returns a iterator to iterate over subset of database
+// content with a particular prefix. Entries are loaded in chunks by short read
+// transactions, so that iterating doesn't block writers growing the file. Writes
+// made during the iteration may or may not be visible to it.
+func (db *BoltDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	prefix = boltKey(prefix)
+	return &boltIterator{
+		db:     db,
+		prefix: prefix,
+		limit:  util.BytesPrefix(prefix).Limit,
+	}
+}
+
+// Compact rewrites the whole database into a new file and swaps it in, as bolt
+// reuses the pages freed by deletions for new writes but never shrinks its file
+// in place. The range is ignored. The database is blocked while compacting.
+func (db *BoltDatabase) Compact(start []byte, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	var (
+		path = filepath.Join(db.fn, boltFile)
+		tmp  = path + ".compact"
+	)
+	os.Remove(tmp)
+	dst, err := bolt.Open(tmp, 0600, boltOptions)
+	if err != nil {
+		return err
+	}
+	if err := bolt.Compact(dst, db.db, boltCompactTxSize); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// The copy is complete, replace the database file with it. Renaming is
+	// atomic, so a crash leaves either file in place.
+	if err := db.db.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	renameErr := os.Rename(tmp, path)
+	if renameErr != nil {
+		os.Remove(tmp)
+	}
+	bdb, err := bolt.Open(path, 0600, boltOptions)
+	if err != nil {
+		return err
+	}
+	db.db = bdb
+	return renameErr
+}
+
+// Stat returns the bolt statistics for an empty property or "bolt.stats".
+func (db *BoltDatabase) Stat(property string) (string, error) {
+	if property != "" && property != "bolt.stats" {
+		return "", errUnknownProperty
+	}
+	var stats string
+	err := db.view(func(tx *bolt.Tx) error {
+		bs := tx.Bucket(boltBucket).Stats()
+		ds := db.db.Stats()
+		stats = fmt.Sprintf("Entries:     %d\nDepth:       %d\nFile size:   %v\nLeaf data:   %v\nFree pages:  %d\nRead txs:    %d\n",
+			bs.KeyN, bs.Depth, common.StorageSize(tx.Size()), common.StorageSize(bs.LeafInuse), ds.FreePageN, ds.TxN)
+		return nil
+	})
+	return stats, err
+}
+
+func (db *BoltDatabase) Close() {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if err := db.db.Close(); err == nil {
+		db.log.Info("Database closed")
+	} else {
+		db.log.Error("Failed to close database", "err", err)
+	}
+}
+
+func (db *BoltDatabase) NewBatch() Batch {
+	return &boltBatch{db: db}
+}
+
+type boltWrite struct {
+	key, value []byte
+	delete     bool
+}
+
+type boltBatch struct {
+	db     *BoltDatabase
+	writes []boltWrite
+	size   int
+}
+
+func (b *boltBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, boltWrite{key: boltKey(key), value: common.CopyBytes(value)})
+	b.size += len(value)
+	return nil
+}
+
+func (b *boltBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, boltWrite{key: boltKey(key), delete: true})
+	b.size += 1
+	return nil
+}
+
+// Write applies all changes of the batch in a single transaction.
+func (b *boltBatch) Write() error {
+	return b.db.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, w := range b.writes {
+			var err error
+			if w.delete {
+				err = bucket.Delete(w.key)
+			} else {
+				err = bucket.Put(w.key, w.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *boltBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// Positions of a bolt iterator relative to its entries.
+const (
+	boltIterBefore = iota // Before the first entry, also the initial position
+	boltIterAt            // At the entry of the current chunk at pos
+	boltIterAfter         // After the last entry
+)
+
+// boltIterator iterates over the entries with a given prefix, loading them in
+// chunks. It implements the LevelDB iterator interface.
+type boltIterator struct {
+	db     *BoltDatabase
+	prefix []byte // Prefix of all iterated keys, as stored
+	limit  []byte // First stored key after the prefix range
+
+	keys   [][]byte // Entries of the current chunk, keys as stored
+	values [][]byte
+	pos    int  // Index of the current entry within the chunk
+	more   bool // Whether entries may follow the chunk
+	state  int  // Position relative to the entries
+
+	err      error
+	released bool
+	releaser util.Releaser
+}
+
+// load fills the chunk with the entries starting at the given key, skipping it
+// if exclusive is set, and positions the iterator at the first of them.
+func (it *boltIterator) load(start []byte, exclusive bool) bool {
+	if bytes.Compare(start, it.prefix) < 0 {
+		start, exclusive = it.prefix, false
+	}
+	it.keys, it.values, it.pos, it.more = it.keys[:0], it.values[:0], 0, false
+	it.err = it.db.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		k, v := c.Seek(start)
+		if exclusive && k != nil && bytes.Equal(k, start) {
+			k, v = c.Next()
+		}
+		for ; k != nil && bytes.HasPrefix(k, it.prefix); k, v = c.Next() {
+			if len(it.keys) == boltIteratorChunk {
+				it.more = true
+				break
+			}
+			it.keys = append(it.keys, common.CopyBytes(k))
+			it.values = append(it.values, common.CopyBytes(v))
+		}
+		return nil
+	})
+	return it.settle(len(it.keys) > 0, boltIterAfter)
+}
+
+// loadOne replaces the chunk with a single entry found by the given cursor
+// positioning function, leaving the iterator at the given position if there is
+// no such entry.
+func (it *boltIterator) loadOne(find func(c *bolt.Cursor) ([]byte, []byte), otherwise int) bool {
+	it.keys, it.values, it.pos, it.more = it.keys[:0], it.values[:0], 0, true
+	it.err = it.db.view(func(tx *bolt.Tx) error {
+		if k, v := find(tx.Bucket(boltBucket).Cursor()); k != nil && bytes.HasPrefix(k, it.prefix) {
+			it.keys = append(it.keys, common.CopyBytes(k))
+			it.values = append(it.values, common.CopyBytes(v))
+		}
+		return nil
+	})
+	return it.settle(len(it.keys) > 0, otherwise)
+}
+
+// settle updates the position after loading, reporting whether the iterator is
+// at an entry.
+func (it *boltIterator) settle(found bool, otherwise int) bool {
+	if it.err != nil || !found {
+		it.keys, it.values = it.keys[:0], it.values[:0]
+		it.state = otherwise
+		return false
+	}
+	it.state = boltIterAt
+	return true
+}
+
+func (it *boltIterator) First() bool {
+	if it.released {
+		return false
+	}
+	return it.load(it.prefix, false)
+}
+
+func (it *boltIterator) Last() bool {
+	if it.released {
+		return false
+	}
+	return it.loadOne(func(c *bolt.Cursor) ([]byte, []byte) {
+		if k, _ := c.Seek(it.limit); k == nil {
+			return c.Last()
+		}
+		return c.Prev()
+	}, boltIterBefore)
+}
+
+func (it *boltIterator) Seek(key []byte) bool {
+	if it.released {
+		return false
+	}
+	return it.load(boltKey(key), false)
+}
+
+func (it *boltIterator) Next() bool {
+	if it.released || it.err != nil {
+		return false
+	}
+	switch it.state {
+	case boltIterBefore:
+		return it.First()
+	case boltIterAfter:
+		return false
+	}
+	if it.pos+1 < len(it.keys) {
+		it.pos++
+		return true
+	}
+	if !it.more {
+		it.keys, it.values = it.keys[:0], it.values[:0]
+		it.state = boltIterAfter
+		return false
+	}
+	return it.load(it.keys[it.pos], true)
+}
+
+func (it *boltIterator) Prev() bool {
+	if it.released || it.err != nil {
+		return false
+	}
+	switch it.state {
+	case boltIterBefore:
+		return false
+	case boltIterAfter:
+		return it.Last()
+	}
+	if it.pos > 0 {
+		it.pos--
+		return true
+	}
+	current := it.keys[it.pos]
+	return it.loadOne(func(c *bolt.Cursor) ([]byte, []byte) {
+		if k, _ := c.Seek(current); k == nil {
+			return c.Last()
+		}
+		return c.Prev()
+	}, boltIterBefore)
+}
+
+func (it *boltIterator) Valid() bool {
+	return it.state == boltIterAt && !it.released
+}
+
+func (it *boltIterator) Key() []byte {
+	if !it.Valid() {
+		return nil
+	}
+	return it.keys[it.pos][1:]
+}
+
+func (it *boltIterator) Value() []byte {
+	if !it.Valid() {
+		return nil
+	}
+	return it.values[it.pos]
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+func (it *boltIterator) Release() {
+	if it.released {
+		return
+	}
+	it.released = true
+	it.keys, it.values = nil, nil
+	if it.releaser != nil {
+		it.releaser.Release()
+		it.releaser = nil
+	}
+}
+
+func (it *boltIterator) SetReleaser(releaser util.Releaser) {
+	if it.released {
+		panic(util.ErrReleased)
+	}
+	if it.releaser != nil && releaser != nil {
+		panic(util.ErrHasReleaser)
+	}
+	it.releaser = releaser
+}
@@ -17,16 +17,18 @@
 package yoobadb
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/yooba-team/yooba/common"
 	"github.com/yooba-team/yooba/log"
 	"github.com/yooba-team/yooba/metrics"
 	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/errors"
+	lerrors "github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
@@ -41,6 +43,9 @@ const (
 
 var OpenFileLimit = 64
 
+// errUnknownProperty is returned by Stat for properties a database doesn't know.
+var errUnknownProperty = errors.New("unknown database property")
+
 type LDBDatabase struct {
 	fn string      // filename for reporting
 	db *leveldb.DB // LevelDB instance
@@ -79,7 +84,7 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 		WriteBuffer:            cache / 4 * opt.MiB, // Two of these are used internally
 		Filter:                 filter.NewBloomFilter(10),
 	})
-	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
+	if _, corrupted := err.(*lerrors.ErrCorrupted); corrupted {
 		db, err = leveldb.RecoverFile(file, nil)
 	}
 	// (Re)check for errors and abort if opening of the db failed
@@ -130,6 +135,23 @@ func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
 	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
 }
 
+// Compact flattens the underlying data store for the given key range. A nil
+// start is treated as a key before all keys, a nil limit as a key after all.
+func (db *LDBDatabase) Compact(start []byte, limit []byte) error {
+	return db.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
+// Stat returns a particular LevelDB property, the "leveldb." prefix may be
+// omitted. An empty property returns the compaction statistics.
+func (db *LDBDatabase) Stat(property string) (string, error) {
+	if property == "" {
+		property = "leveldb.stats"
+	} else if !strings.HasPrefix(property, "leveldb.") {
+		property = "leveldb." + property
+	}
+	return db.db.GetProperty(property)
+}
+
 func (db *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	db.quitLock.Lock()
@@ -437,10 +459,54 @@ func (dt *table) Delete(key []byte) error {
 	return dt.db.Delete(append([]byte(dt.prefix), key...))
 }
 
+func (dt *table) NewIterator() iterator.Iterator {
+	return dt.NewIteratorWithPrefix(nil)
+}
+
+func (dt *table) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return &tableIterator{
+		Iterator: dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...)),
+		prefix:   []byte(dt.prefix),
+	}
+}
+
+func (dt *table) Compact(start []byte, limit []byte) error {
+	start = append([]byte(dt.prefix), start...)
+	if limit == nil {
+		limit = util.BytesPrefix([]byte(dt.prefix)).Limit
+	} else {
+		limit = append([]byte(dt.prefix), limit...)
+	}
+	return dt.db.Compact(start, limit)
+}
+
+func (dt *table) Stat(property string) (string, error) {
+	return dt.db.Stat(property)
+}
+
 func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+// tableIterator strips the table prefix from the keys of an iterator over the
+// underlying database.
+type tableIterator struct {
+	iterator.Iterator
+	prefix []byte
+}
+
+func (it *tableIterator) Seek(key []byte) bool {
+	return it.Iterator.Seek(append(common.CopyBytes(it.prefix), key...))
+}
+
+func (it *tableIterator) Key() []byte {
+	key := it.Iterator.Key()
+	if key == nil {
+		return nil
+	}
+	return key[len(it.prefix):]
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
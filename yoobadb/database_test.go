@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"testing"
@@ -44,6 +45,22 @@ func newTestLDB() (*yoobadb.LDBDatabase, func()) {
 	}
 }
 
+func newTestBolt() (*yoobadb.BoltDatabase, func()) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		panic("failed to create test file: " + err.Error())
+	}
+	db, err := yoobadb.NewBoltDatabase(dirname)
+	if err != nil {
+		panic("failed to create test database: " + err.Error())
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dirname)
+	}
+}
+
 var test_values = []string{"", "a", "1251", "\x00123\x00"}
 
 func TestLDB_PutGet(t *testing.T) {
@@ -56,6 +73,12 @@ func TestMemoryDB_PutGet(t *testing.T) {
 	testPutGet(yoobadb.NewMemDatabase(), t)
 }
 
+func TestBolt_PutGet(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+	testPutGet(db, t)
+}
+
 func testPutGet(db yoobadb.Database, t *testing.T) {
 	t.Parallel()
 
@@ -133,6 +156,12 @@ func TestMemoryDB_ParallelPutGet(t *testing.T) {
 	testParallelPutGet(yoobadb.NewMemDatabase(), t)
 }
 
+func TestBolt_ParallelPutGet(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+	testParallelPutGet(db, t)
+}
+
 func testParallelPutGet(db yoobadb.Database, t *testing.T) {
 	const n = 8
 	var pending sync.WaitGroup
@@ -188,3 +217,186 @@ func testParallelPutGet(db yoobadb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_Iterator(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestMemoryDB_Iterator(t *testing.T) {
+	testIterator(yoobadb.NewMemDatabase(), t)
+}
+
+func TestBolt_Iterator(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestTable_Iterator(t *testing.T) {
+	db := yoobadb.NewMemDatabase()
+	db.Put([]byte("other"), []byte("value"))
+	testIterator(yoobadb.NewTable(db, "t-"), t)
+}
+
+// testIterator checks prefix iteration in both directions over more entries than
+// a bolt iterator loads at once.
+func testIterator(db yoobadb.Database, t *testing.T) {
+	const n = 2500
+
+	batch := db.NewBatch()
+	for i := 0; i < n; i++ {
+		batch.Put([]byte(fmt.Sprintf("a%05d", i)), []byte(strconv.Itoa(i)))
+		batch.Put([]byte(fmt.Sprintf("b%05d", i)), []byte(strconv.Itoa(i)))
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch write failed: %v", err)
+	}
+	// Forward over a prefix
+	it := db.NewIteratorWithPrefix([]byte("b"))
+	count := 0
+	for it.Next() {
+		if want := fmt.Sprintf("b%05d", count); string(it.Key()) != want {
+			t.Fatalf("key %d mismatch: have %q, want %q", count, it.Key(), want)
+		}
+		if want := strconv.Itoa(count); string(it.Value()) != want {
+			t.Fatalf("value %d mismatch: have %q, want %q", count, it.Value(), want)
+		}
+		count++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if count != n {
+		t.Fatalf("entry count mismatch: have %d, want %d", count, n)
+	}
+	// Backward from the end of the prefix
+	count = n
+	for ok := it.Last(); ok; ok = it.Prev() {
+		count--
+		if want := fmt.Sprintf("b%05d", count); string(it.Key()) != want {
+			t.Fatalf("reverse key mismatch: have %q, want %q", it.Key(), want)
+		}
+	}
+	if count != 0 {
+		t.Fatalf("reverse iteration stopped at %d", count)
+	}
+	// Seeking within and before the prefix
+	if !it.Seek([]byte("b01234")) || string(it.Key()) != "b01234" {
+		t.Fatalf("seek mismatch: have %q", it.Key())
+	}
+	if !it.Seek([]byte("a")) || string(it.Key()) != "b00000" {
+		t.Fatalf("seek before prefix mismatch: have %q", it.Key())
+	}
+	it.Release()
+
+	// Unprefixed iteration covers everything
+	it = db.NewIterator()
+	count = 0
+	for it.Next() {
+		count++
+	}
+	it.Release()
+	if count < 2*n {
+		t.Fatalf("entry count mismatch: have %d, want at least %d", count, 2*n)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	src := yoobadb.NewMemDatabase()
+	for i := 0; i < 100; i++ {
+		src.Put([]byte(strconv.Itoa(i)), []byte(fmt.Sprintf("v%d", i)))
+	}
+	dst, remove := newTestBolt()
+	defer remove()
+
+	count, err := yoobadb.Migrate(dst, src)
+	if err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	if count != 100 {
+		t.Fatalf("migrated entry count mismatch: have %d, want %d", count, 100)
+	}
+	for i := 0; i < 100; i++ {
+		if data, err := dst.Get([]byte(strconv.Itoa(i))); err != nil || string(data) != fmt.Sprintf("v%d", i) {
+			t.Fatalf("entry %d mismatch: have %q, %v", i, data, err)
+		}
+	}
+}
+
+func TestOpenEngineMismatch(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+	db.Close()
+
+	if engine := yoobadb.DetectEngine(db.Path()); engine != yoobadb.EngineBolt {
+		t.Fatalf("engine mismatch: have %q, want %q", engine, yoobadb.EngineBolt)
+	}
+	if _, err := yoobadb.Open(yoobadb.EngineLevelDB, db.Path(), 0, 0); err == nil {
+		t.Fatalf("opened bolt database as leveldb")
+	}
+	reopened, err := yoobadb.Open("", db.Path(), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen: %v", err)
+	}
+	if _, ok := reopened.(*yoobadb.BoltDatabase); !ok {
+		t.Fatalf("reopened database type mismatch: %T", reopened)
+	}
+	reopened.Close()
+}
+
+// Tests that compacting a bolt database shrinks its file and keeps all entries
+// and the database usable.
+func TestBolt_Compact(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+
+	batch := db.NewBatch()
+	for i := 0; i < 10000; i++ {
+		batch.Put([]byte(fmt.Sprintf("%05d", i)), bytes.Repeat([]byte{byte(i)}, 100))
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	batch.Reset()
+	for i := 10; i < 10000; i++ {
+		batch.Delete([]byte(fmt.Sprintf("%05d", i)))
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	path := filepath.Join(db.Path(), "bolt.db")
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Compact(nil, nil); err != nil {
+		t.Fatalf("failed to compact: %v", err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size() {
+		t.Errorf("file not shrunk: have %d bytes, was %d", after.Size(), before.Size())
+	}
+	it := db.NewIterator()
+	count := 0
+	for it.Next() {
+		if want := bytes.Repeat([]byte{byte(count)}, 100); !bytes.Equal(it.Value(), want) {
+			t.Errorf("entry %d mismatch after compaction", count)
+		}
+		count++
+	}
+	it.Release()
+	if count != 10 {
+		t.Errorf("entry count mismatch: have %d, want 10", count)
+	}
+	if err := db.Put([]byte("new"), []byte("value")); err != nil {
+		t.Fatalf("failed to write after compaction: %v", err)
+	}
+	if data, err := db.Get([]byte("new")); err != nil || string(data) != "value" {
+		t.Errorf("read after compaction mismatch: have %q, %v", data, err)
+	}
+}
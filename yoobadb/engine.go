@@ -0,0 +1,87 @@
+package yoobadb
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/log"
+)
+
+// Supported database engines.
+const (
+	EngineLevelDB = "leveldb"
+	EngineBolt    = "bolt"
+)
+
+// DetectEngine returns the engine of the database in the given directory, or an
+// empty string if there is no database.
+func DetectEngine(file string) string {
+	switch {
+	case common.FileExist(filepath.Join(file, boltFile)):
+		return EngineBolt
+	case common.FileExist(filepath.Join(file, "CURRENT")):
+		return EngineLevelDB
+	}
+	return ""
+}
+
+// Open opens the database in the given directory with the given engine. An empty
+// engine selects the one the database was created with, or LevelDB for a new one.
+// Opening an existing database with a different engine fails, use Migrate to
+// convert it.
+func Open(engine string, file string, cache int, handles int) (Database, error) {
+	existing := DetectEngine(file)
+	if engine == "" {
+		engine = existing
+	} else if existing != "" && existing != engine {
+		return nil, fmt.Errorf("database %s was created with engine %q, not %q", file, existing, engine)
+	}
+	switch engine {
+	case "", EngineLevelDB:
+		return NewLDBDatabase(file, cache, handles)
+	case EngineBolt:
+		return NewBoltDatabase(file)
+	}
+	return nil, fmt.Errorf("unknown database engine %q", engine)
+}
+
+// Migrate copies the entire content of src into dst, returning the number of
+// entries copied.
+func Migrate(dst, src Database) (int, error) {
+	var (
+		count  int
+		size   common.StorageSize
+		start  = time.Now()
+		logged = time.Now()
+		batch  = dst.NewBatch()
+		it     = src.NewIterator()
+	)
+	defer it.Release()
+
+	for it.Next() {
+		batch.Put(it.Key(), it.Value())
+		count++
+		size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		if batch.ValueSize() >= IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return count, err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Migrating database", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return count, err
+	}
+	if err := batch.Write(); err != nil {
+		return count, err
+	}
+	log.Info("Migrated database", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return count, nil
+}
@@ -16,6 +16,8 @@
 
 package yoobadb
 
+import "github.com/syndtr/goleveldb/leveldb/iterator"
+
 // Code using batches should try to add this much data to the batch.
 // The value was determined empirically.
 const IdealBatchSize = 100 * 1024
@@ -30,10 +32,38 @@ type Deleter interface {
 	Delete(key []byte) error
 }
 
+// Iteratee wraps the iteration over the database content in ascending key order,
+// supported by all databases.
+type Iteratee interface {
+	// NewIterator returns an iterator over the entire database content.
+	NewIterator() iterator.Iterator
+
+	// NewIteratorWithPrefix returns an iterator over the subset of database
+	// content with a particular key prefix.
+	NewIteratorWithPrefix(prefix []byte) iterator.Iterator
+}
+
+// Compacter wraps the compaction of the database storage.
+type Compacter interface {
+	// Compact flattens the underlying data store for the given key range. A nil
+	// start is treated as a key before all keys, a nil limit as a key after all.
+	Compact(start []byte, limit []byte) error
+}
+
+// Stater wraps the retrieval of database internals.
+type Stater interface {
+	// Stat returns a particular internal stat of the database. An empty property
+	// returns a human readable summary of the database statistics.
+	Stat(property string) (string, error)
+}
+
 // Database wraps all database operations. All methods are safe for concurrent use.
 type Database interface {
 	Putter
 	Deleter
+	Iteratee
+	Compacter
+	Stater
 	Get(key []byte) ([]byte, error)
 	Has(key []byte) (bool, error)
 	Close()
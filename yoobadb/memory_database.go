@@ -17,9 +17,14 @@
 package yoobadb
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"sync"
 
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
 	"github.com/yooba-team/yooba/common"
 )
 
@@ -80,6 +85,27 @@ func (db *MemDatabase) Keys() [][]byte {
 	return keys
 }
 
+// NewIterator returns an iterator over a snapshot of the entire database content,
+// in ascending key order.
+func (db *MemDatabase) NewIterator() iterator.Iterator {
+	return db.NewIteratorWithPrefix(nil)
+}
+
+// NewIteratorWithPrefix returns an iterator over a snapshot of the entries whose
+// keys start with prefix, in ascending key order.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snap := memdb.New(comparer.DefaultComparer, 0)
+	for key, value := range db.db {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			snap.Put([]byte(key), value)
+		}
+	}
+	return snap.NewIterator(nil)
+}
+
 func (db *MemDatabase) Delete(key []byte) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
@@ -88,6 +114,26 @@ func (db *MemDatabase) Delete(key []byte) error {
 	return nil
 }
 
+// Compact is a noop, there is nothing to compact in memory.
+func (db *MemDatabase) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// Stat returns the number and total size of the entries for an empty property.
+func (db *MemDatabase) Stat(property string) (string, error) {
+	if property != "" {
+		return "", errUnknownProperty
+	}
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var size common.StorageSize
+	for key, value := range db.db {
+		size += common.StorageSize(len(key) + len(value))
+	}
+	return fmt.Sprintf("Entries: %d\nSize:    %v\n", len(db.db), size), nil
+}
+
 func (db *MemDatabase) Close() {}
 
 func (db *MemDatabase) NewBatch() Batch {
@@ -0,0 +1,106 @@
+package yooclient
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/event"
+	"github.com/yooba-team/yooba/internal/ethapi"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/rpc"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// testBackend serves the states of a chain to the dpos API, only implementing
+// the parts of the backend it uses.
+type testBackend struct {
+	ethapi.Backend
+
+	statedb state.Database
+	config  *params.ChainConfig
+	heads   event.Feed
+
+	lock    sync.Mutex
+	headers []*types.Header
+}
+
+// newTestBackend creates a backend with an empty genesis state.
+func newTestBackend(t *testing.T) *testBackend {
+	b := &testBackend{
+		statedb: state.NewDatabase(yoobadb.NewMemDatabase()),
+		config:  &params.ChainConfig{ChainId: big.NewInt(1), Ethash: &params.EthashConfig{Producers: []common.Address{{0x01}}}},
+	}
+	b.headers = []*types.Header{{Number: new(big.Int), Root: b.state(t).IntermediateRoot(true)}}
+	return b
+}
+
+// state returns a copy of the state of the chain head to modify.
+func (b *testBackend) state(t *testing.T) *state.StateDB {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	root := common.Hash{}
+	if len(b.headers) > 0 {
+		root = b.headers[len(b.headers)-1].Root
+	}
+	statedb, err := state.New(root, b.statedb)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	return statedb
+}
+
+// commit appends a block with the given state to the chain and announces it as
+// the new head.
+func (b *testBackend) commit(t *testing.T, statedb *state.StateDB) {
+	root, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	b.lock.Lock()
+	header := &types.Header{Number: big.NewInt(int64(len(b.headers))), Root: root}
+	b.headers = append(b.headers, header)
+	b.lock.Unlock()
+
+	b.heads.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(header)})
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.config }
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		number = rpc.BlockNumber(len(b.headers) - 1)
+	}
+	if int(number) >= len(b.headers) {
+		return nil, nil, nil
+	}
+	header := b.headers[number]
+	statedb, err := state.New(header.Root, b.statedb)
+	return statedb, header, err
+}
+
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.heads.Subscribe(ch)
+}
+
+// dialTestBackend serves the dpos API of the backend to a client.
+func dialTestBackend(t *testing.T, backend *testBackend) (*Client, func()) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("dpos", ethapi.NewPublicDposAPI(backend)); err != nil {
+		t.Fatal(err)
+	}
+	raw := rpc.DialInProc(server)
+	return NewClient(raw), func() {
+		raw.Close()
+		server.Stop()
+	}
+}
@@ -0,0 +1,90 @@
+package yooclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/consensus/dpos"
+)
+
+// ProducerSchedule is the set of producers taking turns to seal blocks starting
+// at a given block.
+type ProducerSchedule struct {
+	Number    *big.Int         // Block the schedule is in effect from
+	Producers []*dpos.Producer // Scheduled producers in order of their slots
+}
+
+type rpcProducerSchedule struct {
+	Number    *hexutil.Big     `json:"number"`
+	Producers []*dpos.Producer `json:"producers"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s ProducerSchedule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&rpcProducerSchedule{Number: (*hexutil.Big)(s.Number), Producers: s.Producers})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *ProducerSchedule) UnmarshalJSON(input []byte) error {
+	var dec rpcProducerSchedule
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Number == nil {
+		return errors.New("missing required field 'number' for ProducerSchedule")
+	}
+	s.Number, s.Producers = (*big.Int)(dec.Number), dec.Producers
+	return nil
+}
+
+// Producers returns all the registered block producers, active or not. The block
+// number can be nil, in which case the producers are taken from the latest known
+// block.
+func (ec *Client) Producers(ctx context.Context, blockNumber *big.Int) ([]*dpos.Producer, error) {
+	var producers []*dpos.Producer
+	err := ec.c.CallContext(ctx, &producers, "dpos_getProducers", toBlockNumArg(blockNumber))
+	return producers, err
+}
+
+// ProducerAt returns the registration of the given block producer. The block
+// number can be nil, in which case the producer is taken from the latest known
+// block.
+func (ec *Client) ProducerAt(ctx context.Context, producer common.Address, blockNumber *big.Int) (*dpos.Producer, error) {
+	var result *dpos.Producer
+	err := ec.c.CallContext(ctx, &result, "dpos_getProducer", producer, toBlockNumArg(blockNumber))
+	if err == nil && result == nil {
+		return nil, yooba.NotFound
+	}
+	return result, err
+}
+
+// ProducerSchedule returns the producer schedule in effect at the given block. The
+// block number can be nil, in which case the schedule of the latest known block is
+// returned.
+func (ec *Client) ProducerSchedule(ctx context.Context, blockNumber *big.Int) (*ProducerSchedule, error) {
+	var schedule *ProducerSchedule
+	err := ec.c.CallContext(ctx, &schedule, "dpos_getProducerSchedule", toBlockNumArg(blockNumber))
+	if err == nil && schedule == nil {
+		return nil, yooba.NotFound
+	}
+	return schedule, err
+}
+
+// VotesOf returns the votes cast by the given account. The block number can be
+// nil, in which case the votes are taken from the latest known block.
+func (ec *Client) VotesOf(ctx context.Context, owner common.Address, blockNumber *big.Int) ([]*dpos.Vote, error) {
+	var votes []*dpos.Vote
+	err := ec.c.CallContext(ctx, &votes, "dpos_getVotes", owner, toBlockNumArg(blockNumber))
+	return votes, err
+}
+
+// SubscribeProducerSchedule subscribes to notifications about changes of the
+// producer schedule on the given channel.
+func (ec *Client) SubscribeProducerSchedule(ctx context.Context, ch chan<- *ProducerSchedule) (yooba.Subscription, error) {
+	return ec.c.Subscribe(ctx, "dpos", ch, "producerSchedule")
+}
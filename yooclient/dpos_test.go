@@ -0,0 +1,144 @@
+package yooclient
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yooba-team/yooba"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/consensus/dpos"
+	"github.com/yooba-team/yooba/params"
+)
+
+var (
+	testVoter     = common.Address{0xff}
+	testCandidate = []common.Address{{0x02}, {0x03}}
+)
+
+// newDposBackend creates a backend whose head registered the test candidates and
+// elected the first one at the end of the first schedule interval.
+func newDposBackend(t *testing.T) *testBackend {
+	backend := newTestBackend(t)
+
+	statedb := backend.state(t)
+	dpos.Register(statedb, testCandidate[0], &dpos.RegisterData{Url: "https://two.example", Location: "SG"})
+	dpos.Register(statedb, testCandidate[1], &dpos.RegisterData{Url: "https://three.example", Location: "DE"})
+
+	statedb.AddBalance(testVoter, new(big.Int).Mul(big.NewInt(10), big.NewInt(params.Ether)))
+	if err := dpos.CastVote(statedb, testVoter, big.NewInt(10), new(big.Int).Mul(big.NewInt(3), big.NewInt(params.Ether)), &dpos.VoteData{Producers: testCandidate[:1]}); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	dpos.UpdateSchedule(statedb, new(big.Int).SetUint64(params.ProducerScheduleInterval))
+	backend.commit(t, statedb)
+
+	return backend
+}
+
+// Tests that producers, schedules and votes are retrieved from the dpos namespace.
+func TestProducers(t *testing.T) {
+	client, closer := dialTestBackend(t, newDposBackend(t))
+	defer closer()
+
+	ctx := context.Background()
+
+	producers, err := client.Producers(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve producers: %v", err)
+	}
+	if len(producers) != 2 {
+		t.Fatalf("producer count mismatch: have %d, want 2", len(producers))
+	}
+	if p := producers[0]; p.Address != testCandidate[0] || !p.IsActive || p.TotalVotesCount != 3 || p.Url != "https://two.example" || p.Location != "SG" {
+		t.Errorf("elected producer mismatch: have %+v", p)
+	}
+	if p := producers[1]; p.Address != testCandidate[1] || p.IsActive || p.TotalVotesCount != 0 {
+		t.Errorf("candidate mismatch: have %+v", p)
+	}
+	if producers, err := client.Producers(ctx, big.NewInt(0)); err != nil || len(producers) != 0 {
+		t.Errorf("genesis producers mismatch: have %v (%v), want none", producers, err)
+	}
+	producer, err := client.ProducerAt(ctx, testCandidate[1], nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve producer: %v", err)
+	}
+	if !reflect.DeepEqual(producer, producers[1]) {
+		t.Errorf("producer mismatch: have %+v, want %+v", producer, producers[1])
+	}
+	if _, err := client.ProducerAt(ctx, common.Address{0x04}, nil); err != yooba.NotFound {
+		t.Errorf("unknown producer error mismatch: have %v, want %v", err, yooba.NotFound)
+	}
+	// The elected schedule takes effect after the election, the chain config one
+	// before
+	schedule, err := client.ProducerSchedule(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve schedule: %v", err)
+	}
+	if schedule.Number.Uint64() != params.ProducerScheduleInterval+1 || !reflect.DeepEqual(schedule.Producers, producers[:1]) {
+		t.Errorf("schedule mismatch: have %+v", schedule)
+	}
+	schedule, err = client.ProducerSchedule(ctx, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to retrieve genesis schedule: %v", err)
+	}
+	if schedule.Number.Sign() != 0 || len(schedule.Producers) != 1 || schedule.Producers[0].Address != (common.Address{0x01}) || !schedule.Producers[0].IsActive {
+		t.Errorf("genesis schedule mismatch: have %+v", schedule)
+	}
+	votes, err := client.VotesOf(ctx, testVoter, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve votes: %v", err)
+	}
+	if len(votes) != 1 || votes[0].Owner != testVoter || votes[0].Staked != 3 || votes[0].VoteStartTime != 10 || !reflect.DeepEqual(votes[0].Producers[0], *producers[0]) {
+		t.Errorf("votes mismatch: have %+v", votes)
+	}
+	if votes, err := client.VotesOf(ctx, testCandidate[0], nil); err != nil || len(votes) != 0 {
+		t.Errorf("missing votes mismatch: have %v (%v), want none", votes, err)
+	}
+}
+
+// Tests that producer schedule changes are delivered to subscribers.
+func TestSubscribeProducerSchedule(t *testing.T) {
+	backend := newDposBackend(t)
+	client, closer := dialTestBackend(t, backend)
+	defer closer()
+
+	ch := make(chan *ProducerSchedule)
+	sub, err := client.SubscribeProducerSchedule(context.Background(), ch)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Notifications are only delivered once the subscription is active, keep
+	// electing alternating producers until the first one arrives
+	timeout := time.After(time.Second)
+	for i := uint64(2); ; i++ {
+		statedb := backend.state(t)
+		if err := dpos.CastVote(statedb, testVoter, big.NewInt(int64(i)), new(big.Int), &dpos.VoteData{Producers: testCandidate[i%2 : i%2+1]}); err != nil {
+			t.Fatalf("failed to vote: %v", err)
+		}
+		dpos.UpdateSchedule(statedb, new(big.Int).SetUint64(i*params.ProducerScheduleInterval))
+		backend.commit(t, statedb)
+
+		select {
+		case have := <-ch:
+			// Heads may have been elected while the notification was in flight,
+			// check the schedule against its own election
+			elected := (have.Number.Uint64() - 1) / params.ProducerScheduleInterval
+			if have.Number.Uint64() != elected*params.ProducerScheduleInterval+1 || elected < 2 || elected > i {
+				t.Fatalf("schedule number mismatch: have %v, elected up to %d", have.Number, i*params.ProducerScheduleInterval)
+			}
+			if len(have.Producers) != 1 || have.Producers[0].Address != testCandidate[elected%2] || !have.Producers[0].IsActive {
+				t.Fatalf("scheduled producers mismatch: have %+v, want %x", have.Producers, testCandidate[elected%2])
+			}
+			return
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-timeout:
+			t.Fatalf("schedule change not delivered")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
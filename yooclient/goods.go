@@ -0,0 +1,135 @@
+package yooclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/yooba-team/yooba"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/core/types"
+)
+
+// Profile is the public profile of a Yooba account.
+type Profile struct {
+	Name        string      `json:"name"`
+	Homepage    string      `json:"homepage"`
+	IsStore     bool        `json:"isStore"`
+	Score       int8        `json:"score"`
+	GoodsRoot   common.Hash `json:"goodsRoot"`
+	HistoryRoot common.Hash `json:"historyRoot"`
+	OrdersRoot  common.Hash `json:"ordersRoot"`
+}
+
+// OrderStatus is a status change of an order, caused by a transaction included
+// in a block.
+type OrderStatus struct {
+	OrderHash   common.Hash    // Hash of the order
+	Creator     common.Address // Account that created the order
+	Status      int            // New status of the order, one of types.OrderSatus*
+	BlockNumber uint64         // Block the status changed in
+	TxHash      common.Hash    // Transaction changing the status
+}
+
+type rpcOrderStatus struct {
+	OrderHash   common.Hash    `json:"orderHash"`
+	Creator     common.Address `json:"creator"`
+	Status      int            `json:"status"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxHash      common.Hash    `json:"transactionHash"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s OrderStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&rpcOrderStatus{
+		OrderHash:   s.OrderHash,
+		Creator:     s.Creator,
+		Status:      s.Status,
+		BlockNumber: hexutil.Uint64(s.BlockNumber),
+		TxHash:      s.TxHash,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *OrderStatus) UnmarshalJSON(input []byte) error {
+	var dec rpcOrderStatus
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	s.OrderHash, s.Creator, s.Status, s.TxHash = dec.OrderHash, dec.Creator, dec.Status, dec.TxHash
+	s.BlockNumber = uint64(dec.BlockNumber)
+	return nil
+}
+
+// OrderStatusQuery selects the order status updates to subscribe to. Updates
+// match if they concern any of the listed orders or any of the listed creators.
+// Empty lists match all updates.
+type OrderStatusQuery struct {
+	Orders   []common.Hash
+	Creators []common.Address
+}
+
+func toOrderStatusArg(q OrderStatusQuery) interface{} {
+	arg := map[string]interface{}{}
+	if len(q.Orders) > 0 {
+		arg["orders"] = q.Orders
+	}
+	if len(q.Creators) > 0 {
+		arg["creators"] = q.Creators
+	}
+	return arg
+}
+
+// AccountProfile returns the profile of the given account. The block number can
+// be nil, in which case the profile is taken from the latest known block.
+func (ec *Client) AccountProfile(ctx context.Context, account common.Address, blockNumber *big.Int) (*Profile, error) {
+	var profile *Profile
+	err := ec.c.CallContext(ctx, &profile, "yoo_getAccountProfile", account, toBlockNumArg(blockNumber))
+	if err == nil && profile == nil {
+		return nil, yooba.NotFound
+	}
+	return profile, err
+}
+
+// GoodsByHash returns the goods with the given hash.
+func (ec *Client) GoodsByHash(ctx context.Context, hash common.Hash) (*types.Goods, error) {
+	var goods *types.Goods
+	err := ec.c.CallContext(ctx, &goods, "yoo_getGoodsByHash", hash)
+	if err == nil && goods == nil {
+		return nil, yooba.NotFound
+	}
+	return goods, err
+}
+
+// GoodsOf returns the goods offered by the given store account. The block number
+// can be nil, in which case the goods are taken from the latest known block.
+func (ec *Client) GoodsOf(ctx context.Context, owner common.Address, blockNumber *big.Int) ([]*types.Goods, error) {
+	var goods []*types.Goods
+	err := ec.c.CallContext(ctx, &goods, "yoo_getGoodsByOwner", owner, toBlockNumArg(blockNumber))
+	return goods, err
+}
+
+// OrderByHash returns the order with the given hash.
+func (ec *Client) OrderByHash(ctx context.Context, hash common.Hash) (*types.Order, error) {
+	var order *types.Order
+	err := ec.c.CallContext(ctx, &order, "yoo_getOrderByHash", hash)
+	if err == nil && order == nil {
+		return nil, yooba.NotFound
+	}
+	return order, err
+}
+
+// OrdersOf returns the orders created by the given account. The block number can
+// be nil, in which case the orders are taken from the latest known block.
+func (ec *Client) OrdersOf(ctx context.Context, creator common.Address, blockNumber *big.Int) ([]*types.Order, error) {
+	var orders []*types.Order
+	err := ec.c.CallContext(ctx, &orders, "yoo_getOrdersByCreator", creator, toBlockNumArg(blockNumber))
+	return orders, err
+}
+
+// SubscribeOrderStatus subscribes to the status updates of the orders matching
+// the given query.
+func (ec *Client) SubscribeOrderStatus(ctx context.Context, q OrderStatusQuery, ch chan<- *OrderStatus) (yooba.Subscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "orderStatus", toOrderStatusArg(q))
+}
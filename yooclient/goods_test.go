@@ -0,0 +1,196 @@
+package yooclient
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yooba-team/yooba"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/rpc"
+)
+
+var (
+	testStore = common.Address{0x0a}
+	testGoods = &types.Goods{
+		GoodsHash:   common.Hash{0x01},
+		Description: "coffee",
+		Owner:       testStore,
+		Price:       types.GoodsPrice{Price: 250},
+		Url:         "https://store.example/coffee",
+		CreateTime:  big.NewInt(100),
+		StartTime:   big.NewInt(200),
+		EndTime:     big.NewInt(300),
+		Extra:       []byte{0x01, 0x02},
+		Nonce:       types.EncodeNonce(7),
+	}
+	testOrder = &types.Order{
+		OrderHash:  common.Hash{0x02},
+		GoodsList:  []types.Goods{*testGoods},
+		Creator:    common.Address{0x0b},
+		CreateTime: big.NewInt(400),
+		Status:     types.OrderSatusCreate,
+		Extra:      []byte{},
+		Nonce:      types.EncodeNonce(8),
+	}
+)
+
+// TestStoreAPI serves the goods, orders and profiles of a single store.
+type TestStoreAPI struct {
+	updates chan *OrderStatus
+}
+
+func (api *TestStoreAPI) GetAccountProfile(account common.Address, number rpc.BlockNumber) *Profile {
+	if account != testStore {
+		return nil
+	}
+	return &Profile{Name: "store", Homepage: "https://store.example", IsStore: true, Score: 5, GoodsRoot: common.Hash{0x03}}
+}
+
+func (api *TestStoreAPI) GetGoodsByHash(hash common.Hash) *types.Goods {
+	if hash != testGoods.GoodsHash {
+		return nil
+	}
+	return testGoods
+}
+
+func (api *TestStoreAPI) GetGoodsByOwner(owner common.Address, number rpc.BlockNumber) []*types.Goods {
+	if owner != testStore {
+		return []*types.Goods{}
+	}
+	return []*types.Goods{testGoods}
+}
+
+func (api *TestStoreAPI) GetOrderByHash(hash common.Hash) *types.Order {
+	if hash != testOrder.OrderHash {
+		return nil
+	}
+	return testOrder
+}
+
+func (api *TestStoreAPI) GetOrdersByCreator(creator common.Address, number rpc.BlockNumber) []*types.Order {
+	if creator != testOrder.Creator {
+		return []*types.Order{}
+	}
+	return []*types.Order{testOrder}
+}
+
+// OrderStatusCriteria is the order status subscription filter on the wire.
+type OrderStatusCriteria struct {
+	Orders   []common.Hash    `json:"orders"`
+	Creators []common.Address `json:"creators"`
+}
+
+func (api *TestStoreAPI) OrderStatus(ctx context.Context, crit OrderStatusCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for {
+			select {
+			case update := <-api.updates:
+				for _, hash := range crit.Orders {
+					if hash == update.OrderHash {
+						notifier.Notify(sub.ID, update)
+					}
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// Tests that profiles, goods and orders are retrieved with their typed structs.
+func TestGoodsAndOrders(t *testing.T) {
+	server := rpc.NewServer()
+	server.RegisterName("yoo", &TestStoreAPI{})
+	raw := rpc.DialInProc(server)
+	defer raw.Close()
+	client := NewClient(raw)
+
+	ctx := context.Background()
+
+	profile, err := client.AccountProfile(ctx, testStore, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve profile: %v", err)
+	}
+	if !profile.IsStore || profile.Name != "store" || profile.Score != 5 || profile.GoodsRoot != (common.Hash{0x03}) {
+		t.Errorf("profile mismatch: have %+v", profile)
+	}
+	if _, err := client.AccountProfile(ctx, common.Address{}, nil); err != yooba.NotFound {
+		t.Errorf("missing profile error mismatch: have %v, want %v", err, yooba.NotFound)
+	}
+	goods, err := client.GoodsByHash(ctx, testGoods.GoodsHash)
+	if err != nil {
+		t.Fatalf("failed to retrieve goods: %v", err)
+	}
+	if !reflect.DeepEqual(goods, testGoods) {
+		t.Errorf("goods mismatch: have %+v, want %+v", goods, testGoods)
+	}
+	if _, err := client.GoodsByHash(ctx, common.Hash{}); err != yooba.NotFound {
+		t.Errorf("missing goods error mismatch: have %v, want %v", err, yooba.NotFound)
+	}
+	if list, err := client.GoodsOf(ctx, testStore, big.NewInt(1)); err != nil || len(list) != 1 {
+		t.Errorf("store goods mismatch: have %v (%v), want 1 item", list, err)
+	}
+	order, err := client.OrderByHash(ctx, testOrder.OrderHash)
+	if err != nil {
+		t.Fatalf("failed to retrieve order: %v", err)
+	}
+	if !reflect.DeepEqual(order, testOrder) {
+		t.Errorf("order mismatch: have %+v, want %+v", order, testOrder)
+	}
+	if list, err := client.OrdersOf(ctx, testStore, nil); err != nil || len(list) != 0 {
+		t.Errorf("foreign orders mismatch: have %v (%v), want none", list, err)
+	}
+}
+
+// Tests that order status updates are filtered and delivered to subscribers.
+func TestSubscribeOrderStatus(t *testing.T) {
+	api := &TestStoreAPI{updates: make(chan *OrderStatus)}
+
+	server := rpc.NewServer()
+	server.RegisterName("yoo", api)
+	raw := rpc.DialInProc(server)
+	defer raw.Close()
+	client := NewClient(raw)
+
+	ch := make(chan *OrderStatus)
+	sub, err := client.SubscribeOrderStatus(context.Background(), OrderStatusQuery{Orders: []common.Hash{testOrder.OrderHash}}, ch)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Notifications are only delivered once the subscription is active, resend
+	// until the first one arrives, interleaved with updates of other orders
+	want := &OrderStatus{OrderHash: testOrder.OrderHash, Creator: testOrder.Creator, Status: types.OrderSatusSuccess, BlockNumber: 12, TxHash: common.Hash{0x0c}}
+	other := &OrderStatus{OrderHash: common.Hash{0xff}, Status: types.OrderSatusFail}
+
+	timeout := time.After(time.Second)
+	for i := 0; ; i++ {
+		update := want
+		if i%2 == 0 {
+			update = other
+		}
+		select {
+		case api.updates <- update:
+		case have := <-ch:
+			if !reflect.DeepEqual(have, want) {
+				t.Fatalf("update mismatch: have %+v, want %+v", have, want)
+			}
+			return
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-timeout:
+			t.Fatalf("order status update not delivered")
+		}
+	}
+}
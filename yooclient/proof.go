@@ -0,0 +1,149 @@
+package yooclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/crypto"
+)
+
+// errProofMismatch is returned if a proof is valid, but proves different values
+// than the ones reported alongside of it.
+var errProofMismatch = errors.New("proven values don't match reported values")
+
+// AccountResult is the Merkle proof of an account and some of its storage slots
+// as returned by GetProof.
+type AccountResult struct {
+	Address      common.Address
+	AccountProof [][]byte
+	Balance      *big.Int
+	CodeHash     common.Hash
+	Nonce        uint64
+	StorageHash  common.Hash
+	IsStore      bool
+	Score        int8
+	GoodsRoot    common.Hash
+	HistoryRoot  common.Hash
+	OrdersRoot   common.Hash
+	StorageProof []StorageResult
+}
+
+// StorageResult is the Merkle proof of a single storage slot.
+type StorageResult struct {
+	Key   common.Hash
+	Value *big.Int
+	Proof [][]byte
+}
+
+type rpcAccountResult struct {
+	Address      common.Address     `json:"address"`
+	AccountProof []hexutil.Bytes    `json:"accountProof"`
+	Balance      *hexutil.Big       `json:"balance"`
+	CodeHash     common.Hash        `json:"codeHash"`
+	Nonce        hexutil.Uint64     `json:"nonce"`
+	StorageHash  common.Hash        `json:"storageHash"`
+	IsStore      bool               `json:"isStore"`
+	Score        int8               `json:"score"`
+	GoodsRoot    common.Hash        `json:"goodsRoot"`
+	HistoryRoot  common.Hash        `json:"historyRoot"`
+	OrdersRoot   common.Hash        `json:"ordersRoot"`
+	StorageProof []rpcStorageResult `json:"storageProof"`
+}
+
+type rpcStorageResult struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetProof returns the Merkle proof of the given account and storage slots. The
+// block number can be nil, in which case the proof is taken from the latest known
+// block. Use Verify to check the proof against a trusted state root.
+func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountResult, error) {
+	if keys == nil {
+		keys = []common.Hash{}
+	}
+	var res rpcAccountResult
+	if err := ec.c.CallContext(ctx, &res, "yoo_getProof", account, keys, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if res.Balance == nil {
+		return nil, errors.New("server returned proof without balance")
+	}
+	result := &AccountResult{
+		Address:      res.Address,
+		AccountProof: fromHexSlice(res.AccountProof),
+		Balance:      (*big.Int)(res.Balance),
+		CodeHash:     res.CodeHash,
+		Nonce:        uint64(res.Nonce),
+		StorageHash:  res.StorageHash,
+		IsStore:      res.IsStore,
+		Score:        res.Score,
+		GoodsRoot:    res.GoodsRoot,
+		HistoryRoot:  res.HistoryRoot,
+		OrdersRoot:   res.OrdersRoot,
+		StorageProof: make([]StorageResult, len(res.StorageProof)),
+	}
+	for i, slot := range res.StorageProof {
+		if slot.Value == nil {
+			return nil, errors.New("server returned storage proof without value")
+		}
+		result.StorageProof[i] = StorageResult{
+			Key:   slot.Key,
+			Value: (*big.Int)(slot.Value),
+			Proof: fromHexSlice(slot.Proof),
+		}
+	}
+	return result, nil
+}
+
+func fromHexSlice(h []hexutil.Bytes) [][]byte {
+	r := make([][]byte, len(h))
+	for i := range h {
+		r[i] = h[i]
+	}
+	return r
+}
+
+// Verify checks the account and storage proofs against the given state root,
+// which needs to come from a trusted header, and makes sure that the proven
+// values match the reported ones. It doesn't need access to a node.
+func (r *AccountResult) Verify(root common.Hash) error {
+	account, err := state.VerifyAccountProof(root, r.Address, r.AccountProof)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		// Proof of absence, the account needs to be reported empty
+		account = &state.Account{
+			Balance:  new(big.Int),
+			Root:     types.EmptyRootHash,
+			CodeHash: crypto.Keccak256(nil),
+		}
+	}
+	if account.Nonce != r.Nonce || account.Balance.Cmp(r.Balance) != 0 ||
+		account.Root != r.StorageHash || !bytes.Equal(account.CodeHash, r.CodeHash[:]) ||
+		account.IsStore != r.IsStore || account.Score != r.Score ||
+		account.Goodsurl != r.GoodsRoot || account.Historyurl != r.HistoryRoot || account.Ordersurl != r.OrdersRoot {
+		return errProofMismatch
+	}
+	for _, slot := range r.StorageProof {
+		// Empty storage tries can't be proven into, all their slots are zero
+		value := common.Hash{}
+		if account.Root != types.EmptyRootHash {
+			if value, err = state.VerifyStorageProof(account.Root, slot.Key, slot.Proof); err != nil {
+				return err
+			}
+		}
+		if value.Big().Cmp(slot.Value) != 0 {
+			return errProofMismatch
+		}
+	}
+	return nil
+}
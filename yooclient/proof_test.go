@@ -0,0 +1,148 @@
+package yooclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/internal/ethapi"
+	"github.com/yooba-team/yooba/rpc"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+// proofBackend serves a single committed state to the node's blockchain API,
+// which only needs the state and header of a block to prove accounts.
+type proofBackend struct {
+	ethapi.Backend
+	db   state.Database
+	root common.Hash
+}
+
+func (b *proofBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	statedb, err := state.New(b.root, b.db)
+	return statedb, &types.Header{Number: big.NewInt(1), Root: b.root}, err
+}
+
+// newProofClient creates a client for the yoo_getProof endpoint of the node,
+// serving a state with a funded contract account holding some storage.
+func newProofClient(t *testing.T, contract common.Address, slots map[common.Hash]common.Hash) (*Client, common.Hash) {
+	db := state.NewDatabase(yoobadb.NewMemDatabase())
+	statedb, _ := state.New(common.Hash{}, db)
+	statedb.AddBalance(contract, big.NewInt(1000))
+	statedb.SetNonce(contract, 3)
+	statedb.SetCode(contract, []byte{0x60, 0x00})
+	for key, value := range slots {
+		statedb.SetState(contract, key, value)
+	}
+	statedb.AddBalance(common.Address{0xff}, big.NewInt(1))
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	server := rpc.NewServer()
+	server.RegisterName("yoo", ethapi.NewPublicBlockChainAPI(&proofBackend{db: db, root: root}))
+	return NewClient(rpc.DialInProc(server)), root
+}
+
+// Tests that the proofs returned by the node verify against the state root and
+// report the account and storage values of the state.
+func TestGetProof(t *testing.T) {
+	var (
+		contract = common.Address{0x01}
+		slots    = map[common.Hash]common.Hash{{0x01}: {0x11}, {0x02}: {0x22}}
+		keys     = []common.Hash{{0x01}, {0x02}, {0x03}}
+	)
+	client, root := newProofClient(t, contract, slots)
+	defer client.c.Close()
+	ctx := context.Background()
+
+	result, err := client.GetProof(ctx, contract, keys, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve proof: %v", err)
+	}
+	if err := result.Verify(root); err != nil {
+		t.Fatalf("failed to verify proof: %v", err)
+	}
+	if result.Address != contract || result.Balance.Int64() != 1000 || result.Nonce != 3 || result.StorageHash == types.EmptyRootHash {
+		t.Errorf("account mismatch: have %+v", result)
+	}
+	if len(result.StorageProof) != len(keys) {
+		t.Fatalf("storage proof count mismatch: have %d, want %d", len(result.StorageProof), len(keys))
+	}
+	for i, slot := range result.StorageProof {
+		if slot.Key != keys[i] || slot.Value.Cmp(slots[keys[i]].Big()) != 0 {
+			t.Errorf("slot %d: mismatch: have %x = %v, want %x = %v", i, slot.Key, slot.Value, keys[i], slots[keys[i]].Big())
+		}
+	}
+	// Missing accounts are proven absent along with all their slots
+	missing, err := client.GetProof(ctx, common.Address{0x02}, keys[:1], nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve proof of absence: %v", err)
+	}
+	if err := missing.Verify(root); err != nil {
+		t.Fatalf("failed to verify proof of absence: %v", err)
+	}
+	if missing.Balance.Sign() != 0 || missing.StorageHash != types.EmptyRootHash || len(missing.StorageProof[0].Proof) != 0 {
+		t.Errorf("missing account mismatch: have %+v", missing)
+	}
+	// The raw result carries the EIP-1186 fields and the Yooba account roots
+	var raw map[string]interface{}
+	if err := client.c.CallContext(ctx, &raw, "yoo_getProof", contract, keys, "latest"); err != nil {
+		t.Fatalf("failed to retrieve raw proof: %v", err)
+	}
+	for _, field := range []string{"address", "accountProof", "balance", "codeHash", "nonce", "storageHash", "storageProof", "isStore", "score", "goodsRoot", "historyRoot", "ordersRoot"} {
+		if _, ok := raw[field]; !ok {
+			t.Errorf("raw proof lacks field %q", field)
+		}
+	}
+}
+
+// Tests that proofs which were tampered with, or which don't match the values
+// reported alongside them, are rejected.
+func TestGetProofTampered(t *testing.T) {
+	var (
+		contract = common.Address{0x01}
+		keys     = []common.Hash{{0x01}}
+	)
+	client, root := newProofClient(t, contract, map[common.Hash]common.Hash{{0x01}: {0x11}})
+	defer client.c.Close()
+
+	tests := map[string]func(r *AccountResult){
+		"balance":      func(r *AccountResult) { r.Balance = big.NewInt(1001) },
+		"nonce":        func(r *AccountResult) { r.Nonce++ },
+		"storage hash": func(r *AccountResult) { r.StorageHash = common.Hash{0x01} },
+		"code hash":    func(r *AccountResult) { r.CodeHash = common.Hash{0x01} },
+		"score":        func(r *AccountResult) { r.Score = 1 },
+		"goods root":   func(r *AccountResult) { r.GoodsRoot = common.Hash{0x01} },
+		"slot value":   func(r *AccountResult) { r.StorageProof[0].Value = big.NewInt(1) },
+		"account node": func(r *AccountResult) {
+			node := r.AccountProof[len(r.AccountProof)-1]
+			node[len(node)-1] ^= 0xff
+		},
+		"slot node": func(r *AccountResult) {
+			node := r.StorageProof[0].Proof[len(r.StorageProof[0].Proof)-1]
+			node[len(node)-1] ^= 0xff
+		},
+	}
+	for name, tamper := range tests {
+		result, err := client.GetProof(context.Background(), contract, keys, nil)
+		if err != nil {
+			t.Fatalf("%s: failed to retrieve proof: %v", name, err)
+		}
+		tamper(result)
+		if err := result.Verify(root); err == nil {
+			t.Errorf("%s: tampered proof accepted", name)
+		}
+	}
+	// Untampered proofs still don't verify against any other root
+	result, _ := client.GetProof(context.Background(), contract, keys, nil)
+	if err := result.Verify(common.Hash{0x01}); err == nil {
+		t.Errorf("proof accepted against a foreign root")
+	}
+}
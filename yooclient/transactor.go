@@ -0,0 +1,678 @@
+package yooclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yooba-team/yooba"
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/hexutil"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/event"
+	"github.com/yooba-team/yooba/log"
+	"github.com/yooba-team/yooba/rlp"
+	"github.com/yooba-team/yooba/rpc"
+)
+
+var (
+	// ErrTransactorClosed is returned for requests to a transactor that was
+	// closed, and by the pending transactions it was still tracking.
+	ErrTransactorClosed = errors.New("transactor closed")
+
+	// ErrNonceConsumed is returned by pending transactions whose nonce was used
+	// up by a transaction not sent through the transactor.
+	ErrNonceConsumed = errors.New("nonce consumed by another transaction")
+)
+
+// Maximum number of times a request is resubmitted with a fresh nonce after the
+// node reported its nonce as already used.
+const maxNonceRetries = 3
+
+// Gas limit of the plain transfers used to fill nonce gaps.
+const fillerGas = 21000
+
+// SignerFn is a signer function callback when the transactor requires a
+// transaction to be signed by the sending account.
+type SignerFn func(types.Signer, common.Address, *types.Transaction) (*types.Transaction, error)
+
+// TransactorConfig are the configuration parameters of a transactor.
+type TransactorConfig struct {
+	Confirmations uint64        // Number of blocks on top of the including one before a transaction is confirmed
+	BatchSize     int           // Maximum number of transactions submitted in a single RPC batch
+	BatchDelay    time.Duration // Time to wait for further transactions before submitting a batch
+	StuckTimeout  time.Duration // Time after which an unmined transaction is resubmitted with a higher price
+	PriceBump     uint64        // Percentage by which the gas price of stuck transactions is raised
+	MaxGasPrice   *big.Int      // Gas price above which stuck transactions aren't bumped (nil = no limit)
+}
+
+// DefaultTransactorConfig contains the default configurations for transactors.
+var DefaultTransactorConfig = TransactorConfig{
+	Confirmations: 6,
+	BatchSize:     100,
+	BatchDelay:    50 * time.Millisecond,
+	StuckTimeout:  time.Minute,
+	PriceBump:     10,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *TransactorConfig) sanitize() TransactorConfig {
+	conf := *config
+	if conf.BatchSize < 1 {
+		log.Warn("Sanitizing invalid transactor batch size", "provided", conf.BatchSize, "updated", DefaultTransactorConfig.BatchSize)
+		conf.BatchSize = DefaultTransactorConfig.BatchSize
+	}
+	if conf.StuckTimeout <= 0 {
+		log.Warn("Sanitizing invalid transactor stuck timeout", "provided", conf.StuckTimeout, "updated", DefaultTransactorConfig.StuckTimeout)
+		conf.StuckTimeout = DefaultTransactorConfig.StuckTimeout
+	}
+	if conf.PriceBump < 1 {
+		log.Warn("Sanitizing invalid transactor price bump", "provided", conf.PriceBump, "updated", DefaultTransactorConfig.PriceBump)
+		conf.PriceBump = DefaultTransactorConfig.PriceBump
+	}
+	return conf
+}
+
+// TxRequest is a transaction to be sent by a transactor, which takes care of
+// its nonce and signature.
+type TxRequest struct {
+	To       *common.Address // Recipient of the transaction (nil = contract creation)
+	Value    *big.Int        // Amount transferred along the transaction (nil = 0)
+	Gas      uint64          // Gas limit of the transaction (0 = estimate)
+	GasPrice *big.Int        // Gas price of the transaction (nil = gas price oracle)
+	Type     uint            // Yooba transaction type
+	Data     []byte          // Input data of the transaction
+}
+
+// Confirmation is the event fired by a transactor when one of its transactions
+// reached the configured confirmation depth.
+type Confirmation struct {
+	Transaction *types.Transaction // Version of the transaction included in the chain
+	Receipt     *types.Receipt     // Receipt of the included transaction
+	BlockNumber uint64             // Number of the block including the transaction
+	BlockHash   common.Hash        // Hash of the block including the transaction
+}
+
+// PendingTransaction is a transaction submitted by a transactor, tracked until
+// it's confirmed.
+type PendingTransaction struct {
+	request *TxRequest // Request the transaction was created from, nil for gap fillers
+	nonce   uint64     // Nonce assigned to the transaction
+
+	txs  []*types.Transaction // All submitted versions of the transaction, the last being the current one
+	sent time.Time            // Time the current version was submitted
+
+	confirmation *Confirmation // Final confirmation once the transaction is deep enough
+	err          error         // Failure if the transaction can't be confirmed any more
+	done         chan struct{} // Closed when either confirmation or failure is set
+
+	lock sync.RWMutex
+}
+
+// Nonce returns the nonce assigned to the transaction.
+func (p *PendingTransaction) Nonce() uint64 {
+	return p.nonce
+}
+
+// Transaction returns the most recently submitted version of the transaction,
+// which changes if the transaction is resubmitted with a higher gas price.
+func (p *PendingTransaction) Transaction() *types.Transaction {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.txs[len(p.txs)-1]
+}
+
+// Hash returns the hash of the most recently submitted version of the transaction.
+func (p *PendingTransaction) Hash() common.Hash {
+	return p.Transaction().Hash()
+}
+
+// Wait blocks until the transaction is confirmed, returning its receipt.
+func (p *PendingTransaction) Wait(ctx context.Context) (*types.Receipt, error) {
+	select {
+	case <-p.done:
+		if p.err != nil {
+			return nil, p.err
+		}
+		return p.confirmation.Receipt, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resubmitted records a new version of the transaction.
+func (p *PendingTransaction) resubmitted(tx *types.Transaction) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.txs = append(p.txs, tx)
+	p.sent = time.Now()
+}
+
+// finish marks the transaction as either confirmed or failed.
+func (p *PendingTransaction) finish(confirmation *Confirmation, err error) {
+	p.confirmation, p.err = confirmation, err
+	close(p.done)
+}
+
+// txRequest is a request waiting to be submitted in a batch.
+type txRequest struct {
+	*TxRequest
+	retries int                        // Number of times the request was resubmitted after nonce failures
+	result  chan<- *PendingTransaction // Channel to deliver the submitted transaction on
+	errc    chan<- error               // Channel to deliver the submission failure on
+}
+
+// Transactor sends transactions from a single account, managing its nonces and
+// batching concurrent submissions. It watches the chain head to resubmit stuck
+// transactions with a higher gas price and to notify confirmations.
+type Transactor struct {
+	client  *Client
+	from    common.Address
+	signer  types.Signer
+	signFn  SignerFn
+	config  TransactorConfig
+	nonce   uint64                // Next nonce to assign, owned by the loop
+	pending []*PendingTransaction // Submitted but unconfirmed transactions, owned by the loop
+
+	heads   chan *types.Header
+	headSub yooba.Subscription
+	queue   chan *txRequest
+	quit    chan struct{}
+	wg      sync.WaitGroup
+
+	confirmFeed event.Feed
+	scope       event.SubscriptionScope
+	closeOnce   sync.Once
+}
+
+// NewTransactor creates a transactor sending transactions from the given account,
+// signing them with signFn for the given chain. It retrieves the pending nonce of
+// the account and subscribes to new chain heads.
+func NewTransactor(ctx context.Context, client *Client, from common.Address, signFn SignerFn, chainID *big.Int, config TransactorConfig) (*Transactor, error) {
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(chan *types.Header, 16)
+	sub, err := client.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transactor{
+		client:  client,
+		from:    from,
+		signer:  types.NewEIP155Signer(chainID),
+		signFn:  signFn,
+		config:  (&config).sanitize(),
+		nonce:   nonce,
+		heads:   heads,
+		headSub: sub,
+		queue:   make(chan *txRequest),
+		quit:    make(chan struct{}),
+	}
+	t.wg.Add(1)
+	go t.loop()
+
+	return t, nil
+}
+
+// Close stops the transactor, failing any transactions still waiting to be
+// submitted or confirmed with ErrTransactorClosed.
+func (t *Transactor) Close() {
+	t.closeOnce.Do(func() {
+		close(t.quit)
+		t.wg.Wait()
+
+		t.headSub.Unsubscribe()
+		t.scope.Close()
+	})
+}
+
+// SubscribeConfirmations registers a subscription for the confirmations of the
+// transactions sent through the transactor.
+func (t *Transactor) SubscribeConfirmations(ch chan<- *Confirmation) event.Subscription {
+	return t.scope.Track(t.confirmFeed.Subscribe(ch))
+}
+
+// Send submits a transaction, batching it with other concurrent requests. It
+// returns once the transaction was accepted or rejected by the node.
+//
+// Note, a cancelled context only stops waiting, the request is submitted anyway
+// if it was already queued.
+func (t *Transactor) Send(ctx context.Context, request *TxRequest) (*PendingTransaction, error) {
+	txs, errs := t.SendBatch(ctx, []*TxRequest{request})
+	return txs[0], errs[0]
+}
+
+// SendBatch submits a list of transactions in the given order, returning the
+// transactions accepted by the node and the errors of the rejected ones.
+func (t *Transactor) SendBatch(ctx context.Context, requests []*TxRequest) ([]*PendingTransaction, []error) {
+	var (
+		txs     = make([]*PendingTransaction, len(requests))
+		errs    = make([]error, len(requests))
+		results = make([]chan *PendingTransaction, len(requests))
+		errcs   = make([]chan error, len(requests))
+	)
+	for i, request := range requests {
+		results[i], errcs[i] = make(chan *PendingTransaction, 1), make(chan error, 1)
+
+		req := &txRequest{TxRequest: request, result: results[i], errc: errcs[i]}
+		select {
+		case t.queue <- req:
+		case <-t.quit:
+			errs[i] = ErrTransactorClosed
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	for i := range requests {
+		if errs[i] != nil {
+			continue
+		}
+		select {
+		case txs[i] = <-results[i]:
+		case errs[i] = <-errcs[i]:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	return txs, errs
+}
+
+// loop is the main event loop of the transactor, batching submissions and
+// processing chain heads.
+func (t *Transactor) loop() {
+	defer t.wg.Done()
+
+	var (
+		batch   []*txRequest
+		timer   = time.NewTimer(0)
+		waiting bool
+	)
+	<-timer.C // Drain the initial tick
+	defer timer.Stop()
+
+	heads, headErr := t.heads, t.headSub.Err()
+	for {
+		select {
+		case req := <-t.queue:
+			batch = append(batch, req)
+			if len(batch) >= t.config.BatchSize {
+				if waiting && !timer.Stop() {
+					<-timer.C
+				}
+				waiting = false
+				batch = t.submit(batch)
+			}
+			if len(batch) > 0 && !waiting {
+				timer.Reset(t.config.BatchDelay)
+				waiting = true
+			}
+
+		case <-timer.C:
+			waiting = false
+			if batch = t.submit(batch); len(batch) > 0 {
+				timer.Reset(t.config.BatchDelay)
+				waiting = true
+			}
+
+		case head := <-heads:
+			t.update(head)
+
+		case err := <-headErr:
+			log.Warn("Transactor head subscription failed", "from", t.from, "err", err)
+			heads, headErr = nil, nil
+
+		case <-t.quit:
+			for _, req := range batch {
+				req.errc <- ErrTransactorClosed
+			}
+			for _, tx := range t.pending {
+				tx.finish(nil, ErrTransactorClosed)
+			}
+			return
+		}
+	}
+}
+
+// submit assigns nonces to a batch of requests and sends them to the node,
+// recovering any nonce gaps caused by rejected transactions. Requests to be
+// retried with a fresh nonce are returned.
+func (t *Transactor) submit(batch []*txRequest) []*txRequest {
+	ctx := context.Background()
+
+	// Fill in the gas limits and prices not specified by the requests
+	if err := t.fillDefaults(ctx, batch); err != nil {
+		for _, req := range batch {
+			req.errc <- err
+		}
+		return nil
+	}
+	// Sign all the requests with consecutive nonces
+	var (
+		reqs []*txRequest
+		txs  []*types.Transaction
+	)
+	for _, req := range batch {
+		tx, err := t.sign(req.TxRequest, t.nonce+uint64(len(txs)), req.GasPrice)
+		if err != nil {
+			req.errc <- err
+			continue
+		}
+		reqs, txs = append(reqs, req), append(txs, tx)
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	errs := t.send(ctx, txs)
+
+	// Track the accepted transactions and sort out the rejected ones
+	var (
+		retry    []*txRequest
+		failed   []*types.Transaction
+		accepted = -1
+		resync   bool
+	)
+	for i, req := range reqs {
+		switch {
+		case errs[i] == nil:
+			tx := &PendingTransaction{request: req.TxRequest, nonce: txs[i].Nonce(), txs: []*types.Transaction{txs[i]}, sent: time.Now(), done: make(chan struct{})}
+			t.pending = append(t.pending, tx)
+			req.result <- tx
+			accepted = i
+
+		case isNonceTooLow(errs[i]):
+			resync = true
+			if req.retries++; req.retries > maxNonceRetries {
+				req.errc <- errs[i]
+			} else {
+				retry = append(retry, req)
+			}
+
+		default:
+			req.errc <- errs[i]
+			failed = append(failed, txs[i])
+		}
+	}
+	// Rejected transactions in front of accepted ones leave nonce gaps which
+	// would stall the latter, fill them with empty transfers
+	next := t.nonce + uint64(accepted+1)
+	for _, tx := range failed {
+		if tx.Nonce() < next {
+			t.fill(ctx, tx.Nonce(), tx.GasPrice())
+		}
+	}
+	t.nonce = next
+
+	// If some nonces were used up by others, continue from the node's view
+	if resync {
+		nonce, err := t.client.PendingNonceAt(ctx, t.from)
+		if err != nil {
+			log.Warn("Transactor failed to resync nonce", "from", t.from, "err", err)
+		} else if nonce > t.nonce {
+			t.nonce = nonce
+		}
+	}
+	return retry
+}
+
+// fillDefaults estimates the gas limits and retrieves the gas price for the
+// requests not specifying them.
+func (t *Transactor) fillDefaults(ctx context.Context, batch []*txRequest) error {
+	var (
+		estimates []rpc.BatchElem
+		reqs      []*txRequest
+		price     *big.Int
+	)
+	for _, req := range batch {
+		if req.GasPrice == nil && price == nil {
+			var err error
+			if price, err = t.client.SuggestGasPrice(ctx); err != nil {
+				return err
+			}
+		}
+		if req.Gas == 0 {
+			msg := yooba.CallMsg{From: t.from, To: req.To, Value: req.Value, GasPrice: req.GasPrice, Data: req.Data}
+			estimates = append(estimates, rpc.BatchElem{Method: "yoo_estimateGas", Args: []interface{}{toCallArg(msg)}, Result: new(hexutil.Uint64)})
+			reqs = append(reqs, req)
+		}
+	}
+	if len(estimates) > 0 {
+		if err := t.client.c.BatchCallContext(ctx, estimates); err != nil {
+			return err
+		}
+	}
+	// Fill the defaults into copies to leave the caller's requests intact
+	for i, req := range batch {
+		if req.GasPrice == nil {
+			cpy := *req.TxRequest
+			cpy.GasPrice = price
+			batch[i].TxRequest = &cpy
+		}
+	}
+	for i, req := range reqs {
+		if estimates[i].Error != nil {
+			return estimates[i].Error
+		}
+		cpy := *req.TxRequest
+		cpy.Gas = uint64(*estimates[i].Result.(*hexutil.Uint64))
+		req.TxRequest = &cpy
+	}
+	return nil
+}
+
+// sign creates the transaction of a request with the given nonce and gas price.
+func (t *Transactor) sign(req *TxRequest, nonce uint64, price *big.Int) (*types.Transaction, error) {
+	value := req.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	var tx *types.Transaction
+	if req.To == nil {
+		tx = types.NewContractCreation(nonce, value, req.Gas, price, req.Data)
+	} else {
+		tx = types.NewTransaction(nonce, *req.To, value, req.Gas, price, req.Type, req.Data)
+	}
+	return t.signFn(t.signer, t.from, tx)
+}
+
+// send submits a list of signed transactions in a single batch, returning the
+// rejection reason of each. Transactions already known by the node count as
+// accepted.
+func (t *Transactor) send(ctx context.Context, txs []*types.Transaction) []error {
+	var (
+		elems = make([]rpc.BatchElem, len(txs))
+		errs  = make([]error, len(txs))
+	)
+	for i, tx := range txs {
+		data, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		elems[i] = rpc.BatchElem{Method: "yoo_sendRawTransaction", Args: []interface{}{common.ToHex(data)}, Result: new(common.Hash)}
+	}
+	if err := t.client.c.BatchCallContext(ctx, elems); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	for i, elem := range elems {
+		if errs[i] == nil && elem.Error != nil && !strings.HasPrefix(elem.Error.Error(), "known transaction") {
+			errs[i] = elem.Error
+		}
+	}
+	return errs
+}
+
+// fill sends an empty transfer to the transactor's own account to consume the
+// given nonce. It's tracked like any other transaction, just not notified.
+func (t *Transactor) fill(ctx context.Context, nonce uint64, price *big.Int) {
+	tx, err := t.signFn(t.signer, t.from, types.NewTransaction(nonce, t.from, new(big.Int), fillerGas, price, types.TxTypeTransfer, nil))
+	if err == nil {
+		err = t.send(ctx, []*types.Transaction{tx})[0]
+	}
+	if err != nil {
+		log.Warn("Transactor failed to fill nonce gap", "from", t.from, "nonce", nonce, "err", err)
+		return
+	}
+	log.Debug("Transactor filled nonce gap", "from", t.from, "nonce", nonce, "hash", tx.Hash())
+	t.pending = append(t.pending, &PendingTransaction{nonce: nonce, txs: []*types.Transaction{tx}, sent: time.Now(), done: make(chan struct{})})
+}
+
+// rpcInclusion contains the inclusion details of a receipt, which aren't part
+// of types.Receipt.
+type rpcInclusion struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+}
+
+// update checks the tracked transactions against a new chain head, confirming
+// the ones included deep enough and resubmitting the stuck ones.
+func (t *Transactor) update(head *types.Header) {
+	if len(t.pending) == 0 {
+		return
+	}
+	ctx := context.Background()
+
+	// Retrieve the receipts of all versions of the tracked transactions, along
+	// with the account nonce to detect transactions that can't be mined any more
+	var (
+		elems    []rpc.BatchElem
+		owners   []*PendingTransaction
+		versions []*types.Transaction
+		nonce    hexutil.Uint64
+	)
+	for _, tx := range t.pending {
+		tx.lock.RLock()
+		for _, version := range tx.txs {
+			elems = append(elems, rpc.BatchElem{Method: "yoo_getTransactionReceipt", Args: []interface{}{version.Hash()}, Result: new(json.RawMessage)})
+			owners, versions = append(owners, tx), append(versions, version)
+		}
+		tx.lock.RUnlock()
+	}
+	elems = append(elems, rpc.BatchElem{Method: "yoo_getTransactionCount", Args: []interface{}{t.from, "latest"}, Result: &nonce})
+	if err := t.client.c.BatchCallContext(ctx, elems); err != nil {
+		log.Warn("Transactor failed to retrieve receipts", "from", t.from, "err", err)
+		return
+	}
+	if err := elems[len(elems)-1].Error; err != nil {
+		log.Warn("Transactor failed to retrieve nonce", "from", t.from, "err", err)
+		return
+	}
+	// Find the inclusion of each transaction, dropped ones being reset by reorgs
+	inclusions := make(map[*PendingTransaction]*Confirmation)
+	for i, owner := range owners {
+		raw := *elems[i].Result.(*json.RawMessage)
+		if elems[i].Error != nil || len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		var (
+			receipt   = new(types.Receipt)
+			inclusion rpcInclusion
+		)
+		if err := json.Unmarshal(raw, receipt); err != nil {
+			log.Warn("Transactor received invalid receipt", "hash", versions[i].Hash(), "err", err)
+			continue
+		}
+		if err := json.Unmarshal(raw, &inclusion); err != nil {
+			log.Warn("Transactor received invalid receipt", "hash", versions[i].Hash(), "err", err)
+			continue
+		}
+		inclusions[owner] = &Confirmation{
+			Transaction: versions[i],
+			Receipt:     receipt,
+			BlockNumber: uint64(inclusion.BlockNumber),
+			BlockHash:   inclusion.BlockHash,
+		}
+	}
+	// Confirm, fail or resubmit each transaction as needed
+	var (
+		pending []*PendingTransaction
+		stuck   []*PendingTransaction
+	)
+	for _, tx := range t.pending {
+		inclusion := inclusions[tx]
+		switch {
+		case inclusion != nil && head.Number.Uint64() >= inclusion.BlockNumber+t.config.Confirmations:
+			tx.finish(inclusion, nil)
+			if tx.request != nil {
+				t.confirmFeed.Send(inclusion)
+			}
+
+		case inclusion == nil && tx.nonce < uint64(nonce):
+			log.Warn("Transactor nonce consumed by foreign transaction", "from", t.from, "nonce", tx.nonce)
+			tx.finish(nil, ErrNonceConsumed)
+
+		default:
+			pending = append(pending, tx)
+			if inclusion == nil && time.Since(tx.sent) >= t.config.StuckTimeout {
+				stuck = append(stuck, tx)
+			}
+		}
+	}
+	t.pending = pending
+	t.bump(ctx, stuck)
+}
+
+// bump resubmits stuck transactions with their gas prices raised.
+func (t *Transactor) bump(ctx context.Context, stuck []*PendingTransaction) {
+	var (
+		owners []*PendingTransaction
+		txs    []*types.Transaction
+	)
+	for _, tx := range stuck {
+		current := tx.Transaction()
+
+		price := current.GasPrice()
+		price.Mul(price, new(big.Int).SetUint64(100+t.config.PriceBump))
+		price.Add(price, big.NewInt(99)) // Round up to always increase the price
+		price.Div(price, big.NewInt(100))
+
+		if t.config.MaxGasPrice != nil && price.Cmp(t.config.MaxGasPrice) > 0 {
+			if current.GasPrice().Cmp(t.config.MaxGasPrice) >= 0 {
+				continue
+			}
+			price.Set(t.config.MaxGasPrice)
+		}
+		var (
+			bumped *types.Transaction
+			err    error
+		)
+		if tx.request == nil {
+			bumped, err = t.signFn(t.signer, t.from, types.NewTransaction(tx.nonce, t.from, new(big.Int), fillerGas, price, types.TxTypeTransfer, nil))
+		} else {
+			bumped, err = t.sign(&TxRequest{To: current.To(), Value: current.Value(), Gas: current.Gas(), Type: current.Type(), Data: current.Data()}, tx.nonce, price)
+		}
+		if err != nil {
+			log.Warn("Transactor failed to sign bumped transaction", "from", t.from, "nonce", tx.nonce, "err", err)
+			continue
+		}
+		owners, txs = append(owners, tx), append(txs, bumped)
+	}
+	if len(txs) == 0 {
+		return
+	}
+	for i, err := range t.send(ctx, txs) {
+		if err != nil {
+			log.Warn("Transactor failed to resubmit stuck transaction", "from", t.from, "nonce", owners[i].nonce, "err", err)
+			continue
+		}
+		log.Debug("Transactor resubmitted stuck transaction", "from", t.from, "nonce", owners[i].nonce, "price", txs[i].GasPrice())
+		owners[i].resubmitted(txs[i])
+	}
+}
+
+// isNonceTooLow reports whether the node rejected a transaction because its
+// nonce was already used.
+func isNonceTooLow(err error) bool {
+	return strings.Contains(err.Error(), "nonce too low")
+}
@@ -0,0 +1,378 @@
+package yooclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yooba-team/yooba/common"
+	"github.com/yooba-team/yooba/common/math"
+	"github.com/yooba-team/yooba/core"
+	"github.com/yooba-team/yooba/core/rawdb"
+	"github.com/yooba-team/yooba/core/state"
+	"github.com/yooba-team/yooba/core/types"
+	"github.com/yooba-team/yooba/core/vm"
+	"github.com/yooba-team/yooba/crypto"
+	"github.com/yooba-team/yooba/internal/ethapi"
+	"github.com/yooba-team/yooba/params"
+	"github.com/yooba-team/yooba/rpc"
+	"github.com/yooba-team/yooba/yoobadb"
+)
+
+var (
+	transactorKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	transactorAddr   = crypto.PubkeyToAddress(transactorKey.PublicKey)
+	transactorChain  = big.NewInt(1)
+)
+
+// testChain is a minimal node for a single account, keeping a pool of pending
+// transactions and mining them on request. It implements the parts of the API
+// backend used to serve the transactor.
+type testChain struct {
+	ethapi.Backend
+
+	db       *yoobadb.MemDatabase
+	statedb  state.Database
+	nonce    uint64                            // Nonce of the account in the latest block
+	pool     map[uint64]*types.Transaction     // Pending transactions by nonce
+	receipts map[common.Hash]types.Receipts    // Receipts of mined blocks by hash
+	head     uint64                            // Number of the latest block
+	reject   func(tx *types.Transaction) error // Extra validation of submitted transactions
+	hold     func(tx *types.Transaction) bool  // Whether to keep a pooled transaction from being mined
+
+	subs map[rpc.ID]*rpc.Notifier
+	lock sync.Mutex
+}
+
+func newTestChain() *testChain {
+	db := yoobadb.NewMemDatabase()
+	return &testChain{
+		db:       db,
+		statedb:  state.NewDatabase(db),
+		pool:     make(map[uint64]*types.Transaction),
+		receipts: make(map[common.Hash]types.Receipts),
+		subs:     make(map[rpc.ID]*rpc.Notifier),
+	}
+}
+
+// pendingNonce returns the next nonce after the pooled transactions.
+//
+// Note, pendingNonce assumes the lock is held!
+func (c *testChain) pendingNonce() uint64 {
+	nonce := c.nonce
+	for c.pool[nonce] != nil {
+		nonce++
+	}
+	return nonce
+}
+
+// mine includes the executable pooled transactions into a new block and
+// announces it to the head subscribers.
+func (c *testChain) mine() {
+	c.lock.Lock()
+	c.head++
+
+	var (
+		txs      types.Transactions
+		receipts types.Receipts
+	)
+	for tx := c.pool[c.nonce]; tx != nil; tx = c.pool[c.nonce] {
+		if c.hold != nil && c.hold(tx) {
+			break
+		}
+		txs = append(txs, tx)
+		receipts = append(receipts, &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash(), GasUsed: tx.Gas(), Logs: []*types.Log{}})
+
+		delete(c.pool, c.nonce)
+		c.nonce++
+	}
+	block := types.NewBlock(&types.Header{Number: new(big.Int).SetUint64(c.head), Time: big.NewInt(0), Extra: []byte{}}, txs, receipts)
+	rawdb.WriteBody(c.db, block.Hash(), block.NumberU64(), block.Body())
+	rawdb.WriteTxLookupEntries(c.db, block)
+	c.receipts[block.Hash()] = receipts
+
+	subs := make(map[rpc.ID]*rpc.Notifier, len(c.subs))
+	for id, notifier := range c.subs {
+		subs[id] = notifier
+	}
+	c.lock.Unlock()
+
+	for id, notifier := range subs {
+		notifier.Notify(id, block.Header())
+	}
+}
+
+func (c *testChain) ChainDb() yoobadb.Database        { return c.db }
+func (c *testChain) ChainConfig() *params.ChainConfig { return params.TestChainConfig }
+
+func (c *testChain) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(100), nil
+}
+
+// header returns the header of the latest or the pending block.
+//
+// Note, header assumes the lock is held!
+func (c *testChain) header(number rpc.BlockNumber) *types.Header {
+	head := c.head
+	if number == rpc.PendingBlockNumber {
+		head++
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(head), Time: big.NewInt(0), GasLimit: params.GenesisGasLimit}
+}
+
+func (c *testChain) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return types.NewBlockWithHeader(c.header(number)), nil
+}
+
+func (c *testChain) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	statedb, err := state.New(common.Hash{}, c.statedb)
+	if err != nil {
+		return nil, nil, err
+	}
+	if number == rpc.PendingBlockNumber {
+		statedb.SetNonce(transactorAddr, c.pendingNonce())
+	} else {
+		statedb.SetNonce(transactorAddr, c.nonce)
+	}
+	return statedb, c.header(number), nil
+}
+
+func (c *testChain) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+	state.SetBalance(msg.From(), math.MaxBig256)
+	context := core.NewEVMContext(msg, header, nil, &common.Address{})
+	return vm.NewEVM(context, state, c.ChainConfig(), vmCfg), func() error { return nil }, nil
+}
+
+func (c *testChain) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.receipts[hash], nil
+}
+
+func (c *testChain) SendTx(ctx context.Context, tx *types.Transaction) error {
+	if from, err := types.Sender(types.NewEIP155Signer(transactorChain), tx); err != nil || from != transactorAddr {
+		return errors.New("invalid sender")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if tx.Nonce() < c.nonce {
+		return errors.New("nonce too low")
+	}
+	if c.reject != nil {
+		if err := c.reject(tx); err != nil {
+			return err
+		}
+	}
+	if old := c.pool[tx.Nonce()]; old != nil {
+		if old.Hash() == tx.Hash() {
+			return errors.New("known transaction: " + tx.Hash().Hex())
+		}
+		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 {
+			return errors.New("replacement transaction underpriced")
+		}
+	}
+	c.pool[tx.Nonce()] = tx
+	return nil
+}
+
+// TestYooAPI serves the head subscriptions of a test chain.
+type TestYooAPI struct{ chain *testChain }
+
+func (api *TestYooAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+
+	api.chain.lock.Lock()
+	api.chain.subs[sub.ID] = notifier
+	api.chain.lock.Unlock()
+
+	return sub, nil
+}
+
+// newTestTransactor serves the yoo namespace of a test chain to a transactor.
+func newTestTransactor(t *testing.T, chain *testChain, config TransactorConfig) *Transactor {
+	server := rpc.NewServer()
+	server.RegisterName("yoo", ethapi.NewPublicEthereumAPI(chain))
+	server.RegisterName("yoo", ethapi.NewPublicBlockChainAPI(chain))
+	server.RegisterName("yoo", ethapi.NewPublicTransactionPoolAPI(chain, new(ethapi.AddrLocker)))
+	server.RegisterName("yoo", &TestYooAPI{chain})
+
+	signFn := func(signer types.Signer, from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		return types.SignTx(tx, signer, transactorKey)
+	}
+	transactor, err := NewTransactor(context.Background(), NewClient(rpc.DialInProc(server)), transactorAddr, signFn, transactorChain, config)
+	if err != nil {
+		t.Fatalf("failed to create transactor: %v", err)
+	}
+	return transactor
+}
+
+// waitMined keeps mining blocks until the transaction is confirmed.
+func waitMined(t *testing.T, chain *testChain, tx *PendingTransaction) *types.Receipt {
+	for i := 0; i < 100; i++ {
+		chain.mine()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		receipt, err := tx.Wait(ctx)
+		cancel()
+		if err == nil {
+			return receipt
+		}
+		if err != context.DeadlineExceeded {
+			t.Fatalf("transaction %d failed: %v", tx.Nonce(), err)
+		}
+	}
+	t.Fatalf("transaction %d not confirmed", tx.Nonce())
+	return nil
+}
+
+func transfer(value int64) *TxRequest {
+	return &TxRequest{To: &common.Address{0x01}, Value: big.NewInt(value), Type: types.TxTypeTransfer}
+}
+
+// Tests that transactions are submitted with consecutive nonces and notified
+// once they are buried deep enough.
+func TestTransactorConfirmations(t *testing.T) {
+	chain := newTestChain()
+	chain.nonce = 5
+
+	config := DefaultTransactorConfig
+	config.Confirmations = 2
+	transactor := newTestTransactor(t, chain, config)
+	defer transactor.Close()
+
+	confirms := make(chan *Confirmation, 10)
+	sub := transactor.SubscribeConfirmations(confirms)
+	defer sub.Unsubscribe()
+
+	txs, errs := transactor.SendBatch(context.Background(), []*TxRequest{transfer(1), transfer(2), transfer(3)})
+	for i, tx := range txs {
+		if errs[i] != nil {
+			t.Fatalf("transaction %d rejected: %v", i, errs[i])
+		}
+		if tx.Nonce() != uint64(5+i) {
+			t.Errorf("transaction %d: nonce mismatch: have %d, want %d", i, tx.Nonce(), 5+i)
+		}
+		if tx.Transaction().Gas() != params.TxGas || tx.Transaction().GasPrice().Cmp(big.NewInt(100)) != 0 {
+			t.Errorf("transaction %d: defaults not filled: gas %d, price %v", i, tx.Transaction().Gas(), tx.Transaction().GasPrice())
+		}
+	}
+	receipt := waitMined(t, chain, txs[2])
+	if receipt.TxHash != txs[2].Hash() {
+		t.Errorf("receipt mismatch: have %x, want %x", receipt.TxHash, txs[2].Hash())
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case confirm := <-confirms:
+			chain.lock.Lock()
+			head := chain.head
+			chain.lock.Unlock()
+			if head < confirm.BlockNumber+2 {
+				t.Errorf("confirmation too early: head %d, included %d", head, confirm.BlockNumber)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("confirmation %d not notified", i)
+		}
+	}
+}
+
+// Tests that rejected transactions don't leave nonce gaps behind.
+func TestTransactorNonceGap(t *testing.T) {
+	chain := newTestChain()
+	chain.reject = func(tx *types.Transaction) error {
+		if tx.Value().Int64() == 666 {
+			return errors.New("insufficient funds for gas * price + value")
+		}
+		return nil
+	}
+	transactor := newTestTransactor(t, chain, DefaultTransactorConfig)
+	defer transactor.Close()
+
+	txs, errs := transactor.SendBatch(context.Background(), []*TxRequest{transfer(1), transfer(666), transfer(2), transfer(666)})
+	if errs[0] != nil || errs[2] != nil {
+		t.Fatalf("valid transactions rejected: %v", errs)
+	}
+	if errs[1] == nil || errs[3] == nil {
+		t.Fatalf("invalid transactions accepted")
+	}
+	// The trailing nonce is reused, the gap left by the middle one filled
+	tx, err := transactor.Send(context.Background(), transfer(3))
+	if err != nil {
+		t.Fatalf("transaction rejected: %v", err)
+	}
+	if tx.Nonce() != 3 {
+		t.Fatalf("nonce mismatch: have %d, want 3", tx.Nonce())
+	}
+	chain.lock.Lock()
+	filler := chain.pool[1]
+	chain.lock.Unlock()
+	if filler == nil || *filler.To() != transactorAddr || filler.Value().Sign() != 0 {
+		t.Fatalf("nonce gap not filled: %v", filler)
+	}
+	waitMined(t, chain, tx)
+	if _, err := txs[2].Wait(context.Background()); err != nil {
+		t.Fatalf("transaction after gap not mined: %v", err)
+	}
+}
+
+// Tests that nonces used up behind the transactor's back are skipped.
+func TestTransactorNonceResync(t *testing.T) {
+	chain := newTestChain()
+	transactor := newTestTransactor(t, chain, DefaultTransactorConfig)
+	defer transactor.Close()
+
+	// Another sender uses the same account
+	chain.lock.Lock()
+	chain.nonce = 3
+	chain.lock.Unlock()
+
+	tx, err := transactor.Send(context.Background(), transfer(1))
+	if err != nil {
+		t.Fatalf("transaction rejected: %v", err)
+	}
+	if tx.Nonce() != 3 {
+		t.Fatalf("nonce mismatch: have %d, want 3", tx.Nonce())
+	}
+	waitMined(t, chain, tx)
+}
+
+// Tests that stuck transactions are resubmitted with a higher gas price.
+func TestTransactorPriceBump(t *testing.T) {
+	chain := newTestChain()
+
+	config := DefaultTransactorConfig
+	config.Confirmations = 0
+	config.StuckTimeout = time.Millisecond
+	transactor := newTestTransactor(t, chain, config)
+	defer transactor.Close()
+
+	// Hold back the transaction from mining until it was bumped twice
+	chain.hold = func(tx *types.Transaction) bool {
+		return tx.GasPrice().Cmp(big.NewInt(121)) < 0
+	}
+	tx, err := transactor.Send(context.Background(), transfer(1))
+	if err != nil {
+		t.Fatalf("transaction rejected: %v", err)
+	}
+	receipt := waitMined(t, chain, tx)
+	if price := tx.Transaction().GasPrice(); price.Cmp(big.NewInt(121)) != 0 {
+		t.Errorf("bumped price mismatch: have %v, want 121", price)
+	}
+	if receipt.TxHash != tx.Hash() {
+		t.Errorf("receipt of wrong version: have %x, want %x", receipt.TxHash, tx.Hash())
+	}
+}